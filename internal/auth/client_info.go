@@ -0,0 +1,23 @@
+package auth
+
+import "context"
+
+// ClientInfo identifica desde dónde llega una petición, para la auditoría
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+type clientInfoKey struct{}
+
+// WithClientInfo guarda en el contexto la IP y el User-Agent de la petición
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+// ClientInfoFromContext devuelve la IP y el User-Agent de la petición; fuera de una petición HTTP, como en
+// los workers, llegan vacíos
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info
+}
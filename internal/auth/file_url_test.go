@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileURLSigner_Verify(t *testing.T) {
+	signer := NewFileURLSigner("file-secret")
+	now := time.Unix(1767225600, 0)
+	query, err := url.ParseQuery(signer.SignedQuery("/uploads/user123/file_1.pdf", now.Add(15*time.Minute)))
+	require.NoError(t, err)
+	expires, signature := query.Get("expires"), query.Get("signature")
+
+	assert.NoError(t, signer.Verify("/uploads/user123/file_1.pdf", expires, signature, now))
+
+	// Expired tokens are rejected even with a valid signature
+	assert.ErrorIs(t, signer.Verify("/uploads/user123/file_1.pdf", expires, signature, now.Add(16*time.Minute)), ErrFileURLExpired)
+
+	// The signature is bound to the file and the expiry
+	assert.ErrorIs(t, signer.Verify("/uploads/user123/file_2.pdf", expires, signature, now), ErrInvalidFileURLSignature)
+	assert.ErrorIs(t, signer.Verify("/uploads/user123/file_1.pdf", "1767229200", signature, now), ErrInvalidFileURLSignature)
+	assert.ErrorIs(t, signer.Verify("/uploads/user123/file_1.pdf", expires, "", now), ErrInvalidFileURLSignature)
+
+	// Signed with a different secret, or no secret configured
+	assert.ErrorIs(t, NewFileURLSigner("other-secret").Verify("/uploads/user123/file_1.pdf", expires, signature, now), ErrInvalidFileURLSignature)
+	assert.ErrorIs(t, NewFileURLSigner("").Verify("/uploads/user123/file_1.pdf", expires, signature, now), ErrInvalidFileURLSignature)
+}
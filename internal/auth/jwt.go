@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -8,36 +9,120 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Tipos de token: el de acceso autentica las peticiones y el de refresco solo sirve para pedir otro de acceso
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// WebSocketTokenProtocol es el subprotocolo con el que un navegador, que no puede enviar la cabecera
+// Authorization al abrir un WebSocket, pasa el token: Sec-WebSocket-Protocol: bearer, {token}
+const WebSocketTokenProtocol = "bearer"
+
+const (
+	defaultAccessTokenExpiry  = 24 * time.Hour
+	defaultRefreshTokenExpiry = 30 * 24 * time.Hour
+)
+
+var (
+	// ErrInvalidToken indica un token mal firmado, caducado o ilegible
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrInvalidTokenType indica un token válido usado donde se espera el otro tipo
+	ErrInvalidTokenType = errors.New("invalid token type")
+	// ErrTokenRevoked indica un token de refresco invalidado, p. ej. al cerrar sesión
+	ErrTokenRevoked = errors.New("token revoked")
+	// ErrRevocationUnavailable indica que no hay almacén de revocaciones (p. ej. sin Redis)
+	ErrRevocationUnavailable = errors.New("token revocation not available")
 )
 
 type JWTManager struct {
-	secretKey string
-	issuer    string
+	secretKey     string
+	issuer        string
+	accessExpiry  time.Duration
+	refreshExpiry time.Duration
+	revocations   TokenRevocationStore
 }
 
 type Claims struct {
-	UserID string   `json:"user_id"`
-	Email  string   `json:"email"`
-	Roles  []string `json:"roles"`
+	UserID    string   `json:"user_id"`
+	Email     string   `json:"email"`
+	Roles     []string `json:"roles"`
+	TokenType string   `json:"token_type,omitempty"` // Los tokens anteriores a los de refresco no lo traen y se tratan como de acceso
 	jwt.RegisteredClaims
 }
 
-func NewJWTManager(secretKey, issuer string) *JWTManager {
-	return &JWTManager{
-		secretKey: secretKey,
-		issuer:    issuer,
+// JWTOption configura aspectos opcionales de JWTManager
+type JWTOption func(*JWTManager)
+
+// WithTokenExpiry fija la validez de los tokens de acceso y de refresco
+func WithTokenExpiry(access time.Duration, refresh time.Duration) JWTOption {
+	return func(j *JWTManager) {
+		j.accessExpiry = access
+		j.refreshExpiry = refresh
 	}
 }
 
+// WithTokenRevocations permite invalidar tokens de refresco antes de que caduquen
+func WithTokenRevocations(store TokenRevocationStore) JWTOption {
+	return func(j *JWTManager) {
+		j.revocations = store
+	}
+}
+
+func NewJWTManager(secretKey, issuer string, opts ...JWTOption) *JWTManager {
+	j := &JWTManager{
+		secretKey:     secretKey,
+		issuer:        issuer,
+		accessExpiry:  defaultAccessTokenExpiry,
+		refreshExpiry: defaultRefreshTokenExpiry,
+	}
+
+	for _, opt := range opts {
+		opt(j)
+	}
+
+	return j
+}
+
+// AccessTokenExpiry es la validez de los tokens de acceso
+func (j *JWTManager) AccessTokenExpiry() time.Duration {
+	return j.accessExpiry
+}
+
 func (j *JWTManager) GenerateToken(userID, email string, roles []string) (string, error) {
+	return j.signToken(userID, email, roles, TokenTypeAccess, j.accessExpiry)
+}
+
+// GenerateTokenPair emite un token de acceso y uno de refresco, de mayor duración, para el mismo usuario
+func (j *JWTManager) GenerateTokenPair(userID, email string, roles []string) (access string, refresh string, err error) {
+	access, err = j.GenerateToken(userID, email, roles)
+	if err != nil {
+		return "", "", err
+	}
+
+	refresh, err = j.signToken(userID, email, roles, TokenTypeRefresh, j.refreshExpiry)
+	if err != nil {
+		return "", "", err
+	}
+
+	return access, refresh, nil
+}
+
+func (j *JWTManager) signToken(userID, email string, roles []string, tokenType string, expiry time.Duration) (string, error) {
+	now := time.Now()
 	claims := Claims{
-		UserID: userID,
-		Email:  email,
-		Roles:  roles,
+		UserID:    userID,
+		Email:     email,
+		Roles:     roles,
+		TokenType: tokenType,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    j.issuer,
 		},
 	}
@@ -46,7 +131,72 @@ func (j *JWTManager) GenerateToken(userID, email string, roles []string) (string
 	return token.SignedString([]byte(j.secretKey))
 }
 
+// ValidateToken valida un token de acceso; los de refresco se rechazan con ErrInvalidTokenType
 func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := j.parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.TokenType != "" && claims.TokenType != TokenTypeAccess {
+		return nil, fmt.Errorf("%w: expected an access token", ErrInvalidTokenType)
+	}
+
+	return claims, nil
+}
+
+// RefreshToken emite un token de acceso nuevo a partir de un token de refresco vigente y no revocado
+func (j *JWTManager) RefreshToken(ctx context.Context, refresh string) (string, error) {
+	claims, err := j.validateRefreshToken(refresh)
+	if err != nil {
+		return "", err
+	}
+
+	if j.revocations != nil {
+		revoked, err := j.revocations.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			return "", fmt.Errorf("failed to check token revocation: %w", err)
+		}
+		if revoked {
+			return "", ErrTokenRevoked
+		}
+	}
+
+	return j.GenerateToken(claims.UserID, claims.Email, claims.Roles)
+}
+
+// RevokeToken invalida el token de refresco hasta su caducidad, p. ej. al cerrar sesión
+func (j *JWTManager) RevokeToken(ctx context.Context, refresh string) error {
+	if j.revocations == nil {
+		return ErrRevocationUnavailable
+	}
+
+	claims, err := j.validateRefreshToken(refresh)
+	if err != nil {
+		return err
+	}
+
+	if err := j.revocations.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	return nil
+}
+
+func (j *JWTManager) validateRefreshToken(refresh string) (*Claims, error) {
+	claims, err := j.parseToken(refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.TokenType != TokenTypeRefresh {
+		return nil, fmt.Errorf("%w: expected a refresh token", ErrInvalidTokenType)
+	}
+
+	return claims, nil
+}
+
+func (j *JWTManager) parseToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -55,19 +205,24 @@ func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 	})
 
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
 		return claims, nil
 	}
 
-	return nil, errors.New("invalid token")
+	return nil, ErrInvalidToken
 }
 
+// ExtractTokenFromHeader devuelve el token de la cabecera Authorization o, al abrir un WebSocket sin ella,
+// el que sigue a WebSocketTokenProtocol en Sec-WebSocket-Protocol
 func (j *JWTManager) ExtractTokenFromHeader(c *gin.Context) (string, error) {
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
+		if token, ok := extractWebSocketProtocolToken(c); ok {
+			return token, nil
+		}
 		return "", errors.New("authorization header required")
 	}
 
@@ -77,4 +232,25 @@ func (j *JWTManager) ExtractTokenFromHeader(c *gin.Context) (string, error) {
 	}
 
 	return parts[1], nil
+}
+
+func extractWebSocketProtocolToken(c *gin.Context) (string, bool) {
+	// Only the handshake carries subprotocols; anywhere else the header would just be a way around Authorization
+	if !strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+		return "", false
+	}
+
+	var protocols []string
+	for _, value := range c.Request.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(value, ",") {
+			protocols = append(protocols, strings.TrimSpace(protocol))
+		}
+	}
+
+	for i := 0; i+1 < len(protocols); i++ {
+		if protocols[i] == WebSocketTokenProtocol && protocols[i+1] != "" {
+			return protocols[i+1], true
+		}
+	}
+	return "", false
 }
\ No newline at end of file
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTManager_RefreshToken(t *testing.T) {
+	manager := NewJWTManager("test-secret", "test-issuer")
+	access, refresh, err := manager.GenerateTokenPair("user123", "user@example.com", []string{"agent"})
+	require.NoError(t, err)
+
+	newAccess, err := manager.RefreshToken(context.Background(), refresh)
+	require.NoError(t, err)
+
+	claims, err := manager.ValidateToken(newAccess)
+	require.NoError(t, err)
+	assert.Equal(t, "user123", claims.UserID)
+	assert.Equal(t, []string{"agent"}, claims.Roles)
+	assert.Equal(t, TokenTypeAccess, claims.TokenType)
+
+	// An access token can't be used to refresh
+	_, err = manager.RefreshToken(context.Background(), access)
+	assert.ErrorIs(t, err, ErrInvalidTokenType)
+
+	// And a refresh token can't authenticate requests
+	_, err = manager.ValidateToken(refresh)
+	assert.ErrorIs(t, err, ErrInvalidTokenType)
+}
+
+func TestJWTManager_RefreshTokenExpired(t *testing.T) {
+	manager := NewJWTManager("test-secret", "test-issuer", WithTokenExpiry(time.Hour, -time.Minute))
+	_, refresh, err := manager.GenerateTokenPair("user123", "user@example.com", nil)
+	require.NoError(t, err)
+
+	_, err = manager.RefreshToken(context.Background(), refresh)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Signed with a different secret
+	_, refresh, err = NewJWTManager("other-secret", "test-issuer").GenerateTokenPair("user123", "user@example.com", nil)
+	require.NoError(t, err)
+	_, err = manager.RefreshToken(context.Background(), refresh)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestJWTManager_RevokeToken(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	manager := NewJWTManager("test-secret", "test-issuer", WithTokenRevocations(NewRedisTokenRevocationStore(client)))
+
+	_, refresh, err := manager.GenerateTokenPair("user123", "user@example.com", nil)
+	require.NoError(t, err)
+	_, other, err := manager.GenerateTokenPair("user123", "user@example.com", nil)
+	require.NoError(t, err)
+
+	require.NoError(t, manager.RevokeToken(context.Background(), refresh))
+
+	_, err = manager.RefreshToken(context.Background(), refresh)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	// Only the revoked token is affected, and its entry expires with it
+	_, err = manager.RefreshToken(context.Background(), other)
+	assert.NoError(t, err)
+	keys := server.Keys()
+	require.Len(t, keys, 1)
+	assert.InDelta(t, defaultRefreshTokenExpiry.Seconds(), server.TTL(keys[0]).Seconds(), 5)
+
+	// Without a store there's nothing to revoke against
+	err = NewJWTManager("test-secret", "test-issuer").RevokeToken(context.Background(), other)
+	assert.ErrorIs(t, err, ErrRevocationUnavailable)
+}
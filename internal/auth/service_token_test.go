@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceTokenVerifier_Verify(t *testing.T) {
+	verifier := NewServiceTokenVerifier("shared-secret")
+	token := verifier.GenerateToken("delivery-worker")
+
+	service, err := verifier.Verify(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "delivery-worker", service)
+
+	// Signed with a different secret
+	_, err = NewServiceTokenVerifier("other-secret").Verify(token)
+	assert.Error(t, err)
+
+	// Service name tampered with
+	_, err = verifier.Verify("classifier" + token[len("delivery-worker"):])
+	assert.Error(t, err)
+
+	// No secret configured disables service tokens entirely
+	_, err = NewServiceTokenVerifier("").Verify(token)
+	assert.Error(t, err)
+}
+
+func TestServiceIdentityFromContext(t *testing.T) {
+	_, ok := ServiceIdentityFromContext(context.Background())
+	assert.False(t, ok)
+
+	service, ok := ServiceIdentityFromContext(WithServiceIdentity(context.Background(), "classifier"))
+	assert.True(t, ok)
+	assert.Equal(t, "classifier", service)
+	assert.Equal(t, "service:classifier", ServiceUserID(service))
+}
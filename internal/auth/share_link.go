@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ShareLinkSigner firma los tokens de los enlaces compartidos con formato "<id del enlace>.<hmac-sha256 hex>".
+// La firma cubre el enlace, su conversación y su caducidad, así que un token no sirve para otro enlace ni
+// puede alargarse; la revocación se comprueba contra el enlace guardado
+type ShareLinkSigner struct {
+	secret []byte
+}
+
+func NewShareLinkSigner(secret string) *ShareLinkSigner {
+	return &ShareLinkSigner{
+		secret: []byte(secret),
+	}
+}
+
+// Enabled indica si hay un secreto configurado; sin él no se pueden crear ni abrir enlaces
+func (s *ShareLinkSigner) Enabled() bool {
+	return s != nil && len(s.secret) > 0
+}
+
+// GenerateToken firma el enlace linkID de la conversación, válido hasta expiresAt
+func (s *ShareLinkSigner) GenerateToken(linkID string, conversationID string, expiresAt time.Time) string {
+	return linkID + "." + s.sign(linkID, conversationID, expiresAt)
+}
+
+// Verify comprueba que token es la firma del enlace guardado
+func (s *ShareLinkSigner) Verify(token string, conversationID string, expiresAt time.Time) error {
+	if !s.Enabled() {
+		return errors.New("share links disabled")
+	}
+
+	linkID, err := ShareLinkID(token)
+	if err != nil {
+		return err
+	}
+
+	if !hmac.Equal([]byte(token), []byte(s.GenerateToken(linkID, conversationID, expiresAt))) {
+		return errors.New("invalid share link token")
+	}
+
+	return nil
+}
+
+// ShareLinkID extrae del token el ID del enlace, sin validar la firma
+func ShareLinkID(token string) (string, error) {
+	idx := strings.LastIndex(token, ".")
+	if idx <= 0 || idx == len(token)-1 {
+		return "", errors.New("share link token format must be {link}.{signature}")
+	}
+	return token[:idx], nil
+}
+
+func (s *ShareLinkSigner) sign(linkID string, conversationID string, expiresAt time.Time) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(linkID + "|" + conversationID + "|" + strconv.FormatInt(expiresAt.Unix(), 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
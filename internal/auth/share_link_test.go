@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareLinkSigner_Verify(t *testing.T) {
+	signer := NewShareLinkSigner("share-secret")
+	expiresAt := time.Unix(1767225600, 0)
+	token := signer.GenerateToken("link1", "conv123", expiresAt)
+
+	linkID, err := ShareLinkID(token)
+	require.NoError(t, err)
+	assert.Equal(t, "link1", linkID)
+	assert.NoError(t, signer.Verify(token, "conv123", expiresAt))
+
+	// The signature is bound to the conversation and the expiry
+	assert.Error(t, signer.Verify(token, "conv999", expiresAt))
+	assert.Error(t, signer.Verify(token, "conv123", expiresAt.Add(time.Hour)))
+
+	// Signed with a different secret
+	assert.Error(t, NewShareLinkSigner("other-secret").Verify(token, "conv123", expiresAt))
+
+	// No secret configured disables share links entirely
+	assert.Error(t, NewShareLinkSigner("").Verify(token, "conv123", expiresAt))
+
+	_, err = ShareLinkID("link1")
+	assert.Error(t, err)
+}
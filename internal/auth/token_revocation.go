@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TokenRevocationStore guarda los IDs (jti) de los tokens invalidados antes de caducar
+type TokenRevocationStore interface {
+	// Revoke invalida el token hasta expiresAt; después ya no hace falta recordarlo
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
+type redisTokenRevocationStore struct {
+	client *redis.Client
+}
+
+func NewRedisTokenRevocationStore(client *redis.Client) TokenRevocationStore {
+	return &redisTokenRevocationStore{
+		client: client,
+	}
+}
+
+func revokedTokenKey(tokenID string) string {
+	return fmt.Sprintf("revoked_token:%s", tokenID)
+}
+
+func (s *redisTokenRevocationStore) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		// Already expired, so it can't be used anyway
+		return nil
+	}
+	return s.client.Set(ctx, revokedTokenKey(tokenID), 1, ttl).Err()
+}
+
+func (s *redisTokenRevocationStore) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	count, err := s.client.Exists(ctx, revokedTokenKey(tokenID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookSignatureHeader es la cabecera con la que los proveedores de canal firman sus callbacks
+const WebhookSignatureHeader = "X-Webhook-Signature"
+
+// WebhookTimestampHeader lleva el instante Unix (segundos) incluido en la firma con SignWebhookPayload
+const WebhookTimestampHeader = "X-Webhook-Timestamp"
+
+// SignWebhookPayload firma "<timestamp>.<cuerpo>" con HMAC-SHA256 y devuelve "sha256=<hex>". Incluir el
+// instante permite al receptor rechazar peticiones antiguas reenviadas por un tercero
+func SignWebhookPayload(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// EventSignatureHeader lleva la firma de SignEventPayload en los eventos enviados a EVENTS_WEBHOOK_URL
+const EventSignatureHeader = "X-Signature"
+
+// SignEventPayload firma el cuerpo con HMAC-SHA256 y devuelve "sha256=<hex>"
+func SignEventPayload(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// DefaultWebhookTolerance es la antigüedad máxima de un callback firmado antes de considerarlo reenviado
+const DefaultWebhookTolerance = 5 * time.Minute
+
+// WebhookSignatureVerifier valida las firmas de SignWebhookPayload de los callbacks de cada proveedor de
+// canal, con un secreto propio por canal. Solo acepta callbacks cuyo instante esté dentro de la ventana
+// de tolerancia, de modo que una petición capturada no puede reenviarse pasado ese tiempo
+type WebhookSignatureVerifier struct {
+	secrets   map[string][]byte
+	tolerance time.Duration
+	now       func() time.Time
+}
+
+// NewWebhookSignatureVerifier crea el verificador; los canales sin secreto no aceptan ningún callback y una
+// tolerancia no positiva usa DefaultWebhookTolerance
+func NewWebhookSignatureVerifier(secrets map[string]string, tolerance time.Duration) *WebhookSignatureVerifier {
+	if tolerance <= 0 {
+		tolerance = DefaultWebhookTolerance
+	}
+
+	v := &WebhookSignatureVerifier{
+		secrets:   make(map[string][]byte),
+		tolerance: tolerance,
+		now:       time.Now,
+	}
+	for channel, secret := range secrets {
+		if secret != "" {
+			v.secrets[channel] = []byte(secret)
+		}
+	}
+	return v
+}
+
+// Enabled indica si el canal tiene un secreto configurado
+func (v *WebhookSignatureVerifier) Enabled(channel string) bool {
+	return v != nil && len(v.secrets[channel]) > 0
+}
+
+// Sign calcula la firma del cuerpo para el canal en el instante indicado
+func (v *WebhookSignatureVerifier) Sign(channel string, timestamp int64, body []byte) string {
+	return SignWebhookPayload(v.secrets[channel], timestamp, body)
+}
+
+// Verify comprueba el instante y la firma del cuerpo recibido; la firma se acepta con o sin el prefijo "sha256="
+func (v *WebhookSignatureVerifier) Verify(channel string, body []byte, timestamp, signature string) error {
+	if !v.Enabled(channel) {
+		return errors.New("webhooks disabled for channel")
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid webhook timestamp")
+	}
+
+	age := v.now().Sub(time.Unix(ts, 0))
+	if age > v.tolerance || age < -v.tolerance {
+		return errors.New("webhook timestamp outside tolerance")
+	}
+
+	if !strings.HasPrefix(signature, "sha256=") {
+		signature = "sha256=" + signature
+	}
+
+	if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(v.Sign(channel, ts, body))) {
+		return errors.New("invalid webhook signature")
+	}
+
+	return nil
+}
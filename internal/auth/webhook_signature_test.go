@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookSignatureVerifier_Verify(t *testing.T) {
+	verifier := NewWebhookSignatureVerifier(map[string]string{"whatsapp": "provider-secret", "web": ""}, time.Minute)
+	now := time.Unix(1700000000, 0)
+	verifier.now = func() time.Time { return now }
+
+	body := []byte(`{"message_id":"wamid.1","status":"delivered"}`)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := verifier.Sign("whatsapp", now.Unix(), body)
+
+	assert.NoError(t, verifier.Verify("whatsapp", body, timestamp, signature))
+	assert.NoError(t, verifier.Verify("whatsapp", body, timestamp, signature[len("sha256="):]))
+
+	// Body tampered with
+	assert.Error(t, verifier.Verify("whatsapp", []byte(`{"message_id":"wamid.1","status":"read"}`), timestamp, signature))
+
+	// Timestamp changed without re-signing
+	assert.Error(t, verifier.Verify("whatsapp", body, strconv.FormatInt(now.Unix()+1, 10), signature))
+	assert.Error(t, verifier.Verify("whatsapp", body, "", signature))
+
+	// Channels without a secret reject every callback
+	assert.Error(t, verifier.Verify("web", body, timestamp, signature))
+	assert.Error(t, verifier.Verify("messenger", body, timestamp, signature))
+}
+
+func TestWebhookSignatureVerifier_RejectsReplaysOutsideTolerance(t *testing.T) {
+	verifier := NewWebhookSignatureVerifier(map[string]string{"whatsapp": "provider-secret"}, time.Minute)
+	body := []byte(`{"message_id":"wamid.1","status":"delivered"}`)
+	signedAt := time.Unix(1700000000, 0)
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	signature := verifier.Sign("whatsapp", signedAt.Unix(), body)
+
+	verifier.now = func() time.Time { return signedAt.Add(59 * time.Second) }
+	assert.NoError(t, verifier.Verify("whatsapp", body, timestamp, signature))
+
+	verifier.now = func() time.Time { return signedAt.Add(2 * time.Minute) }
+	assert.Error(t, verifier.Verify("whatsapp", body, timestamp, signature))
+
+	// Clocks running ahead are bounded by the same window
+	verifier.now = func() time.Time { return signedAt.Add(-2 * time.Minute) }
+	assert.Error(t, verifier.Verify("whatsapp", body, timestamp, signature))
+}
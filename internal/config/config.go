@@ -1,8 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -11,6 +15,7 @@ type Config struct {
 	Environment string
 	Port        string
 	LogLevel    string
+	LogFormat   string // Uno de LogFormats: json (Cloud Logging) o text
 	VaultConfig VaultConfig
 	Database    DatabaseConfig
 	ExternalAPI ExternalAPIConfig
@@ -18,12 +23,44 @@ type Config struct {
 	JWT         JWTConfig
 	FileStorage FileStorageConfig
 	Events      EventsConfig
+	Delivery    DeliveryConfig
+	Abandonment AbandonmentConfig
+	Expiry      ExpiryConfig
+	Scheduling  SchedulingConfig
+	Moderation  ModerationConfig
+	Scan        ScanConfig
+	API         APIConfig
+	Throttle    ThrottleConfig
+	Counters    CountersConfig
+	PII         PIIConfig
+	AutoReply   AutoReplyConfig
+	AutoClose   AutoCloseConfig
+	Mentions    MentionConfig
+	Reference   ReferenceConfig
+	Archival    ArchivalConfig
+	Content     ContentConfig
+	Webhooks    WebhookConfig
+	Outbox      OutboxConfig
+	Drafts      DraftConfig
+	ShareLinks  ShareLinkConfig
+	Locks       LockConfig
+	Health      HealthConfig
+	RateLimit   RateLimitConfig
+	Tracing     TracingConfig
+	WhatsApp    WhatsAppConfig
+	Messenger   MetaChannelConfig
+	Instagram   MetaChannelConfig
+
+	// ShutdownTimeout limita la espera a que terminen workers, publishers y WebSocket al apagar el servidor
+	ShutdownTimeout time.Duration
 }
 
+// VaultConfig indica de dónde leer los secretos; sin token se usan las variables de entorno
 type VaultConfig struct {
 	Address string
 	Token   string
 	Path    string
+	Timeout time.Duration // Límite de la lectura al arrancar; pasado este tiempo se siguen usando las variables de entorno
 }
 
 type DatabaseConfig struct {
@@ -47,25 +84,328 @@ type RedisConfig struct {
 	Password string
 	DB       int
 	Enabled  bool
+	// ConversationCacheTTL es lo que se cachea cada conversación; cero lo desactiva
+	ConversationCacheTTL time.Duration
+	// MessageCacheTTL es lo que se cachea cada página de mensajes de una conversación; cero lo desactiva
+	MessageCacheTTL time.Duration
+	// ConversationListCacheTTL es lo que se cachea el listado de conversaciones de cada usuario; cero lo desactiva
+	ConversationListCacheTTL time.Duration
 }
 
 type JWTConfig struct {
-	SecretKey string
-	Issuer    string
-	ExpiryHours int
+	SecretKey          string
+	Issuer             string
+	ExpiryHours        int           // Validez de los tokens de acceso
+	RefreshExpiry      time.Duration // Validez de los tokens de refresco
+	ServiceTokenSecret string // Secreto compartido para X-Service-Token; vacío desactiva la autenticación entre servicios
 }
 
 type FileStorageConfig struct {
-	Provider    string // "local", "gcs", "s3"
-	BucketName  string
-	LocalPath   string
-	MaxFileSize int64
+	Provider         string // Uno de FileStorageProviders
+	BucketName       string
+	LocalPath        string
+	MaxFileSize      int64
+	MaxChunkSize     int64         // Tamaño máximo de cada parte en subidas reanudables
+	MaxBatchFiles    int           // Archivos admitidos en una subida múltiple
+	UploadSessionTTL time.Duration // Tiempo tras el cual se descartan las subidas reanudables incompletas
+	SigningSecret    string        // Secreto con el que se firman las descargas locales; vacío las deja públicas
+	URLTTL           time.Duration // Validez de las URLs firmadas que devuelve GET /attachments/{id}
+	AllowedMIMETypes []string      // Tipos MIME detectados admitidos (p. ej. "image/*"); vacío admite cualquiera
+	ThumbnailSize    int           // Lado mayor de las miniaturas de imágenes en píxeles; 0 no las genera
+	ThumbnailWorkers int           // Miniaturas que se generan a la vez en segundo plano
+	ThumbnailQueue   int           // Imágenes que pueden esperar su miniatura; con la cola llena se quedan sin ella
+	S3               S3StorageConfig
+	GCS              GCSStorageConfig
+}
+
+// GCSStorageConfig son los datos de conexión con Cloud Storage cuando Provider es "gcs". Las credenciales
+// son las Application Default Credentials, que el cliente busca por su cuenta
+type GCSStorageConfig struct {
+	Endpoint string // Endpoint alternativo (p. ej. un emulador); vacío usa Google
+	Timeout  time.Duration
+}
+
+// S3StorageConfig son los datos de conexión con S3 cuando Provider es "s3"
+type S3StorageConfig struct {
+	Region          string
+	Endpoint        string // Endpoint compatible con S3 (p. ej. MinIO); vacío usa AWS
+	AccessKeyID     string // Vacío usa la cadena de credenciales por defecto del SDK de AWS
+	SecretAccessKey string
+	SessionToken    string
+	Timeout         time.Duration
 }
 
 type EventsConfig struct {
-	Provider string // "redis", "pubsub", "webhook"
+	Provider string // Uno de EventsProviders
 	Topic    string
 	WebhookURL string
+	WebhookSecret      string        // Secreto con el que se firma cada evento enviado a WebhookURL
+	WebhookTimeout     time.Duration
+	WebhookQueueSize   int           // Eventos pendientes de enviar a WebhookURL; si la cola está llena se descartan
+	KafkaBrokers       []string
+	KafkaWriteTimeout  time.Duration // Límite de cada publicación y de la comprobación de brokers al arrancar
+	StreamBatchWindow  time.Duration // Ventana en la que el WebSocket agrupa eventos en un solo frame; 0 envía cada evento por separado
+	StreamBatchMaxSize int           // Eventos tras los que se envía el lote sin esperar a que venza la ventana
+	TypingAgentRoles   []string      // Roles del JWT cuyos indicadores de escritura se marcan como de agente
+}
+
+// DeliveryConfig controla los reintentos de entregas salientes fallidas
+type DeliveryConfig struct {
+	RetryEnabled     bool
+	RetryInterval    time.Duration // Frecuencia con la que se buscan entregas fallidas
+	RetryMaxAttempts int
+	RetryBaseBackoff time.Duration // Espera tras el primer fallo, se duplica en cada intento
+	RetryMaxBackoff  time.Duration
+	RetryBatchSize   int
+
+	// Tiempo máximo de cada entrega al proveedor; un envío con delivery_mode=sync espera como mucho esto
+	SendTimeout time.Duration
+
+	// Transformadores salientes por canal, aplicados en orden antes de entregar al proveedor
+	OutboundTransformers map[string][]string
+
+	// Secretos con los que cada proveedor firma sus acuses de entrega; un canal sin secreto no los acepta
+	ReceiptSecrets map[string]string
+	// Antigüedad máxima del X-Webhook-Timestamp de un acuse; fuera de la ventana se rechaza como reenviado
+	ReceiptTolerance time.Duration
+
+	// Un webhook reintentado con el mismo provider_message_id devuelve el mensaje ya guardado en vez de un 409
+	InboundDedupEnabled bool
+
+	// Límite de entregas salientes por canal; un canal sin límite entrega sin esperas
+	RateLimits map[string]DeliveryRateLimit
+}
+
+// DeliveryRateLimit regula las entregas a un proveedor; los valores en cero no limitan
+type DeliveryRateLimit struct {
+	Rate          float64 // Entregas por segundo
+	Burst         int     // Entregas seguidas permitidas tras un periodo sin envíos; por defecto, las de un segundo
+	MaxConcurrent int     // Entregas en curso a la vez
+}
+
+// AbandonmentConfig controla la detección de conversaciones abandonadas por el cliente
+type AbandonmentConfig struct {
+	Enabled      bool
+	ScanInterval time.Duration
+	IdleTimeout  time.Duration // Tiempo sin respuesta del cliente al último mensaje del agente o bot
+	Channels     []string      // Canales a vigilar, p. ej. "web"
+	BatchSize    int
+}
+
+// ArchivalConfig controla el archivado de las conversaciones que superan una antigüedad máxima,
+// independientemente de su actividad, y de las activas que llevan demasiado tiempo sin ella
+type ArchivalConfig struct {
+	Enabled      bool
+	ScanInterval time.Duration
+	MaxAge       map[string]time.Duration // Antigüedad máxima desde created_at por canal; 0 no archiva ese canal
+	BatchSize    int                      // Conversaciones archivadas por sentencia, para no bloquear la tabla
+	InactiveDays int                      // Días sin actividad tras los que se archiva una conversación activa; 0 no las archiva
+	DryRun       bool                     // Solo registra cuántas se archivarían
+}
+
+// WebhookConfig controla la entrega a los webhooks de conversación y la retención de su registro de intentos
+type WebhookConfig struct {
+	Timeout           time.Duration
+	Workers           int // Entregas simultáneas como máximo
+	QueueSize         int // Eventos pendientes de entregar; si la cola está llena se descartan
+	DeliveryRetention time.Duration // Los intentos más antiguos se eliminan con su cuerpo; 0 los conserva
+	PruneInterval     time.Duration
+	PruneBatchSize    int
+}
+
+// OutboxConfig controla event_outbox, que garantiza que el evento de cada mensaje guardado se publica al
+// menos una vez
+type OutboxConfig struct {
+	Enabled          bool
+	RelayInterval    time.Duration // Frecuencia con la que el relay busca eventos pendientes
+	BatchSize        int
+	MaxAttempts      int           // Agotados los intentos el evento queda como dead letter, sin más reintentos
+	RetryBaseBackoff time.Duration // Espera tras el primer fallo, se duplica en cada intento
+	RetryMaxBackoff  time.Duration
+	Retention        time.Duration // Los eventos publicados más antiguos se eliminan; 0 los conserva
+	ClaimLease       time.Duration // Cuánto se reserva un evento reclamado para el relay que lo reclamó
+}
+
+// DraftConfig controla los borradores de mensaje que cada usuario guarda en el servidor
+type DraftConfig struct {
+	Enabled   bool
+	MaxLength int // Caracteres como máximo del contenido de un borrador
+}
+
+// ShareLinkConfig controla los enlaces de solo lectura con los que se comparte una conversación
+type ShareLinkConfig struct {
+	Secret     string        // Secreto con el que se firman los tokens; vacío desactiva los enlaces
+	DefaultTTL time.Duration // Duración de un enlace que no indica la suya
+	MaxTTL     time.Duration
+}
+
+// LockConfig controla los bloqueos blandos con los que un agente indica que atiende una conversación;
+// se guardan en Redis y sin él no están disponibles
+type LockConfig struct {
+	DefaultTTL time.Duration // Duración de un bloqueo que no indica la suya
+	MaxTTL     time.Duration
+}
+
+// HealthConfig controla qué dependencias debe tener disponibles el servicio para estar listo
+type HealthConfig struct {
+	RequiredDependencies []string      // Dependencias sin las que /ready responde 503; el resto solo degradan
+	CheckTimeout         time.Duration // Tiempo máximo de cada comprobación de dependencia
+}
+
+// maxS3PresignTTL es la validez máxima que S3 (y Cloud Storage) admite en una URL prefirmada
+const maxS3PresignTTL = 7 * 24 * time.Hour
+
+// HealthDependencies son las dependencias cuyo estado comprueba el readiness
+var HealthDependencies = []string{"database", "redis"}
+
+// FileStorageProviders son los valores admitidos de FILE_STORAGE_PROVIDER
+var FileStorageProviders = []string{"local", "s3", "gcs"}
+
+// LogFormats son los valores admitidos de LOG_FORMAT
+var LogFormats = []string{"json", "text"}
+
+// EventsProviders son los valores admitidos de EVENTS_PROVIDER
+var EventsProviders = []string{"redis", "kafka", "webhook"}
+
+// defaultJWTSecret es el secreto de desarrollo que se usa sin JWT_SECRET; no se admite en producción
+const defaultJWTSecret = "your-secret-key"
+
+// ExpiryConfig controla la eliminación de mensajes efímeros vencidos
+type ExpiryConfig struct {
+	ReaperEnabled   bool
+	ReaperInterval  time.Duration
+	ReaperBatchSize int
+}
+
+// SchedulingConfig controla el worker que envía los mensajes programados cuando llega su hora
+type SchedulingConfig struct {
+	WorkerEnabled   bool
+	WorkerInterval  time.Duration // Frecuencia con la que se buscan envíos pendientes; es el retraso máximo de un envío
+	WorkerBatchSize int
+	// SendingLease es cuánto puede seguir un envío en sending antes de darlo por abandonado y recuperarlo;
+	// debe superar con holgura lo que tarda SendMessage
+	SendingLease time.Duration
+}
+
+// APIConfig controla el formato de las respuestas de la API
+type APIConfig struct {
+	ResponseEnvelope string // "wrapped" ({code, message, data}) o "flat" (solo el recurso)
+	PinnedFirst      bool   // Orden por defecto de GET /messages: fijados primero en lugar de solo cronológico
+	// MaskAccessDenied responde 404 en lugar de 403 a quien pide una conversación ajena, para no revelar que
+	// existe; los administradores reciben siempre el 403
+	MaskAccessDenied bool
+	// IdempotencyKeyTTL es lo que se recuerda cada Idempotency-Key de un envío; se guardan en Redis y sin él
+	// la cabecera se ignora
+	IdempotencyKeyTTL time.Duration
+}
+
+// RateLimitConfig limita las peticiones a la API con una ventana deslizante en Redis; un límite cero no limita
+type RateLimitConfig struct {
+	UserRequests   int           // Peticiones por usuario autenticado en cada ventana
+	PublicRequests int           // Peticiones por IP a las rutas públicas en cada ventana
+	Window         time.Duration
+}
+
+// TracingConfig controla el envío de trazas OpenTelemetry; sin OTLPEndpoint el trazado no hace nada
+type TracingConfig struct {
+	OTLPEndpoint  string  // URL base del colector OTLP/HTTP, p. ej. http://otel-collector:4318
+	ServiceName   string
+	SampleRatio   float64 // Fracción de trazas nuevas que se muestrean, entre 0 y 1
+	ExportTimeout time.Duration
+}
+
+// MetaChannelConfig habilita un canal de Meta (WhatsApp, Messenger o Instagram): la ingesta de mensajes
+// entrantes en /webhooks/{channel}, que sin AppSecret no acepta ningún mensaje, y la entrega de los
+// salientes, que sin AccessToken no se hace
+type MetaChannelConfig struct {
+	AppSecret   string // Secreto de la app de Meta con el que se firma X-Hub-Signature-256
+	VerifyToken string // Token que Meta envía en hub.verify_token al suscribir el webhook
+	AccessToken string // Token de acceso de la página o del sistema con el que se llama a la Graph API
+	GraphAPIURL string // URL base versionada de la Graph API
+}
+
+// SenderEnabled indica si hay credenciales para entregar mensajes salientes en el canal
+func (m MetaChannelConfig) SenderEnabled() bool {
+	return m.AccessToken != ""
+}
+
+// WhatsAppConfig añade el número de la empresa desde el que se envían los mensajes salientes
+type WhatsAppConfig struct {
+	MetaChannelConfig
+	PhoneNumberID string // Se usa cuando la conversación no guarda el número al que escribió el cliente
+}
+
+// CountersConfig controla la reconciliación de los contadores desnormalizados, como message_count
+type CountersConfig struct {
+	ReconcileEnabled   bool
+	ReconcileInterval  time.Duration // Frecuencia con la que se revisa un lote de conversaciones
+	ReconcileBatchSize int
+}
+
+// ThrottleConfig limita la frecuencia de operaciones que generan ráfagas de eventos
+type ThrottleConfig struct {
+	StatusMinInterval time.Duration // Intervalo mínimo entre cambios de estado de una conversación; cero lo desactiva
+	StatusMode        string        // "reject" responde 429, "debounce" descarta el cambio sin error
+}
+
+// ModerationConfig controla la moderación externa del contenido de los mensajes
+type ModerationConfig struct {
+	URL      string // Endpoint del moderador; vacío deshabilita la moderación
+	Timeout  time.Duration
+	FailMode string // "open" envía el mensaje si el moderador falla, "closed" lo rechaza
+}
+
+// PIIConfig controla el enmascarado de datos personales en el contenido de los mensajes antes de guardarlos
+type PIIConfig struct {
+	Enabled    bool
+	Categories []string          // Categorías a detectar, p. ej. credit_card, ssn, email
+	Patterns   map[string]string // Expresiones regulares que sustituyen a las incorporadas; vacío usa la de serie
+}
+
+// AutoReplyConfig controla la respuesta automática a las conversaciones que llegan fuera del horario de atención
+type AutoReplyConfig struct {
+	Enabled  bool
+	Timezone string        // Zona horaria IANA en la que se interpretan los horarios
+	Cooldown time.Duration // Tiempo mínimo entre dos respuestas automáticas en la misma conversación
+	Channels map[string]AutoReplyChannelConfig
+}
+
+// AutoCloseConfig controla el cierre automático de conversaciones con una frase de resolución
+type AutoCloseConfig struct {
+	Enabled        bool
+	Phrases        []string // Frases que resuelven la conversación; las que empiezan por "/" son comandos
+	SenderTypes    []string // Remitentes que pueden cerrar (user, agent, bot); vacío admite a todos salvo system
+	ClosingMessage string   // Mensaje de sistema insertado al cerrar; vacío no inserta nada
+}
+
+// MentionConfig controla las menciones a otros participantes en el contenido de los mensajes
+type MentionConfig struct {
+	Enabled bool
+	Pattern string // Expresión regular con un único grupo de captura: el ID del usuario mencionado
+}
+
+// AutoReplyChannelConfig es el horario y el mensaje de un canal; un mensaje vacío no responde en ese canal
+type AutoReplyChannelConfig struct {
+	Hours   string // p. ej. "mon-fri 09:00-18:00;sat 10:00-14:00"
+	Message string
+}
+
+// ReferenceConfig controla las referencias legibles de las conversaciones, p. ej. WEB-001234
+type ReferenceConfig struct {
+	Prefixes map[string]string // Prefijo por canal; vacío usa el nombre del canal en mayúsculas
+	Digits   int               // Ancho mínimo del número, completado con ceros
+}
+
+// ContentConfig sustituye los requisitos estructurales de los mensajes por tipo de contenido
+type ContentConfig struct {
+	Rules map[string]string // Regla por tipo de contenido, p. ej. "attachment:image"; vacío usa la incorporada
+}
+
+// ScanConfig controla el análisis antivirus de los archivos subidos
+type ScanConfig struct {
+	URL      string // Endpoint del analizador; vacío deshabilita el análisis
+	Timeout  time.Duration
+	FailMode string // "open" acepta el archivo si el analizador falla, "closed" lo rechaza
 }
 
 func Load() *Config {
@@ -76,10 +416,12 @@ func Load() *Config {
 		Environment: getEnv("ENVIRONMENT", "development"),
 		Port:        getEnv("PORT", "8080"),
 		LogLevel:    getEnv("LOG_LEVEL", "info"),
+		LogFormat:   getEnv("LOG_FORMAT", "json"),
 		VaultConfig: VaultConfig{
 			Address: getEnv("VAULT_ADDR", "http://localhost:8200"),
 			Token:   getEnv("VAULT_TOKEN", ""),
 			Path:    getEnv("VAULT_PATH", "secret/microservice"),
+			Timeout: getEnvAsDuration("VAULT_TIMEOUT", 5*time.Second),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -95,29 +437,493 @@ func Load() *Config {
 			Password: getEnv("REDIS_PASSWORD", ""),
 			DB:       getEnvAsInt("REDIS_DB", 0),
 			Enabled:  getEnvAsBool("REDIS_ENABLED", true),
+
+			ConversationCacheTTL:     getEnvAsDuration("CACHE_CONVERSATION_TTL", 30*time.Minute),
+			MessageCacheTTL:          getEnvAsDuration("CACHE_MESSAGE_TTL", 10*time.Minute),
+			ConversationListCacheTTL: getEnvAsDuration("CONVERSATION_LIST_CACHE_TTL", time.Minute),
 		},
 		JWT: JWTConfig{
-			SecretKey:   getEnv("JWT_SECRET", "your-secret-key"),
-			Issuer:      getEnv("JWT_ISSUER", "messaging-service"),
-			ExpiryHours: getEnvAsInt("JWT_EXPIRY_HOURS", 24),
+			SecretKey:          getEnv("JWT_SECRET", defaultJWTSecret),
+			Issuer:             getEnv("JWT_ISSUER", "messaging-service"),
+			ExpiryHours:        getEnvAsInt("JWT_EXPIRY_HOURS", 24),
+			RefreshExpiry:      getEnvAsDuration("JWT_REFRESH_EXPIRY", 30*24*time.Hour),
+			ServiceTokenSecret: getEnv("SERVICE_TOKEN_SECRET", ""),
 		},
 		FileStorage: FileStorageConfig{
-			Provider:    getEnv("FILE_STORAGE_PROVIDER", "local"),
-			BucketName:  getEnv("FILE_STORAGE_BUCKET", "messaging-attachments"),
-			LocalPath:   getEnv("FILE_STORAGE_LOCAL_PATH", "./uploads"),
-			MaxFileSize: getEnvAsInt64("FILE_STORAGE_MAX_SIZE", 10*1024*1024), // 10MB
+			Provider:         getEnv("FILE_STORAGE_PROVIDER", "local"),
+			BucketName:       getEnv("FILE_STORAGE_BUCKET", "messaging-attachments"),
+			LocalPath:        getEnv("FILE_STORAGE_LOCAL_PATH", "./uploads"),
+			MaxFileSize:      getEnvAsInt64("FILE_STORAGE_MAX_SIZE", 10*1024*1024),   // 10MB
+			MaxChunkSize:     getEnvAsInt64("FILE_UPLOAD_MAX_CHUNK_SIZE", 1024*1024), // 1MB
+			MaxBatchFiles:    getEnvAsInt("FILE_UPLOAD_MAX_BATCH", 10),
+			UploadSessionTTL: getEnvAsDuration("FILE_UPLOAD_SESSION_TTL", 24*time.Hour),
+			SigningSecret:    getEnv("FILE_URL_SIGNING_SECRET", ""),
+			URLTTL:           getEnvAsDuration("FILE_URL_TTL", 15*time.Minute),
+			AllowedMIMETypes: getEnvAsSlice("ALLOWED_MIME_TYPES", nil),
+			ThumbnailSize:    getEnvAsInt("FILE_THUMBNAIL_SIZE", 256),
+			ThumbnailWorkers: getEnvAsInt("FILE_THUMBNAIL_WORKERS", 2),
+			ThumbnailQueue:   getEnvAsInt("FILE_THUMBNAIL_QUEUE_SIZE", 32),
+			S3: S3StorageConfig{
+				Region:          getEnv("AWS_REGION", "us-east-1"),
+				Endpoint:        getEnv("FILE_STORAGE_S3_ENDPOINT", ""),
+				AccessKeyID:     getEnv("AWS_ACCESS_KEY_ID", ""),
+				SecretAccessKey: getEnv("AWS_SECRET_ACCESS_KEY", ""),
+				SessionToken:    getEnv("AWS_SESSION_TOKEN", ""),
+				Timeout:         getEnvAsDuration("FILE_STORAGE_S3_TIMEOUT", 30*time.Second),
+			},
+			GCS: GCSStorageConfig{
+				Endpoint: getEnv("FILE_STORAGE_GCS_ENDPOINT", ""),
+				Timeout:  getEnvAsDuration("FILE_STORAGE_GCS_TIMEOUT", 30*time.Second),
+			},
 		},
 		Events: EventsConfig{
 			Provider:   getEnv("EVENTS_PROVIDER", "redis"),
 			Topic:      getEnv("EVENTS_TOPIC", "message.events"),
 			WebhookURL: getEnv("EVENTS_WEBHOOK_URL", ""),
+			WebhookSecret:      getEnv("EVENTS_WEBHOOK_SECRET", ""),
+			WebhookTimeout:     getEnvAsDuration("EVENTS_WEBHOOK_TIMEOUT", 5*time.Second),
+			WebhookQueueSize:   getEnvAsInt("EVENTS_WEBHOOK_QUEUE_SIZE", 1000),
+			KafkaBrokers:       getEnvAsSlice("KAFKA_BROKERS", nil),
+			KafkaWriteTimeout:  getEnvAsDuration("KAFKA_WRITE_TIMEOUT", 10*time.Second),
+			StreamBatchWindow:  getEnvAsDuration("WS_BATCH_WINDOW", 0),
+			StreamBatchMaxSize: getEnvAsInt("WS_BATCH_MAX_SIZE", 50),
+			TypingAgentRoles:   getEnvAsSlice("TYPING_AGENT_ROLES", nil),
+		},
+		Delivery: DeliveryConfig{
+			RetryEnabled:     getEnvAsBool("DELIVERY_RETRY_ENABLED", false),
+			RetryInterval:    getEnvAsDuration("DELIVERY_RETRY_INTERVAL", 30*time.Second),
+			RetryMaxAttempts: getEnvAsInt("DELIVERY_RETRY_MAX_ATTEMPTS", 5),
+			RetryBaseBackoff: getEnvAsDuration("DELIVERY_RETRY_BASE_BACKOFF", 30*time.Second),
+			RetryMaxBackoff:  getEnvAsDuration("DELIVERY_RETRY_MAX_BACKOFF", 30*time.Minute),
+			RetryBatchSize:   getEnvAsInt("DELIVERY_RETRY_BATCH_SIZE", 100),
+			SendTimeout:      getEnvAsDuration("DELIVERY_SEND_TIMEOUT", 10*time.Second),
+			OutboundTransformers: map[string][]string{
+				"whatsapp":  getEnvAsSlice("OUTBOUND_TRANSFORMERS_WHATSAPP", nil),
+				"web":       getEnvAsSlice("OUTBOUND_TRANSFORMERS_WEB", nil),
+				"messenger": getEnvAsSlice("OUTBOUND_TRANSFORMERS_MESSENGER", nil),
+				"instagram": getEnvAsSlice("OUTBOUND_TRANSFORMERS_INSTAGRAM", nil),
+			},
+			ReceiptSecrets: map[string]string{
+				"whatsapp":  getEnv("DELIVERY_RECEIPT_SECRET_WHATSAPP", ""),
+				"web":       getEnv("DELIVERY_RECEIPT_SECRET_WEB", ""),
+				"messenger": getEnv("DELIVERY_RECEIPT_SECRET_MESSENGER", ""),
+				"instagram": getEnv("DELIVERY_RECEIPT_SECRET_INSTAGRAM", ""),
+			},
+			ReceiptTolerance:    getEnvAsDuration("DELIVERY_RECEIPT_TOLERANCE", 5*time.Minute),
+			InboundDedupEnabled: getEnvAsBool("INBOUND_DEDUP_ENABLED", true),
+			RateLimits: map[string]DeliveryRateLimit{
+				"whatsapp":  getDeliveryRateLimit("WHATSAPP"),
+				"web":       getDeliveryRateLimit("WEB"),
+				"messenger": getDeliveryRateLimit("MESSENGER"),
+				"instagram": getDeliveryRateLimit("INSTAGRAM"),
+			},
+		},
+		Abandonment: AbandonmentConfig{
+			Enabled:      getEnvAsBool("ABANDONMENT_ENABLED", true),
+			ScanInterval: getEnvAsDuration("ABANDONMENT_SCAN_INTERVAL", time.Minute),
+			IdleTimeout:  getEnvAsDuration("ABANDONMENT_IDLE_TIMEOUT", 15*time.Minute),
+			Channels:     getEnvAsSlice("ABANDONMENT_CHANNELS", []string{"web"}),
+			BatchSize:    getEnvAsInt("ABANDONMENT_BATCH_SIZE", 100),
+		},
+		Archival: ArchivalConfig{
+			Enabled:      getEnvAsBool("CONVERSATION_ARCHIVAL_ENABLED", false),
+			ScanInterval: getEnvAsDuration("CONVERSATION_ARCHIVAL_INTERVAL", time.Hour),
+			MaxAge: map[string]time.Duration{
+				"whatsapp":  getEnvAsDuration("CONVERSATION_MAX_AGE_WHATSAPP", getEnvAsDuration("CONVERSATION_MAX_AGE", 0)),
+				"web":       getEnvAsDuration("CONVERSATION_MAX_AGE_WEB", getEnvAsDuration("CONVERSATION_MAX_AGE", 0)),
+				"messenger": getEnvAsDuration("CONVERSATION_MAX_AGE_MESSENGER", getEnvAsDuration("CONVERSATION_MAX_AGE", 0)),
+				"instagram": getEnvAsDuration("CONVERSATION_MAX_AGE_INSTAGRAM", getEnvAsDuration("CONVERSATION_MAX_AGE", 0)),
+			},
+			BatchSize:    getEnvAsInt("CONVERSATION_ARCHIVAL_BATCH_SIZE", 500),
+			InactiveDays: getEnvAsInt("CONVERSATION_ARCHIVE_DAYS", 0),
+			DryRun:       getEnvAsBool("CONVERSATION_ARCHIVAL_DRY_RUN", false),
+		},
+		Webhooks: WebhookConfig{
+			Timeout:           getEnvAsDuration("WEBHOOK_TIMEOUT", 5*time.Second),
+			Workers:           getEnvAsInt("WEBHOOK_WORKERS", 4),
+			QueueSize:         getEnvAsInt("WEBHOOK_QUEUE_SIZE", 1000),
+			DeliveryRetention: getEnvAsDuration("WEBHOOK_DELIVERY_RETENTION", 30*24*time.Hour),
+			PruneInterval:     getEnvAsDuration("WEBHOOK_DELIVERY_PRUNE_INTERVAL", time.Hour),
+			PruneBatchSize:    getEnvAsInt("WEBHOOK_DELIVERY_PRUNE_BATCH_SIZE", 1000),
+		},
+		Outbox: OutboxConfig{
+			Enabled:          getEnvAsBool("OUTBOX_ENABLED", true),
+			RelayInterval:    getEnvAsDuration("OUTBOX_RELAY_INTERVAL", 5*time.Second),
+			BatchSize:        getEnvAsInt("OUTBOX_BATCH_SIZE", 100),
+			MaxAttempts:      getEnvAsInt("OUTBOX_MAX_ATTEMPTS", 10),
+			RetryBaseBackoff: getEnvAsDuration("OUTBOX_RETRY_BASE_BACKOFF", 5*time.Second),
+			RetryMaxBackoff:  getEnvAsDuration("OUTBOX_RETRY_MAX_BACKOFF", 10*time.Minute),
+			Retention:        getEnvAsDuration("OUTBOX_RETENTION", 24*time.Hour),
+			ClaimLease:       getEnvAsDuration("OUTBOX_CLAIM_LEASE", time.Minute),
+		},
+		Drafts: DraftConfig{
+			Enabled:   getEnvAsBool("DRAFTS_ENABLED", true),
+			MaxLength: getEnvAsInt("DRAFT_MAX_LENGTH", 20000),
+		},
+		ShareLinks: ShareLinkConfig{
+			Secret:     getEnv("SHARE_LINK_SECRET", ""),
+			DefaultTTL: getEnvAsDuration("SHARE_LINK_DEFAULT_TTL", 24*time.Hour),
+			MaxTTL:     getEnvAsDuration("SHARE_LINK_MAX_TTL", 7*24*time.Hour),
+		},
+		Locks: LockConfig{
+			DefaultTTL: getEnvAsDuration("CONVERSATION_LOCK_DEFAULT_TTL", 5*time.Minute),
+			MaxTTL:     getEnvAsDuration("CONVERSATION_LOCK_MAX_TTL", 30*time.Minute),
+		},
+		Expiry: ExpiryConfig{
+			ReaperEnabled:   getEnvAsBool("MESSAGE_EXPIRY_REAPER_ENABLED", true),
+			ReaperInterval:  getEnvAsDuration("MESSAGE_EXPIRY_REAPER_INTERVAL", 30*time.Second),
+			ReaperBatchSize: getEnvAsInt("MESSAGE_EXPIRY_REAPER_BATCH_SIZE", 100),
+		},
+		Scheduling: SchedulingConfig{
+			WorkerEnabled:   getEnvAsBool("SCHEDULED_MESSAGES_WORKER_ENABLED", true),
+			WorkerInterval:  getEnvAsDuration("SCHEDULED_MESSAGES_WORKER_INTERVAL", 10*time.Second),
+			WorkerBatchSize: getEnvAsInt("SCHEDULED_MESSAGES_WORKER_BATCH_SIZE", 100),
+			SendingLease:    getEnvAsDuration("SCHEDULED_MESSAGES_SENDING_LEASE", 5*time.Minute),
+		},
+		API: APIConfig{
+			ResponseEnvelope:  getEnv("API_RESPONSE_ENVELOPE", "wrapped"),
+			PinnedFirst:       getEnvAsBool("MESSAGES_PINNED_FIRST", false),
+			MaskAccessDenied:  getEnvAsBool("API_MASK_ACCESS_DENIED", true),
+			IdempotencyKeyTTL: getEnvAsDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+		},
+		RateLimit: RateLimitConfig{
+			UserRequests:   getEnvAsInt("RATE_LIMIT_USER_REQUESTS", 0),
+			PublicRequests: getEnvAsInt("RATE_LIMIT_PUBLIC_REQUESTS", 0),
+			Window:         getEnvAsDuration("RATE_LIMIT_WINDOW", time.Minute),
+		},
+		Tracing: TracingConfig{
+			OTLPEndpoint:  getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", ""),
+			ServiceName:   getEnv("OTEL_SERVICE_NAME", "it-messaging-service"),
+			SampleRatio:   getEnvAsFloat("TRACING_SAMPLE_RATIO", 1),
+			ExportTimeout: getEnvAsDuration("TRACING_EXPORT_TIMEOUT", 10*time.Second),
+		},
+		WhatsApp: WhatsAppConfig{
+			MetaChannelConfig: getMetaChannelConfig("WHATSAPP"),
+			PhoneNumberID:     getEnv("WHATSAPP_PHONE_NUMBER_ID", ""),
+		},
+		Messenger: getMetaChannelConfig("MESSENGER"),
+		Instagram: getMetaChannelConfig("INSTAGRAM"),
+		Counters: CountersConfig{
+			ReconcileEnabled:   getEnvAsBool("MESSAGE_COUNT_RECONCILE_ENABLED", true),
+			ReconcileInterval:  getEnvAsDuration("MESSAGE_COUNT_RECONCILE_INTERVAL", time.Minute),
+			ReconcileBatchSize: getEnvAsInt("MESSAGE_COUNT_RECONCILE_BATCH_SIZE", 500),
+		},
+		Throttle: ThrottleConfig{
+			StatusMinInterval: getEnvAsDuration("CONVERSATION_STATUS_MIN_INTERVAL", 0),
+			StatusMode:        getEnv("CONVERSATION_STATUS_THROTTLE_MODE", "reject"),
+		},
+		Moderation: ModerationConfig{
+			URL:      getEnv("MODERATION_URL", ""),
+			Timeout:  getEnvAsDuration("MODERATION_TIMEOUT", 2*time.Second),
+			FailMode: getEnv("MODERATION_FAIL_MODE", "closed"),
+		},
+		PII:         getPIIConfig(),
+		AutoReply: AutoReplyConfig{
+			Enabled:  getEnvAsBool("AUTO_RESPONDER_ENABLED", false),
+			Timezone: getEnv("AUTO_RESPONDER_TIMEZONE", "UTC"),
+			Cooldown: getEnvAsDuration("AUTO_RESPONDER_COOLDOWN", 12*time.Hour),
+			Channels: map[string]AutoReplyChannelConfig{
+				"whatsapp":  getAutoReplyChannelConfig("WHATSAPP"),
+				"web":       getAutoReplyChannelConfig("WEB"),
+				"messenger": getAutoReplyChannelConfig("MESSENGER"),
+				"instagram": getAutoReplyChannelConfig("INSTAGRAM"),
+			},
+		},
+		AutoClose: AutoCloseConfig{
+			Enabled:        getEnvAsBool("AUTO_CLOSE_ENABLED", false),
+			Phrases:        getEnvAsSlice("AUTO_CLOSE_PHRASES", []string{"/resolve"}),
+			SenderTypes:    getEnvAsSlice("AUTO_CLOSE_SENDER_TYPES", nil),
+			ClosingMessage: getEnv("AUTO_CLOSE_MESSAGE", ""),
+		},
+		Mentions: MentionConfig{
+			Enabled: getEnvAsBool("MENTIONS_ENABLED", true),
+			Pattern: getEnv("MENTION_PATTERN", `(?:^|\s)@([A-Za-z0-9_-]+(?:\.[A-Za-z0-9_-]+)*)`),
+		},
+		Reference: ReferenceConfig{
+			Prefixes: map[string]string{
+				"whatsapp":  getEnv("CONVERSATION_REFERENCE_PREFIX_WHATSAPP", "WA"),
+				"web":       getEnv("CONVERSATION_REFERENCE_PREFIX_WEB", "WEB"),
+				"messenger": getEnv("CONVERSATION_REFERENCE_PREFIX_MESSENGER", "FB"),
+				"instagram": getEnv("CONVERSATION_REFERENCE_PREFIX_INSTAGRAM", "IG"),
+			},
+			Digits: getEnvAsInt("CONVERSATION_REFERENCE_DIGITS", 6),
+		},
+		Content: ContentConfig{
+			Rules: map[string]string{
+				"text":  getEnv("CONTENT_RULE_TEXT", ""),
+				"image": getEnv("CONTENT_RULE_IMAGE", ""),
+				"video": getEnv("CONTENT_RULE_VIDEO", ""),
+				"audio": getEnv("CONTENT_RULE_AUDIO", ""),
+				"file":  getEnv("CONTENT_RULE_FILE", ""),
+			},
+		},
+		Health: HealthConfig{
+			RequiredDependencies: getEnvAsSlice("HEALTH_REQUIRED_DEPENDENCIES", []string{"database"}),
+			CheckTimeout:         getEnvAsDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		},
+		Scan: ScanConfig{
+			URL:      getEnv("SCAN_URL", ""),
+			Timeout:  getEnvAsDuration("SCAN_TIMEOUT", 10*time.Second),
+			FailMode: getEnv("SCAN_FAIL_MODE", "closed"),
 		},
 		ExternalAPI: ExternalAPIConfig{
 			BaseURL: getEnv("EXTERNAL_API_URL", "https://api.example.com"),
 			APIKey:  getEnv("EXTERNAL_API_KEY", ""),
 			Timeout: getEnvAsInt("EXTERNAL_API_TIMEOUT", 30),
 		},
+		ShutdownTimeout: getEnvAsDuration("SHUTDOWN_TIMEOUT", 15*time.Second),
+	}
+}
+
+// getAutoReplyChannelConfig lee AUTO_RESPONDER_<CANAL>_HOURS y _MESSAGE, usando los valores generales si no existen
+// Validate rechaza los valores con los que un worker habilitado no podría arrancar, como un intervalo
+// de cero que haría fallar a su ticker
+func (c *Config) Validate() error {
+	var problems []string
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		problems = append(problems, "PORT must be a number between 1 and 65535")
+	}
+	if c.Environment == "production" && (c.JWT.SecretKey == "" || c.JWT.SecretKey == defaultJWTSecret) {
+		problems = append(problems, "JWT_SECRET must be set to a non-default value in production")
+	}
+	problems = requireOneOf(problems, "FILE_STORAGE_PROVIDER", c.FileStorage.Provider, FileStorageProviders)
+	problems = requireOneOf(problems, "EVENTS_PROVIDER", c.Events.Provider, EventsProviders)
+	problems = requireOneOf(problems, "LOG_FORMAT", c.LogFormat, LogFormats)
+	problems = requirePositive(problems, "FILE_STORAGE_MAX_SIZE", c.FileStorage.MaxFileSize)
+	problems = requirePositive(problems, "SHUTDOWN_TIMEOUT", int64(c.ShutdownTimeout))
+	if c.Delivery.RetryEnabled {
+		problems = requirePositive(problems, "DELIVERY_RETRY_INTERVAL", int64(c.Delivery.RetryInterval))
+		problems = requirePositive(problems, "DELIVERY_RETRY_MAX_ATTEMPTS", int64(c.Delivery.RetryMaxAttempts))
+		problems = requirePositive(problems, "DELIVERY_RETRY_BATCH_SIZE", int64(c.Delivery.RetryBatchSize))
+	}
+	if c.Abandonment.Enabled {
+		problems = requirePositive(problems, "ABANDONMENT_SCAN_INTERVAL", int64(c.Abandonment.ScanInterval))
+		problems = requirePositive(problems, "ABANDONMENT_IDLE_TIMEOUT", int64(c.Abandonment.IdleTimeout))
+		problems = requirePositive(problems, "ABANDONMENT_BATCH_SIZE", int64(c.Abandonment.BatchSize))
+	}
+	if c.RateLimit.UserRequests > 0 || c.RateLimit.PublicRequests > 0 {
+		problems = requirePositive(problems, "RATE_LIMIT_WINDOW", int64(c.RateLimit.Window))
+	}
+	if c.Expiry.ReaperEnabled {
+		problems = requirePositive(problems, "MESSAGE_EXPIRY_REAPER_INTERVAL", int64(c.Expiry.ReaperInterval))
+		problems = requirePositive(problems, "MESSAGE_EXPIRY_REAPER_BATCH_SIZE", int64(c.Expiry.ReaperBatchSize))
+	}
+	if c.Scheduling.WorkerEnabled {
+		problems = requirePositive(problems, "SCHEDULED_MESSAGES_WORKER_INTERVAL", int64(c.Scheduling.WorkerInterval))
+		problems = requirePositive(problems, "SCHEDULED_MESSAGES_WORKER_BATCH_SIZE", int64(c.Scheduling.WorkerBatchSize))
+		problems = requirePositive(problems, "SCHEDULED_MESSAGES_SENDING_LEASE", int64(c.Scheduling.SendingLease))
+	}
+	if c.Counters.ReconcileEnabled {
+		problems = requirePositive(problems, "MESSAGE_COUNT_RECONCILE_INTERVAL", int64(c.Counters.ReconcileInterval))
+		problems = requirePositive(problems, "MESSAGE_COUNT_RECONCILE_BATCH_SIZE", int64(c.Counters.ReconcileBatchSize))
+	}
+	if c.Archival.Enabled {
+		problems = requirePositive(problems, "CONVERSATION_ARCHIVAL_INTERVAL", int64(c.Archival.ScanInterval))
+		problems = requirePositive(problems, "CONVERSATION_ARCHIVAL_BATCH_SIZE", int64(c.Archival.BatchSize))
+		if c.Archival.InactiveDays < 0 {
+			problems = append(problems, "CONVERSATION_ARCHIVE_DAYS must not be negative")
+		}
+	}
+	problems = requirePositive(problems, "DELIVERY_SEND_TIMEOUT", int64(c.Delivery.SendTimeout))
+	problems = requirePositive(problems, "WEBHOOK_WORKERS", int64(c.Webhooks.Workers))
+	problems = requirePositive(problems, "WEBHOOK_QUEUE_SIZE", int64(c.Webhooks.QueueSize))
+	if c.Outbox.Enabled {
+		problems = requirePositive(problems, "OUTBOX_RELAY_INTERVAL", int64(c.Outbox.RelayInterval))
+		problems = requirePositive(problems, "OUTBOX_BATCH_SIZE", int64(c.Outbox.BatchSize))
+		problems = requirePositive(problems, "OUTBOX_MAX_ATTEMPTS", int64(c.Outbox.MaxAttempts))
+		problems = requirePositive(problems, "OUTBOX_RETRY_BASE_BACKOFF", int64(c.Outbox.RetryBaseBackoff))
+		problems = requirePositive(problems, "OUTBOX_RETRY_MAX_BACKOFF", int64(c.Outbox.RetryMaxBackoff))
+		problems = requirePositive(problems, "OUTBOX_CLAIM_LEASE", int64(c.Outbox.ClaimLease))
+	}
+	if c.Drafts.Enabled {
+		problems = requirePositive(problems, "DRAFT_MAX_LENGTH", int64(c.Drafts.MaxLength))
+	}
+	if c.ShareLinks.Secret != "" {
+		problems = requirePositive(problems, "SHARE_LINK_DEFAULT_TTL", int64(c.ShareLinks.DefaultTTL))
+		if c.ShareLinks.MaxTTL < c.ShareLinks.DefaultTTL {
+			problems = append(problems, "SHARE_LINK_MAX_TTL must not be shorter than SHARE_LINK_DEFAULT_TTL")
+		}
+	}
+	problems = requirePositive(problems, "CONVERSATION_LOCK_DEFAULT_TTL", int64(c.Locks.DefaultTTL))
+	problems = requirePositive(problems, "IDEMPOTENCY_KEY_TTL", int64(c.API.IdempotencyKeyTTL))
+	if c.VaultConfig.Enabled() {
+		problems = requirePositive(problems, "VAULT_TIMEOUT", int64(c.VaultConfig.Timeout))
+	}
+	if c.Locks.MaxTTL < c.Locks.DefaultTTL {
+		problems = append(problems, "CONVERSATION_LOCK_MAX_TTL must not be shorter than CONVERSATION_LOCK_DEFAULT_TTL")
+	}
+	if c.Events.StreamBatchWindow < 0 {
+		problems = append(problems, "WS_BATCH_WINDOW must not be negative")
+	}
+	if c.Events.StreamBatchWindow > 0 {
+		problems = requirePositive(problems, "WS_BATCH_MAX_SIZE", int64(c.Events.StreamBatchMaxSize))
+	}
+	channels := make([]string, 0, len(c.Delivery.RateLimits))
+	for channel := range c.Delivery.RateLimits {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	for _, channel := range channels {
+		if limit := c.Delivery.RateLimits[channel]; limit.Rate < 0 || limit.Burst < 0 || limit.MaxConcurrent < 0 {
+			problems = append(problems, "delivery rate limits for "+channel+" must not be negative")
+		}
+	}
+	problems = requirePositive(problems, "HEALTH_CHECK_TIMEOUT", int64(c.Health.CheckTimeout))
+	problems = requireVerifyToken(problems, "WHATSAPP", c.WhatsApp.MetaChannelConfig)
+	if c.WhatsApp.SenderEnabled() && c.WhatsApp.PhoneNumberID == "" {
+		problems = append(problems, "WHATSAPP_PHONE_NUMBER_ID must be set when WHATSAPP_ACCESS_TOKEN is set")
+	}
+	problems = requireVerifyToken(problems, "MESSENGER", c.Messenger)
+	problems = requireVerifyToken(problems, "INSTAGRAM", c.Instagram)
+	if c.Tracing.OTLPEndpoint != "" {
+		if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+			problems = append(problems, "TRACING_SAMPLE_RATIO must be between 0 and 1")
+		}
+		problems = requirePositive(problems, "TRACING_EXPORT_TIMEOUT", int64(c.Tracing.ExportTimeout))
+	}
+	problems = requirePositive(problems, "JWT_EXPIRY_HOURS", int64(c.JWT.ExpiryHours))
+	if c.JWT.RefreshExpiry < time.Duration(c.JWT.ExpiryHours)*time.Hour {
+		problems = append(problems, "JWT_REFRESH_EXPIRY must not be shorter than JWT_EXPIRY_HOURS")
+	}
+	if c.Events.Provider == "webhook" {
+		if c.Events.WebhookURL == "" {
+			problems = append(problems, "EVENTS_WEBHOOK_URL is required when EVENTS_PROVIDER is webhook")
+		}
+		if c.Events.WebhookSecret == "" {
+			problems = append(problems, "EVENTS_WEBHOOK_SECRET is required when EVENTS_PROVIDER is webhook")
+		}
+		problems = requirePositive(problems, "EVENTS_WEBHOOK_TIMEOUT", int64(c.Events.WebhookTimeout))
+		problems = requirePositive(problems, "EVENTS_WEBHOOK_QUEUE_SIZE", int64(c.Events.WebhookQueueSize))
+	}
+	if c.Events.Provider == "kafka" {
+		if len(c.Events.KafkaBrokers) == 0 {
+			problems = append(problems, "KAFKA_BROKERS is required when EVENTS_PROVIDER is kafka")
+		}
+		problems = requirePositive(problems, "KAFKA_WRITE_TIMEOUT", int64(c.Events.KafkaWriteTimeout))
+	}
+	problems = requirePositive(problems, "FILE_URL_TTL", int64(c.FileStorage.URLTTL))
+	problems = requirePositive(problems, "FILE_UPLOAD_MAX_BATCH", int64(c.FileStorage.MaxBatchFiles))
+	if c.FileStorage.ThumbnailSize < 0 {
+		problems = append(problems, "FILE_THUMBNAIL_SIZE must not be negative")
+	}
+	if c.FileStorage.ThumbnailSize > 0 {
+		problems = requirePositive(problems, "FILE_THUMBNAIL_WORKERS", int64(c.FileStorage.ThumbnailWorkers))
+		problems = requirePositive(problems, "FILE_THUMBNAIL_QUEUE_SIZE", int64(c.FileStorage.ThumbnailQueue))
+	}
+	if (c.FileStorage.Provider == "s3" || c.FileStorage.Provider == "gcs") && c.FileStorage.URLTTL > maxS3PresignTTL {
+		problems = append(problems, "FILE_URL_TTL must not exceed 168h with the "+c.FileStorage.Provider+" provider")
+	}
+	if c.FileStorage.Provider == "s3" {
+		required := []struct{ key, value string }{
+			{"FILE_STORAGE_BUCKET", c.FileStorage.BucketName},
+			{"AWS_REGION", c.FileStorage.S3.Region},
+		}
+		for _, setting := range required {
+			if setting.value == "" {
+				problems = append(problems, setting.key+" is required when FILE_STORAGE_PROVIDER is s3")
+			}
+		}
+		if c.FileStorage.S3.AccessKeyID != "" && c.FileStorage.S3.SecretAccessKey == "" {
+			problems = append(problems, "AWS_SECRET_ACCESS_KEY is required when AWS_ACCESS_KEY_ID is set")
+		}
+		problems = requirePositive(problems, "FILE_STORAGE_S3_TIMEOUT", int64(c.FileStorage.S3.Timeout))
+	}
+	if c.FileStorage.Provider == "gcs" {
+		if c.FileStorage.BucketName == "" {
+			problems = append(problems, "FILE_STORAGE_BUCKET is required when FILE_STORAGE_PROVIDER is gcs")
+		}
+		problems = requirePositive(problems, "FILE_STORAGE_GCS_TIMEOUT", int64(c.FileStorage.GCS.Timeout))
+	}
+	for _, dependency := range c.Health.RequiredDependencies {
+		known := false
+		for _, name := range HealthDependencies {
+			if dependency == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			problems = append(problems, "HEALTH_REQUIRED_DEPENDENCIES has unknown dependency "+dependency)
+		}
 	}
+	if c.Webhooks.DeliveryRetention > 0 {
+		problems = requirePositive(problems, "WEBHOOK_DELIVERY_PRUNE_INTERVAL", int64(c.Webhooks.PruneInterval))
+		problems = requirePositive(problems, "WEBHOOK_DELIVERY_PRUNE_BATCH_SIZE", int64(c.Webhooks.PruneBatchSize))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+func requireOneOf(problems []string, key string, value string, allowed []string) []string {
+	for _, candidate := range allowed {
+		if value == candidate {
+			return problems
+		}
+	}
+	return append(problems, fmt.Sprintf("%s must be one of %s, got %q", key, strings.Join(allowed, ", "), value))
+}
+
+func requirePositive(problems []string, key string, value int64) []string {
+	if value <= 0 {
+		return append(problems, key+" must be greater than zero")
+	}
+	return problems
+}
+
+// requireVerifyToken exige el token de verificación de un webhook de Meta habilitado, sin el que Meta no
+// llega a suscribirlo
+func requireVerifyToken(problems []string, prefix string, webhook MetaChannelConfig) []string {
+	if webhook.AppSecret != "" && webhook.VerifyToken == "" {
+		return append(problems, prefix+"_VERIFY_TOKEN must be set when "+prefix+"_APP_SECRET is set")
+	}
+	return problems
+}
+
+// getMetaChannelConfig lee <CANAL>_APP_SECRET, <CANAL>_VERIFY_TOKEN y <CANAL>_ACCESS_TOKEN
+func getMetaChannelConfig(channel string) MetaChannelConfig {
+	return MetaChannelConfig{
+		AppSecret:   getEnv(channel+"_APP_SECRET", ""),
+		VerifyToken: getEnv(channel+"_VERIFY_TOKEN", ""),
+		AccessToken: getEnv(channel+"_ACCESS_TOKEN", ""),
+		GraphAPIURL: getEnv("META_GRAPH_API_URL", "https://graph.facebook.com/v19.0"),
+	}
+}
+
+// getDeliveryRateLimit lee DELIVERY_RATE_LIMIT_<CANAL>, DELIVERY_RATE_BURST_<CANAL> y DELIVERY_MAX_CONCURRENCY_<CANAL>
+func getDeliveryRateLimit(channel string) DeliveryRateLimit {
+	return DeliveryRateLimit{
+		Rate:          getEnvAsFloat("DELIVERY_RATE_LIMIT_"+channel, 0),
+		Burst:         getEnvAsInt("DELIVERY_RATE_BURST_"+channel, 0),
+		MaxConcurrent: getEnvAsInt("DELIVERY_MAX_CONCURRENCY_"+channel, 0),
+	}
+}
+
+func getAutoReplyChannelConfig(channel string) AutoReplyChannelConfig {
+	return AutoReplyChannelConfig{
+		Hours:   getEnv("AUTO_RESPONDER_"+channel+"_HOURS", getEnv("AUTO_RESPONDER_HOURS", "mon-fri 09:00-18:00")),
+		Message: getEnv("AUTO_RESPONDER_"+channel+"_MESSAGE", getEnv("AUTO_RESPONDER_MESSAGE", "")),
+	}
+}
+
+// getPIIConfig lee PII_PATTERN_<CATEGORÍA> para cada categoría listada, de modo que una categoría nueva
+// solo necesita su variable de patrón
+func getPIIConfig() PIIConfig {
+	config := PIIConfig{
+		Enabled:    getEnvAsBool("PII_REDACTION_ENABLED", false),
+		Categories: getEnvAsSlice("PII_REDACTION_CATEGORIES", []string{"credit_card", "ssn", "email"}),
+		Patterns:   make(map[string]string),
+	}
+	for _, category := range config.Categories {
+		if pattern := getEnv("PII_PATTERN_"+strings.ToUpper(category), ""); pattern != "" {
+			config.Patterns[category] = pattern
+		}
+	}
+
+	return config
 }
 
 func getEnv(key, defaultValue string) string {
@@ -145,6 +951,15 @@ func getEnvAsInt64(key string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
@@ -152,4 +967,28 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 		}
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validProductionConfig parte de los valores por defecto con lo mínimo que exige producción
+func validProductionConfig() *Config {
+	cfg := Load()
+	cfg.Environment = "production"
+	cfg.Port = "8080"
+	cfg.JWT.SecretKey = "a-real-production-secret"
+	cfg.FileStorage.Provider = "local"
+	cfg.FileStorage.MaxFileSize = 10 * 1024 * 1024
+	cfg.Events.Provider = "redis"
+	return cfg
+}
+
+func TestValidate_ValidConfig(t *testing.T) {
+	assert.NoError(t, validProductionConfig().Validate())
+}
+
+func TestValidate_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(cfg *Config)
+		problem string
+	}{
+		{
+			name:    "default JWT secret in production",
+			mutate:  func(cfg *Config) { cfg.JWT.SecretKey = defaultJWTSecret },
+			problem: "JWT_SECRET must be set to a non-default value in production",
+		},
+		{
+			name:    "empty JWT secret in production",
+			mutate:  func(cfg *Config) { cfg.JWT.SecretKey = "" },
+			problem: "JWT_SECRET must be set to a non-default value in production",
+		},
+		{
+			name:    "unknown file storage provider",
+			mutate:  func(cfg *Config) { cfg.FileStorage.Provider = "ftp" },
+			problem: `FILE_STORAGE_PROVIDER must be one of local, s3, gcs, got "ftp"`,
+		},
+		{
+			name:    "unknown events provider",
+			mutate:  func(cfg *Config) { cfg.Events.Provider = "pubsub" },
+			problem: `EVENTS_PROVIDER must be one of redis, kafka, webhook, got "pubsub"`,
+		},
+		{
+			name:    "unknown log format",
+			mutate:  func(cfg *Config) { cfg.LogFormat = "xml" },
+			problem: `LOG_FORMAT must be one of json, text, got "xml"`,
+		},
+		{
+			name:    "non-positive max file size",
+			mutate:  func(cfg *Config) { cfg.FileStorage.MaxFileSize = 0 },
+			problem: "FILE_STORAGE_MAX_SIZE must be greater than zero",
+		},
+		{
+			name:    "non-numeric port",
+			mutate:  func(cfg *Config) { cfg.Port = "http" },
+			problem: "PORT must be a number between 1 and 65535",
+		},
+		{
+			name:    "port out of range",
+			mutate:  func(cfg *Config) { cfg.Port = "70000" },
+			problem: "PORT must be a number between 1 and 65535",
+		},
+		{
+			name:    "WhatsApp app secret without verify token",
+			mutate:  func(cfg *Config) { cfg.WhatsApp.AppSecret = "app-secret" },
+			problem: "WHATSAPP_VERIFY_TOKEN must be set when WHATSAPP_APP_SECRET is set",
+		},
+		{
+			name:    "Instagram app secret without verify token",
+			mutate:  func(cfg *Config) { cfg.Instagram.AppSecret = "app-secret" },
+			problem: "INSTAGRAM_VERIFY_TOKEN must be set when INSTAGRAM_APP_SECRET is set",
+		},
+		{
+			name:    "WhatsApp access token without phone number id",
+			mutate:  func(cfg *Config) { cfg.WhatsApp.AccessToken = "access-token" },
+			problem: "WHATSAPP_PHONE_NUMBER_ID must be set when WHATSAPP_ACCESS_TOKEN is set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validProductionConfig()
+			tt.mutate(cfg)
+
+			err := cfg.Validate()
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.problem)
+		})
+	}
+}
+
+func TestValidate_DefaultJWTSecretAllowedOutsideProduction(t *testing.T) {
+	cfg := validProductionConfig()
+	cfg.Environment = "development"
+	cfg.JWT.SecretKey = defaultJWTSecret
+
+	assert.NoError(t, cfg.Validate())
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// Claves de los secretos en VAULT_PATH, las mismas que escribe scripts/vault-init.sh
+const (
+	vaultDBPasswordKey    = "db_password"
+	vaultJWTSecretKey     = "jwt_secret"
+	vaultRedisPasswordKey = "redis_password"
+)
+
+// Enabled indica si hay que leer los secretos de Vault: hace falta dirección y token
+func (v VaultConfig) Enabled() bool {
+	return v.Address != "" && v.Token != ""
+}
+
+// LoadVaultSecrets sustituye la contraseña de la base de datos, el secreto JWT y la contraseña de Redis por
+// los que haya en VAULT_PATH (un secreto KV v2, p. ej. secret/microservice). Los que no estén en Vault
+// conservan el valor de las variables de entorno. Devuelve las claves que se tomaron de Vault; si Vault
+// no responde la configuración queda como estaba
+func (c *Config) LoadVaultSecrets(ctx context.Context) ([]string, error) {
+	if !c.VaultConfig.Enabled() {
+		return nil, nil
+	}
+
+	secrets, err := readVaultSecret(ctx, c.VaultConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var loaded []string
+	override := func(key string, target *string) {
+		if value, ok := secrets[key].(string); ok && value != "" {
+			*target = value
+			loaded = append(loaded, key)
+		}
+	}
+	override(vaultDBPasswordKey, &c.Database.Password)
+	override(vaultJWTSecretKey, &c.JWT.SecretKey)
+	override(vaultRedisPasswordKey, &c.Redis.Password)
+
+	return loaded, nil
+}
+
+// readVaultSecret lee el secreto KV v2 de cfg.Path, cuyo primer segmento es el punto de montaje
+func readVaultSecret(ctx context.Context, cfg VaultConfig) (map[string]interface{}, error) {
+	mount, path, ok := strings.Cut(strings.Trim(cfg.Path, "/"), "/")
+	if !ok || path == "" {
+		return nil, fmt.Errorf("invalid VAULT_PATH %q: expected <mount>/<path>", cfg.Path)
+	}
+
+	clientConfig := vault.DefaultConfig()
+	clientConfig.Address = cfg.Address
+	clientConfig.Timeout = cfg.Timeout
+	// An unreachable Vault falls back to the environment, so retrying only delays startup
+	clientConfig.MaxRetries = 0
+
+	client, err := vault.NewClient(clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vault client: %w", err)
+	}
+	client.SetToken(cfg.Token)
+
+	secret, err := client.KVv2(mount).Get(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault secret %q: %w", cfg.Path, err)
+	}
+
+	return secret.Data, nil
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newVaultServer simula un Vault con el secreto KV v2 secret/microservice
+func newVaultServer(t *testing.T, data map[string]interface{}) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/microservice" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     data,
+				"metadata": map[string]interface{}{"version": 1},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func vaultTestConfig(address string) *Config {
+	return &Config{
+		VaultConfig: VaultConfig{Address: address, Token: "test-token", Path: "secret/microservice", Timeout: time.Second},
+		Database:    DatabaseConfig{Password: "env-db-password"},
+		JWT:         JWTConfig{SecretKey: "env-jwt-secret"},
+		Redis:       RedisConfig{Password: "env-redis-password"},
+	}
+}
+
+func TestLoadVaultSecrets_OverridesEnvironment(t *testing.T) {
+	server := newVaultServer(t, map[string]interface{}{
+		"db_password": "vault-db-password",
+		"jwt_secret":  "vault-jwt-secret",
+		"api_key":     "ignored",
+	})
+	cfg := vaultTestConfig(server.URL)
+
+	loaded, err := cfg.LoadVaultSecrets(context.Background())
+
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"db_password", "jwt_secret"}, loaded)
+	assert.Equal(t, "vault-db-password", cfg.Database.Password)
+	assert.Equal(t, "vault-jwt-secret", cfg.JWT.SecretKey)
+	// Secrets missing from Vault keep the environment value
+	assert.Equal(t, "env-redis-password", cfg.Redis.Password)
+}
+
+func TestLoadVaultSecrets_UnreachableKeepsEnvironment(t *testing.T) {
+	server := newVaultServer(t, nil)
+	server.Close()
+	cfg := vaultTestConfig(server.URL)
+
+	_, err := cfg.LoadVaultSecrets(context.Background())
+
+	require.Error(t, err)
+	assert.Equal(t, "env-db-password", cfg.Database.Password)
+	assert.Equal(t, "env-jwt-secret", cfg.JWT.SecretKey)
+	assert.Equal(t, "env-redis-password", cfg.Redis.Password)
+}
+
+func TestLoadVaultSecrets_DisabledWithoutToken(t *testing.T) {
+	cfg := vaultTestConfig("http://127.0.0.1:1")
+	cfg.VaultConfig.Token = ""
+
+	loaded, err := cfg.LoadVaultSecrets(context.Background())
+
+	require.NoError(t, err)
+	assert.Empty(t, loaded)
+	assert.Equal(t, "env-db-password", cfg.Database.Password)
+}
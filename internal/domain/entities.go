@@ -3,8 +3,10 @@ package domain
 import (
 	"time"
 	"database/sql/driver"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"strings"
 )
 
 // User representa un usuario del sistema
@@ -26,6 +28,18 @@ const (
 	ConversationStatusActive   ConversationStatus = "active"
 	ConversationStatusClosed   ConversationStatus = "closed"
 	ConversationStatusArchived ConversationStatus = "archived"
+	// ConversationStatusAbandoned marca chats en los que el cliente dejó de responder; a diferencia de archived, se reactiva si el cliente vuelve a escribir
+	ConversationStatusAbandoned ConversationStatus = "abandoned"
+)
+
+// ConversationStatusOutcome es el resultado de un cambio de estado masivo para una de las conversaciones
+type ConversationStatusOutcome string
+
+const (
+	ConversationStatusOutcomeUpdated     ConversationStatusOutcome = "updated"
+	ConversationStatusOutcomeUnchanged   ConversationStatusOutcome = "unchanged"    // Ya tenía el estado pedido
+	ConversationStatusOutcomeTooFrequent ConversationStatusOutcome = "too_frequent" // Cambió hace menos del intervalo mínimo
+	ConversationStatusOutcomeNotFound    ConversationStatusOutcome = "not_found"    // No existe o no pertenece al usuario
 )
 
 // Channel representa los canales de comunicación
@@ -43,6 +57,7 @@ type SenderType string
 
 const (
 	SenderTypeUser   SenderType = "user"
+	SenderTypeAgent  SenderType = "agent"
 	SenderTypeBot    SenderType = "bot"
 	SenderTypeSystem SenderType = "system"
 )
@@ -58,6 +73,59 @@ const (
 	ContentTypeFile  ContentType = "file"
 )
 
+// DeliveryStatus representa el estado de entrega de un mensaje saliente
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "pending"
+	DeliveryStatusSent      DeliveryStatus = "sent"
+	DeliveryStatusDelivered DeliveryStatus = "delivered"
+	DeliveryStatusRead      DeliveryStatus = "read"
+	DeliveryStatusFailed    DeliveryStatus = "failed"
+)
+
+// MetadataProviderMessageID es la clave de metadatos con el identificador que el proveedor del canal asignó
+// al mensaje al entregarlo; sus acuses de entrega lo usan para referirse al mensaje
+const MetadataProviderMessageID = "provider_message_id"
+
+// Claves de metadatos con el último fallo de entrega al canal; se borran cuando el mensaje se entrega
+const (
+	MetadataDeliveryStatus = "delivery_status"
+	MetadataDeliveryError  = "delivery_error"
+)
+
+// deliveryStatusRank ordena los estados de entrega; failed comparte nivel con sent porque solo puede
+// sustituir a un envío que el proveedor aún no ha entregado
+var deliveryStatusRank = map[DeliveryStatus]int{
+	"":                      0,
+	DeliveryStatusPending:   0,
+	DeliveryStatusSent:      1,
+	DeliveryStatusFailed:    1,
+	DeliveryStatusDelivered: 2,
+	DeliveryStatusRead:      3,
+}
+
+// CanTransitionTo indica si el estado de entrega puede pasar a next. Los estados solo avanzan, de modo que
+// un acuse que llega tarde (delivered después de read) no hace retroceder el mensaje, y un mensaje ya
+// entregado no puede marcarse como fallido. Se puede saltar un estado (read sin delivered), pero un mensaje
+// fallido no admite acuses: solo sale de failed cuando un reintento lo vuelve a enviar
+func (s DeliveryStatus) CanTransitionTo(next DeliveryStatus) bool {
+	current, ok := deliveryStatusRank[s]
+	if !ok || s == DeliveryStatusFailed {
+		return false
+	}
+	target, ok := deliveryStatusRank[next]
+	if !ok || next == "" || next == DeliveryStatusPending {
+		return false
+	}
+
+	if next == DeliveryStatusFailed {
+		return current <= deliveryStatusRank[DeliveryStatusSent]
+	}
+
+	return target > current
+}
+
 // AttachmentType representa el tipo de archivo adjunto
 type AttachmentType string
 
@@ -68,6 +136,19 @@ const (
 	AttachmentTypeAudio AttachmentType = "audio"
 )
 
+// ErrNotFound indica que el recurso no existe o no es accesible para quien lo pide
+var ErrNotFound = errors.New("not found")
+
+// ErrAccessDenied indica que el recurso existe pero quien lo pide no tiene acceso. También es ErrNotFound,
+// así que donde no se distingue se sigue respondiendo 404 sin revelar que existe
+var ErrAccessDenied = errors.New("access denied")
+
+// ErrInvalidCursor indica un cursor de paginación que no se pudo interpretar
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrAlreadyExists indica que el recurso choca con uno ya guardado que debe ser único
+var ErrAlreadyExists = errors.New("already exists")
+
 // JSONB type for PostgreSQL JSONB fields
 type JSONB map[string]interface{}
 
@@ -89,47 +170,356 @@ func (j *JSONB) Scan(value interface{}) error {
 	return json.Unmarshal(bytes, j)
 }
 
+// MergePatch devuelve una copia de j con patch aplicado como JSON Merge Patch (RFC 7386): los objetos
+// anidados se mezclan clave a clave, un null elimina la clave y cualquier otro valor, arrays incluidos,
+// sustituye al anterior
+func (j JSONB) MergePatch(patch JSONB) JSONB {
+	return mergePatch(j, patch)
+}
+
+func mergePatch(target map[string]interface{}, patch map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(target)+len(patch))
+	for key, value := range target {
+		merged[key] = value
+	}
+
+	for key, value := range patch {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		if patchObject, ok := asObject(value); ok {
+			targetObject, _ := asObject(merged[key])
+			merged[key] = mergePatch(targetObject, patchObject)
+			continue
+		}
+		merged[key] = value
+	}
+
+	return merged
+}
+
+// asObject reconoce un objeto JSON tanto decodificado (map[string]interface{}) como JSONB
+func asObject(value interface{}) (map[string]interface{}, bool) {
+	switch object := value.(type) {
+	case map[string]interface{}:
+		return object, true
+	case JSONB:
+		return object, true
+	}
+	return nil, false
+}
+
 // Conversation representa una conversación
 type Conversation struct {
-	ID        string             `json:"id" db:"id"`
-	UserID    string             `json:"user_id" db:"user_id"`
-	Channel   Channel            `json:"channel" db:"channel"`
-	Status    ConversationStatus `json:"status" db:"status"`
-	CreatedAt time.Time          `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at" db:"updated_at"`
-	Messages  []Message          `json:"messages,omitempty" db:"-"`
+	ID              string                 `json:"id" db:"id"`
+	Reference       string                 `json:"reference,omitempty" db:"reference"` // Referencia legible para el cliente, p. ej. WEB-001234
+	UserID          string                 `json:"user_id" db:"user_id"`
+	Channel         Channel                `json:"channel" db:"channel"`
+	Status          ConversationStatus     `json:"status" db:"status"`
+	CreatedAt       time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time              `json:"updated_at" db:"updated_at"`
+	MessageCount    int64                  `json:"message_count" db:"message_count"`
+	StatusChangedAt *time.Time             `json:"status_changed_at,omitempty" db:"status_changed_at"` // Último cambio de estado, para limitar su frecuencia
+	Tags            []string               `json:"tags,omitempty" db:"tags"`                           // Etiquetas normalizadas, sin duplicados
+	Metadata        JSONB                  `json:"metadata,omitempty" db:"metadata"`                   // Datos libres del integrador, p. ej. teléfono o ID del CRM
+	ReadState       *ConversationReadState `json:"read_state,omitempty" db:"-"`
+	Lock            *ConversationLock      `json:"lock,omitempty" db:"-"` // Agente que la está atendiendo, si alguno
+	LastMessage     *Message               `json:"last_message,omitempty" db:"-"` // Último mensaje visible, como vista previa en los listados
+	UnreadCount     int                    `json:"unread_count" db:"-"`                // Solo en los listados: mensajes de otros que el usuario aún no leyó
+	Messages        []Message              `json:"messages,omitempty" db:"-"`
+}
+
+// ConversationLock es el bloqueo blando con el que un agente indica que está atendiendo la conversación.
+// Caduca solo, de modo que un agente que se desconecta no la retiene indefinidamente
+type ConversationLock struct {
+	ConversationID string    `json:"conversation_id"`
+	AgentID        string    `json:"agent_id"`
+	ExpiresAt      time.Time `json:"expires_at"`
 }
 
 // Message representa un mensaje
 type Message struct {
-	ID             string      `json:"id" db:"id"`
-	ConversationID string      `json:"conversation_id" db:"conversation_id"`
-	SenderType     SenderType  `json:"sender_type" db:"sender_type"`
-	SenderID       string      `json:"sender_id" db:"sender_id"`
-	Content        string      `json:"content" db:"content"`
-	ContentType    ContentType `json:"content_type" db:"content_type"`
-	Metadata       JSONB       `json:"metadata" db:"metadata"`
-	Timestamp      time.Time   `json:"timestamp" db:"timestamp"`
-	Attachments    []Attachment `json:"attachments,omitempty" db:"-"`
+	ID               string         `json:"id" db:"id"`
+	ConversationID   string         `json:"conversation_id" db:"conversation_id"`
+	SenderType       SenderType     `json:"sender_type" db:"sender_type"`
+	SenderID         string         `json:"sender_id" db:"sender_id"`
+	Content          string         `json:"content" db:"content"`
+	ContentType      ContentType    `json:"content_type" db:"content_type"`
+	Metadata         JSONB          `json:"metadata" db:"metadata"`
+	Timestamp        time.Time      `json:"timestamp" db:"timestamp"`
+	DeliveryStatus   DeliveryStatus `json:"delivery_status,omitempty" db:"delivery_status"`
+	DeliveryAttempts int            `json:"delivery_attempts,omitempty" db:"delivery_attempts"`
+	NextRetryAt      *time.Time     `json:"next_retry_at,omitempty" db:"next_retry_at"`
+	ExpiresAt        *time.Time     `json:"expires_at,omitempty" db:"expires_at"`
+	PinnedAt         *time.Time     `json:"pinned_at,omitempty" db:"pinned_at"` // Presente solo en los mensajes fijados
+	EditedAt         *time.Time     `json:"edited_at,omitempty" db:"edited_at"`   // Presente solo en los mensajes editados
+	DeletedAt        *time.Time     `json:"deleted_at,omitempty" db:"deleted_at"` // Presente solo en los mensajes borrados
+	ReplyToID        *string        `json:"reply_to_id,omitempty" db:"reply_to_id"` // Mensaje de la misma conversación al que responde
+	Attachments      []Attachment   `json:"attachments,omitempty" db:"-"`
+	ReadBy           []MessageRead  `json:"read_by,omitempty" db:"-"`
+	Read             *bool          `json:"read,omitempty" db:"-"` // Si el usuario que lista los mensajes ya leyó este
+	ReactionCounts   map[string]int `json:"reaction_counts,omitempty" db:"-"` // Reacciones por emoji; el detalle está en /messages/{id}/reactions
+	ReplyCount       int            `json:"reply_count,omitempty" db:"-"`     // Respuestas directas; el hilo está en /messages/{id}/thread
+}
+
+// MessageRead es el acuse de lectura de un mensaje por un usuario
+type MessageRead struct {
+	MessageID string    `json:"message_id" db:"message_id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	ReadAt    time.Time `json:"read_at" db:"read_at"`
 }
 
 // Attachment representa un archivo adjunto
 type Attachment struct {
-	ID        string         `json:"id" db:"id"`
-	MessageID string         `json:"message_id" db:"message_id"`
-	URL       string         `json:"url" db:"url"`
-	Type      AttachmentType `json:"type" db:"type"`
-	Size      int64          `json:"size" db:"size"`
-	Filename  string         `json:"filename" db:"filename"`
-	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+	ID             string         `json:"id" db:"id"`
+	MessageID      string         `json:"message_id" db:"message_id"`
+	ConversationID string         `json:"conversation_id,omitempty" db:"-"` // Solo en consultas que abarcan varias conversaciones
+	URL            string         `json:"url" db:"url"`
+	Type           AttachmentType `json:"type" db:"type"`
+	Size           int64          `json:"size" db:"size"`
+	Filename       string         `json:"filename" db:"filename"`
+	ThumbnailURL   string         `json:"thumbnail_url,omitempty" db:"thumbnail_url"` // Solo en imágenes
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+}
+
+// Reaction representa una reacción (emoji) de un usuario a un mensaje
+type Reaction struct {
+	ID        string    `json:"id" db:"id"`
+	MessageID string    `json:"message_id" db:"message_id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	Emoji     string    `json:"emoji" db:"emoji"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ScheduledMessageStatus es el estado de un envío programado
+type ScheduledMessageStatus string
+
+const (
+	ScheduledMessageStatusScheduled ScheduledMessageStatus = "scheduled"
+	ScheduledMessageStatusSending   ScheduledMessageStatus = "sending"
+	ScheduledMessageStatusSent      ScheduledMessageStatus = "sent"
+	ScheduledMessageStatusFailed    ScheduledMessageStatus = "failed"
+	ScheduledMessageStatusCancelled ScheduledMessageStatus = "cancelled"
+)
+
+// MetadataScheduledMessageID es la clave de metadatos que enlaza el mensaje enviado con su envío programado
+const MetadataScheduledMessageID = "scheduled_message_id"
+
+// ScheduledMessage es un mensaje que se enviará por el flujo normal cuando llegue ScheduledAt
+type ScheduledMessage struct {
+	ID             string                 `json:"id" db:"id"`
+	ConversationID string                 `json:"conversation_id" db:"conversation_id"`
+	SenderType     SenderType             `json:"sender_type" db:"sender_type"`
+	SenderID       string                 `json:"sender_id" db:"sender_id"`
+	Content        string                 `json:"content" db:"content"`
+	ContentType    ContentType            `json:"content_type" db:"content_type"`
+	Metadata       JSONB                  `json:"metadata,omitempty" db:"metadata"`
+	Attachments    []Attachment           `json:"attachments,omitempty" db:"attachments"` // Archivos ya subidos, sin ID hasta el envío
+	ReplyToID      *string                `json:"reply_to_id,omitempty" db:"reply_to_id"`
+	ExpiresAt      *time.Time             `json:"expires_at,omitempty" db:"expires_at"`
+	ScheduledAt    time.Time              `json:"scheduled_at" db:"scheduled_at"`
+	Status         ScheduledMessageStatus `json:"status" db:"status"`
+	MessageID      *string                `json:"message_id,omitempty" db:"message_id"` // El mensaje enviado
+	Error          string                 `json:"error,omitempty" db:"error"`           // Por qué falló el envío
+	CreatedAt      time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at" db:"updated_at"`
+}
+
+// MessageDraft es el borrador privado de un usuario en una conversación; hay como mucho uno por usuario
+type MessageDraft struct {
+	ConversationID string    `json:"conversation_id" db:"conversation_id"`
+	UserID         string    `json:"user_id" db:"user_id"`
+	Content        string    `json:"content" db:"content"`
+	Metadata       JSONB     `json:"metadata,omitempty" db:"metadata"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ConversationShareLink delega el acceso de solo lectura a una conversación a quien tenga su token, sin
+// ser participante, hasta que caduca o se revoca
+type ConversationShareLink struct {
+	ID             string     `json:"id" db:"id"`
+	ConversationID string     `json:"conversation_id" db:"conversation_id"`
+	CreatedBy      string     `json:"created_by" db:"created_by"`
+	ReadOnly       bool       `json:"read_only" db:"read_only"`
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	Token          string     `json:"token,omitempty" db:"-"` // Solo se devuelve al crear el enlace
+}
+
+// ParticipantRole es el papel de un participante en la conversación
+type ParticipantRole string
+
+const (
+	ParticipantRoleMember ParticipantRole = "member"
+	ParticipantRoleAgent  ParticipantRole = "agent"
+)
+
+// IsValid indica si el rol es uno de los admitidos
+func (r ParticipantRole) IsValid() bool {
+	return r == ParticipantRoleMember || r == ParticipantRoleAgent
+}
+
+// ConversationParticipant es un usuario, además del propietario, con acceso a la conversación
+type ConversationParticipant struct {
+	ConversationID string          `json:"conversation_id" db:"conversation_id"`
+	UserID         string          `json:"user_id" db:"user_id"`
+	Role           ParticipantRole `json:"role" db:"role"`
+	JoinedAt       time.Time       `json:"joined_at" db:"joined_at"`
+	LastReadAt     *time.Time      `json:"last_read_at,omitempty" db:"last_read_at"`
+}
+
+// SharedConversation es la vista de solo lectura que se sirve a través de un enlace compartido
+type SharedConversation struct {
+	Conversation Conversation `json:"conversation"`
+	Messages     []Message    `json:"messages"`
+	ReadOnly     bool         `json:"read_only"`
+	ExpiresAt    time.Time    `json:"expires_at"`
+}
+
+// MessageThread es un mensaje con sus respuestas directas en orden cronológico
+type MessageThread struct {
+	Parent  Message   `json:"parent"`
+	Replies []Message `json:"replies"`
+}
+
+// ConversationReadState resume cuántos participantes leyeron hasta el último mensaje
+type ConversationReadState struct {
+	Participants int `json:"participants"`
+	ReadLatest   int `json:"read_latest"`
+}
+
+// ConversationMessageCount es el número de mensajes visibles de una conversación
+type ConversationMessageCount struct {
+	ConversationID string `json:"conversation_id"`
+	Count          int64  `json:"count"`
+}
+
+// ReactionGroup agrupa las reacciones de un mensaje por emoji
+type ReactionGroup struct {
+	Emoji   string   `json:"emoji"`
+	Count   int      `json:"count"`
+	UserIDs []string `json:"user_ids"`
+}
+
+// TemplateMessage es un mensaje guionizado dentro de una plantilla; Content admite variables {{nombre}}
+type TemplateMessage struct {
+	SenderType  SenderType  `json:"sender_type"`
+	ContentType ContentType `json:"content_type"`
+	Content     string      `json:"content"`
+	Metadata    JSONB       `json:"metadata,omitempty"`
+}
+
+// ConversationTemplate representa un flujo de mensajes con el que se inicia una conversación
+type ConversationTemplate struct {
+	ID          string            `json:"id" db:"id"`
+	Name        string            `json:"name" db:"name"`
+	Description string            `json:"description" db:"description"`
+	Channel     Channel           `json:"channel" db:"channel"`
+	Messages    []TemplateMessage `json:"messages" db:"messages"`
+	CreatedBy   string            `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// ConversationWebhook es un endpoint externo que recibe los eventos de una conversación a los que está suscrito
+type ConversationWebhook struct {
+	ID             string    `json:"id" db:"id"`
+	ConversationID string    `json:"conversation_id" db:"conversation_id"`
+	URL            string    `json:"url" db:"url"`
+	EventTypes     []string  `json:"event_types" db:"event_types"`
+	Secret         string    `json:"secret,omitempty" db:"secret"` // Clave HMAC de las entregas; solo se devuelve al crear el webhook
+	CreatedBy      string    `json:"created_by" db:"created_by"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// WebhookDeliveryStatus es el resultado de un intento de entrega a un webhook
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery registra un intento de entrega de un evento a un webhook de conversación, con el cuerpo
+// enviado para poder reenviarlo
+type WebhookDelivery struct {
+	ID             string                `json:"id" db:"id"`
+	WebhookID      string                `json:"webhook_id" db:"webhook_id"`
+	ConversationID string                `json:"conversation_id" db:"conversation_id"`
+	EventType      string                `json:"event_type" db:"event_type"`
+	URL            string                `json:"url" db:"url"`
+	Payload        JSONB                 `json:"payload" db:"payload"`
+	Attempt        int                   `json:"attempt" db:"attempt"`
+	Status         WebhookDeliveryStatus `json:"status" db:"status"`
+	StatusCode     *int                  `json:"status_code,omitempty" db:"status_code"` // Nulo si no hubo respuesta
+	ResponseTimeMs int64                 `json:"response_time_ms" db:"response_time_ms"`
+	Error          string                `json:"error,omitempty" db:"error"`
+	ReplayOf       *string               `json:"replay_of,omitempty" db:"replay_of"` // Entrega original de la que este intento es un reenvío
+	CreatedAt      time.Time             `json:"created_at" db:"created_at"`
+}
+
+// OutboxEvent es un evento guardado en event_outbox en la misma transacción que el cambio que lo produce.
+// Queda pendiente hasta que se publica; el relay reintenta los que fallan hasta agotar los intentos
+type OutboxEvent struct {
+	ID             string          `json:"id" db:"id"`
+	EventType      string          `json:"event_type" db:"event_type"`
+	ConversationID string          `json:"conversation_id" db:"conversation_id"`
+	Payload        json.RawMessage `json:"payload" db:"payload"` // El MessageEvent que se publica
+	Attempts       int             `json:"attempts" db:"attempts"`
+	LastError      string          `json:"last_error,omitempty" db:"last_error"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// ByteRange es un rango de bytes recibido en una subida por partes, con End inclusivo como en Content-Range
+type ByteRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// UploadSession representa el estado de una subida reanudable
+type UploadSession struct {
+	ID             string      `json:"id"`
+	UserID         string      `json:"user_id"`
+	Filename       string      `json:"filename"`
+	TotalSize      int64       `json:"total_size"`
+	ReceivedRanges []ByteRange `json:"received_ranges"`
+	NextOffset     int64       `json:"next_offset"`
+	CreatedAt      time.Time   `json:"created_at"`
+	ExpiresAt      time.Time   `json:"expires_at"`
 }
 
 // MessageEvent representa un evento de mensaje para pub/sub
 type MessageEvent struct {
-	Type           string      `json:"type"`
-	ConversationID string      `json:"conversation_id"`
-	Message        Message     `json:"message"`
-	Timestamp      time.Time   `json:"timestamp"`
+	Type           string    `json:"type"`
+	ConversationID string    `json:"conversation_id"`
+	Message        Message   `json:"message"`
+	UserID         string    `json:"user_id,omitempty"` // Usuario que provocó el evento, p. ej. quien leyó el mensaje
+	Data           JSONB     `json:"data,omitempty"`    // Datos propios del tipo de evento
+	Metadata       JSONB     `json:"metadata,omitempty"` // Contexto común a todos los tipos, p. ej. correlation_id
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// TypingRole distingue si quien escribe es el cliente o un agente de la bandeja compartida
+type TypingRole string
+
+const (
+	TypingRoleCustomer TypingRole = "customer"
+	TypingRoleAgent    TypingRole = "agent"
+)
+
+// TypingEvent indica que un usuario empezó o dejó de escribir en una conversación. Es efímero: no se
+// guarda y los clientes lo descartan a partir de ExpiresAt si no llega otro
+type TypingEvent struct {
+	Type           string     `json:"type"` // Siempre "typing"
+	ConversationID string     `json:"conversation_id"`
+	UserID         string     `json:"user_id"`
+	Role           TypingRole `json:"role"`
+	IsTyping       bool       `json:"is_typing"`
+	ExpiresAt      time.Time  `json:"expires_at"`
 }
 
 // AuditLog representa un registro de auditoría
@@ -144,11 +534,37 @@ type AuditLog struct {
 	CreatedAt time.Time              `json:"created_at" db:"created_at"`
 }
 
+// UserDataPurge resume los datos eliminados al purgar un usuario
+type UserDataPurge struct {
+	UserID            string   `json:"user_id"`
+	Conversations     int64    `json:"conversations"`
+	Messages          int64    `json:"messages"`
+	Attachments       int64    `json:"attachments"`
+	Reactions         int64    `json:"reactions"`          // Reacciones del usuario, también en mensajes ajenos
+	ScheduledMessages int64    `json:"scheduled_messages"` // Envíos programados del usuario o de sus conversaciones
+	Drafts            int64    `json:"drafts"`             // Borradores del usuario, también en conversaciones ajenas
+	Files             int64    `json:"files"`
+	FailedFiles       []string `json:"failed_files,omitempty"` // Archivos que no se pudieron borrar y quedan para limpieza manual
+	ConversationIDs   []string `json:"-"`
+	AttachmentURLs    []string `json:"-"`
+}
+
+// OwnershipTransfer informa del progreso de una transferencia de conversaciones entre propietarios
+type OwnershipTransfer struct {
+	FromUserID  string `json:"from_user_id"`
+	ToUserID    string `json:"to_user_id"`
+	Transferred int    `json:"transferred"` // Conversaciones reasignadas en esta llamada
+	Batches     int    `json:"batches"`
+	Remaining   int64  `json:"remaining"` // Conversaciones pendientes; si es mayor que cero se puede repetir la llamada
+	Completed   bool   `json:"completed"`
+}
+
 // APIResponse estructura estándar para respuestas de API
 type APIResponse struct {
-	Code    string      `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data"`
+	Code       string      `json:"code"`
+	Message    string      `json:"message"`
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty"` // Cursor de la página siguiente en los listados paginados por cursor
 }
 
 // HealthStatus representa el estado de salud del servicio
@@ -159,4 +575,40 @@ type HealthStatus struct {
 	Service   string                 `json:"service"`
 	Version   string                 `json:"version"`
 	Checks    map[string]interface{} `json:"checks,omitempty"`
-}
\ No newline at end of file
+}
+// MessageCursor es la posición de un mensaje en el orden de la conversación. El id desempata los
+// mensajes con el mismo timestamp, de modo que ninguno se salta ni se repite entre páginas
+type MessageCursor struct {
+	Timestamp time.Time
+	ID        string
+}
+
+// NewMessageCursor devuelve el cursor que apunta justo después del mensaje
+func NewMessageCursor(message Message) MessageCursor {
+	return MessageCursor{Timestamp: message.Timestamp, ID: message.ID}
+}
+
+// Encode serializa el cursor como un token opaco apto para una URL
+func (c MessageCursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.Timestamp.UTC().Format(time.RFC3339Nano) + "|" + c.ID))
+}
+
+// DecodeMessageCursor interpreta un token generado por Encode
+func DecodeMessageCursor(token string) (*MessageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	timestamp, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	parsed, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &MessageCursor{Timestamp: parsed, ID: id}, nil
+}
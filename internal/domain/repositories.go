@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"time"
 )
 
 // Messaging repositories
@@ -10,18 +11,104 @@ import (
 type ConversationRepository interface {
 	Create(ctx context.Context, conversation *Conversation) error
 	GetByID(ctx context.Context, id string) (*Conversation, error)
+	GetByReference(ctx context.Context, reference string) (*Conversation, error)
 	GetByUserID(ctx context.Context, userID string, filters ConversationFilters) ([]Conversation, error)
+	// SearchByMetadata devuelve las conversaciones cuyos metadatos contienen match y a las que userID tiene
+	// acceso como propietario o participante ("" para cualquiera), de la más reciente a la más antigua
+	SearchByMetadata(ctx context.Context, match JSONB, userID string, limit int, offset int) ([]Conversation, error)
 	Update(ctx context.Context, conversation *Conversation) error
 	Delete(ctx context.Context, id string) error
+	// DeleteByUserID borra los datos del usuario en una transacción; beforeCommit se ejecuta antes de confirmarla y, si falla, no se borra nada;
+	// los archivos no los borra: AttachmentURLs lista los que quedan sin referencia al confirmarla
+	DeleteByUserID(ctx context.Context, userID string, beforeCommit func(*UserDataPurge) error) (*UserDataPurge, error)
+	GetAbandonmentCandidates(ctx context.Context, idleSince time.Time, channels []Channel, limit int) ([]Conversation, error)
+	CountCreatedBefore(ctx context.Context, channel Channel, createdBefore time.Time) (int64, error)
+	// ArchiveCreatedBefore archiva hasta limit conversaciones del canal no archivadas creadas antes de createdBefore y devuelve sus IDs
+	ArchiveCreatedBefore(ctx context.Context, channel Channel, createdBefore time.Time, now time.Time, limit int) ([]string, error)
+	// CountStale cuenta las conversaciones activas sin actividad desde olderThan
+	CountStale(ctx context.Context, olderThan time.Time) (int64, error)
+	// ArchiveStale archiva en una sola sentencia las conversaciones activas sin actividad desde olderThan y devuelve cuántas archivó
+	ArchiveStale(ctx context.Context, olderThan time.Time) (int64, error)
+	CreateWithMessages(ctx context.Context, conversation *Conversation, messages []Message) error
+	CountByUserID(ctx context.Context, userID string, filters ConversationFilters) (int64, error)
+	// TransferOwnership reasigna un lote de conversaciones y audita cada una en la misma transacción
+	TransferOwnership(ctx context.Context, fromUserID string, toUserID string, filters ConversationFilters, limit int, requestedBy string) ([]string, error)
+	ReconcileMessageCounts(ctx context.Context, afterID string, limit int) (lastID string, fixedIDs []string, err error)
+	ClaimAutoReply(ctx context.Context, conversationID string, repliedBefore time.Time, now time.Time) (bool, error)
+	// UpdateStatusIfIdle cambia el estado solo si el último cambio es anterior o igual a changedBefore; devuelve false si no se aplicó
+	UpdateStatusIfIdle(ctx context.Context, conversationID string, status ConversationStatus, changedBefore time.Time, now time.Time) (bool, error)
+	// UpdateStatusBulk cambia en una sola sentencia el estado de las conversaciones de ownerID ("" para cualquiera)
+	// cuyo último cambio es anterior o igual a changedBefore, y devuelve el resultado de cada una que existe
+	UpdateStatusBulk(ctx context.Context, conversationIDs []string, status ConversationStatus, ownerID string, changedBefore time.Time, now time.Time) (map[string]ConversationStatusOutcome, error)
+	// NextReferenceNumber devuelve el siguiente número de la secuencia del prefijo; llamadas concurrentes nunca obtienen el mismo
+	NextReferenceNumber(ctx context.Context, prefix string) (int64, error)
+	// AddTagToConversations etiqueta en una transacción las conversaciones de ownerID ("" para cualquiera) y devuelve,
+	// por cada una que existe, si se etiquetó ahora (true) o ya tenía la etiqueta (false)
+	AddTagToConversations(ctx context.Context, conversationIDs []string, tag string, ownerID string) (map[string]bool, error)
+	// RemoveTag quita la etiqueta de la conversación; devuelve false si no la tenía
+	RemoveTag(ctx context.Context, conversationID string, tag string) (bool, error)
+	// TouchUpdatedAt marca la conversación como actualizada en updatedAt
+	TouchUpdatedAt(ctx context.Context, conversationID string, updatedAt time.Time) error
+	// UpdateMetadata sustituye los metadatos por lo que devuelve update a partir de los actuales, con la fila
+	// bloqueada para no perder cambios concurrentes; si update falla no se guarda nada
+	UpdateMetadata(ctx context.Context, conversationID string, update func(metadata JSONB) (JSONB, error)) (JSONB, error)
+}
+
+// TxManager ejecuta varias operaciones de repositorio como una sola transacción
+type TxManager interface {
+	// WithinTransaction ejecuta fn con un contexto que lleva la transacción y la confirma si fn no falla;
+	// las llamadas anidadas se unen a la transacción exterior
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 // MessageRepository define las operaciones para mensajes
 type MessageRepository interface {
 	Create(ctx context.Context, message *Message) error
+	// CreateWithAttachments guarda el mensaje y message.Attachments en una sola transacción: o todo o nada
+	CreateWithAttachments(ctx context.Context, message *Message) error
 	GetByID(ctx context.Context, id string) (*Message, error)
 	GetByConversationID(ctx context.Context, conversationID string, pagination PaginationParams) ([]Message, error)
+	CountByConversationID(ctx context.Context, conversationID string) (int64, error)
+	// StreamByConversationID llama a fn con cada mensaje visible de la conversación en orden cronológico, sin
+	// cargarlos todos en memoria, y omite los remitentes de excludeSenderTypes. Se detiene en el primer error de fn
+	StreamByConversationID(ctx context.Context, conversationID string, excludeSenderTypes []SenderType, fn func(message *Message) error) error
+	GetAround(ctx context.Context, target *Message, before int, after int) ([]Message, error)
+	GetPendingRetries(ctx context.Context, now time.Time, maxAttempts int, limit int) ([]Message, error)
+	GetExpired(ctx context.Context, now time.Time, limit int) ([]Message, error)
+	GetByProviderMessageID(ctx context.Context, channel Channel, providerMessageID string) (*Message, error)
+	// GetByConversationProviderMessageID busca el mensaje de la conversación con ese provider_message_id,
+	// que es único dentro de cada conversación
+	GetByConversationProviderMessageID(ctx context.Context, conversationID string, providerMessageID string) (*Message, error)
+	// GetReplies devuelve las respuestas directas al mensaje en orden cronológico
+	GetReplies(ctx context.Context, parentID string) ([]Message, error)
+	// CountReplies devuelve el número de respuestas directas de cada mensaje; los mensajes sin respuestas no aparecen
+	CountReplies(ctx context.Context, messageIDs []string) (map[string]int, error)
+	// GetPinned devuelve hasta limit mensajes fijados de la conversación, por pinned_at
+	GetPinned(ctx context.Context, conversationID string, limit int) ([]Message, error)
+	// Search devuelve los mensajes de las conversaciones del usuario cuyo contenido coincide con query
+	// (búsqueda de texto completo, sin distinguir mayúsculas), de más relevante a menos
+	Search(ctx context.Context, userID string, query string, pagination PaginationParams) ([]Message, error)
+	// SetPinned fija el mensaje en pinnedAt, o lo desfija con nil; fijar de nuevo conserva el pinned_at original
+	SetPinned(ctx context.Context, id string, pinnedAt *time.Time) error
+	// UpdateDeliveryStatus cambia el estado de entrega solo si sigue siendo from; devuelve false si otro cambio se adelantó
+	UpdateDeliveryStatus(ctx context.Context, id string, from DeliveryStatus, to DeliveryStatus) (bool, error)
 	Update(ctx context.Context, message *Message) error
+	// Delete marca el mensaje como borrado (deleted_at); deja de leerse salvo con PaginationParams.IncludeDeleted
 	Delete(ctx context.Context, id string) error
+	// Purge elimina físicamente el mensaje y sus adjuntos, p. ej. al vencer un mensaje efímero
+	Purge(ctx context.Context, id string) error
+}
+
+// MessageReadRepository define las operaciones para acuses de lectura por mensaje
+type MessageReadRepository interface {
+	// MarkRead registra en lote los acuses del usuario sobre mensajes de la conversación y devuelve los IDs que no estaban leídos
+	MarkRead(ctx context.Context, conversationID string, userID string, messageIDs []string, readAt time.Time) ([]string, error)
+	// MarkReadUpTo registra los acuses del usuario sobre todos los mensajes de la conversación hasta upToMessageID
+	// inclusive y devuelve los IDs que no estaban leídos
+	MarkReadUpTo(ctx context.Context, conversationID string, userID string, upToMessageID string, readAt time.Time) ([]string, error)
+	// GetReadStatus devuelve cuándo leyó el usuario cada mensaje de la conversación, indexado por ID de mensaje
+	GetReadStatus(ctx context.Context, conversationID string, userID string) (map[string]time.Time, error)
+	GetByMessageIDs(ctx context.Context, messageIDs []string) (map[string][]MessageRead, error)
 }
 
 // AttachmentRepository define las operaciones para archivos adjuntos
@@ -29,23 +116,149 @@ type AttachmentRepository interface {
 	Create(ctx context.Context, attachment *Attachment) error
 	GetByID(ctx context.Context, id string) (*Attachment, error)
 	GetByMessageID(ctx context.Context, messageID string) ([]Attachment, error)
+	GetByUserID(ctx context.Context, userID string, attachmentType AttachmentType, pagination PaginationParams) ([]Attachment, error)
+	// SearchByFilename devuelve los adjuntos de la conversación cuyo nombre de archivo contiene query, sin distinguir mayúsculas
+	SearchByFilename(ctx context.Context, conversationID string, query string, pagination PaginationParams) ([]Attachment, error)
+	Delete(ctx context.Context, id string) error
+	// SaveThumbnail guarda la miniatura generada para el archivo subido url: la completa en los adjuntos que ya
+	// lo usan sin miniatura y en los que se creen después
+	SaveThumbnail(ctx context.Context, userID string, url string, thumbnailURL string) error
+}
+
+// ReactionRepository define las operaciones para reacciones
+type ReactionRepository interface {
+	// Add guarda la reacción si el usuario no tenía ya ese emoji en el mensaje; en ese caso completa reaction
+	// con la existente y devuelve false
+	Add(ctx context.Context, reaction *Reaction) (bool, error)
+	// Remove borra el emoji del usuario en el mensaje; devuelve false si no lo tenía
+	Remove(ctx context.Context, messageID string, userID string, emoji string) (bool, error)
+	GetByMessageID(ctx context.Context, messageID string) ([]Reaction, error)
+	// CountByMessageIDs devuelve por mensaje el número de reacciones de cada emoji; los mensajes sin reacciones no aparecen
+	CountByMessageIDs(ctx context.Context, messageIDs []string) (map[string]map[string]int, error)
+}
+
+// ParticipantRepository define las operaciones sobre los participantes de una conversación y su marca de lectura
+type ParticipantRepository interface {
+	MarkRead(ctx context.Context, conversationID string, userID string, readAt time.Time) error
+	GetReadStates(ctx context.Context, conversationIDs []string) (map[string]ConversationReadState, error)
+	// FilterParticipants devuelve los usuarios de userIDs que participan en la conversación
+	FilterParticipants(ctx context.Context, conversationID string, userIDs []string) ([]string, error)
+	// AddParticipant añade al usuario o, si ya participa, actualiza su rol; completa participant con lo guardado
+	AddParticipant(ctx context.Context, participant *ConversationParticipant) error
+	// RemoveParticipant devuelve ErrNotFound si el usuario no participa en la conversación
+	RemoveParticipant(ctx context.Context, conversationID string, userID string) error
+	ListParticipants(ctx context.Context, conversationID string) ([]ConversationParticipant, error)
+}
+
+// DraftRepository define las operaciones sobre los borradores de mensaje, uno por conversación y usuario
+type DraftRepository interface {
+	Upsert(ctx context.Context, draft *MessageDraft) error
+	Get(ctx context.Context, conversationID string, userID string) (*MessageDraft, error)
+	Delete(ctx context.Context, conversationID string, userID string) error
+}
+
+// ScheduledMessageRepository define las operaciones sobre los envíos programados
+type ScheduledMessageRepository interface {
+	Create(ctx context.Context, message *ScheduledMessage) error
+	GetByID(ctx context.Context, id string) (*ScheduledMessage, error)
+	// GetDue devuelve hasta limit envíos en estado scheduled cuya hora ya llegó, los más antiguos primero
+	GetDue(ctx context.Context, now time.Time, limit int) ([]ScheduledMessage, error)
+	// UpdateStatus guarda status, message_id y error del envío solo si sigue en from; devuelve false si otro
+	// cambio se adelantó, p. ej. otra instancia lo reclamó o se canceló
+	UpdateStatus(ctx context.Context, message *ScheduledMessage, from ScheduledMessageStatus) (bool, error)
+	// ReleaseStale recupera los envíos que siguen en sending desde antes de staleBefore porque la instancia que
+	// los reclamó cayó: pasan a sent si su mensaje llegó a guardarse y si no vuelven a scheduled. Devuelve
+	// cuántos recuperó
+	ReleaseStale(ctx context.Context, staleBefore time.Time, now time.Time) (int64, error)
+}
+
+// ShareLinkRepository define las operaciones sobre los enlaces compartidos de las conversaciones
+type ShareLinkRepository interface {
+	Create(ctx context.Context, link *ConversationShareLink) error
+	GetByID(ctx context.Context, id string) (*ConversationShareLink, error)
+	ListByConversation(ctx context.Context, conversationID string) ([]ConversationShareLink, error)
+	// Revoke marca el enlace de la conversación como revocado; ErrNotFound si no existe o ya lo estaba
+	Revoke(ctx context.Context, conversationID string, id string, revokedAt time.Time) error
+}
+
+// ConversationTemplateRepository define las operaciones para plantillas de conversación
+type ConversationTemplateRepository interface {
+	Create(ctx context.Context, template *ConversationTemplate) error
+	GetByID(ctx context.Context, id string) (*ConversationTemplate, error)
+	List(ctx context.Context, pagination PaginationParams) ([]ConversationTemplate, error)
+	Update(ctx context.Context, template *ConversationTemplate) error
 	Delete(ctx context.Context, id string) error
 }
 
+// WebhookRepository define las operaciones para webhooks de conversación
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *ConversationWebhook) error
+	GetByID(ctx context.Context, id string) (*ConversationWebhook, error)
+	GetByConversationID(ctx context.Context, conversationID string) ([]ConversationWebhook, error)
+	GetSubscribed(ctx context.Context, conversationID string, eventType string) ([]ConversationWebhook, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// WebhookDeliveryRepository define las operaciones para el registro de entregas a webhooks
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, delivery *WebhookDelivery) error
+	GetByID(ctx context.Context, id string) (*WebhookDelivery, error)
+	// List devuelve las entregas más recientes primero
+	List(ctx context.Context, filters WebhookDeliveryFilters) ([]WebhookDelivery, error)
+	// DeleteCreatedBefore elimina como mucho limit intentos anteriores a before y devuelve cuántos eliminó
+	DeleteCreatedBefore(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+// OutboxRepository define las operaciones sobre los eventos pendientes de publicar de event_outbox
+type OutboxRepository interface {
+	Create(ctx context.Context, event *OutboxEvent) error
+	// ClaimPending reclama como mucho limit eventos sin publicar con next_attempt_at anterior a now y menos
+	// de maxAttempts intentos, los más antiguos primero, aplazando su next_attempt_at hasta leaseUntil. Otro
+	// relay no los ve hasta que el lease vence, así que si quien los reclamó cae se vuelven a publicar
+	ClaimPending(ctx context.Context, now time.Time, leaseUntil time.Time, maxAttempts int, limit int) ([]OutboxEvent, error)
+	MarkDelivered(ctx context.Context, id string, deliveredAt time.Time) error
+	// MarkFailed suma un intento, guarda el error y aplaza el siguiente hasta nextAttemptAt
+	MarkFailed(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error
+	// CountUndelivered devuelve cuántos eventos siguen pendientes y cuántos agotaron maxAttempts
+	CountUndelivered(ctx context.Context, maxAttempts int) (pending int, dead int, err error)
+	// DeleteDeliveredBefore elimina como mucho limit eventos publicados antes de before y devuelve cuántos eliminó
+	DeleteDeliveredBefore(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
 // ConversationFilters para filtrar conversaciones
 type ConversationFilters struct {
-	Channel Channel
-	Status  ConversationStatus
-	Limit   int
-	Offset  int
+	Channel          Channel
+	Status           ConversationStatus
+	Limit            int
+	Offset           int
+	IncludeReadState bool     // Agrega el estado de lectura de los participantes a cada conversación
+	Tags             []string // Solo conversaciones que tienen todas estas etiquetas (ya normalizadas)
+}
+
+// WebhookDeliveryFilters para consultar el registro de entregas; los campos vacíos no filtran
+type WebhookDeliveryFilters struct {
+	WebhookID      string
+	ConversationID string
+	Status         WebhookDeliveryStatus
+	Limit          int
+	Offset         int
 }
 
 // PaginationParams para paginación
 type PaginationParams struct {
-	Limit  int
-	Offset int
-	SortBy string
-	Order  string
+	Limit         int
+	Offset        int
+	SortBy        string
+	Order         string
+	IncludeReadBy bool // Carga los acuses de lectura de cada mensaje
+
+	ExcludeSenderTypes []SenderType // Omite los mensajes de estos remitentes, p. ej. system
+	PinnedFirst        bool         // Antepone los mensajes fijados; la paginación solo recorre los no fijados
+
+	// Before pagina por cursor: devuelve los mensajes anteriores a él e ignora Offset
+	Before *MessageCursor
+
+	IncludeDeleted bool // Incluye los mensajes borrados; solo para administradores
 }
 
 // UserRepository define las operaciones de persistencia para usuarios
@@ -63,6 +276,17 @@ type AuditRepository interface {
 	Create(ctx context.Context, log *AuditLog) error
 	GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*AuditLog, error)
 	GetByAction(ctx context.Context, action string, limit, offset int) ([]*AuditLog, error)
+	List(ctx context.Context, filters AuditLogFilters) ([]*AuditLog, error)
+}
+
+// AuditLogFilters para consultar la auditoría; los campos vacíos no filtran. From es inclusivo y To exclusivo
+type AuditLogFilters struct {
+	UserID string
+	Action string
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Offset int
 }
 
 // HealthRepository define las operaciones para health checks
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// AccessTokenResponse es el token de acceso emitido a partir de un token de refresco
+type AccessTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"` // Segundos de validez del token de acceso
+}
+
+// RefreshToken godoc
+// @Summary Renueva el token de acceso
+// @Description Emite un token de acceso nuevo a partir de un token de refresco. No requiere JWT: el token de refresco es la credencial. Un token de acceso, caducado o revocado devuelve 401
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RefreshTokenRequest true "Token de refresco"
+// @Success 200 {object} domain.APIResponse{data=AccessTokenResponse}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /auth/refresh [post]
+func (h *MessagingHandler) RefreshToken(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	accessToken, err := h.jwtManager.RefreshToken(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		h.respondWithTokenError(c, err, "Failed to refresh token")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Token refreshed successfully", AccessTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(h.jwtManager.AccessTokenExpiry().Seconds()),
+	})
+}
+
+// Logout godoc
+// @Summary Cierra la sesión
+// @Description Revoca el token de refresco para que no pueda emitir más tokens de acceso. Los de acceso ya emitidos siguen valiendo hasta caducar
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RefreshTokenRequest true "Token de refresco"
+// @Success 200 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Failure 503 {object} domain.APIResponse
+// @Router /auth/logout [post]
+func (h *MessagingHandler) Logout(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	if err := h.jwtManager.RevokeToken(c.Request.Context(), req.RefreshToken); err != nil {
+		h.respondWithTokenError(c, err, "Failed to revoke token")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Logged out successfully", nil)
+}
+
+func (h *MessagingHandler) respondWithTokenError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidToken), errors.Is(err, auth.ErrInvalidTokenType), errors.Is(err, auth.ErrTokenRevoked):
+		h.respondWithError(c, http.StatusUnauthorized, "INVALID_TOKEN", err.Error())
+	case errors.Is(err, auth.ErrRevocationUnavailable):
+		h.respondWithError(c, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Token revocation is not available")
+	default:
+		h.log(c).Error(fallback, err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", fallback)
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type AcquireConversationLockRequest struct {
+	TTLSeconds int64 `json:"ttl_seconds,omitempty"` // 0 usa CONVERSATION_LOCK_DEFAULT_TTL
+}
+
+// AcquireConversationLock godoc
+// @Summary Bloquea la conversación para el agente
+// @Description Marca la conversación como atendida por el usuario autenticado hasta que caduque o la libere; si el bloqueo ya es suyo lo renueva. Es un bloqueo blando: avisa a los demás agentes, que lo ven en GET /conversations/{id}, pero no les impide escribir
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param request body AcquireConversationLockRequest false "Duración del bloqueo"
+// @Success 200 {object} domain.APIResponse{data=domain.ConversationLock}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 409 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/lock [post]
+func (h *MessagingHandler) AcquireConversationLock(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	var req AcquireConversationLockRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+	}
+
+	lock, err := h.messagingService.AcquireConversationLock(c.Request.Context(), c.Param("id"), userID, time.Duration(req.TTLSeconds)*time.Second)
+	if err != nil {
+		h.respondWithLockError(c, err, "Failed to acquire conversation lock")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Conversation lock acquired", lock)
+}
+
+// ReleaseConversationLock godoc
+// @Summary Libera el bloqueo de la conversación
+// @Description Libera el bloqueo del usuario autenticado; liberar una conversación sin bloqueo no es un error y la de otro agente devuelve 409
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Success 200 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 409 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/lock [delete]
+func (h *MessagingHandler) ReleaseConversationLock(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	if err := h.messagingService.ReleaseConversationLock(c.Request.Context(), c.Param("id"), userID); err != nil {
+		h.respondWithLockError(c, err, "Failed to release conversation lock")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Conversation lock released", nil)
+}
+
+func (h *MessagingHandler) respondWithLockError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrConversationLocked):
+		h.respondWithError(c, http.StatusConflict, "CONVERSATION_LOCKED", err.Error())
+	case errors.Is(err, services.ErrInvalidLock):
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+	case errors.Is(err, services.ErrConversationLocksDisabled):
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Conversation locks are not enabled")
+	case errors.Is(err, domain.ErrNotFound):
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Conversation not found")
+	default:
+		h.log(c).Error(message, err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", message)
+	}
+}
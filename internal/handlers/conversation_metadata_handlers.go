@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateConversationMetadata godoc
+// @Summary Actualiza parcialmente los metadatos de una conversación
+// @Description Mezcla el cuerpo en los metadatos de la conversación como JSON Merge Patch (RFC 7386): los objetos anidados se mezclan clave a clave, una clave con null se elimina y el resto de valores, arrays incluidos, se sustituyen. Devuelve los metadatos resultantes
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param request body object true "Cambios sobre los metadatos"
+// @Success 200 {object} domain.APIResponse{data=object}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/metadata [patch]
+func (h *MessagingHandler) UpdateConversationMetadata(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	var patch domain.JSONB
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	metadata, err := h.messagingService.UpdateConversationMetadata(c.Request.Context(), c.Param("id"), patch, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidMetadata):
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		case errors.Is(err, domain.ErrNotFound):
+			h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Conversation not found")
+		default:
+			h.log(c).Error("Failed to update conversation metadata", err)
+			h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update conversation metadata")
+		}
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Conversation metadata updated successfully", metadata)
+}
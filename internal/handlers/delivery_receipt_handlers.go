@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// maxDeliveryReceiptBody limita el tamaño de los acuses que se leen antes de comprobar la firma
+const maxDeliveryReceiptBody = 64 << 10
+
+// HandleDeliveryReceipt godoc
+// @Summary Recibe un acuse de entrega de un proveedor de canal
+// @Description Actualiza el estado de entrega (delivered, read, failed) del mensaje al que el proveedor se refiere por su identificador. Los estados solo avanzan: un acuse fuera de orden se acepta sin cambios (applied=false)
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param X-Webhook-Timestamp header string true "Instante Unix (segundos) en que el proveedor firmó el acuse"
+// @Param X-Webhook-Signature header string true "sha256=<HMAC-SHA256 hex de <timestamp>.<cuerpo> con el secreto del canal>"
+// @Param channel path string true "Canal del proveedor (whatsapp, web, messenger, instagram)"
+// @Param request body services.DeliveryReceipt true "Acuse de entrega"
+// @Success 200 {object} domain.APIResponse{data=services.DeliveryReceiptResult}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /webhooks/{channel}/status [post]
+func (h *MessagingHandler) HandleDeliveryReceipt(c *gin.Context) {
+	channel := c.Param("channel")
+	if !h.receipts.Enabled(channel) {
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Delivery receipts not enabled for channel")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxDeliveryReceiptBody))
+	if err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "Failed to read request body")
+		return
+	}
+
+	if err := h.receipts.Verify(channel, body, c.GetHeader(auth.WebhookTimestampHeader), c.GetHeader(auth.WebhookSignatureHeader)); err != nil {
+		h.log(c).Warn("Rejected delivery receipt", map[string]interface{}{
+			"channel": channel,
+			"error":   err.Error(),
+		})
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid signature")
+		return
+	}
+
+	var receipt services.DeliveryReceipt
+	if err := json.Unmarshal(body, &receipt); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	result, err := h.messagingService.ProcessDeliveryReceipt(c.Request.Context(), domain.Channel(channel), receipt)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidDeliveryReceipt) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Message not found")
+			return
+		}
+		h.log(c).Error("Failed to process delivery receipt", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to process delivery receipt")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Delivery receipt processed", result)
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// SaveDraft godoc
+// @Summary Guarda el borrador del usuario en la conversación
+// @Description Crea o sustituye el borrador privado del usuario autenticado. El borrador se elimina automáticamente al enviar un mensaje en la conversación
+// @Tags drafts
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param request body services.SaveDraftRequest true "Contenido del borrador"
+// @Success 200 {object} domain.APIResponse{data=domain.MessageDraft}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/draft [put]
+func (h *MessagingHandler) SaveDraft(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	var req services.SaveDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	draft, err := h.messagingService.SaveDraft(c.Request.Context(), c.Param("id"), req, userID)
+	if err != nil {
+		h.respondWithDraftError(c, err, "Failed to save draft")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Draft saved successfully", draft)
+}
+
+// GetDraft godoc
+// @Summary Obtiene el borrador del usuario en la conversación
+// @Description Devuelve el borrador privado del usuario autenticado; 404 si no tiene ninguno
+// @Tags drafts
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Success 200 {object} domain.APIResponse{data=domain.MessageDraft}
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/draft [get]
+func (h *MessagingHandler) GetDraft(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	draft, err := h.messagingService.GetDraft(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		h.respondWithDraftError(c, err, "Failed to get draft")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Draft retrieved successfully", draft)
+}
+
+// DeleteDraft godoc
+// @Summary Descarta el borrador del usuario en la conversación
+// @Description Elimina el borrador privado del usuario autenticado; no tener borrador no es un error
+// @Tags drafts
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Success 200 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/draft [delete]
+func (h *MessagingHandler) DeleteDraft(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	if err := h.messagingService.DeleteDraft(c.Request.Context(), c.Param("id"), userID); err != nil {
+		h.respondWithDraftError(c, err, "Failed to delete draft")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Draft deleted successfully", nil)
+}
+
+func (h *MessagingHandler) respondWithDraftError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidDraft):
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+	case errors.Is(err, services.ErrDraftsDisabled):
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Drafts are not enabled")
+	case errors.Is(err, domain.ErrNotFound):
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Draft not found")
+	default:
+		h.log(c).Error(message, err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", message)
+	}
+}
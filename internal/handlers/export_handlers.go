@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// exportContentTypes es el Content-Type de cada formato de exportación
+var exportContentTypes = map[string]string{
+	services.ExportFormatJSON: "application/json; charset=utf-8",
+	services.ExportFormatCSV:  "text/csv; charset=utf-8",
+}
+
+// ExportConversation godoc
+// @Summary Exporta todos los mensajes de una conversación
+// @Description Descarga todos los mensajes en orden cronológico, sin paginar, en JSON (array) o CSV (id, sender_type, sender_id, content, content_type, timestamp). Solo el propietario puede exportarla. Los administradores reciben por defecto la exportación completa; el resto recibe siempre la transcripción redactada: sin mensajes de sistema, con los datos personales enmascarados y con el rol del remitente (customer, agent, bot) en lugar de su ID
+// @Tags conversations
+// @Produce json
+// @Produce text/csv
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param format query string false "json o csv" default(json)
+// @Param redact query bool false "Redactar la exportación; solo un administrador puede pedir false"
+// @Param exclude_sender_types query string false "Remitentes a omitir, separados por comas (user, agent, bot, system)"
+// @Success 200 {array} services.ExportedMessage
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 403 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/export [get]
+func (h *MessagingHandler) ExportConversation(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	format := c.DefaultQuery("format", services.ExportFormatJSON)
+	writer, err := services.NewExportWriter(format, c.Writer)
+	if err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	// Only administrators may get the full internal export; everyone else gets the customer-safe transcript
+	isAdmin := h.hasRole(c, "admin")
+	opts := services.ExportOptions{Redact: !isAdmin}
+	if value := c.Query("redact"); value != "" {
+		redact, err := strconv.ParseBool(value)
+		if err != nil {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "redact must be true or false")
+			return
+		}
+		if !redact && !isAdmin {
+			h.respondWithError(c, http.StatusForbidden, "FORBIDDEN", "Only administrators can export unredacted conversations")
+			return
+		}
+		opts.Redact = redact
+	}
+	for _, senderType := range strings.Split(c.Query("exclude_sender_types"), ",") {
+		if senderType = strings.TrimSpace(senderType); senderType != "" {
+			opts.ExcludeSenderTypes = append(opts.ExcludeSenderTypes, domain.SenderType(senderType))
+		}
+	}
+
+	stream := &httpExportWriter{ExportWriter: writer, c: c, format: format}
+	err = h.messagingService.ExportConversation(c.Request.Context(), c.Param("id"), userID, opts, stream)
+	if err == nil {
+		return
+	}
+
+	// Once the body has started the status can no longer change; the client sees a truncated file
+	if stream.started {
+		h.log(c).Error("Conversation export interrupted", err)
+		c.Abort()
+		return
+	}
+
+	switch {
+	case errors.Is(err, services.ErrInvalidMessageFilter):
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Conversation not found")
+	default:
+		h.log(c).Error("Failed to export conversation", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to export conversation")
+	}
+}
+
+// httpExportWriter pone las cabeceras de la descarga justo antes de empezar a escribir el cuerpo, cuando el
+// servicio ya comprobó el acceso
+type httpExportWriter struct {
+	services.ExportWriter
+	c       *gin.Context
+	format  string
+	started bool
+}
+
+func (w *httpExportWriter) Begin(conversation *domain.Conversation) error {
+	disposition := mime.FormatMediaType("attachment", map[string]string{
+		"filename": "conversation-" + conversation.ID + "." + w.format,
+	})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+
+	w.c.Header("Content-Type", exportContentTypes[w.format])
+	w.c.Header("Content-Disposition", disposition)
+	w.c.Header("X-Content-Type-Options", "nosniff")
+	w.c.Status(http.StatusOK)
+	w.started = true
+
+	return w.ExportWriter.Begin(conversation)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/repositories"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exportMessageRepository sirve los mensajes de la conversación exportada
+type exportMessageRepository struct {
+	domain.MessageRepository
+	messages []domain.Message
+}
+
+func (r *exportMessageRepository) StreamByConversationID(ctx context.Context, conversationID string, excludeSenderTypes []domain.SenderType, fn func(message *domain.Message) error) error {
+	for i := range r.messages {
+		if err := fn(&r.messages[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExportConversation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := logger.NewLogger("debug")
+	conversationID := "4f6b8a52-3c1d-4e7a-9b0f-2d5c6e7f8a91"
+	now := time.Now()
+	messagingService := services.NewMessagingService(
+		&streamConversationRepository{
+			ConversationRepository: repositories.NewNoOpConversationRepository(),
+			conversation:           &domain.Conversation{ID: conversationID, UserID: "owner", Channel: domain.ChannelWeb, Status: domain.ConversationStatusActive},
+		},
+		&exportMessageRepository{
+			MessageRepository: repositories.NewNoOpMessageRepository(),
+			messages: []domain.Message{
+				{ID: "msg1", SenderType: domain.SenderTypeUser, SenderID: "owner", Content: "Hola", ContentType: domain.ContentTypeText, Timestamp: now},
+				{ID: "msg2", SenderType: domain.SenderTypeAgent, SenderID: "agent-7", Content: "Buenos días", ContentType: domain.ContentTypeText, Timestamp: now.Add(time.Second)},
+			},
+		},
+		repositories.NewNoOpAttachmentRepository(),
+		services.NewNoOpEventPublisher(),
+		services.NewNoOpCacheService(),
+		log,
+	)
+	jwtManager := auth.NewJWTManager("test-secret", "test-issuer")
+	router := gin.New()
+	SetupRoutes(router, services.NewHealthService(), messagingService, services.NewNoOpFileService(), jwtManager, log)
+
+	export := func(userID string, roles []string, query string) *httptest.ResponseRecorder {
+		token, err := jwtManager.GenerateToken(userID, userID+"@example.com", roles)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/messaging/conversations/"+conversationID+"/export"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// CSV download, redacted for a non-admin owner
+	w := export("owner", []string{"user"}, "?format=csv")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename=conversation-`+conversationID+`.csv`, w.Header().Get("Content-Disposition"))
+	rows, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, "customer", rows[1][2])
+
+	// Non-admins cannot lift the redaction
+	w = export("owner", []string{"user"}, "?redact=false")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Unknown format, and a conversation the caller does not own
+	assert.Equal(t, http.StatusBadRequest, export("owner", []string{"user"}, "?format=xml").Code)
+	w = export("intruder", []string{"user"}, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+}
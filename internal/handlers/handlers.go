@@ -2,13 +2,17 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/company/microservice-template/internal/auth"
 	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/integrations/meta"
 	"github.com/company/microservice-template/internal/middleware"
 	"github.com/company/microservice-template/internal/services"
+	"github.com/company/microservice-template/pkg/lifecycle"
 	"github.com/company/microservice-template/pkg/logger"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -18,20 +22,211 @@ type Handler struct {
 	logger        logger.Logger
 }
 
-func SetupRoutes(router *gin.Engine, healthService services.HealthService, messagingService services.MessagingService, fileService services.FileService, jwtManager *auth.JWTManager, logger logger.Logger) {
+// routeConfig agrupa la configuración opcional de las rutas
+type routeConfig struct {
+	serviceTokens   *auth.ServiceTokenVerifier
+	auditRepo       domain.AuditRepository
+	chunkedUploads  services.ChunkedUploadService
+	events          services.EventSubscriber
+	streams         *lifecycle.Manager
+	batchWindow     time.Duration
+	batchMaxSize    int
+	agentRoles      []string
+	flatResponses   bool
+	pinnedFirst     bool
+	revealDenied    bool
+	receipts        *auth.WebhookSignatureVerifier
+	metaWebhooks    []*meta.Webhook
+	fileURLs        *auth.FileURLSigner
+	uploadsPath     string
+	attachmentTTL   time.Duration
+	maxBatchFiles   int
+	rateLimiter     *middleware.RateLimiter
+	userRateLimit   RateLimit
+	publicRateLimit RateLimit
+}
+
+// RateLimit es el máximo de peticiones admitidas en cualquier intervalo de Window; Limit cero no limita
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+// RouteOption configura aspectos opcionales de SetupRoutes
+type RouteOption func(*routeConfig)
+
+// WithServiceAuth permite a servicios internos autenticarse con X-Service-Token, auditando cada llamada
+func WithServiceAuth(verifier *auth.ServiceTokenVerifier, auditRepo domain.AuditRepository) RouteOption {
+	return func(rc *routeConfig) {
+		rc.serviceTokens = verifier
+		rc.auditRepo = auditRepo
+	}
+}
+
+// WithChunkedUploads habilita las subidas reanudables por partes
+func WithChunkedUploads(chunkedUploads services.ChunkedUploadService) RouteOption {
+	return func(rc *routeConfig) {
+		rc.chunkedUploads = chunkedUploads
+	}
+}
+
+// WithEventStream habilita GET /conversations/{id}/ws, que envía por WebSocket los eventos de la conversación
+func WithEventStream(subscriber services.EventSubscriber) RouteOption {
+	return func(rc *routeConfig) {
+		rc.events = subscriber
+	}
+}
+
+// WithStreamLifecycle registra cada WebSocket en manager, de modo que al apagar el servidor se cierran
+// con CloseGoingAway y se espera a que suelten su suscripción
+func WithStreamLifecycle(manager *lifecycle.Manager) RouteOption {
+	return func(rc *routeConfig) {
+		rc.streams = manager
+	}
+}
+
+// WithStreamBatching agrupa en un solo frame, como array, los eventos del WebSocket que llegan dentro de
+// window, enviando el lote antes si alcanza maxSize. Con window 0 cada evento va en su propio frame
+func WithStreamBatching(window time.Duration, maxSize int) RouteOption {
+	return func(rc *routeConfig) {
+		rc.batchWindow = window
+		rc.batchMaxSize = maxSize
+	}
+}
+
+// WithTypingAgentRoles indica los roles del JWT cuyos indicadores de escritura se publican como de
+// agente; los servicios internos siempre escriben como agente y el resto como cliente
+func WithTypingAgentRoles(roles []string) RouteOption {
+	return func(rc *routeConfig) {
+		rc.agentRoles = roles
+	}
+}
+
+// WithDeliveryReceipts habilita los acuses de entrega de los proveedores de canal, firmados con su secreto
+func WithDeliveryReceipts(verifier *auth.WebhookSignatureVerifier) RouteOption {
+	return func(rc *routeConfig) {
+		rc.receipts = verifier
+	}
+}
+
+// WithMetaWebhooks habilita GET y POST /webhooks/{channel} para el canal de cada webhook (whatsapp,
+// messenger, instagram), por donde Meta entrega los mensajes entrantes
+func WithMetaWebhooks(webhooks ...*meta.Webhook) RouteOption {
+	return func(rc *routeConfig) {
+		rc.metaWebhooks = append(rc.metaWebhooks, webhooks...)
+	}
+}
+
+// WithResponseEnvelope fija el formato por defecto de las respuestas: "flat" devuelve solo el recurso y
+// cualquier otro valor mantiene el envoltorio {code, message, data}
+func WithResponseEnvelope(mode string) RouteOption {
+	return func(rc *routeConfig) {
+		rc.flatResponses = mode == "flat"
+	}
+}
+
+// WithPinnedFirst fija si GET /conversations/{id}/messages antepone los mensajes fijados cuando la
+// petición no indica pinned_first
+func WithPinnedFirst(enabled bool) RouteOption {
+	return func(rc *routeConfig) {
+		rc.pinnedFirst = enabled
+	}
+}
+
+// WithAccessDeniedMasking fija si GET /conversations/{id} responde 404 en lugar de 403 cuando la
+// conversación existe pero el usuario no tiene acceso. Sin esta opción se enmascara
+func WithAccessDeniedMasking(mask bool) RouteOption {
+	return func(rc *routeConfig) {
+		rc.revealDenied = !mask
+	}
+}
+
+// WithSignedUploads exige en /uploads la firma que añade el almacenamiento local a las URLs de descarga y
+// sirve los archivos desde localPath. Con un firmante sin secreto /uploads sigue siendo público
+func WithSignedUploads(signer *auth.FileURLSigner, localPath string) RouteOption {
+	return func(rc *routeConfig) {
+		rc.fileURLs = signer
+		rc.uploadsPath = localPath
+	}
+}
+
+// WithRateLimits limita por usuario las peticiones a /messaging y por IP las de las rutas públicas (acuses
+// de entrega y conversaciones compartidas). Sin esta opción ningún grupo de rutas se limita
+func WithRateLimits(limiter *middleware.RateLimiter, user RateLimit, public RateLimit) RouteOption {
+	return func(rc *routeConfig) {
+		rc.rateLimiter = limiter
+		rc.userRateLimit = user
+		rc.publicRateLimit = public
+	}
+}
+
+// WithAttachmentURLTTL fija la validez de las URLs firmadas que devuelve GET /attachments/{id}
+func WithAttachmentURLTTL(ttl time.Duration) RouteOption {
+	return func(rc *routeConfig) {
+		rc.attachmentTTL = ttl
+	}
+}
+
+// WithUploadBatchLimit fija cuántos archivos admite POST /attachments/upload/batch
+func WithUploadBatchLimit(maxFiles int) RouteOption {
+	return func(rc *routeConfig) {
+		rc.maxBatchFiles = maxFiles
+	}
+}
+
+func SetupRoutes(router *gin.Engine, healthService services.HealthService, messagingService services.MessagingService, fileService services.FileService, jwtManager *auth.JWTManager, logger logger.Logger, opts ...RouteOption) {
 	h := &Handler{
 		healthService: healthService,
 		logger:        logger,
 	}
 
+	rc := &routeConfig{}
+	for _, opt := range opts {
+		opt(rc)
+	}
+
 	// Initialize messaging handler
 	messagingHandler := NewMessagingHandler(messagingService, fileService, jwtManager, logger)
+	if rc.chunkedUploads != nil {
+		messagingHandler.chunkedUploads = rc.chunkedUploads
+	}
+	if rc.events != nil {
+		messagingHandler.events = rc.events
+	}
+	messagingHandler.streams = rc.streams
+	messagingHandler.batchWindow = rc.batchWindow
+	messagingHandler.batchMaxSize = rc.batchMaxSize
+	messagingHandler.agentRoles = rc.agentRoles
+	messagingHandler.flatResponses = rc.flatResponses
+	messagingHandler.pinnedFirst = rc.pinnedFirst
+	messagingHandler.revealDenied = rc.revealDenied
+	messagingHandler.receipts = rc.receipts
+	messagingHandler.metaWebhooks = make(map[domain.Channel]*meta.Webhook, len(rc.metaWebhooks))
+	for _, webhook := range rc.metaWebhooks {
+		messagingHandler.metaWebhooks[webhook.Channel()] = webhook
+	}
+	if rc.attachmentTTL > 0 {
+		messagingHandler.attachmentURLTTL = rc.attachmentTTL
+	}
+	if rc.maxBatchFiles > 0 {
+		messagingHandler.maxBatchFiles = rc.maxBatchFiles
+	}
+	messagingHandler.fileURLs = rc.fileURLs
+	messagingHandler.uploadsPath = rc.uploadsPath
 
 	// Swagger documentation (protegido en producción)
 	router.GET("/swagger/*any", middleware.SwaggerAuth(), ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Prometheus metrics
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	// Serve uploaded files
-	router.Static("/uploads", "./uploads")
+	if rc.fileURLs.Enabled() {
+		router.GET("/uploads/*filepath", messagingHandler.ServeUpload)
+		router.HEAD("/uploads/*filepath", messagingHandler.ServeUpload)
+	} else {
+		router.Static("/uploads", "./uploads")
+	}
 
 	// API routes
 	api := router.Group("/api/v1")
@@ -39,25 +234,113 @@ func SetupRoutes(router *gin.Engine, healthService services.HealthService, messa
 		// Health check
 		api.GET("/health", h.HealthCheck)
 		api.GET("/ready", h.ReadinessCheck)
+
+		publicRateLimit := rc.rateLimiter.RateLimit(rc.publicRateLimit.Limit, rc.publicRateLimit.Window)
+
+		// Acuses de entrega de los proveedores; se autentican con la firma del cuerpo, no con JWT
+		api.POST("/webhooks/:channel/status", publicRateLimit, messagingHandler.HandleDeliveryReceipt)
+		// Mensajes entrantes de WhatsApp, Messenger e Instagram, firmados con el secreto de la app de Meta
+		api.GET("/webhooks/:channel", publicRateLimit, messagingHandler.VerifyMetaWebhook)
+		api.POST("/webhooks/:channel", publicRateLimit, messagingHandler.HandleMetaWebhook)
+
+		// Conversaciones compartidas; el token firmado del enlace sustituye al JWT
+		api.GET("/shared/:token", publicRateLimit, messagingHandler.GetSharedConversation)
+
+		// Renovación y revocación de tokens; el token de refresco del cuerpo es la credencial
+		authRoutes := api.Group("/auth")
+		authRoutes.Use(publicRateLimit)
+		{
+			authRoutes.POST("/refresh", messagingHandler.RefreshToken)
+			authRoutes.POST("/logout", messagingHandler.Logout)
+		}
 		
 		// Messaging routes
 		messaging := api.Group("/messaging")
-		messaging.Use(middleware.JWTAuth(jwtManager))
+		messaging.Use(middleware.ServiceOrJWTAuth(rc.serviceTokens, jwtManager, rc.auditRepo, logger))
+		// After auth, so each user has their own window
+		messaging.Use(rc.rateLimiter.RateLimit(rc.userRateLimit.Limit, rc.userRateLimit.Window))
 		{
 			// Conversations
 			messaging.GET("/conversations", messagingHandler.GetConversations)
+			messaging.GET("/conversations/search", messagingHandler.SearchConversations)
 			messaging.GET("/conversations/:id", messagingHandler.GetConversation)
 			messaging.POST("/conversations", messagingHandler.CreateConversation)
-			messaging.PATCH("/conversations/:id", messagingHandler.UpdateConversation)
+			messaging.POST("/conversations/bulk-tag", messagingHandler.BulkTagConversations)
+			messaging.POST("/conversations/bulk-status", middleware.RequireRole("admin", "agent"), messagingHandler.BulkUpdateConversationStatus)
+			messaging.PATCH("/conversations/:id", middleware.RequireRole("admin", "agent"), messagingHandler.UpdateConversation)
+			messaging.POST("/conversations/:id/read", messagingHandler.MarkConversationRead)
+			messaging.POST("/conversations/:id/lock", messagingHandler.AcquireConversationLock)
+			messaging.DELETE("/conversations/:id/lock", messagingHandler.ReleaseConversationLock)
+			messaging.GET("/conversations/:id/ws", messagingHandler.StreamConversation)
+			messaging.POST("/conversations/:id/typing", messagingHandler.SetTyping)
+			messaging.GET("/conversations/:id/tags", messagingHandler.ListConversationTags)
+			messaging.POST("/conversations/:id/tags", messagingHandler.AddConversationTag)
+			messaging.DELETE("/conversations/:id/tags/:tag", messagingHandler.RemoveConversationTag)
+			messaging.PATCH("/conversations/:id/metadata", messagingHandler.UpdateConversationMetadata)
+			messaging.GET("/conversations/:id/participants", messagingHandler.ListParticipants)
+			messaging.POST("/conversations/:id/participants", messagingHandler.AddParticipant)
+			messaging.DELETE("/conversations/:id/participants/:userId", messagingHandler.RemoveParticipant)
+			messaging.GET("/conversations/:id/draft", messagingHandler.GetDraft)
+			messaging.PUT("/conversations/:id/draft", messagingHandler.SaveDraft)
+			messaging.DELETE("/conversations/:id/draft", messagingHandler.DeleteDraft)
+			messaging.GET("/conversations/:id/share-links", messagingHandler.ListShareLinks)
+			messaging.POST("/conversations/:id/share-links", messagingHandler.CreateShareLink)
+			messaging.DELETE("/conversations/:id/share-links/:linkId", messagingHandler.RevokeShareLink)
+			messaging.GET("/conversations/:id/webhooks", messagingHandler.ListWebhooks)
+			messaging.POST("/conversations/:id/webhooks", messagingHandler.CreateWebhook)
+			messaging.DELETE("/conversations/:id/webhooks/:webhookId", messagingHandler.DeleteWebhook)
+			messaging.GET("/conversations/:id/webhooks/:webhookId/deliveries", messagingHandler.ListWebhookDeliveries)
+			messaging.POST("/conversations/:id/webhooks/:webhookId/deliveries/:deliveryId/replay", messagingHandler.ReplayWebhookDelivery)
 			
 			// Messages
 			messaging.GET("/conversations/:id/messages", messagingHandler.GetMessages)
 			messaging.POST("/conversations/:id/messages", messagingHandler.SendMessage)
+			messaging.DELETE("/conversations/:id/scheduled/:scheduledId", messagingHandler.CancelScheduledMessage)
+			messaging.GET("/conversations/:id/message-count", messagingHandler.GetMessageCount)
+			messaging.GET("/conversations/:id/export", messagingHandler.ExportConversation)
+			messaging.GET("/conversations/:id/attachments/search", messagingHandler.SearchAttachments)
+			messaging.POST("/conversations/:id/messages/read", messagingHandler.MarkMessagesRead)
+			messaging.GET("/conversations/:id/messages/around/:messageId", messagingHandler.GetMessagesAround)
+			messaging.GET("/messages/search", messagingHandler.SearchMessages)
 			messaging.GET("/messages/:id", messagingHandler.GetMessage)
+			messaging.PATCH("/messages/:id", messagingHandler.EditMessage)
+			messaging.GET("/messages/:id/thread", messagingHandler.GetThread)
+			messaging.GET("/messages/:id/reactions", messagingHandler.GetReactions)
+			messaging.POST("/messages/:id/reactions", messagingHandler.AddReaction)
+			messaging.DELETE("/messages/:id/reactions/:emoji", messagingHandler.RemoveReaction)
+			messaging.POST("/messages/:id/read", messagingHandler.MarkMessageRead)
+			messaging.POST("/messages/:id/pin", messagingHandler.PinMessage)
+			messaging.DELETE("/messages/:id/pin", messagingHandler.UnpinMessage)
 			
 			// Attachments
+			messaging.GET("/attachments", messagingHandler.GetUserAttachments)
 			messaging.POST("/attachments/upload", messagingHandler.UploadAttachment)
+			messaging.POST("/attachments/upload/batch", messagingHandler.UploadAttachments)
+			messaging.POST("/attachments/upload/init", messagingHandler.InitUpload)
+			messaging.GET("/attachments/upload/:id", messagingHandler.GetUpload)
+			messaging.PATCH("/attachments/upload/:id", messagingHandler.UploadChunk)
+			messaging.POST("/attachments/upload/:id/complete", messagingHandler.CompleteUpload)
 			messaging.GET("/attachments/:id", messagingHandler.GetAttachment)
+			messaging.GET("/attachments/:id/download", messagingHandler.DownloadAttachment)
+			messaging.DELETE("/attachments/:id", messagingHandler.DeleteAttachment)
+
+			// Templates
+			messaging.GET("/templates", messagingHandler.ListTemplates)
+			messaging.GET("/templates/:id", messagingHandler.GetTemplate)
+			messaging.POST("/templates/:id/conversations", messagingHandler.CreateConversationFromTemplate)
+
+			// Administration
+			admin := messaging.Group("/admin")
+			admin.Use(middleware.RequireRole("admin"))
+			{
+				admin.DELETE("/users/:userID/data", messagingHandler.PurgeUserData)
+				admin.POST("/conversations/transfer-ownership", messagingHandler.TransferConversationOwnership)
+				admin.POST("/templates", messagingHandler.CreateTemplate)
+				admin.PUT("/templates/:id", messagingHandler.UpdateTemplate)
+				admin.DELETE("/templates/:id", messagingHandler.DeleteTemplate)
+				admin.GET("/webhook-deliveries", messagingHandler.ListAllWebhookDeliveries)
+				admin.GET("/audit", messagingHandler.ListAuditLogs)
+			}
 		}
 	}
 }
@@ -84,19 +367,24 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 
 // ReadinessCheck godoc
 // @Summary Readiness check endpoint
-// @Description Verifica si el servicio está listo para recibir tráfico
+// @Description Verifica si el servicio está listo para recibir tráfico. Responde 503 solo si cae una dependencia requerida; si caen opcionales responde 200 con estado degraded y avisos
 // @Tags health
 // @Accept json
 // @Produce json
 // @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} domain.APIResponse
 // @Router /ready [get]
 func (h *Handler) ReadinessCheck(c *gin.Context) {
 	status := h.healthService.CheckReadiness()
 	
 	if status["ready"].(bool) {
+		message := "Service is ready"
+		if status["status"] == "degraded" {
+			message = "Service is ready but degraded"
+		}
 		response := domain.APIResponse{
 			Code:    "SUCCESS",
-			Message: "Service is ready",
+			Message: message,
 			Data:    status,
 		}
 		c.JSON(http.StatusOK, response)
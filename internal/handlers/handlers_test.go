@@ -1,15 +1,25 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/integrations/meta"
+	"github.com/company/microservice-template/internal/integrations/whatsapp"
+	"github.com/company/microservice-template/internal/repositories"
 	"github.com/company/microservice-template/internal/services"
 	"github.com/company/microservice-template/pkg/logger"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHealthCheck(t *testing.T) {
@@ -17,11 +27,11 @@ func TestHealthCheck(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	
-	healthService := services.NewHealthService()
-	messagingService := services.NewMessagingService(nil, nil, nil)
-	fileService := services.NewFileService(nil, nil)
-	jwtManager := auth.NewJWTManager("test-secret", "test-issuer", 24)
 	logger := logger.NewLogger("debug")
+	healthService := services.NewHealthService()
+	messagingService := services.NewMessagingService(nil, nil, nil, nil, nil, logger)
+	fileService := services.NewNoOpFileService()
+	jwtManager := auth.NewJWTManager("test-secret", "test-issuer")
 	
 	SetupRoutes(router, healthService, messagingService, fileService, jwtManager, logger)
 	
@@ -40,11 +50,11 @@ func TestReadinessCheck(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
 	
-	healthService := services.NewHealthService()
-	messagingService := services.NewMessagingService(nil, nil, nil)
-	fileService := services.NewFileService(nil, nil)
-	jwtManager := auth.NewJWTManager("test-secret", "test-issuer", 24)
 	logger := logger.NewLogger("debug")
+	healthService := services.NewHealthService()
+	messagingService := services.NewMessagingService(nil, nil, nil, nil, nil, logger)
+	fileService := services.NewNoOpFileService()
+	jwtManager := auth.NewJWTManager("test-secret", "test-issuer")
 	
 	SetupRoutes(router, healthService, messagingService, fileService, jwtManager, logger)
 	
@@ -56,4 +66,420 @@ func TestReadinessCheck(t *testing.T) {
 	// Assertions
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "ready")
-}
\ No newline at end of file
+}
+
+// failingHealthRepository simula una base de datos caída
+type failingHealthRepository struct{}
+
+func (r failingHealthRepository) CheckDatabase(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func (r failingHealthRepository) CheckExternalServices(ctx context.Context) map[string]error {
+	return map[string]error{}
+}
+
+func TestReadinessCheck_DatabaseDown(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	logger := logger.NewLogger("debug")
+	healthService := services.NewHealthService(
+		services.WithRequiredDependencies([]string{"database"}),
+		services.WithDependencyCheck("database", services.DatabaseCheck(failingHealthRepository{})),
+		services.WithDependencyCheck("redis", func(ctx context.Context) error { return nil }),
+	)
+	messagingService := services.NewMessagingService(nil, nil, nil, nil, nil, logger)
+	jwtManager := auth.NewJWTManager("test-secret", "test-issuer")
+
+	SetupRoutes(router, healthService, messagingService, services.NewNoOpFileService(), jwtManager, logger)
+
+	// Readiness reports each dependency and fails
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/ready", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var response struct {
+		Code string `json:"code"`
+		Data struct {
+			Checks map[string]struct {
+				Status string `json:"status"`
+				Error  string `json:"error"`
+			} `json:"checks"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "SERVICE_UNAVAILABLE", response.Code)
+	assert.Equal(t, "down", response.Data.Checks["database"].Status)
+	assert.Equal(t, "connection refused", response.Data.Checks["database"].Error)
+	assert.Equal(t, "up", response.Data.Checks["redis"].Status)
+
+	// Liveness doesn't depend on the backends
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/health", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestServiceTokenAuth(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	logger := logger.NewLogger("debug")
+	healthService := services.NewHealthService()
+	messagingService := services.NewMessagingService(nil, nil, nil, nil, nil, logger)
+	fileService := services.NewNoOpFileService()
+	jwtManager := auth.NewJWTManager("test-secret", "test-issuer")
+	serviceTokens := auth.NewServiceTokenVerifier("service-secret")
+	auditRepo := &recordingAuditRepository{}
+
+	SetupRoutes(router, healthService, messagingService, fileService, jwtManager, logger, WithServiceAuth(serviceTokens, auditRepo))
+
+	// A valid service token is accepted and audited
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/messaging/templates", nil)
+	req.Header.Set(auth.ServiceTokenHeader, serviceTokens.GenerateToken("classifier"))
+	router.ServeHTTP(w, req)
+
+	assert.NotEqual(t, http.StatusUnauthorized, w.Code)
+	assert.Len(t, auditRepo.logs, 1)
+	assert.Equal(t, "service:classifier", auditRepo.logs[0].UserID)
+	assert.Equal(t, "SERVICE_REQUEST", auditRepo.logs[0].Action)
+
+	// A non-matching token falls through to JWT auth
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/messaging/templates", nil)
+	req.Header.Set(auth.ServiceTokenHeader, "classifier:forged")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Len(t, auditRepo.logs, 1)
+}
+
+func TestMetaWebhooks(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	logger := logger.NewLogger("debug")
+	healthService := services.NewHealthService()
+	messagingService := services.NewMessagingService(nil, nil, nil, nil, nil, logger)
+	fileService := services.NewNoOpFileService()
+	jwtManager := auth.NewJWTManager("test-secret", "test-issuer")
+	webhook := whatsapp.NewWebhook("app-secret", "verify-me", messagingService, logger)
+
+	SetupRoutes(router, healthService, messagingService, fileService, jwtManager, logger, WithMetaWebhooks(webhook))
+
+	// Meta's subscription check gets the challenge back as plain text
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/webhooks/whatsapp?hub.mode=subscribe&hub.verify_token=verify-me&hub.challenge=1158201444", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1158201444", w.Body.String())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/webhooks/whatsapp?hub.mode=subscribe&hub.verify_token=wrong&hub.challenge=1158201444", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// A notification signed with another secret is rejected before reading it
+	body := `{"object":"whatsapp_business_account","entry":[]}`
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/webhooks/whatsapp", strings.NewReader(body))
+	req.Header.Set(meta.SignatureHeader, auth.SignEventPayload([]byte("other-secret"), []byte(body)))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/webhooks/whatsapp", strings.NewReader(body))
+	req.Header.Set(meta.SignatureHeader, auth.SignEventPayload([]byte("app-secret"), []byte(body)))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Channels without a webhook don't accept notifications, and delivery receipts keep their own route
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/webhooks/messenger", strings.NewReader(body))
+	req.Header.Set(meta.SignatureHeader, auth.SignEventPayload([]byte("app-secret"), []byte(body)))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/webhooks/whatsapp/status", strings.NewReader(`{}`))
+	router.ServeHTTP(w, req)
+
+	assert.Contains(t, w.Body.String(), "Delivery receipts not enabled")
+}
+
+func TestRefreshToken(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	logger := logger.NewLogger("debug")
+	healthService := services.NewHealthService()
+	messagingService := services.NewMessagingService(nil, nil, nil, nil, nil, logger)
+	fileService := services.NewNoOpFileService()
+	jwtManager := auth.NewJWTManager("test-secret", "test-issuer")
+
+	SetupRoutes(router, healthService, messagingService, fileService, jwtManager, logger)
+
+	access, refresh, err := jwtManager.GenerateTokenPair("user123", "user@example.com", nil)
+	require.NoError(t, err)
+
+	// A refresh token gets a new access token
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/auth/refresh", strings.NewReader(`{"refresh_token":"`+refresh+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "access_token")
+
+	// An access token is rejected
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/api/v1/auth/refresh", strings.NewReader(`{"refresh_token":"`+access+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "INVALID_TOKEN")
+
+	// And the refresh token can't authenticate other routes
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/v1/messaging/templates", nil)
+	req.Header.Set("Authorization", "Bearer "+refresh)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestResponseEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	data := map[string]string{"id": "conv123"}
+
+	respond := func(h *MessagingHandler, prefer string, data interface{}) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/", nil)
+		if prefer != "" {
+			c.Request.Header.Set("Prefer", prefer)
+		}
+		h.respondWithSuccess(c, http.StatusOK, "Conversation retrieved successfully", data)
+		c.Writer.WriteHeaderNow()
+		return w
+	}
+
+	// Wrapped by default
+	w := respond(&MessagingHandler{}, "", data)
+	assert.JSONEq(t, `{"code":"SUCCESS","message":"Conversation retrieved successfully","data":{"id":"conv123"}}`, w.Body.String())
+
+	// Per-request flat response
+	w = respond(&MessagingHandler{}, "handling=lenient, return=representation", data)
+	assert.JSONEq(t, `{"id":"conv123"}`, w.Body.String())
+	assert.Equal(t, "return=representation", w.Header().Get("Preference-Applied"))
+
+	// Flat by configuration, without a body when there is nothing to return
+	w = respond(&MessagingHandler{flatResponses: true}, "", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestGetConversation_AccessDenied(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := logger.NewLogger("debug")
+	conversationID := "4f6b8a52-3c1d-4e7a-9b0f-2d5c6e7f8a91"
+	messagingService := services.NewMessagingService(
+		&streamConversationRepository{
+			ConversationRepository: repositories.NewNoOpConversationRepository(),
+			conversation:           &domain.Conversation{ID: conversationID, UserID: "owner", Channel: domain.ChannelWeb, Status: domain.ConversationStatusActive},
+		},
+		repositories.NewNoOpMessageRepository(),
+		repositories.NewNoOpAttachmentRepository(),
+		services.NewNoOpEventPublisher(),
+		services.NewNoOpCacheService(),
+		log,
+	)
+	jwtManager := auth.NewJWTManager("test-secret", "test-issuer")
+
+	tests := []struct {
+		name           string
+		opts           []RouteOption
+		conversationID string
+		userID         string
+		roles          []string
+		status         int
+	}{
+		{name: "owner", conversationID: conversationID, userID: "owner", roles: []string{"user"}, status: http.StatusOK},
+		{name: "missing conversation", conversationID: "0b1c2d3e-4f50-4a6b-8c7d-9e0f1a2b3c4d", userID: "owner", roles: []string{"user"}, status: http.StatusNotFound},
+		{name: "another user's conversation is masked by default", conversationID: conversationID, userID: "intruder", roles: []string{"user"}, status: http.StatusNotFound},
+		{name: "admin tooling sees the denial", conversationID: conversationID, userID: "admin1", roles: []string{"admin"}, status: http.StatusForbidden},
+		{name: "masking disabled", opts: []RouteOption{WithAccessDeniedMasking(false)}, conversationID: conversationID, userID: "intruder", roles: []string{"user"}, status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			SetupRoutes(router, services.NewHealthService(), messagingService, services.NewNoOpFileService(), jwtManager, log, tt.opts...)
+			token, err := jwtManager.GenerateToken(tt.userID, tt.userID+"@example.com", tt.roles)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/v1/messaging/conversations/"+tt.conversationID, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestSendMessage_SenderType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := logger.NewLogger("debug")
+	conversationID := "4f6b8a52-3c1d-4e7a-9b0f-2d5c6e7f8a91"
+	messagingService := services.NewMessagingService(
+		&streamConversationRepository{
+			ConversationRepository: repositories.NewNoOpConversationRepository(),
+			conversation:           &domain.Conversation{ID: conversationID, UserID: "owner", Channel: domain.ChannelWeb, Status: domain.ConversationStatusActive},
+		},
+		&streamMessageRepository{MessageRepository: repositories.NewNoOpMessageRepository()},
+		repositories.NewNoOpAttachmentRepository(),
+		services.NewNoOpEventPublisher(),
+		services.NewNoOpCacheService(),
+		log,
+	)
+	jwtManager := auth.NewJWTManager("test-secret", "test-issuer")
+	router := gin.New()
+	SetupRoutes(router, services.NewHealthService(), messagingService, services.NewNoOpFileService(), jwtManager, log)
+
+	tests := []struct {
+		name      string
+		roles     []string
+		requested string
+		expected  domain.SenderType
+	}{
+		{name: "user cannot send as agent", roles: []string{"user"}, requested: "agent", expected: domain.SenderTypeUser},
+		{name: "user cannot send as bot", roles: []string{"user"}, requested: "bot", expected: domain.SenderTypeUser},
+		{name: "missing sender type", roles: []string{"user"}, requested: "", expected: domain.SenderTypeUser},
+		{name: "agent role", roles: []string{"agent"}, requested: "agent", expected: domain.SenderTypeAgent},
+		{name: "admin role", roles: []string{"admin"}, requested: "bot", expected: domain.SenderTypeBot},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwtManager.GenerateToken("owner", "owner@example.com", tt.roles)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			body := `{"conversation_id":"` + conversationID + `","sender_id":"owner","sender_type":"` + tt.requested + `","content":"Hola","content_type":"text"}`
+			req, _ := http.NewRequest("POST", "/api/v1/messaging/conversations/"+conversationID+"/messages", strings.NewReader(body))
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+			var response struct {
+				Data domain.Message `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.expected, response.Data.SenderType)
+		})
+	}
+}
+
+func TestListAuditLogs_AdminOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := logger.NewLogger("debug")
+	auditRepo := &recordingAuditRepository{logs: []*domain.AuditLog{{ID: "audit1", UserID: "agent1", Action: "CONVERSATION_CREATE"}}}
+	messagingService := services.NewMessagingService(
+		repositories.NewNoOpConversationRepository(),
+		repositories.NewNoOpMessageRepository(),
+		repositories.NewNoOpAttachmentRepository(),
+		services.NewNoOpEventPublisher(),
+		services.NewNoOpCacheService(),
+		log,
+		services.WithAuditRepository(auditRepo),
+	)
+	jwtManager := auth.NewJWTManager("test-secret", "test-issuer")
+	router := gin.New()
+	SetupRoutes(router, services.NewHealthService(), messagingService, services.NewNoOpFileService(), jwtManager, log)
+
+	tests := []struct {
+		name   string
+		roles  []string
+		query  string
+		status int
+	}{
+		{name: "admin", roles: []string{"admin"}, query: "?action=CONVERSATION_CREATE&from=2026-03-01T00:00:00Z&to=2026-04-01T00:00:00Z", status: http.StatusOK},
+		{name: "agent", roles: []string{"agent"}, status: http.StatusForbidden},
+		{name: "user", roles: []string{"user"}, status: http.StatusForbidden},
+		{name: "malformed from", roles: []string{"admin"}, query: "?from=yesterday", status: http.StatusBadRequest},
+		{name: "inverted range", roles: []string{"admin"}, query: "?from=2026-04-01T00:00:00Z&to=2026-03-01T00:00:00Z", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwtManager.GenerateToken("caller", "caller@example.com", tt.roles)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/v1/messaging/admin/audit"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestNextMessageCursor(t *testing.T) {
+	now := time.Now()
+	messages := []domain.Message{
+		{ID: "pinned", Timestamp: now, PinnedAt: &now},
+		{ID: "msg2", Timestamp: now},
+		{ID: "msg1", Timestamp: now.Add(-time.Minute)},
+	}
+
+	// A full page points after its last message; the pinned ones prepended to it don't count
+	token := nextMessageCursor(messages, domain.PaginationParams{Limit: 2, PinnedFirst: true})
+	cursor, err := domain.DecodeMessageCursor(token)
+	require.NoError(t, err)
+	assert.Equal(t, "msg1", cursor.ID)
+	assert.True(t, cursor.Timestamp.Equal(now.Add(-time.Minute)))
+
+	// A short page is the last one
+	assert.Empty(t, nextMessageCursor(messages, domain.PaginationParams{Limit: 3, PinnedFirst: true}))
+
+	_, err = domain.DecodeMessageCursor("not-a-cursor")
+	assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+}
+
+type recordingAuditRepository struct {
+	logs []*domain.AuditLog
+}
+
+func (r *recordingAuditRepository) Create(ctx context.Context, log *domain.AuditLog) error {
+	r.logs = append(r.logs, log)
+	return nil
+}
+
+func (r *recordingAuditRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*domain.AuditLog, error) {
+	return r.logs, nil
+}
+
+func (r *recordingAuditRepository) GetByAction(ctx context.Context, action string, limit, offset int) ([]*domain.AuditLog, error) {
+	return r.logs, nil
+}
+
+func (r *recordingAuditRepository) List(ctx context.Context, filters domain.AuditLogFilters) ([]*domain.AuditLog, error) {
+	return r.logs, nil
+}
@@ -1,20 +1,47 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/company/microservice-template/internal/auth"
 	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/integrations/meta"
+	"github.com/company/microservice-template/internal/middleware"
 	"github.com/company/microservice-template/internal/services"
+	"github.com/company/microservice-template/pkg/lifecycle"
 	"github.com/company/microservice-template/pkg/logger"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
+// preferReturnRepresentation es la preferencia (RFC 7240) con la que un cliente pide el recurso sin envoltorio
+const preferReturnRepresentation = "return=representation"
+
 type MessagingHandler struct {
 	messagingService services.MessagingService
 	fileService      services.FileService
+	chunkedUploads   services.ChunkedUploadService
+	events           services.EventSubscriber
+	streams          *lifecycle.Manager // Cierra los WebSocket al apagar el servidor
+	batchWindow      time.Duration // Ventana de agrupación de eventos del WebSocket; 0 no agrupa
+	batchMaxSize     int
+	agentRoles       []string // Roles que escriben como agente en los indicadores de escritura
 	jwtManager       *auth.JWTManager
+	flatResponses    bool // Devuelve el recurso sin el envoltorio APIResponse por defecto
+	pinnedFirst      bool // Antepone los mensajes fijados si la petición no indica pinned_first
+	revealDenied     bool // Responde 403 y no 404 a quien pide una conversación ajena
+	receipts         *auth.WebhookSignatureVerifier
+	metaWebhooks     map[domain.Channel]*meta.Webhook // Mensajes entrantes de WhatsApp, Messenger e Instagram
+	fileURLs         *auth.FileURLSigner
+	uploadsPath      string
+	attachmentURLTTL time.Duration // Validez de la URL firmada que devuelve GetAttachment
+	maxBatchFiles    int           // Archivos admitidos en una subida múltiple
 	logger           logger.Logger
 }
 
@@ -27,7 +54,11 @@ func NewMessagingHandler(
 	return &MessagingHandler{
 		messagingService: messagingService,
 		fileService:      fileService,
+		chunkedUploads:   services.NewNoOpChunkedUploadService(),
+		events:           services.NewNoOpEventSubscriber(),
 		jwtManager:       jwtManager,
+		attachmentURLTTL: defaultAttachmentURLTTL,
+		maxBatchFiles:    defaultMaxBatchFiles,
 		logger:           logger,
 	}
 }
@@ -40,10 +71,13 @@ func NewMessagingHandler(
 // @Produce json
 // @Param Authorization header string true "Bearer token"
 // @Param channel query string false "Canal de comunicación" Enums(whatsapp, web, messenger, instagram)
-// @Param status query string false "Estado de la conversación" Enums(active, closed, archived)
+// @Param status query string false "Estado de la conversación" Enums(active, closed, archived, abandoned)
 // @Param limit query int false "Límite de resultados" default(20)
 // @Param offset query int false "Offset para paginación" default(0)
+// @Param include_read_state query bool false "Incluye cuántos participantes leyeron hasta el último mensaje" default(false)
+// @Param tags query string false "Etiquetas separadas por comas; solo se devuelven las conversaciones que las tienen todas"
 // @Success 200 {object} domain.APIResponse{data=[]domain.Conversation}
+// @Failure 400 {object} domain.APIResponse
 // @Failure 401 {object} domain.APIResponse
 // @Failure 500 {object} domain.APIResponse
 // @Router /conversations [get]
@@ -61,10 +95,18 @@ func (h *MessagingHandler) GetConversations(c *gin.Context) {
 		Limit:   h.parseIntQuery(c, "limit", 20),
 		Offset:  h.parseIntQuery(c, "offset", 0),
 	}
+	filters.IncludeReadState = c.Query("include_read_state") == "true"
+	if tags := c.Query("tags"); tags != "" {
+		filters.Tags = strings.Split(tags, ",")
+	}
 
 	conversations, err := h.messagingService.GetConversations(c.Request.Context(), userID, filters)
 	if err != nil {
-		h.logger.Error("Failed to get conversations", err)
+		if errors.Is(err, services.ErrInvalidTag) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to get conversations", err)
 		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get conversations")
 		return
 	}
@@ -74,14 +116,15 @@ func (h *MessagingHandler) GetConversations(c *gin.Context) {
 
 // GetConversation godoc
 // @Summary Obtiene detalles de una conversación
-// @Description Trae detalles de una conversación incluyendo mensajes
+// @Description Trae detalles de una conversación incluyendo mensajes. Acepta el ID o la referencia legible (p. ej. WEB-001234)
 // @Tags conversations
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer token"
-// @Param id path string true "ID de la conversación"
+// @Param id path string true "ID o referencia de la conversación"
 // @Success 200 {object} domain.APIResponse{data=domain.Conversation}
 // @Failure 401 {object} domain.APIResponse
+// @Failure 403 {object} domain.APIResponse
 // @Failure 404 {object} domain.APIResponse
 // @Failure 500 {object} domain.APIResponse
 // @Router /conversations/{id} [get]
@@ -98,11 +141,24 @@ func (h *MessagingHandler) GetConversation(c *gin.Context) {
 		return
 	}
 
-	conversation, err := h.messagingService.GetConversation(c.Request.Context(), conversationID, userID)
-	if err != nil {
-		h.logger.Error("Failed to get conversation", err)
+	var conversation *domain.Conversation
+	var err error
+	if _, parseErr := uuid.Parse(conversationID); parseErr != nil {
+		conversation, err = h.messagingService.GetConversationByReference(c.Request.Context(), conversationID, userID)
+	} else {
+		conversation, err = h.messagingService.GetConversation(c.Request.Context(), conversationID, userID)
+	}
+	switch {
+	case errors.Is(err, domain.ErrAccessDenied) && (h.revealDenied || h.hasRole(c, "admin")):
+		h.respondWithError(c, http.StatusForbidden, "FORBIDDEN", "Access to conversation denied")
+		return
+	case errors.Is(err, domain.ErrNotFound):
 		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Conversation not found")
 		return
+	case err != nil:
+		h.log(c).Error("Failed to get conversation", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get conversation")
+		return
 	}
 
 	h.respondWithSuccess(c, http.StatusOK, "Conversation retrieved successfully", conversation)
@@ -110,7 +166,7 @@ func (h *MessagingHandler) GetConversation(c *gin.Context) {
 
 // CreateConversation godoc
 // @Summary Crea una nueva conversación
-// @Description Crea una nueva conversación si no existe
+// @Description Crea una nueva conversación si no existe, opcionalmente con metadatos libres (p. ej. teléfono del cliente o ID del CRM)
 // @Tags conversations
 // @Accept json
 // @Produce json
@@ -134,9 +190,13 @@ func (h *MessagingHandler) CreateConversation(c *gin.Context) {
 		return
 	}
 
-	conversation, err := h.messagingService.CreateConversation(c.Request.Context(), userID, req.Channel)
+	conversation, err := h.messagingService.CreateConversation(c.Request.Context(), userID, req.Channel, req.Metadata)
 	if err != nil {
-		h.logger.Error("Failed to create conversation", err)
+		if errors.Is(err, services.ErrInvalidMetadata) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to create conversation", err)
 		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create conversation")
 		return
 	}
@@ -146,7 +206,7 @@ func (h *MessagingHandler) CreateConversation(c *gin.Context) {
 
 // UpdateConversation godoc
 // @Summary Actualiza estado de conversación
-// @Description Actualiza el estado de una conversación (ej: cerrar conversación)
+// @Description Actualiza el estado de una conversación (ej: cerrar conversación). Si se configura un intervalo mínimo entre cambios, los cambios demasiado seguidos devuelven 429 con Retry-After o se descartan. Requiere rol admin o agent
 // @Tags conversations
 // @Accept json
 // @Produce json
@@ -156,7 +216,9 @@ func (h *MessagingHandler) CreateConversation(c *gin.Context) {
 // @Success 200 {object} domain.APIResponse
 // @Failure 400 {object} domain.APIResponse
 // @Failure 401 {object} domain.APIResponse
+// @Failure 403 {object} domain.APIResponse
 // @Failure 404 {object} domain.APIResponse
+// @Failure 429 {object} domain.APIResponse
 // @Failure 500 {object} domain.APIResponse
 // @Router /conversations/{id} [patch]
 func (h *MessagingHandler) UpdateConversation(c *gin.Context) {
@@ -180,7 +242,13 @@ func (h *MessagingHandler) UpdateConversation(c *gin.Context) {
 
 	err := h.messagingService.UpdateConversationStatus(c.Request.Context(), conversationID, req.Status, userID)
 	if err != nil {
-		h.logger.Error("Failed to update conversation", err)
+		var tooFrequent *services.StatusChangeTooFrequentError
+		if errors.As(err, &tooFrequent) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(tooFrequent.RetryAfter.Seconds()))))
+			h.respondWithError(c, http.StatusTooManyRequests, "STATUS_CHANGE_TOO_FREQUENT", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to update conversation", err)
 		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update conversation")
 		return
 	}
@@ -188,18 +256,137 @@ func (h *MessagingHandler) UpdateConversation(c *gin.Context) {
 	h.respondWithSuccess(c, http.StatusOK, "Conversation updated successfully", nil)
 }
 
+// BulkUpdateConversationStatus godoc
+// @Summary Cambia el estado de varias conversaciones a la vez
+// @Description Cambia al estado indicado (p. ej. closed o archived) todas las conversaciones del usuario indicadas, con una única sentencia. Devuelve el resultado de cada conversación: updated, unchanged, too_frequent o not_found. Requiere rol admin o agent
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param request body services.BulkStatusRequest true "Conversaciones y estado"
+// @Success 200 {object} domain.APIResponse{data=services.BulkStatusResult}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 403 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/bulk-status [post]
+func (h *MessagingHandler) BulkUpdateConversationStatus(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	var req services.BulkStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	result, err := h.messagingService.UpdateConversationStatusBulk(c.Request.Context(), req.ConversationIDs, req.Status, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidBulkStatus) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to update conversation statuses", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update conversations")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Conversations updated", result)
+}
+
+// MarkConversationRead godoc
+// @Summary Marca una conversación como leída
+// @Description Registra que el usuario leyó la conversación hasta el último mensaje. Con up_to_message_id registra además el acuse de cada mensaje hasta ese inclusive y publica message.read por los que no estaban leídos. Un servicio interno puede indicar user_id para marcarla en nombre de un agente
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param request body MarkConversationReadRequest false "Último mensaje leído, o lector en nombre del que actúa un servicio interno"
+// @Success 200 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 403 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/read [post]
+func (h *MessagingHandler) MarkConversationRead(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	conversationID := c.Param("id")
+	if conversationID == "" {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "Conversation ID is required")
+		return
+	}
+
+	var req MarkConversationReadRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+	}
+
+	if _, isService := auth.ServiceIdentityFromContext(c.Request.Context()); req.UserID != "" && !isService {
+		h.respondWithError(c, http.StatusForbidden, "FORBIDDEN", "Only internal services can mark conversations as read on behalf of another user")
+		return
+	}
+
+	if req.UpToMessageID != "" {
+		if req.UserID != "" {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "up_to_message_id cannot be combined with user_id")
+			return
+		}
+
+		if err := h.messagingService.MarkConversationReadUpTo(c.Request.Context(), conversationID, req.UpToMessageID, userID); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Conversation or message not found")
+				return
+			}
+			h.log(c).Error("Failed to mark messages as read", err)
+			h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to mark messages as read")
+			return
+		}
+
+		h.respondWithSuccess(c, http.StatusOK, "Messages marked as read", nil)
+		return
+	}
+
+	if err := h.messagingService.MarkConversationRead(c.Request.Context(), conversationID, userID, req.UserID); err != nil {
+		h.log(c).Error("Failed to mark conversation as read", err)
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Conversation not found")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Conversation marked as read", nil)
+}
+
 // GetMessages godoc
 // @Summary Lista mensajes de una conversación
-// @Description Lista los mensajes de una conversación con paginación
+// @Description Lista los mensajes de una conversación, del más reciente al más antiguo. Con cursor se pagina por posición: next_cursor de la respuesta pide la página siguiente, sin saltar ni repetir mensajes aunque lleguen otros nuevos
 // @Tags messages
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer token"
 // @Param id path string true "ID de la conversación"
 // @Param limit query int false "Límite de resultados" default(50)
-// @Param offset query int false "Offset para paginación" default(0)
+// @Param offset query int false "Offset para paginación; se ignora si se indica cursor" default(0)
+// @Param cursor query string false "Cursor devuelto en next_cursor por la página anterior"
+// @Param include_read_by query bool false "Incluye los acuses de lectura de cada mensaje" default(false)
+// @Param exclude_sender_types query string false "Tipos de remitente a omitir, separados por comas (user, agent, bot, system)"
+// @Param pinned_first query bool false "Antepone los mensajes fijados en la primera página (por defecto MESSAGES_PINNED_FIRST)"
+// @Param include_deleted query bool false "Incluye los mensajes borrados, con deleted_at; solo administradores" default(false)
 // @Success 200 {object} domain.APIResponse{data=[]domain.Message}
+// @Failure 400 {object} domain.APIResponse
 // @Failure 401 {object} domain.APIResponse
+// @Failure 403 {object} domain.APIResponse
 // @Failure 404 {object} domain.APIResponse
 // @Failure 500 {object} domain.APIResponse
 // @Router /conversations/{id}/messages [get]
@@ -222,29 +409,85 @@ func (h *MessagingHandler) GetMessages(c *gin.Context) {
 		SortBy: "timestamp",
 		Order:  "DESC",
 	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		before, err := domain.DecodeMessageCursor(cursor)
+		if err != nil {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_CURSOR", "Invalid cursor")
+			return
+		}
+		pagination.Before = before
+	}
+	pagination.IncludeReadBy = c.Query("include_read_by") == "true"
+	pagination.PinnedFirst = h.pinnedFirst
+	if pinnedFirst := c.Query("pinned_first"); pinnedFirst != "" {
+		pagination.PinnedFirst = pinnedFirst == "true"
+	}
+	for _, senderType := range strings.Split(c.Query("exclude_sender_types"), ",") {
+		if senderType = strings.TrimSpace(senderType); senderType != "" {
+			pagination.ExcludeSenderTypes = append(pagination.ExcludeSenderTypes, domain.SenderType(senderType))
+		}
+	}
+	if c.Query("include_deleted") == "true" {
+		if !h.hasRole(c, "admin") {
+			h.respondWithError(c, http.StatusForbidden, "FORBIDDEN", "Only administrators can list deleted messages")
+			return
+		}
+		pagination.IncludeDeleted = true
+	}
 
 	messages, err := h.messagingService.GetMessages(c.Request.Context(), conversationID, userID, pagination)
 	if err != nil {
-		h.logger.Error("Failed to get messages", err)
+		if errors.Is(err, services.ErrInvalidMessageFilter) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to get messages", err)
 		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get messages")
 		return
 	}
 
-	h.respondWithSuccess(c, http.StatusOK, "Messages retrieved successfully", messages)
+	h.respondWithPage(c, http.StatusOK, "Messages retrieved successfully", messages, nextMessageCursor(messages, pagination))
+}
+
+// nextMessageCursor devuelve el cursor de la página siguiente, o "" si la página no se llenó. Los mensajes
+// fijados antepuestos a la primera página no cuentan, porque no forman parte del recorrido
+func nextMessageCursor(messages []domain.Message, pagination domain.PaginationParams) string {
+	if pagination.Limit <= 0 {
+		return ""
+	}
+
+	page := messages
+	if pagination.PinnedFirst {
+		page = nil
+		for _, message := range messages {
+			if message.PinnedAt == nil {
+				page = append(page, message)
+			}
+		}
+	}
+
+	if len(page) < pagination.Limit {
+		return ""
+	}
+	return domain.NewMessageCursor(page[len(page)-1]).Encode()
 }
 
 // SendMessage godoc
 // @Summary Envía un nuevo mensaje
-// @Description Envía un nuevo mensaje (texto, archivo, IA, etc.)
+// @Description Envía un nuevo mensaje (texto, archivo, IA, etc.). Con delivery_mode=async (por defecto) responde en cuanto el mensaje se guarda, con delivery_status=sent, y lo entrega al canal en segundo plano; con sync espera al proveedor (como mucho DELIVERY_SEND_TIMEOUT) y devuelve el estado final (sent o failed). Con reply_to_id el mensaje responde a otro de la misma conversación; si no existe o es de otra responde 400 INVALID_REPLY. Un mensaje entrante con un metadata.provider_message_id ya guardado en la conversación devuelve el existente, o 409 si INBOUND_DEDUP_ENABLED=false. Cada adjunto debe ser un archivo que subió el remitente (la url devuelta por /attachments/upload); si no existe o es de otro usuario responde 400 INVALID_ATTACHMENT y el mensaje no se guarda. Con Idempotency-Key, un reintento con la misma clave del mismo usuario durante IDEMPOTENCY_KEY_TTL devuelve el mensaje original sin crear otro; 409 IDEMPOTENCY_KEY_IN_PROGRESS si la petición original no ha terminado y 422 IDEMPOTENCY_KEY_REUSED si la clave ya se usó en otra conversación. sender_type lo decide el servidor: agent, bot o system solo para servicios internos y los roles admin y agent; el resto de usuarios siempre envía como user. Con un scheduled_at futuro el mensaje no se envía: pasa ya las reglas de contenido, los hooks y la comprobación de adjuntos, se programa y responde 202 con el envío programado (status scheduled), que se cancela por su id con DELETE /conversations/{id}/scheduled/{scheduledId}; un scheduled_at pasado se envía en el acto
 // @Tags messages
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer token"
 // @Param id path string true "ID de la conversación"
+// @Param Idempotency-Key header string false "Clave para reintentar el envío sin duplicar el mensaje (máx. 255 caracteres)"
 // @Param request body services.SendMessageRequest true "Datos del mensaje"
 // @Success 201 {object} domain.APIResponse{data=domain.Message}
+// @Success 202 {object} domain.APIResponse{data=domain.ScheduledMessage}
 // @Failure 400 {object} domain.APIResponse
 // @Failure 401 {object} domain.APIResponse
+// @Failure 409 {object} domain.APIResponse
+// @Failure 422 {object} domain.APIResponse
 // @Failure 500 {object} domain.APIResponse
 // @Router /conversations/{id}/messages [post]
 func (h *MessagingHandler) SendMessage(c *gin.Context) {
@@ -266,20 +509,114 @@ func (h *MessagingHandler) SendMessage(c *gin.Context) {
 		return
 	}
 
-	// Set conversation ID and sender ID from context
+	// Set conversation ID, sender ID and sender type from context
 	req.ConversationID = conversationID
 	req.SenderID = userID
+	req.SenderType = h.senderType(c, req.SenderType)
+	req.IdempotencyKey = c.GetHeader("Idempotency-Key")
+
+	if req.IsScheduled(time.Now()) {
+		scheduled, err := h.messagingService.ScheduleMessage(c.Request.Context(), req)
+		if err != nil {
+			h.respondWithSendError(c, err, "Failed to schedule message")
+			return
+		}
+		h.respondWithSuccess(c, http.StatusAccepted, "Message scheduled successfully", scheduled)
+		return
+	}
 
 	message, err := h.messagingService.SendMessage(c.Request.Context(), req)
 	if err != nil {
-		h.logger.Error("Failed to send message", err)
-		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to send message")
+		h.respondWithSendError(c, err, "Failed to send message")
 		return
 	}
 
 	h.respondWithSuccess(c, http.StatusCreated, "Message sent successfully", message)
 }
 
+// respondWithSendError traduce los errores de SendMessage y ScheduleMessage, que validan la misma petición
+func (h *MessagingHandler) respondWithSendError(c *gin.Context, err error, message string) {
+	var ruleErr *services.ContentRuleError
+	if errors.As(err, &ruleErr) {
+		h.respondWithError(c, http.StatusBadRequest, ruleErr.Code, ruleErr.Error())
+		return
+	}
+	if errors.Is(err, services.ErrMessageRejected) {
+		h.respondWithError(c, http.StatusBadRequest, "MESSAGE_REJECTED", err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrInvalidDeliveryMode) || errors.Is(err, services.ErrInvalidScheduledMessage) || errors.Is(err, services.ErrScheduledMessagesDisabled) {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrInvalidAttachment) {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_ATTACHMENT", err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrInvalidReply) {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REPLY", err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrDuplicateMessage) {
+		h.respondWithError(c, http.StatusConflict, "DUPLICATE_MESSAGE", err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrInvalidIdempotencyKey) {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrIdempotencyKeyInProgress) {
+		h.respondWithError(c, http.StatusConflict, "IDEMPOTENCY_KEY_IN_PROGRESS", err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrIdempotencyKeyReused) {
+		h.respondWithError(c, http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED", err.Error())
+		return
+	}
+	h.log(c).Error(message, err)
+	h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", message)
+}
+
+// GetMessageCount godoc
+// @Summary Cuenta los mensajes de una conversación
+// @Description Devuelve el número de mensajes guardados de la conversación sin cargarlos, igual que message_count; los vencidos cuentan hasta que se eliminan
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Success 200 {object} domain.APIResponse{data=domain.ConversationMessageCount}
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/message-count [get]
+func (h *MessagingHandler) GetMessageCount(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	conversationID := c.Param("id")
+	if conversationID == "" {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "Conversation ID is required")
+		return
+	}
+
+	count, err := h.messagingService.CountMessages(c.Request.Context(), conversationID, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Conversation not found")
+			return
+		}
+		h.log(c).Error("Failed to count messages", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to count messages")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Message count retrieved successfully", count)
+}
+
 // GetMessage godoc
 // @Summary Consulta un mensaje individual
 // @Description Obtiene los detalles de un mensaje específico
@@ -308,7 +645,7 @@ func (h *MessagingHandler) GetMessage(c *gin.Context) {
 
 	message, err := h.messagingService.GetMessage(c.Request.Context(), messageID, userID)
 	if err != nil {
-		h.logger.Error("Failed to get message", err)
+		h.log(c).Error("Failed to get message", err)
 		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Message not found")
 		return
 	}
@@ -316,128 +653,938 @@ func (h *MessagingHandler) GetMessage(c *gin.Context) {
 	h.respondWithSuccess(c, http.StatusOK, "Message retrieved successfully", message)
 }
 
-// UploadAttachment godoc
-// @Summary Sube un archivo adjunto
-// @Description Sube un archivo y devuelve URL segura
-// @Tags attachments
-// @Accept multipart/form-data
+// GetThread godoc
+// @Summary Consulta el hilo de un mensaje
+// @Description Devuelve el mensaje y sus respuestas directas (las que lo indican en reply_to_id) en orden cronológico
+// @Tags messages
+// @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer token"
-// @Param file formData file true "Archivo a subir"
-// @Success 200 {object} domain.APIResponse{data=UploadResponse}
-// @Failure 400 {object} domain.APIResponse
+// @Param id path string true "ID del mensaje"
+// @Success 200 {object} domain.APIResponse{data=domain.MessageThread}
 // @Failure 401 {object} domain.APIResponse
-// @Failure 500 {object} domain.APIResponse
-// @Router /attachments/upload [post]
-func (h *MessagingHandler) UploadAttachment(c *gin.Context) {
+// @Failure 404 {object} domain.APIResponse
+// @Router /messages/{id}/thread [get]
+func (h *MessagingHandler) GetThread(c *gin.Context) {
 	userID := h.getUserIDFromContext(c)
 	if userID == "" {
 		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
 		return
 	}
 
-	file, header, err := c.Request.FormFile("file")
-	if err != nil {
-		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "File is required")
+	messageID := c.Param("id")
+	if messageID == "" {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "Message ID is required")
 		return
 	}
-	defer file.Close()
-
-	uploadReq := services.UploadFileRequest{
-		File:     file,
-		Filename: header.Filename,
-		Size:     header.Size,
-		UserID:   userID,
-	}
 
-	result, err := h.fileService.UploadFile(c.Request.Context(), uploadReq)
+	thread, err := h.messagingService.GetThread(c.Request.Context(), messageID, userID)
 	if err != nil {
-		h.logger.Error("Failed to upload file", err)
-		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to upload file")
+		h.log(c).Error("Failed to get message thread", err)
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Message not found")
 		return
 	}
 
-	response := UploadResponse{
-		URL:      result.URL,
-		Filename: result.Filename,
-		Size:     result.Size,
-		Type:     result.Type,
-	}
-
-	h.respondWithSuccess(c, http.StatusOK, "File uploaded successfully", response)
+	h.respondWithSuccess(c, http.StatusOK, "Thread retrieved successfully", thread)
 }
 
-// GetAttachment godoc
-// @Summary Obtiene detalles de un archivo adjunto
-// @Description Devuelve los detalles de un archivo adjunto
-// @Tags attachments
+// MarkMessageRead godoc
+// @Summary Marca un mensaje como leído
+// @Description Registra el acuse de lectura del usuario sobre el mensaje y publica el evento message.read
+// @Tags messages
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer token"
-// @Param id path string true "ID del archivo adjunto"
-// @Success 200 {object} domain.APIResponse{data=domain.Attachment}
+// @Param id path string true "ID del mensaje"
+// @Success 200 {object} domain.APIResponse
 // @Failure 401 {object} domain.APIResponse
 // @Failure 404 {object} domain.APIResponse
-// @Failure 500 {object} domain.APIResponse
-// @Router /attachments/{id} [get]
-func (h *MessagingHandler) GetAttachment(c *gin.Context) {
+// @Router /messages/{id}/read [post]
+func (h *MessagingHandler) MarkMessageRead(c *gin.Context) {
 	userID := h.getUserIDFromContext(c)
 	if userID == "" {
 		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
 		return
 	}
 
-	attachmentID := c.Param("id")
-	if attachmentID == "" {
-		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "Attachment ID is required")
+	messageID := c.Param("id")
+	if messageID == "" {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "Message ID is required")
 		return
 	}
 
-	attachment, err := h.messagingService.GetAttachment(c.Request.Context(), attachmentID, userID)
-	if err != nil {
-		h.logger.Error("Failed to get attachment", err)
-		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Attachment not found")
+	if err := h.messagingService.MarkMessageRead(c.Request.Context(), messageID, userID); err != nil {
+		h.log(c).Error("Failed to mark message as read", err)
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Message not found")
 		return
 	}
 
-	h.respondWithSuccess(c, http.StatusOK, "Attachment retrieved successfully", attachment)
+	h.respondWithSuccess(c, http.StatusOK, "Message marked as read", nil)
 }
 
-// Helper methods
+// PinMessage godoc
+// @Summary Fija un mensaje en su conversación
+// @Description Marca el mensaje como fijado (pinned_at). Fijar de nuevo un mensaje fijado conserva su pinned_at
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID del mensaje"
+// @Success 200 {object} domain.APIResponse{data=domain.Message}
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /messages/{id}/pin [post]
+func (h *MessagingHandler) PinMessage(c *gin.Context) {
+	h.setMessagePinned(c, true)
+}
 
-func (h *MessagingHandler) getUserIDFromContext(c *gin.Context) string {
-	token, err := h.jwtManager.ExtractTokenFromHeader(c)
-	if err != nil {
-		return ""
-	}
+// UnpinMessage godoc
+// @Summary Desfija un mensaje
+// @Description Quita la marca de fijado del mensaje; desfijar un mensaje que no lo está no es un error
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID del mensaje"
+// @Success 200 {object} domain.APIResponse{data=domain.Message}
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /messages/{id}/pin [delete]
+func (h *MessagingHandler) UnpinMessage(c *gin.Context) {
+	h.setMessagePinned(c, false)
+}
 
-	claims, err := h.jwtManager.ValidateToken(token)
-	if err != nil {
-		return ""
+func (h *MessagingHandler) setMessagePinned(c *gin.Context, pinned bool) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
 	}
 
-	return claims.UserID
-}
-
-func (h *MessagingHandler) parseIntQuery(c *gin.Context, key string, defaultValue int) int {
-	if value := c.Query(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
+	var message *domain.Message
+	var err error
+	if pinned {
+		message, err = h.messagingService.PinMessage(c.Request.Context(), c.Param("id"), userID)
+	} else {
+		message, err = h.messagingService.UnpinMessage(c.Request.Context(), c.Param("id"), userID)
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Message not found")
+			return
 		}
+		h.log(c).Error("Failed to update message pin", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update message pin")
+		return
 	}
-	return defaultValue
+
+	h.respondWithSuccess(c, http.StatusOK, "Message pin updated", message)
 }
 
-func (h *MessagingHandler) respondWithError(c *gin.Context, statusCode int, code, message string) {
-	response := domain.APIResponse{
-		Code:    code,
-		Message: message,
-		Data:    nil,
+// EditMessage godoc
+// @Summary Edita un mensaje
+// @Description Sustituye el contenido del mensaje y marca edited_at; publica message.edited. Los mensajes de sistema no se pueden editar
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID del mensaje"
+// @Param request body EditMessageRequest true "Contenido nuevo"
+// @Success 200 {object} domain.APIResponse{data=domain.Message}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /messages/{id} [patch]
+func (h *MessagingHandler) EditMessage(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	var req EditMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	message, err := h.messagingService.EditMessage(c.Request.Context(), c.Param("id"), userID, req.Content)
+	if err != nil {
+		var ruleErr *services.ContentRuleError
+		if errors.As(err, &ruleErr) {
+			h.respondWithError(c, http.StatusBadRequest, ruleErr.Code, ruleErr.Error())
+			return
+		}
+		if errors.Is(err, services.ErrMessageNotEditable) {
+			h.respondWithError(c, http.StatusBadRequest, "MESSAGE_NOT_EDITABLE", err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Message not found")
+			return
+		}
+		h.log(c).Error("Failed to edit message", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to edit message")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Message edited successfully", message)
+}
+
+// SearchConversations godoc
+// @Summary Busca conversaciones por identificador del cliente
+// @Description Devuelve las conversaciones cuyos metadatos tienen el external_id o el teléfono indicados (si se indican ambos, los dos), de la más reciente a la más antigua. Solo incluye las conversaciones a las que el usuario tiene acceso como propietario o participante
+// @Tags conversations
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param external_id query string false "metadata.external_id del cliente"
+// @Param phone query string false "metadata.phone del cliente, p. ej. +34600111222"
+// @Param limit query int false "Límite de resultados (máximo 100)" default(20)
+// @Param offset query int false "Offset para paginación" default(0)
+// @Success 200 {object} domain.APIResponse{data=[]domain.Conversation}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/search [get]
+func (h *MessagingHandler) SearchConversations(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	criteria := services.ConversationSearchCriteria{
+		ExternalID: c.Query("external_id"),
+		Phone:      c.Query("phone"),
+	}
+	pagination := domain.PaginationParams{
+		Limit:  h.parseIntQuery(c, "limit", 20),
+		Offset: h.parseIntQuery(c, "offset", 0),
+	}
+
+	conversations, err := h.messagingService.SearchConversations(c.Request.Context(), userID, criteria, pagination)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidConversationSearch) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to search conversations", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to search conversations")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Conversations retrieved successfully", conversations)
+}
+
+// SearchMessages godoc
+// @Summary Busca mensajes por contenido
+// @Description Búsqueda de texto completo, sin distinguir mayúsculas, en los mensajes de las conversaciones del usuario, de más relevante a menos
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param q query string true "Texto a buscar"
+// @Param limit query int false "Límite de resultados" default(50)
+// @Param offset query int false "Offset para paginación" default(0)
+// @Success 200 {object} domain.APIResponse{data=[]domain.Message}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /messages/search [get]
+func (h *MessagingHandler) SearchMessages(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	pagination := domain.PaginationParams{
+		Limit:  h.parseIntQuery(c, "limit", 50),
+		Offset: h.parseIntQuery(c, "offset", 0),
+	}
+
+	messages, err := h.messagingService.SearchMessages(c.Request.Context(), userID, c.Query("q"), pagination)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidMessageFilter) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to search messages", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to search messages")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Messages retrieved successfully", messages)
+}
+
+// GetMessagesAround godoc
+// @Summary Lista mensajes alrededor de un mensaje
+// @Description Devuelve el mensaje indicado junto con los anteriores y posteriores, en orden cronológico. Cada lado admite como máximo 100 mensajes
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param messageId path string true "ID del mensaje objetivo"
+// @Param before query int false "Mensajes anteriores" default(20)
+// @Param after query int false "Mensajes posteriores" default(20)
+// @Success 200 {object} domain.APIResponse{data=[]domain.Message}
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /conversations/{id}/messages/around/{messageId} [get]
+func (h *MessagingHandler) GetMessagesAround(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	conversationID := c.Param("id")
+	messageID := c.Param("messageId")
+	if conversationID == "" || messageID == "" {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "Conversation ID and message ID are required")
+		return
+	}
+
+	before := h.parseIntQuery(c, "before", 20)
+	after := h.parseIntQuery(c, "after", 20)
+
+	messages, err := h.messagingService.GetMessagesAround(c.Request.Context(), conversationID, messageID, userID, before, after)
+	if err != nil {
+		h.log(c).Error("Failed to get messages around target", err)
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Message not found")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Messages retrieved successfully", messages)
+}
+
+// MarkMessagesRead godoc
+// @Summary Marca varios mensajes como leídos
+// @Description Registra en lote los acuses de lectura de mensajes de la conversación; los ya leídos se ignoran
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param request body MarkMessagesReadRequest true "Mensajes leídos"
+// @Success 200 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /conversations/{id}/messages/read [post]
+func (h *MessagingHandler) MarkMessagesRead(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	conversationID := c.Param("id")
+	if conversationID == "" {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "Conversation ID is required")
+		return
+	}
+
+	var req MarkMessagesReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	if err := h.messagingService.MarkMessagesRead(c.Request.Context(), conversationID, req.MessageIDs, userID); err != nil {
+		h.log(c).Error("Failed to mark messages as read", err)
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Conversation not found")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Messages marked as read", nil)
+}
+
+// GetReactions godoc
+// @Summary Lista reacciones de un mensaje
+// @Description Obtiene las reacciones de un mensaje agrupadas por emoji con los usuarios que reaccionaron
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID del mensaje"
+// @Success 200 {object} domain.APIResponse{data=[]domain.ReactionGroup}
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /messages/{id}/reactions [get]
+func (h *MessagingHandler) GetReactions(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	messageID := c.Param("id")
+	if messageID == "" {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "Message ID is required")
+		return
+	}
+
+	reactions, err := h.messagingService.GetReactions(c.Request.Context(), messageID, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Message not found")
+			return
+		}
+		h.log(c).Error("Failed to get reactions", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get reactions")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Reactions retrieved successfully", reactions)
+}
+
+// AddReaction godoc
+// @Summary Reacciona a un mensaje
+// @Description Añade un emoji del usuario al mensaje y publica reaction.added. Un usuario tiene como mucho una reacción de cada emoji por mensaje: repetirla devuelve la existente con 200
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID del mensaje"
+// @Param request body AddReactionRequest true "Emoji"
+// @Success 200 {object} domain.APIResponse{data=domain.Reaction}
+// @Success 201 {object} domain.APIResponse{data=domain.Reaction}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /messages/{id}/reactions [post]
+func (h *MessagingHandler) AddReaction(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	var req AddReactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	reaction, added, err := h.messagingService.AddReaction(c.Request.Context(), c.Param("id"), userID, req.Emoji)
+	if err != nil {
+		h.respondWithReactionError(c, err, "Failed to add reaction")
+		return
+	}
+
+	if !added {
+		h.respondWithSuccess(c, http.StatusOK, "Reaction already exists", reaction)
+		return
+	}
+	h.respondWithSuccess(c, http.StatusCreated, "Reaction added", reaction)
+}
+
+// RemoveReaction godoc
+// @Summary Quita una reacción de un mensaje
+// @Description Quita el emoji del usuario del mensaje y publica reaction.removed; quitar uno que no estaba no es un error
+// @Tags messages
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID del mensaje"
+// @Param emoji path string true "Emoji, codificado para URL"
+// @Success 200 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /messages/{id}/reactions/{emoji} [delete]
+func (h *MessagingHandler) RemoveReaction(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	if err := h.messagingService.RemoveReaction(c.Request.Context(), c.Param("id"), userID, c.Param("emoji")); err != nil {
+		h.respondWithReactionError(c, err, "Failed to remove reaction")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Reaction removed", nil)
+}
+
+func (h *MessagingHandler) respondWithReactionError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidReaction):
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Message not found")
+	default:
+		h.log(c).Error(message, err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", message)
+	}
+}
+
+// UploadAttachment godoc
+// @Summary Sube un archivo adjunto
+// @Description Sube un archivo y devuelve URL segura
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param file formData file true "Archivo a subir"
+// @Success 200 {object} domain.APIResponse{data=UploadResponse}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 413 {object} domain.APIResponse
+// @Failure 422 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /attachments/upload [post]
+func (h *MessagingHandler) UploadAttachment(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "File is required")
+		return
+	}
+	defer file.Close()
+
+	uploadReq := services.UploadFileRequest{
+		File:     file,
+		Filename: header.Filename,
+		Size:     header.Size,
+		UserID:   userID,
+	}
+
+	result, err := h.fileService.UploadFile(c.Request.Context(), uploadReq)
+	if err != nil {
+		h.respondWithUploadError(c, err, "Failed to upload file")
+		return
+	}
+
+	response := UploadResponse{
+		URL:          result.URL,
+		Filename:     result.Filename,
+		Size:         result.Size,
+		Type:         result.Type,
+		ThumbnailURL: result.ThumbnailURL,
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "File uploaded successfully", response)
+}
+
+// GetUserAttachments godoc
+// @Summary Lista los adjuntos del usuario
+// @Description Devuelve los adjuntos de todas las conversaciones del usuario, del más reciente al más antiguo, con la conversación de cada uno
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param type query string false "Tipo de adjunto (image, video, audio, file)"
+// @Param limit query int false "Límite de resultados" default(50)
+// @Param offset query int false "Offset para paginación" default(0)
+// @Success 200 {object} domain.APIResponse{data=[]domain.Attachment}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /attachments [get]
+func (h *MessagingHandler) GetUserAttachments(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	pagination := domain.PaginationParams{
+		Limit:  h.parseIntQuery(c, "limit", 50),
+		Offset: h.parseIntQuery(c, "offset", 0),
+	}
+
+	attachments, err := h.messagingService.GetUserAttachments(c.Request.Context(), userID, domain.AttachmentType(c.Query("type")), pagination)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAttachmentFilter) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to get user attachments", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get attachments")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Attachments retrieved successfully", attachments)
+}
+
+// SearchAttachments godoc
+// @Summary Busca adjuntos por nombre de archivo
+// @Description Devuelve los adjuntos de la conversación cuyo nombre de archivo contiene el texto buscado, sin distinguir mayúsculas, del más reciente al más antiguo
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param q query string true "Texto a buscar en el nombre de archivo"
+// @Param limit query int false "Límite de resultados" default(50)
+// @Param offset query int false "Offset para paginación" default(0)
+// @Success 200 {object} domain.APIResponse{data=[]domain.Attachment}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/attachments/search [get]
+func (h *MessagingHandler) SearchAttachments(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	conversationID := c.Param("id")
+	if conversationID == "" {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "Conversation ID is required")
+		return
+	}
+
+	pagination := domain.PaginationParams{
+		Limit:  h.parseIntQuery(c, "limit", 50),
+		Offset: h.parseIntQuery(c, "offset", 0),
+	}
+
+	attachments, err := h.messagingService.SearchAttachments(c.Request.Context(), conversationID, userID, c.Query("q"), pagination)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAttachmentFilter):
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		case errors.Is(err, domain.ErrNotFound):
+			h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Conversation not found")
+		default:
+			h.log(c).Error("Failed to search attachments", err)
+			h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to search attachments")
+		}
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Attachments retrieved successfully", attachments)
+}
+
+// GetAttachment godoc
+// @Summary Obtiene detalles de un archivo adjunto
+// @Description Devuelve los detalles de un archivo adjunto; url es una URL de descarga firmada que caduca según FILE_URL_TTL
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID del archivo adjunto"
+// @Success 200 {object} domain.APIResponse{data=domain.Attachment}
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /attachments/{id} [get]
+func (h *MessagingHandler) GetAttachment(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	attachmentID := c.Param("id")
+	if attachmentID == "" {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "Attachment ID is required")
+		return
+	}
+
+	attachment, err := h.messagingService.GetAttachment(c.Request.Context(), attachmentID, userID)
+	if err != nil {
+		h.log(c).Error("Failed to get attachment", err)
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Attachment not found")
+		return
+	}
+
+	presignedURL, err := h.fileService.GeneratePresignedURL(c.Request.Context(), attachment.URL, h.attachmentURLTTL)
+	if err != nil {
+		h.log(c).Error("Failed to sign attachment url", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get attachment")
+		return
+	}
+	attachment.URL = presignedURL
+
+	if attachment.ThumbnailURL != "" {
+		attachment.ThumbnailURL, err = h.fileService.GeneratePresignedURL(c.Request.Context(), attachment.ThumbnailURL, h.attachmentURLTTL)
+		if err != nil {
+			h.log(c).Error("Failed to sign attachment thumbnail url", err)
+			h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get attachment")
+			return
+		}
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Attachment retrieved successfully", attachment)
+}
+
+// DeleteAttachment godoc
+// @Summary Borra un archivo adjunto
+// @Description Borra el adjunto y su archivo (y la miniatura, si la tiene). Solo el propietario de la conversación puede hacerlo; si el archivo ya no existía el adjunto se borra igualmente
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID del archivo adjunto"
+// @Success 200 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /attachments/{id} [delete]
+func (h *MessagingHandler) DeleteAttachment(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	if err := h.messagingService.DeleteAttachment(c.Request.Context(), c.Param("id"), userID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Attachment not found")
+			return
+		}
+		h.log(c).Error("Failed to delete attachment", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete attachment")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Attachment deleted successfully", nil)
+}
+
+// PurgeUserData godoc
+// @Summary Purga todos los datos de un usuario
+// @Description Elimina definitivamente las conversaciones, mensajes, adjuntos y archivos del usuario (solo administradores)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param userID path string true "ID del usuario a purgar"
+// @Success 200 {object} domain.APIResponse{data=domain.UserDataPurge}
+// @Failure 401 {object} domain.APIResponse
+// @Failure 403 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /admin/users/{userID}/data [delete]
+func (h *MessagingHandler) PurgeUserData(c *gin.Context) {
+	adminID := h.getUserIDFromContext(c)
+	if adminID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	userID := c.Param("userID")
+	if userID == "" {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "User ID is required")
+		return
+	}
+
+	purge, err := h.messagingService.PurgeUser(c.Request.Context(), userID, adminID)
+	if err != nil {
+		h.log(c).Error("Failed to purge user data", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to purge user data")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "User data purged successfully", purge)
+}
+
+// TransferConversationOwnership godoc
+// @Summary Transfiere en bloque la propiedad de conversaciones
+// @Description Reasigna por lotes las conversaciones de un usuario a otro agente o equipo (solo administradores). Si la respuesta indica completed=false, repetir la misma solicitud continúa donde quedó
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param request body services.OwnershipTransferRequest true "Origen, destino y filtros de la transferencia"
+// @Success 200 {object} domain.APIResponse{data=domain.OwnershipTransfer}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 403 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /admin/conversations/transfer-ownership [post]
+func (h *MessagingHandler) TransferConversationOwnership(c *gin.Context) {
+	adminID := h.getUserIDFromContext(c)
+	if adminID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	var req services.OwnershipTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	transfer, err := h.messagingService.TransferOwnership(c.Request.Context(), req, adminID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTransfer) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to transfer conversation ownership", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to transfer conversation ownership")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Conversation ownership transferred successfully", transfer)
+}
+
+// ListAuditLogs godoc
+// @Summary Consulta la auditoría
+// @Description Entradas de auditoría, las más recientes primero (solo administradores). Se pueden filtrar por usuario, por acción (CONVERSATION_CREATE, CONVERSATION_STATUS_UPDATE...) y por rango de fechas
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param user_id query string false "Usuario que hizo las operaciones"
+// @Param action query string false "Acción auditada"
+// @Param from query string false "Desde esta fecha, incluida (RFC3339)"
+// @Param to query string false "Hasta esta fecha, excluida (RFC3339)"
+// @Param limit query int false "Límite de resultados (máx. 100)" default(50)
+// @Param offset query int false "Offset para paginación" default(0)
+// @Success 200 {object} domain.APIResponse{data=[]domain.AuditLog}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 403 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /admin/audit [get]
+func (h *MessagingHandler) ListAuditLogs(c *gin.Context) {
+	if h.getUserIDFromContext(c) == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	filters := domain.AuditLogFilters{
+		UserID: c.Query("user_id"),
+		Action: c.Query("action"),
+		Limit:  h.parseIntQuery(c, "limit", 50),
+		Offset: h.parseIntQuery(c, "offset", 0),
+	}
+	var err error
+	if filters.From, err = h.parseTimeQuery(c, "from"); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if filters.To, err = h.parseTimeQuery(c, "to"); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	logs, err := h.messagingService.ListAuditLogs(c.Request.Context(), filters)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAuditFilter) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to list audit logs", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list audit logs")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Audit logs retrieved successfully", logs)
+}
+
+// Helper methods
+
+// log devuelve el logger con el request_id y la traza de la petición
+func (h *MessagingHandler) log(c *gin.Context) logger.Logger {
+	return h.logger.WithContext(c.Request.Context())
+}
+
+func (h *MessagingHandler) getUserIDFromContext(c *gin.Context) string {
+	// Internal services authenticated by ServiceOrJWTAuth carry no JWT
+	if service, ok := auth.ServiceIdentityFromContext(c.Request.Context()); ok {
+		return auth.ServiceUserID(service)
+	}
+
+	// Validated once by middleware.Authenticate
+	claims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		return ""
+	}
+
+	return claims.UserID
+}
+
+// senderType decide con qué tipo de remitente se guarda el mensaje. Solo los servicios internos y los roles
+// admin y agent pueden enviar como agent, bot o system; el resto envía siempre como user, pida lo que pida,
+// para que un cliente no pueda hacer que el servicio entregue sus mensajes al canal como si fueran del negocio
+func (h *MessagingHandler) senderType(c *gin.Context, requested domain.SenderType) domain.SenderType {
+	if requested == "" || requested == domain.SenderTypeUser {
+		return domain.SenderTypeUser
+	}
+	if _, isService := auth.ServiceIdentityFromContext(c.Request.Context()); isService {
+		return requested
+	}
+	if h.hasRole(c, "admin") || h.hasRole(c, "agent") {
+		return requested
+	}
+	return domain.SenderTypeUser
+}
+
+// hasRole indica si el token de la petición incluye el rol; las llamadas de servicios internos no tienen roles
+func (h *MessagingHandler) hasRole(c *gin.Context, role string) bool {
+	claims, ok := middleware.ClaimsFromContext(c)
+	if !ok {
+		return false
+	}
+	for _, userRole := range claims.Roles {
+		if userRole == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *MessagingHandler) parseIntQuery(c *gin.Context, key string, defaultValue int) int {
+	if value := c.Query(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+// parseTimeQuery lee un parámetro RFC3339; si no viene devuelve el instante cero
+func (h *MessagingHandler) parseTimeQuery(c *gin.Context, key string) (time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC3339 timestamp", key)
+	}
+	return parsed, nil
+}
+
+func (h *MessagingHandler) respondWithError(c *gin.Context, statusCode int, code, message string) {
+	response := domain.APIResponse{
+		Code:    code,
+		Message: message,
+		Data:    nil,
 	}
 	c.JSON(statusCode, response)
 }
 
 func (h *MessagingHandler) respondWithSuccess(c *gin.Context, statusCode int, message string, data interface{}) {
+	if h.wantsFlatResponse(c) {
+		if data == nil {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		c.JSON(statusCode, data)
+		return
+	}
+
 	response := domain.APIResponse{
 		Code:    "SUCCESS",
 		Message: message,
@@ -446,19 +1593,71 @@ func (h *MessagingHandler) respondWithSuccess(c *gin.Context, statusCode int, me
 	c.JSON(statusCode, response)
 }
 
+// respondWithPage responde como respondWithSuccess y añade el cursor de la página siguiente: en el
+// envoltorio como next_cursor y, sin envoltorio, en la cabecera X-Next-Cursor
+func (h *MessagingHandler) respondWithPage(c *gin.Context, statusCode int, message string, data interface{}, nextCursor string) {
+	if h.wantsFlatResponse(c) {
+		if nextCursor != "" {
+			c.Header("X-Next-Cursor", nextCursor)
+		}
+		c.JSON(statusCode, data)
+		return
+	}
+
+	response := domain.APIResponse{
+		Code:       "SUCCESS",
+		Message:    message,
+		Data:       data,
+		NextCursor: nextCursor,
+	}
+	c.JSON(statusCode, response)
+}
+
+// wantsFlatResponse indica si la respuesta debe omitir el envoltorio APIResponse, por
+// configuración o porque el cliente envió "Prefer: return=representation"
+func (h *MessagingHandler) wantsFlatResponse(c *gin.Context) bool {
+	for _, preference := range strings.Split(c.GetHeader("Prefer"), ",") {
+		if strings.TrimSpace(preference) == preferReturnRepresentation {
+			c.Header("Preference-Applied", preferReturnRepresentation)
+			return true
+		}
+	}
+
+	return h.flatResponses
+}
+
 // Request/Response types
 
 type CreateConversationRequest struct {
-	Channel domain.Channel `json:"channel" binding:"required"`
+	Channel  domain.Channel `json:"channel" binding:"required"`
+	Metadata domain.JSONB   `json:"metadata,omitempty"` // Datos libres del integrador, p. ej. teléfono o ID del CRM
 }
 
 type UpdateConversationRequest struct {
 	Status domain.ConversationStatus `json:"status" binding:"required"`
 }
 
+type EditMessageRequest struct {
+	Content string `json:"content"`
+}
+
+type AddReactionRequest struct {
+	Emoji string `json:"emoji" binding:"required"`
+}
+
+type MarkMessagesReadRequest struct {
+	MessageIDs []string `json:"message_ids" binding:"required,min=1,max=500"`
+}
+
+type MarkConversationReadRequest struct {
+	UserID        string `json:"user_id,omitempty"`
+	UpToMessageID string `json:"up_to_message_id,omitempty"`
+}
+
 type UploadResponse struct {
-	URL      string                `json:"url"`
-	Filename string                `json:"filename"`
-	Size     int64                 `json:"size"`
-	Type     domain.AttachmentType `json:"type"`
+	URL          string                `json:"url"`
+	Filename     string                `json:"filename"`
+	Size         int64                 `json:"size"`
+	Type         domain.AttachmentType `json:"type"`
+	ThumbnailURL string                `json:"thumbnail_url,omitempty"`
 }
\ No newline at end of file
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/integrations/meta"
+	"github.com/gin-gonic/gin"
+)
+
+// maxMetaNotificationBody limita el tamaño de las notificaciones que se leen antes de comprobar la firma
+const maxMetaNotificationBody = 1 << 20
+
+// VerifyMetaWebhook godoc
+// @Summary Verifica la suscripción de un webhook de Meta
+// @Description Responde a la verificación de Meta devolviendo hub.challenge en texto plano cuando hub.mode es subscribe y hub.verify_token coincide con el token del canal (<CANAL>_VERIFY_TOKEN)
+// @Tags webhooks
+// @Produce plain
+// @Param channel path string true "Canal de Meta (whatsapp, messenger, instagram)"
+// @Param hub.mode query string true "Siempre subscribe"
+// @Param hub.verify_token query string true "Token configurado en la app de Meta"
+// @Param hub.challenge query string true "Valor que hay que devolver"
+// @Success 200 {string} string
+// @Failure 403 {object} domain.APIResponse
+// @Router /webhooks/{channel} [get]
+func (h *MessagingHandler) VerifyMetaWebhook(c *gin.Context) {
+	webhook := h.metaWebhooks[domain.Channel(c.Param("channel"))]
+	challenge, ok := webhook.Challenge(c.Query("hub.mode"), c.Query("hub.verify_token"), c.Query("hub.challenge"))
+	if !ok {
+		h.respondWithError(c, http.StatusForbidden, "FORBIDDEN", "Invalid verify token")
+		return
+	}
+
+	c.String(http.StatusOK, challenge)
+}
+
+// HandleMetaWebhook godoc
+// @Summary Recibe mensajes entrantes de WhatsApp, Messenger o Instagram
+// @Description Verifica X-Hub-Signature-256 con el secreto de la app del canal y guarda cada mensaje de texto entrante (sender_type user) en la conversación activa del remitente en ese canal, que se crea si no existe con user_id "<canal>:<id del remitente>". Los reintentos de un mensaje ya guardado y los eventos que no son mensajes de texto se aceptan sin guardar nada. Un error 5xx hace que Meta reintente la notificación
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param X-Hub-Signature-256 header string true "sha256=<HMAC-SHA256 hex del cuerpo con el secreto de la app>"
+// @Param channel path string true "Canal de Meta (whatsapp, messenger, instagram)"
+// @Param request body object true "Notificación de la plataforma"
+// @Success 200 {object} domain.APIResponse{data=meta.IngestResult}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /webhooks/{channel} [post]
+func (h *MessagingHandler) HandleMetaWebhook(c *gin.Context) {
+	channel := c.Param("channel")
+	webhook := h.metaWebhooks[domain.Channel(channel)]
+	if !webhook.Enabled() {
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Inbound webhook not enabled for channel")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxMetaNotificationBody))
+	if err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "Failed to read request body")
+		return
+	}
+
+	if err := webhook.VerifySignature(body, c.GetHeader(meta.SignatureHeader)); err != nil {
+		h.log(c).Warn("Rejected inbound notification", map[string]interface{}{
+			"channel": channel,
+			"error":   err.Error(),
+		})
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid signature")
+		return
+	}
+
+	result, err := webhook.Ingest(c.Request.Context(), body)
+	if err != nil {
+		if errors.Is(err, meta.ErrInvalidNotification) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to ingest inbound notification", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to ingest inbound notification")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Notification processed", result)
+}
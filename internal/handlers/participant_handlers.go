@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// AddParticipant godoc
+// @Summary Añade un participante a la conversación
+// @Description Da acceso de lectura y escritura a la conversación a otro usuario (p. ej. un agente que se une a la conversación de un cliente). Solo el propietario o un servicio interno pueden añadir participantes; si el usuario ya participa se actualiza su rol (member o agent)
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param request body services.AddParticipantRequest true "Usuario y rol"
+// @Success 201 {object} domain.APIResponse{data=domain.ConversationParticipant}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/participants [post]
+func (h *MessagingHandler) AddParticipant(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	var req services.AddParticipantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	participant, err := h.messagingService.AddParticipant(c.Request.Context(), c.Param("id"), req, userID)
+	if err != nil {
+		h.respondWithParticipantError(c, err, "Failed to add participant")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusCreated, "Participant added successfully", participant)
+}
+
+// ListParticipants godoc
+// @Summary Lista los participantes de la conversación
+// @Description Devuelve los participantes de la conversación, sin su propietario, por orden de llegada
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Success 200 {object} domain.APIResponse{data=[]domain.ConversationParticipant}
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/participants [get]
+func (h *MessagingHandler) ListParticipants(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	participants, err := h.messagingService.ListParticipants(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		h.respondWithParticipantError(c, err, "Failed to list participants")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Participants retrieved successfully", participants)
+}
+
+// RemoveParticipant godoc
+// @Summary Retira a un participante de la conversación
+// @Description Retira el acceso de un participante. Pueden hacerlo el propietario, un servicio interno o el propio participante al abandonar la conversación
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param userId path string true "ID del participante"
+// @Success 200 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/participants/{userId} [delete]
+func (h *MessagingHandler) RemoveParticipant(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	if err := h.messagingService.RemoveParticipant(c.Request.Context(), c.Param("id"), c.Param("userId"), userID); err != nil {
+		h.respondWithParticipantError(c, err, "Failed to remove participant")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Participant removed successfully", nil)
+}
+
+func (h *MessagingHandler) respondWithParticipantError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidParticipant):
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+	case errors.Is(err, services.ErrParticipantsDisabled):
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Participants are not enabled")
+	case errors.Is(err, domain.ErrNotFound):
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Conversation or participant not found")
+	default:
+		h.log(c).Error(message, err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", message)
+	}
+}
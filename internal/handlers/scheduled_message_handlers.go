@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CancelScheduledMessage godoc
+// @Summary Cancela un mensaje programado
+// @Description Cancela un envío con scheduled_at que aún no se ha enviado; pueden cancelarlo quien lo programó y el propietario de la conversación. Si ya se envió, falló o se canceló responde 409
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param scheduledId path string true "ID del mensaje programado (el id que devolvió el envío)"
+// @Success 200 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 409 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/scheduled/{scheduledId} [delete]
+func (h *MessagingHandler) CancelScheduledMessage(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	err := h.messagingService.CancelScheduledMessage(c.Request.Context(), c.Param("id"), c.Param("scheduledId"), userID)
+	switch {
+	case err == nil:
+		h.respondWithSuccess(c, http.StatusOK, "Scheduled message cancelled successfully", nil)
+	case errors.Is(err, services.ErrScheduledMessageNotPending):
+		h.respondWithError(c, http.StatusConflict, "SCHEDULED_MESSAGE_NOT_PENDING", err.Error())
+	case errors.Is(err, services.ErrScheduledMessagesDisabled):
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Scheduled messages are not enabled")
+	case errors.Is(err, domain.ErrNotFound):
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Scheduled message not found")
+	default:
+		h.log(c).Error("Failed to cancel scheduled message", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to cancel scheduled message")
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateShareLinkRequest struct {
+	TTLSeconds int64 `json:"ttl_seconds,omitempty"` // 0 usa SHARE_LINK_DEFAULT_TTL
+	ReadOnly   *bool `json:"read_only,omitempty"`   // Por defecto true; solo se admiten enlaces de solo lectura
+}
+
+// CreateShareLink godoc
+// @Summary Crea un enlace compartido de la conversación
+// @Description Crea un enlace firmado y con caducidad que da acceso de solo lectura a la conversación y sus mensajes sin ser participante. El token solo se devuelve en esta respuesta
+// @Tags share-links
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param request body CreateShareLinkRequest false "Duración y alcance del enlace"
+// @Success 201 {object} domain.APIResponse{data=domain.ConversationShareLink}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/share-links [post]
+func (h *MessagingHandler) CreateShareLink(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	var req CreateShareLinkRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+	}
+	readOnly := req.ReadOnly == nil || *req.ReadOnly
+
+	link, err := h.messagingService.CreateShareLink(c.Request.Context(), c.Param("id"), userID, time.Duration(req.TTLSeconds)*time.Second, readOnly)
+	if err != nil {
+		h.respondWithShareLinkError(c, err, "Failed to create share link")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusCreated, "Share link created successfully", link)
+}
+
+// ListShareLinks godoc
+// @Summary Lista los enlaces compartidos de la conversación
+// @Description Devuelve los enlaces de la conversación, incluidos los caducados y revocados, sin sus tokens
+// @Tags share-links
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Success 200 {object} domain.APIResponse{data=[]domain.ConversationShareLink}
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/share-links [get]
+func (h *MessagingHandler) ListShareLinks(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	links, err := h.messagingService.ListShareLinks(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		h.respondWithShareLinkError(c, err, "Failed to list share links")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Share links retrieved successfully", links)
+}
+
+// RevokeShareLink godoc
+// @Summary Revoca un enlace compartido
+// @Description Invalida el enlace en el acto; su token deja de dar acceso aunque no haya caducado
+// @Tags share-links
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param linkId path string true "ID del enlace"
+// @Success 200 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/share-links/{linkId} [delete]
+func (h *MessagingHandler) RevokeShareLink(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	if err := h.messagingService.RevokeShareLink(c.Request.Context(), c.Param("id"), c.Param("linkId"), userID); err != nil {
+		h.respondWithShareLinkError(c, err, "Failed to revoke share link")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Share link revoked successfully", nil)
+}
+
+// GetSharedConversation godoc
+// @Summary Consulta una conversación compartida
+// @Description Sirve en solo lectura la conversación de un enlace compartido y una página de sus mensajes. No requiere JWT: el token firmado del enlace es la credencial. Un token inválido, caducado o revocado devuelve 404
+// @Tags share-links
+// @Accept json
+// @Produce json
+// @Param token path string true "Token del enlace compartido"
+// @Param limit query int false "Límite de mensajes (máx. 200)" default(50)
+// @Param offset query int false "Offset para paginación" default(0)
+// @Success 200 {object} domain.APIResponse{data=domain.SharedConversation}
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /shared/{token} [get]
+func (h *MessagingHandler) GetSharedConversation(c *gin.Context) {
+	pagination := domain.PaginationParams{
+		Limit:  h.parseIntQuery(c, "limit", 50),
+		Offset: h.parseIntQuery(c, "offset", 0),
+		SortBy: "timestamp",
+		Order:  "DESC",
+	}
+
+	shared, err := h.messagingService.GetSharedConversation(c.Request.Context(), c.Param("token"), pagination)
+	if err != nil {
+		h.respondWithShareLinkError(c, err, "Failed to get shared conversation")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Shared conversation retrieved successfully", shared)
+}
+
+func (h *MessagingHandler) respondWithShareLinkError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidShareLink):
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+	case errors.Is(err, services.ErrShareLinksDisabled):
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Share links are not enabled")
+	case errors.Is(err, domain.ErrNotFound):
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Share link not found")
+	default:
+		h.log(c).Error(message, err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", message)
+	}
+}
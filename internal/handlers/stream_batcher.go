@@ -0,0 +1,63 @@
+package handlers
+
+import "time"
+
+// streamBatcher agrupa los eventos de un stream en lotes que se envían como un solo frame. El lote se envía
+// cuando vence la ventana, que empieza con su primer evento, o antes si alcanza maxSize. Con window 0 cada
+// evento va en su propio frame. No es seguro para uso concurrente: lo usa solo el bucle de escritura de
+// cada conexión
+type streamBatcher struct {
+	window  time.Duration
+	maxSize int
+	batch   []interface{}
+	timer   *time.Timer
+}
+
+func newStreamBatcher(window time.Duration, maxSize int) *streamBatcher {
+	return &streamBatcher{
+		window:  window,
+		maxSize: maxSize,
+	}
+}
+
+// Add añade el evento y devuelve el frame que hay que enviar ya: el propio evento si no se agrupa, el lote
+// si se ha llenado, o nil mientras el lote espera a que venza la ventana
+func (b *streamBatcher) Add(event interface{}) interface{} {
+	if b.window <= 0 {
+		return event
+	}
+
+	b.batch = append(b.batch, event)
+	if len(b.batch) == 1 {
+		b.timer = time.NewTimer(b.window)
+	}
+	if len(b.batch) < b.maxSize {
+		return nil
+	}
+	return b.Flush()
+}
+
+// Expired avisa de que venció la ventana del lote en curso; es nil mientras no hay lote, así que un select
+// no lo elige
+func (b *streamBatcher) Expired() <-chan time.Time {
+	if b.timer == nil {
+		return nil
+	}
+	return b.timer.C
+}
+
+// Flush devuelve el lote en curso y empieza uno nuevo
+func (b *streamBatcher) Flush() []interface{} {
+	b.Stop()
+	batch := b.batch
+	b.batch = nil
+	return batch
+}
+
+// Stop para el temporizador del lote en curso
+func (b *streamBatcher) Stop() {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamBatcher_UnbatchedByDefault(t *testing.T) {
+	// Setup
+	batcher := newStreamBatcher(0, 50)
+
+	// Execute
+	frame := batcher.Add("event-1")
+
+	// Assert: each event is its own frame and no window is pending
+	assert.Equal(t, "event-1", frame)
+	assert.Nil(t, batcher.Expired())
+}
+
+func TestStreamBatcher_FlushesWhenFull(t *testing.T) {
+	// Setup
+	batcher := newStreamBatcher(time.Minute, 2)
+	defer batcher.Stop()
+
+	// Execute
+	first := batcher.Add("event-1")
+	second := batcher.Add("event-2")
+
+	// Assert
+	assert.Nil(t, first)
+	assert.Equal(t, []interface{}{"event-1", "event-2"}, second)
+	assert.Nil(t, batcher.Expired())
+}
+
+func TestStreamBatcher_FlushesOnWindowExpiry(t *testing.T) {
+	// Setup
+	batcher := newStreamBatcher(10*time.Millisecond, 50)
+	defer batcher.Stop()
+
+	// Execute
+	require.Nil(t, batcher.Add("event-1"))
+	require.Nil(t, batcher.Add("event-2"))
+
+	// Assert: the window started with the first event
+	select {
+	case <-batcher.Expired():
+	case <-time.After(time.Second):
+		t.Fatal("batch window did not expire")
+	}
+	assert.Equal(t, []interface{}{"event-1", "event-2"}, batcher.Flush())
+	assert.Nil(t, batcher.Expired())
+}
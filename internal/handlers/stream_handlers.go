@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsWriteWait limita cada escritura en el socket
+	wsWriteWait = 10 * time.Second
+	// wsPongWait es cuánto se espera la respuesta a un ping antes de dar la conexión por muerta
+	wsPongWait = 60 * time.Second
+	// wsPingPeriod debe ser menor que wsPongWait para que el pong llegue a tiempo
+	wsPingPeriod = wsPongWait * 9 / 10
+	// wsMaxMessageSize acota lo que puede enviar el cliente, que solo responde a los pings
+	wsMaxMessageSize = 512
+)
+
+// streamUpgrader acepta cualquier origen, igual que la política CORS: la autenticación va en la
+// cabecera Authorization o en el subprotocolo, no en cookies, así que otra web no puede abrir el socket en
+// nombre del usuario. Del subprotocolo solo se devuelve "bearer", nunca el token
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	Subprotocols:    []string{auth.WebSocketTokenProtocol},
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// StreamConversation godoc
+// @Summary Recibe los eventos de una conversación en tiempo real
+// @Description Abre un WebSocket que envía como JSON cada MessageEvent publicado en la conversación y los TypingEvent efímeros de los demás participantes, o con WS_BATCH_WINDOW un array con los eventos de cada ventana. El servidor hace ping periódicamente y cierra la conexión si el cliente no responde
+// @Tags conversations
+// @Param Authorization header string false "Bearer token"
+// @Param Sec-WebSocket-Protocol header string false "bearer, {token}; para navegadores, que no pueden enviar Authorization"
+// @Param id path string true "ID de la conversación"
+// @Success 101 {object} domain.MessageEvent
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 503 {object} domain.APIResponse
+// @Router /conversations/{id}/ws [get]
+func (h *MessagingHandler) StreamConversation(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	conversationID := c.Param("id")
+
+	// Ownership is checked before upgrading so the client still gets a regular HTTP error
+	if _, err := h.messagingService.GetConversation(c.Request.Context(), conversationID, userID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Conversation not found")
+			return
+		}
+		h.log(c).Error("Failed to get conversation", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get conversation")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	// Hijacked connections are not closed by http.Server.Shutdown, so the lifecycle manager ends them
+	var serverStopping <-chan struct{}
+	if h.streams != nil {
+		shutdownCtx, done := h.streams.Track("websocket-stream")
+		defer done()
+		defer context.AfterFunc(shutdownCtx, cancel)()
+		serverStopping = shutdownCtx.Done()
+	}
+
+	events, err := h.events.Subscribe(ctx, conversationID)
+	if err != nil {
+		h.log(c).Error("Failed to subscribe to conversation events", err)
+		h.respondWithError(c, http.StatusServiceUnavailable, "EVENTS_UNAVAILABLE", "Real-time events are not available")
+		return
+	}
+
+	// Upgrade writes its own HTTP error when the handshake is invalid
+	conn, err := streamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// The client only answers pings; a read error means it went away
+	go func() {
+		defer cancel()
+		conn.SetReadLimit(wsMaxMessageSize)
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		})
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	writeFrame := func(frame interface{}) error {
+		conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		return conn.WriteJSON(frame)
+	}
+
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	batcher := newStreamBatcher(h.batchWindow, h.batchMaxSize)
+	defer batcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			select {
+			case <-serverStopping:
+				closeFrame := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+				conn.WriteControl(websocket.CloseMessage, closeFrame, time.Now().Add(wsWriteWait))
+			default:
+			}
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			// Typing is relayed to everyone else in the conversation, never echoed to the writer
+			if isOwnTyping(event, userID) {
+				continue
+			}
+			// While a batch waits for its window, Add returns nil
+			if frame := batcher.Add(event); frame != nil {
+				if err := writeFrame(frame); err != nil {
+					return
+				}
+			}
+		case <-batcher.Expired():
+			if err := writeFrame(batcher.Flush()); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// isOwnTyping indica si el evento es un indicador de escritura del propio usuario conectado
+func isOwnTyping(event json.RawMessage, userID string) bool {
+	var typing domain.TypingEvent
+	if err := json.Unmarshal(event, &typing); err != nil {
+		return false
+	}
+	return typing.Type == "typing" && typing.UserID == userID
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/repositories"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/company/microservice-template/pkg/lifecycle"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamConversationRepository sirve una única conversación; el resto de operaciones no está disponible
+type streamConversationRepository struct {
+	domain.ConversationRepository
+	conversation *domain.Conversation
+}
+
+func (r *streamConversationRepository) GetByID(ctx context.Context, id string) (*domain.Conversation, error) {
+	if id != r.conversation.ID {
+		return nil, domain.ErrNotFound
+	}
+	copied := *r.conversation
+	return &copied, nil
+}
+
+func (r *streamConversationRepository) TouchUpdatedAt(ctx context.Context, id string, updatedAt time.Time) error {
+	return nil
+}
+
+// streamMessageRepository acepta los mensajes nuevos sin guardarlos
+type streamMessageRepository struct {
+	domain.MessageRepository
+}
+
+func (r *streamMessageRepository) Create(ctx context.Context, message *domain.Message) error {
+	return nil
+}
+
+// streamTestServer levanta las rutas con eventos sobre miniredis y la conversación conv123 de user123
+type streamTestServer struct {
+	redis            *miniredis.Miniredis
+	publisher        services.EventPublisher
+	messagingService services.MessagingService
+	jwtManager       *auth.JWTManager
+	url              string
+	httpURL          string
+}
+
+func newStreamTestServer(t *testing.T, opts ...RouteOption) *streamTestServer {
+	gin.SetMode(gin.TestMode)
+	redisServer := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: redisServer.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	log := logger.NewLogger("debug")
+	publisher := services.NewRedisEventPublisher(client, "message.events", log)
+	conversationRepo := &streamConversationRepository{
+		ConversationRepository: repositories.NewNoOpConversationRepository(),
+		conversation:           &domain.Conversation{ID: "conv123", UserID: "user123", Channel: domain.ChannelWeb, Status: domain.ConversationStatusActive},
+	}
+	messagingService := services.NewMessagingService(
+		conversationRepo,
+		&streamMessageRepository{MessageRepository: repositories.NewNoOpMessageRepository()},
+		repositories.NewNoOpAttachmentRepository(),
+		publisher,
+		services.NewNoOpCacheService(),
+		log,
+	)
+	jwtManager := auth.NewJWTManager("test-secret", "test-issuer")
+
+	router := gin.New()
+	opts = append([]RouteOption{WithEventStream(services.NewRedisEventSubscriber(client, "message.events", log))}, opts...)
+	SetupRoutes(router, services.NewHealthService(), messagingService, services.NewNoOpFileService(), jwtManager, log, opts...)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return &streamTestServer{
+		redis:            redisServer,
+		publisher:        publisher,
+		messagingService: messagingService,
+		jwtManager:       jwtManager,
+		url:              "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/messaging/conversations/",
+		httpURL:          server.URL + "/api/v1/messaging/conversations/",
+	}
+}
+
+// userHeader autentica como userID con un JWT que incluye roles
+func (s *streamTestServer) userHeader(t *testing.T, userID string, roles ...string) http.Header {
+	if len(roles) == 0 {
+		roles = []string{"user"}
+	}
+	token, err := s.jwtManager.GenerateToken(userID, userID+"@example.com", roles)
+	require.NoError(t, err)
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+// dial abre el WebSocket de la conversación con el JWT de userID
+func (s *streamTestServer) dial(t *testing.T, conversationID string, userID string) (*websocket.Conn, *http.Response, error) {
+	return s.dialWith(conversationID, s.userHeader(t, userID))
+}
+
+func (s *streamTestServer) dialWith(conversationID string, header http.Header) (*websocket.Conn, *http.Response, error) {
+	return websocket.DefaultDialer.Dial(s.url+conversationID+"/ws", header)
+}
+
+// post hace una petición POST a la ruta relativa a las conversaciones
+func (s *streamTestServer) post(t *testing.T, path string, header http.Header, body string) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, s.httpURL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header = header.Clone()
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func (s *streamTestServer) publish(t *testing.T, conversationID string, eventType string) {
+	require.NoError(t, s.publisher.PublishMessageEvent(context.Background(), domain.MessageEvent{
+		Type:           eventType,
+		ConversationID: conversationID,
+		Timestamp:      time.Now(),
+	}))
+}
+
+func TestStreamConversation(t *testing.T) {
+	server := newStreamTestServer(t)
+
+	// Another user's conversation is rejected before the upgrade
+	_, resp, err := server.dial(t, "conv123", "intruder")
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// The owner connects and receives the event of a message sent through the service
+	conn, _, err := server.dial(t, "conv123", "user123")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Unrelated conversations are filtered out
+	server.publish(t, "conv999", "message.received")
+
+	message, err := server.messagingService.SendMessage(context.Background(), services.SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "user123",
+		Content:        "Hola",
+		ContentType:    domain.ContentTypeText,
+	})
+	require.NoError(t, err)
+
+	var event domain.MessageEvent
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, "message.received", event.Type)
+	assert.Equal(t, "conv123", event.ConversationID)
+	assert.Equal(t, message.ID, event.Message.ID)
+	assert.Equal(t, "Hola", event.Message.Content)
+
+	// Closing the socket releases the Redis subscription
+	conn.Close()
+	assert.Eventually(t, func() bool {
+		return server.redis.PubSubNumSub("message.events")["message.events"] == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestStreamConversation_TokenInSubprotocol(t *testing.T) {
+	server := newStreamTestServer(t)
+	token := strings.TrimPrefix(server.userHeader(t, "user123").Get("Authorization"), "Bearer ")
+
+	// Browsers can't set Authorization on a WebSocket, so the token goes after the bearer subprotocol
+	conn, resp, err := server.dialWith("conv123", http.Header{"Sec-WebSocket-Protocol": {"bearer, " + token}})
+	require.NoError(t, err)
+	defer conn.Close()
+	// Only the marker protocol is echoed back, never the token
+	assert.Equal(t, "bearer", conn.Subprotocol())
+	assert.Equal(t, "bearer", resp.Header.Get("Sec-WebSocket-Protocol"))
+
+	server.publish(t, "conv123", "message.received")
+	var event domain.MessageEvent
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, "message.received", event.Type)
+
+	// An invalid token is still rejected before the upgrade
+	_, resp, err = server.dialWith("conv123", http.Header{"Sec-WebSocket-Protocol": {"bearer, not-a-token"}})
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Outside the handshake the header is not a credential
+	resp = server.post(t, "conv123/typing", http.Header{"Sec-WebSocket-Protocol": {"bearer, " + token}}, `{}`)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestStreamConversation_Batching(t *testing.T) {
+	server := newStreamTestServer(t, WithStreamBatching(200*time.Millisecond, 2))
+
+	conn, _, err := server.dial(t, "conv123", "user123")
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	// Reaching the max size flushes without waiting for the window
+	server.publish(t, "conv123", "message.received")
+	server.publish(t, "conv123", "message.read")
+	var batch []domain.MessageEvent
+	require.NoError(t, conn.ReadJSON(&batch))
+	require.Len(t, batch, 2)
+	assert.Equal(t, "message.received", batch[0].Type)
+	assert.Equal(t, "message.read", batch[1].Type)
+
+	// A partial batch is flushed when the window expires
+	server.publish(t, "conv123", "message.edited")
+	require.NoError(t, conn.ReadJSON(&batch))
+	require.Len(t, batch, 1)
+	assert.Equal(t, "message.edited", batch[0].Type)
+}
+
+func TestSetTyping_RelayedToOtherParticipants(t *testing.T) {
+	serviceTokens := auth.NewServiceTokenVerifier("service-secret")
+	server := newStreamTestServer(t, WithServiceAuth(serviceTokens, nil), WithTypingAgentRoles([]string{"support"}))
+	agentHeader := http.Header{auth.ServiceTokenHeader: {serviceTokens.GenerateToken("agent-desk")}}
+
+	customer, _, err := server.dial(t, "conv123", "user123")
+	require.NoError(t, err)
+	defer customer.Close()
+	agent, _, err := server.dialWith("conv123", agentHeader)
+	require.NoError(t, err)
+	defer agent.Close()
+	require.NoError(t, customer.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, agent.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	// Only participants can publish typing
+	resp := server.post(t, "conv123/typing", server.userHeader(t, "intruder"), "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// The customer's typing reaches the agent
+	resp = server.post(t, "conv123/typing", server.userHeader(t, "user123"), "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var event domain.TypingEvent
+	require.NoError(t, agent.ReadJSON(&event))
+	assert.Equal(t, "typing", event.Type)
+	assert.Equal(t, "conv123", event.ConversationID)
+	assert.Equal(t, "user123", event.UserID)
+	assert.Equal(t, domain.TypingRoleCustomer, event.Role)
+	assert.True(t, event.IsTyping)
+	assert.True(t, event.ExpiresAt.After(time.Now()))
+
+	// The agent's typing reaches the customer, who never got their own event back
+	resp = server.post(t, "conv123/typing", agentHeader, `{"is_typing": false}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NoError(t, customer.ReadJSON(&event))
+	assert.Equal(t, auth.ServiceUserID("agent-desk"), event.UserID)
+	assert.Equal(t, domain.TypingRoleAgent, event.Role)
+	assert.False(t, event.IsTyping)
+
+	// Closing the sockets releases the conversation's typing channel
+	customer.Close()
+	agent.Close()
+	channel := services.ConversationEventChannel("conv123")
+	assert.Eventually(t, func() bool {
+		return server.redis.PubSubNumSub(channel)[channel] == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSetTyping_AgentRolesFromConfig(t *testing.T) {
+	server := newStreamTestServer(t, WithTypingAgentRoles([]string{"support"}))
+
+	// A conversation transferred to a support user is typed in as an agent
+	resp := server.post(t, "conv123/typing", server.userHeader(t, "user123", "user", "support"), "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Data domain.TypingEvent `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, domain.TypingRoleAgent, body.Data.Role)
+}
+
+func TestStreamConversation_ClosedOnShutdown(t *testing.T) {
+	manager := lifecycle.NewManager(logger.NewLogger("debug"))
+	server := newStreamTestServer(t, WithStreamLifecycle(manager))
+
+	conn, _, err := server.dial(t, "conv123", "user123")
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Eventually(t, func() bool {
+		return server.redis.PubSubNumSub("message.events")["message.events"] == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Shutdown waits for the stream to end within the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, manager.Shutdown(ctx))
+
+	// The client is told the server is going away and the Redis subscription is released
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "unexpected error: %v", err)
+	assert.Eventually(t, func() bool {
+		return server.redis.PubSubNumSub("message.events")["message.events"] == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// BulkTagConversations godoc
+// @Summary Etiqueta varias conversaciones a la vez
+// @Description Añade la etiqueta (normalizada a minúsculas, sin duplicados) a todas las conversaciones del usuario indicadas, en una única transacción. Devuelve el resultado de cada conversación: tagged, already_tagged o not_found
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param request body services.BulkTagRequest true "Conversaciones y etiqueta"
+// @Success 200 {object} domain.APIResponse{data=services.BulkTagResult}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/bulk-tag [post]
+func (h *MessagingHandler) BulkTagConversations(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	var req services.BulkTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	result, err := h.messagingService.AddTagToConversations(c.Request.Context(), req.ConversationIDs, req.Tag, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTag) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to tag conversations", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to tag conversations")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Conversations tagged", result)
+}
+
+// TagRequest es la etiqueta que se añade a una conversación
+type TagRequest struct {
+	Tag string `json:"tag" binding:"required"`
+}
+
+// ListConversationTags godoc
+// @Summary Lista las etiquetas de una conversación
+// @Description Devuelve las etiquetas de la conversación en el orden en que se añadieron
+// @Tags conversations
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Success 200 {object} domain.APIResponse{data=[]string}
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/tags [get]
+func (h *MessagingHandler) ListConversationTags(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	tags, err := h.messagingService.ListTags(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		h.respondWithTagError(c, err, "Failed to get tags")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Tags retrieved successfully", tags)
+}
+
+// AddConversationTag godoc
+// @Summary Etiqueta una conversación
+// @Description Añade la etiqueta (normalizada a minúsculas, con guiones en lugar de espacios) y devuelve las etiquetas de la conversación; añadir una que ya tenía no es un error
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param request body TagRequest true "Etiqueta"
+// @Success 200 {object} domain.APIResponse{data=[]string}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/tags [post]
+func (h *MessagingHandler) AddConversationTag(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	var req TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	tags, err := h.messagingService.AddTag(c.Request.Context(), c.Param("id"), req.Tag, userID)
+	if err != nil {
+		h.respondWithTagError(c, err, "Failed to tag conversation")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Conversation tagged", tags)
+}
+
+// RemoveConversationTag godoc
+// @Summary Quita una etiqueta de una conversación
+// @Description Quita la etiqueta y devuelve las que le quedan a la conversación; quitar una que no tenía no es un error
+// @Tags conversations
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param tag path string true "Etiqueta"
+// @Success 200 {object} domain.APIResponse{data=[]string}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/tags/{tag} [delete]
+func (h *MessagingHandler) RemoveConversationTag(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	tags, err := h.messagingService.RemoveTag(c.Request.Context(), c.Param("id"), c.Param("tag"), userID)
+	if err != nil {
+		h.respondWithTagError(c, err, "Failed to remove tag")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Tag removed", tags)
+}
+
+func (h *MessagingHandler) respondWithTagError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTag):
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Conversation not found")
+	default:
+		h.log(c).Error(message, err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", message)
+	}
+}
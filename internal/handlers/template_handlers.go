@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateFromTemplateRequest struct {
+	Variables map[string]string `json:"variables"`
+}
+
+// ListTemplates godoc
+// @Summary Lista plantillas de conversación
+// @Description Obtiene las plantillas disponibles para iniciar conversaciones guionizadas
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param limit query int false "Límite de resultados" default(20)
+// @Param offset query int false "Offset para paginación" default(0)
+// @Success 200 {object} domain.APIResponse{data=[]domain.ConversationTemplate}
+// @Failure 401 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /templates [get]
+func (h *MessagingHandler) ListTemplates(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	pagination := domain.PaginationParams{
+		Limit:  h.parseIntQuery(c, "limit", 20),
+		Offset: h.parseIntQuery(c, "offset", 0),
+	}
+
+	templates, err := h.messagingService.ListTemplates(c.Request.Context(), pagination)
+	if err != nil {
+		h.log(c).Error("Failed to list templates", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list templates")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Templates retrieved successfully", templates)
+}
+
+// GetTemplate godoc
+// @Summary Obtiene una plantilla de conversación
+// @Description Trae la plantilla con su secuencia de mensajes
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la plantilla"
+// @Success 200 {object} domain.APIResponse{data=domain.ConversationTemplate}
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /templates/{id} [get]
+func (h *MessagingHandler) GetTemplate(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	template, err := h.messagingService.GetTemplate(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.log(c).Error("Failed to get template", err)
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Template not found")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Template retrieved successfully", template)
+}
+
+// CreateConversationFromTemplate godoc
+// @Summary Crea una conversación a partir de una plantilla
+// @Description Crea la conversación e inserta en orden los mensajes de la plantilla con las variables renderizadas
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la plantilla"
+// @Param request body CreateFromTemplateRequest true "Variables de la plantilla"
+// @Success 201 {object} domain.APIResponse{data=domain.Conversation}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /templates/{id}/conversations [post]
+func (h *MessagingHandler) CreateConversationFromTemplate(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	var req CreateFromTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	conversation, err := h.messagingService.CreateConversationFromTemplate(c.Request.Context(), userID, c.Param("id"), req.Variables)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTemplate) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to create conversation from template", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create conversation")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusCreated, "Conversation created successfully", conversation)
+}
+
+// CreateTemplate godoc
+// @Summary Crea una plantilla de conversación
+// @Description Define una secuencia de mensajes de bot/sistema con variables {{nombre}} (solo administradores)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param request body services.ConversationTemplateRequest true "Datos de la plantilla"
+// @Success 201 {object} domain.APIResponse{data=domain.ConversationTemplate}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 403 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /admin/templates [post]
+func (h *MessagingHandler) CreateTemplate(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	var req services.ConversationTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	template, err := h.messagingService.CreateTemplate(c.Request.Context(), req, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTemplate) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to create template", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create template")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusCreated, "Template created successfully", template)
+}
+
+// UpdateTemplate godoc
+// @Summary Actualiza una plantilla de conversación
+// @Description Reemplaza el nombre, canal y secuencia de mensajes de la plantilla (solo administradores)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la plantilla"
+// @Param request body services.ConversationTemplateRequest true "Datos de la plantilla"
+// @Success 200 {object} domain.APIResponse{data=domain.ConversationTemplate}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 403 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /admin/templates/{id} [put]
+func (h *MessagingHandler) UpdateTemplate(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	var req services.ConversationTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	template, err := h.messagingService.UpdateTemplate(c.Request.Context(), c.Param("id"), req, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTemplate) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to update template", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update template")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Template updated successfully", template)
+}
+
+// DeleteTemplate godoc
+// @Summary Elimina una plantilla de conversación
+// @Description Elimina la plantilla; las conversaciones ya creadas no se modifican (solo administradores)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la plantilla"
+// @Success 200 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 403 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /admin/templates/{id} [delete]
+func (h *MessagingHandler) DeleteTemplate(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	if err := h.messagingService.DeleteTemplate(c.Request.Context(), c.Param("id"), userID); err != nil {
+		h.log(c).Error("Failed to delete template", err)
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Template not found")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Template deleted successfully", nil)
+}
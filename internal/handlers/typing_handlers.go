@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// TypingRequest indica si el usuario está escribiendo; si se omite is_typing se asume true
+type TypingRequest struct {
+	IsTyping *bool `json:"is_typing,omitempty"`
+}
+
+// SetTyping godoc
+// @Summary Publica el indicador de escritura
+// @Description Publica un evento typing efímero en la conversación, que reciben por WebSocket los demás clientes y agentes conectados. role es agent para los servicios internos y los roles de TYPING_AGENT_ROLES, y customer para el resto. No se guarda; caduca en expires_at si el cliente no lo renueva
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param request body TypingRequest false "Estado de escritura"
+// @Success 200 {object} domain.APIResponse{data=domain.TypingEvent}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /conversations/{id}/typing [post]
+func (h *MessagingHandler) SetTyping(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	var req TypingRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+	}
+
+	isTyping := true
+	if req.IsTyping != nil {
+		isTyping = *req.IsTyping
+	}
+
+	event, err := h.messagingService.SetTyping(c.Request.Context(), c.Param("id"), userID, h.typingRole(c), isTyping)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Conversation not found")
+			return
+		}
+		h.log(c).Error("Failed to publish typing event", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to publish typing event")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Typing indicator published", event)
+}
+
+// typingRole decide si el usuario escribe como agente o como cliente
+func (h *MessagingHandler) typingRole(c *gin.Context) domain.TypingRole {
+	_, isService := auth.ServiceIdentityFromContext(c.Request.Context())
+	return services.ResolveTypingRole(isService, c.GetStringSlice("user_roles"), h.agentRoles)
+}
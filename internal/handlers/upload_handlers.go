@@ -0,0 +1,410 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultAttachmentURLTTL es la validez de las URLs de descarga cuando no se configura FILE_URL_TTL
+	defaultAttachmentURLTTL = 15 * time.Minute
+	// defaultMaxBatchFiles es el máximo de archivos por subida múltiple cuando no se configura FILE_UPLOAD_MAX_BATCH
+	defaultMaxBatchFiles = 10
+)
+
+type InitUploadRequest struct {
+	Filename  string `json:"filename" binding:"required"`
+	TotalSize int64  `json:"total_size" binding:"required"`
+}
+
+// InitUpload godoc
+// @Summary Inicia una subida reanudable
+// @Description Crea una subida por partes y devuelve su ID; las partes incompletas se descartan al expirar
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param request body InitUploadRequest true "Nombre y tamaño total del archivo"
+// @Success 201 {object} domain.APIResponse{data=domain.UploadSession}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /attachments/upload/init [post]
+func (h *MessagingHandler) InitUpload(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	var req InitUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	session, err := h.chunkedUploads.InitUpload(c.Request.Context(), userID, req.Filename, req.TotalSize)
+	if err != nil {
+		h.respondWithUploadError(c, err, "Failed to init upload")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusCreated, "Upload initialized successfully", session)
+}
+
+// UploadChunk godoc
+// @Summary Envía una parte de una subida reanudable
+// @Description Recibe el rango indicado en Content-Range (bytes inicio-fin/total) con los bytes en el cuerpo
+// @Tags attachments
+// @Accept application/octet-stream
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param Content-Range header string true "Rango de bytes, p. ej. bytes 0-1048575/5242880"
+// @Param id path string true "ID de la subida"
+// @Success 200 {object} domain.APIResponse{data=domain.UploadSession}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /attachments/upload/{id} [patch]
+func (h *MessagingHandler) UploadChunk(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	start, end, err := parseContentRange(c.GetHeader("Content-Range"))
+	if err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	// The declared size is checked before reading so an oversized range never gets buffered
+	expected := end - start + 1
+	if err := h.chunkedUploads.CheckChunkSize(expected); err != nil {
+		h.respondWithUploadError(c, err, "Failed to store chunk")
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, expected))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "Chunk size does not match Content-Range")
+			return
+		}
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "Failed to read chunk")
+		return
+	}
+	if int64(len(data)) != expected {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "Chunk size does not match Content-Range")
+		return
+	}
+
+	session, err := h.chunkedUploads.UploadChunk(c.Request.Context(), c.Param("id"), userID, start, data)
+	if err != nil {
+		h.respondWithUploadError(c, err, "Failed to store chunk")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Chunk received successfully", session)
+}
+
+// GetUpload godoc
+// @Summary Consulta el estado de una subida reanudable
+// @Description Devuelve los rangos recibidos y el siguiente offset pendiente para reanudar
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la subida"
+// @Success 200 {object} domain.APIResponse{data=domain.UploadSession}
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /attachments/upload/{id} [get]
+func (h *MessagingHandler) GetUpload(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	session, err := h.chunkedUploads.GetUpload(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		h.respondWithUploadError(c, err, "Failed to get upload")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Upload retrieved successfully", session)
+}
+
+// CompleteUpload godoc
+// @Summary Finaliza una subida reanudable
+// @Description Ensambla las partes recibidas, guarda el archivo y devuelve su URL
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la subida"
+// @Success 200 {object} domain.APIResponse{data=UploadResponse}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 409 {object} domain.APIResponse
+// @Failure 413 {object} domain.APIResponse
+// @Failure 422 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /attachments/upload/{id}/complete [post]
+func (h *MessagingHandler) CompleteUpload(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	result, err := h.chunkedUploads.CompleteUpload(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		h.respondWithUploadError(c, err, "Failed to complete upload")
+		return
+	}
+
+	response := UploadResponse{
+		URL:          result.URL,
+		Filename:     result.Filename,
+		Size:         result.Size,
+		Type:         result.Type,
+		ThumbnailURL: result.ThumbnailURL,
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "File uploaded successfully", response)
+}
+
+// UploadAttachments godoc
+// @Summary Sube varios archivos adjuntos
+// @Description Sube los archivos del campo files, hasta FILE_UPLOAD_MAX_BATCH, y devuelve sus URLs en el mismo orden. Cada archivo debe respetar FILE_STORAGE_MAX_SIZE; si uno falla se borran los ya subidos y no se devuelve ninguno
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param files formData file true "Archivos a subir (repetir el campo por cada archivo)"
+// @Success 200 {object} domain.APIResponse{data=[]UploadResponse}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 413 {object} domain.APIResponse
+// @Failure 422 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /attachments/upload/batch [post]
+func (h *MessagingHandler) UploadAttachments(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["files"]) == 0 {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", "At least one file is required in the files field")
+		return
+	}
+	files := form.File["files"]
+	if len(files) > h.maxBatchFiles {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", fmt.Sprintf("At most %d files can be uploaded at once", h.maxBatchFiles))
+		return
+	}
+
+	responses := make([]UploadResponse, 0, len(files))
+	for _, header := range files {
+		result, err := h.uploadFormFile(c.Request.Context(), header, userID)
+		if err != nil {
+			h.deleteUploadedFiles(c.Request.Context(), responses)
+			h.respondWithUploadError(c, fmt.Errorf("%s: %w", header.Filename, err), "Failed to upload files")
+			return
+		}
+
+		responses = append(responses, UploadResponse{
+			URL:          result.URL,
+			Filename:     result.Filename,
+			Size:         result.Size,
+			Type:         result.Type,
+			ThumbnailURL: result.ThumbnailURL,
+		})
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Files uploaded successfully", responses)
+}
+
+func (h *MessagingHandler) uploadFormFile(ctx context.Context, header *multipart.FileHeader, userID string) (*services.UploadFileResponse, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer file.Close()
+
+	return h.fileService.UploadFile(ctx, services.UploadFileRequest{
+		File:     file,
+		Filename: header.Filename,
+		Size:     header.Size,
+		UserID:   userID,
+	})
+}
+
+// deleteUploadedFiles deshace, en lo posible, los archivos ya subidos de una subida múltiple que ha fallado
+func (h *MessagingHandler) deleteUploadedFiles(ctx context.Context, uploaded []UploadResponse) {
+	// The client may be gone already; the cleanup must still happen
+	ctx = context.WithoutCancel(ctx)
+
+	for _, upload := range uploaded {
+		urls := []string{upload.URL}
+		if upload.ThumbnailURL != "" && upload.ThumbnailURL != upload.URL {
+			urls = append(urls, upload.ThumbnailURL)
+		}
+		for _, url := range urls {
+			if err := h.fileService.DeleteFile(ctx, url); err != nil {
+				h.logger.WithContext(ctx).Warn("Failed to delete file of failed batch upload", map[string]interface{}{
+					"url":   url,
+					"error": err.Error(),
+				})
+			}
+		}
+	}
+}
+
+func (h *MessagingHandler) respondWithUploadError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrUploadNotFound):
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Upload not found")
+	case errors.Is(err, services.ErrInvalidChunk):
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+	case errors.Is(err, services.ErrUploadIncomplete):
+		h.respondWithError(c, http.StatusConflict, "UPLOAD_INCOMPLETE", err.Error())
+	case errors.Is(err, services.ErrInvalidFileType):
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_FILE_TYPE", err.Error())
+	case errors.Is(err, services.ErrFileTooLarge):
+		h.respondWithError(c, http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE", err.Error())
+	case errors.Is(err, services.ErrFileRejected):
+		h.respondWithError(c, http.StatusUnprocessableEntity, "FILE_REJECTED", err.Error())
+	default:
+		h.log(c).Error(message, err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", message)
+	}
+}
+
+// ServeUpload sirve un archivo del almacenamiento local si la URL lleva una firma válida y sin caducar
+func (h *MessagingHandler) ServeUpload(c *gin.Context) {
+	filePath := c.Param("filepath")
+	err := h.fileURLs.Verify("/uploads"+filePath, c.Query("expires"), c.Query("signature"), time.Now())
+	switch {
+	case errors.Is(err, auth.ErrFileURLExpired):
+		h.respondWithError(c, http.StatusForbidden, "URL_EXPIRED", "Download URL has expired")
+		return
+	case err != nil:
+		h.respondWithError(c, http.StatusForbidden, "INVALID_SIGNATURE", "Invalid download URL signature")
+		return
+	}
+
+	c.FileFromFS(filePath, gin.Dir(h.uploadsPath, false))
+}
+
+// DownloadAttachment godoc
+// @Summary Descarga un archivo adjunto
+// @Description Envía el contenido del adjunto si el usuario tiene acceso a su conversación. Con almacenamiento local admite peticiones Range; con S3 y GCS el objeto se transmite completo desde el proveedor
+// @Tags attachments
+// @Produce octet-stream
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID del archivo adjunto"
+// @Param Range header string false "Rango de bytes, p. ej. bytes=0-1023"
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 416 {string} string
+// @Failure 500 {object} domain.APIResponse
+// @Router /attachments/{id}/download [get]
+func (h *MessagingHandler) DownloadAttachment(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	attachment, err := h.messagingService.GetAttachment(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Attachment not found")
+			return
+		}
+		h.log(c).Error("Failed to get attachment", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to download attachment")
+		return
+	}
+
+	body, info, err := h.fileService.DownloadFile(c.Request.Context(), attachment.URL)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Attachment file not found")
+			return
+		}
+		h.log(c).Error("Failed to download attachment", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to download attachment")
+		return
+	}
+	defer body.Close()
+
+	filename := attachment.Filename
+	if filename == "" {
+		filename = info.Filename
+	}
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(strings.ToLower(filepath.Ext(filename)))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", disposition)
+	// The declared type is what was checked on upload; browsers must not guess another one
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	if seeker, ok := body.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, filename, info.ModTime, seeker)
+		return
+	}
+
+	contentLength := info.Size
+	if contentLength <= 0 {
+		contentLength = -1
+	}
+	c.DataFromReader(http.StatusOK, contentLength, contentType, body, nil)
+}
+
+// parseContentRange interpreta "bytes inicio-fin/total" (fin inclusivo)
+func parseContentRange(header string) (int64, int64, error) {
+	var start, end int64
+	var total string
+	if _, err := fmt.Sscanf(header, "bytes %d-%d/%s", &start, &end, &total); err != nil {
+		return 0, 0, fmt.Errorf("Content-Range header must be bytes {start}-{end}/{total}")
+	}
+	if start < 0 || end < start {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	return start, end, nil
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// downloadMessageRepository sirve el mensaje msg1 de la conversación conv123
+type downloadMessageRepository struct {
+	domain.MessageRepository
+}
+
+func (r *downloadMessageRepository) GetByID(ctx context.Context, id string) (*domain.Message, error) {
+	if id != "msg1" {
+		return nil, domain.ErrNotFound
+	}
+	return &domain.Message{ID: "msg1", ConversationID: "conv123"}, nil
+}
+
+// downloadAttachmentRepository sirve un único adjunto del mensaje msg1
+type downloadAttachmentRepository struct {
+	domain.AttachmentRepository
+	attachment *domain.Attachment
+}
+
+func (r *downloadAttachmentRepository) GetByID(ctx context.Context, id string) (*domain.Attachment, error) {
+	if id != r.attachment.ID {
+		return nil, domain.ErrNotFound
+	}
+	copied := *r.attachment
+	return &copied, nil
+}
+
+func (r *downloadAttachmentRepository) GetByMessageID(ctx context.Context, messageID string) ([]domain.Attachment, error) {
+	return []domain.Attachment{*r.attachment}, nil
+}
+
+// setupFileRouter sirve las rutas con almacenamiento local en un directorio temporal que ya contiene
+// user123/stored.txt, el archivo del adjunto att1
+func setupFileRouter(t *testing.T, maxFileSize int64, opts ...RouteOption) (*gin.Engine, *auth.JWTManager, string) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	uploadsPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(uploadsPath, "user123"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(uploadsPath, "user123", "stored.txt"), []byte("0123456789"), 0644))
+
+	logger := logger.NewLogger("debug")
+	messagingService := services.NewMessagingService(
+		&streamConversationRepository{conversation: &domain.Conversation{ID: "conv123", UserID: "user123", Status: domain.ConversationStatusActive}},
+		&downloadMessageRepository{},
+		&downloadAttachmentRepository{attachment: &domain.Attachment{ID: "att1", MessageID: "msg1", URL: "/uploads/user123/stored.txt", Filename: "informe final.txt"}},
+		services.NewNoOpEventPublisher(),
+		nil,
+		logger,
+	)
+	fileService := services.NewLocalFileService(&config.FileStorageConfig{LocalPath: uploadsPath, MaxFileSize: maxFileSize}, logger)
+	jwtManager := auth.NewJWTManager("test-secret", "test-issuer")
+
+	SetupRoutes(router, services.NewHealthService(), messagingService, fileService, jwtManager, logger, opts...)
+	return router, jwtManager, uploadsPath
+}
+
+func TestDownloadAttachment(t *testing.T) {
+	router, jwtManager, _ := setupFileRouter(t, 1024)
+	token, err := jwtManager.GenerateToken("user123", "user@example.com", nil)
+	require.NoError(t, err)
+
+	download := func(token string, rangeHeader string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v1/messaging/attachments/att1/download", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// The whole file, named after the original upload
+	w := download(token, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0123456789", w.Body.String())
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="informe final.txt"`, w.Header().Get("Content-Disposition"))
+
+	// A range request gets only the requested bytes
+	w = download(token, "bytes=2-5")
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "2345", w.Body.String())
+	assert.Equal(t, "bytes 2-5/10", w.Header().Get("Content-Range"))
+
+	// Someone without access to the conversation can't tell the attachment exists
+	stranger, err := jwtManager.GenerateToken("stranger", "stranger@example.com", nil)
+	require.NoError(t, err)
+	w = download(stranger, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	body, _ := io.ReadAll(w.Body)
+	assert.NotContains(t, string(body), "0123456789")
+}
+
+func TestUploadAttachments(t *testing.T) {
+	router, jwtManager, uploadsPath := setupFileRouter(t, 16, WithUploadBatchLimit(3))
+	token, err := jwtManager.GenerateToken("user123", "user@example.com", nil)
+	require.NoError(t, err)
+
+	upload := func(files map[string]string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		for _, name := range sortedKeys(files) {
+			part, err := writer.CreateFormFile("files", name)
+			require.NoError(t, err)
+			part.Write([]byte(files[name]))
+		}
+		require.NoError(t, writer.Close())
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v1/messaging/attachments/upload/batch", &body)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		router.ServeHTTP(w, req)
+		return w
+	}
+	storedFiles := func() int {
+		entries, err := os.ReadDir(filepath.Join(uploadsPath, "user123"))
+		require.NoError(t, err)
+		return len(entries) - 1 // user123/stored.txt belongs to the fixture
+	}
+
+	// Every file is uploaded and returned in order
+	w := upload(map[string]string{"a.txt": "first", "b.txt": "second", "c.txt": "third"})
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data []UploadResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Data, 3)
+	assert.Equal(t, []string{"a.txt", "b.txt", "c.txt"}, []string{response.Data[0].Filename, response.Data[1].Filename, response.Data[2].Filename})
+	assert.Equal(t, int64(6), response.Data[1].Size)
+	assert.Equal(t, 3, storedFiles())
+
+	// One file over the size cap fails the batch and the files uploaded before it are removed
+	w = upload(map[string]string{"a.txt": "fits", "b.txt": "this one is far too large", "c.txt": "fits"})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "FILE_TOO_LARGE")
+	assert.Contains(t, w.Body.String(), "b.txt")
+	assert.Equal(t, 3, storedFiles())
+
+	// More files than allowed are rejected before anything is stored
+	w = upload(map[string]string{"a.txt": "1", "b.txt": "2", "c.txt": "3", "d.txt": "4"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, 3, storedFiles())
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
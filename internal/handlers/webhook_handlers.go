@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateWebhook godoc
+// @Summary Registra un webhook en la conversación
+// @Description Registra una URL que recibirá por POST solo los eventos de la conversación indicados en event_types (message.received, conversation.closed, reaction.added...)
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param request body services.ConversationWebhookRequest true "URL y tipos de evento suscritos"
+// @Success 201 {object} domain.APIResponse{data=domain.ConversationWebhook}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /conversations/{id}/webhooks [post]
+func (h *MessagingHandler) CreateWebhook(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	var req services.ConversationWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+
+	webhook, err := h.messagingService.CreateWebhook(c.Request.Context(), c.Param("id"), req, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidWebhook) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to create webhook", err)
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Conversation not found")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusCreated, "Webhook created successfully", webhook)
+}
+
+// ListWebhooks godoc
+// @Summary Lista los webhooks de la conversación
+// @Description Obtiene los webhooks registrados en la conversación con sus tipos de evento suscritos
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Success 200 {object} domain.APIResponse{data=[]domain.ConversationWebhook}
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /conversations/{id}/webhooks [get]
+func (h *MessagingHandler) ListWebhooks(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	webhooks, err := h.messagingService.ListWebhooks(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		h.log(c).Error("Failed to list webhooks", err)
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Conversation not found")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Webhooks retrieved successfully", webhooks)
+}
+
+// DeleteWebhook godoc
+// @Summary Elimina un webhook de la conversación
+// @Description Deja de entregar eventos de la conversación a la URL del webhook
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param webhookId path string true "ID del webhook"
+// @Success 200 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /conversations/{id}/webhooks/{webhookId} [delete]
+func (h *MessagingHandler) DeleteWebhook(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	if err := h.messagingService.DeleteWebhook(c.Request.Context(), c.Param("id"), c.Param("webhookId"), userID); err != nil {
+		h.log(c).Error("Failed to delete webhook", err)
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Webhook not found")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Webhook deleted successfully", nil)
+}
+
+// ListWebhookDeliveries godoc
+// @Summary Lista las entregas de un webhook
+// @Description Obtiene los intentos de entrega del webhook, los más recientes primero, con código de respuesta, duración, número de intento y error
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param webhookId path string true "ID del webhook"
+// @Param status query string false "Filtrar por resultado (succeeded, failed)"
+// @Param limit query int false "Límite de resultados (máx. 100)" default(50)
+// @Param offset query int false "Offset para paginación" default(0)
+// @Success 200 {object} domain.APIResponse{data=[]domain.WebhookDelivery}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /conversations/{id}/webhooks/{webhookId}/deliveries [get]
+func (h *MessagingHandler) ListWebhookDeliveries(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	deliveries, err := h.messagingService.ListWebhookDeliveries(c.Request.Context(), c.Param("id"), c.Param("webhookId"), h.webhookDeliveryFilters(c), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidDeliveryFilter) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to list webhook deliveries", err)
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Webhook not found")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Webhook deliveries retrieved successfully", deliveries)
+}
+
+// ReplayWebhookDelivery godoc
+// @Summary Reenvía una entrega fallida
+// @Description Vuelve a enviar a la URL actual del webhook el mismo evento de una entrega fallida; el reenvío se registra como un intento nuevo con replay_of apuntando a la entrega original
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "ID de la conversación"
+// @Param webhookId path string true "ID del webhook"
+// @Param deliveryId path string true "ID de la entrega"
+// @Success 200 {object} domain.APIResponse{data=domain.WebhookDelivery}
+// @Failure 401 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Failure 409 {object} domain.APIResponse
+// @Router /conversations/{id}/webhooks/{webhookId}/deliveries/{deliveryId}/replay [post]
+func (h *MessagingHandler) ReplayWebhookDelivery(c *gin.Context) {
+	userID := h.getUserIDFromContext(c)
+	if userID == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	delivery, err := h.messagingService.ReplayWebhookDelivery(c.Request.Context(), c.Param("id"), c.Param("webhookId"), c.Param("deliveryId"), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrDeliveryNotReplayable) {
+			h.respondWithError(c, http.StatusConflict, "DELIVERY_NOT_REPLAYABLE", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to replay webhook delivery", err)
+		h.respondWithError(c, http.StatusNotFound, "NOT_FOUND", "Webhook delivery not found")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Webhook delivery replayed", delivery)
+}
+
+// ListAllWebhookDeliveries godoc
+// @Summary Lista las entregas de todos los webhooks
+// @Description Vista global de los intentos de entrega a webhooks, los más recientes primero (solo administradores)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param status query string false "Filtrar por resultado (succeeded, failed)"
+// @Param webhook_id query string false "Filtrar por webhook"
+// @Param conversation_id query string false "Filtrar por conversación"
+// @Param limit query int false "Límite de resultados (máx. 100)" default(50)
+// @Param offset query int false "Offset para paginación" default(0)
+// @Success 200 {object} domain.APIResponse{data=[]domain.WebhookDelivery}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Failure 403 {object} domain.APIResponse
+// @Failure 500 {object} domain.APIResponse
+// @Router /admin/webhook-deliveries [get]
+func (h *MessagingHandler) ListAllWebhookDeliveries(c *gin.Context) {
+	if h.getUserIDFromContext(c) == "" {
+		h.respondWithError(c, http.StatusUnauthorized, "UNAUTHORIZED", "Invalid token")
+		return
+	}
+
+	filters := h.webhookDeliveryFilters(c)
+	filters.WebhookID = c.Query("webhook_id")
+	filters.ConversationID = c.Query("conversation_id")
+
+	deliveries, err := h.messagingService.ListAllWebhookDeliveries(c.Request.Context(), filters)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidDeliveryFilter) {
+			h.respondWithError(c, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+			return
+		}
+		h.log(c).Error("Failed to list webhook deliveries", err)
+		h.respondWithError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list webhook deliveries")
+		return
+	}
+
+	h.respondWithSuccess(c, http.StatusOK, "Webhook deliveries retrieved successfully", deliveries)
+}
+
+func (h *MessagingHandler) webhookDeliveryFilters(c *gin.Context) domain.WebhookDeliveryFilters {
+	return domain.WebhookDeliveryFilters{
+		Status: domain.WebhookDeliveryStatus(c.Query("status")),
+		Limit:  h.parseIntQuery(c, "limit", 50),
+		Offset: h.parseIntQuery(c, "offset", 0),
+	}
+}
@@ -0,0 +1,138 @@
+package messenger
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/integrations/meta"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+// Notification es el cuerpo que la plataforma de Messenger envía al webhook, tanto para páginas de
+// Facebook (object "page") como para cuentas de Instagram (object "instagram")
+type Notification struct {
+	Object string  `json:"object"`
+	Entry  []Entry `json:"entry"`
+}
+
+type Entry struct {
+	ID        string  `json:"id"` // Página o cuenta de Instagram que recibió los eventos
+	Time      int64   `json:"time"`
+	Messaging []Event `json:"messaging"`
+}
+
+// Event es un evento de mensajería; solo uno de Message, Postback, Delivery, Read o Reaction viene relleno
+type Event struct {
+	Sender    Participant     `json:"sender"`
+	Recipient Participant     `json:"recipient"`
+	Timestamp int64           `json:"timestamp"` // Milisegundos Unix
+	Message   *Message        `json:"message,omitempty"`
+	Postback  json.RawMessage `json:"postback,omitempty"`
+	Delivery  json.RawMessage `json:"delivery,omitempty"`
+	Read      json.RawMessage `json:"read,omitempty"`
+	Reaction  json.RawMessage `json:"reaction,omitempty"`
+}
+
+type Participant struct {
+	ID string `json:"id"` // PSID en Messenger, IGSID en Instagram
+}
+
+type Message struct {
+	MID         string            `json:"mid"`
+	Text        string            `json:"text,omitempty"`
+	IsEcho      bool              `json:"is_echo,omitempty"` // Lo envió la propia página, no el cliente
+	Attachments []json.RawMessage `json:"attachments,omitempty"`
+}
+
+// objects es el object de las notificaciones de cada canal
+var objects = map[domain.Channel]string{
+	domain.ChannelMessenger: "page",
+	domain.ChannelInstagram: "instagram",
+}
+
+// recipientKeys es la clave de metadatos de la conversación con la página o cuenta que recibió el mensaje
+var recipientKeys = map[domain.Channel]string{
+	domain.ChannelMessenger: "page_id",
+	domain.ChannelInstagram: "instagram_account_id",
+}
+
+// NewWebhook crea el receptor de Messenger o Instagram según channel; sin appSecret no acepta ninguna
+// notificación
+func NewWebhook(channel domain.Channel, appSecret string, verifyToken string, messaging services.MessagingService, logger logger.Logger) *meta.Webhook {
+	return meta.NewWebhook(channel, appSecret, verifyToken, Parser(channel), messaging, logger)
+}
+
+// Parser traduce las notificaciones del canal a sus mensajes de texto entrantes. Los ecos de los mensajes
+// enviados por la página, los adjuntos y los eventos que no son mensajes (postbacks, acuses de entrega y
+// lectura, reacciones) se devuelven en skipped
+func Parser(channel domain.Channel) meta.Parser {
+	return func(body []byte) ([]meta.InboundMessage, []string, error) {
+		var notification Notification
+		if err := json.Unmarshal(body, &notification); err != nil {
+			return nil, nil, err
+		}
+		if object, ok := objects[channel]; !ok || notification.Object != object {
+			return nil, nil, fmt.Errorf("unexpected object %q for channel %s", notification.Object, channel)
+		}
+
+		var messages []meta.InboundMessage
+		var skipped []string
+		for _, entry := range notification.Entry {
+			for _, event := range entry.Messaging {
+				inbound, ok := toInboundMessage(event)
+				if !ok {
+					skipped = append(skipped, eventKind(event))
+					continue
+				}
+				inbound.ConversationMetadata = domain.JSONB{recipientKeys[channel]: event.Recipient.ID}
+				messages = append(messages, inbound)
+			}
+		}
+		return messages, skipped, nil
+	}
+}
+
+func toInboundMessage(event Event) (meta.InboundMessage, bool) {
+	message := event.Message
+	// Attachments would have to be downloaded from Meta first, so only text is ingested for now
+	if message == nil || message.IsEcho || message.Text == "" || message.MID == "" || event.Sender.ID == "" {
+		return meta.InboundMessage{}, false
+	}
+
+	inbound := meta.InboundMessage{
+		SenderID:    event.Sender.ID,
+		ContentType: domain.ContentTypeText,
+		Content:     message.Text,
+		Metadata: map[string]interface{}{
+			domain.MetadataProviderMessageID: message.MID,
+		},
+	}
+	// The message is stored when it arrives; keep when the customer actually sent it
+	if event.Timestamp > 0 {
+		inbound.Metadata["sent_at"] = time.UnixMilli(event.Timestamp).UTC().Format(time.RFC3339)
+	}
+
+	return inbound, true
+}
+
+// eventKind describe un evento que no se ingiere, para el log
+func eventKind(event Event) string {
+	switch {
+	case event.Message != nil && event.Message.IsEcho:
+		return "echo " + event.Message.MID
+	case event.Message != nil:
+		return "message " + event.Message.MID
+	case event.Postback != nil:
+		return "postback"
+	case event.Delivery != nil:
+		return "delivery"
+	case event.Read != nil:
+		return "read"
+	case event.Reaction != nil:
+		return "reaction"
+	}
+	return "unknown"
+}
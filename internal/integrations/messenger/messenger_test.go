@@ -0,0 +1,107 @@
+package messenger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMessagingService abre conversaciones nuevas y anota lo que se guarda; el resto de operaciones
+// no está disponible
+type recordingMessagingService struct {
+	services.MessagingService
+	conversations []*domain.Conversation
+	sent          []services.SendMessageRequest
+}
+
+func (s *recordingMessagingService) GetConversations(ctx context.Context, userID string, filters domain.ConversationFilters) ([]domain.Conversation, error) {
+	return nil, nil
+}
+
+func (s *recordingMessagingService) CreateConversation(ctx context.Context, userID string, channel domain.Channel, metadata domain.JSONB) (*domain.Conversation, error) {
+	conversation := &domain.Conversation{ID: "conv123", UserID: userID, Channel: channel, Metadata: metadata}
+	s.conversations = append(s.conversations, conversation)
+	return conversation, nil
+}
+
+func (s *recordingMessagingService) SendMessage(ctx context.Context, req services.SendMessageRequest) (*domain.Message, error) {
+	s.sent = append(s.sent, req)
+	return &domain.Message{ConversationID: req.ConversationID}, nil
+}
+
+func notification(object string) string {
+	return `{
+		"object": "` + object + `",
+		"entry": [{
+			"id": "1234567890",
+			"time": 1700000000000,
+			"messaging": [
+				{"sender": {"id": "6543210"}, "recipient": {"id": "1234567890"}, "timestamp": 1700000000000, "message": {"mid": "m_AG5Hz2Uq7tuwNEhXfYYKj8mJEM", "text": "Hola, necesito ayuda"}},
+				{"sender": {"id": "1234567890"}, "recipient": {"id": "6543210"}, "timestamp": 1700000000500, "message": {"mid": "m_echo", "text": "Hola, ¿en qué podemos ayudarte?", "is_echo": true}},
+				{"sender": {"id": "6543210"}, "recipient": {"id": "1234567890"}, "timestamp": 1700000001000, "postback": {"title": "Empezar", "payload": "GET_STARTED"}},
+				{"sender": {"id": "6543210"}, "recipient": {"id": "1234567890"}, "timestamp": 1700000002000, "read": {"watermark": 1700000000500}}
+			]
+		}]
+	}`
+}
+
+func TestWebhook_IngestTextMessagePerChannel(t *testing.T) {
+	tests := []struct {
+		channel     domain.Channel
+		object      string
+		userID      string
+		metadataKey string
+	}{
+		{channel: domain.ChannelMessenger, object: "page", userID: "messenger:6543210", metadataKey: "page_id"},
+		{channel: domain.ChannelInstagram, object: "instagram", userID: "instagram:6543210", metadataKey: "instagram_account_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.channel), func(t *testing.T) {
+			messaging := &recordingMessagingService{}
+			webhook := NewWebhook(tt.channel, "app-secret", "verify-me", messaging, logger.NewLogger("debug"))
+
+			// Execute
+			result, err := webhook.Ingest(context.Background(), []byte(notification(tt.object)))
+
+			// Assert: only the customer's text is stored; the echo, postback and read are acknowledged and skipped
+			require.NoError(t, err)
+			assert.Equal(t, 1, result.Received)
+			assert.Equal(t, 3, result.Skipped)
+
+			require.Len(t, messaging.conversations, 1)
+			assert.Equal(t, tt.channel, messaging.conversations[0].Channel)
+			assert.Equal(t, tt.userID, messaging.conversations[0].UserID)
+			assert.Equal(t, domain.JSONB{tt.metadataKey: "1234567890"}, messaging.conversations[0].Metadata)
+
+			require.Len(t, messaging.sent, 1)
+			assert.Equal(t, services.SendMessageRequest{
+				ConversationID: "conv123",
+				SenderType:     domain.SenderTypeUser,
+				SenderID:       tt.userID,
+				Content:        "Hola, necesito ayuda",
+				ContentType:    domain.ContentTypeText,
+				Metadata: map[string]interface{}{
+					domain.MetadataProviderMessageID: "m_AG5Hz2Uq7tuwNEhXfYYKj8mJEM",
+					"sent_at":                        "2023-11-14T22:13:20Z",
+				},
+			}, messaging.sent[0])
+		})
+	}
+}
+
+func TestWebhook_RejectsOtherChannelNotification(t *testing.T) {
+	// An Instagram notification posted to the Messenger webhook is not ingested as Messenger
+	messaging := &recordingMessagingService{}
+	webhook := NewWebhook(domain.ChannelMessenger, "app-secret", "verify-me", messaging, logger.NewLogger("debug"))
+
+	_, err := webhook.Ingest(context.Background(), []byte(notification("instagram")))
+
+	assert.Error(t, err)
+	assert.Empty(t, messaging.sent)
+}
@@ -0,0 +1,62 @@
+package messenger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/integrations/meta"
+)
+
+// Sender entrega los mensajes de texto salientes con la Send API de Messenger, que también atiende a las
+// cuentas de Instagram vinculadas a la página
+type Sender struct {
+	client *meta.GraphClient
+}
+
+// NewSender crea el adaptador de salida con el token de acceso de la página
+func NewSender(graphAPIURL string, pageAccessToken string, timeout time.Duration) *Sender {
+	return &Sender{client: meta.NewGraphClient(graphAPIURL, pageAccessToken, timeout)}
+}
+
+type sendRequest struct {
+	Recipient     Participant `json:"recipient"`
+	MessagingType string      `json:"messaging_type"`
+	Message       sendMessage `json:"message"`
+}
+
+type sendMessage struct {
+	Text string `json:"text"`
+}
+
+type sendResponse struct {
+	RecipientID string `json:"recipient_id"`
+	MessageID   string `json:"message_id"`
+}
+
+// Send responde al PSID o IGSID del cliente y guarda el mid en los metadatos del mensaje
+func (s *Sender) Send(ctx context.Context, conversation *domain.Conversation, message *domain.Message) error {
+	if message.ContentType != domain.ContentTypeText {
+		return fmt.Errorf("%s sender does not support content type %s", conversation.Channel, message.ContentType)
+	}
+
+	recipientID, ok := meta.RecipientID(conversation)
+	if !ok {
+		return fmt.Errorf("conversation %s has no %s recipient", conversation.ID, conversation.Channel)
+	}
+
+	var response sendResponse
+	err := s.client.Post(ctx, "/me/messages", sendRequest{
+		Recipient: Participant{ID: recipientID},
+		// Replies within the 24h window opened by the customer's last message
+		MessagingType: "RESPONSE",
+		Message:       sendMessage{Text: message.Content},
+	}, &response)
+	if err != nil {
+		return err
+	}
+
+	meta.RecordProviderMessageID(message, response.MessageID)
+	return nil
+}
@@ -0,0 +1,50 @@
+package messenger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSender_Send(t *testing.T) {
+	// Setup: the Send API answers with the mid of the sent message
+	var path string
+	var request sendRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Write([]byte(`{"recipient_id": "6789012345", "message_id": "m_OUT1"}`))
+	}))
+	defer server.Close()
+
+	sender := NewSender(server.URL, "page-token", time.Second)
+	conversation := &domain.Conversation{ID: "conv123", UserID: "instagram:6789012345", Channel: domain.ChannelInstagram}
+	message := &domain.Message{ID: "msg123", ContentType: domain.ContentTypeText, Content: "Su pedido ha salido"}
+
+	// Execute
+	err := sender.Send(context.Background(), conversation, message)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "/me/messages", path)
+	assert.Equal(t, "6789012345", request.Recipient.ID)
+	assert.Equal(t, "RESPONSE", request.MessagingType)
+	assert.Equal(t, "Su pedido ha salido", request.Message.Text)
+	assert.Equal(t, "m_OUT1", message.Metadata[domain.MetadataProviderMessageID])
+}
+
+func TestSender_SendWithoutRecipient(t *testing.T) {
+	sender := NewSender("http://127.0.0.1:1", "page-token", time.Second)
+
+	// A conversation opened on another channel has no PSID to reply to
+	err := sender.Send(context.Background(), &domain.Conversation{ID: "conv123", UserID: "whatsapp:34600111222", Channel: domain.ChannelMessenger}, &domain.Message{ContentType: domain.ContentTypeText, Content: "Hola"})
+
+	assert.ErrorContains(t, err, "has no messenger recipient")
+}
@@ -0,0 +1,89 @@
+package meta
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultGraphAPIURL es la versión de la Graph API a la que se envían los mensajes salientes
+const DefaultGraphAPIURL = "https://graph.facebook.com/v19.0"
+
+// GraphError es el error que devuelve la Graph API, p. ej. un token caducado o un destinatario fuera de
+// la ventana de 24 horas
+type GraphError struct {
+	StatusCode int    `json:"-"`
+	Message    string `json:"message"`
+	Type       string `json:"type"`
+	Code       int    `json:"code"`
+}
+
+func (e *GraphError) Error() string {
+	return fmt.Sprintf("graph api request failed with status %d: %s (code %d)", e.StatusCode, e.Message, e.Code)
+}
+
+// GraphClient llama a la Graph API con el token de acceso de una página o de un número de WhatsApp
+type GraphClient struct {
+	baseURL     string
+	accessToken string
+	httpClient  *http.Client
+}
+
+// NewGraphClient crea un cliente contra baseURL (DefaultGraphAPIURL si está vacío)
+func NewGraphClient(baseURL string, accessToken string, timeout time.Duration) *GraphClient {
+	if baseURL == "" {
+		baseURL = DefaultGraphAPIURL
+	}
+
+	return &GraphClient{
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		accessToken: accessToken,
+		httpClient:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Post envía body como JSON a path y decodifica la respuesta en result
+func (c *GraphClient) Post(ctx context.Context, path string, body interface{}, result interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal graph api request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create graph api request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("graph api request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read graph api response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var envelope struct {
+			Error GraphError `json:"error"`
+		}
+		// Not every failure comes from the Graph API itself (e.g. a proxy error page), so the body may not parse
+		_ = json.Unmarshal(respBody, &envelope)
+		envelope.Error.StatusCode = resp.StatusCode
+		return &envelope.Error
+	}
+
+	if err := json.Unmarshal(respBody, result); err != nil {
+		return fmt.Errorf("failed to decode graph api response: %w", err)
+	}
+	return nil
+}
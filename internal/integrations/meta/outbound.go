@@ -0,0 +1,30 @@
+package meta
+
+import (
+	"strings"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// UserID es el user_id de las conversaciones que un remitente abre en el canal: "<canal>:<id del remitente>"
+func UserID(channel domain.Channel, senderID string) string {
+	return string(channel) + ":" + senderID
+}
+
+// RecipientID devuelve el identificador en la plataforma del cliente de una conversación abierta por el
+// webhook del canal; las conversaciones creadas con otro user_id no tienen destinatario
+func RecipientID(conversation *domain.Conversation) (string, bool) {
+	recipientID, ok := strings.CutPrefix(conversation.UserID, string(conversation.Channel)+":")
+	return recipientID, ok && recipientID != ""
+}
+
+// RecordProviderMessageID guarda en el mensaje el identificador que la plataforma le asignó al enviarlo
+func RecordProviderMessageID(message *domain.Message, providerMessageID string) {
+	if providerMessageID == "" {
+		return
+	}
+	if message.Metadata == nil {
+		message.Metadata = make(domain.JSONB)
+	}
+	message.Metadata[domain.MetadataProviderMessageID] = providerMessageID
+}
@@ -0,0 +1,184 @@
+package meta
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+// SignatureHeader es la cabecera con la que Meta firma el cuerpo de cada notificación con el secreto de la app
+const SignatureHeader = "X-Hub-Signature-256"
+
+var (
+	// ErrInvalidSignature indica una notificación sin firma o firmada con otro secreto
+	ErrInvalidSignature = errors.New("invalid meta signature")
+	// ErrInvalidNotification indica un cuerpo que no es una notificación de la plataforma del webhook
+	ErrInvalidNotification = errors.New("invalid meta notification")
+)
+
+// InboundMessage es un mensaje entrante ya traducido al modelo del servicio
+type InboundMessage struct {
+	SenderID    string // Identificador del remitente en la plataforma: wa_id, PSID o IGSID
+	ContentType domain.ContentType
+	Content     string
+	Metadata    map[string]interface{} // Debe incluir provider_message_id para descartar reintentos
+	// Datos del remitente que se guardan en los metadatos de la conversación si hay que crearla
+	ConversationMetadata domain.JSONB
+}
+
+// Parser traduce el cuerpo de una notificación a los mensajes que hay que guardar. Los eventos que no se
+// ingieren (acuses, postbacks, tipos de mensaje sin equivalente) se describen en skipped y no son un error
+type Parser func(body []byte) (messages []InboundMessage, skipped []string, err error)
+
+// IngestResult resume una notificación procesada
+type IngestResult struct {
+	Received int `json:"received"` // Mensajes guardados, incluidos los reintentos ya guardados antes
+	Skipped  int `json:"skipped"`  // Eventos que no se ingieren
+}
+
+// Webhook recibe las notificaciones de una plataforma de Meta (WhatsApp, Messenger, Instagram) y guarda
+// cada mensaje entrante en la conversación activa de su remitente en el canal, creándola si no existe.
+// El user_id de esas conversaciones es "<canal>:<id del remitente>", para no confundirlo con los usuarios
+// autenticados con JWT
+type Webhook struct {
+	channel     domain.Channel
+	appSecret   []byte
+	verifyToken string
+	parse       Parser
+	messaging   services.MessagingService
+	logger      logger.Logger
+
+	// Serializes find-or-create so two messages from a new sender don't open two conversations
+	conversationMu sync.Mutex
+}
+
+// NewWebhook crea el receptor del canal; sin appSecret no acepta ninguna notificación
+func NewWebhook(channel domain.Channel, appSecret string, verifyToken string, parse Parser, messaging services.MessagingService, logger logger.Logger) *Webhook {
+	return &Webhook{
+		channel:     channel,
+		appSecret:   []byte(appSecret),
+		verifyToken: verifyToken,
+		parse:       parse,
+		messaging:   messaging,
+		logger:      logger,
+	}
+}
+
+// Channel es el canal de las conversaciones que crea el webhook
+func (w *Webhook) Channel() domain.Channel {
+	return w.channel
+}
+
+// Enabled indica si hay un secreto de app con el que verificar las notificaciones
+func (w *Webhook) Enabled() bool {
+	return w != nil && len(w.appSecret) > 0
+}
+
+// UserID es el user_id de las conversaciones del remitente en el canal
+func (w *Webhook) UserID(senderID string) string {
+	return UserID(w.channel, senderID)
+}
+
+// VerifySignature comprueba que signature sea "sha256=<HMAC-SHA256 hex del cuerpo>" con el secreto de la app
+func (w *Webhook) VerifySignature(body []byte, signature string) error {
+	if !w.Enabled() || signature == "" {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(auth.SignEventPayload(w.appSecret, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Challenge responde a la verificación con la que Meta suscribe el webhook: devuelve challenge solo si
+// mode es "subscribe" y token coincide con el configurado
+func (w *Webhook) Challenge(mode string, token string, challenge string) (string, bool) {
+	if w == nil || w.verifyToken == "" || mode != "subscribe" || challenge == "" {
+		return "", false
+	}
+	if !hmac.Equal([]byte(token), []byte(w.verifyToken)) {
+		return "", false
+	}
+	return challenge, true
+}
+
+// Ingest guarda los mensajes entrantes de una notificación ya verificada. Si alguno falla devuelve el error
+// para que Meta reintente la notificación; los mensajes ya guardados se descartan como duplicados por su
+// provider_message_id
+func (w *Webhook) Ingest(ctx context.Context, body []byte) (*IngestResult, error) {
+	messages, skipped, err := w.parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotification, err)
+	}
+
+	if len(skipped) > 0 {
+		w.logger.Info("Skipped unsupported inbound events", map[string]interface{}{
+			"channel": w.channel,
+			"events":  skipped,
+		})
+	}
+
+	for _, inbound := range messages {
+		if _, err := w.deliver(ctx, inbound); err != nil {
+			return nil, err
+		}
+	}
+
+	return &IngestResult{Received: len(messages), Skipped: len(skipped)}, nil
+}
+
+func (w *Webhook) deliver(ctx context.Context, inbound InboundMessage) (*domain.Message, error) {
+	userID := w.UserID(inbound.SenderID)
+
+	conversation, err := w.findOrCreateConversation(ctx, userID, inbound.ConversationMetadata)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := w.messaging.SendMessage(ctx, services.SendMessageRequest{
+		ConversationID: conversation.ID,
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       userID,
+		Content:        inbound.Content,
+		ContentType:    inbound.ContentType,
+		Metadata:       inbound.Metadata,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store %s message %v: %w", w.channel, inbound.Metadata[domain.MetadataProviderMessageID], err)
+	}
+
+	return message, nil
+}
+
+// findOrCreateConversation devuelve la conversación activa del remitente en el canal o abre una nueva con
+// los metadatos indicados
+func (w *Webhook) findOrCreateConversation(ctx context.Context, userID string, metadata domain.JSONB) (*domain.Conversation, error) {
+	w.conversationMu.Lock()
+	defer w.conversationMu.Unlock()
+
+	conversations, err := w.messaging.GetConversations(ctx, userID, domain.ConversationFilters{
+		Channel: w.channel,
+		Status:  domain.ConversationStatusActive,
+		Limit:   1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s conversation: %w", w.channel, err)
+	}
+	if len(conversations) > 0 {
+		return &conversations[0], nil
+	}
+
+	conversation, err := w.messaging.CreateConversation(ctx, userID, w.channel, metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s conversation: %w", w.channel, err)
+	}
+
+	return conversation, nil
+}
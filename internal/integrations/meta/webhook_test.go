@@ -0,0 +1,186 @@
+package meta
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/repositories"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAppSecret = "meta-app-secret"
+
+// memoryConversationRepository guarda las conversaciones en memoria
+type memoryConversationRepository struct {
+	domain.ConversationRepository
+	mu            sync.Mutex
+	conversations map[string]domain.Conversation
+}
+
+func (r *memoryConversationRepository) Create(ctx context.Context, conversation *domain.Conversation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.conversations[conversation.ID] = *conversation
+	return nil
+}
+
+func (r *memoryConversationRepository) GetByID(ctx context.Context, id string) (*domain.Conversation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	conversation, ok := r.conversations[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return &conversation, nil
+}
+
+func (r *memoryConversationRepository) GetByUserID(ctx context.Context, userID string, filters domain.ConversationFilters) ([]domain.Conversation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var conversations []domain.Conversation
+	for _, conversation := range r.conversations {
+		if conversation.UserID == userID && conversation.Channel == filters.Channel && conversation.Status == filters.Status {
+			conversations = append(conversations, conversation)
+		}
+	}
+	return conversations, nil
+}
+
+func (r *memoryConversationRepository) TouchUpdatedAt(ctx context.Context, id string, updatedAt time.Time) error {
+	return nil
+}
+
+// memoryMessageRepository guarda los mensajes en memoria y los busca por provider_message_id
+type memoryMessageRepository struct {
+	domain.MessageRepository
+	mu       sync.Mutex
+	messages []domain.Message
+}
+
+func (r *memoryMessageRepository) Create(ctx context.Context, message *domain.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, *message)
+	return nil
+}
+
+func (r *memoryMessageRepository) GetByConversationProviderMessageID(ctx context.Context, conversationID string, providerMessageID string) (*domain.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, message := range r.messages {
+		if message.ConversationID == conversationID && message.Metadata[domain.MetadataProviderMessageID] == providerMessageID {
+			return &message, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+// textParser trata el cuerpo como el texto de un único mensaje de sender-1 con id mid-1
+func textParser(body []byte) ([]InboundMessage, []string, error) {
+	if len(body) == 0 {
+		return nil, nil, errors.New("empty body")
+	}
+	return []InboundMessage{{
+		SenderID:             "sender-1",
+		ContentType:          domain.ContentTypeText,
+		Content:              string(body),
+		Metadata:             map[string]interface{}{domain.MetadataProviderMessageID: "mid-1"},
+		ConversationMetadata: domain.JSONB{"page_id": "page-1"},
+	}}, []string{"postback"}, nil
+}
+
+func newTestWebhook(channel domain.Channel) (*Webhook, *memoryConversationRepository, *memoryMessageRepository) {
+	log := logger.NewLogger("debug")
+	conversationRepo := &memoryConversationRepository{
+		ConversationRepository: repositories.NewNoOpConversationRepository(),
+		conversations:          map[string]domain.Conversation{},
+	}
+	messageRepo := &memoryMessageRepository{MessageRepository: repositories.NewNoOpMessageRepository()}
+	messagingService := services.NewMessagingService(
+		conversationRepo,
+		messageRepo,
+		repositories.NewNoOpAttachmentRepository(),
+		services.NewNoOpEventPublisher(),
+		services.NewNoOpCacheService(),
+		log,
+		services.WithInboundDeduplication(true),
+	)
+
+	return NewWebhook(channel, testAppSecret, "verify-me", textParser, messagingService, log), conversationRepo, messageRepo
+}
+
+func TestWebhook_VerifySignature(t *testing.T) {
+	webhook, _, _ := newTestWebhook(domain.ChannelMessenger)
+	body := []byte("Hola")
+
+	assert.NoError(t, webhook.VerifySignature(body, auth.SignEventPayload([]byte(testAppSecret), body)))
+
+	// Another secret, a tampered body, a missing header and a disabled webhook are all rejected
+	assert.ErrorIs(t, webhook.VerifySignature(body, auth.SignEventPayload([]byte("other-secret"), body)), ErrInvalidSignature)
+	assert.ErrorIs(t, webhook.VerifySignature([]byte("Adiós"), auth.SignEventPayload([]byte(testAppSecret), body)), ErrInvalidSignature)
+	assert.ErrorIs(t, webhook.VerifySignature(body, ""), ErrInvalidSignature)
+	disabled := NewWebhook(domain.ChannelMessenger, "", "verify-me", textParser, nil, logger.NewLogger("debug"))
+	assert.ErrorIs(t, disabled.VerifySignature(body, auth.SignEventPayload(nil, body)), ErrInvalidSignature)
+}
+
+func TestWebhook_IngestCreatesConversationForSender(t *testing.T) {
+	webhook, conversationRepo, messageRepo := newTestWebhook(domain.ChannelInstagram)
+
+	// Execute
+	result, err := webhook.Ingest(context.Background(), []byte("Hola, necesito ayuda"))
+
+	// Assert: the message opens a conversation in the webhook's channel keyed by the sender
+	require.NoError(t, err)
+	assert.Equal(t, &IngestResult{Received: 1, Skipped: 1}, result)
+
+	require.Len(t, conversationRepo.conversations, 1)
+	var conversation domain.Conversation
+	for _, stored := range conversationRepo.conversations {
+		conversation = stored
+	}
+	assert.Equal(t, "instagram:sender-1", conversation.UserID)
+	assert.Equal(t, domain.ChannelInstagram, conversation.Channel)
+	assert.Equal(t, domain.JSONB{"page_id": "page-1"}, conversation.Metadata)
+
+	require.Len(t, messageRepo.messages, 1)
+	message := messageRepo.messages[0]
+	assert.Equal(t, conversation.ID, message.ConversationID)
+	assert.Equal(t, domain.SenderTypeUser, message.SenderType)
+	assert.Equal(t, "instagram:sender-1", message.SenderID)
+	assert.Equal(t, "Hola, necesito ayuda", message.Content)
+
+	// A retried notification reuses the conversation and doesn't store the message twice
+	_, err = webhook.Ingest(context.Background(), []byte("Hola, necesito ayuda"))
+	require.NoError(t, err)
+	assert.Len(t, conversationRepo.conversations, 1)
+	assert.Len(t, messageRepo.messages, 1)
+}
+
+func TestWebhook_IngestRejectsUnparseableNotification(t *testing.T) {
+	webhook, _, _ := newTestWebhook(domain.ChannelMessenger)
+
+	_, err := webhook.Ingest(context.Background(), nil)
+
+	assert.ErrorIs(t, err, ErrInvalidNotification)
+}
+
+func TestWebhook_Challenge(t *testing.T) {
+	webhook, _, _ := newTestWebhook(domain.ChannelMessenger)
+
+	challenge, ok := webhook.Challenge("subscribe", "verify-me", "1158201444")
+	assert.True(t, ok)
+	assert.Equal(t, "1158201444", challenge)
+
+	_, ok = webhook.Challenge("subscribe", "wrong-token", "1158201444")
+	assert.False(t, ok)
+	_, ok = webhook.Challenge("unsubscribe", "verify-me", "1158201444")
+	assert.False(t, ok)
+}
@@ -0,0 +1,78 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/integrations/meta"
+)
+
+// Sender entrega los mensajes de texto salientes con WhatsApp Cloud API
+type Sender struct {
+	client        *meta.GraphClient
+	phoneNumberID string
+}
+
+// NewSender crea el adaptador de salida; phoneNumberID es el número que envía cuando la conversación no
+// guarda el número de la empresa al que escribió el cliente
+func NewSender(graphAPIURL string, accessToken string, phoneNumberID string, timeout time.Duration) *Sender {
+	return &Sender{
+		client:        meta.NewGraphClient(graphAPIURL, accessToken, timeout),
+		phoneNumberID: phoneNumberID,
+	}
+}
+
+type sendRequest struct {
+	MessagingProduct string `json:"messaging_product"`
+	RecipientType    string `json:"recipient_type"`
+	To               string `json:"to"`
+	Type             string `json:"type"`
+	Text             Text   `json:"text"`
+}
+
+type sendResponse struct {
+	Messages []struct {
+		ID string `json:"id"`
+	} `json:"messages"`
+}
+
+// Send envía el mensaje al wa_id del cliente y guarda el wamid en los metadatos del mensaje
+func (s *Sender) Send(ctx context.Context, conversation *domain.Conversation, message *domain.Message) error {
+	if message.ContentType != domain.ContentTypeText {
+		return fmt.Errorf("whatsapp sender does not support content type %s", message.ContentType)
+	}
+
+	// Only conversations opened by the webhook have a recipient; metadata can be set by API callers
+	to, ok := meta.RecipientID(conversation)
+	if !ok {
+		return fmt.Errorf("conversation %s has no whatsapp recipient", conversation.ID)
+	}
+
+	phoneNumberID := s.phoneNumberID
+	// Reply from the business number the customer wrote to
+	if id, _ := conversation.Metadata["whatsapp_phone_number_id"].(string); id != "" {
+		phoneNumberID = id
+	}
+	if phoneNumberID == "" {
+		return fmt.Errorf("no whatsapp phone number id for conversation %s", conversation.ID)
+	}
+
+	var response sendResponse
+	err := s.client.Post(ctx, "/"+phoneNumberID+"/messages", sendRequest{
+		MessagingProduct: "whatsapp",
+		RecipientType:    "individual",
+		To:               to,
+		Type:             "text",
+		Text:             Text{Body: message.Content},
+	}, &response)
+	if err != nil {
+		return err
+	}
+
+	if len(response.Messages) > 0 {
+		meta.RecordProviderMessageID(message, response.Messages[0].ID)
+	}
+	return nil
+}
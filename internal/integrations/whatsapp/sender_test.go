@@ -0,0 +1,71 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/integrations/meta"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSender_Send(t *testing.T) {
+	// Setup: Cloud API answers with the wamid of the sent message
+	var path, authorization string
+	var request sendRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		authorization = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Write([]byte(`{"messaging_product": "whatsapp", "contacts": [{"input": "34600111222", "wa_id": "34600111222"}], "messages": [{"id": "wamid.OUT1"}]}`))
+	}))
+	defer server.Close()
+
+	sender := NewSender(server.URL, "access-token", "100000000000001", time.Second)
+	conversation := &domain.Conversation{
+		ID:       "conv123",
+		UserID:   "whatsapp:34600111222",
+		Channel:  domain.ChannelWhatsApp,
+		Metadata: domain.JSONB{"whatsapp_phone_number_id": "106540352242922"},
+	}
+	message := &domain.Message{ID: "msg123", ContentType: domain.ContentTypeText, Content: "Su pedido ha salido"}
+
+	// Execute
+	err := sender.Send(context.Background(), conversation, message)
+
+	// Assert: the reply leaves from the number the customer wrote to
+	require.NoError(t, err)
+	assert.Equal(t, "/106540352242922/messages", path)
+	assert.Equal(t, "Bearer access-token", authorization)
+	assert.Equal(t, "34600111222", request.To)
+	assert.Equal(t, "Su pedido ha salido", request.Text.Body)
+	assert.Equal(t, "wamid.OUT1", message.Metadata[domain.MetadataProviderMessageID])
+}
+
+func TestSender_SendErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"message": "Re-engagement message", "type": "OAuthException", "code": 131047}}`))
+	}))
+	defer server.Close()
+	sender := NewSender(server.URL, "access-token", "100000000000001", time.Second)
+	text := &domain.Message{ContentType: domain.ContentTypeText, Content: "Hola"}
+
+	// The Graph API error is returned so the delivery is recorded as failed
+	err := sender.Send(context.Background(), &domain.Conversation{UserID: "whatsapp:34600111222", Channel: domain.ChannelWhatsApp}, text)
+	var graphErr *meta.GraphError
+	require.ErrorAs(t, err, &graphErr)
+	assert.Equal(t, 131047, graphErr.Code)
+
+	// A conversation created through the API has no one to reply to, even with a phone in its metadata
+	err = sender.Send(context.Background(), &domain.Conversation{UserID: "user123", Channel: domain.ChannelWhatsApp, Metadata: domain.JSONB{"phone": "+34600111222"}}, text)
+	assert.ErrorContains(t, err, "no whatsapp recipient")
+
+	err = sender.Send(context.Background(), &domain.Conversation{UserID: "whatsapp:34600111222", Channel: domain.ChannelWhatsApp}, &domain.Message{ContentType: domain.ContentTypeImage})
+	assert.ErrorContains(t, err, "does not support content type image")
+}
@@ -0,0 +1,135 @@
+package whatsapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/integrations/meta"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+// Notification es el cuerpo que WhatsApp Cloud API envía al webhook. Solo se modelan los campos que se
+// usan para ingerir mensajes entrantes
+type Notification struct {
+	Object string  `json:"object"`
+	Entry  []Entry `json:"entry"`
+}
+
+type Entry struct {
+	ID      string   `json:"id"` // Cuenta de WhatsApp Business
+	Changes []Change `json:"changes"`
+}
+
+type Change struct {
+	Field string `json:"field"`
+	Value Value  `json:"value"`
+}
+
+type Value struct {
+	MessagingProduct string    `json:"messaging_product"`
+	Metadata         Metadata  `json:"metadata"`
+	Contacts         []Contact `json:"contacts"`
+	Messages         []Message `json:"messages"`
+}
+
+// Metadata identifica el número de la empresa que recibió los mensajes
+type Metadata struct {
+	DisplayPhoneNumber string `json:"display_phone_number"`
+	PhoneNumberID      string `json:"phone_number_id"`
+}
+
+type Contact struct {
+	WaID    string  `json:"wa_id"`
+	Profile Profile `json:"profile"`
+}
+
+type Profile struct {
+	Name string `json:"name"`
+}
+
+// Message es un mensaje entrante; Text solo viene relleno en los de tipo text
+type Message struct {
+	From      string `json:"from"`
+	ID        string `json:"id"`
+	Timestamp string `json:"timestamp"` // Segundos Unix como texto
+	Type      string `json:"type"`
+	Text      *Text  `json:"text,omitempty"`
+}
+
+type Text struct {
+	Body string `json:"body"`
+}
+
+// NewWebhook crea el receptor de WhatsApp Cloud API; sin appSecret no acepta ninguna notificación
+func NewWebhook(appSecret string, verifyToken string, messaging services.MessagingService, logger logger.Logger) *meta.Webhook {
+	return meta.NewWebhook(domain.ChannelWhatsApp, appSecret, verifyToken, Parse, messaging, logger)
+}
+
+// Parse traduce una notificación a sus mensajes de texto entrantes. Los cambios que no son mensajes
+// (acuses de estado, plantillas) se ignoran; los mensajes que no son de texto, como imágenes, ubicaciones
+// o reacciones, se devuelven aparte en skipped con su wamid
+func Parse(body []byte) (messages []meta.InboundMessage, skipped []string, err error) {
+	var notification Notification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, nil, err
+	}
+	if notification.Object != "whatsapp_business_account" {
+		return nil, nil, fmt.Errorf("unexpected object %q", notification.Object)
+	}
+
+	for _, entry := range notification.Entry {
+		for _, change := range entry.Changes {
+			if change.Field != "messages" {
+				continue
+			}
+
+			names := make(map[string]string, len(change.Value.Contacts))
+			for _, contact := range change.Value.Contacts {
+				names[contact.WaID] = contact.Profile.Name
+			}
+
+			for _, message := range change.Value.Messages {
+				inbound, ok := toInboundMessage(message)
+				if !ok {
+					skipped = append(skipped, message.Type+" "+message.ID)
+					continue
+				}
+				inbound.ConversationMetadata = domain.JSONB{
+					"phone":                    "+" + message.From,
+					"whatsapp_phone_number_id": change.Value.Metadata.PhoneNumberID,
+				}
+				if name := names[message.From]; name != "" {
+					inbound.ConversationMetadata["profile_name"] = name
+				}
+				messages = append(messages, inbound)
+			}
+		}
+	}
+	return messages, skipped, nil
+}
+
+func toInboundMessage(message Message) (meta.InboundMessage, bool) {
+	// Media would have to be downloaded from Meta first, so only text is ingested for now
+	if message.Type != "text" || message.Text == nil || message.From == "" || message.ID == "" {
+		return meta.InboundMessage{}, false
+	}
+
+	inbound := meta.InboundMessage{
+		SenderID:    message.From,
+		ContentType: domain.ContentTypeText,
+		Content:     message.Text.Body,
+		Metadata: map[string]interface{}{
+			domain.MetadataProviderMessageID: message.ID,
+		},
+	}
+	// The message is stored when it arrives; keep when the customer actually sent it
+	if seconds, err := strconv.ParseInt(message.Timestamp, 10, 64); err == nil {
+		inbound.Metadata["sent_at"] = time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+	}
+
+	return inbound, true
+}
@@ -0,0 +1,54 @@
+package whatsapp
+
+import (
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const textNotification = `{
+	"object": "whatsapp_business_account",
+	"entry": [{
+		"id": "102290129340398",
+		"changes": [{
+			"field": "messages",
+			"value": {
+				"messaging_product": "whatsapp",
+				"metadata": {"display_phone_number": "15550783881", "phone_number_id": "106540352242922"},
+				"contacts": [{"profile": {"name": "Ana"}, "wa_id": "34600111222"}],
+				"messages": [
+					{"from": "34600111222", "id": "wamid.HBgLMzQ2MDAxMTEyMjIVAgASGBQzQUI", "timestamp": "1700000000", "type": "text", "text": {"body": "Hola, necesito ayuda"}},
+					{"from": "34600111222", "id": "wamid.HBgLMzQ2MDAxMTEyMjIVAgASGBQzQUJ", "timestamp": "1700000001", "type": "location", "location": {"latitude": 40.4, "longitude": -3.7}}
+				]
+			}
+		}]
+	}]
+}`
+
+func TestParse_TextMessage(t *testing.T) {
+	messages, skipped, err := Parse([]byte(textNotification))
+
+	// The text message is kept with the sender's phone; the location is skipped
+	require.NoError(t, err)
+	assert.Equal(t, []string{"location wamid.HBgLMzQ2MDAxMTEyMjIVAgASGBQzQUJ"}, skipped)
+	require.Len(t, messages, 1)
+	message := messages[0]
+	assert.Equal(t, "34600111222", message.SenderID)
+	assert.Equal(t, domain.ContentTypeText, message.ContentType)
+	assert.Equal(t, "Hola, necesito ayuda", message.Content)
+	assert.Equal(t, map[string]interface{}{
+		domain.MetadataProviderMessageID: "wamid.HBgLMzQ2MDAxMTEyMjIVAgASGBQzQUI",
+		"sent_at":                        "2023-11-14T22:13:20Z",
+	}, message.Metadata)
+	assert.Equal(t, domain.JSONB{"phone": "+34600111222", "whatsapp_phone_number_id": "106540352242922", "profile_name": "Ana"}, message.ConversationMetadata)
+}
+
+func TestParse_RejectsOtherPayloads(t *testing.T) {
+	_, _, notJSON := Parse([]byte("not json"))
+	_, _, otherObject := Parse([]byte(`{"object": "page", "entry": []}`))
+
+	assert.Error(t, notJSON)
+	assert.Error(t, otherObject)
+}
@@ -1,13 +1,34 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-func JWTAuth(jwtManager *auth.JWTManager) gin.HandlerFunc {
+// ClaimsContextKey es la clave del contexto de gin donde Authenticate guarda las *auth.Claims del JWT validado
+const ClaimsContextKey = "jwt_claims"
+
+// ClaimsFromContext devuelve las claims que Authenticate dejó en el contexto, para no volver a parsear el token.
+// Las llamadas de servicios internos no tienen claims
+func ClaimsFromContext(c *gin.Context) (*auth.Claims, bool) {
+	value, exists := c.Get(ClaimsContextKey)
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*auth.Claims)
+	return claims, ok
+}
+
+// Authenticate valida el JWT una sola vez por petición y deja sus claims en el contexto; un token ausente o
+// inválido se rechaza con 401 antes de llegar al handler
+func Authenticate(jwtManager *auth.JWTManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString, err := jwtManager.ExtractTokenFromHeader(c)
 		if err != nil {
@@ -32,6 +53,7 @@ func JWTAuth(jwtManager *auth.JWTManager) gin.HandlerFunc {
 		}
 
 		// Agregar claims al contexto
+		c.Set(ClaimsContextKey, claims)
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
 		c.Set("user_roles", claims.Roles)
@@ -39,39 +61,22 @@ func JWTAuth(jwtManager *auth.JWTManager) gin.HandlerFunc {
 	}
 }
 
-func RequireRole(requiredRole string) gin.HandlerFunc {
+// RequireRole admite la petición solo si el JWT validado por Authenticate incluye alguno de los roles
+// indicados; en otro caso responde 403. Las llamadas de servicios internos no tienen roles
+func RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		roles, exists := c.Get("user_roles")
-		if !exists {
-			c.JSON(http.StatusForbidden, gin.H{
-				"code":    "FORBIDDEN",
-				"message": "User roles not found",
-				"data":    nil,
-			})
-			c.Abort()
-			return
-		}
-
-		userRoles, ok := roles.([]string)
+		claims, ok := ClaimsFromContext(c)
 		if !ok {
 			c.JSON(http.StatusForbidden, gin.H{
 				"code":    "FORBIDDEN",
-				"message": "Invalid user roles format",
+				"message": "User roles not found",
 				"data":    nil,
 			})
 			c.Abort()
 			return
 		}
 
-		hasRole := false
-		for _, role := range userRoles {
-			if role == requiredRole {
-				hasRole = true
-				break
-			}
-		}
-
-		if !hasRole {
+		if !hasAnyRole(claims.Roles, roles) {
 			c.JSON(http.StatusForbidden, gin.H{
 				"code":    "INSUFFICIENT_PERMISSIONS",
 				"message": "Insufficient permissions for this resource",
@@ -85,6 +90,17 @@ func RequireRole(requiredRole string) gin.HandlerFunc {
 	}
 }
 
+func hasAnyRole(userRoles []string, allowed []string) bool {
+	for _, role := range userRoles {
+		for _, allowedRole := range allowed {
+			if role == allowedRole {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func SwaggerAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Solo permitir Swagger en desarrollo
@@ -99,4 +115,71 @@ func SwaggerAuth() gin.HandlerFunc {
 		}
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// serviceAuditTimeout limita la escritura de la auditoría de una llamada entre servicios
+const serviceAuditTimeout = 5 * time.Second
+
+// ServiceOrJWTAuth acepta llamadas de servicios internos con un X-Service-Token válido y
+// audita cada una; cualquier otra petición (o un token que no coincide) pasa por Authenticate. En ambos
+// casos deja la IP y el User-Agent en el contexto para la auditoría de las operaciones
+func ServiceOrJWTAuth(verifier *auth.ServiceTokenVerifier, jwtManager *auth.JWTManager, auditRepo domain.AuditRepository, logger logger.Logger) gin.HandlerFunc {
+	jwtAuth := Authenticate(jwtManager)
+
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.WithClientInfo(c.Request.Context(), auth.ClientInfo{
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		}))
+
+		token := c.GetHeader(auth.ServiceTokenHeader)
+		if token == "" || !verifier.Enabled() {
+			jwtAuth(c)
+			return
+		}
+
+		service, err := verifier.Verify(token)
+		if err != nil {
+			logger.Warn("Rejected service token, falling back to JWT auth", map[string]interface{}{
+				"path":  c.Request.URL.Path,
+				"error": err.Error(),
+			})
+			jwtAuth(c)
+			return
+		}
+
+		actorID := auth.ServiceUserID(service)
+		c.Set("user_id", actorID)
+		c.Set("service_name", service)
+		c.Request = c.Request.WithContext(auth.WithServiceIdentity(c.Request.Context(), service))
+
+		c.Next()
+
+		if auditRepo == nil {
+			return
+		}
+
+		auditLog := &domain.AuditLog{
+			ID:       uuid.New().String(),
+			UserID:   actorID,
+			Action:   "SERVICE_REQUEST",
+			Resource: c.Request.Method + " " + c.Request.URL.Path,
+			Details: map[string]interface{}{
+				"service": service,
+				"route":   c.FullPath(),
+				"status":  c.Writer.Status(),
+			},
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			CreatedAt: time.Now(),
+		}
+
+		// The request context may already be cancelled once the client has its response
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), serviceAuditTimeout)
+		defer cancel()
+
+		if err := auditRepo.Create(ctx, auditLog); err != nil {
+			logger.Error("Failed to audit service request", err)
+		}
+	}
+}
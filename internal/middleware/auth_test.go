@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRoleProtectedRouter sirve GET /ping a los roles admin y agent; el handler devuelve el user_id de las claims del contexto
+func newRoleProtectedRouter(jwtManager *auth.JWTManager) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/ping", Authenticate(jwtManager), RequireRole("admin", "agent"), func(c *gin.Context) {
+		claims, ok := ClaimsFromContext(c)
+		if !ok {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.String(http.StatusOK, claims.UserID)
+	})
+	return router
+}
+
+func pingWithRoles(t *testing.T, router *gin.Engine, jwtManager *auth.JWTManager, roles ...string) *httptest.ResponseRecorder {
+	token, err := jwtManager.GenerateToken("user123", "user@example.com", roles)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRequireRole_Allowed(t *testing.T) {
+	jwtManager := auth.NewJWTManager("test-secret", "test-issuer")
+	router := newRoleProtectedRouter(jwtManager)
+
+	for _, roles := range [][]string{{"admin"}, {"agent"}, {"user", "agent"}} {
+		rec := pingWithRoles(t, router, jwtManager, roles...)
+		assert.Equal(t, http.StatusOK, rec.Code, "roles %v", roles)
+		assert.Equal(t, "user123", rec.Body.String())
+	}
+}
+
+func TestRequireRole_Forbidden(t *testing.T) {
+	jwtManager := auth.NewJWTManager("test-secret", "test-issuer")
+	router := newRoleProtectedRouter(jwtManager)
+
+	rec := pingWithRoles(t, router, jwtManager, "user")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "INSUFFICIENT_PERMISSIONS")
+
+	rec = pingWithRoles(t, router, jwtManager)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Without a validated token there are no claims to check
+	gin.SetMode(gin.TestMode)
+	unauthenticated := gin.New()
+	unauthenticated.GET("/ping", RequireRole("admin"), func(c *gin.Context) { c.Status(http.StatusOK) })
+	rec = httptest.NewRecorder()
+	unauthenticated.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestAuthenticate_RejectsBeforeHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtManager := auth.NewJWTManager("test-secret", "test-issuer")
+	reached := false
+	router := gin.New()
+	router.GET("/ping", Authenticate(jwtManager), func(c *gin.Context) {
+		reached = true
+		c.Status(http.StatusOK)
+	})
+
+	// No token
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Token signed with another secret
+	token, err := auth.NewJWTManager("other-secret", "test-issuer").GenerateToken("user123", "user@example.com", nil)
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	assert.False(t, reached)
+}
@@ -5,15 +5,16 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func Logger(logger logger.Logger) gin.HandlerFunc {
+func Logger(log logger.Logger) gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		logger.Info("HTTP Request",
+		log.Info("HTTP Request",
 			"method", param.Method,
 			"path", param.Path,
 			"status", param.StatusCode,
 			"latency", param.Latency,
 			"client_ip", param.ClientIP,
 			"user_agent", param.Request.UserAgent(),
+			"request_id", logger.RequestIDFromContext(param.Request.Context()),
 		)
 		return ""
 	})
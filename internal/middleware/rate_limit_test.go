@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRateLimitedRouter sirve GET /ping limitado a limit peticiones por minuto; X-User simula el user_id de la autenticación
+func newRateLimitedRouter(t *testing.T, limit int) (*gin.Engine, *miniredis.Miniredis) {
+	gin.SetMode(gin.TestMode)
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	limiter := NewRateLimiter(client, logger.NewLogger("error"))
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if userID := c.GetHeader("X-User"); userID != "" {
+			c.Set("user_id", userID)
+		}
+		c.Next()
+	})
+	router.Use(limiter.RateLimit(limit, time.Minute))
+	router.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	return router, server
+}
+
+func ping(router *gin.Engine, userID string, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.RemoteAddr = remoteAddr
+	if userID != "" {
+		req.Header.Set("X-User", userID)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRateLimit_RejectsRequestsOverTheLimit(t *testing.T) {
+	router, _ := newRateLimitedRouter(t, 10)
+
+	for i := 0; i < 10; i++ {
+		require.Equal(t, http.StatusOK, ping(router, "user1", "10.0.0.1:1234").Code, "request %d", i+1)
+	}
+
+	// The 11th request in the window is rejected
+	rec := ping(router, "user1", "10.0.0.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	assert.True(t, retryAfter > 0 && retryAfter <= 60, "Retry-After %d", retryAfter)
+
+	// Other users, even from the same address, have their own window
+	assert.Equal(t, http.StatusOK, ping(router, "user2", "10.0.0.1:1234").Code)
+}
+
+func TestRateLimit_FallsBackToClientIP(t *testing.T) {
+	router, _ := newRateLimitedRouter(t, 2)
+
+	assert.Equal(t, http.StatusOK, ping(router, "", "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusOK, ping(router, "", "10.0.0.1:5678").Code)
+	assert.Equal(t, http.StatusTooManyRequests, ping(router, "", "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusOK, ping(router, "", "10.0.0.2:1234").Code)
+}
+
+func TestRateLimit_FailsOpenWithoutRedis(t *testing.T) {
+	router, server := newRateLimitedRouter(t, 1)
+	server.Close()
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, ping(router, "user1", "10.0.0.1:1234").Code)
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader es la cabecera con el ID de correlación de la petición, en la entrada y en la respuesta
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limita el ID que se acepta del cliente, ya que se repite en cada línea de log
+const maxRequestIDLength = 128
+
+// RequestID reutiliza el X-Request-ID de la petición o genera uno, lo devuelve en la respuesta y lo deja en
+// el contexto para los logs (Logger.WithContext) y los eventos que provoque la petición
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+
+		c.Request = c.Request.WithContext(logger.ContextWithRequestID(c.Request.Context(), requestID))
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// isValidRequestID admite IDs de ASCII imprimible sin espacios, para que un cliente no pueda inyectar
+// saltos de línea o valores enormes en los logs
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] <= ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID())
+	// Echoes the ID handlers see in the request context
+	router.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, logger.RequestIDFromContext(c.Request.Context()))
+	})
+
+	request := func(requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		if requestID != "" {
+			req.Header.Set(RequestIDHeader, requestID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Generated when the client sends none
+	w := request("")
+	generated := w.Header().Get(RequestIDHeader)
+	_, err := uuid.Parse(generated)
+	require.NoError(t, err)
+	assert.Equal(t, generated, w.Body.String())
+
+	// A provided ID is echoed and reaches the handler
+	w = request("req-from-gateway-42")
+	assert.Equal(t, "req-from-gateway-42", w.Header().Get(RequestIDHeader))
+	assert.Equal(t, "req-from-gateway-42", w.Body.String())
+
+	// IDs that would pollute the logs are replaced
+	for _, invalid := range []string{"two words", strings.Repeat("a", maxRequestIDLength+1)} {
+		w = request(invalid)
+		assert.NotEqual(t, invalid, w.Header().Get(RequestIDHeader))
+		_, err := uuid.Parse(w.Header().Get(RequestIDHeader))
+		assert.NoError(t, err)
+	}
+}
@@ -1,49 +0,0 @@
-package middleware
-
-import (
-	"github.com/gin-gonic/gin"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/trace"
-)
-
-const tracerName = "microservice-template"
-
-func Tracing() gin.HandlerFunc {
-	tracer := otel.Tracer(tracerName)
-	
-	return func(c *gin.Context) {
-		// Extract trace context from headers
-		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
-		
-		// Start span
-		ctx, span := tracer.Start(ctx, c.Request.Method+" "+c.FullPath(),
-			trace.WithAttributes(
-				attribute.String("http.method", c.Request.Method),
-				attribute.String("http.url", c.Request.URL.String()),
-				attribute.String("http.route", c.FullPath()),
-				attribute.String("http.user_agent", c.Request.UserAgent()),
-				attribute.String("http.remote_addr", c.ClientIP()),
-			),
-		)
-		defer span.End()
-
-		// Add span to context
-		c.Request = c.Request.WithContext(ctx)
-		
-		// Process request
-		c.Next()
-		
-		// Add response attributes
-		span.SetAttributes(
-			attribute.Int("http.status_code", c.Writer.Status()),
-			attribute.Int("http.response_size", c.Writer.Size()),
-		)
-		
-		// Set span status based on HTTP status
-		if c.Writer.Status() >= 400 {
-			span.SetAttributes(attribute.Bool("error", true))
-		}
-	}
-}
\ No newline at end of file
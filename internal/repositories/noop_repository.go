@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/company/microservice-template/internal/domain"
 )
@@ -36,6 +37,86 @@ func (r *noOpConversationRepository) Delete(ctx context.Context, id string) erro
 	return fmt.Errorf("database not available")
 }
 
+func (r *noOpConversationRepository) DeleteByUserID(ctx context.Context, userID string, beforeCommit func(*domain.UserDataPurge) error) (*domain.UserDataPurge, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) GetAbandonmentCandidates(ctx context.Context, idleSince time.Time, channels []domain.Channel, limit int) ([]domain.Conversation, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) CreateWithMessages(ctx context.Context, conversation *domain.Conversation, messages []domain.Message) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) CountByUserID(ctx context.Context, userID string, filters domain.ConversationFilters) (int64, error) {
+	return 0, fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) TransferOwnership(ctx context.Context, fromUserID string, toUserID string, filters domain.ConversationFilters, limit int, requestedBy string) ([]string, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) ReconcileMessageCounts(ctx context.Context, afterID string, limit int) (string, []string, error) {
+	return "", nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) GetByReference(ctx context.Context, reference string) (*domain.Conversation, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) NextReferenceNumber(ctx context.Context, prefix string) (int64, error) {
+	return 0, fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) RemoveTag(ctx context.Context, conversationID string, tag string) (bool, error) {
+	return false, fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) TouchUpdatedAt(ctx context.Context, conversationID string, updatedAt time.Time) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) AddTagToConversations(ctx context.Context, conversationIDs []string, tag string, ownerID string) (map[string]bool, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) CountCreatedBefore(ctx context.Context, channel domain.Channel, createdBefore time.Time) (int64, error) {
+	return 0, fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) ArchiveCreatedBefore(ctx context.Context, channel domain.Channel, createdBefore time.Time, now time.Time, limit int) ([]string, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) CountStale(ctx context.Context, olderThan time.Time) (int64, error) {
+	return 0, fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) ArchiveStale(ctx context.Context, olderThan time.Time) (int64, error) {
+	return 0, fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) ClaimAutoReply(ctx context.Context, conversationID string, repliedBefore time.Time, now time.Time) (bool, error) {
+	return false, fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) UpdateStatusIfIdle(ctx context.Context, conversationID string, status domain.ConversationStatus, changedBefore time.Time, now time.Time) (bool, error) {
+	return false, fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) SearchByMetadata(ctx context.Context, match domain.JSONB, userID string, limit int, offset int) ([]domain.Conversation, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) UpdateStatusBulk(ctx context.Context, conversationIDs []string, status domain.ConversationStatus, ownerID string, changedBefore time.Time, now time.Time) (map[string]domain.ConversationStatusOutcome, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpConversationRepository) UpdateMetadata(ctx context.Context, conversationID string, update func(metadata domain.JSONB) (domain.JSONB, error)) (domain.JSONB, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
 // NoOp Message Repository
 type noOpMessageRepository struct{}
 
@@ -55,6 +136,62 @@ func (r *noOpMessageRepository) GetByConversationID(ctx context.Context, convers
 	return nil, fmt.Errorf("database not available")
 }
 
+func (r *noOpMessageRepository) GetPendingRetries(ctx context.Context, now time.Time, maxAttempts int, limit int) ([]domain.Message, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpMessageRepository) StreamByConversationID(ctx context.Context, conversationID string, excludeSenderTypes []domain.SenderType, fn func(message *domain.Message) error) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpMessageRepository) GetReplies(ctx context.Context, parentID string) ([]domain.Message, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpMessageRepository) CountReplies(ctx context.Context, messageIDs []string) (map[string]int, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpMessageRepository) GetAround(ctx context.Context, target *domain.Message, before int, after int) ([]domain.Message, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpMessageRepository) CountByConversationID(ctx context.Context, conversationID string) (int64, error) {
+	return 0, fmt.Errorf("database not available")
+}
+
+func (r *noOpMessageRepository) GetExpired(ctx context.Context, now time.Time, limit int) ([]domain.Message, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpMessageRepository) CreateWithAttachments(ctx context.Context, message *domain.Message) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpMessageRepository) GetByProviderMessageID(ctx context.Context, channel domain.Channel, providerMessageID string) (*domain.Message, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpMessageRepository) GetByConversationProviderMessageID(ctx context.Context, conversationID string, providerMessageID string) (*domain.Message, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpMessageRepository) GetPinned(ctx context.Context, conversationID string, limit int) ([]domain.Message, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpMessageRepository) Search(ctx context.Context, userID string, query string, pagination domain.PaginationParams) ([]domain.Message, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpMessageRepository) SetPinned(ctx context.Context, id string, pinnedAt *time.Time) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpMessageRepository) UpdateDeliveryStatus(ctx context.Context, id string, from domain.DeliveryStatus, to domain.DeliveryStatus) (bool, error) {
+	return false, fmt.Errorf("database not available")
+}
+
 func (r *noOpMessageRepository) Update(ctx context.Context, message *domain.Message) error {
 	return fmt.Errorf("database not available")
 }
@@ -63,6 +200,10 @@ func (r *noOpMessageRepository) Delete(ctx context.Context, id string) error {
 	return fmt.Errorf("database not available")
 }
 
+func (r *noOpMessageRepository) Purge(ctx context.Context, id string) error {
+	return fmt.Errorf("database not available")
+}
+
 // NoOp Attachment Repository
 type noOpAttachmentRepository struct{}
 
@@ -82,6 +223,322 @@ func (r *noOpAttachmentRepository) GetByMessageID(ctx context.Context, messageID
 	return nil, fmt.Errorf("database not available")
 }
 
+func (r *noOpAttachmentRepository) GetByUserID(ctx context.Context, userID string, attachmentType domain.AttachmentType, pagination domain.PaginationParams) ([]domain.Attachment, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpAttachmentRepository) SearchByFilename(ctx context.Context, conversationID string, query string, pagination domain.PaginationParams) ([]domain.Attachment, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
 func (r *noOpAttachmentRepository) Delete(ctx context.Context, id string) error {
 	return fmt.Errorf("database not available")
-}
\ No newline at end of file
+}
+
+func (r *noOpAttachmentRepository) SaveThumbnail(ctx context.Context, userID string, url string, thumbnailURL string) error {
+	return fmt.Errorf("database not available")
+}
+
+// NoOp Reaction Repository
+type noOpReactionRepository struct{}
+
+func NewNoOpReactionRepository() domain.ReactionRepository {
+	return &noOpReactionRepository{}
+}
+
+func (r *noOpReactionRepository) Add(ctx context.Context, reaction *domain.Reaction) (bool, error) {
+	return false, fmt.Errorf("database not available")
+}
+
+func (r *noOpReactionRepository) Remove(ctx context.Context, messageID string, userID string, emoji string) (bool, error) {
+	return false, fmt.Errorf("database not available")
+}
+
+func (r *noOpReactionRepository) GetByMessageID(ctx context.Context, messageID string) ([]domain.Reaction, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpReactionRepository) CountByMessageIDs(ctx context.Context, messageIDs []string) (map[string]map[string]int, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+// NoOp Audit Repository
+type noOpAuditRepository struct{}
+
+func NewNoOpAuditRepository() domain.AuditRepository {
+	return &noOpAuditRepository{}
+}
+
+func (r *noOpAuditRepository) Create(ctx context.Context, log *domain.AuditLog) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpAuditRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*domain.AuditLog, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpAuditRepository) GetByAction(ctx context.Context, action string, limit, offset int) ([]*domain.AuditLog, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpAuditRepository) List(ctx context.Context, filters domain.AuditLogFilters) ([]*domain.AuditLog, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+// NoOp Conversation Template Repository
+type noOpTemplateRepository struct{}
+
+func NewNoOpTemplateRepository() domain.ConversationTemplateRepository {
+	return &noOpTemplateRepository{}
+}
+
+func (r *noOpTemplateRepository) Create(ctx context.Context, template *domain.ConversationTemplate) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpTemplateRepository) GetByID(ctx context.Context, id string) (*domain.ConversationTemplate, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpTemplateRepository) List(ctx context.Context, pagination domain.PaginationParams) ([]domain.ConversationTemplate, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpTemplateRepository) Update(ctx context.Context, template *domain.ConversationTemplate) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpTemplateRepository) Delete(ctx context.Context, id string) error {
+	return fmt.Errorf("database not available")
+}
+
+// NoOp Participant Repository
+type noOpParticipantRepository struct{}
+
+func NewNoOpParticipantRepository() domain.ParticipantRepository {
+	return &noOpParticipantRepository{}
+}
+
+func (r *noOpParticipantRepository) MarkRead(ctx context.Context, conversationID string, userID string, readAt time.Time) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpParticipantRepository) GetReadStates(ctx context.Context, conversationIDs []string) (map[string]domain.ConversationReadState, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpParticipantRepository) FilterParticipants(ctx context.Context, conversationID string, userIDs []string) ([]string, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpParticipantRepository) AddParticipant(ctx context.Context, participant *domain.ConversationParticipant) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpParticipantRepository) RemoveParticipant(ctx context.Context, conversationID string, userID string) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpParticipantRepository) ListParticipants(ctx context.Context, conversationID string) ([]domain.ConversationParticipant, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+// NoOp Draft Repository
+type noOpDraftRepository struct{}
+
+func NewNoOpDraftRepository() domain.DraftRepository {
+	return &noOpDraftRepository{}
+}
+
+func (r *noOpDraftRepository) Upsert(ctx context.Context, draft *domain.MessageDraft) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpDraftRepository) Get(ctx context.Context, conversationID string, userID string) (*domain.MessageDraft, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpDraftRepository) Delete(ctx context.Context, conversationID string, userID string) error {
+	return fmt.Errorf("database not available")
+}
+
+// NoOp Scheduled Message Repository
+type noOpScheduledMessageRepository struct{}
+
+func NewNoOpScheduledMessageRepository() domain.ScheduledMessageRepository {
+	return &noOpScheduledMessageRepository{}
+}
+
+func (r *noOpScheduledMessageRepository) Create(ctx context.Context, message *domain.ScheduledMessage) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpScheduledMessageRepository) GetByID(ctx context.Context, id string) (*domain.ScheduledMessage, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpScheduledMessageRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]domain.ScheduledMessage, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpScheduledMessageRepository) UpdateStatus(ctx context.Context, message *domain.ScheduledMessage, from domain.ScheduledMessageStatus) (bool, error) {
+	return false, fmt.Errorf("database not available")
+}
+
+func (r *noOpScheduledMessageRepository) ReleaseStale(ctx context.Context, staleBefore time.Time, now time.Time) (int64, error) {
+	return 0, fmt.Errorf("database not available")
+}
+
+// NoOp Share Link Repository
+type noOpShareLinkRepository struct{}
+
+func NewNoOpShareLinkRepository() domain.ShareLinkRepository {
+	return &noOpShareLinkRepository{}
+}
+
+func (r *noOpShareLinkRepository) Create(ctx context.Context, link *domain.ConversationShareLink) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpShareLinkRepository) GetByID(ctx context.Context, id string) (*domain.ConversationShareLink, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpShareLinkRepository) ListByConversation(ctx context.Context, conversationID string) ([]domain.ConversationShareLink, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpShareLinkRepository) Revoke(ctx context.Context, conversationID string, id string, revokedAt time.Time) error {
+	return fmt.Errorf("database not available")
+}
+
+// NoOp Message Read Repository
+type noOpMessageReadRepository struct{}
+
+func NewNoOpMessageReadRepository() domain.MessageReadRepository {
+	return &noOpMessageReadRepository{}
+}
+
+func (r *noOpMessageReadRepository) MarkRead(ctx context.Context, conversationID string, userID string, messageIDs []string, readAt time.Time) ([]string, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpMessageReadRepository) MarkReadUpTo(ctx context.Context, conversationID string, userID string, upToMessageID string, readAt time.Time) ([]string, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpMessageReadRepository) GetReadStatus(ctx context.Context, conversationID string, userID string) (map[string]time.Time, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpMessageReadRepository) GetByMessageIDs(ctx context.Context, messageIDs []string) (map[string][]domain.MessageRead, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+// NoOp Webhook Repository
+type noOpWebhookRepository struct{}
+
+func NewNoOpWebhookRepository() domain.WebhookRepository {
+	return &noOpWebhookRepository{}
+}
+
+func (r *noOpWebhookRepository) Create(ctx context.Context, webhook *domain.ConversationWebhook) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpWebhookRepository) GetByID(ctx context.Context, id string) (*domain.ConversationWebhook, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpWebhookRepository) GetByConversationID(ctx context.Context, conversationID string) ([]domain.ConversationWebhook, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpWebhookRepository) GetSubscribed(ctx context.Context, conversationID string, eventType string) ([]domain.ConversationWebhook, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpWebhookRepository) Delete(ctx context.Context, id string) error {
+	return fmt.Errorf("database not available")
+}
+
+// NoOp Webhook Delivery Repository
+type noOpWebhookDeliveryRepository struct{}
+
+func NewNoOpWebhookDeliveryRepository() domain.WebhookDeliveryRepository {
+	return &noOpWebhookDeliveryRepository{}
+}
+
+func (r *noOpWebhookDeliveryRepository) Create(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpWebhookDeliveryRepository) GetByID(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpWebhookDeliveryRepository) List(ctx context.Context, filters domain.WebhookDeliveryFilters) ([]domain.WebhookDelivery, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpWebhookDeliveryRepository) DeleteCreatedBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return 0, fmt.Errorf("database not available")
+}
+
+// NoOp Outbox Repository
+type noOpOutboxRepository struct{}
+
+func NewNoOpOutboxRepository() domain.OutboxRepository {
+	return &noOpOutboxRepository{}
+}
+
+func (r *noOpOutboxRepository) Create(ctx context.Context, event *domain.OutboxEvent) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpOutboxRepository) ClaimPending(ctx context.Context, now time.Time, leaseUntil time.Time, maxAttempts int, limit int) ([]domain.OutboxEvent, error) {
+	return nil, fmt.Errorf("database not available")
+}
+
+func (r *noOpOutboxRepository) MarkDelivered(ctx context.Context, id string, deliveredAt time.Time) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpOutboxRepository) MarkFailed(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpOutboxRepository) CountUndelivered(ctx context.Context, maxAttempts int) (int, int, error) {
+	return 0, 0, fmt.Errorf("database not available")
+}
+
+func (r *noOpOutboxRepository) DeleteDeliveredBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return 0, fmt.Errorf("database not available")
+}
+
+// NoOp TxManager
+type noOpTxManager struct{}
+
+// NewNoOpTxManager ejecuta las operaciones sin transacción, para cuando no hay base de datos
+func NewNoOpTxManager() domain.TxManager {
+	return &noOpTxManager{}
+}
+
+func (m *noOpTxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// NoOp Health Repository
+type noOpHealthRepository struct{}
+
+func NewNoOpHealthRepository() domain.HealthRepository {
+	return &noOpHealthRepository{}
+}
+
+func (r *noOpHealthRepository) CheckDatabase(ctx context.Context) error {
+	return fmt.Errorf("database not available")
+}
+
+func (r *noOpHealthRepository) CheckExternalServices(ctx context.Context) map[string]error {
+	return map[string]error{}
+}
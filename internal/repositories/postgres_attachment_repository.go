@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/company/microservice-template/internal/domain"
 	"github.com/company/microservice-template/pkg/logger"
@@ -21,21 +22,25 @@ func NewPostgresAttachmentRepository(db *sql.DB, logger logger.Logger) domain.At
 	}
 }
 
+// createAttachmentQuery también la usa el repositorio de mensajes para guardar los adjuntos con su mensaje.
+// Sin thumbnail_url toma la miniatura ya generada del archivo, si la hay, y la devuelve
+const createAttachmentQuery = `
+	INSERT INTO attachments (id, message_id, url, type, size, filename, thumbnail_url, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, COALESCE(NULLIF($7, ''), (SELECT thumbnail_url FROM file_thumbnails WHERE url = $3)), $8)
+	RETURNING COALESCE(thumbnail_url, '')
+`
+
 func (r *postgresAttachmentRepository) Create(ctx context.Context, attachment *domain.Attachment) error {
-	query := `
-		INSERT INTO attachments (id, message_id, url, type, size, filename, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`
-	
-	_, err := r.db.ExecContext(ctx, query,
+	err := conn(ctx, r.db).QueryRowContext(ctx, createAttachmentQuery,
 		attachment.ID,
 		attachment.MessageID,
 		attachment.URL,
 		attachment.Type,
 		attachment.Size,
 		attachment.Filename,
+		attachment.ThumbnailURL,
 		attachment.CreatedAt,
-	)
+	).Scan(&attachment.ThumbnailURL)
 	
 	if err != nil {
 		r.logger.Error("Failed to create attachment", err)
@@ -47,25 +52,26 @@ func (r *postgresAttachmentRepository) Create(ctx context.Context, attachment *d
 
 func (r *postgresAttachmentRepository) GetByID(ctx context.Context, id string) (*domain.Attachment, error) {
 	query := `
-		SELECT id, message_id, url, type, size, filename, created_at
+		SELECT id, message_id, url, type, size, filename, COALESCE(thumbnail_url, ''), created_at
 		FROM attachments
 		WHERE id = $1
 	`
 	
 	var attachment domain.Attachment
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&attachment.ID,
 		&attachment.MessageID,
 		&attachment.URL,
 		&attachment.Type,
 		&attachment.Size,
 		&attachment.Filename,
+		&attachment.ThumbnailURL,
 		&attachment.CreatedAt,
 	)
 	
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("attachment not found")
+			return nil, fmt.Errorf("attachment %w", domain.ErrNotFound)
 		}
 		r.logger.Error("Failed to get attachment by ID", err)
 		return nil, fmt.Errorf("failed to get attachment: %w", err)
@@ -76,13 +82,13 @@ func (r *postgresAttachmentRepository) GetByID(ctx context.Context, id string) (
 
 func (r *postgresAttachmentRepository) GetByMessageID(ctx context.Context, messageID string) ([]domain.Attachment, error) {
 	query := `
-		SELECT id, message_id, url, type, size, filename, created_at
+		SELECT id, message_id, url, type, size, filename, COALESCE(thumbnail_url, ''), created_at
 		FROM attachments
 		WHERE message_id = $1
 		ORDER BY created_at ASC
 	`
 	
-	rows, err := r.db.QueryContext(ctx, query, messageID)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, messageID)
 	if err != nil {
 		r.logger.Error("Failed to get attachments by message ID", err)
 		return nil, fmt.Errorf("failed to get attachments: %w", err)
@@ -99,6 +105,140 @@ func (r *postgresAttachmentRepository) GetByMessageID(ctx context.Context, messa
 			&attachment.Type,
 			&attachment.Size,
 			&attachment.Filename,
+			&attachment.ThumbnailURL,
+			&attachment.CreatedAt,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan attachment row", err)
+			continue
+		}
+		attachments = append(attachments, attachment)
+	}
+	
+	if err = rows.Err(); err != nil {
+		r.logger.Error("Error iterating attachment rows", err)
+		return nil, fmt.Errorf("failed to iterate attachments: %w", err)
+	}
+	
+	return attachments, nil
+}
+
+// GetByUserID devuelve, de más reciente a más antiguo, los adjuntos de todas las conversaciones del usuario,
+// opcionalmente de un solo tipo. Los adjuntos de mensajes efímeros vencidos no se incluyen
+func (r *postgresAttachmentRepository) GetByUserID(ctx context.Context, userID string, attachmentType domain.AttachmentType, pagination domain.PaginationParams) ([]domain.Attachment, error) {
+	query := `
+		SELECT a.id, a.message_id, m.conversation_id, a.url, a.type, a.size, a.filename, COALESCE(a.thumbnail_url, ''), a.created_at
+		FROM attachments a
+		JOIN messages m ON m.id = a.message_id
+		JOIN conversations c ON c.id = m.conversation_id
+		WHERE c.user_id = $1 AND ` + visibleMessage + `
+	`
+	
+	args := []interface{}{userID}
+	argIndex := 2
+	
+	if attachmentType != "" {
+		query += fmt.Sprintf(" AND a.type = $%d", argIndex)
+		args = append(args, attachmentType)
+		argIndex++
+	}
+	
+	query += " ORDER BY a.created_at DESC, a.id DESC"
+	
+	if pagination.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argIndex)
+		args = append(args, pagination.Limit)
+		argIndex++
+	}
+	
+	if pagination.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argIndex)
+		args = append(args, pagination.Offset)
+	}
+	
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to get attachments by user ID", err)
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+	defer rows.Close()
+	
+	var attachments []domain.Attachment
+	for rows.Next() {
+		var attachment domain.Attachment
+		err := rows.Scan(
+			&attachment.ID,
+			&attachment.MessageID,
+			&attachment.ConversationID,
+			&attachment.URL,
+			&attachment.Type,
+			&attachment.Size,
+			&attachment.Filename,
+			&attachment.ThumbnailURL,
+			&attachment.CreatedAt,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan attachment row", err)
+			continue
+		}
+		attachments = append(attachments, attachment)
+	}
+	
+	if err = rows.Err(); err != nil {
+		r.logger.Error("Error iterating attachment rows", err)
+		return nil, fmt.Errorf("failed to iterate attachments: %w", err)
+	}
+	
+	return attachments, nil
+}
+
+// likeEscaper neutraliza los comodines de LIKE para que la búsqueda sea literal
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchByFilename busca por subcadena en el nombre de archivo, del adjunto más reciente al más antiguo.
+// El índice trigram sobre filename permite resolver el ILIKE sin recorrer la tabla
+func (r *postgresAttachmentRepository) SearchByFilename(ctx context.Context, conversationID string, query string, pagination domain.PaginationParams) ([]domain.Attachment, error) {
+	sqlQuery := `
+		SELECT a.id, a.message_id, m.conversation_id, a.url, a.type, a.size, a.filename, COALESCE(a.thumbnail_url, ''), a.created_at
+		FROM attachments a
+		JOIN messages m ON m.id = a.message_id
+		WHERE m.conversation_id = $1 AND a.filename ILIKE $2 AND ` + visibleMessage + `
+		ORDER BY a.created_at DESC, a.id DESC
+	`
+	
+	args := []interface{}{conversationID, "%" + likeEscaper.Replace(query) + "%"}
+	argIndex := 3
+	
+	if pagination.Limit > 0 {
+		sqlQuery += fmt.Sprintf(" LIMIT $%d", argIndex)
+		args = append(args, pagination.Limit)
+		argIndex++
+	}
+	
+	if pagination.Offset > 0 {
+		sqlQuery += fmt.Sprintf(" OFFSET $%d", argIndex)
+		args = append(args, pagination.Offset)
+	}
+	
+	rows, err := conn(ctx, r.db).QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		r.logger.Error("Failed to search attachments by filename", err)
+		return nil, fmt.Errorf("failed to search attachments: %w", err)
+	}
+	defer rows.Close()
+	
+	var attachments []domain.Attachment
+	for rows.Next() {
+		var attachment domain.Attachment
+		err := rows.Scan(
+			&attachment.ID,
+			&attachment.MessageID,
+			&attachment.ConversationID,
+			&attachment.URL,
+			&attachment.Type,
+			&attachment.Size,
+			&attachment.Filename,
+			&attachment.ThumbnailURL,
 			&attachment.CreatedAt,
 		)
 		if err != nil {
@@ -119,7 +259,7 @@ func (r *postgresAttachmentRepository) GetByMessageID(ctx context.Context, messa
 func (r *postgresAttachmentRepository) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM attachments WHERE id = $1`
 	
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
 		r.logger.Error("Failed to delete attachment", err)
 		return fmt.Errorf("failed to delete attachment: %w", err)
@@ -131,8 +271,28 @@ func (r *postgresAttachmentRepository) Delete(ctx context.Context, id string) er
 	}
 	
 	if rowsAffected == 0 {
-		return fmt.Errorf("attachment not found")
+		return fmt.Errorf("attachment %w", domain.ErrNotFound)
+	}
+	
+	return nil
+}
+
+func (r *postgresAttachmentRepository) SaveThumbnail(ctx context.Context, userID string, url string, thumbnailURL string) error {
+	// Attachments created later read file_thumbnails in createAttachmentQuery
+	query := `
+		WITH saved AS (
+			INSERT INTO file_thumbnails (url, thumbnail_url, user_id)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (url) DO UPDATE SET thumbnail_url = EXCLUDED.thumbnail_url
+		)
+		UPDATE attachments SET thumbnail_url = $2
+		WHERE url = $1 AND thumbnail_url IS NULL
+	`
+	
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, url, thumbnailURL, userID); err != nil {
+		r.logger.Error("Failed to save thumbnail", err)
+		return fmt.Errorf("failed to save thumbnail: %w", err)
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
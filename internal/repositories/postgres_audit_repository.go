@@ -0,0 +1,163 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+type postgresAuditRepository struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewPostgresAuditRepository(db *sql.DB, logger logger.Logger) domain.AuditRepository {
+	return &postgresAuditRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *postgresAuditRepository) Create(ctx context.Context, log *domain.AuditLog) error {
+	detailsJSON, err := json.Marshal(log.Details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+
+	query := `
+		INSERT INTO audit_logs (id, user_id, action, resource, details, ip_address, user_agent, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		log.ID,
+		log.UserID,
+		log.Action,
+		log.Resource,
+		detailsJSON,
+		log.IPAddress,
+		log.UserAgent,
+		log.CreatedAt,
+	)
+
+	if err != nil {
+		r.logger.Error("Failed to create audit log", err)
+		return fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresAuditRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*domain.AuditLog, error) {
+	query := `
+		SELECT id, user_id, action, resource, details, ip_address, user_agent, created_at
+		FROM audit_logs
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	return r.list(ctx, query, userID, limit, offset)
+}
+
+func (r *postgresAuditRepository) GetByAction(ctx context.Context, action string, limit, offset int) ([]*domain.AuditLog, error) {
+	query := `
+		SELECT id, user_id, action, resource, details, ip_address, user_agent, created_at
+		FROM audit_logs
+		WHERE action = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	return r.list(ctx, query, action, limit, offset)
+}
+
+func (r *postgresAuditRepository) List(ctx context.Context, filters domain.AuditLogFilters) ([]*domain.AuditLog, error) {
+	query := `SELECT id, user_id, action, resource, details, ip_address, user_agent, created_at FROM audit_logs`
+
+	var conditions []string
+	var args []interface{}
+	if filters.UserID != "" {
+		args = append(args, filters.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filters.Action != "" {
+		args = append(args, filters.Action)
+		conditions = append(conditions, fmt.Sprintf("action = $%d", len(args)))
+	}
+	if !filters.From.IsZero() {
+		args = append(args, filters.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if !filters.To.IsZero() {
+		args = append(args, filters.To)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query += " ORDER BY created_at DESC, id"
+
+	if filters.Limit > 0 {
+		args = append(args, filters.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	if filters.Offset > 0 {
+		args = append(args, filters.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	return r.list(ctx, query, args...)
+}
+
+func (r *postgresAuditRepository) list(ctx context.Context, query string, args ...interface{}) ([]*domain.AuditLog, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to get audit logs", err)
+		return nil, fmt.Errorf("failed to get audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []*domain.AuditLog
+	for rows.Next() {
+		var log domain.AuditLog
+		var detailsJSON []byte
+		err := rows.Scan(
+			&log.ID,
+			&log.UserID,
+			&log.Action,
+			&log.Resource,
+			&detailsJSON,
+			&log.IPAddress,
+			&log.UserAgent,
+			&log.CreatedAt,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan audit log row", err)
+			continue
+		}
+
+		if len(detailsJSON) > 0 {
+			if err := json.Unmarshal(detailsJSON, &log.Details); err != nil {
+				r.logger.Error("Failed to unmarshal audit details", err)
+			}
+		}
+
+		logs = append(logs, &log)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.Error("Error iterating audit log rows", err)
+		return nil, fmt.Errorf("failed to iterate audit logs: %w", err)
+	}
+
+	return logs, nil
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresAuditRepository_ListFiltersByTimeRange(t *testing.T) {
+	db := openTestDatabase(t)
+	repo := NewPostgresAuditRepository(db, logger.NewLogger("error"))
+	ctx := context.Background()
+
+	userID := "audit-test-" + uuid.New().String()
+	t.Cleanup(func() { db.Exec(`DELETE FROM audit_logs WHERE user_id = $1`, userID) })
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ids := make([]string, 4)
+	for i := range ids {
+		ids[i] = uuid.New().String()
+		action := "CONVERSATION_STATUS_UPDATE"
+		if i == 2 {
+			action = "CONVERSATION_CREATE"
+		}
+		require.NoError(t, repo.Create(ctx, &domain.AuditLog{
+			ID:        ids[i],
+			UserID:    userID,
+			Action:    action,
+			Resource:  "conversation:" + uuid.New().String(),
+			Details:   map[string]interface{}{},
+			CreatedAt: start.Add(time.Duration(i) * time.Hour),
+		}))
+	}
+
+	listIDs := func(filters domain.AuditLogFilters) []string {
+		filters.UserID = userID
+		logs, err := repo.List(ctx, filters)
+		require.NoError(t, err)
+		var got []string
+		for _, log := range logs {
+			got = append(got, log.ID)
+		}
+		return got
+	}
+
+	// From is inclusive and To exclusive, newest first
+	assert.Equal(t, []string{ids[2], ids[1]}, listIDs(domain.AuditLogFilters{From: start.Add(time.Hour), To: start.Add(3 * time.Hour)}))
+	assert.Equal(t, []string{ids[3], ids[2]}, listIDs(domain.AuditLogFilters{From: start.Add(2 * time.Hour)}))
+	assert.Equal(t, []string{ids[0]}, listIDs(domain.AuditLogFilters{To: start.Add(time.Hour)}))
+
+	// Combined with the action filter and paginated
+	assert.Equal(t, []string{ids[1]}, listIDs(domain.AuditLogFilters{Action: "CONVERSATION_STATUS_UPDATE", To: start.Add(3 * time.Hour), Limit: 1, Offset: 1}))
+}
@@ -3,11 +3,15 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/company/microservice-template/internal/domain"
 	"github.com/company/microservice-template/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type postgresConversationRepository struct {
@@ -24,17 +28,24 @@ func NewPostgresConversationRepository(db *sql.DB, logger logger.Logger) domain.
 
 func (r *postgresConversationRepository) Create(ctx context.Context, conversation *domain.Conversation) error {
 	query := `
-		INSERT INTO conversations (id, user_id, channel, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO conversations (id, user_id, channel, status, created_at, updated_at, reference, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
 	`
 	
-	_, err := r.db.ExecContext(ctx, query,
+	metadataJSON, err := conversationMetadataJSON(conversation.Metadata)
+	if err != nil {
+		return err
+	}
+	
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
 		conversation.ID,
 		conversation.UserID,
 		conversation.Channel,
 		conversation.Status,
 		conversation.CreatedAt,
 		conversation.UpdatedAt,
+		conversation.Reference,
+		metadataJSON,
 	)
 	
 	if err != nil {
@@ -47,24 +58,29 @@ func (r *postgresConversationRepository) Create(ctx context.Context, conversatio
 
 func (r *postgresConversationRepository) GetByID(ctx context.Context, id string) (*domain.Conversation, error) {
 	query := `
-		SELECT id, user_id, channel, status, created_at, updated_at
+		SELECT id, user_id, channel, status, created_at, updated_at, message_count, status_changed_at, COALESCE(reference, ''), tags, metadata
 		FROM conversations
 		WHERE id = $1
 	`
 	
 	var conversation domain.Conversation
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&conversation.ID,
 		&conversation.UserID,
 		&conversation.Channel,
 		&conversation.Status,
 		&conversation.CreatedAt,
 		&conversation.UpdatedAt,
+		&conversation.MessageCount,
+		&conversation.StatusChangedAt,
+		&conversation.Reference,
+		pq.Array(&conversation.Tags),
+		&conversation.Metadata,
 	)
 	
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("conversation not found")
+			return nil, fmt.Errorf("conversation %w", domain.ErrNotFound)
 		}
 		r.logger.Error("Failed to get conversation by ID", err)
 		return nil, fmt.Errorf("failed to get conversation: %w", err)
@@ -73,32 +89,59 @@ func (r *postgresConversationRepository) GetByID(ctx context.Context, id string)
 	return &conversation, nil
 }
 
+// GetByReference busca una conversación por su referencia legible, sin distinguir mayúsculas
+func (r *postgresConversationRepository) GetByReference(ctx context.Context, reference string) (*domain.Conversation, error) {
+	query := `
+		SELECT id, user_id, channel, status, created_at, updated_at, message_count, status_changed_at, COALESCE(reference, ''), tags, metadata
+		FROM conversations
+		WHERE reference = UPPER($1)
+	`
+	
+	var conversation domain.Conversation
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, reference).Scan(
+		&conversation.ID,
+		&conversation.UserID,
+		&conversation.Channel,
+		&conversation.Status,
+		&conversation.CreatedAt,
+		&conversation.UpdatedAt,
+		&conversation.MessageCount,
+		&conversation.StatusChangedAt,
+		&conversation.Reference,
+		pq.Array(&conversation.Tags),
+		&conversation.Metadata,
+	)
+	
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("conversation %w", domain.ErrNotFound)
+		}
+		r.logger.Error("Failed to get conversation by reference", err)
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	
+	return &conversation, nil
+}
+
 func (r *postgresConversationRepository) GetByUserID(ctx context.Context, userID string, filters domain.ConversationFilters) ([]domain.Conversation, error) {
 	var conditions []string
 	var args []interface{}
 	argIndex := 1
 	
-	// Base query
+	// Base query; the lateral joins bring each conversation's latest message and unread count in the same round-trip
 	query := `
-		SELECT id, user_id, channel, status, created_at, updated_at
+		SELECT id, user_id, channel, status, created_at, updated_at, message_count, status_changed_at, COALESCE(reference, ''), tags, metadata,
+			` + lastMessageColumns + `, unread_count
 		FROM conversations
+		` + lastMessageJoin + `
+		` + unreadCountJoin + `
 		WHERE user_id = $1
 	`
 	args = append(args, userID)
 	argIndex++
 	
 	// Add filters
-	if filters.Channel != "" {
-		conditions = append(conditions, fmt.Sprintf("channel = $%d", argIndex))
-		args = append(args, filters.Channel)
-		argIndex++
-	}
-	
-	if filters.Status != "" {
-		conditions = append(conditions, fmt.Sprintf("status = $%d", argIndex))
-		args = append(args, filters.Status)
-		argIndex++
-	}
+	conditions, args, argIndex = appendConversationFilters(conditions, args, argIndex, filters)
 	
 	// Add conditions to query
 	if len(conditions) > 0 {
@@ -119,7 +162,7 @@ func (r *postgresConversationRepository) GetByUserID(ctx context.Context, userID
 		args = append(args, filters.Offset)
 	}
 	
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		r.logger.Error("Failed to get conversations by user ID", err)
 		return nil, fmt.Errorf("failed to get conversations: %w", err)
@@ -129,6 +172,7 @@ func (r *postgresConversationRepository) GetByUserID(ctx context.Context, userID
 	var conversations []domain.Conversation
 	for rows.Next() {
 		var conversation domain.Conversation
+		var lastMessage lastMessageRow
 		err := rows.Scan(
 			&conversation.ID,
 			&conversation.UserID,
@@ -136,11 +180,24 @@ func (r *postgresConversationRepository) GetByUserID(ctx context.Context, userID
 			&conversation.Status,
 			&conversation.CreatedAt,
 			&conversation.UpdatedAt,
+			&conversation.MessageCount,
+			&conversation.StatusChangedAt,
+			&conversation.Reference,
+			pq.Array(&conversation.Tags),
+			&conversation.Metadata,
+			&lastMessage.id,
+			&lastMessage.senderType,
+			&lastMessage.senderID,
+			&lastMessage.content,
+			&lastMessage.contentType,
+			&lastMessage.timestamp,
+			&conversation.UnreadCount,
 		)
 		if err != nil {
 			r.logger.Error("Failed to scan conversation row", err)
 			continue
 		}
+		conversation.LastMessage = lastMessage.message(conversation.ID)
 		conversations = append(conversations, conversation)
 	}
 	
@@ -152,19 +209,76 @@ func (r *postgresConversationRepository) GetByUserID(ctx context.Context, userID
 	return conversations, nil
 }
 
+// lastMessageJoin une a cada conversación su último mensaje visible; las columnas llevan prefijo para no
+// chocar con las de conversations
+const lastMessageJoin = `
+		LEFT JOIN LATERAL (
+			SELECT id AS last_message_id, sender_type AS last_message_sender_type, sender_id AS last_message_sender_id,
+				content AS last_message_content, content_type AS last_message_content_type, timestamp AS last_message_timestamp
+			FROM messages
+			WHERE conversation_id = conversations.id AND ` + visibleMessage + `
+			ORDER BY timestamp DESC, id DESC
+			LIMIT 1
+		) last_message ON true`
+
+// unreadCountJoin cuenta, para el usuario $1, los mensajes visibles de otros remitentes posteriores a su
+// marca de lectura y sin acuse de lectura propio
+const unreadCountJoin = `
+		LEFT JOIN LATERAL (
+			SELECT COUNT(*) AS unread_count
+			FROM messages m
+			WHERE m.conversation_id = conversations.id AND ` + visibleMessage + ` AND m.sender_id <> $1
+				AND m.timestamp > COALESCE((
+					SELECT rm.last_read_at FROM conversation_read_markers rm
+					WHERE rm.conversation_id = conversations.id AND rm.user_id = $1
+				), '-infinity')
+				AND NOT EXISTS (SELECT 1 FROM message_reads r WHERE r.message_id = m.id AND r.user_id = $1)
+		) unread ON true`
+
+const lastMessageColumns = `last_message_id, last_message_sender_type, last_message_sender_id,
+			last_message_content, last_message_content_type, last_message_timestamp`
+
+// lastMessageRow recibe las columnas de lastMessageJoin, nulas si la conversación no tiene mensajes
+type lastMessageRow struct {
+	id          sql.NullString
+	senderType  sql.NullString
+	senderID    sql.NullString
+	content     sql.NullString
+	contentType sql.NullString
+	timestamp   sql.NullTime
+}
+
+func (m lastMessageRow) message(conversationID string) *domain.Message {
+	if !m.id.Valid {
+		return nil
+	}
+	return &domain.Message{
+		ID:             m.id.String,
+		ConversationID: conversationID,
+		SenderType:     domain.SenderType(m.senderType.String),
+		SenderID:       m.senderID.String,
+		Content:        m.content.String,
+		ContentType:    domain.ContentType(m.contentType.String),
+		Timestamp:      m.timestamp.Time,
+	}
+}
+
+// Update no toca los metadatos, que solo cambian con UpdateMetadata: así un cambio de estado hecho sobre una
+// copia cacheada no deshace una mezcla de metadatos concurrente
 func (r *postgresConversationRepository) Update(ctx context.Context, conversation *domain.Conversation) error {
 	query := `
 		UPDATE conversations
-		SET user_id = $2, channel = $3, status = $4, updated_at = $5
+		SET user_id = $2, channel = $3, status = $4, updated_at = $5, status_changed_at = $6
 		WHERE id = $1
 	`
 	
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
 		conversation.ID,
 		conversation.UserID,
 		conversation.Channel,
 		conversation.Status,
 		conversation.UpdatedAt,
+		conversation.StatusChangedAt,
 	)
 	
 	if err != nil {
@@ -178,7 +292,7 @@ func (r *postgresConversationRepository) Update(ctx context.Context, conversatio
 	}
 	
 	if rowsAffected == 0 {
-		return fmt.Errorf("conversation not found")
+		return fmt.Errorf("conversation %w", domain.ErrNotFound)
 	}
 	
 	return nil
@@ -187,7 +301,7 @@ func (r *postgresConversationRepository) Update(ctx context.Context, conversatio
 func (r *postgresConversationRepository) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM conversations WHERE id = $1`
 	
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
 		r.logger.Error("Failed to delete conversation", err)
 		return fmt.Errorf("failed to delete conversation: %w", err)
@@ -199,8 +313,827 @@ func (r *postgresConversationRepository) Delete(ctx context.Context, id string)
 	}
 	
 	if rowsAffected == 0 {
-		return fmt.Errorf("conversation not found")
+		return fmt.Errorf("conversation %w", domain.ErrNotFound)
+	}
+	
+	return nil
+}
+
+// DeleteByUserID elimina en una transacción las conversaciones del usuario con sus mensajes y adjuntos, y
+// además sus mensajes, adjuntos y reacciones en conversaciones ajenas
+func (r *postgresConversationRepository) DeleteByUserID(ctx context.Context, userID string, beforeCommit func(*domain.UserDataPurge) error) (*domain.UserDataPurge, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		r.logger.Error("Failed to begin user purge transaction", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	purge := &domain.UserDataPurge{UserID: userID}
+	
+	// Lock the user's conversations so no messages are added mid-purge
+	purge.ConversationIDs, err = queryStrings(ctx, tx, `SELECT id FROM conversations WHERE user_id = $1 FOR UPDATE`, userID)
+	if err != nil {
+		r.logger.Error("Failed to list conversations to purge", err)
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	
+	// Other users' conversations lose the user's messages, so their cached pages are stale too
+	otherIDs, err := queryStrings(ctx, tx, `
+		SELECT DISTINCT m.conversation_id
+		FROM messages m JOIN conversations c ON c.id = m.conversation_id
+		WHERE m.sender_id = $1 AND c.user_id <> $1
+	`, userID)
+	if err != nil {
+		r.logger.Error("Failed to list conversations to purge", err)
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	purge.ConversationIDs = append(purge.ConversationIDs, otherIDs...)
+	
+	// Thumbnails are separate files, except for small images that use the original as their thumbnail
+	purge.AttachmentURLs, err = queryStrings(ctx, tx, `
+		SELECT DISTINCT u.url
+		FROM attachments a
+		JOIN messages m ON m.id = a.message_id
+		JOIN conversations c ON c.id = m.conversation_id
+		CROSS JOIN LATERAL (VALUES (a.url), (a.thumbnail_url)) AS u(url)
+		WHERE (c.user_id = $1 OR m.sender_id = $1) AND u.url IS NOT NULL
+	`, userID)
+	if err != nil {
+		r.logger.Error("Failed to list attachments to purge", err)
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	
+	// Files of pending scheduled sends only exist in their JSON; a sent one's files belong to its message
+	scheduledURLs, err := queryStrings(ctx, tx, `
+		SELECT DISTINCT u.url
+		FROM scheduled_messages s
+		CROSS JOIN LATERAL jsonb_array_elements(s.attachments) AS a(attachment)
+		CROSS JOIN LATERAL (VALUES (a.attachment->>'url'), (a.attachment->>'thumbnail_url')) AS u(url)
+		WHERE (s.sender_id = $1 OR s.conversation_id IN (SELECT id FROM conversations WHERE user_id = $1))
+			AND s.status <> 'sent' AND u.url IS NOT NULL AND u.url <> ''
+	`, userID)
+	if err != nil {
+		r.logger.Error("Failed to list scheduled attachments to purge", err)
+		return nil, fmt.Errorf("failed to list scheduled attachments: %w", err)
+	}
+	purge.AttachmentURLs = append(purge.AttachmentURLs, scheduledURLs...)
+	
+	// Memberships, read markers, receipts and thumbnail records are not reported separately
+	var readRows int64
+	steps := []struct {
+		query string
+		count *int64
+	}{
+		{`DELETE FROM reactions WHERE user_id = $1`, &purge.Reactions},
+		{`DELETE FROM attachments WHERE message_id IN (
+			SELECT m.id FROM messages m JOIN conversations c ON c.id = m.conversation_id
+			WHERE c.user_id = $1 OR m.sender_id = $1
+		)`, &purge.Attachments},
+		{`DELETE FROM messages WHERE conversation_id IN (SELECT id FROM conversations WHERE user_id = $1)`, &purge.Messages},
+		// What is left of the user's messages is in other users' conversations, whose counters must drop
+		{`WITH visible AS (
+			SELECT conversation_id, COUNT(*) AS count FROM messages
+			WHERE sender_id = $1 AND deleted_at IS NULL
+			GROUP BY conversation_id
+		), recount AS (
+			UPDATE conversations c SET message_count = GREATEST(c.message_count - visible.count, 0)
+			FROM visible WHERE c.id = visible.conversation_id
+		)
+		DELETE FROM messages WHERE sender_id = $1`, &purge.Messages},
+		{`DELETE FROM scheduled_messages WHERE sender_id = $1 OR conversation_id IN (
+			SELECT id FROM conversations WHERE user_id = $1
+		)`, &purge.ScheduledMessages},
+		{`DELETE FROM conversation_participants WHERE user_id = $1`, &readRows},
+		{`DELETE FROM conversation_read_markers WHERE user_id = $1`, &readRows},
+		{`DELETE FROM message_reads WHERE user_id = $1`, &readRows},
+		{`DELETE FROM file_thumbnails WHERE user_id = $1`, &readRows},
+		{`DELETE FROM message_drafts WHERE user_id = $1`, &purge.Drafts},
+		{`DELETE FROM conversations WHERE user_id = $1`, &purge.Conversations},
+	}
+	
+	for _, step := range steps {
+		result, err := tx.ExecContext(ctx, step.query, userID)
+		if err != nil {
+			r.logger.Error("Failed to purge user data", err)
+			return nil, fmt.Errorf("failed to purge user data: %w", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		*step.count += affected
+	}
+	
+	if beforeCommit != nil {
+		if err := beforeCommit(purge); err != nil {
+			return nil, err
+		}
+	}
+	
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit user purge", err)
+		return nil, fmt.Errorf("failed to commit user purge: %w", err)
+	}
+	
+	return purge, nil
+}
+
+func queryStrings(ctx context.Context, tx dbtx, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	
+	return values, rows.Err()
+}
+
+// SearchByMetadata usa la contención JSONB (metadata @> match), que resuelve el índice GIN de metadata
+func (r *postgresConversationRepository) SearchByMetadata(ctx context.Context, match domain.JSONB, userID string, limit int, offset int) ([]domain.Conversation, error) {
+	matchJSON, err := json.Marshal(match)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	
+	query := `
+		SELECT c.id, c.user_id, c.channel, c.status, c.created_at, c.updated_at, c.message_count, c.status_changed_at, COALESCE(c.reference, ''), c.tags, c.metadata
+		FROM conversations c
+		WHERE c.metadata @> $1::jsonb
+			AND ($2 = '' OR c.user_id = $2 OR EXISTS (
+				SELECT 1 FROM conversation_participants p WHERE p.conversation_id = c.id AND p.user_id = $2
+			))
+		ORDER BY c.updated_at DESC, c.id
+		LIMIT $3 OFFSET $4
+	`
+	
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, string(matchJSON), userID, limit, offset)
+	if err != nil {
+		r.logger.Error("Failed to search conversations by metadata", err)
+		return nil, fmt.Errorf("failed to search conversations: %w", err)
+	}
+	defer rows.Close()
+	
+	var conversations []domain.Conversation
+	for rows.Next() {
+		var conversation domain.Conversation
+		err := rows.Scan(
+			&conversation.ID,
+			&conversation.UserID,
+			&conversation.Channel,
+			&conversation.Status,
+			&conversation.CreatedAt,
+			&conversation.UpdatedAt,
+			&conversation.MessageCount,
+			&conversation.StatusChangedAt,
+			&conversation.Reference,
+			pq.Array(&conversation.Tags),
+			&conversation.Metadata,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan conversation: %w", err)
+		}
+		conversations = append(conversations, conversation)
+	}
+	
+	return conversations, rows.Err()
+}
+
+// GetAbandonmentCandidates devuelve conversaciones activas cuyo último mensaje es del agente o bot
+// y en las que el cliente no escribe desde idleSince
+func (r *postgresConversationRepository) GetAbandonmentCandidates(ctx context.Context, idleSince time.Time, channels []domain.Channel, limit int) ([]domain.Conversation, error) {
+	query := `
+		SELECT c.id, c.user_id, c.channel, c.status, c.created_at, c.updated_at, c.message_count, c.status_changed_at, COALESCE(c.reference, ''), c.tags, c.metadata
+		FROM conversations c
+		JOIN LATERAL (
+			SELECT sender_type, timestamp
+			FROM messages m
+			WHERE m.conversation_id = c.id AND m.deleted_at IS NULL
+			ORDER BY m.timestamp DESC
+			LIMIT 1
+		) last ON TRUE
+		WHERE c.status = 'active'
+			AND c.channel = ANY($2)
+			AND last.sender_type <> 'user'
+			AND last.timestamp < $1
+		ORDER BY c.updated_at ASC
+		LIMIT $3
+	`
+	
+	channelNames := make([]string, len(channels))
+	for i, channel := range channels {
+		channelNames[i] = string(channel)
+	}
+	
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, idleSince, pq.Array(channelNames), limit)
+	if err != nil {
+		r.logger.Error("Failed to get abandonment candidates", err)
+		return nil, fmt.Errorf("failed to get abandonment candidates: %w", err)
+	}
+	defer rows.Close()
+	
+	var conversations []domain.Conversation
+	for rows.Next() {
+		var conversation domain.Conversation
+		err := rows.Scan(
+			&conversation.ID,
+			&conversation.UserID,
+			&conversation.Channel,
+			&conversation.Status,
+			&conversation.CreatedAt,
+			&conversation.UpdatedAt,
+			&conversation.MessageCount,
+			&conversation.StatusChangedAt,
+			&conversation.Reference,
+			pq.Array(&conversation.Tags),
+			&conversation.Metadata,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan conversation row", err)
+			continue
+		}
+		conversations = append(conversations, conversation)
+	}
+	
+	if err = rows.Err(); err != nil {
+		r.logger.Error("Error iterating conversation rows", err)
+		return nil, fmt.Errorf("failed to iterate conversations: %w", err)
+	}
+	
+	return conversations, nil
+}
+
+// CreateWithMessages crea la conversación y sus mensajes iniciales en una única transacción
+func (r *postgresConversationRepository) CreateWithMessages(ctx context.Context, conversation *domain.Conversation, messages []domain.Message) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		r.logger.Error("Failed to begin conversation transaction", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	metadataJSON, err := conversationMetadataJSON(conversation.Metadata)
+	if err != nil {
+		return err
+	}
+	
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO conversations (id, user_id, channel, status, created_at, updated_at, message_count, reference, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9)
+	`,
+		conversation.ID,
+		conversation.UserID,
+		conversation.Channel,
+		conversation.Status,
+		conversation.CreatedAt,
+		conversation.UpdatedAt,
+		len(messages),
+		conversation.Reference,
+		metadataJSON,
+	)
+	if err != nil {
+		r.logger.Error("Failed to create conversation", err)
+		return fmt.Errorf("failed to create conversation: %w", err)
+	}
+	
+	for i := range messages {
+		args, err := insertMessageArgs(&messages[i])
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, insertMessageQuery, args...); err != nil {
+			r.logger.Error("Failed to create message", err)
+			return fmt.Errorf("failed to create message: %w", err)
+		}
+	}
+	
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit conversation transaction", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+
+// appendConversationFilters añade las condiciones de canal y estado de filters a partir del placeholder argIndex
+func appendConversationFilters(conditions []string, args []interface{}, argIndex int, filters domain.ConversationFilters) ([]string, []interface{}, int) {
+	if filters.Channel != "" {
+		conditions = append(conditions, fmt.Sprintf("channel = $%d", argIndex))
+		args = append(args, filters.Channel)
+		argIndex++
+	}
+	
+	if filters.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("status = $%d", argIndex))
+		args = append(args, filters.Status)
+		argIndex++
+	}
+	
+	// Containment keeps the AND semantics and can use the GIN index on tags
+	if len(filters.Tags) > 0 {
+		conditions = append(conditions, fmt.Sprintf("tags @> $%d::text[]", argIndex))
+		args = append(args, pq.Array(filters.Tags))
+		argIndex++
+	}
+	
+	return conditions, args, argIndex
+}
+
+// TransferOwnership reasigna al nuevo propietario un lote de hasta limit conversaciones del usuario
+// que cumplen los filtros y devuelve sus IDs. En la misma transacción registra una fila de auditoría
+// CONVERSATION_OWNERSHIP_TRANSFERRED por conversación a nombre de requestedBy. Las filas bloqueadas por
+// otra transferencia se omiten, y como solo se toman conversaciones que siguen siendo de fromUserID,
+// repetir la llamada continúa donde quedó
+func (r *postgresConversationRepository) TransferOwnership(ctx context.Context, fromUserID string, toUserID string, filters domain.ConversationFilters, limit int, requestedBy string) ([]string, error) {
+	conditions, args, argIndex := appendConversationFilters([]string{"user_id = $1"}, []interface{}{fromUserID, toUserID}, 3, filters)
+	args = append(args, limit)
+	
+	query := fmt.Sprintf(`
+		UPDATE conversations
+		SET user_id = $2, updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM conversations
+			WHERE %s
+			ORDER BY id
+			LIMIT $%d
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id
+	`, strings.Join(conditions, " AND "), argIndex)
+	
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		r.logger.Error("Failed to begin ownership transfer transaction", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	ids, err := queryStrings(ctx, tx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to transfer conversation ownership", err)
+		return nil, fmt.Errorf("failed to transfer ownership: %w", err)
+	}
+	
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	
+	details, err := json.Marshal(map[string]interface{}{
+		"from_user_id": fromUserID,
+		"to_user_id":   toUserID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+	
+	auditIDs := make([]string, len(ids))
+	for i := range ids {
+		auditIDs[i] = uuid.New().String()
+	}
+	
+	auditQuery := `
+		INSERT INTO audit_logs (id, user_id, action, resource, details, created_at)
+		SELECT a.id, $3, 'CONVERSATION_OWNERSHIP_TRANSFERRED', 'conversation:' || a.conversation_id, $4, NOW()
+		FROM unnest($1::uuid[], $2::text[]) AS a(id, conversation_id)
+	`
+	if _, err := tx.ExecContext(ctx, auditQuery, pq.Array(auditIDs), pq.Array(ids), requestedBy, details); err != nil {
+		r.logger.Error("Failed to audit ownership transfer", err)
+		return nil, fmt.Errorf("failed to audit ownership transfer: %w", err)
+	}
+	
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit ownership transfer", err)
+		return nil, fmt.Errorf("failed to commit ownership transfer: %w", err)
+	}
+	
+	return ids, nil
+}
+
+// CountByUserID cuenta las conversaciones del usuario que cumplen los filtros
+func (r *postgresConversationRepository) CountByUserID(ctx context.Context, userID string, filters domain.ConversationFilters) (int64, error) {
+	conditions, args, _ := appendConversationFilters([]string{"user_id = $1"}, []interface{}{userID}, 2, filters)
+	query := "SELECT COUNT(*) FROM conversations WHERE " + strings.Join(conditions, " AND ")
+	
+	var count int64
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		r.logger.Error("Failed to count conversations", err)
+		return 0, fmt.Errorf("failed to count conversations: %w", err)
+	}
+	
+	return count, nil
+}
+
+// ReconcileMessageCounts recalcula message_count a partir de la tabla messages para un lote de hasta limit
+// conversaciones posteriores a afterID en orden de id, corrige solo las que difieren y devuelve sus IDs junto
+// con el último id del lote para continuar desde ahí, o "" si no había conversaciones tras afterID. Las filas
+// del lote se bloquean para no pisar los incrementos de envíos concurrentes; un mensaje confirmado durante
+// la pasada se corrige en la siguiente
+func (r *postgresConversationRepository) ReconcileMessageCounts(ctx context.Context, afterID string, limit int) (string, []string, error) {
+	query := `
+		WITH batch AS (
+			SELECT id FROM conversations
+			WHERE $1::uuid IS NULL OR id > $1::uuid
+			ORDER BY id
+			LIMIT $2
+			FOR UPDATE
+		), fixed AS (
+			UPDATE conversations c
+			SET message_count = actual.count
+			FROM (
+				SELECT b.id, (SELECT COUNT(*) FROM messages m WHERE m.conversation_id = b.id AND m.deleted_at IS NULL) AS count
+				FROM batch b
+			) actual
+			WHERE c.id = actual.id AND c.message_count <> actual.count
+			RETURNING c.id
+		)
+		SELECT COALESCE((SELECT id::text FROM batch ORDER BY id DESC LIMIT 1), ''),
+			COALESCE((SELECT array_agg(id::text) FROM fixed), '{}')
+	`
+	
+	var after sql.NullString
+	if afterID != "" {
+		after = sql.NullString{String: afterID, Valid: true}
+	}
+	
+	var lastID string
+	var fixedIDs []string
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, after, limit).Scan(&lastID, pq.Array(&fixedIDs)); err != nil {
+		r.logger.Error("Failed to reconcile message counts", err)
+		return "", nil, fmt.Errorf("failed to reconcile message counts: %w", err)
+	}
+	
+	return lastID, fixedIDs, nil
+}
+
+// ClaimAutoReply marca la conversación como respondida automáticamente en now si no lo estaba ya desde
+// repliedBefore. La comprobación y la marca son una sola sentencia, así que solo un llamador obtiene true
+func (r *postgresConversationRepository) ClaimAutoReply(ctx context.Context, conversationID string, repliedBefore time.Time, now time.Time) (bool, error) {
+	query := `
+		UPDATE conversations
+		SET auto_replied_at = $3
+		WHERE id = $1 AND (auto_replied_at IS NULL OR auto_replied_at < $2)
+	`
+	
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, conversationID, repliedBefore, now)
+	if err != nil {
+		r.logger.Error("Failed to claim auto reply", err)
+		return false, fmt.Errorf("failed to claim auto reply: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	return rowsAffected > 0, nil
+}
+
+// UpdateStatusIfIdle cambia el estado de la conversación solo si no cambió después de changedBefore.
+// La comprobación del intervalo y el cambio son una sola sentencia, así que dos cambios simultáneos
+// no pueden saltarse el intervalo mínimo
+func (r *postgresConversationRepository) UpdateStatusIfIdle(ctx context.Context, conversationID string, status domain.ConversationStatus, changedBefore time.Time, now time.Time) (bool, error) {
+	query := `
+		UPDATE conversations
+		SET status = $2, status_changed_at = $4, updated_at = $4
+		WHERE id = $1 AND (status_changed_at IS NULL OR status_changed_at <= $3)
+	`
+	
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, conversationID, status, changedBefore, now)
+	if err != nil {
+		r.logger.Error("Failed to update conversation status", err)
+		return false, fmt.Errorf("failed to update conversation status: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	return rowsAffected > 0, nil
+}
+
+// UpdateStatusBulk bloquea las conversaciones solicitadas y cambia, en la misma sentencia, las que no tienen
+// ya el estado y no cambiaron después de changedBefore
+func (r *postgresConversationRepository) UpdateStatusBulk(ctx context.Context, conversationIDs []string, status domain.ConversationStatus, ownerID string, changedBefore time.Time, now time.Time) (map[string]domain.ConversationStatusOutcome, error) {
+	query := `
+		WITH target AS (
+			SELECT id, status <> $2 AS changing, (status_changed_at IS NULL OR status_changed_at <= $4) AS idle
+			FROM conversations
+			WHERE id = ANY($1::uuid[]) AND ($3 = '' OR user_id = $3)
+			FOR UPDATE
+		), updated AS (
+			UPDATE conversations c
+			SET status = $2, status_changed_at = $5, updated_at = $5
+			FROM target t
+			WHERE c.id = t.id AND t.changing AND t.idle
+		)
+		SELECT id, changing, idle FROM target
+	`
+	
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, pq.Array(conversationIDs), status, ownerID, changedBefore, now)
+	if err != nil {
+		r.logger.Error("Failed to update conversation statuses", err)
+		return nil, fmt.Errorf("failed to update conversation statuses: %w", err)
+	}
+	defer rows.Close()
+	
+	outcomes := make(map[string]domain.ConversationStatusOutcome)
+	for rows.Next() {
+		var id string
+		var changing, idle bool
+		if err := rows.Scan(&id, &changing, &idle); err != nil {
+			return nil, fmt.Errorf("failed to scan conversation: %w", err)
+		}
+		
+		switch {
+		case !changing:
+			outcomes[id] = domain.ConversationStatusOutcomeUnchanged
+		case !idle:
+			outcomes[id] = domain.ConversationStatusOutcomeTooFrequent
+		default:
+			outcomes[id] = domain.ConversationStatusOutcomeUpdated
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate conversations: %w", err)
+	}
+	
+	return outcomes, nil
+}
+
+// NextReferenceNumber incrementa y devuelve el contador del prefijo. El upsert bloquea la fila del contador
+// hasta terminar la sentencia, por lo que dos llamadas simultáneas nunca obtienen el mismo número
+func (r *postgresConversationRepository) NextReferenceNumber(ctx context.Context, prefix string) (int64, error) {
+	query := `
+		INSERT INTO conversation_reference_counters (prefix, last_value)
+		VALUES ($1, 1)
+		ON CONFLICT (prefix) DO UPDATE SET last_value = conversation_reference_counters.last_value + 1
+		RETURNING last_value
+	`
+	
+	var next int64
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, prefix).Scan(&next); err != nil {
+		r.logger.Error("Failed to get next reference number", err)
+		return 0, fmt.Errorf("failed to get next reference number: %w", err)
+	}
+	
+	return next, nil
+}
+
+// AddTagToConversations añade la etiqueta a las conversaciones indicadas dentro de una transacción. Las filas
+// se bloquean antes de actualizarlas para que el resultado de cada conversación refleje lo que se escribió
+func (r *postgresConversationRepository) AddTagToConversations(ctx context.Context, conversationIDs []string, tag string, ownerID string) (map[string]bool, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		r.logger.Error("Failed to begin bulk tag transaction", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, $2 = ANY(tags)
+		FROM conversations
+		WHERE id = ANY($1::uuid[]) AND ($3 = '' OR user_id = $3)
+		ORDER BY id
+		FOR UPDATE
+	`, pq.Array(conversationIDs), tag, ownerID)
+	if err != nil {
+		r.logger.Error("Failed to lock conversations for tagging", err)
+		return nil, fmt.Errorf("failed to get conversations: %w", err)
+	}
+	
+	results := make(map[string]bool)
+	var toTag []string
+	for rows.Next() {
+		var id string
+		var alreadyTagged bool
+		if err := rows.Scan(&id, &alreadyTagged); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan conversation: %w", err)
+		}
+		results[id] = !alreadyTagged
+		if !alreadyTagged {
+			toTag = append(toTag, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate conversations: %w", err)
+	}
+	
+	if len(toTag) > 0 {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE conversations
+			SET tags = array_append(tags, $2), updated_at = NOW()
+			WHERE id = ANY($1::uuid[])
+		`, pq.Array(toTag), tag)
+		if err != nil {
+			r.logger.Error("Failed to tag conversations", err)
+			return nil, fmt.Errorf("failed to tag conversations: %w", err)
+		}
+	}
+	
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit bulk tag transaction", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	
+	return results, nil
+}
+
+func (r *postgresConversationRepository) RemoveTag(ctx context.Context, conversationID string, tag string) (bool, error) {
+	query := `
+		UPDATE conversations
+		SET tags = array_remove(tags, $2), updated_at = NOW()
+		WHERE id = $1 AND $2 = ANY(tags)
+	`
+	
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, conversationID, tag)
+	if err != nil {
+		r.logger.Error("Failed to remove conversation tag", err)
+		return false, fmt.Errorf("failed to remove tag: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	
+	return rowsAffected > 0, nil
+}
+
+func (r *postgresConversationRepository) TouchUpdatedAt(ctx context.Context, conversationID string, updatedAt time.Time) error {
+	// A message stored late never moves the conversation back in the listing
+	query := `UPDATE conversations SET updated_at = GREATEST(updated_at, $2) WHERE id = $1`
+	
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, conversationID, updatedAt)
+	if err != nil {
+		r.logger.Error("Failed to touch conversation", err)
+		return fmt.Errorf("failed to update conversation: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	if rowsAffected == 0 {
+		return fmt.Errorf("conversation %w", domain.ErrNotFound)
+	}
+	
+	return nil
+}
+
+// CountCreatedBefore cuenta las conversaciones no archivadas del canal creadas antes de createdBefore
+func (r *postgresConversationRepository) CountCreatedBefore(ctx context.Context, channel domain.Channel, createdBefore time.Time) (int64, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM conversations
+		WHERE channel = $1 AND created_at < $2 AND status <> 'archived'
+	`
+	
+	var count int64
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, channel, createdBefore).Scan(&count); err != nil {
+		r.logger.Error("Failed to count aged conversations", err)
+		return 0, fmt.Errorf("failed to count conversations: %w", err)
+	}
+	
+	return count, nil
+}
+
+// ArchiveCreatedBefore archiva un lote de las conversaciones más antiguas del canal. Solo bloquea las filas
+// del lote y omite las que tiene bloqueadas otra transacción, que se archivarán en una pasada posterior
+func (r *postgresConversationRepository) ArchiveCreatedBefore(ctx context.Context, channel domain.Channel, createdBefore time.Time, now time.Time, limit int) ([]string, error) {
+	query := `
+		UPDATE conversations
+		SET status = 'archived', status_changed_at = $3, updated_at = $3
+		WHERE id IN (
+			SELECT id FROM conversations
+			WHERE channel = $1 AND created_at < $2 AND status <> 'archived'
+			ORDER BY created_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id
+	`
+	
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, channel, createdBefore, now, limit)
+	if err != nil {
+		r.logger.Error("Failed to archive aged conversations", err)
+		return nil, fmt.Errorf("failed to archive conversations: %w", err)
+	}
+	defer rows.Close()
+	
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan conversation id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	
+	return ids, rows.Err()
+}
+
+// CountStale cuenta las conversaciones activas cuyo updated_at, que avanza con cada mensaje, es anterior a olderThan
+func (r *postgresConversationRepository) CountStale(ctx context.Context, olderThan time.Time) (int64, error) {
+	query := `SELECT COUNT(*) FROM conversations WHERE status = 'active' AND updated_at < $1`
+	
+	var count int64
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, olderThan).Scan(&count); err != nil {
+		r.logger.Error("Failed to count stale conversations", err)
+		return 0, fmt.Errorf("failed to count conversations: %w", err)
+	}
+	
+	return count, nil
+}
+
+// ArchiveStale archiva en un único UPDATE las conversaciones activas sin actividad desde olderThan. Omite las
+// filas que tiene bloqueadas otra transacción, como una conversación que está recibiendo un mensaje
+func (r *postgresConversationRepository) ArchiveStale(ctx context.Context, olderThan time.Time) (int64, error) {
+	query := `
+		UPDATE conversations
+		SET status = 'archived', status_changed_at = NOW(), updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM conversations
+			WHERE status = 'active' AND updated_at < $1
+			FOR UPDATE SKIP LOCKED
+		)
+	`
+	
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, olderThan)
+	if err != nil {
+		r.logger.Error("Failed to archive stale conversations", err)
+		return 0, fmt.Errorf("failed to archive conversations: %w", err)
+	}
+	
+	archived, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	return archived, nil
+}
+
+func (r *postgresConversationRepository) UpdateMetadata(ctx context.Context, conversationID string, update func(metadata domain.JSONB) (domain.JSONB, error)) (domain.JSONB, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		r.logger.Error("Failed to begin metadata transaction", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	var metadata domain.JSONB
+	err = tx.QueryRowContext(ctx, `SELECT metadata FROM conversations WHERE id = $1 FOR UPDATE`, conversationID).Scan(&metadata)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("conversation %w", domain.ErrNotFound)
+		}
+		r.logger.Error("Failed to lock conversation metadata", err)
+		return nil, fmt.Errorf("failed to get conversation metadata: %w", err)
+	}
+	
+	updated, err := update(metadata)
+	if err != nil {
+		return nil, err
+	}
+	
+	metadataJSON, err := conversationMetadataJSON(updated)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE conversations SET metadata = $2 WHERE id = $1`, conversationID, metadataJSON); err != nil {
+		r.logger.Error("Failed to update conversation metadata", err)
+		return nil, fmt.Errorf("failed to update conversation metadata: %w", err)
+	}
+	
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit metadata transaction", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	
+	return updated, nil
+}
+
+// conversationMetadataJSON serializa los metadatos; sin metadatos se guarda un objeto vacío, no null
+func conversationMetadataJSON(metadata domain.JSONB) ([]byte, error) {
+	if metadata == nil {
+		metadata = domain.JSONB{}
+	}
+	
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal conversation metadata: %w", err)
+	}
+	return metadataJSON, nil
+}
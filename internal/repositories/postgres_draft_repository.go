@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+type postgresDraftRepository struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewPostgresDraftRepository(db *sql.DB, logger logger.Logger) domain.DraftRepository {
+	return &postgresDraftRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Upsert guarda el borrador del usuario en la conversación, sustituyendo el anterior si lo había
+func (r *postgresDraftRepository) Upsert(ctx context.Context, draft *domain.MessageDraft) error {
+	metadataJSON, err := json.Marshal(draft.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal draft metadata: %w", err)
+	}
+
+	query := `
+		INSERT INTO message_drafts (conversation_id, user_id, content, metadata, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (conversation_id, user_id)
+		DO UPDATE SET content = EXCLUDED.content, metadata = EXCLUDED.metadata, updated_at = EXCLUDED.updated_at
+	`
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query, draft.ConversationID, draft.UserID, draft.Content, metadataJSON, draft.UpdatedAt)
+	if err != nil {
+		r.logger.Error("Failed to save draft", err)
+		return fmt.Errorf("failed to save draft: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresDraftRepository) Get(ctx context.Context, conversationID string, userID string) (*domain.MessageDraft, error) {
+	query := `
+		SELECT conversation_id, user_id, content, metadata, updated_at
+		FROM message_drafts
+		WHERE conversation_id = $1 AND user_id = $2
+	`
+
+	var draft domain.MessageDraft
+	var metadataJSON []byte
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, conversationID, userID).Scan(
+		&draft.ConversationID,
+		&draft.UserID,
+		&draft.Content,
+		&metadataJSON,
+		&draft.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("draft %w", domain.ErrNotFound)
+		}
+		r.logger.Error("Failed to get draft", err)
+		return nil, fmt.Errorf("failed to get draft: %w", err)
+	}
+
+	if len(metadataJSON) > 0 {
+		if err := json.Unmarshal(metadataJSON, &draft.Metadata); err != nil {
+			r.logger.Error("Failed to unmarshal draft metadata", err)
+		}
+	}
+
+	return &draft, nil
+}
+
+// Delete elimina el borrador; que no exista no es un error
+func (r *postgresDraftRepository) Delete(ctx context.Context, conversationID string, userID string) error {
+	query := `DELETE FROM message_drafts WHERE conversation_id = $1 AND user_id = $2`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, conversationID, userID); err != nil {
+		r.logger.Error("Failed to delete draft", err)
+		return fmt.Errorf("failed to delete draft: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+// defaultDatabaseCheckTimeout limita CheckDatabase cuando el contexto no trae plazo
+const defaultDatabaseCheckTimeout = 2 * time.Second
+
+type postgresHealthRepository struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewPostgresHealthRepository(db *sql.DB, logger logger.Logger) domain.HealthRepository {
+	return &postgresHealthRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CheckDatabase ejecuta SELECT 1: a diferencia de un ping, comprueba que la base de datos atiende consultas
+func (r *postgresHealthRepository) CheckDatabase(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultDatabaseCheckTimeout)
+		defer cancel()
+	}
+
+	var one int
+	if err := r.db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		r.logger.Warn("Database health check failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return fmt.Errorf("database check failed: %w", err)
+	}
+
+	return nil
+}
+
+// CheckExternalServices no comprueba nada: Redis y el resto de dependencias se registran en el HealthService
+func (r *postgresHealthRepository) CheckExternalServices(ctx context.Context) map[string]error {
+	return map[string]error{}
+}
@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/lib/pq"
+)
+
+type postgresMessageReadRepository struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewPostgresMessageReadRepository(db *sql.DB, logger logger.Logger) domain.MessageReadRepository {
+	return &postgresMessageReadRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// MarkRead inserta todos los acuses en una sola sentencia. Los IDs que no pertenecen a la
+// conversación se ignoran y los ya leídos no se reescriben, por lo que repetir la llamada no genera escrituras
+func (r *postgresMessageReadRepository) MarkRead(ctx context.Context, conversationID string, userID string, messageIDs []string, readAt time.Time) ([]string, error) {
+	if len(messageIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		INSERT INTO message_reads (message_id, user_id, read_at)
+		SELECT m.id, $3, $4
+		FROM messages m
+		WHERE m.id = ANY($1::uuid[]) AND m.conversation_id = $2 AND ` + visibleMessage + `
+		ON CONFLICT (message_id, user_id) DO NOTHING
+		RETURNING message_id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, pq.Array(messageIDs), conversationID, userID, readAt)
+	if err != nil {
+		r.logger.Error("Failed to mark messages as read", err)
+		return nil, fmt.Errorf("failed to mark messages as read: %w", err)
+	}
+
+	return scanMarkedMessageIDs(rows)
+}
+
+// MarkReadUpTo marca de una vez los mensajes de la conversación anteriores o iguales a upToMessageID, en el
+// mismo orden (timestamp, id) que la paginación. Si el mensaje no pertenece a la conversación no marca nada
+func (r *postgresMessageReadRepository) MarkReadUpTo(ctx context.Context, conversationID string, userID string, upToMessageID string, readAt time.Time) ([]string, error) {
+	query := `
+		INSERT INTO message_reads (message_id, user_id, read_at)
+		SELECT m.id, $3, $4
+		FROM messages m
+		WHERE m.conversation_id = $1 AND ` + visibleMessage + `
+		  AND (m.timestamp, m.id) <= (SELECT timestamp, id FROM messages WHERE id = $2 AND conversation_id = $1)
+		ON CONFLICT (message_id, user_id) DO NOTHING
+		RETURNING message_id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, conversationID, upToMessageID, userID, readAt)
+	if err != nil {
+		r.logger.Error("Failed to mark messages as read", err)
+		return nil, fmt.Errorf("failed to mark messages as read: %w", err)
+	}
+
+	return scanMarkedMessageIDs(rows)
+}
+
+// scanMarkedMessageIDs lee los IDs devueltos por RETURNING message_id y cierra las filas
+func scanMarkedMessageIDs(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+
+	var marked []string
+	for rows.Next() {
+		var messageID string
+		if err := rows.Scan(&messageID); err != nil {
+			return nil, fmt.Errorf("failed to scan message read: %w", err)
+		}
+		marked = append(marked, messageID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate message reads: %w", err)
+	}
+
+	return marked, nil
+}
+
+// GetReadStatus devuelve los acuses del usuario sobre los mensajes de la conversación
+func (r *postgresMessageReadRepository) GetReadStatus(ctx context.Context, conversationID string, userID string) (map[string]time.Time, error) {
+	query := `
+		SELECT mr.message_id, mr.read_at
+		FROM message_reads mr
+		JOIN messages m ON m.id = mr.message_id
+		WHERE m.conversation_id = $1 AND mr.user_id = $2
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, conversationID, userID)
+	if err != nil {
+		r.logger.Error("Failed to get read status", err)
+		return nil, fmt.Errorf("failed to get read status: %w", err)
+	}
+	defer rows.Close()
+
+	status := make(map[string]time.Time)
+	for rows.Next() {
+		var messageID string
+		var readAt time.Time
+		if err := rows.Scan(&messageID, &readAt); err != nil {
+			return nil, fmt.Errorf("failed to scan read status: %w", err)
+		}
+		status[messageID] = readAt
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate read status: %w", err)
+	}
+
+	return status, nil
+}
+
+// GetByMessageIDs devuelve los acuses de varios mensajes agrupados por mensaje, en orden de lectura
+func (r *postgresMessageReadRepository) GetByMessageIDs(ctx context.Context, messageIDs []string) (map[string][]domain.MessageRead, error) {
+	reads := make(map[string][]domain.MessageRead)
+	if len(messageIDs) == 0 {
+		return reads, nil
+	}
+
+	query := `
+		SELECT message_id, user_id, read_at
+		FROM message_reads
+		WHERE message_id = ANY($1::uuid[])
+		ORDER BY read_at ASC
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, pq.Array(messageIDs))
+	if err != nil {
+		r.logger.Error("Failed to get message reads", err)
+		return nil, fmt.Errorf("failed to get message reads: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var read domain.MessageRead
+		if err := rows.Scan(&read.MessageID, &read.UserID, &read.ReadAt); err != nil {
+			r.logger.Error("Failed to scan message read row", err)
+			continue
+		}
+		reads[read.MessageID] = append(reads[read.MessageID], read)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate message reads: %w", err)
+	}
+
+	return reads, nil
+}
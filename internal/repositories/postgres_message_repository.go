@@ -4,12 +4,84 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/company/microservice-template/internal/domain"
 	"github.com/company/microservice-template/pkg/logger"
+	"github.com/lib/pq"
 )
 
+// messageColumns lista las columnas leídas por scanMessage, en el mismo orden
+const messageColumns = `id, conversation_id, sender_type, sender_id, content, content_type, metadata, timestamp,
+		COALESCE(delivery_status, ''), delivery_attempts, next_retry_at, expires_at, pinned_at, edited_at, deleted_at,
+		reply_to_id`
+
+// notExpired excluye de las lecturas los mensajes efímeros ya vencidos
+const notExpired = `(expires_at IS NULL OR expires_at > NOW())`
+
+// notDeleted excluye los mensajes borrados, que se conservan para auditoría
+const notDeleted = `deleted_at IS NULL`
+
+// visibleMessage es la condición de las lecturas normales: ni vencidos ni borrados
+const visibleMessage = notExpired + ` AND ` + notDeleted
+
+// rowScanner abstrae *sql.Row y *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// insertMessageQuery inserta un mensaje con los argumentos de insertMessageArgs
+const insertMessageQuery = `
+	INSERT INTO messages (id, conversation_id, sender_type, sender_id, content, content_type, metadata, timestamp,
+		delivery_status, delivery_attempts, next_retry_at, expires_at, reply_to_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10, $11, $12, $13)
+`
+
+func insertMessageArgs(message *domain.Message) ([]interface{}, error) {
+	metadataJSON, err := json.Marshal(message.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	return []interface{}{
+		message.ID,
+		message.ConversationID,
+		message.SenderType,
+		message.SenderID,
+		message.Content,
+		message.ContentType,
+		metadataJSON,
+		message.Timestamp,
+		message.DeliveryStatus,
+		message.DeliveryAttempts,
+		message.NextRetryAt,
+		message.ExpiresAt,
+		message.ReplyToID,
+	}, nil
+}
+
+// createMessageQuery inserta el mensaje e incrementa el contador desnormalizado de la conversación
+const createMessageQuery = `
+	WITH inserted AS (` + insertMessageQuery + ` RETURNING conversation_id)
+	UPDATE conversations SET message_count = message_count + 1
+	FROM inserted
+	WHERE conversations.id = inserted.conversation_id
+`
+
+// providerMessageIDIndex es el índice único que impide guardar dos veces el mismo mensaje del proveedor
+const providerMessageIDIndex = "idx_messages_conversation_provider_message_id"
+
+// createMessageError traduce la violación de providerMessageIDIndex en domain.ErrAlreadyExists
+func createMessageError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == providerMessageIDIndex {
+		return fmt.Errorf("message %w", domain.ErrAlreadyExists)
+	}
+	return fmt.Errorf("failed to create message: %w", err)
+}
+
 type postgresMessageRepository struct {
 	db     *sql.DB
 	logger logger.Logger
@@ -23,30 +95,60 @@ func NewPostgresMessageRepository(db *sql.DB, logger logger.Logger) domain.Messa
 }
 
 func (r *postgresMessageRepository) Create(ctx context.Context, message *domain.Message) error {
-	metadataJSON, err := json.Marshal(message.Metadata)
+	args, err := insertMessageArgs(message)
 	if err != nil {
-		return fmt.Errorf("failed to marshal metadata: %w", err)
+		return err
 	}
-
-	query := `
-		INSERT INTO messages (id, conversation_id, sender_type, sender_id, content, content_type, metadata, timestamp)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-	`
 	
-	_, err = r.db.ExecContext(ctx, query,
-		message.ID,
-		message.ConversationID,
-		message.SenderType,
-		message.SenderID,
-		message.Content,
-		message.ContentType,
-		metadataJSON,
-		message.Timestamp,
-	)
+	_, err = conn(ctx, r.db).ExecContext(ctx, createMessageQuery, args...)
+	
+	if err != nil {
+		r.logger.Error("Failed to create message", err)
+		return createMessageError(err)
+	}
+	
+	return nil
+}
+
+func (r *postgresMessageRepository) CreateWithAttachments(ctx context.Context, message *domain.Message) error {
+	args, err := insertMessageArgs(message)
+	if err != nil {
+		return err
+	}
 	
+	tx, err := beginTx(ctx, r.db)
 	if err != nil {
+		r.logger.Error("Failed to begin message transaction", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	
+	if _, err := tx.ExecContext(ctx, createMessageQuery, args...); err != nil {
 		r.logger.Error("Failed to create message", err)
-		return fmt.Errorf("failed to create message: %w", err)
+		return createMessageError(err)
+	}
+	
+	for i := range message.Attachments {
+		attachment := &message.Attachments[i]
+		err := tx.QueryRowContext(ctx, createAttachmentQuery,
+			attachment.ID,
+			attachment.MessageID,
+			attachment.URL,
+			attachment.Type,
+			attachment.Size,
+			attachment.Filename,
+			attachment.ThumbnailURL,
+			attachment.CreatedAt,
+		).Scan(&attachment.ThumbnailURL)
+		if err != nil {
+			r.logger.Error("Failed to create attachment", err)
+			return fmt.Errorf("failed to create attachment: %w", err)
+		}
+	}
+	
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit message transaction", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	
 	return nil
@@ -54,57 +156,61 @@ func (r *postgresMessageRepository) Create(ctx context.Context, message *domain.
 
 func (r *postgresMessageRepository) GetByID(ctx context.Context, id string) (*domain.Message, error) {
 	query := `
-		SELECT id, conversation_id, sender_type, sender_id, content, content_type, metadata, timestamp
+		SELECT ` + messageColumns + `
 		FROM messages
-		WHERE id = $1
+		WHERE id = $1 AND ` + visibleMessage + `
 	`
 	
-	var message domain.Message
-	var metadataJSON []byte
-	
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&message.ID,
-		&message.ConversationID,
-		&message.SenderType,
-		&message.SenderID,
-		&message.Content,
-		&message.ContentType,
-		&metadataJSON,
-		&message.Timestamp,
-	)
-	
+	message, err := r.scanMessage(conn(ctx, r.db).QueryRowContext(ctx, query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("message not found")
+			return nil, fmt.Errorf("message %w", domain.ErrNotFound)
 		}
 		r.logger.Error("Failed to get message by ID", err)
 		return nil, fmt.Errorf("failed to get message: %w", err)
 	}
 	
-	// Unmarshal metadata
-	if len(metadataJSON) > 0 {
-		if err := json.Unmarshal(metadataJSON, &message.Metadata); err != nil {
-			r.logger.Error("Failed to unmarshal message metadata", err)
-			message.Metadata = make(domain.JSONB)
-		}
-	} else {
-		message.Metadata = make(domain.JSONB)
-	}
-	
-	return &message, nil
+	return message, nil
 }
 
 func (r *postgresMessageRepository) GetByConversationID(ctx context.Context, conversationID string, pagination domain.PaginationParams) ([]domain.Message, error) {
 	query := `
-		SELECT id, conversation_id, sender_type, sender_id, content, content_type, metadata, timestamp
+		SELECT ` + messageColumns + `
 		FROM messages
-		WHERE conversation_id = $1
-		ORDER BY timestamp DESC
+		WHERE conversation_id = $1 AND ` + notExpired + `
 	`
 	
 	args := []interface{}{conversationID}
 	argIndex := 2
 	
+	if !pagination.IncludeDeleted {
+		query += " AND " + notDeleted
+	}
+	
+	if len(pagination.ExcludeSenderTypes) > 0 {
+		senderTypes := make([]string, len(pagination.ExcludeSenderTypes))
+		for i, senderType := range pagination.ExcludeSenderTypes {
+			senderTypes[i] = string(senderType)
+		}
+		query += fmt.Sprintf(" AND sender_type <> ALL($%d)", argIndex)
+		args = append(args, pq.Array(senderTypes))
+		argIndex++
+	}
+	
+	// Pinned messages are returned separately, so the page only walks the rest
+	if pagination.PinnedFirst {
+		query += " AND pinned_at IS NULL"
+	}
+	
+	// Keyset pagination: the id breaks timestamp ties, so no message is skipped or repeated
+	if pagination.Before != nil {
+		query += fmt.Sprintf(" AND (timestamp, id) < ($%d, $%d)", argIndex, argIndex+1)
+		args = append(args, pagination.Before.Timestamp, pagination.Before.ID)
+		argIndex += 2
+	}
+	
+	query += " ORDER BY timestamp DESC, id DESC"
+	
 	// Add pagination
 	if pagination.Limit > 0 {
 		query += fmt.Sprintf(" LIMIT $%d", argIndex)
@@ -112,52 +218,368 @@ func (r *postgresMessageRepository) GetByConversationID(ctx context.Context, con
 		argIndex++
 	}
 	
-	if pagination.Offset > 0 {
+	if pagination.Offset > 0 && pagination.Before == nil {
 		query += fmt.Sprintf(" OFFSET $%d", argIndex)
 		args = append(args, pagination.Offset)
 	}
 	
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		r.logger.Error("Failed to get messages by conversation ID", err)
 		return nil, fmt.Errorf("failed to get messages: %w", err)
 	}
 	defer rows.Close()
 	
+	return r.collectMessages(rows)
+}
+
+func (r *postgresMessageRepository) StreamByConversationID(ctx context.Context, conversationID string, excludeSenderTypes []domain.SenderType, fn func(message *domain.Message) error) error {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE conversation_id = $1 AND ` + visibleMessage + `
+	`
+	args := []interface{}{conversationID}
+
+	if len(excludeSenderTypes) > 0 {
+		senderTypes := make([]string, len(excludeSenderTypes))
+		for i, senderType := range excludeSenderTypes {
+			senderTypes[i] = string(senderType)
+		}
+		query += " AND sender_type <> ALL($2)"
+		args = append(args, pq.Array(senderTypes))
+	}
+
+	query += " ORDER BY timestamp ASC, id ASC"
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to stream messages by conversation ID", err)
+		return fmt.Errorf("failed to stream messages: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		message, err := r.scanMessage(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan message: %w", err)
+		}
+		if err := fn(message); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating message rows", err)
+		return fmt.Errorf("failed to iterate messages: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresMessageRepository) GetPendingRetries(ctx context.Context, now time.Time, maxAttempts int, limit int) ([]domain.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE delivery_status = $1
+		  AND delivery_attempts < $2
+		  AND (next_retry_at IS NULL OR next_retry_at <= $3)
+		  AND ` + visibleMessage + `
+		ORDER BY next_retry_at ASC NULLS FIRST
+		LIMIT $4
+	`
+	
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, domain.DeliveryStatusFailed, maxAttempts, now, limit)
+	if err != nil {
+		r.logger.Error("Failed to get messages pending delivery retry", err)
+		return nil, fmt.Errorf("failed to get pending retries: %w", err)
+	}
+	defer rows.Close()
+	
+	return r.collectMessages(rows)
+}
+
+func (r *postgresMessageRepository) GetReplies(ctx context.Context, parentID string) ([]domain.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE reply_to_id = $1 AND ` + visibleMessage + `
+		ORDER BY timestamp ASC, id ASC
+	`
+	
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, parentID)
+	if err != nil {
+		r.logger.Error("Failed to get message replies", err)
+		return nil, fmt.Errorf("failed to get replies: %w", err)
+	}
+	defer rows.Close()
+	
+	return r.collectMessages(rows)
+}
+
+func (r *postgresMessageRepository) CountReplies(ctx context.Context, messageIDs []string) (map[string]int, error) {
+	counts := make(map[string]int)
+	if len(messageIDs) == 0 {
+		return counts, nil
+	}
+	
+	query := `
+		SELECT reply_to_id, COUNT(*)
+		FROM messages
+		WHERE reply_to_id = ANY($1::uuid[]) AND ` + visibleMessage + `
+		GROUP BY reply_to_id
+	`
+	
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, pq.Array(messageIDs))
+	if err != nil {
+		r.logger.Error("Failed to count message replies", err)
+		return nil, fmt.Errorf("failed to count replies: %w", err)
+	}
+	defer rows.Close()
+	
+	for rows.Next() {
+		var messageID string
+		var count int
+		if err := rows.Scan(&messageID, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan reply count: %w", err)
+		}
+		counts[messageID] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count replies: %w", err)
+	}
+	
+	return counts, nil
+}
+
+func (r *postgresMessageRepository) GetPinned(ctx context.Context, conversationID string, limit int) ([]domain.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE conversation_id = $1 AND pinned_at IS NOT NULL AND ` + visibleMessage + `
+		ORDER BY pinned_at, id
+		LIMIT $2
+	`
+	
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, conversationID, limit)
+	if err != nil {
+		r.logger.Error("Failed to get pinned messages", err)
+		return nil, fmt.Errorf("failed to get pinned messages: %w", err)
+	}
+	defer rows.Close()
+	
+	return r.collectMessages(rows)
+}
+
+// messageSearchDocument es el documento de texto completo de un mensaje. La configuración 'simple' no
+// aplica stemming de ningún idioma y pasa todo a minúsculas, así que la búsqueda no distingue mayúsculas.
+// Para no recorrer la tabla se recomienda el índice GIN sobre la misma expresión:
+//
+//	CREATE INDEX idx_messages_content_fts ON messages USING GIN (to_tsvector('simple', content));
+const messageSearchDocument = `to_tsvector('simple', content)`
+
+// Search busca con plainto_tsquery, que trata query como texto plano: los operadores de tsquery no tienen
+// efecto. Solo se consideran las conversaciones cuyo dueño es userID
+func (r *postgresMessageRepository) Search(ctx context.Context, userID string, query string, pagination domain.PaginationParams) ([]domain.Message, error) {
+	sqlQuery := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE conversation_id IN (SELECT id FROM conversations WHERE user_id = $1)
+		  AND ` + messageSearchDocument + ` @@ plainto_tsquery('simple', $2)
+		  AND ` + visibleMessage + `
+		ORDER BY ts_rank(` + messageSearchDocument + `, plainto_tsquery('simple', $2)) DESC, timestamp DESC, id DESC
+	`
+	
+	args := []interface{}{userID, query}
+	argIndex := 3
+	
+	if pagination.Limit > 0 {
+		sqlQuery += fmt.Sprintf(" LIMIT $%d", argIndex)
+		args = append(args, pagination.Limit)
+		argIndex++
+	}
+	
+	if pagination.Offset > 0 {
+		sqlQuery += fmt.Sprintf(" OFFSET $%d", argIndex)
+		args = append(args, pagination.Offset)
+	}
+	
+	rows, err := conn(ctx, r.db).QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		r.logger.Error("Failed to search messages", err)
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+	defer rows.Close()
+	
+	return r.collectMessages(rows)
+}
+
+func (r *postgresMessageRepository) SetPinned(ctx context.Context, id string, pinnedAt *time.Time) error {
+	query := `
+		UPDATE messages
+		SET pinned_at = CASE WHEN $2::timestamptz IS NULL THEN NULL ELSE COALESCE(pinned_at, $2) END
+		WHERE id = $1 AND ` + visibleMessage + `
+	`
+	
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, pinnedAt)
+	if err != nil {
+		r.logger.Error("Failed to pin message", err)
+		return fmt.Errorf("failed to pin message: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("message %w", domain.ErrNotFound)
+	}
+	
+	return nil
+}
+
+// GetAround devuelve en orden cronológico hasta before mensajes anteriores al objetivo, el propio
+// objetivo y hasta after posteriores. Los empates de timestamp se desempatan por id
+func (r *postgresMessageRepository) GetAround(ctx context.Context, target *domain.Message, before int, after int) ([]domain.Message, error) {
+	query := `
+		SELECT ` + messageColumns + ` FROM (
+			(SELECT * FROM messages
+			 WHERE conversation_id = $1 AND ` + visibleMessage + ` AND (timestamp, id) < ($2, $3)
+			 ORDER BY timestamp DESC, id DESC
+			 LIMIT $4)
+			UNION ALL
+			(SELECT * FROM messages
+			 WHERE conversation_id = $1 AND ` + visibleMessage + ` AND (timestamp, id) >= ($2, $3)
+			 ORDER BY timestamp ASC, id ASC
+			 LIMIT $5)
+		) window_messages
+		ORDER BY timestamp ASC, id ASC
+	`
+	
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, target.ConversationID, target.Timestamp, target.ID, before, after+1)
+	if err != nil {
+		r.logger.Error("Failed to get messages around target", err)
+		return nil, fmt.Errorf("failed to get messages around target: %w", err)
+	}
+	defer rows.Close()
+	
+	return r.collectMessages(rows)
+}
+
+// CountByConversationID cuenta los mensajes guardados de la conversación, con la misma definición que
+// conversations.message_count: los vencidos cuentan hasta que el reaper los elimina y los borrados no cuentan
+func (r *postgresMessageRepository) CountByConversationID(ctx context.Context, conversationID string) (int64, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM messages
+		WHERE conversation_id = $1 AND ` + notDeleted + `
+	`
+	
+	var count int64
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, conversationID).Scan(&count); err != nil {
+		r.logger.Error("Failed to count messages", err)
+		return 0, fmt.Errorf("failed to count messages: %w", err)
+	}
+	
+	return count, nil
+}
+
+// GetExpired devuelve los mensajes efímeros vencidos pendientes de eliminar
+func (r *postgresMessageRepository) GetExpired(ctx context.Context, now time.Time, limit int) ([]domain.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE expires_at IS NOT NULL AND expires_at <= $1
+		ORDER BY expires_at ASC
+		LIMIT $2
+	`
+	
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, now, limit)
+	if err != nil {
+		r.logger.Error("Failed to get expired messages", err)
+		return nil, fmt.Errorf("failed to get expired messages: %w", err)
+	}
+	defer rows.Close()
+	
+	return r.collectMessages(rows)
+}
+
+// GetByProviderMessageID busca el mensaje de una conversación del canal al que el proveedor asignó providerMessageID
+func (r *postgresMessageRepository) GetByProviderMessageID(ctx context.Context, channel domain.Channel, providerMessageID string) (*domain.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE metadata->>'` + domain.MetadataProviderMessageID + `' = $2
+			AND conversation_id IN (SELECT id FROM conversations WHERE channel = $1)
+		LIMIT 1
+	`
+	
+	message, err := r.scanMessage(conn(ctx, r.db).QueryRowContext(ctx, query, channel, providerMessageID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("message %w", domain.ErrNotFound)
+		}
+		r.logger.Error("Failed to get message by provider message ID", err)
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	
+	return message, nil
+}
+
+// GetByConversationProviderMessageID busca el mensaje de la conversación al que el proveedor asignó providerMessageID
+func (r *postgresMessageRepository) GetByConversationProviderMessageID(ctx context.Context, conversationID string, providerMessageID string) (*domain.Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages
+		WHERE conversation_id = $1 AND metadata->>'` + domain.MetadataProviderMessageID + `' = $2
+	`
+	
+	message, err := r.scanMessage(conn(ctx, r.db).QueryRowContext(ctx, query, conversationID, providerMessageID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("message %w", domain.ErrNotFound)
+		}
+		r.logger.Error("Failed to get message by provider message ID", err)
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	
+	return message, nil
+}
+
+// UpdateDeliveryStatus cambia el estado de entrega del mensaje a to si sigue siendo from
+func (r *postgresMessageRepository) UpdateDeliveryStatus(ctx context.Context, id string, from domain.DeliveryStatus, to domain.DeliveryStatus) (bool, error) {
+	query := `
+		UPDATE messages
+		SET delivery_status = $3, next_retry_at = NULL
+		WHERE id = $1 AND COALESCE(delivery_status, '') = $2
+	`
+	
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, from, to)
+	if err != nil {
+		r.logger.Error("Failed to update delivery status", err)
+		return false, fmt.Errorf("failed to update delivery status: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	return rowsAffected > 0, nil
+}
+
+func (r *postgresMessageRepository) collectMessages(rows *sql.Rows) ([]domain.Message, error) {
 	var messages []domain.Message
 	for rows.Next() {
-		var message domain.Message
-		var metadataJSON []byte
-		
-		err := rows.Scan(
-			&message.ID,
-			&message.ConversationID,
-			&message.SenderType,
-			&message.SenderID,
-			&message.Content,
-			&message.ContentType,
-			&metadataJSON,
-			&message.Timestamp,
-		)
+		message, err := r.scanMessage(rows)
 		if err != nil {
 			r.logger.Error("Failed to scan message row", err)
 			continue
 		}
-		
-		// Unmarshal metadata
-		if len(metadataJSON) > 0 {
-			if err := json.Unmarshal(metadataJSON, &message.Metadata); err != nil {
-				r.logger.Error("Failed to unmarshal message metadata", err)
-				message.Metadata = make(domain.JSONB)
-			}
-		} else {
-			message.Metadata = make(domain.JSONB)
-		}
-		
-		messages = append(messages, message)
+		messages = append(messages, *message)
 	}
 	
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		r.logger.Error("Error iterating message rows", err)
 		return nil, fmt.Errorf("failed to iterate messages: %w", err)
 	}
@@ -173,11 +595,12 @@ func (r *postgresMessageRepository) Update(ctx context.Context, message *domain.
 
 	query := `
 		UPDATE messages
-		SET conversation_id = $2, sender_type = $3, sender_id = $4, content = $5, content_type = $6, metadata = $7, timestamp = $8
+		SET conversation_id = $2, sender_type = $3, sender_id = $4, content = $5, content_type = $6, metadata = $7, timestamp = $8,
+			delivery_status = NULLIF($9, ''), delivery_attempts = $10, next_retry_at = $11, expires_at = $12, edited_at = $13
 		WHERE id = $1
 	`
 	
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
 		message.ID,
 		message.ConversationID,
 		message.SenderType,
@@ -186,6 +609,11 @@ func (r *postgresMessageRepository) Update(ctx context.Context, message *domain.
 		message.ContentType,
 		metadataJSON,
 		message.Timestamp,
+		message.DeliveryStatus,
+		message.DeliveryAttempts,
+		message.NextRetryAt,
+		message.ExpiresAt,
+		message.EditedAt,
 	)
 	
 	if err != nil {
@@ -199,16 +627,46 @@ func (r *postgresMessageRepository) Update(ctx context.Context, message *domain.
 	}
 	
 	if rowsAffected == 0 {
-		return fmt.Errorf("message not found")
+		return fmt.Errorf("message %w", domain.ErrNotFound)
 	}
 	
 	return nil
 }
 
+// Delete borra el mensaje de forma lógica: la fila se conserva con deleted_at para auditoría y deja de
+// contar en message_count. Borrar de nuevo un mensaje ya borrado devuelve ErrNotFound
 func (r *postgresMessageRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM messages WHERE id = $1`
+	// Rows affected come from the counter update, which only runs when the message was still visible
+	query := `
+		WITH deleted AS (
+			UPDATE messages SET deleted_at = NOW()
+			WHERE id = $1 AND ` + notDeleted + `
+			RETURNING conversation_id
+		)
+		UPDATE conversations SET message_count = GREATEST(message_count - 1, 0)
+		FROM deleted
+		WHERE conversations.id = deleted.conversation_id
+	`
+	
+	return r.execDelete(ctx, query, id)
+}
+
+// Purge elimina la fila del mensaje; sus adjuntos desaparecen con ella (ON DELETE CASCADE). Un mensaje ya
+// borrado de forma lógica no vuelve a descontarse de message_count
+func (r *postgresMessageRepository) Purge(ctx context.Context, id string) error {
+	query := `
+		WITH purged AS (DELETE FROM messages WHERE id = $1 RETURNING conversation_id, deleted_at)
+		UPDATE conversations
+		SET message_count = GREATEST(message_count - CASE WHEN purged.deleted_at IS NULL THEN 1 ELSE 0 END, 0)
+		FROM purged
+		WHERE conversations.id = purged.conversation_id
+	`
 	
-	result, err := r.db.ExecContext(ctx, query, id)
+	return r.execDelete(ctx, query, id)
+}
+
+func (r *postgresMessageRepository) execDelete(ctx context.Context, query string, id string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
 		r.logger.Error("Failed to delete message", err)
 		return fmt.Errorf("failed to delete message: %w", err)
@@ -220,8 +678,47 @@ func (r *postgresMessageRepository) Delete(ctx context.Context, id string) error
 	}
 	
 	if rowsAffected == 0 {
-		return fmt.Errorf("message not found")
+		return fmt.Errorf("message %w", domain.ErrNotFound)
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+
+func (r *postgresMessageRepository) scanMessage(row rowScanner) (*domain.Message, error) {
+	var message domain.Message
+	var metadataJSON []byte
+	
+	err := row.Scan(
+		&message.ID,
+		&message.ConversationID,
+		&message.SenderType,
+		&message.SenderID,
+		&message.Content,
+		&message.ContentType,
+		&metadataJSON,
+		&message.Timestamp,
+		&message.DeliveryStatus,
+		&message.DeliveryAttempts,
+		&message.NextRetryAt,
+		&message.ExpiresAt,
+		&message.PinnedAt,
+		&message.EditedAt,
+		&message.DeletedAt,
+		&message.ReplyToID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Unmarshal metadata
+	if len(metadataJSON) > 0 {
+		if err := json.Unmarshal(metadataJSON, &message.Metadata); err != nil {
+			r.logger.Error("Failed to unmarshal message metadata", err)
+			message.Metadata = make(domain.JSONB)
+		}
+	} else {
+		message.Metadata = make(domain.JSONB)
+	}
+	
+	return &message, nil
+}
@@ -0,0 +1,1034 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/internal/services"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// openTestDatabase conecta con la base de TEST_DATABASE_URL, que debe tener aplicado scripts/init-messaging.sql
+func openTestDatabase(t *testing.T) *sql.DB {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Ping())
+
+	return db
+}
+
+func createTestConversation(t *testing.T, repo domain.ConversationRepository) *domain.Conversation {
+	conversation := &domain.Conversation{
+		ID:        uuid.New().String(),
+		UserID:    "counter-test-" + uuid.New().String(),
+		Channel:   domain.ChannelWeb,
+		Status:    domain.ConversationStatusActive,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	require.NoError(t, repo.Create(context.Background(), conversation))
+	t.Cleanup(func() { repo.Delete(context.Background(), conversation.ID) })
+
+	return conversation
+}
+
+func TestPostgresMessageRepository_ConcurrentCreatesKeepCounterAccurate(t *testing.T) {
+	db := openTestDatabase(t)
+	log := logger.NewLogger("error")
+	conversationRepo := NewPostgresConversationRepository(db, log)
+	messageRepo := NewPostgresMessageRepository(db, log)
+	ctx := context.Background()
+
+	conversation := createTestConversation(t, conversationRepo)
+
+	const senders = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, senders)
+	ids := make(chan string, senders)
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			message := &domain.Message{
+				ID:             uuid.New().String(),
+				ConversationID: conversation.ID,
+				SenderType:     domain.SenderTypeUser,
+				SenderID:       conversation.UserID,
+				Content:        "hola",
+				ContentType:    domain.ContentTypeText,
+				Metadata:       domain.JSONB{},
+				Timestamp:      time.Now(),
+			}
+			if err := messageRepo.Create(ctx, message); err != nil {
+				errs <- err
+				return
+			}
+			ids <- message.ID
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	close(ids)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	stored, err := conversationRepo.GetByID(ctx, conversation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(senders), stored.MessageCount)
+
+	// Deletes decrement the same counter
+	deleted := 0
+	for id := range ids {
+		if deleted == 10 {
+			break
+		}
+		require.NoError(t, messageRepo.Delete(ctx, id))
+		deleted++
+	}
+
+	stored, err = conversationRepo.GetByID(ctx, conversation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(senders-10), stored.MessageCount)
+}
+
+func TestPostgresMessageRepository_CursorPaginationWithTimestampTies(t *testing.T) {
+	db := openTestDatabase(t)
+	log := logger.NewLogger("error")
+	conversationRepo := NewPostgresConversationRepository(db, log)
+	messageRepo := NewPostgresMessageRepository(db, log)
+	ctx := context.Background()
+
+	conversation := createTestConversation(t, conversationRepo)
+
+	// Two of the three messages share a timestamp, so the page boundary falls between them
+	shared := time.Now().Truncate(time.Microsecond)
+	timestamps := []time.Time{shared.Add(-time.Second), shared, shared}
+	for _, timestamp := range timestamps {
+		require.NoError(t, messageRepo.Create(ctx, &domain.Message{
+			ID:             uuid.New().String(),
+			ConversationID: conversation.ID,
+			SenderType:     domain.SenderTypeUser,
+			SenderID:       conversation.UserID,
+			Content:        "hola",
+			ContentType:    domain.ContentTypeText,
+			Metadata:       domain.JSONB{},
+			Timestamp:      timestamp,
+		}))
+	}
+
+	var seen []string
+	pagination := domain.PaginationParams{Limit: 1}
+	for {
+		page, err := messageRepo.GetByConversationID(ctx, conversation.ID, pagination)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		require.Len(t, page, 1)
+		seen = append(seen, page[0].ID)
+
+		cursor := domain.NewMessageCursor(page[0])
+		pagination.Before = &cursor
+	}
+
+	// Every message is returned exactly once, newest first
+	require.Len(t, seen, 3)
+	assert.NotEqual(t, seen[0], seen[1])
+	last, err := messageRepo.GetByID(ctx, seen[2])
+	require.NoError(t, err)
+	assert.True(t, last.Timestamp.Equal(timestamps[0]))
+}
+
+func TestPostgresMessageRepository_SoftDelete(t *testing.T) {
+	db := openTestDatabase(t)
+	log := logger.NewLogger("error")
+	conversationRepo := NewPostgresConversationRepository(db, log)
+	messageRepo := NewPostgresMessageRepository(db, log)
+	ctx := context.Background()
+
+	conversation := createTestConversation(t, conversationRepo)
+
+	var ids []string
+	for i := 0; i < 2; i++ {
+		message := &domain.Message{
+			ID:             uuid.New().String(),
+			ConversationID: conversation.ID,
+			SenderType:     domain.SenderTypeUser,
+			SenderID:       conversation.UserID,
+			Content:        "hola",
+			ContentType:    domain.ContentTypeText,
+			Metadata:       domain.JSONB{},
+			Timestamp:      time.Now(),
+		}
+		require.NoError(t, messageRepo.Create(ctx, message))
+		ids = append(ids, message.ID)
+	}
+
+	require.NoError(t, messageRepo.Delete(ctx, ids[0]))
+
+	// The deleted message disappears from normal reads
+	messages, err := messageRepo.GetByConversationID(ctx, conversation.ID, domain.PaginationParams{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, ids[1], messages[0].ID)
+	_, err = messageRepo.GetByID(ctx, ids[0])
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.ErrorIs(t, messageRepo.Delete(ctx, ids[0]), domain.ErrNotFound)
+
+	// But the row is kept and can still be listed with the flag
+	messages, err = messageRepo.GetByConversationID(ctx, conversation.ID, domain.PaginationParams{Limit: 10, IncludeDeleted: true})
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	for _, message := range messages {
+		assert.Equal(t, message.ID == ids[0], message.DeletedAt != nil)
+	}
+
+	stored, err := conversationRepo.GetByID(ctx, conversation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stored.MessageCount)
+
+	// Purging the deleted row doesn't decrement the counter again
+	require.NoError(t, messageRepo.Purge(ctx, ids[0]))
+	stored, err = conversationRepo.GetByID(ctx, conversation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stored.MessageCount)
+}
+
+func TestPostgresMessageRepository_RepliesInTimestampOrder(t *testing.T) {
+	db := openTestDatabase(t)
+	log := logger.NewLogger("error")
+	conversationRepo := NewPostgresConversationRepository(db, log)
+	messageRepo := NewPostgresMessageRepository(db, log)
+	ctx := context.Background()
+
+	conversation := createTestConversation(t, conversationRepo)
+	newMessage := func(timestamp time.Time, replyToID *string) *domain.Message {
+		message := &domain.Message{
+			ID:             uuid.New().String(),
+			ConversationID: conversation.ID,
+			SenderType:     domain.SenderTypeUser,
+			SenderID:       conversation.UserID,
+			Content:        "hola",
+			ContentType:    domain.ContentTypeText,
+			Metadata:       domain.JSONB{},
+			Timestamp:      timestamp,
+			ReplyToID:      replyToID,
+		}
+		require.NoError(t, messageRepo.Create(ctx, message))
+		return message
+	}
+
+	base := time.Now().Add(-time.Hour)
+	parent := newMessage(base, nil)
+	// Replies are stored out of order; the thread reads them by timestamp
+	later := newMessage(base.Add(2*time.Minute), &parent.ID)
+	earlier := newMessage(base.Add(time.Minute), &parent.ID)
+	nested := newMessage(base.Add(3*time.Minute), &earlier.ID)
+	deleted := newMessage(base.Add(4*time.Minute), &parent.ID)
+	require.NoError(t, messageRepo.Delete(ctx, deleted.ID))
+
+	replies, err := messageRepo.GetReplies(ctx, parent.ID)
+	require.NoError(t, err)
+	require.Len(t, replies, 2)
+	assert.Equal(t, earlier.ID, replies[0].ID)
+	assert.Equal(t, later.ID, replies[1].ID)
+	require.NotNil(t, replies[0].ReplyToID)
+	assert.Equal(t, parent.ID, *replies[0].ReplyToID)
+
+	counts, err := messageRepo.CountReplies(ctx, []string{parent.ID, earlier.ID, nested.ID})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{parent.ID: 2, earlier.ID: 1}, counts)
+}
+
+func TestPostgresMessageRepository_Search(t *testing.T) {
+	db := openTestDatabase(t)
+	log := logger.NewLogger("error")
+	conversationRepo := NewPostgresConversationRepository(db, log)
+	messageRepo := NewPostgresMessageRepository(db, log)
+	ctx := context.Background()
+
+	own := createTestConversation(t, conversationRepo)
+	other := createTestConversation(t, conversationRepo)
+
+	// A unique word keeps the search isolated from rows left by other tests
+	word := "factura" + uuid.New().String()[:8]
+	create := func(conversation *domain.Conversation, content string) string {
+		message := &domain.Message{
+			ID:             uuid.New().String(),
+			ConversationID: conversation.ID,
+			SenderType:     domain.SenderTypeUser,
+			SenderID:       conversation.UserID,
+			Content:        content,
+			ContentType:    domain.ContentTypeText,
+			Metadata:       domain.JSONB{},
+			Timestamp:      time.Now(),
+		}
+		require.NoError(t, messageRepo.Create(ctx, message))
+		return message.ID
+	}
+	match := create(own, "Necesito la "+strings.ToUpper(word)+" de marzo")
+	create(own, "hola")
+	create(other, "Otra "+word)
+
+	// Matching ignores case and only covers the user's own conversations
+	messages, err := messageRepo.Search(ctx, own.UserID, word, domain.PaginationParams{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, match, messages[0].ID)
+
+	// Deleted messages are not found
+	require.NoError(t, messageRepo.Delete(ctx, match))
+	messages, err = messageRepo.Search(ctx, own.UserID, word, domain.PaginationParams{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func TestPostgresMessageReadRepository_MarkReadUpTo(t *testing.T) {
+	db := openTestDatabase(t)
+	log := logger.NewLogger("error")
+	conversationRepo := NewPostgresConversationRepository(db, log)
+	messageRepo := NewPostgresMessageRepository(db, log)
+	readRepo := NewPostgresMessageReadRepository(db, log)
+	ctx := context.Background()
+
+	conversation := createTestConversation(t, conversationRepo)
+
+	base := time.Now().Truncate(time.Microsecond)
+	ids := make([]string, 3)
+	for i := range ids {
+		ids[i] = uuid.New().String()
+		require.NoError(t, messageRepo.Create(ctx, &domain.Message{
+			ID:             ids[i],
+			ConversationID: conversation.ID,
+			SenderType:     domain.SenderTypeUser,
+			SenderID:       conversation.UserID,
+			Content:        "hola",
+			ContentType:    domain.ContentTypeText,
+			Metadata:       domain.JSONB{},
+			Timestamp:      base.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	// Everything up to and including the second message is marked in one batch
+	marked, err := readRepo.MarkReadUpTo(ctx, conversation.ID, "agent1", ids[1], time.Now())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, ids[:2], marked)
+
+	// Repeating it writes nothing
+	marked, err = readRepo.MarkReadUpTo(ctx, conversation.ID, "agent1", ids[1], time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, marked)
+
+	status, err := readRepo.GetReadStatus(ctx, conversation.ID, "agent1")
+	require.NoError(t, err)
+	assert.Len(t, status, 2)
+	assert.Contains(t, status, ids[0])
+	assert.NotContains(t, status, ids[2])
+
+	// Other users have their own receipts
+	status, err = readRepo.GetReadStatus(ctx, conversation.ID, "agent2")
+	require.NoError(t, err)
+	assert.Empty(t, status)
+}
+
+func TestPostgresReactionRepository_AddRemove(t *testing.T) {
+	db := openTestDatabase(t)
+	log := logger.NewLogger("error")
+	conversationRepo := NewPostgresConversationRepository(db, log)
+	messageRepo := NewPostgresMessageRepository(db, log)
+	reactionRepo := NewPostgresReactionRepository(db, log)
+	ctx := context.Background()
+
+	conversation := createTestConversation(t, conversationRepo)
+	message := &domain.Message{
+		ID:             uuid.New().String(),
+		ConversationID: conversation.ID,
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       conversation.UserID,
+		Content:        "hola",
+		ContentType:    domain.ContentTypeText,
+		Metadata:       domain.JSONB{},
+		Timestamp:      time.Now(),
+	}
+	require.NoError(t, messageRepo.Create(ctx, message))
+
+	newReaction := func() *domain.Reaction {
+		return &domain.Reaction{ID: uuid.New().String(), MessageID: message.ID, UserID: "agent1", Emoji: "👍", CreatedAt: time.Now()}
+	}
+
+	first := newReaction()
+	added, err := reactionRepo.Add(ctx, first)
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	// The same emoji by the same user returns the stored reaction
+	again := newReaction()
+	added, err = reactionRepo.Add(ctx, again)
+	require.NoError(t, err)
+	assert.False(t, added)
+	assert.Equal(t, first.ID, again.ID)
+
+	counts, err := reactionRepo.CountByMessageIDs(ctx, []string{message.ID})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"👍": 1}, counts[message.ID])
+
+	removed, err := reactionRepo.Remove(ctx, message.ID, "agent1", "👍")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	removed, err = reactionRepo.Remove(ctx, message.ID, "agent1", "👍")
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestPostgresParticipantRepository_AddListRemove(t *testing.T) {
+	db := openTestDatabase(t)
+	log := logger.NewLogger("error")
+	conversationRepo := NewPostgresConversationRepository(db, log)
+	participantRepo := NewPostgresParticipantRepository(db, log)
+	ctx := context.Background()
+
+	conversation := createTestConversation(t, conversationRepo)
+	joinedAt := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+
+	agent := &domain.ConversationParticipant{ConversationID: conversation.ID, UserID: "agent1", Role: domain.ParticipantRoleAgent, JoinedAt: joinedAt}
+	require.NoError(t, participantRepo.AddParticipant(ctx, agent))
+	require.NoError(t, participantRepo.MarkRead(ctx, conversation.ID, "agent1", time.Now()))
+
+	// Adding again only changes the role; joined_at and the read marker are kept
+	again := &domain.ConversationParticipant{ConversationID: conversation.ID, UserID: "agent1", Role: domain.ParticipantRoleMember, JoinedAt: time.Now()}
+	require.NoError(t, participantRepo.AddParticipant(ctx, again))
+	assert.True(t, joinedAt.Equal(again.JoinedAt))
+	assert.NotNil(t, again.LastReadAt)
+
+	require.NoError(t, participantRepo.AddParticipant(ctx, &domain.ConversationParticipant{ConversationID: conversation.ID, UserID: "user2", Role: domain.ParticipantRoleMember, JoinedAt: time.Now()}))
+
+	// Read markers of the owner or of anyone else don't make them participants
+	require.NoError(t, participantRepo.MarkRead(ctx, conversation.ID, conversation.UserID, time.Now()))
+	require.NoError(t, participantRepo.MarkRead(ctx, conversation.ID, "reader1", time.Now()))
+
+	participants, err := participantRepo.ListParticipants(ctx, conversation.ID)
+	require.NoError(t, err)
+	require.Len(t, participants, 2)
+	assert.Equal(t, "agent1", participants[0].UserID)
+	assert.Equal(t, domain.ParticipantRoleMember, participants[0].Role)
+	assert.Equal(t, "user2", participants[1].UserID)
+
+	require.NoError(t, participantRepo.RemoveParticipant(ctx, conversation.ID, "agent1"))
+	assert.ErrorIs(t, participantRepo.RemoveParticipant(ctx, conversation.ID, "agent1"), domain.ErrNotFound)
+
+	found, err := participantRepo.FilterParticipants(ctx, conversation.ID, []string{"agent1", "user2", "reader1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user2"}, found)
+}
+
+func TestPostgresConversationRepository_TagFilterMatchesAllTags(t *testing.T) {
+	db := openTestDatabase(t)
+	log := logger.NewLogger("error")
+	conversationRepo := NewPostgresConversationRepository(db, log)
+	ctx := context.Background()
+
+	// Conversations of one user, tagged {vip}, {vip, billing} and {billing}
+	both := createTestConversation(t, conversationRepo)
+	vipOnly := &domain.Conversation{ID: uuid.New().String(), UserID: both.UserID, Channel: domain.ChannelWeb, Status: domain.ConversationStatusActive, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	billingOnly := &domain.Conversation{ID: uuid.New().String(), UserID: both.UserID, Channel: domain.ChannelWeb, Status: domain.ConversationStatusActive, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	for _, conversation := range []*domain.Conversation{vipOnly, billingOnly} {
+		require.NoError(t, conversationRepo.Create(ctx, conversation))
+		id := conversation.ID
+		t.Cleanup(func() { conversationRepo.Delete(context.Background(), id) })
+	}
+	_, err := conversationRepo.AddTagToConversations(ctx, []string{both.ID, vipOnly.ID}, "vip", "")
+	require.NoError(t, err)
+	_, err = conversationRepo.AddTagToConversations(ctx, []string{both.ID, billingOnly.ID}, "billing", "")
+	require.NoError(t, err)
+
+	// Every listed tag must be present
+	conversations, err := conversationRepo.GetByUserID(ctx, both.UserID, domain.ConversationFilters{Tags: []string{"vip", "billing"}})
+	require.NoError(t, err)
+	require.Len(t, conversations, 1)
+	assert.Equal(t, both.ID, conversations[0].ID)
+	assert.ElementsMatch(t, []string{"vip", "billing"}, conversations[0].Tags)
+
+	conversations, err = conversationRepo.GetByUserID(ctx, both.UserID, domain.ConversationFilters{Tags: []string{"vip"}})
+	require.NoError(t, err)
+	assert.Len(t, conversations, 2)
+
+	// Removing a tag takes the conversation out of the filter
+	removed, err := conversationRepo.RemoveTag(ctx, both.ID, "billing")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	conversations, err = conversationRepo.GetByUserID(ctx, both.UserID, domain.ConversationFilters{Tags: []string{"vip", "billing"}})
+	require.NoError(t, err)
+	assert.Empty(t, conversations)
+}
+
+func TestPostgresConversationRepository_MetadataRoundTrip(t *testing.T) {
+	db := openTestDatabase(t)
+	log := logger.NewLogger("error")
+	conversationRepo := NewPostgresConversationRepository(db, log)
+	ctx := context.Background()
+
+	conversation := &domain.Conversation{
+		ID:      uuid.New().String(),
+		UserID:  "metadata-test-" + uuid.New().String(),
+		Channel: domain.ChannelWhatsApp,
+		Status:  domain.ConversationStatusActive,
+		Metadata: domain.JSONB{
+			"customer": map[string]interface{}{"phone": "+34600111222", "crm": map[string]interface{}{"id": "CRM-42"}},
+			"priority": "high",
+			"labels":   []interface{}{"a", "b"},
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	require.NoError(t, conversationRepo.Create(ctx, conversation))
+	t.Cleanup(func() { conversationRepo.Delete(context.Background(), conversation.ID) })
+
+	// Nested objects and arrays come back as written
+	stored, err := conversationRepo.GetByID(ctx, conversation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, conversation.Metadata, stored.Metadata)
+
+	// The update callback sees the stored metadata and its result is persisted
+	updated, err := conversationRepo.UpdateMetadata(ctx, conversation.ID, func(metadata domain.JSONB) (domain.JSONB, error) {
+		assert.Equal(t, conversation.Metadata, metadata)
+		return metadata.MergePatch(domain.JSONB{"priority": nil, "customer": map[string]interface{}{"crm": map[string]interface{}{"stage": 2}}}), nil
+	})
+	require.NoError(t, err)
+	stored, err = conversationRepo.GetByID(ctx, conversation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.JSONB{
+		"customer": map[string]interface{}{"phone": "+34600111222", "crm": map[string]interface{}{"id": "CRM-42", "stage": float64(2)}},
+		"labels":   []interface{}{"a", "b"},
+	}, stored.Metadata)
+	assert.Len(t, updated, 2)
+
+	// A failing callback leaves the metadata untouched
+	_, err = conversationRepo.UpdateMetadata(ctx, conversation.ID, func(domain.JSONB) (domain.JSONB, error) {
+		return nil, errors.New("rejected")
+	})
+	require.Error(t, err)
+	unchanged, err := conversationRepo.GetByID(ctx, conversation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, stored.Metadata, unchanged.Metadata)
+
+	_, err = conversationRepo.UpdateMetadata(ctx, uuid.New().String(), func(metadata domain.JSONB) (domain.JSONB, error) { return metadata, nil })
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestPostgresConversationRepository_UpdateStatusBulk(t *testing.T) {
+	db := openTestDatabase(t)
+	log := logger.NewLogger("error")
+	conversationRepo := NewPostgresConversationRepository(db, log)
+	ctx := context.Background()
+
+	owner := "bulk-status-" + uuid.New().String()
+	create := func(userID string, status domain.ConversationStatus) string {
+		conversation := &domain.Conversation{
+			ID:        uuid.New().String(),
+			UserID:    userID,
+			Channel:   domain.ChannelWeb,
+			Status:    status,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		require.NoError(t, conversationRepo.Create(ctx, conversation))
+		t.Cleanup(func() { conversationRepo.Delete(context.Background(), conversation.ID) })
+		return conversation.ID
+	}
+	owned := create(owner, domain.ConversationStatusActive)
+	alreadyClosed := create(owner, domain.ConversationStatusClosed)
+	notOwned := create("someone-else-"+uuid.New().String(), domain.ConversationStatusActive)
+
+	now := time.Now()
+	outcomes, err := conversationRepo.UpdateStatusBulk(ctx, []string{owned, alreadyClosed, notOwned}, domain.ConversationStatusClosed, owner, now, now)
+
+	// Only the owner's conversations are reported, and only the one that changed is updated
+	require.NoError(t, err)
+	assert.Equal(t, map[string]domain.ConversationStatusOutcome{
+		owned:         domain.ConversationStatusOutcomeUpdated,
+		alreadyClosed: domain.ConversationStatusOutcomeUnchanged,
+	}, outcomes)
+	stored, err := conversationRepo.GetByID(ctx, owned)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ConversationStatusClosed, stored.Status)
+	stored, err = conversationRepo.GetByID(ctx, notOwned)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ConversationStatusActive, stored.Status)
+
+	// A conversation that just changed is left alone while the minimum interval lasts
+	outcomes, err = conversationRepo.UpdateStatusBulk(ctx, []string{owned}, domain.ConversationStatusArchived, owner, now.Add(-time.Minute), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, domain.ConversationStatusOutcomeTooFrequent, outcomes[owned])
+}
+
+func TestPostgresConversationRepository_SearchByMetadata(t *testing.T) {
+	db := openTestDatabase(t)
+	log := logger.NewLogger("error")
+	conversationRepo := NewPostgresConversationRepository(db, log)
+	participantRepo := NewPostgresParticipantRepository(db, log)
+	ctx := context.Background()
+
+	owner := "metadata-search-" + uuid.New().String()
+	externalID := "CRM-" + uuid.New().String()
+	create := func(metadata domain.JSONB) string {
+		conversation := &domain.Conversation{
+			ID:        uuid.New().String(),
+			UserID:    owner,
+			Channel:   domain.ChannelWhatsApp,
+			Status:    domain.ConversationStatusActive,
+			Metadata:  metadata,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		require.NoError(t, conversationRepo.Create(ctx, conversation))
+		t.Cleanup(func() { conversationRepo.Delete(context.Background(), conversation.ID) })
+		return conversation.ID
+	}
+	matching := create(domain.JSONB{"external_id": externalID, "phone": "+34600111222", "tier": "gold"})
+	create(domain.JSONB{"external_id": "CRM-" + uuid.New().String(), "phone": "+34600111222"})
+
+	// Only the conversation whose metadata contains the external id is returned
+	found, err := conversationRepo.SearchByMetadata(ctx, domain.JSONB{"external_id": externalID}, owner, 20, 0)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, matching, found[0].ID)
+	assert.Equal(t, "gold", found[0].Metadata["tier"])
+
+	// Another agent only finds it once they participate in the conversation
+	agent := "metadata-search-agent-" + uuid.New().String()
+	found, err = conversationRepo.SearchByMetadata(ctx, domain.JSONB{"external_id": externalID}, agent, 20, 0)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	require.NoError(t, participantRepo.AddParticipant(ctx, &domain.ConversationParticipant{ConversationID: matching, UserID: agent, Role: domain.ParticipantRoleAgent, JoinedAt: time.Now()}))
+	found, err = conversationRepo.SearchByMetadata(ctx, domain.JSONB{"external_id": externalID}, agent, 20, 0)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, matching, found[0].ID)
+}
+
+func TestPostgresTxManager_RollsBackMessageAndConversationTouch(t *testing.T) {
+	db := openTestDatabase(t)
+	log := logger.NewLogger("error")
+	conversationRepo := NewPostgresConversationRepository(db, log)
+	messageRepo := NewPostgresMessageRepository(db, log)
+	txManager := NewPostgresTxManager(db, log)
+	ctx := context.Background()
+
+	conversation := createTestConversation(t, conversationRepo)
+	before, err := conversationRepo.GetByID(ctx, conversation.ID)
+	require.NoError(t, err)
+
+	message := &domain.Message{
+		ID:             uuid.New().String(),
+		ConversationID: conversation.ID,
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       conversation.UserID,
+		Content:        "hola",
+		ContentType:    domain.ContentTypeText,
+		Metadata:       domain.JSONB{},
+		Timestamp:      time.Now().Add(time.Hour),
+		Attachments: []domain.Attachment{{
+			ID:        uuid.New().String(),
+			URL:       "https://files.example.com/a.png",
+			Type:      domain.AttachmentTypeImage,
+			Size:      10,
+			Filename:  "a.png",
+			CreatedAt: time.Now(),
+		}},
+	}
+	message.Attachments[0].MessageID = message.ID
+
+	// The insert succeeds and then something fails before the transaction commits
+	forced := errors.New("forced failure")
+	err = txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		require.NoError(t, messageRepo.CreateWithAttachments(ctx, message))
+		require.NoError(t, conversationRepo.TouchUpdatedAt(ctx, conversation.ID, message.Timestamp))
+		return forced
+	})
+	require.ErrorIs(t, err, forced)
+
+	// Neither the message nor the conversation changes survive
+	_, err = messageRepo.GetByID(ctx, message.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	after, err := conversationRepo.GetByID(ctx, conversation.ID)
+	require.NoError(t, err)
+	assert.True(t, before.UpdatedAt.Equal(after.UpdatedAt), "updated_at changed: %v -> %v", before.UpdatedAt, after.UpdatedAt)
+	assert.Equal(t, before.MessageCount, after.MessageCount)
+
+	// Once committed, both writes are visible
+	require.NoError(t, txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := messageRepo.CreateWithAttachments(ctx, message); err != nil {
+			return err
+		}
+		return conversationRepo.TouchUpdatedAt(ctx, conversation.ID, message.Timestamp)
+	}))
+
+	after, err = conversationRepo.GetByID(ctx, conversation.ID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, message.Timestamp, after.UpdatedAt, time.Millisecond)
+	assert.Equal(t, before.MessageCount+1, after.MessageCount)
+}
+
+func TestPostgresConversationRepository_TouchUpdatedAtReordersConversations(t *testing.T) {
+	db := openTestDatabase(t)
+	log := logger.NewLogger("error")
+	conversationRepo := NewPostgresConversationRepository(db, log)
+	messageRepo := NewPostgresMessageRepository(db, log)
+	txManager := NewPostgresTxManager(db, log)
+	ctx := context.Background()
+
+	older := createTestConversation(t, conversationRepo)
+	newer := &domain.Conversation{
+		ID:        uuid.New().String(),
+		UserID:    older.UserID,
+		Channel:   domain.ChannelWeb,
+		Status:    domain.ConversationStatusActive,
+		CreatedAt: time.Now().Add(time.Minute),
+		UpdatedAt: time.Now().Add(time.Minute),
+	}
+	require.NoError(t, conversationRepo.Create(ctx, newer))
+	t.Cleanup(func() { conversationRepo.Delete(context.Background(), newer.ID) })
+
+	conversations, err := conversationRepo.GetByUserID(ctx, older.UserID, domain.ConversationFilters{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, conversations, 2)
+	assert.Equal(t, newer.ID, conversations[0].ID)
+
+	// A new message in the older conversation brings it to the top
+	sentAt := time.Now().Add(time.Hour)
+	require.NoError(t, txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := messageRepo.Create(ctx, &domain.Message{
+			ID:             uuid.New().String(),
+			ConversationID: older.ID,
+			SenderType:     domain.SenderTypeUser,
+			SenderID:       older.UserID,
+			Content:        "hola",
+			ContentType:    domain.ContentTypeText,
+			Metadata:       domain.JSONB{},
+			Timestamp:      sentAt,
+		}); err != nil {
+			return err
+		}
+		return conversationRepo.TouchUpdatedAt(ctx, older.ID, sentAt)
+	}))
+
+	conversations, err = conversationRepo.GetByUserID(ctx, older.UserID, domain.ConversationFilters{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, conversations, 2)
+	assert.Equal(t, older.ID, conversations[0].ID)
+
+	// A message stored late doesn't move it back
+	require.NoError(t, conversationRepo.TouchUpdatedAt(ctx, older.ID, time.Now().Add(-time.Hour)))
+	touched, err := conversationRepo.GetByID(ctx, older.ID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, sentAt, touched.UpdatedAt, time.Millisecond)
+}
+
+func TestPostgresConversationRepository_GetByUserIDIncludesLastMessage(t *testing.T) {
+	db := openTestDatabase(t)
+	log := logger.NewLogger("error")
+	conversationRepo := NewPostgresConversationRepository(db, log)
+	messageRepo := NewPostgresMessageRepository(db, log)
+	txManager := NewPostgresTxManager(db, log)
+	ctx := context.Background()
+
+	first := createTestConversation(t, conversationRepo)
+	conversations := []*domain.Conversation{first}
+	for i := 0; i < 2; i++ {
+		conversation := &domain.Conversation{
+			ID:        uuid.New().String(),
+			UserID:    first.UserID,
+			Channel:   domain.ChannelWeb,
+			Status:    domain.ConversationStatusActive,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		require.NoError(t, conversationRepo.Create(ctx, conversation))
+		t.Cleanup(func() { conversationRepo.Delete(context.Background(), conversation.ID) })
+		conversations = append(conversations, conversation)
+	}
+
+	send := func(conversationID string, content string, sentAt time.Time) {
+		require.NoError(t, txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := messageRepo.Create(ctx, &domain.Message{
+				ID:             uuid.New().String(),
+				ConversationID: conversationID,
+				SenderType:     domain.SenderTypeUser,
+				SenderID:       first.UserID,
+				Content:        content,
+				ContentType:    domain.ContentTypeText,
+				Metadata:       domain.JSONB{},
+				Timestamp:      sentAt,
+			}); err != nil {
+				return err
+			}
+			return conversationRepo.TouchUpdatedAt(ctx, conversationID, sentAt)
+		}))
+	}
+
+	// Each conversation gets two messages; the second one is the preview
+	base := time.Now().Add(time.Hour).Truncate(time.Microsecond)
+	send(conversations[0].ID, "hola", base)
+	send(conversations[0].ID, "¿sigue abierto?", base.Add(3*time.Minute))
+	send(conversations[1].ID, "buenas", base.Add(time.Minute))
+	send(conversations[1].ID, "gracias", base.Add(5*time.Minute))
+	send(conversations[2].ID, "ayuda", base.Add(2*time.Minute))
+	send(conversations[2].ID, "¿hay alguien?", base.Add(4*time.Minute))
+
+	listed, err := conversationRepo.GetByUserID(ctx, first.UserID, domain.ConversationFilters{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, listed, 3)
+
+	// Most recent activity first, each with its own latest message
+	assert.Equal(t, conversations[1].ID, listed[0].ID)
+	assert.Equal(t, conversations[2].ID, listed[1].ID)
+	assert.Equal(t, conversations[0].ID, listed[2].ID)
+
+	require.NotNil(t, listed[0].LastMessage)
+	assert.Equal(t, "gracias", listed[0].LastMessage.Content)
+	assert.True(t, base.Add(5*time.Minute).Equal(listed[0].LastMessage.Timestamp))
+	require.NotNil(t, listed[1].LastMessage)
+	assert.Equal(t, "¿hay alguien?", listed[1].LastMessage.Content)
+	require.NotNil(t, listed[2].LastMessage)
+	assert.Equal(t, "¿sigue abierto?", listed[2].LastMessage.Content)
+	assert.Equal(t, conversations[0].ID, listed[2].LastMessage.ConversationID)
+}
+
+func TestPostgresConversationRepository_GetByUserIDCountsUnread(t *testing.T) {
+	db := openTestDatabase(t)
+	log := logger.NewLogger("error")
+	conversationRepo := NewPostgresConversationRepository(db, log)
+	messageRepo := NewPostgresMessageRepository(db, log)
+	participantRepo := NewPostgresParticipantRepository(db, log)
+	readRepo := NewPostgresMessageReadRepository(db, log)
+	ctx := context.Background()
+
+	conversation := createTestConversation(t, conversationRepo)
+	owner := conversation.UserID
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+	send := func(senderID string, offset time.Duration) string {
+		id := uuid.New().String()
+		require.NoError(t, messageRepo.Create(ctx, &domain.Message{
+			ID:             id,
+			ConversationID: conversation.ID,
+			SenderType:     domain.SenderTypeUser,
+			SenderID:       senderID,
+			Content:        "hola",
+			ContentType:    domain.ContentTypeText,
+			Metadata:       domain.JSONB{},
+			Timestamp:      base.Add(offset),
+		}))
+		return id
+	}
+
+	send("agent1", 0)
+	send(owner, time.Minute)
+	receipted := send("agent1", 2*time.Minute)
+	send("agent1", 3*time.Minute)
+	send(owner, 4*time.Minute)
+
+	unread := func() int {
+		listed, err := conversationRepo.GetByUserID(ctx, owner, domain.ConversationFilters{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, listed, 1)
+		return listed[0].UnreadCount
+	}
+
+	// The owner's own messages never count
+	assert.Equal(t, 3, unread())
+
+	// The read marker covers everything up to it
+	require.NoError(t, participantRepo.MarkRead(ctx, conversation.ID, owner, base.Add(30*time.Second)))
+	assert.Equal(t, 2, unread())
+
+	// A receipt on a later message counts as read too
+	_, err := readRepo.MarkRead(ctx, conversation.ID, owner, []string{receipted}, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, unread())
+}
+
+func TestPostgresConversationRepository_ReconcileMessageCounts(t *testing.T) {
+	db := openTestDatabase(t)
+	log := logger.NewLogger("error")
+	conversationRepo := NewPostgresConversationRepository(db, log)
+	ctx := context.Background()
+
+	conversation := createTestConversation(t, conversationRepo)
+
+	// Simulate drift: the counter says 7 but the conversation has no messages
+	_, err := db.ExecContext(ctx, `UPDATE conversations SET message_count = 7 WHERE id = $1`, conversation.ID)
+	require.NoError(t, err)
+
+	var fixed []string
+	cursor := ""
+	for {
+		lastID, fixedIDs, err := conversationRepo.ReconcileMessageCounts(ctx, cursor, 500)
+		require.NoError(t, err)
+		fixed = append(fixed, fixedIDs...)
+		if lastID == "" {
+			break
+		}
+		cursor = lastID
+	}
+
+	assert.Contains(t, fixed, conversation.ID)
+
+	stored, err := conversationRepo.GetByID(ctx, conversation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), stored.MessageCount)
+}
+
+func TestPostgresConversationRepository_NextReferenceNumberIsUnique(t *testing.T) {
+	db := openTestDatabase(t)
+	conversationRepo := NewPostgresConversationRepository(db, logger.NewLogger("error"))
+	ctx := context.Background()
+
+	prefix := "T" + uuid.New().String()[:8]
+	t.Cleanup(func() { db.Exec(`DELETE FROM conversation_reference_counters WHERE prefix = $1`, prefix) })
+
+	const callers = 50
+	var wg sync.WaitGroup
+	numbers := make(chan int64, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			next, err := conversationRepo.NextReferenceNumber(ctx, prefix)
+			assert.NoError(t, err)
+			numbers <- next
+		}()
+	}
+	wg.Wait()
+	close(numbers)
+
+	seen := make(map[int64]bool)
+	for next := range numbers {
+		assert.False(t, seen[next], "duplicate reference number %d", next)
+		seen[next] = true
+	}
+	assert.Len(t, seen, callers)
+}
+
+func TestPostgresHealthRepository_CheckDatabase(t *testing.T) {
+	db := openTestDatabase(t)
+	healthRepo := NewPostgresHealthRepository(db, logger.NewLogger("error"))
+
+	assert.NoError(t, healthRepo.CheckDatabase(context.Background()))
+
+	// A closed pool is reported as down
+	require.NoError(t, db.Close())
+	assert.Error(t, healthRepo.CheckDatabase(context.Background()))
+}
+
+func TestSendMessage_TracesRepositoryQueries(t *testing.T) {
+	db := openTestDatabase(t)
+	log := logger.NewLogger("error")
+	conversationRepo := NewPostgresConversationRepository(db, log)
+	conversation := createTestConversation(t, conversationRepo)
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	service := services.NewTracingMessagingService(services.NewMessagingService(
+		conversationRepo,
+		NewPostgresMessageRepository(db, log),
+		NewPostgresAttachmentRepository(db, log),
+		services.NewNoOpEventPublisher(),
+		services.NewNoOpCacheService(),
+		log,
+	))
+	message, err := service.SendMessage(context.Background(), services.SendMessageRequest{
+		ConversationID: conversation.ID,
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       conversation.UserID,
+		Content:        "traced",
+		ContentType:    domain.ContentTypeText,
+	})
+	require.NoError(t, err)
+
+	var call sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "MessagingService.SendMessage" {
+			call = span
+		}
+	}
+	require.NotNil(t, call)
+
+	var insert sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "INSERT messages" {
+			insert = span
+		}
+	}
+	require.NotNil(t, insert, "the message insert should have its own span")
+	assert.Equal(t, call.SpanContext().SpanID(), insert.Parent().SpanID())
+	for _, attr := range call.Attributes() {
+		if attr.Key == "message_id" {
+			assert.Equal(t, message.ID, attr.Value.AsString())
+		}
+	}
+}
+
+func TestPostgresConversationRepository_ArchiveStale(t *testing.T) {
+	db := openTestDatabase(t)
+	conversationRepo := NewPostgresConversationRepository(db, logger.NewLogger("error"))
+	ctx := context.Background()
+
+	stale := createTestConversation(t, conversationRepo)
+	recent := createTestConversation(t, conversationRepo)
+	closed := createTestConversation(t, conversationRepo)
+	for _, conversation := range []*domain.Conversation{stale, closed} {
+		_, err := db.Exec(`UPDATE conversations SET updated_at = NOW() - INTERVAL '100 days' WHERE id = $1`, conversation.ID)
+		require.NoError(t, err)
+	}
+	_, err := db.Exec(`UPDATE conversations SET status = 'closed' WHERE id = $1`, closed.ID)
+	require.NoError(t, err)
+
+	olderThan := time.Now().AddDate(0, 0, -90)
+	count, err := conversationRepo.CountStale(ctx, olderThan)
+	require.NoError(t, err)
+
+	archived, err := conversationRepo.ArchiveStale(ctx, olderThan)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, archived, int64(1))
+	assert.Equal(t, count, archived)
+
+	// Only the stale active conversation is archived
+	expected := map[string]domain.ConversationStatus{
+		stale.ID:  domain.ConversationStatusArchived,
+		recent.ID: domain.ConversationStatusActive,
+		closed.ID: domain.ConversationStatusClosed,
+	}
+	for id, status := range expected {
+		conversation, err := conversationRepo.GetByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, status, conversation.Status, id)
+	}
+}
@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+type postgresOutboxRepository struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewPostgresOutboxRepository(db *sql.DB, logger logger.Logger) domain.OutboxRepository {
+	return &postgresOutboxRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const outboxColumns = `id, event_type, conversation_id, payload, attempts, last_error, next_attempt_at, created_at, delivered_at`
+
+func (r *postgresOutboxRepository) Create(ctx context.Context, event *domain.OutboxEvent) error {
+	query := `
+		INSERT INTO event_outbox (` + outboxColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	// Sent as text so Postgres parses it as JSONB rather than bytea
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		event.ID,
+		event.EventType,
+		event.ConversationID,
+		string(event.Payload),
+		event.Attempts,
+		event.LastError,
+		event.NextAttemptAt,
+		event.CreatedAt,
+		event.DeliveredAt,
+	)
+
+	if err != nil {
+		r.logger.Error("Failed to create outbox event", err)
+		return fmt.Errorf("failed to create outbox event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresOutboxRepository) ClaimPending(ctx context.Context, now time.Time, leaseUntil time.Time, maxAttempts int, limit int) ([]domain.OutboxEvent, error) {
+	// Rows locked by a concurrent claim are skipped, and the lease hides the claimed ones once it commits
+	query := `
+		WITH claimed AS (
+			UPDATE event_outbox
+			SET next_attempt_at = $4
+			WHERE id IN (
+				SELECT id FROM event_outbox
+				WHERE delivered_at IS NULL AND next_attempt_at <= $1 AND attempts < $2
+				ORDER BY created_at, id
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING ` + outboxColumns + `
+		)
+		SELECT ` + outboxColumns + ` FROM claimed ORDER BY created_at, id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, now, maxAttempts, limit, leaseUntil)
+	if err != nil {
+		r.logger.Error("Failed to claim pending outbox events", err)
+		return nil, fmt.Errorf("failed to claim pending outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []domain.OutboxEvent
+	for rows.Next() {
+		var event domain.OutboxEvent
+		var payload []byte
+		var deliveredAt sql.NullTime
+		err := rows.Scan(
+			&event.ID,
+			&event.EventType,
+			&event.ConversationID,
+			&payload,
+			&event.Attempts,
+			&event.LastError,
+			&event.NextAttemptAt,
+			&event.CreatedAt,
+			&deliveredAt,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan outbox event row", err)
+			continue
+		}
+		event.Payload = payload
+		if deliveredAt.Valid {
+			event.DeliveredAt = &deliveredAt.Time
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate outbox events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *postgresOutboxRepository) MarkDelivered(ctx context.Context, id string, deliveredAt time.Time) error {
+	query := `UPDATE event_outbox SET delivered_at = $2, attempts = attempts + 1 WHERE id = $1`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id, deliveredAt); err != nil {
+		r.logger.Error("Failed to mark outbox event as delivered", err)
+		return fmt.Errorf("failed to mark outbox event as delivered: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresOutboxRepository) MarkFailed(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error {
+	query := `UPDATE event_outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1`
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id, lastError, nextAttemptAt); err != nil {
+		r.logger.Error("Failed to mark outbox event as failed", err)
+		return fmt.Errorf("failed to mark outbox event as failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresOutboxRepository) CountUndelivered(ctx context.Context, maxAttempts int) (int, int, error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE attempts < $1),
+			COUNT(*) FILTER (WHERE attempts >= $1)
+		FROM event_outbox
+		WHERE delivered_at IS NULL
+	`
+
+	var pending, dead int
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, maxAttempts).Scan(&pending, &dead); err != nil {
+		r.logger.Error("Failed to count undelivered outbox events", err)
+		return 0, 0, fmt.Errorf("failed to count undelivered outbox events: %w", err)
+	}
+
+	return pending, dead, nil
+}
+
+func (r *postgresOutboxRepository) DeleteDeliveredBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM event_outbox
+		WHERE id IN (
+			SELECT id FROM event_outbox
+			WHERE delivered_at < $1
+			ORDER BY delivered_at
+			LIMIT $2
+		)
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, before, limit)
+	if err != nil {
+		r.logger.Error("Failed to delete delivered outbox events", err)
+		return 0, fmt.Errorf("failed to delete delivered outbox events: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
@@ -0,0 +1,230 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/lib/pq"
+)
+
+type postgresParticipantRepository struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewPostgresParticipantRepository(db *sql.DB, logger logger.Logger) domain.ParticipantRepository {
+	return &postgresParticipantRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// MarkRead avanza la marca de lectura del usuario. La marca se guarda aparte de los participantes, así que
+// leer una conversación no da acceso a ella
+func (r *postgresParticipantRepository) MarkRead(ctx context.Context, conversationID string, userID string, readAt time.Time) error {
+	query := `
+		INSERT INTO conversation_read_markers (conversation_id, user_id, last_read_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (conversation_id, user_id)
+		DO UPDATE SET last_read_at = GREATEST(conversation_read_markers.last_read_at, EXCLUDED.last_read_at)
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, conversationID, userID, readAt)
+	if err != nil {
+		r.logger.Error("Failed to mark conversation as read", err)
+		return fmt.Errorf("failed to mark conversation as read: %w", err)
+	}
+
+	return nil
+}
+
+// GetReadStates calcula en una sola consulta el estado de lectura de varias conversaciones entre su
+// propietario y sus participantes; las marcas de quien no es ninguno de los dos no cuentan
+func (r *postgresParticipantRepository) GetReadStates(ctx context.Context, conversationIDs []string) (map[string]domain.ConversationReadState, error) {
+	states := make(map[string]domain.ConversationReadState)
+	if len(conversationIDs) == 0 {
+		return states, nil
+	}
+
+	query := `
+		WITH latest AS (
+			SELECT conversation_id, MAX(timestamp) AS last_message_at
+			FROM messages
+			WHERE conversation_id = ANY($1::uuid[]) AND ` + visibleMessage + `
+			GROUP BY conversation_id
+		),
+		readers AS (
+			SELECT id AS conversation_id, user_id FROM conversations WHERE id = ANY($1::uuid[])
+			UNION
+			SELECT conversation_id, user_id FROM conversation_participants WHERE conversation_id = ANY($1::uuid[])
+		)
+		SELECT r.conversation_id,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE m.last_read_at IS NOT NULL AND (l.last_message_at IS NULL OR m.last_read_at >= l.last_message_at))
+		FROM readers r
+		LEFT JOIN conversation_read_markers m ON m.conversation_id = r.conversation_id AND m.user_id = r.user_id
+		LEFT JOIN latest l ON l.conversation_id = r.conversation_id
+		GROUP BY r.conversation_id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, pq.Array(conversationIDs))
+	if err != nil {
+		r.logger.Error("Failed to get conversation read states", err)
+		return nil, fmt.Errorf("failed to get read states: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var conversationID string
+		var state domain.ConversationReadState
+		if err := rows.Scan(&conversationID, &state.Participants, &state.ReadLatest); err != nil {
+			r.logger.Error("Failed to scan read state row", err)
+			continue
+		}
+		states[conversationID] = state
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate read states: %w", err)
+	}
+
+	return states, nil
+}
+
+// FilterParticipants devuelve los usuarios de userIDs que participan en la conversación
+func (r *postgresParticipantRepository) FilterParticipants(ctx context.Context, conversationID string, userIDs []string) ([]string, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT user_id
+		FROM conversation_participants
+		WHERE conversation_id = $1 AND user_id = ANY($2)
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, conversationID, pq.Array(userIDs))
+	if err != nil {
+		r.logger.Error("Failed to filter conversation participants", err)
+		return nil, fmt.Errorf("failed to filter participants: %w", err)
+	}
+	defer rows.Close()
+
+	var participants []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			r.logger.Error("Failed to scan participant row", err)
+			continue
+		}
+		participants = append(participants, userID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate participants: %w", err)
+	}
+
+	return participants, nil
+}
+
+// AddParticipant añade al usuario o, si ya participa, actualiza su rol conservando joined_at; devuelve la
+// marca de lectura que tuviera
+func (r *postgresParticipantRepository) AddParticipant(ctx context.Context, participant *domain.ConversationParticipant) error {
+	query := `
+		INSERT INTO conversation_participants (conversation_id, user_id, role, joined_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (conversation_id, user_id)
+		DO UPDATE SET role = EXCLUDED.role
+		RETURNING joined_at, (
+			SELECT m.last_read_at FROM conversation_read_markers m
+			WHERE m.conversation_id = $1 AND m.user_id = $2
+		)
+	`
+
+	var lastReadAt sql.NullTime
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		participant.ConversationID,
+		participant.UserID,
+		participant.Role,
+		participant.JoinedAt,
+	).Scan(&participant.JoinedAt, &lastReadAt)
+	if err != nil {
+		r.logger.Error("Failed to add conversation participant", err)
+		return fmt.Errorf("failed to add participant: %w", err)
+	}
+
+	participant.LastReadAt = nil
+	if lastReadAt.Valid {
+		participant.LastReadAt = &lastReadAt.Time
+	}
+
+	return nil
+}
+
+func (r *postgresParticipantRepository) RemoveParticipant(ctx context.Context, conversationID string, userID string) error {
+	query := `DELETE FROM conversation_participants WHERE conversation_id = $1 AND user_id = $2`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, conversationID, userID)
+	if err != nil {
+		r.logger.Error("Failed to remove conversation participant", err)
+		return fmt.Errorf("failed to remove participant: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("participant %w", domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+// ListParticipants devuelve los participantes por orden de llegada, con su marca de lectura
+func (r *postgresParticipantRepository) ListParticipants(ctx context.Context, conversationID string) ([]domain.ConversationParticipant, error) {
+	query := `
+		SELECT p.conversation_id, p.user_id, p.role, p.joined_at, m.last_read_at
+		FROM conversation_participants p
+		LEFT JOIN conversation_read_markers m ON m.conversation_id = p.conversation_id AND m.user_id = p.user_id
+		WHERE p.conversation_id = $1
+		ORDER BY p.joined_at ASC, p.user_id ASC
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, conversationID)
+	if err != nil {
+		r.logger.Error("Failed to list conversation participants", err)
+		return nil, fmt.Errorf("failed to list participants: %w", err)
+	}
+	defer rows.Close()
+
+	participants := []domain.ConversationParticipant{}
+	for rows.Next() {
+		var participant domain.ConversationParticipant
+		var lastReadAt sql.NullTime
+		if err := rows.Scan(
+			&participant.ConversationID,
+			&participant.UserID,
+			&participant.Role,
+			&participant.JoinedAt,
+			&lastReadAt,
+		); err != nil {
+			r.logger.Error("Failed to scan participant row", err)
+			continue
+		}
+		if lastReadAt.Valid {
+			participant.LastReadAt = &lastReadAt.Time
+		}
+		participants = append(participants, participant)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate participants: %w", err)
+	}
+
+	return participants, nil
+}
@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/lib/pq"
+)
+
+type postgresReactionRepository struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewPostgresReactionRepository(db *sql.DB, logger logger.Logger) domain.ReactionRepository {
+	return &postgresReactionRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Add se apoya en la restricción UNIQUE (message_id, user_id, emoji), así que dos peticiones simultáneas
+// no pueden duplicar la reacción
+func (r *postgresReactionRepository) Add(ctx context.Context, reaction *domain.Reaction) (bool, error) {
+	query := `
+		WITH inserted AS (
+			INSERT INTO reactions (id, message_id, user_id, emoji, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (message_id, user_id, emoji) DO NOTHING
+			RETURNING id, created_at
+		)
+		SELECT id, created_at, true FROM inserted
+		UNION ALL
+		SELECT id, created_at, false FROM reactions
+		WHERE message_id = $2 AND user_id = $3 AND emoji = $4 AND NOT EXISTS (SELECT 1 FROM inserted)
+	`
+
+	var added bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		reaction.ID,
+		reaction.MessageID,
+		reaction.UserID,
+		reaction.Emoji,
+		reaction.CreatedAt,
+	).Scan(&reaction.ID, &reaction.CreatedAt, &added)
+	if err == sql.ErrNoRows {
+		// A concurrent request inserted the same reaction after this statement's snapshot
+		return false, nil
+	}
+	if err != nil {
+		r.logger.Error("Failed to add reaction", err)
+		return false, fmt.Errorf("failed to add reaction: %w", err)
+	}
+
+	return added, nil
+}
+
+func (r *postgresReactionRepository) Remove(ctx context.Context, messageID string, userID string, emoji string) (bool, error) {
+	query := `DELETE FROM reactions WHERE message_id = $1 AND user_id = $2 AND emoji = $3`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, messageID, userID, emoji)
+	if err != nil {
+		r.logger.Error("Failed to remove reaction", err)
+		return false, fmt.Errorf("failed to remove reaction: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *postgresReactionRepository) GetByMessageID(ctx context.Context, messageID string) ([]domain.Reaction, error) {
+	query := `
+		SELECT id, message_id, user_id, emoji, created_at
+		FROM reactions
+		WHERE message_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, messageID)
+	if err != nil {
+		r.logger.Error("Failed to get reactions by message ID", err)
+		return nil, fmt.Errorf("failed to get reactions: %w", err)
+	}
+	defer rows.Close()
+
+	var reactions []domain.Reaction
+	for rows.Next() {
+		var reaction domain.Reaction
+		err := rows.Scan(
+			&reaction.ID,
+			&reaction.MessageID,
+			&reaction.UserID,
+			&reaction.Emoji,
+			&reaction.CreatedAt,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan reaction row", err)
+			continue
+		}
+		reactions = append(reactions, reaction)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.Error("Error iterating reaction rows", err)
+		return nil, fmt.Errorf("failed to iterate reactions: %w", err)
+	}
+
+	return reactions, nil
+}
+
+func (r *postgresReactionRepository) CountByMessageIDs(ctx context.Context, messageIDs []string) (map[string]map[string]int, error) {
+	counts := make(map[string]map[string]int)
+	if len(messageIDs) == 0 {
+		return counts, nil
+	}
+
+	query := `
+		SELECT message_id, emoji, COUNT(*)
+		FROM reactions
+		WHERE message_id = ANY($1)
+		GROUP BY message_id, emoji
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, pq.Array(messageIDs))
+	if err != nil {
+		r.logger.Error("Failed to count reactions by message IDs", err)
+		return nil, fmt.Errorf("failed to count reactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var messageID, emoji string
+		var count int
+		if err := rows.Scan(&messageID, &emoji, &count); err != nil {
+			r.logger.Error("Failed to scan reaction count row", err)
+			continue
+		}
+		if counts[messageID] == nil {
+			counts[messageID] = make(map[string]int)
+		}
+		counts[messageID][emoji] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate reaction counts: %w", err)
+	}
+
+	return counts, nil
+}
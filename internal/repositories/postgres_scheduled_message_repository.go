@@ -0,0 +1,218 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+// scheduledMessageColumns lista las columnas leídas por scanScheduledMessage, en el mismo orden
+const scheduledMessageColumns = `id, conversation_id, sender_type, sender_id, content, content_type, metadata, attachments,
+	reply_to_id, expires_at, scheduled_at, status, message_id, error, created_at, updated_at`
+
+type postgresScheduledMessageRepository struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewPostgresScheduledMessageRepository(db *sql.DB, logger logger.Logger) domain.ScheduledMessageRepository {
+	return &postgresScheduledMessageRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *postgresScheduledMessageRepository) Create(ctx context.Context, message *domain.ScheduledMessage) error {
+	metadataJSON, err := json.Marshal(message.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scheduled message metadata: %w", err)
+	}
+
+	attachments := message.Attachments
+	if attachments == nil {
+		attachments = []domain.Attachment{}
+	}
+	attachmentsJSON, err := json.Marshal(attachments)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scheduled message attachments: %w", err)
+	}
+
+	query := `
+		INSERT INTO scheduled_messages (id, conversation_id, sender_type, sender_id, content, content_type, metadata,
+			attachments, reply_to_id, expires_at, scheduled_at, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		message.ID,
+		message.ConversationID,
+		message.SenderType,
+		message.SenderID,
+		message.Content,
+		message.ContentType,
+		metadataJSON,
+		attachmentsJSON,
+		message.ReplyToID,
+		message.ExpiresAt,
+		message.ScheduledAt,
+		message.Status,
+		message.CreatedAt,
+		message.UpdatedAt,
+	)
+	if err != nil {
+		r.logger.Error("Failed to create scheduled message", err)
+		return fmt.Errorf("failed to create scheduled message: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresScheduledMessageRepository) GetByID(ctx context.Context, id string) (*domain.ScheduledMessage, error) {
+	query := `SELECT ` + scheduledMessageColumns + ` FROM scheduled_messages WHERE id = $1`
+
+	message, err := r.scanScheduledMessage(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("scheduled message %w", domain.ErrNotFound)
+		}
+		r.logger.Error("Failed to get scheduled message", err)
+		return nil, fmt.Errorf("failed to get scheduled message: %w", err)
+	}
+
+	return message, nil
+}
+
+func (r *postgresScheduledMessageRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]domain.ScheduledMessage, error) {
+	query := `
+		SELECT ` + scheduledMessageColumns + `
+		FROM scheduled_messages
+		WHERE status = $1 AND scheduled_at <= $2
+		ORDER BY scheduled_at ASC
+		LIMIT $3
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, domain.ScheduledMessageStatusScheduled, now, limit)
+	if err != nil {
+		r.logger.Error("Failed to get due scheduled messages", err)
+		return nil, fmt.Errorf("failed to get due scheduled messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []domain.ScheduledMessage
+	for rows.Next() {
+		message, err := r.scanScheduledMessage(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan scheduled message", err)
+			return nil, fmt.Errorf("failed to scan scheduled message: %w", err)
+		}
+		messages = append(messages, *message)
+	}
+
+	return messages, rows.Err()
+}
+
+func (r *postgresScheduledMessageRepository) UpdateStatus(ctx context.Context, message *domain.ScheduledMessage, from domain.ScheduledMessageStatus) (bool, error) {
+	query := `
+		UPDATE scheduled_messages
+		SET status = $2, message_id = $3, error = $4, updated_at = $5
+		WHERE id = $1 AND status = $6
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, message.ID, message.Status, message.MessageID, message.Error, message.UpdatedAt, from)
+	if err != nil {
+		r.logger.Error("Failed to update scheduled message status", err)
+		return false, fmt.Errorf("failed to update scheduled message status: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update scheduled message status: %w", err)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *postgresScheduledMessageRepository) ReleaseStale(ctx context.Context, staleBefore time.Time, now time.Time) (int64, error) {
+	// The sent message carries the scheduled ID in its metadata, so a send that was saved isn't repeated
+	query := `
+		WITH stale AS (
+			SELECT s.id, (
+				SELECT m.id FROM messages m
+				WHERE m.conversation_id = s.conversation_id AND m.metadata->>'scheduled_message_id' = s.id::text
+				LIMIT 1
+			) AS message_id
+			FROM scheduled_messages s
+			WHERE s.status = $1 AND s.updated_at < $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE scheduled_messages s
+		SET status = CASE WHEN stale.message_id IS NULL THEN $3 ELSE $4 END,
+			message_id = stale.message_id,
+			updated_at = $5
+		FROM stale
+		WHERE s.id = stale.id
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
+		domain.ScheduledMessageStatusSending,
+		staleBefore,
+		domain.ScheduledMessageStatusScheduled,
+		domain.ScheduledMessageStatusSent,
+		now,
+	)
+	if err != nil {
+		r.logger.Error("Failed to release stale scheduled messages", err)
+		return 0, fmt.Errorf("failed to release stale scheduled messages: %w", err)
+	}
+
+	released, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to release stale scheduled messages: %w", err)
+	}
+
+	return released, nil
+}
+
+func (r *postgresScheduledMessageRepository) scanScheduledMessage(row rowScanner) (*domain.ScheduledMessage, error) {
+	var message domain.ScheduledMessage
+	var metadataJSON, attachmentsJSON []byte
+	err := row.Scan(
+		&message.ID,
+		&message.ConversationID,
+		&message.SenderType,
+		&message.SenderID,
+		&message.Content,
+		&message.ContentType,
+		&metadataJSON,
+		&attachmentsJSON,
+		&message.ReplyToID,
+		&message.ExpiresAt,
+		&message.ScheduledAt,
+		&message.Status,
+		&message.MessageID,
+		&message.Error,
+		&message.CreatedAt,
+		&message.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metadataJSON) > 0 {
+		if err := json.Unmarshal(metadataJSON, &message.Metadata); err != nil {
+			r.logger.Error("Failed to unmarshal scheduled message metadata", err)
+		}
+	}
+	if len(attachmentsJSON) > 0 {
+		if err := json.Unmarshal(attachmentsJSON, &message.Attachments); err != nil {
+			r.logger.Error("Failed to unmarshal scheduled message attachments", err)
+		}
+	}
+
+	return &message, nil
+}
@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+// shareLinkColumns lista las columnas leídas por scanShareLink, en el mismo orden
+const shareLinkColumns = `id, conversation_id, created_by, read_only, expires_at, revoked_at, created_at`
+
+type postgresShareLinkRepository struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewPostgresShareLinkRepository(db *sql.DB, logger logger.Logger) domain.ShareLinkRepository {
+	return &postgresShareLinkRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *postgresShareLinkRepository) Create(ctx context.Context, link *domain.ConversationShareLink) error {
+	query := `
+		INSERT INTO conversation_share_links (id, conversation_id, created_by, read_only, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, link.ID, link.ConversationID, link.CreatedBy, link.ReadOnly, link.ExpiresAt, link.CreatedAt)
+	if err != nil {
+		r.logger.Error("Failed to create share link", err)
+		return fmt.Errorf("failed to create share link: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresShareLinkRepository) GetByID(ctx context.Context, id string) (*domain.ConversationShareLink, error) {
+	query := `SELECT ` + shareLinkColumns + ` FROM conversation_share_links WHERE id = $1`
+
+	link, err := scanShareLink(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("share link %w", domain.ErrNotFound)
+		}
+		r.logger.Error("Failed to get share link", err)
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+
+	return link, nil
+}
+
+// ListByConversation devuelve los enlaces de la conversación, del más reciente al más antiguo, incluidos
+// los caducados y revocados
+func (r *postgresShareLinkRepository) ListByConversation(ctx context.Context, conversationID string) ([]domain.ConversationShareLink, error) {
+	query := `
+		SELECT ` + shareLinkColumns + `
+		FROM conversation_share_links
+		WHERE conversation_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, conversationID)
+	if err != nil {
+		r.logger.Error("Failed to list share links", err)
+		return nil, fmt.Errorf("failed to list share links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []domain.ConversationShareLink
+	for rows.Next() {
+		link, err := scanShareLink(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan share link", err)
+			return nil, fmt.Errorf("failed to scan share link: %w", err)
+		}
+		links = append(links, *link)
+	}
+
+	return links, rows.Err()
+}
+
+func (r *postgresShareLinkRepository) Revoke(ctx context.Context, conversationID string, id string, revokedAt time.Time) error {
+	query := `
+		UPDATE conversation_share_links
+		SET revoked_at = $3
+		WHERE conversation_id = $1 AND id = $2 AND revoked_at IS NULL
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, conversationID, id, revokedAt)
+	if err != nil {
+		r.logger.Error("Failed to revoke share link", err)
+		return fmt.Errorf("failed to revoke share link: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke share link: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("share link %w", domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+func scanShareLink(row rowScanner) (*domain.ConversationShareLink, error) {
+	var link domain.ConversationShareLink
+	err := row.Scan(
+		&link.ID,
+		&link.ConversationID,
+		&link.CreatedBy,
+		&link.ReadOnly,
+		&link.ExpiresAt,
+		&link.RevokedAt,
+		&link.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
@@ -0,0 +1,187 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+type postgresTemplateRepository struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewPostgresTemplateRepository(db *sql.DB, logger logger.Logger) domain.ConversationTemplateRepository {
+	return &postgresTemplateRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *postgresTemplateRepository) Create(ctx context.Context, template *domain.ConversationTemplate) error {
+	messagesJSON, err := json.Marshal(template.Messages)
+	if err != nil {
+		return fmt.Errorf("failed to marshal template messages: %w", err)
+	}
+
+	query := `
+		INSERT INTO conversation_templates (id, name, description, channel, messages, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, query,
+		template.ID,
+		template.Name,
+		template.Description,
+		template.Channel,
+		messagesJSON,
+		template.CreatedBy,
+		template.CreatedAt,
+		template.UpdatedAt,
+	)
+
+	if err != nil {
+		r.logger.Error("Failed to create conversation template", err)
+		return fmt.Errorf("failed to create template: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresTemplateRepository) GetByID(ctx context.Context, id string) (*domain.ConversationTemplate, error) {
+	query := `
+		SELECT id, name, description, channel, messages, created_by, created_at, updated_at
+		FROM conversation_templates
+		WHERE id = $1
+	`
+
+	template, err := r.scanTemplate(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("template %w", domain.ErrNotFound)
+		}
+		r.logger.Error("Failed to get conversation template by ID", err)
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	return template, nil
+}
+
+func (r *postgresTemplateRepository) List(ctx context.Context, pagination domain.PaginationParams) ([]domain.ConversationTemplate, error) {
+	query := `
+		SELECT id, name, description, channel, messages, created_by, created_at, updated_at
+		FROM conversation_templates
+		ORDER BY name ASC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, pagination.Limit, pagination.Offset)
+	if err != nil {
+		r.logger.Error("Failed to list conversation templates", err)
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []domain.ConversationTemplate
+	for rows.Next() {
+		template, err := r.scanTemplate(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan conversation template row", err)
+			continue
+		}
+		templates = append(templates, *template)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.logger.Error("Error iterating conversation template rows", err)
+		return nil, fmt.Errorf("failed to iterate templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+func (r *postgresTemplateRepository) Update(ctx context.Context, template *domain.ConversationTemplate) error {
+	messagesJSON, err := json.Marshal(template.Messages)
+	if err != nil {
+		return fmt.Errorf("failed to marshal template messages: %w", err)
+	}
+
+	query := `
+		UPDATE conversation_templates
+		SET name = $2, description = $3, channel = $4, messages = $5, updated_at = $6
+		WHERE id = $1
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
+		template.ID,
+		template.Name,
+		template.Description,
+		template.Channel,
+		messagesJSON,
+		template.UpdatedAt,
+	)
+
+	if err != nil {
+		r.logger.Error("Failed to update conversation template", err)
+		return fmt.Errorf("failed to update template: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("template %w", domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *postgresTemplateRepository) Delete(ctx context.Context, id string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM conversation_templates WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("Failed to delete conversation template", err)
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("template %w", domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *postgresTemplateRepository) scanTemplate(row rowScanner) (*domain.ConversationTemplate, error) {
+	var template domain.ConversationTemplate
+	var messagesJSON []byte
+
+	err := row.Scan(
+		&template.ID,
+		&template.Name,
+		&template.Description,
+		&template.Channel,
+		&messagesJSON,
+		&template.CreatedBy,
+		&template.CreatedAt,
+		&template.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(messagesJSON, &template.Messages); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal template messages: %w", err)
+	}
+
+	return &template, nil
+}
@@ -0,0 +1,189 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+type postgresWebhookDeliveryRepository struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewPostgresWebhookDeliveryRepository(db *sql.DB, logger logger.Logger) domain.WebhookDeliveryRepository {
+	return &postgresWebhookDeliveryRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+const webhookDeliveryColumns = `id, webhook_id, conversation_id, event_type, url, payload, attempt, status,
+		status_code, response_time_ms, error, replay_of, created_at`
+
+func (r *postgresWebhookDeliveryRepository) Create(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (` + webhookDeliveryColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		delivery.ID,
+		delivery.WebhookID,
+		delivery.ConversationID,
+		delivery.EventType,
+		delivery.URL,
+		delivery.Payload,
+		delivery.Attempt,
+		delivery.Status,
+		delivery.StatusCode,
+		delivery.ResponseTimeMs,
+		delivery.Error,
+		delivery.ReplayOf,
+		delivery.CreatedAt,
+	)
+
+	if err != nil {
+		r.logger.Error("Failed to create webhook delivery", err)
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresWebhookDeliveryRepository) GetByID(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+
+	delivery, err := r.scanDelivery(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("webhook delivery %w", domain.ErrNotFound)
+		}
+		r.logger.Error("Failed to get webhook delivery by ID", err)
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+func (r *postgresWebhookDeliveryRepository) List(ctx context.Context, filters domain.WebhookDeliveryFilters) ([]domain.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries`
+
+	var conditions []string
+	var args []interface{}
+	if filters.WebhookID != "" {
+		args = append(args, filters.WebhookID)
+		conditions = append(conditions, fmt.Sprintf("webhook_id = $%d", len(args)))
+	}
+	if filters.ConversationID != "" {
+		args = append(args, filters.ConversationID)
+		conditions = append(conditions, fmt.Sprintf("conversation_id = $%d", len(args)))
+	}
+	if filters.Status != "" {
+		args = append(args, filters.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query += " ORDER BY created_at DESC, id"
+
+	if filters.Limit > 0 {
+		args = append(args, filters.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	if filters.Offset > 0 {
+		args = append(args, filters.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to list webhook deliveries", err)
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		delivery, err := r.scanDelivery(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan webhook delivery row", err)
+			continue
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *postgresWebhookDeliveryRepository) DeleteCreatedBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	query := `
+		DELETE FROM webhook_deliveries
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE created_at < $1
+			ORDER BY created_at
+			LIMIT $2
+		)
+	`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, before, limit)
+	if err != nil {
+		r.logger.Error("Failed to delete old webhook deliveries", err)
+		return 0, fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+func (r *postgresWebhookDeliveryRepository) scanDelivery(row rowScanner) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	var statusCode sql.NullInt64
+	var replayOf sql.NullString
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.ConversationID,
+		&delivery.EventType,
+		&delivery.URL,
+		&delivery.Payload,
+		&delivery.Attempt,
+		&delivery.Status,
+		&statusCode,
+		&delivery.ResponseTimeMs,
+		&delivery.Error,
+		&replayOf,
+		&delivery.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode.Valid {
+		code := int(statusCode.Int64)
+		delivery.StatusCode = &code
+	}
+	if replayOf.Valid {
+		delivery.ReplayOf = &replayOf.String
+	}
+
+	return &delivery, nil
+}
@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/lib/pq"
+)
+
+type postgresWebhookRepository struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+func NewPostgresWebhookRepository(db *sql.DB, logger logger.Logger) domain.WebhookRepository {
+	return &postgresWebhookRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *postgresWebhookRepository) Create(ctx context.Context, webhook *domain.ConversationWebhook) error {
+	query := `
+		INSERT INTO conversation_webhooks (id, conversation_id, url, event_types, secret, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		webhook.ID,
+		webhook.ConversationID,
+		webhook.URL,
+		pq.Array(webhook.EventTypes),
+		webhook.Secret,
+		webhook.CreatedBy,
+		webhook.CreatedAt,
+	)
+
+	if err != nil {
+		r.logger.Error("Failed to create conversation webhook", err)
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresWebhookRepository) GetByID(ctx context.Context, id string) (*domain.ConversationWebhook, error) {
+	query := `
+		SELECT id, conversation_id, url, event_types, secret, created_by, created_at
+		FROM conversation_webhooks
+		WHERE id = $1
+	`
+
+	webhook, err := r.scanWebhook(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("webhook %w", domain.ErrNotFound)
+		}
+		r.logger.Error("Failed to get conversation webhook by ID", err)
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (r *postgresWebhookRepository) GetByConversationID(ctx context.Context, conversationID string) ([]domain.ConversationWebhook, error) {
+	query := `
+		SELECT id, conversation_id, url, event_types, secret, created_by, created_at
+		FROM conversation_webhooks
+		WHERE conversation_id = $1
+		ORDER BY created_at ASC
+	`
+
+	return r.query(ctx, query, conversationID)
+}
+
+// GetSubscribed devuelve los webhooks de la conversación suscritos al tipo de evento
+func (r *postgresWebhookRepository) GetSubscribed(ctx context.Context, conversationID string, eventType string) ([]domain.ConversationWebhook, error) {
+	query := `
+		SELECT id, conversation_id, url, event_types, secret, created_by, created_at
+		FROM conversation_webhooks
+		WHERE conversation_id = $1 AND $2 = ANY(event_types)
+		ORDER BY created_at ASC
+	`
+
+	return r.query(ctx, query, conversationID, eventType)
+}
+
+func (r *postgresWebhookRepository) Delete(ctx context.Context, id string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM conversation_webhooks WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("Failed to delete conversation webhook", err)
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook %w", domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *postgresWebhookRepository) query(ctx context.Context, query string, args ...interface{}) ([]domain.ConversationWebhook, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Failed to get conversation webhooks", err)
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []domain.ConversationWebhook
+	for rows.Next() {
+		webhook, err := r.scanWebhook(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan conversation webhook row", err)
+			continue
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (r *postgresWebhookRepository) scanWebhook(row rowScanner) (*domain.ConversationWebhook, error) {
+	var webhook domain.ConversationWebhook
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.ConversationID,
+		&webhook.URL,
+		pq.Array(&webhook.EventTypes),
+		&webhook.Secret,
+		&webhook.CreatedBy,
+		&webhook.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/company/microservice-template/internal/repositories"
+
+var (
+	// queryTablePattern saca la primera tabla de la consulta para el nombre del span
+	queryTablePattern = regexp.MustCompile(`(?i)\b(?:FROM|INTO|UPDATE|JOIN)\s+([a-z_][a-z0-9_.]*)`)
+	// queryOperationPattern encuentra la primera operación de una consulta que empieza por WITH
+	queryOperationPattern = regexp.MustCompile(`(?i)\b(SELECT|INSERT|UPDATE|DELETE)\b`)
+)
+
+// tracedConn abre un span hijo del contexto por cada consulta; con el TracerProvider por defecto no hace nada
+type tracedConn struct {
+	dbtx
+}
+
+func (c tracedConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	result, err := c.dbtx.ExecContext(ctx, query, args...)
+	recordQueryError(span, err)
+	return result, err
+}
+
+func (c tracedConn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	rows, err := c.dbtx.QueryContext(ctx, query, args...)
+	recordQueryError(span, err)
+	return rows, err
+}
+
+func (c tracedConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+
+	row := c.dbtx.QueryRowContext(ctx, query, args...)
+	recordQueryError(span, row.Err())
+	return row
+}
+
+// startQuerySpan nombra el span como "<operación> <tabla>" (p. ej. "INSERT messages"); los argumentos no se
+// registran porque pueden llevar contenido de los mensajes
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	statement := strings.Join(strings.Fields(query), " ")
+	operation, _, _ := strings.Cut(statement, " ")
+	operation = strings.ToUpper(operation)
+	if operation == "WITH" {
+		// Name CTEs after the statement inside them, e.g. the INSERT of a message that also bumps the counter
+		if match := queryOperationPattern.FindStringSubmatch(statement); match != nil {
+			operation = strings.ToUpper(match[1])
+		}
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation", operation),
+		attribute.String("db.statement", statement),
+	}
+	name := operation
+	if match := queryTablePattern.FindStringSubmatch(statement); match != nil {
+		attrs = append(attrs, attribute.String("db.sql.table", match[1]))
+		name += " " + match[1]
+	}
+
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// recordQueryError marca el span como fallido; sql.ErrNoRows no es un fallo de la consulta
+func recordQueryError(span trace.Span, err error) {
+	if err == nil || errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+// dbtx agrupa las operaciones que comparten *sql.DB y *sql.Tx
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txContextKey struct{}
+
+// txFromContext devuelve la transacción abierta por WithinTransaction, o nil si no hay ninguna
+func txFromContext(ctx context.Context) *sql.Tx {
+	tx, _ := ctx.Value(txContextKey{}).(*sql.Tx)
+	return tx
+}
+
+// conn devuelve la transacción del contexto si la hay; si no, la conexión del repositorio. Cada consulta
+// abre un span
+func conn(ctx context.Context, db *sql.DB) dbtx {
+	if tx := txFromContext(ctx); tx != nil {
+		return tracedConn{tx}
+	}
+	return tracedConn{db}
+}
+
+// scopedTx es una transacción propia del repositorio o, si el contexto ya trae una, esa misma; en el
+// segundo caso Commit y Rollback no hacen nada y la cierra quien la abrió
+type scopedTx struct {
+	*sql.Tx
+	owned bool
+}
+
+func (t *scopedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return tracedConn{t.Tx}.ExecContext(ctx, query, args...)
+}
+
+func (t *scopedTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return tracedConn{t.Tx}.QueryContext(ctx, query, args...)
+}
+
+func (t *scopedTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return tracedConn{t.Tx}.QueryRowContext(ctx, query, args...)
+}
+
+func (t *scopedTx) Commit() error {
+	if !t.owned {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+func (t *scopedTx) Rollback() error {
+	if !t.owned {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
+
+// beginTx abre una transacción o se une a la del contexto
+func beginTx(ctx context.Context, db *sql.DB) (*scopedTx, error) {
+	if tx := txFromContext(ctx); tx != nil {
+		return &scopedTx{Tx: tx}, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &scopedTx{Tx: tx, owned: true}, nil
+}
+
+type postgresTxManager struct {
+	db     *sql.DB
+	logger logger.Logger
+}
+
+// NewPostgresTxManager crea un domain.TxManager sobre db; los repositorios Postgres usan la transacción que
+// deja en el contexto
+func NewPostgresTxManager(db *sql.DB, logger logger.Logger) domain.TxManager {
+	return &postgresTxManager{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (m *postgresTxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Nested calls join the outer transaction
+	if txFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		m.logger.Error("Failed to begin transaction", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txContextKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		m.logger.Error("Failed to commit transaction", err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
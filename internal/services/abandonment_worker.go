@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+// AbandonmentWorker marca como abandonadas las conversaciones en las que el cliente dejó de responder
+type AbandonmentWorker struct {
+	conversationRepo domain.ConversationRepository
+	eventPublisher   EventPublisher
+	cacheService     CacheService
+	config           config.AbandonmentConfig
+	logger           logger.Logger
+}
+
+func NewAbandonmentWorker(
+	conversationRepo domain.ConversationRepository,
+	eventPublisher EventPublisher,
+	cacheService CacheService,
+	config config.AbandonmentConfig,
+	logger logger.Logger,
+) *AbandonmentWorker {
+	return &AbandonmentWorker{
+		conversationRepo: conversationRepo,
+		eventPublisher:   eventPublisher,
+		cacheService:     cacheService,
+		config:           config,
+		logger:           logger,
+	}
+}
+
+// Start ejecuta el escaneo periódico hasta que se cancele el contexto
+func (w *AbandonmentWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.config.ScanInterval)
+	defer ticker.Stop()
+
+	w.logger.Info("Abandonment worker started", map[string]interface{}{
+		"interval":     w.config.ScanInterval.String(),
+		"idle_timeout": w.config.IdleTimeout.String(),
+		"channels":     w.config.Channels,
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("Abandonment worker stopped")
+			return
+		case <-ticker.C:
+			w.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce procesa un lote de conversaciones inactivas y devuelve cuántas se marcaron como abandonadas
+func (w *AbandonmentWorker) RunOnce(ctx context.Context) int {
+	channels := make([]domain.Channel, len(w.config.Channels))
+	for i, channel := range w.config.Channels {
+		channels[i] = domain.Channel(channel)
+	}
+
+	idleSince := time.Now().Add(-w.config.IdleTimeout)
+	conversations, err := w.conversationRepo.GetAbandonmentCandidates(ctx, idleSince, channels, w.config.BatchSize)
+	if err != nil {
+		w.logger.Error("Failed to load abandonment candidates", err)
+		return 0
+	}
+
+	abandoned := 0
+	for i := range conversations {
+		if w.markAbandoned(ctx, &conversations[i]) {
+			abandoned++
+		}
+	}
+
+	return abandoned
+}
+
+func (w *AbandonmentWorker) markAbandoned(ctx context.Context, conversation *domain.Conversation) bool {
+	now := time.Now()
+	conversation.Status = domain.ConversationStatusAbandoned
+	conversation.StatusChangedAt = &now
+	conversation.UpdatedAt = now
+
+	if err := w.conversationRepo.Update(ctx, conversation); err != nil {
+		w.logger.Error("Failed to mark conversation as abandoned", err)
+		return false
+	}
+
+	if w.cacheService != nil {
+		_ = w.cacheService.DeleteConversation(ctx, conversation.ID)
+		_ = w.cacheService.InvalidateConversationList(ctx, conversation.UserID)
+	}
+
+	if w.eventPublisher != nil {
+		event := domain.MessageEvent{
+			Type:           "conversation.abandoned",
+			ConversationID: conversation.ID,
+			Timestamp:      time.Now(),
+		}
+
+		if err := w.eventPublisher.PublishMessageEvent(ctx, event); err != nil {
+			w.logger.Error("Failed to publish conversation abandoned event", err)
+		}
+	}
+
+	w.logger.Info("Conversation marked as abandoned", map[string]interface{}{
+		"conversation_id": conversation.ID,
+		"channel":         conversation.Channel,
+	})
+
+	return true
+}
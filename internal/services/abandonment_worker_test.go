@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestAbandonmentConfig() config.AbandonmentConfig {
+	return config.AbandonmentConfig{
+		Enabled:      true,
+		ScanInterval: time.Second,
+		IdleTimeout:  15 * time.Minute,
+		Channels:     []string{"web"},
+		BatchSize:    10,
+	}
+}
+
+func TestAbandonmentWorker_MarksIdleConversations(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	publisher := &recordingEventPublisher{}
+
+	worker := NewAbandonmentWorker(
+		mockConversationRepo,
+		publisher,
+		NewNoOpCacheService(),
+		newTestAbandonmentConfig(),
+		logger.NewLogger("debug"),
+	)
+
+	idle := domain.Conversation{ID: "conv123", UserID: "user123", Channel: domain.ChannelWeb, Status: domain.ConversationStatusActive}
+
+	mockConversationRepo.On("GetAbandonmentCandidates", mock.Anything, mock.MatchedBy(func(idleSince time.Time) bool {
+		return idleSince.Before(time.Now().Add(-14 * time.Minute))
+	}), []domain.Channel{domain.ChannelWeb}, 10).Return([]domain.Conversation{idle}, nil)
+	mockConversationRepo.On("Update", mock.Anything, mock.MatchedBy(func(c *domain.Conversation) bool {
+		return c.ID == "conv123" && c.Status == domain.ConversationStatusAbandoned
+	})).Return(nil)
+
+	// Execute
+	abandoned := worker.RunOnce(context.Background())
+
+	// Assert
+	assert.Equal(t, 1, abandoned)
+	assert.Len(t, publisher.events, 1)
+	assert.Equal(t, "conversation.abandoned", publisher.events[0].Type)
+	assert.Equal(t, "conv123", publisher.events[0].ConversationID)
+	mockConversationRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_SendMessage_ReactivatesAbandonedConversation(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	conversation := &domain.Conversation{ID: "conv123", UserID: "user123", Channel: domain.ChannelWeb, Status: domain.ConversationStatusAbandoned}
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(conversation, nil)
+	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+	mockConversationRepo.On("TouchUpdatedAt", mock.Anything, mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+	mockConversationRepo.On("Update", mock.Anything, mock.MatchedBy(func(c *domain.Conversation) bool {
+		return c.Status == domain.ConversationStatusActive
+	})).Return(nil)
+
+	// Execute
+	_, err := service.SendMessage(context.Background(), SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "user123",
+		Content:        "sorry, I'm back",
+		ContentType:    domain.ContentTypeText,
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	mockConversationRepo.AssertExpectations(t)
+}
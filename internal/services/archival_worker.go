@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+// defaultArchivalBatchSize se usa cuando la configuración no fija un tamaño de lote positivo
+const defaultArchivalBatchSize = 500
+
+// ArchivalWorker archiva las conversaciones que superan la antigüedad máxima de su canal, tengan o no
+// actividad reciente, y las activas que llevan InactiveDays sin actividad. A diferencia del
+// AbandonmentWorker, no espera a que responda el cliente
+type ArchivalWorker struct {
+	conversationRepo domain.ConversationRepository
+	eventPublisher   EventPublisher
+	cacheService     CacheService
+	config           config.ArchivalConfig
+	logger           logger.Logger
+}
+
+func NewArchivalWorker(
+	conversationRepo domain.ConversationRepository,
+	eventPublisher EventPublisher,
+	cacheService CacheService,
+	config config.ArchivalConfig,
+	logger logger.Logger,
+) *ArchivalWorker {
+	// A non-positive LIMIT would archive nothing (0) or fail every statement (negative)
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultArchivalBatchSize
+	}
+
+	return &ArchivalWorker{
+		conversationRepo: conversationRepo,
+		eventPublisher:   eventPublisher,
+		cacheService:     cacheService,
+		config:           config,
+		logger:           logger,
+	}
+}
+
+// Start ejecuta el archivado periódico hasta que se cancele el contexto
+func (w *ArchivalWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.config.ScanInterval)
+	defer ticker.Stop()
+
+	w.logger.Info("Archival worker started", map[string]interface{}{
+		"interval": w.config.ScanInterval.String(),
+		"dry_run":  w.config.DryRun,
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("Archival worker stopped")
+			return
+		case <-ticker.C:
+			w.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce archiva por lotes las conversaciones vencidas de cada canal y después las inactivas, y devuelve
+// cuántas se archivaron, o en modo dry-run cuántas se archivarían
+func (w *ArchivalWorker) RunOnce(ctx context.Context) int {
+	channels := make([]string, 0, len(w.config.MaxAge))
+	for channel, maxAge := range w.config.MaxAge {
+		if maxAge > 0 {
+			channels = append(channels, channel)
+		}
+	}
+	sort.Strings(channels)
+
+	total := 0
+	for _, channel := range channels {
+		createdBefore := time.Now().Add(-w.config.MaxAge[channel])
+		if w.config.DryRun {
+			total += w.countAged(ctx, domain.Channel(channel), createdBefore)
+		} else {
+			total += w.archiveAged(ctx, domain.Channel(channel), createdBefore)
+		}
+	}
+
+	if w.config.InactiveDays > 0 && ctx.Err() == nil {
+		olderThan := time.Now().AddDate(0, 0, -w.config.InactiveDays)
+		if w.config.DryRun {
+			total += w.countStale(ctx, olderThan)
+		} else {
+			total += w.archiveStale(ctx, olderThan)
+		}
+	}
+
+	return total
+}
+
+func (w *ArchivalWorker) countStale(ctx context.Context, olderThan time.Time) int {
+	count, err := w.conversationRepo.CountStale(ctx, olderThan)
+	if err != nil {
+		w.logger.Error("Failed to count inactive conversations", err)
+		return 0
+	}
+
+	if count > 0 {
+		w.logger.Info("Archival dry run", map[string]interface{}{
+			"inactive_since": olderThan,
+			"would_archive":  count,
+		})
+	}
+
+	return int(count)
+}
+
+// archiveStale archiva las conversaciones inactivas con un único UPDATE; como no devuelve sus IDs, no
+// publica un evento por conversación
+func (w *ArchivalWorker) archiveStale(ctx context.Context, olderThan time.Time) int {
+	archived, err := w.conversationRepo.ArchiveStale(ctx, olderThan)
+	if err != nil {
+		w.logger.Error("Failed to archive inactive conversations", err)
+		return 0
+	}
+
+	if archived > 0 {
+		w.logger.Info("Inactive conversations archived", map[string]interface{}{
+			"inactive_since": olderThan,
+			"archived":       archived,
+		})
+	}
+
+	return int(archived)
+}
+
+func (w *ArchivalWorker) countAged(ctx context.Context, channel domain.Channel, createdBefore time.Time) int {
+	count, err := w.conversationRepo.CountCreatedBefore(ctx, channel, createdBefore)
+	if err != nil {
+		w.logger.Error("Failed to count conversations over max age", err)
+		return 0
+	}
+
+	if count > 0 {
+		w.logger.Info("Archival dry run", map[string]interface{}{
+			"channel":        channel,
+			"created_before": createdBefore,
+			"would_archive":  count,
+		})
+	}
+
+	return int(count)
+}
+
+func (w *ArchivalWorker) archiveAged(ctx context.Context, channel domain.Channel, createdBefore time.Time) int {
+	archived := 0
+	for ctx.Err() == nil {
+		now := time.Now()
+		ids, err := w.conversationRepo.ArchiveCreatedBefore(ctx, channel, createdBefore, now, w.config.BatchSize)
+		if err != nil {
+			w.logger.Error("Failed to archive conversations over max age", err)
+			break
+		}
+
+		for _, id := range ids {
+			w.afterArchive(ctx, channel, id, now)
+		}
+		archived += len(ids)
+
+		// Each batch is its own short statement so the table is never locked for long
+		if len(ids) == 0 || len(ids) < w.config.BatchSize {
+			break
+		}
+	}
+
+	if archived > 0 {
+		w.logger.Info("Conversations archived for exceeding max age", map[string]interface{}{
+			"channel":  channel,
+			"archived": archived,
+		})
+	}
+
+	return archived
+}
+
+func (w *ArchivalWorker) afterArchive(ctx context.Context, channel domain.Channel, conversationID string, now time.Time) {
+	if w.cacheService != nil {
+		_ = w.cacheService.DeleteConversation(ctx, conversationID)
+	}
+
+	if w.eventPublisher == nil {
+		return
+	}
+
+	event := domain.MessageEvent{
+		Type:           "conversation.archived",
+		ConversationID: conversationID,
+		Data: domain.JSONB{
+			"reason":  "max_age",
+			"channel": channel,
+			"max_age": w.config.MaxAge[string(channel)].String(),
+		},
+		Timestamp: now,
+	}
+
+	if err := w.eventPublisher.PublishMessageEvent(ctx, event); err != nil {
+		w.logger.Error("Failed to publish conversation archived event", err)
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestArchivalConfig() config.ArchivalConfig {
+	return config.ArchivalConfig{
+		Enabled:      true,
+		ScanInterval: time.Second,
+		MaxAge: map[string]time.Duration{
+			"web":      30 * 24 * time.Hour,
+			"whatsapp": 0,
+		},
+		BatchSize: 2,
+	}
+}
+
+func TestArchivalWorker_ArchivesInBatches(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	publisher := &recordingEventPublisher{}
+
+	worker := NewArchivalWorker(mockConversationRepo, publisher, NewNoOpCacheService(), newTestArchivalConfig(), logger.NewLogger("debug"))
+
+	createdBefore := mock.MatchedBy(func(createdBefore time.Time) bool {
+		return createdBefore.Before(time.Now().Add(-29 * 24 * time.Hour))
+	})
+	mockConversationRepo.On("ArchiveCreatedBefore", mock.Anything, domain.ChannelWeb, createdBefore, mock.Anything, 2).Return([]string{"conv1", "conv2"}, nil).Once()
+	mockConversationRepo.On("ArchiveCreatedBefore", mock.Anything, domain.ChannelWeb, createdBefore, mock.Anything, 2).Return([]string{"conv3"}, nil).Once()
+
+	// Execute
+	archived := worker.RunOnce(context.Background())
+
+	// Assert
+	assert.Equal(t, 3, archived)
+	assert.Len(t, publisher.events, 3)
+	assert.Equal(t, "conversation.archived", publisher.events[0].Type)
+	assert.Equal(t, "max_age", publisher.events[0].Data["reason"])
+	mockConversationRepo.AssertExpectations(t)
+
+	// Channels without a max age are never touched
+	mockConversationRepo.AssertNotCalled(t, "ArchiveCreatedBefore", mock.Anything, domain.ChannelWhatsApp, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestArchivalWorker_DryRun(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	publisher := &recordingEventPublisher{}
+
+	cfg := newTestArchivalConfig()
+	cfg.DryRun = true
+	worker := NewArchivalWorker(mockConversationRepo, publisher, NewNoOpCacheService(), cfg, logger.NewLogger("debug"))
+
+	mockConversationRepo.On("CountCreatedBefore", mock.Anything, domain.ChannelWeb, mock.Anything).Return(int64(42), nil)
+
+	// Execute
+	wouldArchive := worker.RunOnce(context.Background())
+
+	// Assert
+	assert.Equal(t, 42, wouldArchive)
+	assert.Empty(t, publisher.events)
+	mockConversationRepo.AssertNotCalled(t, "ArchiveCreatedBefore", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestArchivalWorker_DefaultsNonPositiveBatchSize(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+
+	cfg := newTestArchivalConfig()
+	cfg.BatchSize = 0
+	worker := NewArchivalWorker(mockConversationRepo, NewNoOpEventPublisher(), NewNoOpCacheService(), cfg, logger.NewLogger("debug"))
+
+	mockConversationRepo.On("ArchiveCreatedBefore", mock.Anything, domain.ChannelWeb, mock.Anything, mock.Anything, defaultArchivalBatchSize).Return([]string{}, nil).Once()
+
+	// Execute
+	archived := worker.RunOnce(context.Background())
+
+	// Assert
+	assert.Equal(t, 0, archived)
+	mockConversationRepo.AssertExpectations(t)
+}
+
+func TestArchivalWorker_ArchivesInactiveConversations(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+
+	cfg := newTestArchivalConfig()
+	cfg.MaxAge = nil
+	cfg.InactiveDays = 90
+	worker := NewArchivalWorker(mockConversationRepo, NewNoOpEventPublisher(), NewNoOpCacheService(), cfg, logger.NewLogger("debug"))
+
+	olderThan := mock.MatchedBy(func(olderThan time.Time) bool {
+		return olderThan.Before(time.Now().Add(-89*24*time.Hour)) && olderThan.After(time.Now().Add(-91*24*time.Hour))
+	})
+	mockConversationRepo.On("ArchiveStale", mock.Anything, olderThan).Return(int64(7), nil).Once()
+
+	// Execute
+	archived := worker.RunOnce(context.Background())
+
+	// Assert
+	assert.Equal(t, 7, archived)
+	mockConversationRepo.AssertExpectations(t)
+
+	// The dry run only counts them
+	cfg.DryRun = true
+	worker = NewArchivalWorker(mockConversationRepo, NewNoOpEventPublisher(), NewNoOpCacheService(), cfg, logger.NewLogger("debug"))
+	mockConversationRepo.On("CountStale", mock.Anything, olderThan).Return(int64(3), nil).Once()
+
+	assert.Equal(t, 3, worker.RunOnce(context.Background()))
+	mockConversationRepo.AssertNumberOfCalls(t, "ArchiveStale", 1)
+}
+
+func TestArchivalWorker_StopsOnContextCancel(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockConversationRepo.On("ArchiveCreatedBefore", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]string{}, nil)
+
+	cfg := newTestArchivalConfig()
+	cfg.ScanInterval = 10 * time.Millisecond
+	worker := NewArchivalWorker(mockConversationRepo, NewNoOpEventPublisher(), NewNoOpCacheService(), cfg, logger.NewLogger("debug"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		worker.Start(ctx)
+		close(stopped)
+	}()
+
+	// Execute
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+
+	// Assert
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("archival worker did not stop after cancellation")
+	}
+}
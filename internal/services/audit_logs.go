@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// ErrInvalidAuditFilter indica una consulta de auditoría con un rango de fechas inválido
+var ErrInvalidAuditFilter = errors.New("invalid audit filter")
+
+// maxAuditLogsPage limita cuántas entradas de auditoría devuelve una consulta
+const maxAuditLogsPage = 100
+
+// ListAuditLogs devuelve las entradas de auditoría que cumplen los filtros, las más recientes primero
+func (s *messagingService) ListAuditLogs(ctx context.Context, filters domain.AuditLogFilters) ([]*domain.AuditLog, error) {
+	if s.auditRepo == nil {
+		return nil, fmt.Errorf("audit logs not available")
+	}
+	if !filters.From.IsZero() && !filters.To.IsZero() && !filters.From.Before(filters.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidAuditFilter)
+	}
+
+	if filters.Limit <= 0 || filters.Limit > maxAuditLogsPage {
+		filters.Limit = maxAuditLogsPage
+	}
+	if filters.Offset < 0 {
+		filters.Offset = 0
+	}
+
+	logs, err := s.auditRepo.List(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+
+	return logs, nil
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/google/uuid"
+)
+
+// autoCloseSenderID identifica los mensajes de cierre insertados por la regla de cierre automático
+const autoCloseSenderID = "auto_close"
+
+// AutoCloseRule cierra la conversación cuando un mensaje coincide con una frase de resolución
+type AutoCloseRule struct {
+	// Phrases son las frases que resuelven la conversación; una que empieza por "/" es un comando
+	// (p. ej. "/resolve") y admite texto después
+	Phrases []string
+	// SenderTypes limita qué remitentes pueden cerrar; vacío admite a todos salvo system
+	SenderTypes []domain.SenderType
+	// ClosingMessage se inserta como mensaje de sistema al cerrar; vacío no inserta nada
+	ClosingMessage string
+}
+
+// BuildAutoCloseRule construye la regla a partir de la configuración. Devuelve nil si está desactivada
+func BuildAutoCloseRule(cfg config.AutoCloseConfig) (*AutoCloseRule, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	rule := &AutoCloseRule{ClosingMessage: cfg.ClosingMessage}
+	for _, phrase := range cfg.Phrases {
+		if phrase = normalizeResolutionText(phrase); phrase != "" {
+			rule.Phrases = append(rule.Phrases, phrase)
+		}
+	}
+	if len(rule.Phrases) == 0 {
+		return nil, fmt.Errorf("at least one auto-close phrase is required")
+	}
+
+	for _, senderType := range cfg.SenderTypes {
+		switch domain.SenderType(senderType) {
+		case domain.SenderTypeUser, domain.SenderTypeAgent, domain.SenderTypeBot:
+			rule.SenderTypes = append(rule.SenderTypes, domain.SenderType(senderType))
+		default:
+			return nil, fmt.Errorf("unknown auto-close sender type %q", senderType)
+		}
+	}
+
+	return rule, nil
+}
+
+// Matches devuelve la frase con la que coincide el mensaje, o "" si no resuelve la conversación
+func (r *AutoCloseRule) Matches(message *domain.Message) string {
+	if message.SenderType == domain.SenderTypeSystem || message.ContentType != domain.ContentTypeText {
+		return ""
+	}
+	if len(r.SenderTypes) > 0 && !containsSenderType(r.SenderTypes, message.SenderType) {
+		return ""
+	}
+
+	content := normalizeResolutionText(message.Content)
+	for _, phrase := range r.Phrases {
+		if content == phrase {
+			return phrase
+		}
+		if strings.HasPrefix(phrase, "/") && strings.HasPrefix(content, phrase+" ") {
+			return phrase
+		}
+	}
+
+	return ""
+}
+
+// normalizeResolutionText compara sin distinguir mayúsculas ni la puntuación final, p. ej. "¡Resuelto!"
+func normalizeResolutionText(text string) string {
+	text = strings.ToLower(strings.TrimSpace(text))
+	return strings.TrimSpace(strings.Trim(text, ".!¡?¿ "))
+}
+
+func containsSenderType(senderTypes []domain.SenderType, senderType domain.SenderType) bool {
+	for _, candidate := range senderTypes {
+		if candidate == senderType {
+			return true
+		}
+	}
+	return false
+}
+
+// WithAutoClose cierra la conversación cuando un mensaje coincide con una frase de resolución de rule,
+// registra el cierre en la auditoría y, si la regla lo indica, inserta un mensaje de cierre. Una regla nil
+// no cambia nada
+func WithAutoClose(rule *AutoCloseRule) MessagingServiceOption {
+	return func(s *messagingService) {
+		if rule == nil {
+			return
+		}
+
+		s.sendHooks = append(s.sendHooks, NewSendHook("auto_close", SendHookPostPersist, func(ctx context.Context, send *SendContext) error {
+			if send.Conversation.Status == domain.ConversationStatusClosed {
+				return nil
+			}
+
+			phrase := rule.Matches(send.Message)
+			if phrase == "" {
+				return nil
+			}
+
+			return s.autoCloseConversation(ctx, rule, send, phrase)
+		}))
+	}
+}
+
+// autoCloseConversation cierra la conversación como lo haría su dueño, de modo que se aplican la
+// limitación de cambios de estado y el evento conversation.closed
+func (s *messagingService) autoCloseConversation(ctx context.Context, rule *AutoCloseRule, send *SendContext, phrase string) error {
+	conversation := send.Conversation
+	if err := s.UpdateConversationStatus(ctx, conversation.ID, domain.ConversationStatusClosed, conversation.UserID); err != nil {
+		return fmt.Errorf("failed to auto-close conversation: %w", err)
+	}
+	conversation.Status = domain.ConversationStatusClosed
+
+	s.log(ctx).Info("Conversation auto-closed", map[string]interface{}{
+		"conversation_id": conversation.ID,
+		"message_id":      send.Message.ID,
+		"phrase":          phrase,
+	})
+
+	if err := s.recordAutoCloseAudit(ctx, send, phrase); err != nil {
+		s.log(ctx).Error("Failed to record auto-close audit log", err)
+	}
+
+	if rule.ClosingMessage != "" {
+		if err := s.sendClosingMessage(ctx, conversation, rule.ClosingMessage); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sendClosingMessage inserta el mensaje de sistema que avisa del cierre
+func (s *messagingService) sendClosingMessage(ctx context.Context, conversation *domain.Conversation, content string) error {
+	message := &domain.Message{
+		ID:             uuid.New().String(),
+		ConversationID: conversation.ID,
+		SenderType:     domain.SenderTypeSystem,
+		SenderID:       autoCloseSenderID,
+		Content:        content,
+		ContentType:    domain.ContentTypeText,
+		Metadata:       domain.JSONB{"auto_close": true},
+		Timestamp:      time.Now(),
+	}
+
+	if err := s.messageRepo.Create(ctx, message); err != nil {
+		return fmt.Errorf("failed to create closing message: %w", err)
+	}
+
+	if s.eventPublisher != nil {
+		event := domain.MessageEvent{
+			Type:           "message.received",
+			ConversationID: conversation.ID,
+			Message:        *message,
+			Timestamp:      message.Timestamp,
+		}
+
+		if err := s.eventPublisher.PublishMessageEvent(ctx, event); err != nil {
+			s.log(ctx).Error("Failed to publish closing message event", err)
+		}
+	}
+
+	return nil
+}
+
+// recordAutoCloseAudit registra qué mensaje cerró la conversación
+func (s *messagingService) recordAutoCloseAudit(ctx context.Context, send *SendContext, phrase string) error {
+	if s.auditRepo == nil {
+		return nil
+	}
+
+	auditLog := newAuditLog(ctx, send.Message.SenderID, "CONVERSATION_AUTO_CLOSE", "conversation:"+send.Conversation.ID, map[string]interface{}{
+		"message_id":  send.Message.ID,
+		"sender_type": send.Message.SenderType,
+		"phrase":      phrase,
+	})
+
+	return s.auditRepo.Create(ctx, auditLog)
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoCloseRule_Matches(t *testing.T) {
+	rule, err := BuildAutoCloseRule(config.AutoCloseConfig{
+		Enabled:     true,
+		Phrases:     []string{"/resolve", "Resuelto"},
+		SenderTypes: []string{"user"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		message domain.Message
+		want    string
+	}{
+		{"command", domain.Message{SenderType: domain.SenderTypeUser, ContentType: domain.ContentTypeText, Content: "/resolve"}, "/resolve"},
+		{"command with note", domain.Message{SenderType: domain.SenderTypeUser, ContentType: domain.ContentTypeText, Content: "/Resolve pedido entregado"}, "/resolve"},
+		{"phrase ignores case and punctuation", domain.Message{SenderType: domain.SenderTypeUser, ContentType: domain.ContentTypeText, Content: " ¡RESUELTO! "}, "resuelto"},
+		{"phrase inside a sentence", domain.Message{SenderType: domain.SenderTypeUser, ContentType: domain.ContentTypeText, Content: "¿ya está resuelto?"}, ""},
+		{"command prefix of another word", domain.Message{SenderType: domain.SenderTypeUser, ContentType: domain.ContentTypeText, Content: "/resolved"}, ""},
+		{"sender type not allowed", domain.Message{SenderType: domain.SenderTypeBot, ContentType: domain.ContentTypeText, Content: "/resolve"}, ""},
+		{"system message", domain.Message{SenderType: domain.SenderTypeSystem, ContentType: domain.ContentTypeText, Content: "/resolve"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rule.Matches(&tt.message))
+		})
+	}
+}
+
+func TestBuildAutoCloseRule(t *testing.T) {
+	rule, err := BuildAutoCloseRule(config.AutoCloseConfig{Phrases: []string{"/resolve"}})
+	assert.NoError(t, err)
+	assert.Nil(t, rule)
+
+	_, err = BuildAutoCloseRule(config.AutoCloseConfig{Enabled: true, Phrases: []string{"/resolve"}, SenderTypes: []string{"system"}})
+	assert.Error(t, err)
+
+	_, err = BuildAutoCloseRule(config.AutoCloseConfig{Enabled: true, Phrases: []string{" ! "}})
+	assert.Error(t, err)
+}
+
+func TestMessagingService_SendMessage_AutoClosesOnResolution(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAuditRepo := new(MockAuditRepository)
+	publisher := &recordingEventPublisher{}
+
+	rule, err := BuildAutoCloseRule(config.AutoCloseConfig{Enabled: true, Phrases: []string{"/resolve"}, ClosingMessage: "Conversación cerrada"})
+	require.NoError(t, err)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		new(MockAttachmentRepository),
+		publisher,
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithAuditRepository(mockAuditRepo),
+		WithAutoClose(rule),
+	)
+
+	conversation := &domain.Conversation{ID: "conv123", UserID: "user123", Channel: domain.ChannelWeb, Status: domain.ConversationStatusActive}
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(conversation, nil)
+	mockConversationRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Conversation")).Return(nil)
+	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+	mockConversationRepo.On("TouchUpdatedAt", mock.Anything, mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.MatchedBy(func(log *domain.AuditLog) bool {
+		return log.Action == "CONVERSATION_AUTO_CLOSE" && log.Resource == "conversation:conv123" && log.Details["phrase"] == "/resolve"
+	})).Return(nil)
+
+	// Execute
+	_, err = service.SendMessage(context.Background(), SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "user123",
+		Content:        "/resolve entregado",
+		ContentType:    domain.ContentTypeText,
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, domain.ConversationStatusClosed, conversation.Status)
+	mockAuditRepo.AssertExpectations(t)
+
+	var closed bool
+	for _, event := range publisher.events {
+		closed = closed || event.Type == "conversation.closed"
+	}
+	assert.True(t, closed)
+
+	// The message and the closing system message
+	mockMessageRepo.AssertNumberOfCalls(t, "Create", 2)
+	closing := mockMessageRepo.Calls[len(mockMessageRepo.Calls)-1].Arguments.Get(1).(*domain.Message)
+	assert.Equal(t, domain.SenderTypeSystem, closing.SenderType)
+	assert.Equal(t, "Conversación cerrada", closing.Content)
+}
@@ -0,0 +1,281 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// autoResponderSenderID identifica como remitente los mensajes del respondedor automático
+const autoResponderSenderID = "auto_responder"
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+type businessWindow struct {
+	days  map[time.Weekday]bool
+	start int // Minutos desde medianoche, inclusivo
+	end   int // Minutos desde medianoche, exclusivo
+}
+
+// BusinessHours es el horario de atención de un canal en una zona horaria
+type BusinessHours struct {
+	windows  []businessWindow
+	location *time.Location
+}
+
+// ParseBusinessHours interpreta un horario como "mon-fri 09:00-18:00; sat 10:00-14:00". Cada tramo indica
+// días (rango o lista separada por comas) y un intervalo horario en la zona horaria loc
+func ParseBusinessHours(spec string, loc *time.Location) (*BusinessHours, error) {
+	hours := &BusinessHours{location: loc}
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.Fields(part)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid business hours %q, expected \"<days> <HH:MM-HH:MM>\"", part)
+		}
+
+		days, err := parseWeekdays(fields[0])
+		if err != nil {
+			return nil, err
+		}
+
+		start, end, err := parseTimeRange(fields[1])
+		if err != nil {
+			return nil, err
+		}
+
+		hours.windows = append(hours.windows, businessWindow{days: days, start: start, end: end})
+	}
+
+	if len(hours.windows) == 0 {
+		return nil, fmt.Errorf("business hours %q define no windows", spec)
+	}
+
+	return hours, nil
+}
+
+// IsOpen indica si t cae dentro de algún tramo del horario
+func (h *BusinessHours) IsOpen(t time.Time) bool {
+	local := t.In(h.location)
+	minute := local.Hour()*60 + local.Minute()
+	for _, window := range h.windows {
+		if window.days[local.Weekday()] && minute >= window.start && minute < window.end {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseWeekdays(spec string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	for _, item := range strings.Split(strings.ToLower(spec), ",") {
+		bounds := strings.SplitN(item, "-", 2)
+		first, ok := weekdayNames[bounds[0]]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", bounds[0])
+		}
+
+		last := first
+		if len(bounds) == 2 {
+			if last, ok = weekdayNames[bounds[1]]; !ok {
+				return nil, fmt.Errorf("invalid weekday %q", bounds[1])
+			}
+		}
+
+		// Ranges may wrap around the week, e.g. sat-sun or fri-mon
+		for day := first; ; day = (day + 1) % 7 {
+			days[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+
+	return days, nil
+}
+
+func parseTimeRange(spec string) (int, int, error) {
+	bounds := strings.SplitN(spec, "-", 2)
+	if len(bounds) != 2 {
+		return 0, 0, fmt.Errorf("invalid time range %q, expected HH:MM-HH:MM", spec)
+	}
+
+	start, err := time.Parse("15:04", bounds[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time %q: %w", bounds[0], err)
+	}
+
+	end, err := time.Parse("15:04", bounds[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time %q: %w", bounds[1], err)
+	}
+
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+	if endMinute == 0 {
+		endMinute = 24 * 60 // "18:00-00:00" runs until midnight
+	}
+	if endMinute <= startMinute {
+		return 0, 0, fmt.Errorf("invalid time range %q, end must be after start", spec)
+	}
+
+	return startMinute, endMinute, nil
+}
+
+// AutoResponderSchedule es el horario de un canal y el mensaje que se envía fuera de él
+type AutoResponderSchedule struct {
+	Hours   *BusinessHours
+	Message string
+}
+
+// BuildAutoResponderSchedules interpreta los horarios configurados de los canales que tienen mensaje
+func BuildAutoResponderSchedules(cfg config.AutoReplyConfig) (map[domain.Channel]AutoResponderSchedule, error) {
+	location, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auto responder timezone %q: %w", cfg.Timezone, err)
+	}
+
+	schedules := make(map[domain.Channel]AutoResponderSchedule)
+	for channel, channelConfig := range cfg.Channels {
+		if channelConfig.Message == "" {
+			continue
+		}
+
+		hours, err := ParseBusinessHours(channelConfig.Hours, location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid business hours for channel %s: %w", channel, err)
+		}
+
+		schedules[domain.Channel(channel)] = AutoResponderSchedule{Hours: hours, Message: channelConfig.Message}
+	}
+
+	return schedules, nil
+}
+
+// AutoResponder inserta un mensaje de sistema en las conversaciones que se abren o reciben un mensaje
+// del cliente fuera del horario de atención de su canal. Cada conversación recibe como mucho una
+// respuesta por cooldown, por lo que los mensajes siguientes de la misma noche no la repiten
+type AutoResponder struct {
+	conversationRepo domain.ConversationRepository
+	messageRepo      domain.MessageRepository
+	eventPublisher   EventPublisher
+	schedules        map[domain.Channel]AutoResponderSchedule
+	cooldown         time.Duration
+	logger           logger.Logger
+	now              func() time.Time
+}
+
+func NewAutoResponder(
+	conversationRepo domain.ConversationRepository,
+	messageRepo domain.MessageRepository,
+	eventPublisher EventPublisher,
+	schedules map[domain.Channel]AutoResponderSchedule,
+	cooldown time.Duration,
+	logger logger.Logger,
+) *AutoResponder {
+	return &AutoResponder{
+		conversationRepo: conversationRepo,
+		messageRepo:      messageRepo,
+		eventPublisher:   eventPublisher,
+		schedules:        schedules,
+		cooldown:         cooldown,
+		logger:           logger,
+		now:              time.Now,
+	}
+}
+
+// Respond envía la respuesta automática si el canal está fuera de horario y la conversación no la
+// recibió dentro del cooldown. Devuelve el mensaje insertado o nil si no correspondía responder
+func (r *AutoResponder) Respond(ctx context.Context, conversation *domain.Conversation) (*domain.Message, error) {
+	schedule, ok := r.schedules[conversation.Channel]
+	if !ok {
+		return nil, nil
+	}
+
+	now := r.now()
+	if schedule.Hours.IsOpen(now) {
+		return nil, nil
+	}
+
+	// The claim is atomic, so concurrent messages cannot both trigger a reply
+	claimed, err := r.conversationRepo.ClaimAutoReply(ctx, conversation.ID, now.Add(-r.cooldown), now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim auto reply: %w", err)
+	}
+	if !claimed {
+		return nil, nil
+	}
+
+	message := &domain.Message{
+		ID:             uuid.New().String(),
+		ConversationID: conversation.ID,
+		SenderType:     domain.SenderTypeSystem,
+		SenderID:       autoResponderSenderID,
+		Content:        schedule.Message,
+		ContentType:    domain.ContentTypeText,
+		Metadata:       domain.JSONB{"auto_response": true},
+		Timestamp:      now,
+	}
+
+	if err := r.messageRepo.Create(ctx, message); err != nil {
+		return nil, fmt.Errorf("failed to create auto response: %w", err)
+	}
+
+	if r.eventPublisher != nil {
+		event := domain.MessageEvent{
+			Type:           "message.received",
+			ConversationID: conversation.ID,
+			Message:        *message,
+			Timestamp:      now,
+		}
+
+		if err := r.eventPublisher.PublishMessageEvent(ctx, event); err != nil {
+			r.logger.Error("Failed to publish auto response event", err)
+		}
+	}
+
+	r.logger.Info("Auto response sent", map[string]interface{}{
+		"conversation_id": conversation.ID,
+		"channel":         conversation.Channel,
+	})
+
+	return message, nil
+}
+
+// WithAutoResponder responde automáticamente fuera de horario al crear conversaciones y con el
+// primer mensaje del cliente. Un responder nil no cambia nada
+func WithAutoResponder(responder *AutoResponder) MessagingServiceOption {
+	return func(s *messagingService) {
+		if responder == nil {
+			return
+		}
+
+		s.autoResponder = responder
+		s.sendHooks = append(s.sendHooks, NewSendHook("auto_responder", SendHookPostPersist, func(ctx context.Context, send *SendContext) error {
+			if send.Message.SenderType != domain.SenderTypeUser {
+				return nil
+			}
+			_, err := responder.Respond(ctx, send.Conversation)
+			return err
+		}))
+	}
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBusinessHours(t *testing.T) {
+	hours, err := ParseBusinessHours("mon-fri 09:00-18:00; sat 10:00-14:00", time.UTC)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		at   time.Time
+		open bool
+	}{
+		{"weekday morning", time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC), true},
+		{"weekday closing time", time.Date(2026, 10, 14, 18, 0, 0, 0, time.UTC), false},
+		{"weekday night", time.Date(2026, 10, 14, 22, 30, 0, 0, time.UTC), false},
+		{"saturday window", time.Date(2026, 10, 17, 11, 0, 0, 0, time.UTC), true},
+		{"saturday afternoon", time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC), false},
+		{"sunday", time.Date(2026, 10, 18, 11, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.open, hours.IsOpen(tt.at))
+		})
+	}
+}
+
+func TestParseBusinessHours_UsesLocation(t *testing.T) {
+	location := time.FixedZone("UTC-5", -5*60*60)
+	hours, err := ParseBusinessHours("mon-fri 09:00-18:00", location)
+	require.NoError(t, err)
+
+	// 13:00 UTC is 08:00 in UTC-5
+	assert.False(t, hours.IsOpen(time.Date(2026, 10, 14, 13, 0, 0, 0, time.UTC)))
+	assert.True(t, hours.IsOpen(time.Date(2026, 10, 14, 14, 0, 0, 0, time.UTC)))
+}
+
+func TestParseBusinessHours_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "mon-fri", "mon-fry 09:00-18:00", "mon 9-18", "mon 18:00-09:00"} {
+		t.Run(spec, func(t *testing.T) {
+			_, err := ParseBusinessHours(spec, time.UTC)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func newTestAutoResponder(t *testing.T, conversationRepo domain.ConversationRepository, messageRepo domain.MessageRepository, publisher EventPublisher, now time.Time) *AutoResponder {
+	hours, err := ParseBusinessHours("mon-fri 09:00-18:00", time.UTC)
+	require.NoError(t, err)
+
+	responder := NewAutoResponder(
+		conversationRepo,
+		messageRepo,
+		publisher,
+		map[domain.Channel]AutoResponderSchedule{
+			domain.ChannelWhatsApp: {Hours: hours, Message: "Estamos fuera de horario, te responderemos pronto"},
+		},
+		12*time.Hour,
+		logger.NewLogger("debug"),
+	)
+	responder.now = func() time.Time { return now }
+
+	return responder
+}
+
+func TestAutoResponder_RespondsOffHours(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	publisher := &recordingEventPublisher{}
+	now := time.Date(2026, 10, 14, 22, 30, 0, 0, time.UTC)
+	responder := newTestAutoResponder(t, mockConversationRepo, mockMessageRepo, publisher, now)
+
+	conversation := &domain.Conversation{ID: "conv1", Channel: domain.ChannelWhatsApp}
+	mockConversationRepo.On("ClaimAutoReply", mock.Anything, "conv1", now.Add(-12*time.Hour), now).Return(true, nil)
+	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+
+	// Execute
+	message, err := responder.Respond(context.Background(), conversation)
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, message)
+	assert.Equal(t, domain.SenderTypeSystem, message.SenderType)
+	assert.Equal(t, "auto_responder", message.SenderID)
+	assert.Equal(t, true, message.Metadata["auto_response"])
+	assert.Len(t, publisher.events, 1)
+	mockMessageRepo.AssertExpectations(t)
+}
+
+func TestAutoResponder_SkipsDuringBusinessHours(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	now := time.Date(2026, 10, 14, 11, 0, 0, 0, time.UTC)
+	responder := newTestAutoResponder(t, mockConversationRepo, mockMessageRepo, NewNoOpEventPublisher(), now)
+
+	// Execute
+	message, err := responder.Respond(context.Background(), &domain.Conversation{ID: "conv1", Channel: domain.ChannelWhatsApp})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Nil(t, message)
+	mockConversationRepo.AssertNotCalled(t, "ClaimAutoReply", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAutoResponder_DoesNotRepeatWithinCooldown(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	now := time.Date(2026, 10, 14, 22, 30, 0, 0, time.UTC)
+	responder := newTestAutoResponder(t, mockConversationRepo, mockMessageRepo, NewNoOpEventPublisher(), now)
+
+	mockConversationRepo.On("ClaimAutoReply", mock.Anything, "conv1", mock.Anything, now).Return(false, nil)
+
+	// Execute
+	message, err := responder.Respond(context.Background(), &domain.Conversation{ID: "conv1", Channel: domain.ChannelWhatsApp})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Nil(t, message)
+	mockMessageRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_CreateConversation_AutoResponds(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	now := time.Date(2026, 10, 17, 20, 0, 0, 0, time.UTC)
+	responder := newTestAutoResponder(t, mockConversationRepo, mockMessageRepo, NewNoOpEventPublisher(), now)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithAutoResponder(responder),
+	)
+
+	mockConversationRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Conversation")).Return(nil)
+	mockConversationRepo.On("ClaimAutoReply", mock.Anything, mock.AnythingOfType("string"), mock.Anything, now).Return(true, nil)
+	mockMessageRepo.On("Create", mock.Anything, mock.MatchedBy(func(m *domain.Message) bool {
+		return m.SenderType == domain.SenderTypeSystem
+	})).Return(nil)
+
+	// Execute
+	conversation, err := service.CreateConversation(context.Background(), "user123", domain.ChannelWhatsApp, nil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.NotNil(t, conversation)
+	mockConversationRepo.AssertExpectations(t)
+	mockMessageRepo.AssertExpectations(t)
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/company/microservice-template/internal/config"
 	"github.com/company/microservice-template/internal/domain"
 	"github.com/company/microservice-template/pkg/logger"
 	"github.com/redis/go-redis/v9"
@@ -15,26 +17,49 @@ type CacheService interface {
 	GetConversation(ctx context.Context, id string) (*domain.Conversation, error)
 	SetConversation(ctx context.Context, conversation *domain.Conversation) error
 	DeleteConversation(ctx context.Context, id string) error
-	GetMessages(ctx context.Context, conversationID string) ([]domain.Message, error)
-	SetMessages(ctx context.Context, conversationID string, messages []domain.Message) error
+	// GetMessages devuelve una página de mensajes cacheada; cada combinación de paginación es una página distinta
+	GetMessages(ctx context.Context, conversationID string, pagination domain.PaginationParams) ([]domain.Message, error)
+	SetMessages(ctx context.Context, conversationID string, pagination domain.PaginationParams, messages []domain.Message) error
+	// DeleteMessages descarta todas las páginas cacheadas de la conversación
 	DeleteMessages(ctx context.Context, conversationID string) error
+	// ConversationListVersion devuelve el contador de cambios de las conversaciones del usuario; el
+	// listado se guarda y se lee con la versión obtenida antes de consultar la base de datos
+	ConversationListVersion(ctx context.Context, userID string) (int64, error)
+	GetConversationList(ctx context.Context, userID string, version int64, filters domain.ConversationFilters) ([]domain.Conversation, error)
+	SetConversationList(ctx context.Context, userID string, version int64, filters domain.ConversationFilters, conversations []domain.Conversation) error
+	// InvalidateConversationList incrementa el contador del usuario, con lo que sus listados cacheados dejan de leerse
+	InvalidateConversationList(ctx context.Context, userID string) error
 }
 
+// conversationOwnerExpiration es lo que se recuerda el dueño de una conversación cacheada, para que
+// DeleteConversation pueda invalidar su listado aunque quien la modifica solo conozca el ID
+const conversationOwnerExpiration = 24 * time.Hour
+
 type redisCacheService struct {
-	client     *redis.Client
-	logger     logger.Logger
-	expiration time.Duration
+	client            *redis.Client
+	logger            logger.Logger
+	expiration        time.Duration
+	messageExpiration time.Duration
+	listExpiration    time.Duration
 }
 
-func NewRedisCacheService(client *redis.Client, logger logger.Logger) CacheService {
+// NewRedisCacheService crea la caché en Redis con las duraciones de cfg; una duración cero desactiva
+// esa parte de la caché
+func NewRedisCacheService(client *redis.Client, logger logger.Logger, cfg config.RedisConfig) CacheService {
 	return &redisCacheService{
-		client:     client,
-		logger:     logger,
-		expiration: 30 * time.Minute, // Default cache expiration
+		client:            client,
+		logger:            logger,
+		expiration:        cfg.ConversationCacheTTL,
+		messageExpiration: cfg.MessageCacheTTL,
+		listExpiration:    cfg.ConversationListCacheTTL,
 	}
 }
 
 func (c *redisCacheService) GetConversation(ctx context.Context, id string) (*domain.Conversation, error) {
+	if c.expiration <= 0 {
+		return nil, fmt.Errorf("conversation cache disabled")
+	}
+
 	key := fmt.Sprintf("conversation:%s", id)
 	
 	data, err := c.client.Get(ctx, key).Result()
@@ -55,6 +80,10 @@ func (c *redisCacheService) GetConversation(ctx context.Context, id string) (*do
 }
 
 func (c *redisCacheService) SetConversation(ctx context.Context, conversation *domain.Conversation) error {
+	if c.expiration <= 0 {
+		return nil
+	}
+
 	key := fmt.Sprintf("conversation:%s", conversation.ID)
 	
 	data, err := json.Marshal(conversation)
@@ -63,7 +92,10 @@ func (c *redisCacheService) SetConversation(ctx context.Context, conversation *d
 		return err
 	}
 
-	if err := c.client.Set(ctx, key, data, c.expiration).Err(); err != nil {
+	pipe := c.client.Pipeline()
+	pipe.Set(ctx, key, data, c.expiration)
+	pipe.Set(ctx, conversationOwnerKey(conversation.ID), conversation.UserID, conversationOwnerExpiration)
+	if _, err := pipe.Exec(ctx); err != nil {
 		c.logger.Error("Failed to set conversation in cache", err)
 		return err
 	}
@@ -79,13 +111,27 @@ func (c *redisCacheService) DeleteConversation(ctx context.Context, id string) e
 		return err
 	}
 
-	return nil
+	// Any change to the conversation also changes its owner's list
+	owner, err := c.client.Get(ctx, conversationOwnerKey(id)).Result()
+	if err == redis.Nil {
+		return nil
+	}
+	if err != nil {
+		c.logger.Error("Failed to get conversation owner from cache", err)
+		return err
+	}
+
+	return c.InvalidateConversationList(ctx, owner)
 }
 
-func (c *redisCacheService) GetMessages(ctx context.Context, conversationID string) ([]domain.Message, error) {
+func (c *redisCacheService) GetMessages(ctx context.Context, conversationID string, pagination domain.PaginationParams) ([]domain.Message, error) {
+	if c.messageExpiration <= 0 {
+		return nil, fmt.Errorf("message cache disabled")
+	}
+
 	key := fmt.Sprintf("messages:%s", conversationID)
 	
-	data, err := c.client.Get(ctx, key).Result()
+	data, err := c.client.HGet(ctx, key, messagePageField(pagination)).Result()
 	if err != nil {
 		if err == redis.Nil {
 			return nil, fmt.Errorf("messages not found in cache")
@@ -102,7 +148,11 @@ func (c *redisCacheService) GetMessages(ctx context.Context, conversationID stri
 	return messages, nil
 }
 
-func (c *redisCacheService) SetMessages(ctx context.Context, conversationID string, messages []domain.Message) error {
+func (c *redisCacheService) SetMessages(ctx context.Context, conversationID string, pagination domain.PaginationParams, messages []domain.Message) error {
+	if c.messageExpiration <= 0 {
+		return nil
+	}
+
 	key := fmt.Sprintf("messages:%s", conversationID)
 	
 	data, err := json.Marshal(messages)
@@ -111,9 +161,11 @@ func (c *redisCacheService) SetMessages(ctx context.Context, conversationID stri
 		return err
 	}
 
-	// Cache messages for shorter time
-	expiration := 10 * time.Minute
-	if err := c.client.Set(ctx, key, data, expiration).Err(); err != nil {
+	// All pages share one hash, so DeleteMessages drops them together
+	pipe := c.client.Pipeline()
+	pipe.HSet(ctx, key, messagePageField(pagination), data)
+	pipe.Expire(ctx, key, c.messageExpiration)
+	if _, err := pipe.Exec(ctx); err != nil {
 		c.logger.Error("Failed to set messages in cache", err)
 		return err
 	}
@@ -132,6 +184,102 @@ func (c *redisCacheService) DeleteMessages(ctx context.Context, conversationID s
 	return nil
 }
 
+func (c *redisCacheService) ConversationListVersion(ctx context.Context, userID string) (int64, error) {
+	version, err := c.client.Get(ctx, conversationListVersionKey(userID)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return version, err
+}
+
+func (c *redisCacheService) GetConversationList(ctx context.Context, userID string, version int64, filters domain.ConversationFilters) ([]domain.Conversation, error) {
+	if c.listExpiration <= 0 {
+		return nil, fmt.Errorf("conversation list cache disabled")
+	}
+
+	data, err := c.client.Get(ctx, conversationListKey(userID, version, filters)).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, fmt.Errorf("conversation list not found in cache")
+		}
+		return nil, err
+	}
+
+	var conversations []domain.Conversation
+	if err := json.Unmarshal([]byte(data), &conversations); err != nil {
+		c.logger.Error("Failed to unmarshal cached conversation list", err)
+		return nil, err
+	}
+
+	return conversations, nil
+}
+
+func (c *redisCacheService) SetConversationList(ctx context.Context, userID string, version int64, filters domain.ConversationFilters, conversations []domain.Conversation) error {
+	if c.listExpiration <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(conversations)
+	if err != nil {
+		c.logger.Error("Failed to marshal conversation list for cache", err)
+		return err
+	}
+
+	pipe := c.client.Pipeline()
+	pipe.Set(ctx, conversationListKey(userID, version, filters), data, c.listExpiration)
+	for _, conversation := range conversations {
+		pipe.Set(ctx, conversationOwnerKey(conversation.ID), userID, conversationOwnerExpiration)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		c.logger.Error("Failed to set conversation list in cache", err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *redisCacheService) InvalidateConversationList(ctx context.Context, userID string) error {
+	if err := c.client.Incr(ctx, conversationListVersionKey(userID)).Err(); err != nil {
+		c.logger.Error("Failed to invalidate conversation list in cache", err)
+		return err
+	}
+
+	return nil
+}
+
+// conversationListKey incluye la versión del usuario y cada filtro, así que una página cacheada
+// nunca se sirve para otra consulta ni después de un cambio
+func conversationListKey(userID string, version int64, filters domain.ConversationFilters) string {
+	return fmt.Sprintf("conversation_list:%s:v%d:%s:%s:%d:%d:%t:%s",
+		userID, version, filters.Channel, filters.Status, filters.Limit, filters.Offset, filters.IncludeReadState,
+		strings.Join(filters.Tags, ","))
+}
+
+// messagePageField identifica la página dentro del hash de la conversación con todo lo que cambia su contenido
+func messagePageField(pagination domain.PaginationParams) string {
+	excluded := make([]string, len(pagination.ExcludeSenderTypes))
+	for i, senderType := range pagination.ExcludeSenderTypes {
+		excluded[i] = string(senderType)
+	}
+
+	before := ""
+	if pagination.Before != nil {
+		before = pagination.Before.Timestamp.UTC().Format(time.RFC3339Nano) + "/" + pagination.Before.ID
+	}
+
+	return fmt.Sprintf("%d:%d:%s:%s:%t:%t:%s:%s",
+		pagination.Limit, pagination.Offset, pagination.SortBy, pagination.Order, pagination.PinnedFirst,
+		pagination.IncludeDeleted, strings.Join(excluded, ","), before)
+}
+
+func conversationListVersionKey(userID string) string {
+	return fmt.Sprintf("conversation_list_version:%s", userID)
+}
+
+func conversationOwnerKey(conversationID string) string {
+	return fmt.Sprintf("conversation_owner:%s", conversationID)
+}
+
 // NoOpCacheService for when caching is disabled
 type noOpCacheService struct{}
 
@@ -151,14 +299,30 @@ func (c *noOpCacheService) DeleteConversation(ctx context.Context, id string) er
 	return nil
 }
 
-func (c *noOpCacheService) GetMessages(ctx context.Context, conversationID string) ([]domain.Message, error) {
+func (c *noOpCacheService) GetMessages(ctx context.Context, conversationID string, pagination domain.PaginationParams) ([]domain.Message, error) {
 	return nil, fmt.Errorf("cache disabled")
 }
 
-func (c *noOpCacheService) SetMessages(ctx context.Context, conversationID string, messages []domain.Message) error {
+func (c *noOpCacheService) SetMessages(ctx context.Context, conversationID string, pagination domain.PaginationParams, messages []domain.Message) error {
 	return nil
 }
 
 func (c *noOpCacheService) DeleteMessages(ctx context.Context, conversationID string) error {
 	return nil
-}
\ No newline at end of file
+}
+
+func (c *noOpCacheService) ConversationListVersion(ctx context.Context, userID string) (int64, error) {
+	return 0, nil
+}
+
+func (c *noOpCacheService) GetConversationList(ctx context.Context, userID string, version int64, filters domain.ConversationFilters) ([]domain.Conversation, error) {
+	return nil, fmt.Errorf("cache disabled")
+}
+
+func (c *noOpCacheService) SetConversationList(ctx context.Context, userID string, version int64, filters domain.ConversationFilters, conversations []domain.Conversation) error {
+	return nil
+}
+
+func (c *noOpCacheService) InvalidateConversationList(ctx context.Context, userID string) error {
+	return nil
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// ErrInvalidDeliveryMode indica un delivery_mode desconocido en la petición de envío
+var ErrInvalidDeliveryMode = errors.New("invalid delivery mode")
+
+// DeliveryMode indica si SendMessage espera a que el proveedor del canal acepte el mensaje
+type DeliveryMode string
+
+const (
+	// DeliveryModeAsync responde en cuanto el mensaje queda guardado, con estado sent, y lo entrega en segundo plano
+	DeliveryModeAsync DeliveryMode = "async"
+	// DeliveryModeSync espera la respuesta del proveedor, como mucho el timeout de entrega, y devuelve el estado final
+	DeliveryModeSync DeliveryMode = "sync"
+)
+
+func isValidDeliveryMode(mode DeliveryMode) bool {
+	return mode == "" || mode == DeliveryModeAsync || mode == DeliveryModeSync
+}
+
+// channelDelivery entrega los mensajes salientes al proveedor del canal de su conversación
+type channelDelivery struct {
+	senders      ChannelSenders
+	timeout      time.Duration
+	retryBackoff time.Duration
+}
+
+// WithChannelDelivery entrega cada mensaje saliente con el sender del canal de la conversación una vez
+// guardado. timeout limita cada entrega, y con ello la espera de un envío sync; una entrega fallida queda
+// en failed para que el worker de reintentos la recoja pasado retryBackoff
+func WithChannelDelivery(senders ChannelSenders, timeout time.Duration, retryBackoff time.Duration) MessagingServiceOption {
+	return func(s *messagingService) {
+		if len(senders) == 0 {
+			return
+		}
+
+		s.channelDelivery = &channelDelivery{
+			senders:      senders,
+			timeout:      timeout,
+			retryBackoff: retryBackoff,
+		}
+		s.sendHooks = append(s.sendHooks, NewSendHook("channel_delivery", SendHookPostPersist, s.deliverToChannel))
+	}
+}
+
+// isOutboundMessage indica si el mensaje va hacia el cliente: solo salen los de agentes y bots, y nunca los
+// que llegaron ya con el identificador del proveedor
+func isOutboundMessage(message *domain.Message) bool {
+	switch message.SenderType {
+	case domain.SenderTypeAgent, domain.SenderTypeBot:
+		return inboundProviderMessageID(message.Metadata) == ""
+	default:
+		return false
+	}
+}
+
+func (s *messagingService) deliverToChannel(ctx context.Context, send *SendContext) error {
+	sender, ok := s.channelDelivery.senders[send.Conversation.Channel]
+	if !ok || !isOutboundMessage(send.Message) {
+		return nil
+	}
+
+	if send.Request.DeliveryMode == DeliveryModeSync {
+		// The caller waits at most the delivery timeout, including any wait for the channel's rate limit
+		sendCtx, cancel := context.WithTimeout(ctx, s.channelDelivery.timeout)
+		defer cancel()
+		return s.recordChannelDelivery(ctx, send.Message, s.sendToChannel(sendCtx, sender, send.Conversation, send.Message))
+	}
+
+	// The background delivery works on its own copies: the response and later hooks still use send
+	conversation := *send.Conversation
+	message := copyOutboundMessage(send.Message)
+	send.Message.DeliveryStatus = domain.DeliveryStatusSent
+
+	go func() {
+		// The request context ends with the response; a delivery queued behind the channel's rate limit waits its turn
+		ctx := context.WithoutCancel(ctx)
+
+		if err := s.recordChannelDelivery(ctx, message, s.sendToChannel(ctx, sender, &conversation, message)); err != nil {
+			s.log(ctx).Error("Channel delivery failed", map[string]interface{}{
+				"message_id": message.ID,
+				"channel":    conversation.Channel,
+				"error":      err.Error(),
+			})
+		}
+	}()
+
+	return nil
+}
+
+// recordChannelDelivery guarda el resultado de la entrega en el mensaje y devuelve el error de envío, si lo hubo
+func (s *messagingService) recordChannelDelivery(ctx context.Context, message *domain.Message, sendErr error) error {
+	message.DeliveryAttempts++
+	recordDeliveryMetadata(message, sendErr)
+	if sendErr == nil {
+		message.DeliveryStatus = domain.DeliveryStatusSent
+		message.NextRetryAt = nil
+	} else {
+		nextRetry := time.Now().Add(s.channelDelivery.retryBackoff)
+		message.DeliveryStatus = domain.DeliveryStatusFailed
+		message.NextRetryAt = &nextRetry
+	}
+
+	if err := s.messageRepo.Update(ctx, message); err != nil {
+		return fmt.Errorf("failed to record channel delivery: %w", err)
+	}
+	if s.cacheService != nil {
+		_ = s.cacheService.DeleteMessages(ctx, message.ConversationID)
+	}
+
+	if sendErr != nil {
+		return fmt.Errorf("failed to deliver message: %w", sendErr)
+	}
+	return nil
+}
+
+// recordDeliveryMetadata deja en los metadatos el motivo del último fallo de entrega, visible para quien solo
+// lee metadata; una entrega correcta lo borra y el estado queda solo en delivery_status
+func recordDeliveryMetadata(message *domain.Message, sendErr error) {
+	if sendErr == nil {
+		delete(message.Metadata, domain.MetadataDeliveryStatus)
+		delete(message.Metadata, domain.MetadataDeliveryError)
+		return
+	}
+
+	if message.Metadata == nil {
+		message.Metadata = make(domain.JSONB)
+	}
+	message.Metadata[domain.MetadataDeliveryStatus] = string(domain.DeliveryStatusFailed)
+	message.Metadata[domain.MetadataDeliveryError] = sendErr.Error()
+}
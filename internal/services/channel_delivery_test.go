@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func outboundRequest(mode DeliveryMode) SendMessageRequest {
+	return SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeBot,
+		SenderID:       "user123",
+		Content:        "Su pedido ha salido",
+		ContentType:    domain.ContentTypeText,
+		DeliveryMode:   mode,
+	}
+}
+
+func TestMessagingService_SendMessage_SyncDelivery(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockSender := new(MockChannelSender)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo}, WithChannelDelivery(ChannelSenders{domain.ChannelWhatsApp: mockSender}, time.Second, time.Minute))
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123", Channel: domain.ChannelWhatsApp}, nil)
+	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+	mockConversationRepo.On("TouchUpdatedAt", mock.Anything, mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+	mockMessageRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+	mockSender.On("Send", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	mockSender.On("Send", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("provider unavailable")).Once()
+
+	// Execute: the response carries the provider's result
+	message, err := service.SendMessage(context.Background(), outboundRequest(DeliveryModeSync))
+	require.NoError(t, err)
+	assert.Equal(t, domain.DeliveryStatusSent, message.DeliveryStatus)
+	assert.Equal(t, 1, message.DeliveryAttempts)
+
+	// A failed delivery is still stored and left for the retry worker
+	message, err = service.SendMessage(context.Background(), outboundRequest(DeliveryModeSync))
+	require.NoError(t, err)
+	assert.Equal(t, domain.DeliveryStatusFailed, message.DeliveryStatus)
+	require.NotNil(t, message.NextRetryAt)
+	assert.Equal(t, "failed", message.Metadata[domain.MetadataDeliveryStatus])
+	assert.Contains(t, message.Metadata[domain.MetadataDeliveryError], "provider unavailable")
+	mockMessageRepo.AssertNumberOfCalls(t, "Update", 2)
+}
+
+func TestMessagingService_SendMessage_AsyncDelivery(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockSender := new(MockChannelSender)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo}, WithChannelDelivery(ChannelSenders{domain.ChannelWhatsApp: mockSender}, time.Second, time.Minute))
+
+	recorded := make(chan *domain.Message, 1)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123", Channel: domain.ChannelWhatsApp}, nil)
+	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+	mockConversationRepo.On("TouchUpdatedAt", mock.Anything, mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+	mockMessageRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Message")).Run(func(args mock.Arguments) {
+		recorded <- args.Get(1).(*domain.Message)
+	}).Return(nil)
+	mockSender.On("Send", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("provider unavailable"))
+
+	// Execute: the call returns before the provider answers
+	message, err := service.SendMessage(context.Background(), outboundRequest(""))
+	require.NoError(t, err)
+	assert.Equal(t, domain.DeliveryStatusSent, message.DeliveryStatus)
+
+	// The background delivery records its own outcome
+	select {
+	case stored := <-recorded:
+		assert.Equal(t, message.ID, stored.ID)
+		assert.Equal(t, domain.DeliveryStatusFailed, stored.DeliveryStatus)
+	case <-time.After(time.Second):
+		t.Fatal("background delivery was not recorded")
+	}
+}
+
+func TestMessagingService_SendMessage_DeliveryModeValidation(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockSender := new(MockChannelSender)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo}, WithChannelDelivery(ChannelSenders{domain.ChannelWhatsApp: mockSender}, time.Second, time.Minute))
+
+	// Execute
+	_, err := service.SendMessage(context.Background(), outboundRequest("eventually"))
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidDeliveryMode)
+	mockMessageRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_SendMessage_InboundNotDelivered(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockSender := new(MockChannelSender)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo}, WithChannelDelivery(ChannelSenders{domain.ChannelWhatsApp: mockSender}, time.Second, time.Minute))
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123", Channel: domain.ChannelWhatsApp}, nil)
+	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+	mockConversationRepo.On("TouchUpdatedAt", mock.Anything, mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+
+	req := outboundRequest(DeliveryModeSync)
+	req.SenderType = domain.SenderTypeUser
+	req.Metadata = map[string]interface{}{domain.MetadataProviderMessageID: "wamid.1"}
+
+	// Execute: a message that came from the provider is not sent back to it
+	message, err := service.SendMessage(context.Background(), req)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Empty(t, message.DeliveryStatus)
+	mockSender.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_SendMessage_DeliversAgentAndBotMessagesToChannelSenders(t *testing.T) {
+	tests := []struct {
+		name       string
+		channel    domain.Channel
+		senderType domain.SenderType
+		delivered  bool
+	}{
+		{name: "agent on whatsapp", channel: domain.ChannelWhatsApp, senderType: domain.SenderTypeAgent, delivered: true},
+		{name: "bot on whatsapp", channel: domain.ChannelWhatsApp, senderType: domain.SenderTypeBot, delivered: true},
+		{name: "customer on whatsapp", channel: domain.ChannelWhatsApp, senderType: domain.SenderTypeUser},
+		{name: "system on whatsapp", channel: domain.ChannelWhatsApp, senderType: domain.SenderTypeSystem},
+		{name: "agent on web", channel: domain.ChannelWeb, senderType: domain.SenderTypeAgent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup: only whatsapp has an adapter, as when the web channel is served by the client apps
+			mockConversationRepo := new(MockConversationRepository)
+			mockMessageRepo := new(MockMessageRepository)
+			mockSender := new(MockChannelSender)
+			service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo}, WithChannelDelivery(ChannelSenders{domain.ChannelWhatsApp: mockSender}, time.Second, time.Minute))
+
+			mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123", Channel: tt.channel}, nil)
+			mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+			mockConversationRepo.On("TouchUpdatedAt", mock.Anything, mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+			mockMessageRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+			mockSender.On("Send", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			req := outboundRequest(DeliveryModeSync)
+			req.SenderType = tt.senderType
+
+			// Execute
+			message, err := service.SendMessage(context.Background(), req)
+
+			// Assert
+			require.NoError(t, err)
+			if tt.delivered {
+				mockSender.AssertNumberOfCalls(t, "Send", 1)
+				assert.Equal(t, domain.DeliveryStatusSent, message.DeliveryStatus)
+			} else {
+				mockSender.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything)
+				assert.Empty(t, message.DeliveryStatus)
+			}
+		})
+	}
+}
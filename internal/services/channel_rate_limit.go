@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// channelRateLimiter combina un token bucket, que fija el ritmo de entregas por segundo, con un máximo de
+// entregas simultáneas. Las entregas que exceden la capacidad esperan su turno en vez de fallar
+type channelRateLimiter struct {
+	rate  float64 // tokens por segundo; cero no limita el ritmo
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	slots chan struct{} // nil si no hay máximo de entregas simultáneas
+}
+
+func newChannelRateLimiter(limit config.DeliveryRateLimit) *channelRateLimiter {
+	limiter := &channelRateLimiter{rate: limit.Rate}
+
+	if limit.Rate > 0 {
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = int(math.Max(1, math.Ceil(limit.Rate)))
+		}
+		limiter.burst = float64(burst)
+		limiter.tokens = limiter.burst
+		limiter.last = time.Now()
+	}
+
+	if limit.MaxConcurrent > 0 {
+		limiter.slots = make(chan struct{}, limit.MaxConcurrent)
+	}
+
+	return limiter
+}
+
+// acquire espera una plaza libre y un token, o a que termine ctx. release libera la plaza al acabar la entrega
+func (l *channelRateLimiter) acquire(ctx context.Context) (release func(), err error) {
+	release = func() {}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+			release = func() { <-l.slots }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if err := l.waitToken(ctx); err != nil {
+		release()
+		return nil, err
+	}
+
+	return release, nil
+}
+
+// waitToken reserva el siguiente token y espera a que se genere. Cada llamada reserva el suyo, así que las
+// entregas en espera salen en orden de llegada al ritmo configurado
+func (l *channelRateLimiter) waitToken(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give the reservation back to the deliveries still waiting
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// rateLimitedChannelSender entrega con el sender del canal sin superar su límite de ritmo y concurrencia
+type rateLimitedChannelSender struct {
+	sender  ChannelSender
+	limiter *channelRateLimiter
+}
+
+func (s *rateLimitedChannelSender) Send(ctx context.Context, conversation *domain.Conversation, message *domain.Message) error {
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return s.sender.Send(ctx, conversation, message)
+}
+
+// WithRateLimits envuelve los adaptadores de los canales con límite configurado para que la entrega se
+// regule sola dentro de los límites del proveedor. Todos los envíos por un canal, incluidos los reintentos,
+// comparten su límite
+func (senders ChannelSenders) WithRateLimits(limits map[string]config.DeliveryRateLimit) ChannelSenders {
+	wrapped := make(ChannelSenders, len(senders))
+	for channel, sender := range senders {
+		if limit, ok := limits[string(channel)]; ok && (limit.Rate > 0 || limit.MaxConcurrent > 0) {
+			sender = &rateLimitedChannelSender{sender: sender, limiter: newChannelRateLimiter(limit)}
+		}
+		wrapped[channel] = sender
+	}
+
+	return wrapped
+}
+
+// sendToChannel entrega el mensaje con el timeout de entrega. La espera de turno en el límite del canal
+// solo la acota ctx, de modo que una entrega encolada no agota el timeout antes de llegar al proveedor
+func (s *messagingService) sendToChannel(ctx context.Context, sender ChannelSender, conversation *domain.Conversation, message *domain.Message) error {
+	if limited, ok := sender.(*rateLimitedChannelSender); ok {
+		release, err := limited.limiter.acquire(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+		sender = limited.sender
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, s.channelDelivery.timeout)
+	defer cancel()
+
+	return sender.Send(sendCtx, conversation, message)
+}
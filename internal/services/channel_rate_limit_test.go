@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrencyRecordingSender registra cuántas entregas llegan a estar en curso a la vez
+type concurrencyRecordingSender struct {
+	inFlight    int32
+	maxInFlight int32
+	delay       time.Duration
+}
+
+func (s *concurrencyRecordingSender) Send(ctx context.Context, conversation *domain.Conversation, message *domain.Message) error {
+	current := atomic.AddInt32(&s.inFlight, 1)
+	defer atomic.AddInt32(&s.inFlight, -1)
+
+	for {
+		max := atomic.LoadInt32(&s.maxInFlight)
+		if current <= max || atomic.CompareAndSwapInt32(&s.maxInFlight, max, current) {
+			break
+		}
+	}
+
+	time.Sleep(s.delay)
+	return nil
+}
+
+func TestChannelSenders_WithRateLimits_CapsConcurrency(t *testing.T) {
+	// Setup
+	recorder := &concurrencyRecordingSender{delay: 20 * time.Millisecond}
+	senders := ChannelSenders{domain.ChannelWhatsApp: recorder, domain.ChannelWeb: new(MockChannelSender)}.
+		WithRateLimits(map[string]config.DeliveryRateLimit{"whatsapp": {MaxConcurrent: 2}})
+
+	// Channels without a limit keep their sender as is
+	_, limited := senders[domain.ChannelWeb].(*rateLimitedChannelSender)
+	assert.False(t, limited)
+
+	// Execute: a burst of deliveries queues instead of running all at once
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, senders[domain.ChannelWhatsApp].Send(context.Background(), &domain.Conversation{}, &domain.Message{}))
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, int32(2), atomic.LoadInt32(&recorder.maxInFlight))
+}
+
+func TestChannelRateLimiter_PacesDeliveries(t *testing.T) {
+	// Setup: 100 deliveries per second with a burst of 2
+	limiter := newChannelRateLimiter(config.DeliveryRateLimit{Rate: 100, Burst: 2})
+
+	// Execute: the burst goes through immediately, the rest wait ~10ms each
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		release, err := limiter.acquire(context.Background())
+		require.NoError(t, err)
+		release()
+	}
+
+	// Assert
+	assert.GreaterOrEqual(t, time.Since(start), 25*time.Millisecond)
+}
+
+func TestChannelRateLimiter_WaitEndsWithContext(t *testing.T) {
+	// Setup: one delivery per minute, already used
+	limiter := newChannelRateLimiter(config.DeliveryRateLimit{Rate: 1.0 / 60, Burst: 1})
+	release, err := limiter.acquire(context.Background())
+	require.NoError(t, err)
+	release()
+
+	// Execute
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = limiter.acquire(ctx)
+
+	// Assert: the caller gives up and its reservation is returned
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.InDelta(t, 0, limiter.tokens, 0.01)
+}
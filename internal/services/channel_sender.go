@@ -0,0 +1,17 @@
+package services
+
+import (
+	"context"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// ChannelSender entrega un mensaje saliente al proveedor de un canal. Si el proveedor devuelve un
+// identificador propio, se guarda en message.Metadata[domain.MetadataProviderMessageID] para poder
+// asociarle después sus acuses de entrega
+type ChannelSender interface {
+	Send(ctx context.Context, conversation *domain.Conversation, message *domain.Message) error
+}
+
+// ChannelSenders agrupa los adaptadores de entrega disponibles por canal
+type ChannelSenders map[domain.Channel]ChannelSender
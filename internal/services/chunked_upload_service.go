@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrUploadNotFound indica que la subida no existe, expiró o pertenece a otro usuario
+	ErrUploadNotFound = errors.New("upload not found")
+	// ErrInvalidChunk indica una parte fuera de rango, demasiado grande o solapada con otra
+	ErrInvalidChunk = errors.New("invalid chunk")
+	// ErrUploadIncomplete indica que faltan rangos por recibir al finalizar
+	ErrUploadIncomplete = errors.New("upload incomplete")
+)
+
+// ChunkedUploadService gestiona subidas reanudables por rangos de bytes
+type ChunkedUploadService interface {
+	InitUpload(ctx context.Context, userID string, filename string, totalSize int64) (*domain.UploadSession, error)
+	// CheckChunkSize valida el tamaño declarado de una parte antes de leer su cuerpo
+	CheckChunkSize(size int64) error
+	UploadChunk(ctx context.Context, uploadID string, userID string, start int64, data []byte) (*domain.UploadSession, error)
+	GetUpload(ctx context.Context, uploadID string, userID string) (*domain.UploadSession, error)
+	CompleteUpload(ctx context.Context, uploadID string, userID string) (*UploadFileResponse, error)
+}
+
+// redisChunkedUploadService guarda el estado y las partes en Redis; el TTL descarta las subidas incompletas
+type redisChunkedUploadService struct {
+	client      *redis.Client
+	fileService FileService
+	config      *config.FileStorageConfig
+	logger      logger.Logger
+}
+
+func NewRedisChunkedUploadService(client *redis.Client, fileService FileService, config *config.FileStorageConfig, logger logger.Logger) ChunkedUploadService {
+	return &redisChunkedUploadService{
+		client:      client,
+		fileService: fileService,
+		config:      config,
+		logger:      logger,
+	}
+}
+
+func (s *redisChunkedUploadService) InitUpload(ctx context.Context, userID string, filename string, totalSize int64) (*domain.UploadSession, error) {
+	if totalSize <= 0 {
+		return nil, fmt.Errorf("%w: total size must be positive", ErrInvalidChunk)
+	}
+	if totalSize > s.config.MaxFileSize {
+		return nil, fmt.Errorf("%w: file size exceeds maximum allowed size of %d bytes", ErrInvalidChunk, s.config.MaxFileSize)
+	}
+
+	now := time.Now()
+	session := &domain.UploadSession{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		Filename:       filename,
+		TotalSize:      totalSize,
+		ReceivedRanges: []domain.ByteRange{},
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(s.config.UploadSessionTTL),
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal upload session: %w", err)
+	}
+
+	if err := s.client.Set(ctx, uploadSessionKey(session.ID), data, s.config.UploadSessionTTL).Err(); err != nil {
+		s.logger.Error("Failed to store upload session", err)
+		return nil, fmt.Errorf("failed to init upload: %w", err)
+	}
+
+	s.logger.Info("Resumable upload started", map[string]interface{}{
+		"upload_id":  session.ID,
+		"user_id":    userID,
+		"total_size": totalSize,
+	})
+
+	return session, nil
+}
+
+func (s *redisChunkedUploadService) CheckChunkSize(size int64) error {
+	if size <= 0 {
+		return fmt.Errorf("%w: empty chunk", ErrInvalidChunk)
+	}
+	if size > s.config.MaxChunkSize {
+		return fmt.Errorf("%w: chunk exceeds maximum size of %d bytes", ErrInvalidChunk, s.config.MaxChunkSize)
+	}
+	return nil
+}
+
+// storeChunkScript guarda la parte solo si no se solapa con ninguna recibida, de forma atómica frente a
+// otras partes de la misma subida enviadas en paralelo. Devuelve 1 si la guardó, 0 si ya estaba
+// guardada y el rango recibido con el que se solapa en otro caso
+var storeChunkScript = redis.NewScript(`
+local start = tonumber(ARGV[1])
+local finish = tonumber(ARGV[2])
+for _, field in ipairs(redis.call('HKEYS', KEYS[1])) do
+	if field == ARGV[3] then
+		return 0
+	end
+	local s, e = string.match(field, '^(%d+)%-(%d+)$')
+	if s and start <= tonumber(e) and tonumber(s) <= finish then
+		return field
+	end
+end
+redis.call('HSET', KEYS[1], ARGV[3], ARGV[4])
+redis.call('EXPIREAT', KEYS[1], ARGV[5])
+return 1
+`)
+
+func (s *redisChunkedUploadService) UploadChunk(ctx context.Context, uploadID string, userID string, start int64, data []byte) (*domain.UploadSession, error) {
+	session, err := s.loadSession(ctx, uploadID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.CheckChunkSize(int64(len(data))); err != nil {
+		return nil, err
+	}
+
+	chunk := domain.ByteRange{Start: start, End: start + int64(len(data)) - 1}
+	if chunk.Start < 0 || chunk.End >= session.TotalSize {
+		return nil, fmt.Errorf("%w: range %d-%d outside of %d bytes", ErrInvalidChunk, chunk.Start, chunk.End, session.TotalSize)
+	}
+
+	// Re-sending an already stored chunk is how clients resume after a lost response, so it is not an error
+	result, err := storeChunkScript.Run(ctx, s.client, []string{uploadChunksKey(uploadID)},
+		chunk.Start, chunk.End, formatByteRange(chunk), data, session.ExpiresAt.Unix()).Result()
+	if err != nil {
+		s.logger.Error("Failed to store upload chunk", err)
+		return nil, fmt.Errorf("failed to store chunk: %w", err)
+	}
+	if overlapping, ok := result.(string); ok {
+		return nil, fmt.Errorf("%w: range %d-%d overlaps received range %s", ErrInvalidChunk, chunk.Start, chunk.End, overlapping)
+	}
+
+	// Reload so the ranges include chunks stored concurrently by other requests
+	return s.loadSession(ctx, uploadID, userID)
+}
+
+func (s *redisChunkedUploadService) GetUpload(ctx context.Context, uploadID string, userID string) (*domain.UploadSession, error) {
+	return s.loadSession(ctx, uploadID, userID)
+}
+
+func (s *redisChunkedUploadService) CompleteUpload(ctx context.Context, uploadID string, userID string) (*UploadFileResponse, error) {
+	session, err := s.loadSession(ctx, uploadID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if next := nextMissingOffset(session.ReceivedRanges, session.TotalSize); next < session.TotalSize {
+		return nil, fmt.Errorf("%w: missing bytes from offset %d", ErrUploadIncomplete, next)
+	}
+
+	chunks, err := s.client.HGetAll(ctx, uploadChunksKey(uploadID)).Result()
+	if err != nil {
+		s.logger.Error("Failed to load upload chunks", err)
+		return nil, fmt.Errorf("failed to load chunks: %w", err)
+	}
+
+	readers := make([]io.Reader, 0, len(session.ReceivedRanges))
+	for _, chunk := range session.ReceivedRanges {
+		readers = append(readers, strings.NewReader(chunks[formatByteRange(chunk)]))
+	}
+
+	result, err := s.fileService.UploadFile(ctx, UploadFileRequest{
+		File:     io.MultiReader(readers...),
+		Filename: session.Filename,
+		Size:     session.TotalSize,
+		UserID:   userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.client.Del(ctx, uploadSessionKey(uploadID), uploadChunksKey(uploadID)).Err(); err != nil {
+		s.logger.Warn("Failed to clean up completed upload", map[string]interface{}{
+			"upload_id": uploadID,
+			"error":     err.Error(),
+		})
+	}
+
+	s.logger.Info("Resumable upload completed", map[string]interface{}{
+		"upload_id": uploadID,
+		"user_id":   userID,
+		"url":       result.URL,
+	})
+
+	return result, nil
+}
+
+// loadSession lee el estado de la subida y reconstruye los rangos recibidos a partir de las partes guardadas
+func (s *redisChunkedUploadService) loadSession(ctx context.Context, uploadID string, userID string) (*domain.UploadSession, error) {
+	data, err := s.client.Get(ctx, uploadSessionKey(uploadID)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, ErrUploadNotFound
+		}
+		s.logger.Error("Failed to load upload session", err)
+		return nil, fmt.Errorf("failed to load upload: %w", err)
+	}
+
+	var session domain.UploadSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal upload session: %w", err)
+	}
+
+	if session.UserID != userID {
+		return nil, ErrUploadNotFound
+	}
+
+	fields, err := s.client.HKeys(ctx, uploadChunksKey(uploadID)).Result()
+	if err != nil {
+		s.logger.Error("Failed to load upload ranges", err)
+		return nil, fmt.Errorf("failed to load upload: %w", err)
+	}
+
+	session.ReceivedRanges = make([]domain.ByteRange, 0, len(fields))
+	for _, field := range fields {
+		if chunk, ok := parseByteRange(field); ok {
+			session.ReceivedRanges = append(session.ReceivedRanges, chunk)
+		}
+	}
+	session.ReceivedRanges = sortByteRanges(session.ReceivedRanges)
+	session.NextOffset = nextMissingOffset(session.ReceivedRanges, session.TotalSize)
+
+	return &session, nil
+}
+
+func uploadSessionKey(uploadID string) string {
+	return fmt.Sprintf("upload:%s", uploadID)
+}
+
+func uploadChunksKey(uploadID string) string {
+	return fmt.Sprintf("upload:%s:chunks", uploadID)
+}
+
+func formatByteRange(r domain.ByteRange) string {
+	return fmt.Sprintf("%d-%d", r.Start, r.End)
+}
+
+func parseByteRange(value string) (domain.ByteRange, bool) {
+	parts := strings.SplitN(value, "-", 2)
+	if len(parts) != 2 {
+		return domain.ByteRange{}, false
+	}
+
+	start, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return domain.ByteRange{}, false
+	}
+	end, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || end < start {
+		return domain.ByteRange{}, false
+	}
+
+	return domain.ByteRange{Start: start, End: end}, true
+}
+
+func sortByteRanges(ranges []domain.ByteRange) []domain.ByteRange {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+	return ranges
+}
+
+// nextMissingOffset devuelve el primer byte aún no recibido, o totalSize si la subida está completa
+func nextMissingOffset(sorted []domain.ByteRange, totalSize int64) int64 {
+	var next int64
+	for _, r := range sorted {
+		if r.Start > next {
+			break
+		}
+		if r.End+1 > next {
+			next = r.End + 1
+		}
+	}
+	if next > totalSize {
+		return totalSize
+	}
+	return next
+}
+
+// NoOpChunkedUploadService for when Redis is not available
+type noOpChunkedUploadService struct{}
+
+func NewNoOpChunkedUploadService() ChunkedUploadService {
+	return &noOpChunkedUploadService{}
+}
+
+func (s *noOpChunkedUploadService) InitUpload(ctx context.Context, userID string, filename string, totalSize int64) (*domain.UploadSession, error) {
+	return nil, fmt.Errorf("resumable uploads are disabled")
+}
+
+func (s *noOpChunkedUploadService) CheckChunkSize(size int64) error {
+	return fmt.Errorf("resumable uploads are disabled")
+}
+
+func (s *noOpChunkedUploadService) UploadChunk(ctx context.Context, uploadID string, userID string, start int64, data []byte) (*domain.UploadSession, error) {
+	return nil, fmt.Errorf("resumable uploads are disabled")
+}
+
+func (s *noOpChunkedUploadService) GetUpload(ctx context.Context, uploadID string, userID string) (*domain.UploadSession, error) {
+	return nil, fmt.Errorf("resumable uploads are disabled")
+}
+
+func (s *noOpChunkedUploadService) CompleteUpload(ctx context.Context, uploadID string, userID string) (*UploadFileResponse, error) {
+	return nil, fmt.Errorf("resumable uploads are disabled")
+}
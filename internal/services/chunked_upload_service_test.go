@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextMissingOffset(t *testing.T) {
+	tests := []struct {
+		name     string
+		ranges   []domain.ByteRange
+		total    int64
+		expected int64
+	}{
+		{"nothing received", nil, 100, 0},
+		{"contiguous prefix", []domain.ByteRange{{Start: 0, End: 49}}, 100, 50},
+		{"gap after first chunk", []domain.ByteRange{{Start: 0, End: 29}, {Start: 50, End: 99}}, 100, 30},
+		{"first chunk missing", []domain.ByteRange{{Start: 50, End: 99}}, 100, 0},
+		{"complete", []domain.ByteRange{{Start: 0, End: 49}, {Start: 50, End: 99}}, 100, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, nextMissingOffset(tt.ranges, tt.total))
+		})
+	}
+}
+
+func TestParseByteRange(t *testing.T) {
+	r, ok := parseByteRange(formatByteRange(domain.ByteRange{Start: 1024, End: 2047}))
+	assert.True(t, ok)
+	assert.Equal(t, domain.ByteRange{Start: 1024, End: 2047}, r)
+
+	_, ok = parseByteRange("20-10")
+	assert.False(t, ok)
+
+	_, ok = parseByteRange("garbage")
+	assert.False(t, ok)
+}
+
+func TestRedisChunkedUploadService_CheckChunkSize(t *testing.T) {
+	service := NewRedisChunkedUploadService(nil, nil, &config.FileStorageConfig{MaxChunkSize: 1024}, logger.NewLogger("debug"))
+
+	assert.NoError(t, service.CheckChunkSize(1024))
+	assert.ErrorIs(t, service.CheckChunkSize(1025), ErrInvalidChunk)
+	assert.ErrorIs(t, service.CheckChunkSize(0), ErrInvalidChunk)
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+// ErrFileRejected indica que el análisis del archivo subido lo bloqueó
+var ErrFileRejected = errors.New("file rejected")
+
+// FailMode decide qué ocurre cuando un servicio externo de moderación o análisis falla o no responde
+type FailMode string
+
+const (
+	// FailOpen deja pasar el contenido si el servicio externo falla
+	FailOpen FailMode = "open"
+	// FailClosed bloquea el contenido si el servicio externo falla
+	FailClosed FailMode = "closed"
+)
+
+// ParseFailMode interpreta el valor de configuración open|closed
+func ParseFailMode(value string) (FailMode, error) {
+	switch FailMode(value) {
+	case FailOpen, FailClosed:
+		return FailMode(value), nil
+	default:
+		return "", fmt.Errorf("invalid fail mode %q, expected open or closed", value)
+	}
+}
+
+// applyFailMode decide si la operación continúa tras un fallo del servicio externo y registra la decisión
+func applyFailMode(log logger.Logger, service string, mode FailMode, err error, fields map[string]interface{}) error {
+	fields["service"] = service
+	fields["fail_mode"] = mode
+	fields["error"] = err.Error()
+
+	if mode == FailOpen {
+		log.Warn("External check failed, allowing content", fields)
+		return nil
+	}
+
+	log.Warn("External check failed, blocking content", fields)
+	return fmt.Errorf("%s unavailable: %w", service, err)
+}
+
+// ModerationResult es el veredicto del moderador sobre un mensaje
+type ModerationResult struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// ContentModerator revisa el contenido de un mensaje antes de guardarlo
+type ContentModerator interface {
+	Moderate(ctx context.Context, message *domain.Message) (*ModerationResult, error)
+}
+
+// ScanResult es el veredicto del analizador sobre un archivo
+type ScanResult struct {
+	Clean  bool   `json:"clean"`
+	Threat string `json:"threat,omitempty"`
+}
+
+// FileScanner analiza el contenido de un archivo antes de almacenarlo
+type FileScanner interface {
+	Scan(ctx context.Context, filename string, content []byte) (*ScanResult, error)
+}
+
+type httpContentModerator struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewHTTPContentModerator crea un moderador que envía el mensaje por POST y espera {"allowed": bool, "reason": string}
+func NewHTTPContentModerator(url string, timeout time.Duration) ContentModerator {
+	return &httpContentModerator{
+		url:        url,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (m *httpContentModerator) Moderate(ctx context.Context, message *domain.Message) (*ModerationResult, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"conversation_id": message.ConversationID,
+		"sender_type":     message.SenderType,
+		"content_type":    message.ContentType,
+		"content":         message.Content,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal moderation request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create moderation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var result ModerationResult
+	if err := doCheckRequest(m.httpClient, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+type httpFileScanner struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewHTTPFileScanner crea un analizador que envía el archivo por POST y espera {"clean": bool, "threat": string}
+func NewHTTPFileScanner(url string, timeout time.Duration) FileScanner {
+	return &httpFileScanner{
+		url:        url,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *httpFileScanner) Scan(ctx context.Context, filename string, content []byte) (*ScanResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scan request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-Filename", filename)
+
+	var result ScanResult
+	if err := doCheckRequest(s.httpClient, req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// doCheckRequest ejecuta la petición y decodifica el veredicto; cualquier respuesta distinta de 200 es un fallo del servicio
+func doCheckRequest(client *http.Client, req *http.Request, result interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request failed with status: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// NewModerationSendHook rechaza los mensajes que el moderador no permite; failMode decide si se envían cuando el moderador falla
+func NewModerationSendHook(moderator ContentModerator, failMode FailMode, log logger.Logger) SendHook {
+	return NewSendHook("moderation", SendHookPrePersist, func(ctx context.Context, send *SendContext) error {
+		result, err := moderator.Moderate(ctx, send.Message)
+		if err != nil {
+			return applyFailMode(log, "moderation", failMode, err, map[string]interface{}{
+				"conversation_id": send.Message.ConversationID,
+				"message_id":      send.Message.ID,
+			})
+		}
+
+		if !result.Allowed {
+			return fmt.Errorf("content not allowed: %s", result.Reason)
+		}
+
+		return nil
+	})
+}
+
+type scanningFileService struct {
+	FileService
+	scanner  FileScanner
+	failMode FailMode
+	logger   logger.Logger
+}
+
+// NewScanningFileService analiza cada archivo antes de delegar la subida en fileService
+func NewScanningFileService(fileService FileService, scanner FileScanner, failMode FailMode, logger logger.Logger) FileService {
+	return &scanningFileService{
+		FileService: fileService,
+		scanner:     scanner,
+		failMode:    failMode,
+		logger:      logger,
+	}
+}
+
+func (s *scanningFileService) UploadFile(ctx context.Context, req UploadFileRequest) (*UploadFileResponse, error) {
+	content, err := io.ReadAll(req.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	result, err := s.scanner.Scan(ctx, req.Filename, content)
+	if err != nil {
+		if err := applyFailMode(s.logger, "scan", s.failMode, err, map[string]interface{}{
+			"filename": req.Filename,
+			"user_id":  req.UserID,
+		}); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrFileRejected, err)
+		}
+	} else if !result.Clean {
+		s.logger.Warn("File rejected by scanner", map[string]interface{}{
+			"filename": req.Filename,
+			"user_id":  req.UserID,
+			"threat":   result.Threat,
+		})
+		return nil, fmt.Errorf("%w: threat detected: %s", ErrFileRejected, result.Threat)
+	}
+
+	req.File = bytes.NewReader(content)
+	return s.FileService.UploadFile(ctx, req)
+}
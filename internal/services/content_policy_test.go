@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParseFailMode(t *testing.T) {
+	mode, err := ParseFailMode("open")
+	assert.NoError(t, err)
+	assert.Equal(t, FailOpen, mode)
+
+	mode, err = ParseFailMode("closed")
+	assert.NoError(t, err)
+	assert.Equal(t, FailClosed, mode)
+
+	_, err = ParseFailMode("maybe")
+	assert.Error(t, err)
+}
+
+func TestModerationSendHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"allowed": false, "reason": "spam"}`))
+	}))
+	defer server.Close()
+
+	hook := NewModerationSendHook(NewHTTPContentModerator(server.URL, time.Second), FailOpen, logger.NewLogger("debug"))
+	err := hook.Handle(context.Background(), &SendContext{Message: &domain.Message{Content: "buy now"}})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "spam")
+}
+
+func TestModerationSendHook_FailMode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	moderator := NewHTTPContentModerator(server.URL, time.Second)
+	send := &SendContext{Message: &domain.Message{ID: "msg123", Content: "hola"}}
+
+	// Fail-open lets the message through when the moderator is down
+	err := NewModerationSendHook(moderator, FailOpen, logger.NewLogger("debug")).Handle(context.Background(), send)
+	assert.NoError(t, err)
+
+	// Fail-closed blocks it
+	err = NewModerationSendHook(moderator, FailClosed, logger.NewLogger("debug")).Handle(context.Background(), send)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "moderation unavailable")
+}
+
+func TestScanningFileService(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Filename") == "eicar.txt" {
+			w.Write([]byte(`{"clean": false, "threat": "EICAR-Test-File"}`))
+			return
+		}
+		w.Write([]byte(`{"clean": true}`))
+	}))
+	defer server.Close()
+
+	mockFileService := new(MockFileService)
+	mockFileService.On("UploadFile", mock.Anything, mock.Anything).Return(&UploadFileResponse{URL: "/uploads/user123/doc.txt"}, nil)
+
+	service := NewScanningFileService(mockFileService, NewHTTPFileScanner(server.URL, time.Second), FailClosed, logger.NewLogger("debug"))
+
+	result, err := service.UploadFile(context.Background(), UploadFileRequest{File: strings.NewReader("contenido"), Filename: "doc.txt", UserID: "user123"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/uploads/user123/doc.txt", result.URL)
+
+	_, err = service.UploadFile(context.Background(), UploadFileRequest{File: strings.NewReader("X5O!P%@AP"), Filename: "eicar.txt", UserID: "user123"})
+	assert.True(t, errors.Is(err, ErrFileRejected))
+	mockFileService.AssertNumberOfCalls(t, "UploadFile", 1)
+}
+
+func TestScanningFileService_FailMode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	mockFileService := new(MockFileService)
+	mockFileService.On("UploadFile", mock.Anything, mock.Anything).Return(&UploadFileResponse{URL: "/uploads/user123/doc.txt"}, nil)
+	scanner := NewHTTPFileScanner(server.URL, time.Second)
+
+	// Fail-closed rejects the upload when the scanner errors
+	closed := NewScanningFileService(mockFileService, scanner, FailClosed, logger.NewLogger("debug"))
+	_, err := closed.UploadFile(context.Background(), UploadFileRequest{File: strings.NewReader("contenido"), Filename: "doc.txt"})
+	assert.True(t, errors.Is(err, ErrFileRejected))
+	mockFileService.AssertNotCalled(t, "UploadFile", mock.Anything, mock.Anything)
+
+	// Fail-open stores it anyway
+	open := NewScanningFileService(mockFileService, scanner, FailOpen, logger.NewLogger("debug"))
+	_, err = open.UploadFile(context.Background(), UploadFileRequest{File: strings.NewReader("contenido"), Filename: "doc.txt"})
+	assert.NoError(t, err)
+	mockFileService.AssertNumberOfCalls(t, "UploadFile", 1)
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// ContentRule son los requisitos estructurales de los mensajes de un tipo de contenido
+type ContentRule struct {
+	RequireContent    bool                    // El texto del mensaje no puede estar vacío
+	RequireAttachment bool                    // Debe incluir al menos un adjunto
+	AttachmentTypes   []domain.AttachmentType // Tipos de adjunto que cumplen el requisito; vacío acepta cualquiera
+}
+
+// ContentRules asocia cada tipo de contenido con sus requisitos; un tipo sin regla no se acepta
+type ContentRules map[domain.ContentType]ContentRule
+
+// DefaultContentRules exige texto en los mensajes de texto y un adjunto del tipo correspondiente en el resto,
+// donde el texto es un pie opcional
+var DefaultContentRules = ContentRules{
+	domain.ContentTypeText:  {RequireContent: true},
+	domain.ContentTypeImage: {RequireAttachment: true, AttachmentTypes: []domain.AttachmentType{domain.AttachmentTypeImage}},
+	domain.ContentTypeVideo: {RequireAttachment: true, AttachmentTypes: []domain.AttachmentType{domain.AttachmentTypeVideo}},
+	domain.ContentTypeAudio: {RequireAttachment: true, AttachmentTypes: []domain.AttachmentType{domain.AttachmentTypeAudio}},
+	domain.ContentTypeFile:  {RequireAttachment: true},
+}
+
+// Códigos de error de ContentRuleError, devueltos tal cual por la API
+const (
+	ContentRuleCodeContentRequired    = "CONTENT_REQUIRED"
+	ContentRuleCodeAttachmentRequired = "ATTACHMENT_REQUIRED"
+	ContentRuleCodeInvalidContentType = "INVALID_CONTENT_TYPE"
+	ContentRuleCodeInvalidAttachment  = "INVALID_ATTACHMENT"
+)
+
+// ContentRuleError indica qué requisito estructural incumple el mensaje
+type ContentRuleError struct {
+	Code        string
+	ContentType domain.ContentType
+	Reason      string
+}
+
+func (e *ContentRuleError) Error() string {
+	return fmt.Sprintf("%s message %s", e.ContentType, e.Reason)
+}
+
+// ParseContentRule interpreta una regla como "content", "attachment" o "attachment:image,attachment:video".
+// "none" no exige nada
+func ParseContentRule(spec string) (ContentRule, error) {
+	var rule ContentRule
+	for _, token := range strings.Split(spec, ",") {
+		token = strings.TrimSpace(strings.ToLower(token))
+		switch {
+		case token == "" || token == "none":
+		case token == "content":
+			rule.RequireContent = true
+		case token == "attachment":
+			rule.RequireAttachment = true
+		case strings.HasPrefix(token, "attachment:"):
+			attachmentType := domain.AttachmentType(strings.TrimPrefix(token, "attachment:"))
+			if !isValidAttachmentType(attachmentType) {
+				return ContentRule{}, fmt.Errorf("unknown attachment type %q in content rule", attachmentType)
+			}
+			rule.RequireAttachment = true
+			rule.AttachmentTypes = append(rule.AttachmentTypes, attachmentType)
+		default:
+			return ContentRule{}, fmt.Errorf("unknown content rule %q", token)
+		}
+	}
+
+	return rule, nil
+}
+
+// BuildContentRules parte de DefaultContentRules y sustituye las reglas de los tipos con especificación
+func BuildContentRules(specs map[string]string) (ContentRules, error) {
+	rules := make(ContentRules, len(DefaultContentRules))
+	for contentType, rule := range DefaultContentRules {
+		rules[contentType] = rule
+	}
+
+	for contentType, spec := range specs {
+		if spec == "" {
+			continue
+		}
+		if _, ok := DefaultContentRules[domain.ContentType(contentType)]; !ok {
+			return nil, fmt.Errorf("unknown content type %q", contentType)
+		}
+
+		rule, err := ParseContentRule(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule for content type %s: %w", contentType, err)
+		}
+		rules[domain.ContentType(contentType)] = rule
+	}
+
+	return rules, nil
+}
+
+// WithContentRules sustituye los requisitos estructurales por tipo de contenido que valida SendMessage
+func WithContentRules(rules ContentRules) MessagingServiceOption {
+	return func(s *messagingService) {
+		s.contentRules = rules
+	}
+}
+
+// Check comprueba el mensaje y sus adjuntos contra la regla de su tipo de contenido
+func (r ContentRules) Check(message *domain.Message, attachments []CreateAttachmentRequest) error {
+	rule, ok := r[message.ContentType]
+	if !ok {
+		return &ContentRuleError{Code: ContentRuleCodeInvalidContentType, ContentType: message.ContentType, Reason: "is not a supported content type"}
+	}
+
+	for _, attachment := range attachments {
+		if !isValidAttachmentType(attachment.Type) {
+			return &ContentRuleError{Code: ContentRuleCodeInvalidAttachment, ContentType: message.ContentType, Reason: fmt.Sprintf("has an attachment of unknown type %q", attachment.Type)}
+		}
+	}
+
+	if rule.RequireContent && strings.TrimSpace(message.Content) == "" {
+		return &ContentRuleError{Code: ContentRuleCodeContentRequired, ContentType: message.ContentType, Reason: "requires non-empty content"}
+	}
+
+	if rule.RequireAttachment && !hasMatchingAttachment(attachments, rule.AttachmentTypes) {
+		reason := "requires an attachment"
+		if len(rule.AttachmentTypes) > 0 {
+			reason = fmt.Sprintf("requires an attachment of type %v", rule.AttachmentTypes)
+		}
+		return &ContentRuleError{Code: ContentRuleCodeAttachmentRequired, ContentType: message.ContentType, Reason: reason}
+	}
+
+	return nil
+}
+
+func hasMatchingAttachment(attachments []CreateAttachmentRequest, types []domain.AttachmentType) bool {
+	for _, attachment := range attachments {
+		if len(types) == 0 {
+			return true
+		}
+		for _, allowed := range types {
+			if attachment.Type == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateMessage es el hook de validación incorporado: remitente, estructura según el tipo de contenido
+// y expiración
+func (s *messagingService) validateMessage(ctx context.Context, send *SendContext) error {
+	message := send.Message
+
+	if !isValidSenderType(message.SenderType) {
+		return fmt.Errorf("invalid sender type: %s", message.SenderType)
+	}
+
+	if err := s.contentRules.Check(message, send.Request.Attachments); err != nil {
+		return err
+	}
+
+	if message.ExpiresAt != nil && !message.ExpiresAt.After(message.Timestamp) {
+		return fmt.Errorf("expires_at must be in the future")
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentRules_Check(t *testing.T) {
+	image := CreateAttachmentRequest{URL: "https://files.example/a.png", Type: domain.AttachmentTypeImage, Size: 10, Filename: "a.png"}
+	document := CreateAttachmentRequest{URL: "https://files.example/a.pdf", Type: domain.AttachmentTypeFile, Size: 10, Filename: "a.pdf"}
+
+	tests := []struct {
+		name        string
+		contentType domain.ContentType
+		content     string
+		attachments []CreateAttachmentRequest
+		code        string
+	}{
+		{"text with content", domain.ContentTypeText, "hola", nil, ""},
+		{"text without content", domain.ContentTypeText, "   ", nil, ContentRuleCodeContentRequired},
+		{"image with image attachment and no caption", domain.ContentTypeImage, "", []CreateAttachmentRequest{image}, ""},
+		{"image without attachment", domain.ContentTypeImage, "mira", nil, ContentRuleCodeAttachmentRequired},
+		{"image with document attachment", domain.ContentTypeImage, "", []CreateAttachmentRequest{document}, ContentRuleCodeAttachmentRequired},
+		{"file with any attachment", domain.ContentTypeFile, "", []CreateAttachmentRequest{document}, ""},
+		{"unknown content type", domain.ContentType("sticker"), "hola", nil, ContentRuleCodeInvalidContentType},
+		{"unknown attachment type", domain.ContentTypeFile, "", []CreateAttachmentRequest{{Type: "exe"}}, ContentRuleCodeInvalidAttachment},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := DefaultContentRules.Check(&domain.Message{ContentType: tt.contentType, Content: tt.content}, tt.attachments)
+			if tt.code == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var ruleErr *ContentRuleError
+			require.True(t, errors.As(err, &ruleErr))
+			assert.Equal(t, tt.code, ruleErr.Code)
+		})
+	}
+}
+
+func TestBuildContentRules(t *testing.T) {
+	rules, err := BuildContentRules(map[string]string{"image": "content,attachment:image", "video": ""})
+	require.NoError(t, err)
+
+	assert.Equal(t, ContentRule{RequireContent: true, RequireAttachment: true, AttachmentTypes: []domain.AttachmentType{domain.AttachmentTypeImage}}, rules[domain.ContentTypeImage])
+	assert.Equal(t, DefaultContentRules[domain.ContentTypeVideo], rules[domain.ContentTypeVideo])
+
+	// The defaults are not modified
+	assert.False(t, DefaultContentRules[domain.ContentTypeImage].RequireContent)
+}
+
+func TestBuildContentRules_Invalid(t *testing.T) {
+	for name, specs := range map[string]map[string]string{
+		"unknown content type":    {"sticker": "attachment"},
+		"unknown token":           {"text": "caption"},
+		"unknown attachment type": {"image": "attachment:gif"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := BuildContentRules(specs)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMessagingService_SendMessage_ContentRules(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		mockAttachmentRepo,
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	conversation := &domain.Conversation{ID: "conv123", UserID: "user123", Channel: domain.ChannelWeb, Status: domain.ConversationStatusActive}
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(conversation, nil)
+	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+	mockConversationRepo.On("TouchUpdatedAt", mock.Anything, mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+	mockMessageRepo.On("CreateWithAttachments", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+
+	req := SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "user123",
+		ContentType:    domain.ContentTypeImage,
+	}
+
+	// Execute: an image without attachment is rejected before it is stored
+	message, err := service.SendMessage(context.Background(), req)
+
+	// Assert
+	assert.Nil(t, message)
+	assert.ErrorIs(t, err, ErrMessageRejected)
+	var ruleErr *ContentRuleError
+	require.True(t, errors.As(err, &ruleErr))
+	assert.Equal(t, ContentRuleCodeAttachmentRequired, ruleErr.Code)
+	mockMessageRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockMessageRepo.AssertNotCalled(t, "CreateWithAttachments", mock.Anything, mock.Anything)
+
+	// Execute: with the image attached it is stored together with the attachment, in one transaction
+	req.Attachments = []CreateAttachmentRequest{{URL: "https://files.example/a.png", Type: domain.AttachmentTypeImage, Size: 10, Filename: "a.png"}}
+	message, err = service.SendMessage(context.Background(), req)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, message.Attachments, 1)
+	assert.Equal(t, message.ID, message.Attachments[0].MessageID)
+	mockMessageRepo.AssertNumberOfCalls(t, "CreateWithAttachments", 1)
+	mockMessageRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockAttachmentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
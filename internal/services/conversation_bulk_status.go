@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidBulkStatus indica una solicitud de cambio de estado masivo no válida
+var ErrInvalidBulkStatus = errors.New("invalid bulk status request")
+
+// maxBulkStatusConversations limita cuántas conversaciones cambian de estado en una sola sentencia
+const maxBulkStatusConversations = 500
+
+// BulkStatusRequest es la solicitud de POST /conversations/bulk-status
+type BulkStatusRequest struct {
+	ConversationIDs []string                  `json:"ids" binding:"required"`
+	Status          domain.ConversationStatus `json:"status" binding:"required"`
+}
+
+// BulkStatusItem es el resultado para una de las conversaciones solicitadas
+type BulkStatusItem struct {
+	ConversationID string                           `json:"conversation_id"`
+	Result         domain.ConversationStatusOutcome `json:"result"`
+}
+
+// BulkStatusResult resume un cambio de estado masivo, con el resultado de cada conversación en el orden solicitado
+type BulkStatusResult struct {
+	Status  domain.ConversationStatus `json:"status"`
+	Updated int                       `json:"updated"`
+	Results []BulkStatusItem          `json:"results"`
+}
+
+// isValidConversationStatus indica si el estado es uno de los conocidos
+func isValidConversationStatus(status domain.ConversationStatus) bool {
+	switch status {
+	case domain.ConversationStatusActive, domain.ConversationStatusClosed, domain.ConversationStatusArchived, domain.ConversationStatusAbandoned:
+		return true
+	default:
+		return false
+	}
+}
+
+// UpdateConversationStatusBulk cambia el estado de todas las conversaciones del usuario indicadas con una
+// única sentencia. Las que no existen o son de otro usuario se informan como not_found, y las que cambiaron
+// de estado hace menos de CONVERSATION_STATUS_MIN_INTERVAL como too_frequent
+func (s *messagingService) UpdateConversationStatusBulk(ctx context.Context, conversationIDs []string, status domain.ConversationStatus, userID string) (*BulkStatusResult, error) {
+	if !isValidConversationStatus(status) {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidBulkStatus, status)
+	}
+
+	// Same deduplication and canonical ids as the bulk tagging, so results line up with what Postgres returns
+	seen := make(map[string]bool, len(conversationIDs))
+	var ids, valid []string
+	for _, id := range conversationIDs {
+		id = strings.TrimSpace(id)
+		parsed, parseErr := uuid.Parse(id)
+		if parseErr == nil {
+			id = parsed.String()
+		}
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		if parseErr == nil {
+			valid = append(valid, id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: ids is required", ErrInvalidBulkStatus)
+	}
+	if len(ids) > maxBulkStatusConversations {
+		return nil, fmt.Errorf("%w: at most %d conversations per request", ErrInvalidBulkStatus, maxBulkStatusConversations)
+	}
+
+	ownerID := userID
+	if isTrustedService(ctx) {
+		ownerID = ""
+	}
+
+	now := time.Now()
+	outcomes := map[string]domain.ConversationStatusOutcome{}
+	if len(valid) > 0 {
+		var err error
+		if outcomes, err = s.conversationRepo.UpdateStatusBulk(ctx, valid, status, ownerID, now.Add(-s.statusThrottle.minInterval), now); err != nil {
+			return nil, fmt.Errorf("failed to update conversations: %w", err)
+		}
+	}
+
+	result := &BulkStatusResult{Status: status, Results: make([]BulkStatusItem, 0, len(ids))}
+	for _, id := range ids {
+		item := BulkStatusItem{ConversationID: id, Result: domain.ConversationStatusOutcomeNotFound}
+		if outcome, ok := outcomes[id]; ok {
+			item.Result = outcome
+		}
+		result.Results = append(result.Results, item)
+
+		if item.Result != domain.ConversationStatusOutcomeUpdated {
+			continue
+		}
+		result.Updated++
+
+		if s.cacheService != nil {
+			_ = s.cacheService.DeleteConversation(ctx, id)
+		}
+		s.publishConversationStatusChanged(ctx, id, userID, status, now)
+	}
+
+	// A trusted service's conversations have unknown owners, so their listings expire instead
+	if result.Updated > 0 {
+		s.invalidateConversationList(ctx, ownerID)
+	}
+
+	s.log(ctx).Info("Conversation statuses updated", map[string]interface{}{
+		"status":    status,
+		"requested": len(ids),
+		"updated":   result.Updated,
+		"user_id":   userID,
+	})
+
+	return result, nil
+}
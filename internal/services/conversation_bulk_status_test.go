@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessagingService_UpdateConversationStatusBulk(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	publisher := &recordingEventPublisher{}
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		publisher,
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	owned := "550e8400-e29b-41d4-a716-446655440001"
+	alreadyClosed := "550e8400-e29b-41d4-a716-446655440002"
+	notOwned := "550e8400-e29b-41d4-a716-446655440003"
+	// The repository only reports the conversations that belong to the user
+	mockConversationRepo.On("UpdateStatusBulk", mock.Anything, []string{owned, alreadyClosed, notOwned}, domain.ConversationStatusClosed, "user123", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).
+		Return(map[string]domain.ConversationStatusOutcome{
+			owned:         domain.ConversationStatusOutcomeUpdated,
+			alreadyClosed: domain.ConversationStatusOutcomeUnchanged,
+		}, nil)
+
+	// Execute
+	result, err := service.UpdateConversationStatusBulk(context.Background(), []string{owned, alreadyClosed, notOwned, owned}, domain.ConversationStatusClosed, "user123")
+
+	// Assert: only the owned conversation that changed counts and is announced
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Updated)
+	assert.Equal(t, []BulkStatusItem{
+		{ConversationID: owned, Result: domain.ConversationStatusOutcomeUpdated},
+		{ConversationID: alreadyClosed, Result: domain.ConversationStatusOutcomeUnchanged},
+		{ConversationID: notOwned, Result: domain.ConversationStatusOutcomeNotFound},
+	}, result.Results)
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, "conversation.closed", publisher.events[0].Type)
+	assert.Equal(t, owned, publisher.events[0].ConversationID)
+	mockConversationRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_UpdateConversationStatusBulk_Invalid(t *testing.T) {
+	mockConversationRepo := new(MockConversationRepository)
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	_, err := service.UpdateConversationStatusBulk(context.Background(), []string{"550e8400-e29b-41d4-a716-446655440001"}, "deleted", "user123")
+	assert.ErrorIs(t, err, ErrInvalidBulkStatus)
+
+	_, err = service.UpdateConversationStatusBulk(context.Background(), []string{" "}, domain.ConversationStatusArchived, "user123")
+	assert.ErrorIs(t, err, ErrInvalidBulkStatus)
+
+	mockConversationRepo.AssertNotCalled(t, "UpdateStatusBulk", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessagingService_CreateConversation_PublishesCreated(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	publisher := &recordingEventPublisher{}
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, publisher: publisher})
+
+	mockConversationRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Conversation")).Return(nil)
+
+	// Execute
+	conversation, err := service.CreateConversation(context.Background(), "user123", domain.ChannelWhatsApp, nil)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, publisher.events, 1)
+	event := publisher.events[0]
+	assert.Equal(t, "conversation.created", event.Type)
+	assert.Equal(t, conversation.ID, event.ConversationID)
+	assert.Equal(t, "user123", event.UserID)
+	assert.Equal(t, domain.ConversationStatusActive, event.Data["status"])
+	assert.Equal(t, domain.ChannelWhatsApp, event.Data["channel"])
+	assert.Equal(t, conversation.CreatedAt, event.Timestamp)
+}
+
+func TestMessagingService_EventsCarryRequestCorrelationID(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	publisher := &recordingEventPublisher{}
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, publisher: publisher})
+
+	mockConversationRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Conversation")).Return(nil)
+	ctx := logger.ContextWithRequestID(context.Background(), "req-42")
+
+	// Execute
+	_, err := service.CreateConversation(ctx, "user123", domain.ChannelWeb, nil)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, "req-42", publisher.events[0].Metadata["correlation_id"])
+}
+
+func TestMessagingService_UpdateConversationStatus_PublishesLifecycleEvents(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      domain.ConversationStatus
+		to        domain.ConversationStatus
+		eventType string // Empty when no event is expected
+	}{
+		{name: "active to closed", from: domain.ConversationStatusActive, to: domain.ConversationStatusClosed, eventType: "conversation.closed"},
+		{name: "closed to archived", from: domain.ConversationStatusClosed, to: domain.ConversationStatusArchived, eventType: "conversation.archived"},
+		{name: "reopened", from: domain.ConversationStatusClosed, to: domain.ConversationStatusActive},
+		{name: "already closed", from: domain.ConversationStatusClosed, to: domain.ConversationStatusClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockConversationRepo := new(MockConversationRepository)
+			publisher := &recordingEventPublisher{}
+			service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, publisher: publisher})
+
+			mockConversationRepo.On("GetByID", mock.Anything, "conv1").Return(&domain.Conversation{
+				ID:     "conv1",
+				UserID: "user123",
+				Status: tt.from,
+			}, nil)
+			mockConversationRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Conversation")).Return(nil)
+
+			// Execute
+			err := service.UpdateConversationStatus(context.Background(), "conv1", tt.to, "user123")
+
+			// Assert
+			require.NoError(t, err)
+			if tt.eventType == "" {
+				assert.Empty(t, publisher.events)
+				return
+			}
+			require.Len(t, publisher.events, 1)
+			event := publisher.events[0]
+			assert.Equal(t, tt.eventType, event.Type)
+			assert.Equal(t, "conv1", event.ConversationID)
+			assert.Equal(t, "user123", event.UserID)
+			assert.Equal(t, tt.to, event.Data["status"])
+			assert.False(t, event.Timestamp.IsZero())
+		})
+	}
+}
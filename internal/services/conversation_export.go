@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// Formatos de exportación de una conversación
+const (
+	ExportFormatJSON = "json"
+	ExportFormatCSV  = "csv"
+)
+
+// ErrInvalidExportFormat indica un formato de exportación desconocido
+var ErrInvalidExportFormat = errors.New("invalid export format")
+
+// exportCSVHeader son las columnas del CSV, en el orden de ExportedMessage
+var exportCSVHeader = []string{"id", "sender_type", "sender_id", "content", "content_type", "timestamp"}
+
+// ExportedMessage es una fila de la exportación; no incluye metadatos, que pueden contener datos internos
+type ExportedMessage struct {
+	ID          string             `json:"id"`
+	SenderType  domain.SenderType  `json:"sender_type"`
+	SenderID    string             `json:"sender_id"`
+	Content     string             `json:"content"`
+	ContentType domain.ContentType `json:"content_type"`
+	Timestamp   time.Time          `json:"timestamp"`
+}
+
+// ExportOptions configura la exportación de una conversación
+type ExportOptions struct {
+	// Redact produce la transcripción para terceros: sin mensajes de sistema, con los datos personales
+	// enmascarados y con el rol del remitente en lugar de su ID
+	Redact             bool
+	ExcludeSenderTypes []domain.SenderType
+}
+
+// ExportWriter escribe la exportación: Begin una vez comprobado el acceso, WriteMessage con cada mensaje en
+// orden cronológico y Close al terminar. Si la exportación falla a medias no se llama a Close
+type ExportWriter interface {
+	Begin(conversation *domain.Conversation) error
+	WriteMessage(message ExportedMessage) error
+	Close() error
+}
+
+// NewExportWriter crea el escritor del formato indicado sobre w, sin almacenar los mensajes
+func NewExportWriter(format string, w io.Writer) (ExportWriter, error) {
+	switch format {
+	case ExportFormatJSON:
+		return &jsonExportWriter{w: w, encoder: json.NewEncoder(w)}, nil
+	case ExportFormatCSV:
+		return &csvExportWriter{w: csv.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q, must be json or csv", ErrInvalidExportFormat, format)
+	}
+}
+
+// jsonExportWriter escribe un array JSON, un mensaje cada vez
+type jsonExportWriter struct {
+	w       io.Writer
+	encoder *json.Encoder
+	written int
+}
+
+func (j *jsonExportWriter) Begin(conversation *domain.Conversation) error {
+	_, err := io.WriteString(j.w, "[")
+	return err
+}
+
+func (j *jsonExportWriter) WriteMessage(message ExportedMessage) error {
+	if j.written > 0 {
+		if _, err := io.WriteString(j.w, ","); err != nil {
+			return err
+		}
+	}
+	j.written++
+	return j.encoder.Encode(message)
+}
+
+func (j *jsonExportWriter) Close() error {
+	_, err := io.WriteString(j.w, "]\n")
+	return err
+}
+
+// csvExportWriter escribe las columnas de exportCSVHeader; csv.Writer vuelca a w a medida que se llena su búfer
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvExportWriter) Begin(conversation *domain.Conversation) error {
+	return c.w.Write(exportCSVHeader)
+}
+
+func (c *csvExportWriter) WriteMessage(message ExportedMessage) error {
+	return c.w.Write([]string{
+		message.ID,
+		string(message.SenderType),
+		message.SenderID,
+		message.Content,
+		string(message.ContentType),
+		message.Timestamp.UTC().Format(time.RFC3339Nano),
+	})
+}
+
+func (c *csvExportWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// ExportConversation escribe en w todos los mensajes de la conversación, sin paginar. Solo puede exportarla
+// su propietario
+func (s *messagingService) ExportConversation(ctx context.Context, conversationID string, userID string, opts ExportOptions, w ExportWriter) error {
+	for _, senderType := range opts.ExcludeSenderTypes {
+		if !isValidSenderType(senderType) {
+			return fmt.Errorf("%w: unknown sender type %q", ErrInvalidMessageFilter, senderType)
+		}
+	}
+
+	conversation, err := s.getOwnedConversation(ctx, conversationID, userID)
+	if err != nil {
+		return err
+	}
+
+	exclude := opts.ExcludeSenderTypes
+	var redactor *PIIRedactor
+	if opts.Redact {
+		exclude = append(append([]domain.SenderType(nil), exclude...), domain.SenderTypeSystem)
+		redactor = s.exportRedactor()
+	}
+
+	if err := w.Begin(conversation); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	exported := 0
+	err = s.messageRepo.StreamByConversationID(ctx, conversation.ID, exclude, func(message *domain.Message) error {
+		exported++
+		if err := w.WriteMessage(exportMessage(message, redactor)); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	s.log(ctx).Info("Conversation exported", map[string]interface{}{
+		"conversation_id": conversation.ID,
+		"user_id":         userID,
+		"messages":        exported,
+		"redacted":        opts.Redact,
+	})
+
+	return nil
+}
+
+// exportMessage convierte el mensaje en una fila de la exportación; con redactor, redactada
+func exportMessage(message *domain.Message, redactor *PIIRedactor) ExportedMessage {
+	exported := ExportedMessage{
+		ID:          message.ID,
+		SenderType:  message.SenderType,
+		SenderID:    message.SenderID,
+		Content:     message.Content,
+		ContentType: message.ContentType,
+		Timestamp:   message.Timestamp,
+	}
+
+	if redactor != nil {
+		exported.SenderID, exported.Content = redactForExport(message, redactor)
+	}
+
+	return exported
+}
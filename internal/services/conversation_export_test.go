@@ -0,0 +1,140 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func exportTestMessages() []domain.Message {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	return []domain.Message{
+		{ID: "msg1", SenderType: domain.SenderTypeUser, SenderID: "customer-42", Content: "Hola, mi correo es ana@example.com", ContentType: domain.ContentTypeText, Timestamp: start},
+		{ID: "msg2", SenderType: domain.SenderTypeAgent, SenderID: "agent-7", Content: "Gracias, \"Ana\", lo reviso,\nun momento", ContentType: domain.ContentTypeText, Timestamp: start.Add(time.Minute)},
+		{ID: "msg3", SenderType: domain.SenderTypeBot, SenderID: "bot-1", Content: "Encuesta enviada", ContentType: domain.ContentTypeText, Timestamp: start.Add(2 * time.Minute)},
+	}
+}
+
+func TestMessagingService_ExportConversation_CSV(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo})
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	messages := exportTestMessages()
+	mockMessageRepo.On("StreamByConversationID", mock.Anything, "conv123", []domain.SenderType(nil)).Return(messages, nil)
+
+	var out bytes.Buffer
+	writer, err := NewExportWriter(ExportFormatCSV, &out)
+	require.NoError(t, err)
+
+	// Execute
+	err = service.ExportConversation(context.Background(), "conv123", "user123", ExportOptions{}, writer)
+
+	// Assert: one row per message after the header, content with commas, quotes and newlines intact
+	require.NoError(t, err)
+	rows, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, len(messages)+1)
+	assert.Equal(t, []string{"id", "sender_type", "sender_id", "content", "content_type", "timestamp"}, rows[0])
+	assert.Equal(t, []string{"msg2", "agent", "agent-7", "Gracias, \"Ana\", lo reviso,\nun momento", "text", "2026-03-01T09:01:00Z"}, rows[2])
+}
+
+func TestMessagingService_ExportConversation_JSON(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo})
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	messages := exportTestMessages()
+	mockMessageRepo.On("StreamByConversationID", mock.Anything, "conv123", []domain.SenderType(nil)).Return(messages, nil)
+
+	var out bytes.Buffer
+	writer, err := NewExportWriter(ExportFormatJSON, &out)
+	require.NoError(t, err)
+
+	// Execute
+	err = service.ExportConversation(context.Background(), "conv123", "user123", ExportOptions{}, writer)
+
+	// Assert
+	require.NoError(t, err)
+	require.True(t, json.Valid(out.Bytes()), out.String())
+	var exported []ExportedMessage
+	require.NoError(t, json.Unmarshal(out.Bytes(), &exported))
+	require.Len(t, exported, len(messages))
+	assert.Equal(t, "msg1", exported[0].ID)
+	assert.Equal(t, "customer-42", exported[0].SenderID)
+	assert.True(t, exported[2].Timestamp.Equal(messages[2].Timestamp))
+}
+
+func TestMessagingService_ExportConversation_EmptyJSON(t *testing.T) {
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo})
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("StreamByConversationID", mock.Anything, "conv123", []domain.SenderType(nil)).Return([]domain.Message{}, nil)
+
+	var out bytes.Buffer
+	writer, _ := NewExportWriter(ExportFormatJSON, &out)
+	require.NoError(t, service.ExportConversation(context.Background(), "conv123", "user123", ExportOptions{}, writer))
+
+	assert.JSONEq(t, `[]`, out.String())
+}
+
+func TestMessagingService_ExportConversation_Redacted(t *testing.T) {
+	// Setup: system messages are left out by the query
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo})
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("StreamByConversationID", mock.Anything, "conv123", []domain.SenderType{domain.SenderTypeBot, domain.SenderTypeSystem}).
+		Return(exportTestMessages()[:2], nil)
+
+	var out bytes.Buffer
+	writer, _ := NewExportWriter(ExportFormatJSON, &out)
+
+	// Execute
+	err := service.ExportConversation(context.Background(), "conv123", "user123", ExportOptions{
+		Redact:             true,
+		ExcludeSenderTypes: []domain.SenderType{domain.SenderTypeBot},
+	}, writer)
+
+	// Assert: role labels instead of sender IDs, PII masked
+	require.NoError(t, err)
+	var exported []ExportedMessage
+	require.NoError(t, json.Unmarshal(out.Bytes(), &exported))
+	require.Len(t, exported, 2)
+	assert.Equal(t, "customer", exported[0].SenderID)
+	assert.Equal(t, "agent", exported[1].SenderID)
+	assert.Equal(t, "Hola, mi correo es [REDACTED_EMAIL]", exported[0].Content)
+	assert.NotContains(t, out.String(), "customer-42")
+}
+
+func TestMessagingService_ExportConversation_Rejected(t *testing.T) {
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo})
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	var out bytes.Buffer
+	writer, _ := NewExportWriter(ExportFormatCSV, &out)
+
+	// Only the owner may export, and nothing is written before the check
+	err := service.ExportConversation(context.Background(), "conv123", "intruder", ExportOptions{}, writer)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.Zero(t, out.Len())
+	mockMessageRepo.AssertNotCalled(t, "StreamByConversationID", mock.Anything, mock.Anything, mock.Anything)
+
+	err = service.ExportConversation(context.Background(), "conv123", "user123", ExportOptions{ExcludeSenderTypes: []domain.SenderType{"robot"}}, writer)
+	assert.ErrorIs(t, err, ErrInvalidMessageFilter)
+
+	_, err = NewExportWriter("xml", &out)
+	assert.ErrorIs(t, err, ErrInvalidExportFormat)
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// cachedConversationList devuelve el listado cacheado del usuario y la versión con la que debe guardarse
+// si no lo estaba. cacheable es false si no se pudo leer la versión, en cuyo caso no se usa la caché
+func (s *messagingService) cachedConversationList(ctx context.Context, userID string, filters domain.ConversationFilters) (conversations []domain.Conversation, version int64, cacheable bool) {
+	if s.cacheService == nil {
+		return nil, 0, false
+	}
+
+	version, err := s.cacheService.ConversationListVersion(ctx, userID)
+	if err != nil {
+		return nil, 0, false
+	}
+
+	if cached, err := s.cacheService.GetConversationList(ctx, userID, version, filters); err == nil {
+		return cached, version, true
+	}
+
+	return nil, version, true
+}
+
+// invalidateConversationList descarta los listados cacheados del usuario tras un cambio en cualquiera
+// de sus conversaciones (mensaje nuevo, estado, lectura, alta)
+func (s *messagingService) invalidateConversationList(ctx context.Context, userID string) {
+	if s.cacheService == nil || userID == "" {
+		return
+	}
+
+	if err := s.cacheService.InvalidateConversationList(ctx, userID); err != nil {
+		s.log(ctx).Warn("Failed to invalidate conversation list cache", map[string]interface{}{
+			"user_id": userID,
+			"error":   err.Error(),
+		})
+	}
+}
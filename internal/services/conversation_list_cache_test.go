@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryListCache guarda en memoria solo el listado de conversaciones; el resto se comporta como la caché desactivada
+type memoryListCache struct {
+	CacheService
+	versions map[string]int64
+	lists    map[string][]domain.Conversation
+}
+
+func newMemoryListCache() *memoryListCache {
+	return &memoryListCache{
+		CacheService: NewNoOpCacheService(),
+		versions:     map[string]int64{},
+		lists:        map[string][]domain.Conversation{},
+	}
+}
+
+func (c *memoryListCache) ConversationListVersion(ctx context.Context, userID string) (int64, error) {
+	return c.versions[userID], nil
+}
+
+func (c *memoryListCache) GetConversationList(ctx context.Context, userID string, version int64, filters domain.ConversationFilters) ([]domain.Conversation, error) {
+	if list, ok := c.lists[conversationListKey(userID, version, filters)]; ok {
+		return list, nil
+	}
+	return nil, fmt.Errorf("conversation list not found in cache")
+}
+
+func (c *memoryListCache) SetConversationList(ctx context.Context, userID string, version int64, filters domain.ConversationFilters, conversations []domain.Conversation) error {
+	c.lists[conversationListKey(userID, version, filters)] = conversations
+	return nil
+}
+
+func (c *memoryListCache) InvalidateConversationList(ctx context.Context, userID string) error {
+	c.versions[userID]++
+	return nil
+}
+
+func TestMessagingService_GetConversations_CachedUntilChange(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	cache := newMemoryListCache()
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		cache,
+		logger.NewLogger("debug"),
+	)
+
+	filters := domain.ConversationFilters{Limit: 20}
+	mockConversationRepo.On("GetByUserID", mock.Anything, "user123", filters).Return([]domain.Conversation{{ID: "conv1", UserID: "user123"}}, nil)
+	mockConversationRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Conversation")).Return(nil)
+
+	// Execute: the second inbox open is served from the cache
+	_, err := service.GetConversations(context.Background(), "user123", filters)
+	require.NoError(t, err)
+	conversations, err := service.GetConversations(context.Background(), "user123", filters)
+	require.NoError(t, err)
+	assert.Len(t, conversations, 1)
+	mockConversationRepo.AssertNumberOfCalls(t, "GetByUserID", 1)
+
+	// A new conversation bumps the version, so the list is queried again
+	_, err = service.CreateConversation(context.Background(), "user123", domain.ChannelWeb, nil)
+	require.NoError(t, err)
+	_, err = service.GetConversations(context.Background(), "user123", filters)
+	require.NoError(t, err)
+	mockConversationRepo.AssertNumberOfCalls(t, "GetByUserID", 2)
+
+	// Other users and other filters are cached separately
+	otherFilters := domain.ConversationFilters{Limit: 20, Status: domain.ConversationStatusClosed}
+	mockConversationRepo.On("GetByUserID", mock.Anything, "user123", otherFilters).Return([]domain.Conversation{}, nil)
+	_, err = service.GetConversations(context.Background(), "user123", otherFilters)
+	require.NoError(t, err)
+	mockConversationRepo.AssertNumberOfCalls(t, "GetByUserID", 3)
+}
+
+func TestMessagingService_GetConversations_ChangeDuringQueryNotCached(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	cache := newMemoryListCache()
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		cache,
+		logger.NewLogger("debug"),
+	)
+
+	// A message arrives while the list is being read from the database
+	filters := domain.ConversationFilters{Limit: 20}
+	mockConversationRepo.On("GetByUserID", mock.Anything, "user123", filters).
+		Run(func(args mock.Arguments) { cache.versions["user123"]++ }).
+		Return([]domain.Conversation{{ID: "conv1", UserID: "user123"}}, nil)
+
+	// Execute
+	_, err := service.GetConversations(context.Background(), "user123", filters)
+	require.NoError(t, err)
+	_, err = service.GetConversations(context.Background(), "user123", filters)
+	require.NoError(t, err)
+
+	// Assert: the possibly stale result was stored under the old version and never served
+	mockConversationRepo.AssertNumberOfCalls(t, "GetByUserID", 2)
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrConversationLocked indica que otro agente tiene el bloqueo de la conversación
+	ErrConversationLocked = errors.New("conversation locked")
+	// ErrConversationLocksDisabled indica que el servicio no tiene dónde guardar bloqueos
+	ErrConversationLocksDisabled = errors.New("conversation locks are not enabled")
+	// ErrInvalidLock indica una petición de bloqueo con parámetros no válidos
+	ErrInvalidLock = errors.New("invalid conversation lock")
+)
+
+// ConversationLocker guarda los bloqueos blandos de las conversaciones, cada uno con su caducidad
+type ConversationLocker interface {
+	// Acquire toma el bloqueo para agentID, o lo renueva si ya es suyo. Devuelve el bloqueo vigente y si
+	// pertenece a agentID
+	Acquire(ctx context.Context, conversationID string, agentID string, ttl time.Duration) (*domain.ConversationLock, bool, error)
+	// Release libera el bloqueo si es de agentID; devuelve el bloqueo de otro agente si lo hay
+	Release(ctx context.Context, conversationID string, agentID string) (*domain.ConversationLock, error)
+	// Get devuelve el bloqueo vigente, o nil si la conversación no está bloqueada
+	Get(ctx context.Context, conversationID string) (*domain.ConversationLock, error)
+}
+
+// renewLockScript renueva el bloqueo si es de quien lo pide o lo toma si caducó tras el SET NX; si es de
+// otro, devuelve su titular y el tiempo que le queda
+var renewLockScript = redis.NewScript(`
+local holder = redis.call('GET', KEYS[1])
+if not holder or holder == ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+	return {ARGV[1], tonumber(ARGV[2])}
+end
+return {holder, redis.call('PTTL', KEYS[1])}
+`)
+
+// releaseLockScript borra el bloqueo solo si es de quien lo pide; si no, devuelve su titular y el tiempo que le queda
+var releaseLockScript = redis.NewScript(`
+local holder = redis.call('GET', KEYS[1])
+if not holder then
+	return {'', 0}
+end
+if holder == ARGV[1] then
+	redis.call('DEL', KEYS[1])
+	return {'', 0}
+end
+return {holder, redis.call('PTTL', KEYS[1])}
+`)
+
+type redisConversationLocker struct {
+	client *redis.Client
+	logger logger.Logger
+}
+
+func NewRedisConversationLocker(client *redis.Client, logger logger.Logger) ConversationLocker {
+	return &redisConversationLocker{
+		client: client,
+		logger: logger,
+	}
+}
+
+func conversationLockKey(conversationID string) string {
+	return fmt.Sprintf("conversation_lock:%s", conversationID)
+}
+
+func (l *redisConversationLocker) Acquire(ctx context.Context, conversationID string, agentID string, ttl time.Duration) (*domain.ConversationLock, bool, error) {
+	key := conversationLockKey(conversationID)
+
+	acquired, err := l.client.SetNX(ctx, key, agentID, ttl).Result()
+	if err != nil {
+		l.logger.Error("Failed to acquire conversation lock", err)
+		return nil, false, fmt.Errorf("failed to acquire conversation lock: %w", err)
+	}
+	if acquired {
+		return newConversationLock(conversationID, agentID, ttl), true, nil
+	}
+
+	holder, remaining, err := runLockScript(ctx, l.client, renewLockScript, key, agentID, ttl.Milliseconds())
+	if err != nil {
+		l.logger.Error("Failed to renew conversation lock", err)
+		return nil, false, fmt.Errorf("failed to renew conversation lock: %w", err)
+	}
+	return newConversationLock(conversationID, holder, remaining), holder == agentID, nil
+}
+
+func (l *redisConversationLocker) Release(ctx context.Context, conversationID string, agentID string) (*domain.ConversationLock, error) {
+	holder, remaining, err := runLockScript(ctx, l.client, releaseLockScript, conversationLockKey(conversationID), agentID)
+	if err != nil {
+		l.logger.Error("Failed to release conversation lock", err)
+		return nil, fmt.Errorf("failed to release conversation lock: %w", err)
+	}
+	if holder == "" {
+		return nil, nil
+	}
+
+	return newConversationLock(conversationID, holder, remaining), nil
+}
+
+func (l *redisConversationLocker) Get(ctx context.Context, conversationID string) (*domain.ConversationLock, error) {
+	key := conversationLockKey(conversationID)
+
+	pipe := l.client.Pipeline()
+	holderCmd := pipe.Get(ctx, key)
+	ttlCmd := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get conversation lock: %w", err)
+	}
+
+	holder, err := holderCmd.Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation lock: %w", err)
+	}
+
+	return newConversationLock(conversationID, holder, ttlCmd.Val()), nil
+}
+
+// runLockScript ejecuta un script de bloqueo y devuelve el titular ("" si no hay) y el tiempo que le queda
+func runLockScript(ctx context.Context, client *redis.Client, script *redis.Script, key string, args ...interface{}) (string, time.Duration, error) {
+	result, err := script.Run(ctx, client, []string{key}, args...).Slice()
+	if err != nil {
+		return "", 0, err
+	}
+	if len(result) != 2 {
+		return "", 0, fmt.Errorf("unexpected lock script result: %v", result)
+	}
+
+	holder, _ := result[0].(string)
+	remaining, _ := result[1].(int64)
+	return holder, time.Duration(remaining) * time.Millisecond, nil
+}
+
+func newConversationLock(conversationID string, agentID string, remaining time.Duration) *domain.ConversationLock {
+	return &domain.ConversationLock{
+		ConversationID: conversationID,
+		AgentID:        agentID,
+		ExpiresAt:      time.Now().Add(remaining),
+	}
+}
+
+// conversationLocks agrupa la configuración de los bloqueos de conversación
+type conversationLocks struct {
+	locker     ConversationLocker
+	defaultTTL time.Duration
+	maxTTL     time.Duration
+}
+
+// WithConversationLocks habilita los bloqueos blandos de conversación. Un bloqueo sin duración usa
+// defaultTTL y ninguno puede durar más de maxTTL
+func WithConversationLocks(locker ConversationLocker, defaultTTL time.Duration, maxTTL time.Duration) MessagingServiceOption {
+	return func(s *messagingService) {
+		if locker == nil {
+			return
+		}
+
+		s.locks = &conversationLocks{
+			locker:     locker,
+			defaultTTL: defaultTTL,
+			maxTTL:     maxTTL,
+		}
+	}
+}
+
+// AcquireConversationLock marca la conversación como atendida por agentID durante ttl (0 usa la duración
+// por defecto). Si el bloqueo ya es suyo lo renueva; si es de otro agente devuelve ErrConversationLocked.
+// El bloqueo es blando: avisa a los demás agentes pero no impide que escriban
+func (s *messagingService) AcquireConversationLock(ctx context.Context, conversationID string, agentID string, ttl time.Duration) (*domain.ConversationLock, error) {
+	if s.locks == nil {
+		return nil, ErrConversationLocksDisabled
+	}
+	if ttl < 0 || ttl > s.locks.maxTTL {
+		return nil, fmt.Errorf("%w: ttl must be between 0 and %s", ErrInvalidLock, s.locks.maxTTL)
+	}
+	if ttl == 0 {
+		ttl = s.locks.defaultTTL
+	}
+
+	if _, err := s.GetConversation(ctx, conversationID, agentID); err != nil {
+		return nil, err
+	}
+
+	lock, acquired, err := s.locks.locker.Acquire(ctx, conversationID, agentID, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return lock, conversationLockedError(lock)
+	}
+
+	return lock, nil
+}
+
+// ReleaseConversationLock libera el bloqueo de agentID. Liberar una conversación sin bloqueo no es un error;
+// la de otro agente devuelve ErrConversationLocked
+func (s *messagingService) ReleaseConversationLock(ctx context.Context, conversationID string, agentID string) error {
+	if s.locks == nil {
+		return ErrConversationLocksDisabled
+	}
+
+	if _, err := s.GetConversation(ctx, conversationID, agentID); err != nil {
+		return err
+	}
+
+	held, err := s.locks.locker.Release(ctx, conversationID, agentID)
+	if err != nil {
+		return err
+	}
+	if held != nil {
+		return conversationLockedError(held)
+	}
+
+	return nil
+}
+
+// attachConversationLock añade a la conversación quién la tiene bloqueada. Un fallo del almacén de
+// bloqueos solo se registra: el bloqueo es informativo y no debe impedir leer la conversación
+func (s *messagingService) attachConversationLock(ctx context.Context, conversation *domain.Conversation) {
+	if s.locks == nil {
+		return
+	}
+
+	lock, err := s.locks.locker.Get(ctx, conversation.ID)
+	if err != nil {
+		s.log(ctx).Error("Failed to get conversation lock", err)
+		return
+	}
+	conversation.Lock = lock
+}
+
+func conversationLockedError(lock *domain.ConversationLock) error {
+	return fmt.Errorf("%w by %s until %s", ErrConversationLocked, lock.AgentID, lock.ExpiresAt.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryConversationLocker guarda los bloqueos en memoria con la misma semántica que el de Redis
+type memoryConversationLocker struct {
+	locks map[string]domain.ConversationLock
+}
+
+func newMemoryConversationLocker() *memoryConversationLocker {
+	return &memoryConversationLocker{locks: make(map[string]domain.ConversationLock)}
+}
+
+func (l *memoryConversationLocker) Acquire(ctx context.Context, conversationID string, agentID string, ttl time.Duration) (*domain.ConversationLock, bool, error) {
+	if lock, ok := l.current(conversationID); ok && lock.AgentID != agentID {
+		return lock, false, nil
+	}
+
+	lock := domain.ConversationLock{ConversationID: conversationID, AgentID: agentID, ExpiresAt: time.Now().Add(ttl)}
+	l.locks[conversationID] = lock
+	return &lock, true, nil
+}
+
+func (l *memoryConversationLocker) Release(ctx context.Context, conversationID string, agentID string) (*domain.ConversationLock, error) {
+	lock, ok := l.current(conversationID)
+	if !ok {
+		return nil, nil
+	}
+	if lock.AgentID != agentID {
+		return lock, nil
+	}
+
+	delete(l.locks, conversationID)
+	return nil, nil
+}
+
+func (l *memoryConversationLocker) Get(ctx context.Context, conversationID string) (*domain.ConversationLock, error) {
+	lock, _ := l.current(conversationID)
+	return lock, nil
+}
+
+func (l *memoryConversationLocker) current(conversationID string) (*domain.ConversationLock, bool) {
+	lock, ok := l.locks[conversationID]
+	if !ok || !time.Now().Before(lock.ExpiresAt) {
+		return nil, false
+	}
+	return &lock, true
+}
+
+func TestMessagingService_ConversationLock(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	locker := newMemoryConversationLocker()
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithConversationLocks(locker, 5*time.Minute, 30*time.Minute),
+	)
+
+	// Both agents act as trusted services so ownership doesn't get in the way
+	ctx := auth.WithServiceIdentity(context.Background(), "agent-desk")
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "customer"}, nil)
+
+	// Execute: the first agent takes the lock with the default ttl
+	lock, err := service.AcquireConversationLock(ctx, "conv123", "agent1", 0)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), lock.ExpiresAt, time.Second)
+
+	// The holder shows up when reading the conversation
+	conversation, err := service.GetConversation(ctx, "conv123", "agent2")
+	require.NoError(t, err)
+	require.NotNil(t, conversation.Lock)
+	assert.Equal(t, "agent1", conversation.Lock.AgentID)
+
+	// A second agent can neither take nor release it
+	_, err = service.AcquireConversationLock(ctx, "conv123", "agent2", 0)
+	assert.ErrorIs(t, err, ErrConversationLocked)
+	assert.ErrorIs(t, service.ReleaseConversationLock(ctx, "conv123", "agent2"), ErrConversationLocked)
+
+	// The holder renews and then releases it
+	_, err = service.AcquireConversationLock(ctx, "conv123", "agent1", 10*time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, service.ReleaseConversationLock(ctx, "conv123", "agent1"))
+	_, err = service.AcquireConversationLock(ctx, "conv123", "agent2", 0)
+	assert.NoError(t, err)
+
+	// Locks can't outlive the configured maximum
+	_, err = service.AcquireConversationLock(ctx, "conv123", "agent2", time.Hour)
+	assert.ErrorIs(t, err, ErrInvalidLock)
+}
+
+func TestMessagingService_ConversationLock_Expires(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	locker := newMemoryConversationLocker()
+	service := NewMessagingService(mockConversationRepo, nil, nil, nil, nil, logger.NewLogger("debug"), WithConversationLocks(locker, time.Minute, time.Minute))
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	locker.locks["conv123"] = domain.ConversationLock{ConversationID: "conv123", AgentID: "crashed", ExpiresAt: time.Now().Add(-time.Second)}
+
+	// Execute: an expired lock no longer blocks anyone
+	lock, err := service.AcquireConversationLock(context.Background(), "conv123", "user123", 0)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "user123", lock.AgentID)
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// ErrInvalidMetadata indica unos metadatos de conversación no válidos
+var ErrInvalidMetadata = errors.New("invalid conversation metadata")
+
+// maxConversationMetadataSize limita el tamaño en JSON de los metadatos de una conversación, que viajan en
+// cada lectura y en la caché
+const maxConversationMetadataSize = 16 * 1024
+
+// validateConversationMetadata comprueba que los metadatos no superen maxConversationMetadataSize
+func validateConversationMetadata(metadata domain.JSONB) error {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMetadata, err)
+	}
+	if len(encoded) > maxConversationMetadataSize {
+		return fmt.Errorf("%w: metadata exceeds %d bytes", ErrInvalidMetadata, maxConversationMetadataSize)
+	}
+
+	return nil
+}
+
+// UpdateConversationMetadata mezcla patch en los metadatos de la conversación como JSON Merge Patch: las
+// claves con null se eliminan, los objetos anidados se mezclan y el resto de valores se sustituyen.
+// Devuelve los metadatos resultantes
+func (s *messagingService) UpdateConversationMetadata(ctx context.Context, conversationID string, patch domain.JSONB, userID string) (domain.JSONB, error) {
+	if patch == nil {
+		return nil, fmt.Errorf("%w: patch must be a JSON object", ErrInvalidMetadata)
+	}
+
+	conversation, err := s.GetConversation(ctx, conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := s.conversationRepo.UpdateMetadata(ctx, conversation.ID, func(current domain.JSONB) (domain.JSONB, error) {
+		merged := current.MergePatch(patch)
+		if err := validateConversationMetadata(merged); err != nil {
+			return nil, err
+		}
+		return merged, nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidMetadata) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update conversation metadata: %w", err)
+	}
+
+	if s.cacheService != nil {
+		_ = s.cacheService.DeleteConversation(ctx, conversation.ID)
+	}
+	s.invalidateConversationList(ctx, conversation.UserID)
+
+	return metadata, nil
+}
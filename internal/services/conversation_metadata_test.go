@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONBMergePatch(t *testing.T) {
+	current := domain.JSONB{
+		"customer": map[string]interface{}{"phone": "+34600111222", "crm": map[string]interface{}{"id": "CRM-42", "stage": "lead"}},
+		"priority": "high",
+		"labels":   []interface{}{"a", "b"},
+	}
+
+	merged := current.MergePatch(domain.JSONB{
+		"customer": map[string]interface{}{"crm": map[string]interface{}{"stage": "won"}, "phone": nil},
+		"priority": nil,
+		"labels":   []interface{}{"c"},
+		"source":   "campaign",
+	})
+
+	// Nested objects merge key by key, null deletes and arrays are replaced as a whole
+	assert.Equal(t, domain.JSONB{
+		"customer": map[string]interface{}{"crm": map[string]interface{}{"id": "CRM-42", "stage": "won"}},
+		"labels":   []interface{}{"c"},
+		"source":   "campaign",
+	}, merged)
+	// The original metadata is not modified
+	assert.Equal(t, "lead", current["customer"].(map[string]interface{})["crm"].(map[string]interface{})["stage"])
+	assert.Equal(t, "high", current["priority"])
+
+	// A patch object replaces a scalar, and merging into no metadata starts from an empty object
+	assert.Equal(t, domain.JSONB{"priority": map[string]interface{}{"level": "high"}},
+		current.MergePatch(domain.JSONB{"customer": nil, "labels": nil, "priority": map[string]interface{}{"level": "high"}}))
+	assert.Equal(t, domain.JSONB{"a": map[string]interface{}{"b": "c"}},
+		domain.JSONB(nil).MergePatch(domain.JSONB{"a": map[string]interface{}{"b": "c", "d": nil}}))
+}
+
+func TestMessagingService_UpdateConversationMetadata(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	conversation := &domain.Conversation{ID: "conv123", UserID: "user123", Channel: domain.ChannelWeb}
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(conversation, nil)
+	mockConversationRepo.On("UpdateMetadata", mock.Anything, "conv123").
+		Return(domain.JSONB{"crm_id": "CRM-42", "priority": "low"}, nil)
+
+	// Execute
+	metadata, err := service.UpdateConversationMetadata(context.Background(), "conv123", domain.JSONB{"priority": "high", "crm_id": nil}, "user123")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, domain.JSONB{"priority": "high"}, metadata)
+	mockConversationRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_UpdateConversationMetadata_Invalid(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	conversation := &domain.Conversation{ID: "conv123", UserID: "user123", Channel: domain.ChannelWeb}
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(conversation, nil)
+	mockConversationRepo.On("UpdateMetadata", mock.Anything, "conv123").Return(domain.JSONB{}, nil)
+
+	// Execute
+	_, noPatch := service.UpdateConversationMetadata(context.Background(), "conv123", nil, "user123")
+	_, tooLarge := service.UpdateConversationMetadata(context.Background(), "conv123", domain.JSONB{"notes": strings.Repeat("x", maxConversationMetadataSize)}, "user123")
+	_, createTooLarge := service.CreateConversation(context.Background(), "user123", domain.ChannelWeb, domain.JSONB{"notes": strings.Repeat("x", maxConversationMetadataSize)})
+
+	// Assert
+	assert.ErrorIs(t, noPatch, ErrInvalidMetadata)
+	assert.ErrorIs(t, tooLarge, ErrInvalidMetadata)
+	assert.ErrorIs(t, createTooLarge, ErrInvalidMetadata)
+	mockConversationRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+var (
+	// ErrParticipantsDisabled indica que el servicio no tiene repositorio de participantes
+	ErrParticipantsDisabled = errors.New("participants are not enabled")
+	// ErrInvalidParticipant indica una solicitud de participante no válida
+	ErrInvalidParticipant = errors.New("invalid participant")
+)
+
+// AddParticipantRequest es la solicitud de POST /conversations/:id/participants
+type AddParticipantRequest struct {
+	UserID string                 `json:"user_id" binding:"required"`
+	Role   domain.ParticipantRole `json:"role,omitempty"` // Por defecto member
+}
+
+// canAccessConversation indica si el usuario puede leer y escribir en la conversación: su propietario,
+// sus participantes y los servicios internos de confianza
+func (s *messagingService) canAccessConversation(ctx context.Context, conversation *domain.Conversation, userID string) (bool, error) {
+	if conversation.UserID == userID || isTrustedService(ctx) {
+		return true, nil
+	}
+	if s.participantRepo == nil || userID == "" {
+		return false, nil
+	}
+
+	found, err := s.participantRepo.FilterParticipants(ctx, conversation.ID, []string{userID})
+	if err != nil {
+		return false, fmt.Errorf("failed to check participants: %w", err)
+	}
+	return len(found) > 0, nil
+}
+
+// getOwnedConversation obtiene la conversación solo si el usuario es su propietario o un servicio interno
+func (s *messagingService) getOwnedConversation(ctx context.Context, conversationID string, userID string) (*domain.Conversation, error) {
+	conversation, err := s.conversationRepo.GetByID(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+	if conversation.UserID != userID && !isTrustedService(ctx) {
+		return nil, fmt.Errorf("conversation %w: %w", domain.ErrAccessDenied, domain.ErrNotFound)
+	}
+	return conversation, nil
+}
+
+// AddParticipant da acceso a la conversación a otro usuario, p. ej. un agente que se une a la conversación
+// de un cliente. Solo el propietario o un servicio interno pueden añadir participantes; si el usuario ya
+// participa se actualiza su rol
+func (s *messagingService) AddParticipant(ctx context.Context, conversationID string, req AddParticipantRequest, userID string) (*domain.ConversationParticipant, error) {
+	if s.participantRepo == nil {
+		return nil, ErrParticipantsDisabled
+	}
+
+	participantID := strings.TrimSpace(req.UserID)
+	if participantID == "" {
+		return nil, fmt.Errorf("%w: user_id is required", ErrInvalidParticipant)
+	}
+	role := req.Role
+	if role == "" {
+		role = domain.ParticipantRoleMember
+	}
+	if !role.IsValid() {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidParticipant, req.Role)
+	}
+
+	conversation, err := s.getOwnedConversation(ctx, conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if participantID == conversation.UserID {
+		return nil, fmt.Errorf("%w: the owner already has access to the conversation", ErrInvalidParticipant)
+	}
+
+	participant := &domain.ConversationParticipant{
+		ConversationID: conversationID,
+		UserID:         participantID,
+		Role:           role,
+		JoinedAt:       time.Now(),
+	}
+	if err := s.participantRepo.AddParticipant(ctx, participant); err != nil {
+		return nil, fmt.Errorf("failed to add participant: %w", err)
+	}
+
+	// The owner's list shows the read state of every participant
+	s.invalidateConversationList(ctx, conversation.UserID)
+
+	s.log(ctx).Info("Participant added to conversation", map[string]interface{}{
+		"conversation_id": conversationID,
+		"participant_id":  participantID,
+		"role":            role,
+		"user_id":         userID,
+	})
+
+	return participant, nil
+}
+
+// RemoveParticipant retira el acceso de un participante. Pueden hacerlo el propietario, un servicio
+// interno o el propio participante al abandonar la conversación
+func (s *messagingService) RemoveParticipant(ctx context.Context, conversationID string, participantID string, userID string) error {
+	if s.participantRepo == nil {
+		return ErrParticipantsDisabled
+	}
+
+	var conversation *domain.Conversation
+	var err error
+	if participantID == userID {
+		conversation, err = s.GetConversation(ctx, conversationID, userID)
+	} else {
+		conversation, err = s.getOwnedConversation(ctx, conversationID, userID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.participantRepo.RemoveParticipant(ctx, conversationID, participantID); err != nil {
+		return fmt.Errorf("failed to remove participant: %w", err)
+	}
+
+	s.invalidateConversationList(ctx, conversation.UserID)
+
+	s.log(ctx).Info("Participant removed from conversation", map[string]interface{}{
+		"conversation_id": conversationID,
+		"participant_id":  participantID,
+		"user_id":         userID,
+	})
+
+	return nil
+}
+
+// ListParticipants devuelve los participantes de la conversación, sin incluir a su propietario
+func (s *messagingService) ListParticipants(ctx context.Context, conversationID string, userID string) ([]domain.ConversationParticipant, error) {
+	if s.participantRepo == nil {
+		return nil, ErrParticipantsDisabled
+	}
+
+	if _, err := s.GetConversation(ctx, conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	participants, err := s.participantRepo.ListParticipants(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list participants: %w", err)
+	}
+
+	return participants, nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessagingService_GetMessages_ParticipantAccess(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockParticipantRepo := new(MockParticipantRepository)
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		mockAttachmentRepo,
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithParticipantRepository(mockParticipantRepo),
+	)
+
+	pagination := domain.PaginationParams{Limit: 20}
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "customer1"}, nil)
+	mockParticipantRepo.On("FilterParticipants", mock.Anything, "conv123", []string{"agent1"}).Return([]string{"agent1"}, nil)
+	mockParticipantRepo.On("FilterParticipants", mock.Anything, "conv123", []string{"stranger"}).Return([]string{}, nil)
+	mockMessageRepo.On("GetByConversationID", mock.Anything, "conv123", pagination).Return([]domain.Message{{ID: "msg1", ConversationID: "conv123"}}, nil)
+	mockMessageRepo.On("CountReplies", mock.Anything, mock.Anything).Return(map[string]int{}, nil)
+	mockAttachmentRepo.On("GetByMessageID", mock.Anything, "msg1").Return([]domain.Attachment{}, nil)
+
+	// A participant reads the conversation like its owner
+	messages, err := service.GetMessages(context.Background(), "conv123", "agent1", pagination)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "msg1", messages[0].ID)
+
+	// Anyone else is denied
+	_, err = service.GetMessages(context.Background(), "conv123", "stranger", pagination)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	mockMessageRepo.AssertNumberOfCalls(t, "GetByConversationID", 1)
+}
+
+func TestMessagingService_AddParticipant(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockParticipantRepo := new(MockParticipantRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo}, WithParticipantRepository(mockParticipantRepo))
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "customer1"}, nil)
+	mockParticipantRepo.On("AddParticipant", mock.Anything, mock.AnythingOfType("*domain.ConversationParticipant")).Return(nil)
+
+	// The owner adds an agent
+	participant, err := service.AddParticipant(context.Background(), "conv123", AddParticipantRequest{UserID: "agent1", Role: domain.ParticipantRoleAgent}, "customer1")
+	require.NoError(t, err)
+	assert.Equal(t, "agent1", participant.UserID)
+	assert.Equal(t, domain.ParticipantRoleAgent, participant.Role)
+
+	// The role defaults to member
+	participant, err = service.AddParticipant(context.Background(), "conv123", AddParticipantRequest{UserID: "user2"}, "customer1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.ParticipantRoleMember, participant.Role)
+
+	// Unknown roles and the owner itself are rejected
+	_, err = service.AddParticipant(context.Background(), "conv123", AddParticipantRequest{UserID: "user3", Role: "admin"}, "customer1")
+	assert.ErrorIs(t, err, ErrInvalidParticipant)
+	_, err = service.AddParticipant(context.Background(), "conv123", AddParticipantRequest{UserID: "customer1"}, "customer1")
+	assert.ErrorIs(t, err, ErrInvalidParticipant)
+
+	// Only the owner can add participants
+	_, err = service.AddParticipant(context.Background(), "conv123", AddParticipantRequest{UserID: "user3"}, "agent1")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	mockParticipantRepo.AssertNumberOfCalls(t, "AddParticipant", 2)
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// defaultReferenceDigits es el ancho mínimo del número de las referencias, completado con ceros
+const defaultReferenceDigits = 6
+
+// conversationReferences genera las referencias legibles de las conversaciones, con un prefijo y una
+// secuencia propios por canal
+type conversationReferences struct {
+	prefixes map[domain.Channel]string
+	digits   int
+}
+
+// WithConversationReferences asigna a cada conversación nueva una referencia legible como WEB-001234.
+// prefixes fija el prefijo de cada canal (por defecto el nombre del canal en mayúsculas) y digits el
+// ancho mínimo del número
+func WithConversationReferences(prefixes map[domain.Channel]string, digits int) MessagingServiceOption {
+	return func(s *messagingService) {
+		if digits <= 0 {
+			digits = defaultReferenceDigits
+		}
+
+		normalized := make(map[domain.Channel]string, len(prefixes))
+		for channel, prefix := range prefixes {
+			if prefix = strings.ToUpper(strings.TrimSpace(prefix)); prefix != "" {
+				normalized[channel] = prefix
+			}
+		}
+
+		s.references = &conversationReferences{prefixes: normalized, digits: digits}
+	}
+}
+
+// assignReference toma el siguiente número de la secuencia del canal y fija conversation.Reference. Un
+// número consumido por una conversación que después no llega a crearse deja un hueco, igual que una secuencia
+func (s *messagingService) assignReference(ctx context.Context, conversation *domain.Conversation) error {
+	if s.references == nil {
+		return nil
+	}
+
+	prefix, ok := s.references.prefixes[conversation.Channel]
+	if !ok {
+		prefix = strings.ToUpper(string(conversation.Channel))
+	}
+
+	next, err := s.conversationRepo.NextReferenceNumber(ctx, prefix)
+	if err != nil {
+		return fmt.Errorf("failed to generate conversation reference: %w", err)
+	}
+
+	conversation.Reference = fmt.Sprintf("%s-%0*d", prefix, s.references.digits, next)
+	return nil
+}
+
+// GetConversationByReference obtiene una conversación del usuario a partir de su referencia legible
+func (s *messagingService) GetConversationByReference(ctx context.Context, reference string, userID string) (*domain.Conversation, error) {
+	conversation, err := s.conversationRepo.GetByReference(ctx, reference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	// Same access rules as the lookup by ID
+	allowed, err := s.canAccessConversation(ctx, conversation, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, fmt.Errorf("conversation %w: %w", domain.ErrAccessDenied, domain.ErrNotFound)
+	}
+
+	if s.cacheService != nil {
+		_ = s.cacheService.SetConversation(ctx, conversation)
+	}
+	s.attachConversationLock(ctx, conversation)
+
+	return conversation, nil
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// ErrInvalidConversationSearch indica una búsqueda de conversaciones sin criterios o con valores no válidos
+var ErrInvalidConversationSearch = errors.New("invalid conversation search")
+
+const (
+	// maxConversationSearchValueLength acota cada identificador buscado
+	maxConversationSearchValueLength = 255
+	// maxConversationSearchLimit acota los resultados de una página de búsqueda
+	maxConversationSearchLimit = 100
+)
+
+// ConversationSearchCriteria identifica al cliente por los datos guardados en los metadatos de sus
+// conversaciones; los criterios vacíos no filtran y los indicados deben cumplirse todos
+type ConversationSearchCriteria struct {
+	ExternalID string // metadata.external_id, p. ej. el ID del cliente en el CRM
+	Phone      string // metadata.phone, en formato E.164 como lo guarda la ingesta de WhatsApp
+}
+
+// metadata traduce los criterios al documento que deben contener los metadatos
+func (c ConversationSearchCriteria) metadata() (domain.JSONB, error) {
+	match := domain.JSONB{}
+	for key, value := range map[string]string{"external_id": c.ExternalID, "phone": c.Phone} {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if utf8.RuneCountInString(value) > maxConversationSearchValueLength {
+			return nil, fmt.Errorf("%w: %s cannot exceed %d characters", ErrInvalidConversationSearch, key, maxConversationSearchValueLength)
+		}
+		match[key] = value
+	}
+
+	if len(match) == 0 {
+		return nil, fmt.Errorf("%w: external_id or phone is required", ErrInvalidConversationSearch)
+	}
+	return match, nil
+}
+
+// SearchConversations busca las conversaciones de un cliente por su identificador externo o su teléfono.
+// Solo devuelve las que el usuario puede abrir (como propietario o participante); un servicio interno las
+// ve todas
+func (s *messagingService) SearchConversations(ctx context.Context, userID string, criteria ConversationSearchCriteria, pagination domain.PaginationParams) ([]domain.Conversation, error) {
+	match, err := criteria.metadata()
+	if err != nil {
+		return nil, err
+	}
+
+	limit := pagination.Limit
+	if limit <= 0 || limit > maxConversationSearchLimit {
+		limit = maxConversationSearchLimit
+	}
+	offset := pagination.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	accessibleBy := userID
+	if isTrustedService(ctx) {
+		accessibleBy = ""
+	}
+
+	conversations, err := s.conversationRepo.SearchByMetadata(ctx, match, accessibleBy, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search conversations: %w", err)
+	}
+
+	return conversations, nil
+}
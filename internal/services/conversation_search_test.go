@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessagingService_SearchConversations(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo})
+
+	match := domain.JSONB{"external_id": "CRM-42", "phone": "+34600111222"}
+	mockConversationRepo.On("SearchByMetadata", mock.Anything, match, "agent1", maxConversationSearchLimit, 0).
+		Return([]domain.Conversation{{ID: "conv1", Metadata: match}}, nil)
+
+	// Execute: values are trimmed and an oversized page is capped
+	conversations, err := service.SearchConversations(context.Background(), "agent1", ConversationSearchCriteria{ExternalID: " CRM-42 ", Phone: "+34600111222"}, domain.PaginationParams{Limit: 1000})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, conversations, 1)
+	assert.Equal(t, "conv1", conversations[0].ID)
+	mockConversationRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_SearchConversations_RequiresCriteria(t *testing.T) {
+	mockConversationRepo := new(MockConversationRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo})
+
+	_, err := service.SearchConversations(context.Background(), "agent1", ConversationSearchCriteria{Phone: "  "}, domain.PaginationParams{})
+
+	assert.ErrorIs(t, err, ErrInvalidConversationSearch)
+	mockConversationRepo.AssertNotCalled(t, "SearchByMetadata", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidTag indica una etiqueta o una solicitud de etiquetado no válida
+var ErrInvalidTag = errors.New("invalid tag")
+
+// maxBulkTagConversations limita cuántas conversaciones se etiquetan en una sola transacción
+const maxBulkTagConversations = 500
+
+// tagPattern es el formato de una etiqueta ya normalizada
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_:-]{0,49}$`)
+
+// BulkTagStatus es el resultado del etiquetado de una conversación
+type BulkTagStatus string
+
+const (
+	BulkTagStatusTagged        BulkTagStatus = "tagged"
+	BulkTagStatusAlreadyTagged BulkTagStatus = "already_tagged"
+	BulkTagStatusNotFound      BulkTagStatus = "not_found" // No existe o no pertenece al usuario
+)
+
+// BulkTagRequest es la solicitud de POST /conversations/bulk-tag
+type BulkTagRequest struct {
+	ConversationIDs []string `json:"conversation_ids" binding:"required"`
+	Tag             string   `json:"tag" binding:"required"`
+}
+
+// BulkTagItem es el resultado para una de las conversaciones solicitadas
+type BulkTagItem struct {
+	ConversationID string        `json:"conversation_id"`
+	Status         BulkTagStatus `json:"status"`
+}
+
+// BulkTagResult resume un etiquetado masivo, con el resultado de cada conversación en el orden solicitado
+type BulkTagResult struct {
+	Tag     string        `json:"tag"`
+	Tagged  int           `json:"tagged"`
+	Results []BulkTagItem `json:"results"`
+}
+
+// normalizeTag pasa la etiqueta a minúsculas y sustituye los espacios por guiones, de modo que
+// "Billing Issue" y "billing-issue" sean la misma etiqueta
+func normalizeTag(tag string) (string, error) {
+	normalized := strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+	if !tagPattern.MatchString(normalized) {
+		return "", fmt.Errorf("%w: %q must be 1-50 letters, digits, '-', '_' or ':'", ErrInvalidTag, tag)
+	}
+	return normalized, nil
+}
+
+// normalizeTagFilter normaliza las etiquetas de un filtro y las ordena sin duplicados, de modo que el
+// mismo filtro escrito de otra forma comparta la caché del listado
+func normalizeTagFilter(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	sort.Strings(normalized)
+
+	return normalized, nil
+}
+
+// AddTag añade la etiqueta a la conversación y devuelve sus etiquetas; añadir una que ya tenía no es un error
+func (s *messagingService) AddTag(ctx context.Context, conversationID string, tag string, userID string) ([]string, error) {
+	normalized, err := normalizeTag(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	conversation, err := s.GetConversation(ctx, conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tagged, err := s.conversationRepo.AddTagToConversations(ctx, []string{conversation.ID}, normalized, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to tag conversation: %w", err)
+	}
+
+	tags := conversationTags(conversation)
+	if tagged[conversation.ID] {
+		tags = append(tags, normalized)
+		s.invalidateConversationTags(ctx, conversation)
+	}
+
+	return tags, nil
+}
+
+// RemoveTag quita la etiqueta de la conversación y devuelve las que le quedan; quitar una que no tenía no es un error
+func (s *messagingService) RemoveTag(ctx context.Context, conversationID string, tag string, userID string) ([]string, error) {
+	normalized, err := normalizeTag(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	conversation, err := s.GetConversation(ctx, conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := s.conversationRepo.RemoveTag(ctx, conversation.ID, normalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove tag: %w", err)
+	}
+
+	tags := make([]string, 0, len(conversation.Tags))
+	for _, existing := range conversation.Tags {
+		if existing != normalized {
+			tags = append(tags, existing)
+		}
+	}
+	if removed {
+		s.invalidateConversationTags(ctx, conversation)
+	}
+
+	return tags, nil
+}
+
+// ListTags devuelve las etiquetas de la conversación en el orden en que se añadieron
+func (s *messagingService) ListTags(ctx context.Context, conversationID string, userID string) ([]string, error) {
+	conversation, err := s.GetConversation(ctx, conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return conversationTags(conversation), nil
+}
+
+// conversationTags copia las etiquetas para no compartir el slice con la conversación cacheada
+func conversationTags(conversation *domain.Conversation) []string {
+	return append(make([]string, 0, len(conversation.Tags)+1), conversation.Tags...)
+}
+
+// invalidateConversationTags descarta las copias cacheadas que ya no muestran las etiquetas actuales; los
+// listados del dueño también, porque se pueden filtrar por etiqueta
+func (s *messagingService) invalidateConversationTags(ctx context.Context, conversation *domain.Conversation) {
+	if s.cacheService != nil {
+		_ = s.cacheService.DeleteConversation(ctx, conversation.ID)
+	}
+	s.invalidateConversationList(ctx, conversation.UserID)
+}
+
+// AddTagToConversations añade la etiqueta a todas las conversaciones accesibles para el usuario en una
+// única transacción. Las conversaciones que no existen o son de otro usuario se informan como not_found
+func (s *messagingService) AddTagToConversations(ctx context.Context, conversationIDs []string, tag string, userID string) (*BulkTagResult, error) {
+	normalized, err := normalizeTag(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deduplicate while keeping the requested order for the per-conversation results. Valid ids are
+	// canonicalized so that the query and the lookup of its results use the same form as Postgres returns
+	seen := make(map[string]bool, len(conversationIDs))
+	var ids, valid []string
+	for _, id := range conversationIDs {
+		id = strings.TrimSpace(id)
+		parsed, parseErr := uuid.Parse(id)
+		if parseErr == nil {
+			id = parsed.String()
+		}
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		if parseErr == nil {
+			valid = append(valid, id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: conversation_ids is required", ErrInvalidTag)
+	}
+	if len(ids) > maxBulkTagConversations {
+		return nil, fmt.Errorf("%w: at most %d conversations per request", ErrInvalidTag, maxBulkTagConversations)
+	}
+
+	ownerID := userID
+	if isTrustedService(ctx) {
+		ownerID = ""
+	}
+
+	tagged := map[string]bool{}
+	if len(valid) > 0 {
+		if tagged, err = s.conversationRepo.AddTagToConversations(ctx, valid, normalized, ownerID); err != nil {
+			return nil, fmt.Errorf("failed to tag conversations: %w", err)
+		}
+	}
+
+	result := &BulkTagResult{Tag: normalized, Results: make([]BulkTagItem, 0, len(ids))}
+	for _, id := range ids {
+		item := BulkTagItem{ConversationID: id, Status: BulkTagStatusNotFound}
+		if added, ok := tagged[id]; ok {
+			item.Status = BulkTagStatusAlreadyTagged
+			if added {
+				item.Status = BulkTagStatusTagged
+				result.Tagged++
+
+				// Cached copies would not show the new tag
+				if s.cacheService != nil {
+					_ = s.cacheService.DeleteConversation(ctx, id)
+				}
+			}
+		}
+		result.Results = append(result.Results, item)
+	}
+
+	// Listings can be filtered by tag; a trusted service's conversations have unknown owners and expire instead
+	if result.Tagged > 0 {
+		s.invalidateConversationList(ctx, ownerID)
+	}
+
+	s.log(ctx).Info("Conversations tagged", map[string]interface{}{
+		"tag":       normalized,
+		"requested": len(ids),
+		"tagged":    result.Tagged,
+		"user_id":   userID,
+	})
+
+	return result, nil
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tag, err := normalizeTag("  Billing   Issue ")
+	require.NoError(t, err)
+	assert.Equal(t, "billing-issue", tag)
+
+	for _, invalid := range []string{"", "   ", "-leading", "tag!", "a-very-long-tag-that-goes-well-beyond-the-fifty-character-limit"} {
+		_, err := normalizeTag(invalid)
+		assert.True(t, errors.Is(err, ErrInvalidTag), invalid)
+	}
+}
+
+func TestMessagingService_AddTagToConversations(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	conv1 := "550e8400-e29b-41d4-a716-446655440001"
+	conv2 := "550e8400-e29b-41d4-a716-446655440002"
+	conv3 := "550e8400-e29b-41d4-a716-446655440003"
+	mockConversationRepo.On("AddTagToConversations", mock.Anything, []string{conv1, conv2, conv3}, "vip", "user123").
+		Return(map[string]bool{conv1: true, conv2: false}, nil)
+
+	// Execute
+	// Upper-case ids are the same conversations as their canonical form
+	result, err := service.AddTagToConversations(context.Background(), []string{conv1, strings.ToUpper(conv2), strings.ToUpper(conv1), conv3, "not-a-uuid"}, "VIP", "user123")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "vip", result.Tag)
+	assert.Equal(t, 1, result.Tagged)
+	assert.Equal(t, []BulkTagItem{
+		{ConversationID: conv1, Status: BulkTagStatusTagged},
+		{ConversationID: conv2, Status: BulkTagStatusAlreadyTagged},
+		{ConversationID: conv3, Status: BulkTagStatusNotFound},
+		{ConversationID: "not-a-uuid", Status: BulkTagStatusNotFound},
+	}, result.Results)
+	mockConversationRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_AddTagToConversations_Invalid(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	// Execute
+	_, badTag := service.AddTagToConversations(context.Background(), []string{"550e8400-e29b-41d4-a716-446655440001"}, "?", "user123")
+	_, noIDs := service.AddTagToConversations(context.Background(), []string{" "}, "vip", "user123")
+
+	// Assert
+	assert.True(t, errors.Is(badTag, ErrInvalidTag))
+	assert.True(t, errors.Is(noIDs, ErrInvalidTag))
+	mockConversationRepo.AssertNotCalled(t, "AddTagToConversations", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_AddRemoveTag(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123", Tags: []string{"vip"}}, nil)
+	mockConversationRepo.On("AddTagToConversations", mock.Anything, []string{"conv123"}, "billing-issue", "").Return(map[string]bool{"conv123": true}, nil)
+	mockConversationRepo.On("AddTagToConversations", mock.Anything, []string{"conv123"}, "vip", "").Return(map[string]bool{"conv123": false}, nil)
+	mockConversationRepo.On("RemoveTag", mock.Anything, "conv123", "vip").Return(true, nil)
+
+	// Tags are returned with the conversation
+	conversation, err := service.GetConversation(context.Background(), "conv123", "user123")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vip"}, conversation.Tags)
+
+	// Execute
+	tags, err := service.AddTag(context.Background(), "conv123", "Billing Issue", "user123")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vip", "billing-issue"}, tags)
+
+	// An existing tag is not added twice
+	tags, err = service.AddTag(context.Background(), "conv123", "VIP", "user123")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vip"}, tags)
+
+	tags, err = service.RemoveTag(context.Background(), "conv123", "vip", "user123")
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+
+	// Other users' conversations are rejected before writing
+	_, err = service.AddTag(context.Background(), "conv123", "vip", "intruder")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	mockConversationRepo.AssertNumberOfCalls(t, "AddTagToConversations", 2)
+}
+
+func TestMessagingService_GetConversations_TagFilter(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	// Tags are normalized, deduplicated and sorted before reaching the repository
+	expected := domain.ConversationFilters{Limit: 20, Tags: []string{"billing-issue", "vip"}}
+	mockConversationRepo.On("GetByUserID", mock.Anything, "user123", expected).Return([]domain.Conversation{{ID: "conv123"}}, nil)
+
+	// Execute
+	conversations, err := service.GetConversations(context.Background(), "user123", domain.ConversationFilters{Limit: 20, Tags: []string{"VIP", "billing issue", "vip"}})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, conversations, 1)
+
+	_, err = service.GetConversations(context.Background(), "user123", domain.ConversationFilters{Tags: []string{"bad tag!"}})
+	assert.ErrorIs(t, err, ErrInvalidTag)
+}
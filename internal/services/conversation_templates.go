@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidTemplate indica una plantilla mal definida o variables sin valor al renderizarla
+var ErrInvalidTemplate = errors.New("invalid template")
+
+// templateVariable reconoce los marcadores {{nombre}} del contenido de una plantilla
+var templateVariable = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+type ConversationTemplateRequest struct {
+	Name        string                   `json:"name" binding:"required"`
+	Description string                   `json:"description"`
+	Channel     domain.Channel           `json:"channel" binding:"required"`
+	Messages    []domain.TemplateMessage `json:"messages" binding:"required"`
+}
+
+// WithTemplateRepository habilita las plantillas de conversación
+func WithTemplateRepository(repo domain.ConversationTemplateRepository) MessagingServiceOption {
+	return func(s *messagingService) {
+		s.templateRepo = repo
+	}
+}
+
+func (s *messagingService) CreateTemplate(ctx context.Context, req ConversationTemplateRequest, userID string) (*domain.ConversationTemplate, error) {
+	if s.templateRepo == nil {
+		return nil, fmt.Errorf("templates not available")
+	}
+
+	if err := validateTemplateRequest(req); err != nil {
+		return nil, err
+	}
+
+	template := &domain.ConversationTemplate{
+		ID:          uuid.New().String(),
+		Name:        req.Name,
+		Description: req.Description,
+		Channel:     req.Channel,
+		Messages:    req.Messages,
+		CreatedBy:   userID,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	if err := s.templateRepo.Create(ctx, template); err != nil {
+		s.log(ctx).Error("Failed to create conversation template", err)
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+
+	s.log(ctx).Info("Conversation template created", map[string]interface{}{
+		"template_id": template.ID,
+		"name":        template.Name,
+		"user_id":     userID,
+	})
+
+	return template, nil
+}
+
+func (s *messagingService) GetTemplate(ctx context.Context, id string) (*domain.ConversationTemplate, error) {
+	if s.templateRepo == nil {
+		return nil, fmt.Errorf("templates not available")
+	}
+
+	return s.templateRepo.GetByID(ctx, id)
+}
+
+func (s *messagingService) ListTemplates(ctx context.Context, pagination domain.PaginationParams) ([]domain.ConversationTemplate, error) {
+	if s.templateRepo == nil {
+		return nil, fmt.Errorf("templates not available")
+	}
+
+	templates, err := s.templateRepo.List(ctx, pagination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+func (s *messagingService) UpdateTemplate(ctx context.Context, id string, req ConversationTemplateRequest, userID string) (*domain.ConversationTemplate, error) {
+	if s.templateRepo == nil {
+		return nil, fmt.Errorf("templates not available")
+	}
+
+	if err := validateTemplateRequest(req); err != nil {
+		return nil, err
+	}
+
+	template, err := s.templateRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	template.Name = req.Name
+	template.Description = req.Description
+	template.Channel = req.Channel
+	template.Messages = req.Messages
+	template.UpdatedAt = time.Now()
+
+	if err := s.templateRepo.Update(ctx, template); err != nil {
+		s.log(ctx).Error("Failed to update conversation template", err)
+		return nil, fmt.Errorf("failed to update template: %w", err)
+	}
+
+	s.log(ctx).Info("Conversation template updated", map[string]interface{}{
+		"template_id": template.ID,
+		"user_id":     userID,
+	})
+
+	return template, nil
+}
+
+func (s *messagingService) DeleteTemplate(ctx context.Context, id string, userID string) error {
+	if s.templateRepo == nil {
+		return fmt.Errorf("templates not available")
+	}
+
+	if err := s.templateRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.log(ctx).Info("Conversation template deleted", map[string]interface{}{
+		"template_id": id,
+		"user_id":     userID,
+	})
+
+	return nil
+}
+
+// CreateConversationFromTemplate crea una conversación con los mensajes de la plantilla ya renderizados
+func (s *messagingService) CreateConversationFromTemplate(ctx context.Context, userID string, templateID string, vars map[string]string) (*domain.Conversation, error) {
+	if s.templateRepo == nil {
+		return nil, fmt.Errorf("templates not available")
+	}
+
+	template, err := s.templateRepo.GetByID(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	conversation := &domain.Conversation{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Channel:   template.Channel,
+		Status:    domain.ConversationStatusActive,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	messages := make([]domain.Message, 0, len(template.Messages))
+	for i, step := range template.Messages {
+		content, err := renderTemplateContent(step.Content, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, domain.Message{
+			ID:             uuid.New().String(),
+			ConversationID: conversation.ID,
+			SenderType:     step.SenderType,
+			SenderID:       "template:" + template.ID,
+			Content:        content,
+			ContentType:    step.ContentType,
+			Metadata:       step.Metadata,
+			// Offset each message so the scripted order survives timestamp sorting
+			Timestamp: now.Add(time.Duration(i) * time.Millisecond),
+		})
+	}
+
+	if err := s.assignReference(ctx, conversation); err != nil {
+		return nil, err
+	}
+
+	if err := s.conversationRepo.CreateWithMessages(ctx, conversation, messages); err != nil {
+		s.log(ctx).Error("Failed to create conversation from template", err)
+		return nil, fmt.Errorf("failed to create conversation: %w", err)
+	}
+
+	s.invalidateConversationList(ctx, userID)
+	conversation.Messages = messages
+	s.publishConversationCreated(ctx, conversation)
+
+	s.log(ctx).Info("Conversation created from template", map[string]interface{}{
+		"conversation_id": conversation.ID,
+		"template_id":     template.ID,
+		"user_id":         userID,
+		"messages":        len(messages),
+	})
+
+	return conversation, nil
+}
+
+func validateTemplateRequest(req ConversationTemplateRequest) error {
+	if !isValidChannel(req.Channel) {
+		return fmt.Errorf("%w: unsupported channel %q", ErrInvalidTemplate, req.Channel)
+	}
+	if len(req.Messages) == 0 {
+		return fmt.Errorf("%w: template must contain at least one message", ErrInvalidTemplate)
+	}
+
+	for i, message := range req.Messages {
+		if message.SenderType != domain.SenderTypeBot && message.SenderType != domain.SenderTypeSystem {
+			return fmt.Errorf("%w: message %d: sender type must be bot or system", ErrInvalidTemplate, i)
+		}
+		if message.ContentType == "" {
+			return fmt.Errorf("%w: message %d: content type is required", ErrInvalidTemplate, i)
+		}
+		if !isValidContentType(message.ContentType) {
+			return fmt.Errorf("%w: message %d: unsupported content type %q", ErrInvalidTemplate, i, message.ContentType)
+		}
+		if strings.TrimSpace(message.Content) == "" {
+			return fmt.Errorf("%w: message %d: content is required", ErrInvalidTemplate, i)
+		}
+	}
+
+	return nil
+}
+
+// renderTemplateContent sustituye las variables {{nombre}} y falla si alguna no fue proporcionada
+func renderTemplateContent(content string, vars map[string]string) (string, error) {
+	missing := map[string]bool{}
+
+	rendered := templateVariable.ReplaceAllStringFunc(content, func(match string) string {
+		name := templateVariable.FindStringSubmatch(match)[1]
+		value, ok := vars[name]
+		if !ok {
+			missing[name] = true
+			return match
+		}
+		return value
+	})
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("%w: missing variables: %s", ErrInvalidTemplate, strings.Join(names, ", "))
+	}
+
+	return rendered, nil
+}
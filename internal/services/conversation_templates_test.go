@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTemplateRepository struct {
+	mock.Mock
+}
+
+func (m *MockTemplateRepository) Create(ctx context.Context, template *domain.ConversationTemplate) error {
+	args := m.Called(ctx, template)
+	return args.Error(0)
+}
+
+func (m *MockTemplateRepository) GetByID(ctx context.Context, id string) (*domain.ConversationTemplate, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ConversationTemplate), args.Error(1)
+}
+
+func (m *MockTemplateRepository) List(ctx context.Context, pagination domain.PaginationParams) ([]domain.ConversationTemplate, error) {
+	args := m.Called(ctx, pagination)
+	return args.Get(0).([]domain.ConversationTemplate), args.Error(1)
+}
+
+func (m *MockTemplateRepository) Update(ctx context.Context, template *domain.ConversationTemplate) error {
+	args := m.Called(ctx, template)
+	return args.Error(0)
+}
+
+func (m *MockTemplateRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func newOnboardingTemplate() *domain.ConversationTemplate {
+	return &domain.ConversationTemplate{
+		ID:      "tpl123",
+		Name:    "Onboarding",
+		Channel: domain.ChannelWeb,
+		Messages: []domain.TemplateMessage{
+			{SenderType: domain.SenderTypeBot, ContentType: domain.ContentTypeText, Content: "Hola {{name}}, bienvenido a {{ product }}"},
+			{SenderType: domain.SenderTypeSystem, ContentType: domain.ContentTypeText, Content: "Un agente se unirá en breve"},
+		},
+	}
+}
+
+func TestMessagingService_CreateConversationFromTemplate(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockTemplateRepo := new(MockTemplateRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithTemplateRepository(mockTemplateRepo),
+	)
+
+	mockTemplateRepo.On("GetByID", mock.Anything, "tpl123").Return(newOnboardingTemplate(), nil)
+	mockConversationRepo.On("CreateWithMessages", mock.Anything, mock.AnythingOfType("*domain.Conversation"), mock.MatchedBy(func(messages []domain.Message) bool {
+		return len(messages) == 2 &&
+			messages[0].Content == "Hola Ana, bienvenido a Acme" &&
+			messages[0].Timestamp.Before(messages[1].Timestamp)
+	})).Return(nil)
+
+	// Execute
+	conversation, err := service.CreateConversationFromTemplate(context.Background(), "user123", "tpl123", map[string]string{
+		"name":    "Ana",
+		"product": "Acme",
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "user123", conversation.UserID)
+	assert.Equal(t, domain.ChannelWeb, conversation.Channel)
+	assert.Len(t, conversation.Messages, 2)
+	assert.Equal(t, conversation.ID, conversation.Messages[1].ConversationID)
+	mockConversationRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_CreateConversationFromTemplate_MissingVariables(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockTemplateRepo := new(MockTemplateRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithTemplateRepository(mockTemplateRepo),
+	)
+
+	mockTemplateRepo.On("GetByID", mock.Anything, "tpl123").Return(newOnboardingTemplate(), nil)
+
+	// Execute
+	conversation, err := service.CreateConversationFromTemplate(context.Background(), "user123", "tpl123", map[string]string{})
+
+	// Assert
+	assert.Nil(t, conversation)
+	assert.ErrorIs(t, err, ErrInvalidTemplate)
+	assert.Contains(t, err.Error(), "name, product")
+	mockConversationRepo.AssertNotCalled(t, "CreateWithMessages", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_CreateTemplate_RejectsUserMessages(t *testing.T) {
+	// Setup
+	mockTemplateRepo := new(MockTemplateRepository)
+
+	service := NewMessagingService(
+		new(MockConversationRepository),
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithTemplateRepository(mockTemplateRepo),
+	)
+
+	// Execute
+	template, err := service.CreateTemplate(context.Background(), ConversationTemplateRequest{
+		Name:    "FAQ",
+		Channel: domain.ChannelWeb,
+		Messages: []domain.TemplateMessage{
+			{SenderType: domain.SenderTypeUser, ContentType: domain.ContentTypeText, Content: "hi"},
+		},
+	}, "admin1")
+
+	// Assert
+	assert.Nil(t, template)
+	assert.ErrorIs(t, err, ErrInvalidTemplate)
+	mockTemplateRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_CreateTemplate_RejectsUnknownEnums(t *testing.T) {
+	service := NewMessagingService(
+		new(MockConversationRepository),
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithTemplateRepository(new(MockTemplateRepository)),
+	)
+
+	tests := []struct {
+		name    string
+		request ConversationTemplateRequest
+	}{
+		{"unknown channel", ConversationTemplateRequest{
+			Name:     "FAQ",
+			Channel:  domain.Channel("telegram"),
+			Messages: []domain.TemplateMessage{{SenderType: domain.SenderTypeBot, ContentType: domain.ContentTypeText, Content: "hi"}},
+		}},
+		{"unknown content type", ConversationTemplateRequest{
+			Name:     "FAQ",
+			Channel:  domain.ChannelWeb,
+			Messages: []domain.TemplateMessage{{SenderType: domain.SenderTypeBot, ContentType: domain.ContentType("sticker"), Content: "hi"}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template, err := service.CreateTemplate(context.Background(), tt.request, "admin1")
+
+			assert.Nil(t, template)
+			assert.ErrorIs(t, err, ErrInvalidTemplate)
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidWebhook indica una URL no válida o tipos de evento desconocidos al registrar un webhook
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// WebhookEventTypes son los tipos de evento a los que se puede suscribir un webhook de conversación
+var WebhookEventTypes = map[string]bool{
+	"message.received":                   true,
+	"message.read":                       true,
+	"message.expired":                    true,
+	"message.edited":                     true,
+	"conversation.closed":                true,
+	"conversation.abandoned":             true,
+	"conversation.archived":              true,
+	"conversation.ownership_transferred": true,
+	"reaction.added":                     true,
+	"reaction.removed":                   true,
+	"delivery.permanently_failed":        true,
+	"message.status_changed":             true,
+}
+
+// defaultWebhookTimeout limita cada entrega a un webhook de conversación
+const defaultWebhookTimeout = 5 * time.Second
+
+type ConversationWebhookRequest struct {
+	URL        string   `json:"url" binding:"required"`
+	EventTypes []string `json:"event_types" binding:"required"` // Eventos que recibirá el webhook, p. ej. message.received
+}
+
+// WithWebhookRepository habilita los webhooks por conversación
+func WithWebhookRepository(repo domain.WebhookRepository) MessagingServiceOption {
+	return func(s *messagingService) {
+		s.webhookRepo = repo
+	}
+}
+
+// CreateWebhook registra un webhook en la conversación suscrito solo a los tipos de evento indicados.
+// El webhook devuelto incluye el secreto con el que se firman sus entregas, que no vuelve a mostrarse
+func (s *messagingService) CreateWebhook(ctx context.Context, conversationID string, req ConversationWebhookRequest, userID string) (*domain.ConversationWebhook, error) {
+	if s.webhookRepo == nil {
+		return nil, fmt.Errorf("webhooks not available")
+	}
+
+	if _, err := s.GetConversation(ctx, conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	eventTypes, err := validateWebhookRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	webhook := &domain.ConversationWebhook{
+		ID:             uuid.New().String(),
+		ConversationID: conversationID,
+		URL:            req.URL,
+		EventTypes:     eventTypes,
+		Secret:         secret,
+		CreatedBy:      userID,
+		CreatedAt:      time.Now(),
+	}
+
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	s.log(ctx).Info("Conversation webhook created", map[string]interface{}{
+		"webhook_id":      webhook.ID,
+		"conversation_id": conversationID,
+		"event_types":     eventTypes,
+		"user_id":         userID,
+	})
+
+	return webhook, nil
+}
+
+// ListWebhooks devuelve los webhooks registrados en la conversación
+func (s *messagingService) ListWebhooks(ctx context.Context, conversationID string, userID string) ([]domain.ConversationWebhook, error) {
+	if s.webhookRepo == nil {
+		return nil, fmt.Errorf("webhooks not available")
+	}
+
+	if _, err := s.GetConversation(ctx, conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	webhooks, err := s.webhookRepo.GetByConversationID(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook elimina un webhook de la conversación
+func (s *messagingService) DeleteWebhook(ctx context.Context, conversationID string, webhookID string, userID string) error {
+	if s.webhookRepo == nil {
+		return fmt.Errorf("webhooks not available")
+	}
+
+	if _, err := s.getConversationWebhook(ctx, conversationID, webhookID, userID); err != nil {
+		return err
+	}
+
+	if err := s.webhookRepo.Delete(ctx, webhookID); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	return nil
+}
+
+// getConversationWebhook devuelve el webhook si pertenece a una conversación accesible para el usuario
+func (s *messagingService) getConversationWebhook(ctx context.Context, conversationID string, webhookID string, userID string) (*domain.ConversationWebhook, error) {
+	if _, err := s.GetConversation(ctx, conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.webhookRepo.GetByID(ctx, webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	if webhook.ConversationID != conversationID {
+		return nil, fmt.Errorf("webhook %w", domain.ErrNotFound)
+	}
+
+	return webhook, nil
+}
+
+// newWebhookSecret genera la clave HMAC con la que se firman las entregas de un webhook
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// validateWebhookRequest comprueba la URL y devuelve los tipos de evento sin duplicados y ordenados.
+// Las direcciones internas se rechazan al conectar, no aquí, porque el nombre puede resolver distinto más tarde
+func validateWebhookRequest(req ConversationWebhookRequest) ([]string, error) {
+	target, err := url.Parse(req.URL)
+	if err != nil || target.Scheme != "https" || target.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute https URL", ErrInvalidWebhook)
+	}
+
+	if len(req.EventTypes) == 0 {
+		return nil, fmt.Errorf("%w: at least one event type is required", ErrInvalidWebhook)
+	}
+
+	seen := make(map[string]bool, len(req.EventTypes))
+	eventTypes := make([]string, 0, len(req.EventTypes))
+	for _, eventType := range req.EventTypes {
+		if !WebhookEventTypes[eventType] {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, eventType)
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	sort.Strings(eventTypes)
+
+	return eventTypes, nil
+}
+
+// ConversationWebhookPublisher publica cada evento con next y además lo encola para entregarlo a los
+// webhooks de la conversación suscritos a su tipo. Las entregas las hace un número fijo de workers, de
+// modo que una ráfaga de eventos no abre conexiones sin límite; si la cola está llena el evento no se
+// entrega a los webhooks y se registra en el log. Un webhook que falla no afecta a la publicación
+type ConversationWebhookPublisher struct {
+	next      EventPublisher
+	repo      domain.WebhookRepository
+	deliverer *WebhookDeliverer
+	queue     chan domain.MessageEvent
+	workers   int
+	logger    logger.Logger
+}
+
+func NewConversationWebhookPublisher(next EventPublisher, repo domain.WebhookRepository, deliverer *WebhookDeliverer, config config.WebhookConfig, logger logger.Logger) *ConversationWebhookPublisher {
+	workers := config.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	return &ConversationWebhookPublisher{
+		next:      next,
+		repo:      repo,
+		deliverer: deliverer,
+		queue:     make(chan domain.MessageEvent, config.QueueSize),
+		workers:   workers,
+		logger:    logger,
+	}
+}
+
+// Start ejecuta los workers de entrega hasta que se cancele el contexto; al cancelarse los workers
+// entregan lo que queda en cola durante webhookFlushTimeout como mucho
+func (p *ConversationWebhookPublisher) Start(ctx context.Context) {
+	p.logger.Info("Conversation webhook publisher started", map[string]interface{}{
+		"workers":    p.workers,
+		"queue_size": cap(p.queue),
+	})
+
+	// A delivery in flight when shutdown starts is finished rather than aborted
+	deliverCtx := context.WithoutCancel(ctx)
+
+	done := make(chan struct{})
+	for i := 0; i < p.workers; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for {
+				select {
+				case <-ctx.Done():
+					flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), webhookFlushTimeout)
+					drainQueue(flushCtx, p.queue, p.deliver)
+					cancel()
+					return
+				case event := <-p.queue:
+					p.deliver(deliverCtx, event)
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < p.workers; i++ {
+		<-done
+	}
+	p.logger.Info("Conversation webhook publisher stopped", map[string]interface{}{
+		"undelivered": len(p.queue),
+	})
+}
+
+func (p *ConversationWebhookPublisher) PublishMessageEvent(ctx context.Context, event domain.MessageEvent) error {
+	err := p.next.PublishMessageEvent(ctx, event)
+
+	if event.ConversationID != "" {
+		select {
+		case p.queue <- event:
+		default:
+			p.logger.Warn("Conversation webhook queue full, event not delivered to webhooks", map[string]interface{}{
+				"conversation_id": event.ConversationID,
+				"event_type":      event.Type,
+			})
+		}
+	}
+
+	return err
+}
+
+// PublishEvent no pasa por los webhooks de la conversación, que solo reciben eventos de mensajes
+func (p *ConversationWebhookPublisher) PublishEvent(ctx context.Context, channel string, payload interface{}) error {
+	return p.next.PublishEvent(ctx, channel, payload)
+}
+
+func (p *ConversationWebhookPublisher) deliver(ctx context.Context, event domain.MessageEvent) {
+	webhooks, err := p.repo.GetSubscribed(ctx, event.ConversationID, event.Type)
+	if err != nil {
+		p.logger.Error("Failed to get subscribed conversation webhooks", err)
+		return
+	}
+
+	if len(webhooks) == 0 {
+		return
+	}
+
+	// The payload is stored with every attempt, so it is kept as a JSON object rather than raw bytes
+	body, err := json.Marshal(event)
+	if err != nil {
+		p.logger.Error("Failed to marshal webhook event", err)
+		return
+	}
+	var payload domain.JSONB
+	if err := json.Unmarshal(body, &payload); err != nil {
+		p.logger.Error("Failed to decode webhook event payload", err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		p.deliverer.Deliver(ctx, webhook, event.Type, payload, 1, nil)
+	}
+}
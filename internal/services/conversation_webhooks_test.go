@@ -0,0 +1,192 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockWebhookRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepository) Create(ctx context.Context, webhook *domain.ConversationWebhook) error {
+	args := m.Called(ctx, webhook)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) GetByID(ctx context.Context, id string) (*domain.ConversationWebhook, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ConversationWebhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) GetByConversationID(ctx context.Context, conversationID string) ([]domain.ConversationWebhook, error) {
+	args := m.Called(ctx, conversationID)
+	return args.Get(0).([]domain.ConversationWebhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) GetSubscribed(ctx context.Context, conversationID string, eventType string) ([]domain.ConversationWebhook, error) {
+	args := m.Called(ctx, conversationID, eventType)
+	return args.Get(0).([]domain.ConversationWebhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestMessagingService_CreateWebhook(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockWebhookRepo := new(MockWebhookRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithWebhookRepository(mockWebhookRepo),
+	)
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv1").Return(&domain.Conversation{ID: "conv1", UserID: "user123"}, nil)
+	mockWebhookRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.ConversationWebhook")).Return(nil)
+
+	// Execute
+	webhook, err := service.CreateWebhook(context.Background(), "conv1", ConversationWebhookRequest{
+		URL:        "https://partner.example.com/hooks",
+		EventTypes: []string{"reaction.added", "message.received", "reaction.added"},
+	}, "user123")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"message.received", "reaction.added"}, webhook.EventTypes)
+	assert.Len(t, webhook.Secret, 64)
+	mockWebhookRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_CreateWebhook_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		req  ConversationWebhookRequest
+	}{
+		{"unknown event type", ConversationWebhookRequest{URL: "https://partner.example.com/hooks", EventTypes: []string{"message.deleted"}}},
+		{"no event types", ConversationWebhookRequest{URL: "https://partner.example.com/hooks"}},
+		{"relative url", ConversationWebhookRequest{URL: "/hooks", EventTypes: []string{"message.received"}}},
+		{"unsupported scheme", ConversationWebhookRequest{URL: "ftp://partner.example.com", EventTypes: []string{"message.received"}}},
+		{"plain http", ConversationWebhookRequest{URL: "http://partner.example.com/hooks", EventTypes: []string{"message.received"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockConversationRepo := new(MockConversationRepository)
+			mockWebhookRepo := new(MockWebhookRepository)
+
+			service := NewMessagingService(
+				mockConversationRepo,
+				new(MockMessageRepository),
+				new(MockAttachmentRepository),
+				NewNoOpEventPublisher(),
+				NewNoOpCacheService(),
+				logger.NewLogger("debug"),
+				WithWebhookRepository(mockWebhookRepo),
+			)
+
+			mockConversationRepo.On("GetByID", mock.Anything, "conv1").Return(&domain.Conversation{ID: "conv1", UserID: "user123"}, nil)
+
+			// Execute
+			webhook, err := service.CreateWebhook(context.Background(), "conv1", tt.req, "user123")
+
+			// Assert
+			assert.True(t, errors.Is(err, ErrInvalidWebhook))
+			assert.Nil(t, webhook)
+			mockWebhookRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestConversationWebhookPublisher_DeliversOnlySubscribedEvents(t *testing.T) {
+	// Setup
+	received := make(chan *http.Request, 2)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		received <- r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	mockWebhookRepo := new(MockWebhookRepository)
+	webhook := domain.ConversationWebhook{ID: "hook1", ConversationID: "conv1", URL: server.URL, EventTypes: []string{"message.received"}, Secret: "s3cret"}
+	mockWebhookRepo.On("GetSubscribed", mock.Anything, "conv1", "message.received").Return([]domain.ConversationWebhook{webhook}, nil)
+	mockWebhookRepo.On("GetSubscribed", mock.Anything, "conv1", "message.read").Return([]domain.ConversationWebhook{}, nil)
+
+	mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+	mockDeliveryRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.WebhookDelivery")).Return(nil)
+
+	next := &recordingEventPublisher{}
+	deliverer := newTestWebhookDeliverer(mockDeliveryRepo, server)
+	publisher := NewConversationWebhookPublisher(next, mockWebhookRepo, deliverer, config.WebhookConfig{Workers: 2, QueueSize: 10}, logger.NewLogger("debug"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go publisher.Start(ctx)
+
+	// Execute
+	assert.NoError(t, publisher.PublishMessageEvent(context.Background(), domain.MessageEvent{Type: "message.read", ConversationID: "conv1"}))
+	assert.NoError(t, publisher.PublishMessageEvent(context.Background(), domain.MessageEvent{Type: "message.received", ConversationID: "conv1"}))
+
+	// Assert: the delivery is signed over the timestamp and the body with the webhook secret
+	select {
+	case r := <-received:
+		assert.Equal(t, "message.received", r.Header.Get("X-Event-Type"))
+		body, _ := io.ReadAll(r.Body)
+		timestamp, err := strconv.ParseInt(r.Header.Get(auth.WebhookTimestampHeader), 10, 64)
+		require.NoError(t, err)
+		assert.Equal(t, auth.SignWebhookPayload([]byte("s3cret"), timestamp, body), r.Header.Get(auth.WebhookSignatureHeader))
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	select {
+	case r := <-received:
+		t.Fatalf("unexpected delivery of %s", r.Header.Get("X-Event-Type"))
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	assert.Len(t, next.events, 2)
+}
+
+func TestConversationWebhookPublisher_DropsEventsWhenQueueIsFull(t *testing.T) {
+	// Setup: no workers are started, so nothing drains the queue
+	next := &recordingEventPublisher{}
+	publisher := NewConversationWebhookPublisher(next, new(MockWebhookRepository), nil, config.WebhookConfig{Workers: 1, QueueSize: 1}, logger.NewLogger("debug"))
+
+	// Execute
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, publisher.PublishMessageEvent(context.Background(), domain.MessageEvent{Type: "message.received", ConversationID: "conv1"}))
+	}
+
+	// Assert: publishing never blocks and the upstream publisher still gets every event
+	assert.Len(t, publisher.queue, 1)
+	assert.Len(t, next.events, 3)
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+var (
+	// ErrInvalidDeliveryReceipt indica un acuse de entrega con datos no válidos
+	ErrInvalidDeliveryReceipt = errors.New("invalid delivery receipt")
+	// ErrInvalidDeliveryTransition indica un cambio de estado de entrega que haría retroceder el mensaje
+	ErrInvalidDeliveryTransition = errors.New("invalid delivery status transition")
+)
+
+// maxDeliveryStatusAttempts limita los reintentos cuando otro acuse cambia el estado a la vez
+const maxDeliveryStatusAttempts = 3
+
+// DeliveryReceipt es el acuse de estado que envía el proveedor de un canal sobre un mensaje saliente
+type DeliveryReceipt struct {
+	MessageID string                `json:"message_id" binding:"required"` // Identificador del mensaje en el proveedor
+	Status    domain.DeliveryStatus `json:"status" binding:"required"`     // delivered, read o failed
+	Timestamp *time.Time            `json:"timestamp,omitempty"`
+	Error     string                `json:"error,omitempty"` // Motivo del fallo informado por el proveedor
+}
+
+// DeliveryReceiptResult indica cómo quedó el mensaje tras procesar el acuse. Applied es false cuando el
+// acuse llegó fuera de orden y no hizo avanzar el estado
+type DeliveryReceiptResult struct {
+	MessageID      string                `json:"message_id"`
+	PreviousStatus domain.DeliveryStatus `json:"previous_status"`
+	Status         domain.DeliveryStatus `json:"status"`
+	Applied        bool                  `json:"applied"`
+}
+
+// ProcessDeliveryReceipt aplica un acuse de entrega del proveedor al mensaje al que se refiere, respetando
+// que el estado solo avance, y publica message.status_changed cuando cambia
+func (s *messagingService) ProcessDeliveryReceipt(ctx context.Context, channel domain.Channel, receipt DeliveryReceipt) (*DeliveryReceiptResult, error) {
+	if !isValidChannel(channel) {
+		return nil, fmt.Errorf("%w: unknown channel %q", ErrInvalidDeliveryReceipt, channel)
+	}
+	if receipt.MessageID == "" {
+		return nil, fmt.Errorf("%w: message_id is required", ErrInvalidDeliveryReceipt)
+	}
+	switch receipt.Status {
+	case domain.DeliveryStatusDelivered, domain.DeliveryStatusRead, domain.DeliveryStatusFailed:
+	default:
+		return nil, fmt.Errorf("%w: unsupported status %q", ErrInvalidDeliveryReceipt, receipt.Status)
+	}
+
+	message, err := s.messageRepo.GetByProviderMessageID(ctx, channel, receipt.MessageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	message, previous, applied, err := s.advanceDeliveryStatus(ctx, message, receipt.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DeliveryReceiptResult{
+		MessageID:      message.ID,
+		PreviousStatus: previous,
+		Status:         message.DeliveryStatus,
+		Applied:        applied,
+	}
+	if !applied {
+		s.log(ctx).Info("Out-of-order delivery receipt ignored", map[string]interface{}{
+			"message_id": message.ID,
+			"status":     message.DeliveryStatus,
+			"receipt":    receipt.Status,
+		})
+		return result, nil
+	}
+
+	data := domain.JSONB{
+		"channel":             channel,
+		"provider_message_id": receipt.MessageID,
+	}
+	if receipt.Error != "" {
+		data["error"] = receipt.Error
+	}
+	if receipt.Timestamp != nil {
+		data["provider_timestamp"] = receipt.Timestamp
+	}
+	s.publishDeliveryStatusEvent(ctx, message, previous, data)
+
+	return result, nil
+}
+
+// UpdateDeliveryStatus lleva el mensaje messageID a status para las integraciones que ya lo tienen
+// identificado; los acuses de los proveedores, que lo nombran por su identificador, entran por
+// ProcessDeliveryReceipt. Un cambio que no avanza el estado devuelve ErrInvalidDeliveryTransition
+func (s *messagingService) UpdateDeliveryStatus(ctx context.Context, messageID string, status domain.DeliveryStatus) (*domain.Message, error) {
+	message, err := s.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	message, previous, applied, err := s.advanceDeliveryStatus(ctx, message, status)
+	if err != nil {
+		return nil, err
+	}
+	if !applied {
+		return nil, fmt.Errorf("%w: %q to %q", ErrInvalidDeliveryTransition, message.DeliveryStatus, status)
+	}
+
+	s.publishDeliveryStatusEvent(ctx, message, previous, domain.JSONB{})
+	return message, nil
+}
+
+// advanceDeliveryStatus cambia el estado de entrega del mensaje a status si la transición está permitida.
+// Si otro cambio se adelanta, relee el mensaje y vuelve a comprobarla. Devuelve el mensaje con su estado
+// final, el estado desde el que se aplicó el cambio y si llegó a aplicarse
+func (s *messagingService) advanceDeliveryStatus(ctx context.Context, message *domain.Message, status domain.DeliveryStatus) (*domain.Message, domain.DeliveryStatus, bool, error) {
+	for attempt := 1; ; attempt++ {
+		previous := message.DeliveryStatus
+		if !previous.CanTransitionTo(status) {
+			return message, previous, false, nil
+		}
+
+		updated, err := s.messageRepo.UpdateDeliveryStatus(ctx, message.ID, previous, status)
+		if err != nil {
+			return nil, "", false, fmt.Errorf("failed to update delivery status: %w", err)
+		}
+		if updated {
+			message.DeliveryStatus = status
+			return message, previous, true, nil
+		}
+
+		// Another change moved the status first; re-check the transition against the stored one
+		if attempt == maxDeliveryStatusAttempts {
+			return nil, "", false, fmt.Errorf("failed to update delivery status: concurrent updates")
+		}
+		if message, err = s.messageRepo.GetByID(ctx, message.ID); err != nil {
+			return nil, "", false, fmt.Errorf("failed to get message: %w", err)
+		}
+	}
+}
+
+// publishDeliveryStatusEvent publica message.status_changed con el estado anterior y el nuevo en data
+func (s *messagingService) publishDeliveryStatusEvent(ctx context.Context, message *domain.Message, previous domain.DeliveryStatus, data domain.JSONB) {
+	if s.cacheService != nil {
+		_ = s.cacheService.DeleteMessages(ctx, message.ConversationID)
+	}
+
+	if s.eventPublisher == nil {
+		return
+	}
+
+	data["previous_status"] = previous
+	data["status"] = message.DeliveryStatus
+
+	event := domain.MessageEvent{
+		Type:           "message.status_changed",
+		ConversationID: message.ConversationID,
+		Message:        *message,
+		Data:           data,
+		Timestamp:      time.Now(),
+	}
+
+	if err := s.eventPublisher.PublishMessageEvent(ctx, event); err != nil {
+		s.log(ctx).Error("Failed to publish delivery status event", err)
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeliveryStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from    domain.DeliveryStatus
+		to      domain.DeliveryStatus
+		allowed bool
+	}{
+		{domain.DeliveryStatusSent, domain.DeliveryStatusDelivered, true},
+		{domain.DeliveryStatusSent, domain.DeliveryStatusRead, true},
+		{domain.DeliveryStatusDelivered, domain.DeliveryStatusRead, true},
+		{domain.DeliveryStatusSent, domain.DeliveryStatusFailed, true},
+		{domain.DeliveryStatusFailed, domain.DeliveryStatusDelivered, false},
+		{domain.DeliveryStatusFailed, domain.DeliveryStatusRead, false},
+		{domain.DeliveryStatusRead, domain.DeliveryStatusDelivered, false},
+		{domain.DeliveryStatusDelivered, domain.DeliveryStatusDelivered, false},
+		{domain.DeliveryStatusDelivered, domain.DeliveryStatusFailed, false},
+		{domain.DeliveryStatusFailed, domain.DeliveryStatusFailed, false},
+		{domain.DeliveryStatusSent, domain.DeliveryStatusPending, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.allowed, tt.from.CanTransitionTo(tt.to))
+		})
+	}
+}
+
+func TestMessagingService_ProcessDeliveryReceipt(t *testing.T) {
+	// Setup
+	mockMessageRepo := new(MockMessageRepository)
+	publisher := &recordingEventPublisher{}
+	service := newTestMessagingService(testServiceDeps{messageRepo: mockMessageRepo, publisher: publisher})
+
+	message := &domain.Message{ID: "msg1", ConversationID: "conv1", DeliveryStatus: domain.DeliveryStatusSent}
+	mockMessageRepo.On("GetByProviderMessageID", mock.Anything, domain.ChannelWhatsApp, "wamid.1").Return(message, nil)
+	mockMessageRepo.On("UpdateDeliveryStatus", mock.Anything, "msg1", domain.DeliveryStatusSent, domain.DeliveryStatusDelivered).Return(true, nil)
+
+	// Execute
+	result, err := service.ProcessDeliveryReceipt(context.Background(), domain.ChannelWhatsApp, DeliveryReceipt{MessageID: "wamid.1", Status: domain.DeliveryStatusDelivered})
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, result.Applied)
+	assert.Equal(t, domain.DeliveryStatusSent, result.PreviousStatus)
+	assert.Equal(t, domain.DeliveryStatusDelivered, result.Status)
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, "message.status_changed", publisher.events[0].Type)
+	assert.Equal(t, domain.DeliveryStatusDelivered, publisher.events[0].Message.DeliveryStatus)
+	mockMessageRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_ProcessDeliveryReceipt_OutOfOrder(t *testing.T) {
+	// Setup
+	mockMessageRepo := new(MockMessageRepository)
+	publisher := &recordingEventPublisher{}
+	service := newTestMessagingService(testServiceDeps{messageRepo: mockMessageRepo, publisher: publisher})
+
+	// The read receipt arrived before the delivered one
+	message := &domain.Message{ID: "msg1", ConversationID: "conv1", DeliveryStatus: domain.DeliveryStatusRead}
+	mockMessageRepo.On("GetByProviderMessageID", mock.Anything, domain.ChannelWhatsApp, "wamid.1").Return(message, nil)
+
+	// Execute
+	result, err := service.ProcessDeliveryReceipt(context.Background(), domain.ChannelWhatsApp, DeliveryReceipt{MessageID: "wamid.1", Status: domain.DeliveryStatusDelivered})
+
+	// Assert
+	require.NoError(t, err)
+	assert.False(t, result.Applied)
+	assert.Equal(t, domain.DeliveryStatusRead, result.Status)
+	assert.Empty(t, publisher.events)
+	mockMessageRepo.AssertNotCalled(t, "UpdateDeliveryStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_ProcessDeliveryReceipt_ConcurrentUpdate(t *testing.T) {
+	// Setup
+	mockMessageRepo := new(MockMessageRepository)
+	service := newTestMessagingService(testServiceDeps{messageRepo: mockMessageRepo})
+
+	mockMessageRepo.On("GetByProviderMessageID", mock.Anything, domain.ChannelWhatsApp, "wamid.1").
+		Return(&domain.Message{ID: "msg1", DeliveryStatus: domain.DeliveryStatusSent}, nil)
+	mockMessageRepo.On("UpdateDeliveryStatus", mock.Anything, "msg1", domain.DeliveryStatusSent, domain.DeliveryStatusRead).Return(false, nil)
+	mockMessageRepo.On("GetByID", mock.Anything, "msg1").Return(&domain.Message{ID: "msg1", DeliveryStatus: domain.DeliveryStatusDelivered}, nil)
+	mockMessageRepo.On("UpdateDeliveryStatus", mock.Anything, "msg1", domain.DeliveryStatusDelivered, domain.DeliveryStatusRead).Return(true, nil)
+
+	// Execute
+	result, err := service.ProcessDeliveryReceipt(context.Background(), domain.ChannelWhatsApp, DeliveryReceipt{MessageID: "wamid.1", Status: domain.DeliveryStatusRead})
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, result.Applied)
+	assert.Equal(t, domain.DeliveryStatusDelivered, result.PreviousStatus)
+	mockMessageRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_UpdateDeliveryStatus(t *testing.T) {
+	// Setup
+	mockMessageRepo := new(MockMessageRepository)
+	publisher := &recordingEventPublisher{}
+	service := newTestMessagingService(testServiceDeps{messageRepo: mockMessageRepo, publisher: publisher})
+
+	mockMessageRepo.On("GetByID", mock.Anything, "msg1").Return(&domain.Message{ID: "msg1", ConversationID: "conv1", DeliveryStatus: domain.DeliveryStatusSent}, nil)
+	mockMessageRepo.On("UpdateDeliveryStatus", mock.Anything, "msg1", domain.DeliveryStatusSent, domain.DeliveryStatusRead).Return(true, nil)
+
+	// Execute: a read receipt may arrive without the delivered one
+	message, err := service.UpdateDeliveryStatus(context.Background(), "msg1", domain.DeliveryStatusRead)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, domain.DeliveryStatusRead, message.DeliveryStatus)
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, "message.status_changed", publisher.events[0].Type)
+	assert.Equal(t, domain.DeliveryStatusSent, publisher.events[0].Data["previous_status"])
+	assert.Equal(t, domain.DeliveryStatusRead, publisher.events[0].Data["status"])
+}
+
+func TestMessagingService_UpdateDeliveryStatus_InvalidTransition(t *testing.T) {
+	// Setup
+	mockMessageRepo := new(MockMessageRepository)
+	publisher := &recordingEventPublisher{}
+	service := newTestMessagingService(testServiceDeps{messageRepo: mockMessageRepo, publisher: publisher})
+
+	mockMessageRepo.On("GetByID", mock.Anything, "msg1").Return(&domain.Message{ID: "msg1", ConversationID: "conv1", DeliveryStatus: domain.DeliveryStatusFailed}, nil)
+
+	// Execute
+	message, err := service.UpdateDeliveryStatus(context.Background(), "msg1", domain.DeliveryStatusRead)
+
+	// Assert: a failed message only leaves that state through a retry
+	assert.ErrorIs(t, err, ErrInvalidDeliveryTransition)
+	assert.Nil(t, message)
+	assert.Empty(t, publisher.events)
+	mockMessageRepo.AssertNotCalled(t, "UpdateDeliveryStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_ProcessDeliveryReceipt_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel domain.Channel
+		receipt DeliveryReceipt
+	}{
+		{"unknown channel", "sms", DeliveryReceipt{MessageID: "wamid.1", Status: domain.DeliveryStatusRead}},
+		{"missing message id", domain.ChannelWhatsApp, DeliveryReceipt{Status: domain.DeliveryStatusRead}},
+		{"unsupported status", domain.ChannelWhatsApp, DeliveryReceipt{MessageID: "wamid.1", Status: domain.DeliveryStatusSent}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockMessageRepo := new(MockMessageRepository)
+			service := newTestMessagingService(testServiceDeps{messageRepo: mockMessageRepo})
+
+			result, err := service.ProcessDeliveryReceipt(context.Background(), tt.channel, tt.receipt)
+
+			assert.True(t, errors.Is(err, ErrInvalidDeliveryReceipt))
+			assert.Nil(t, result)
+			mockMessageRepo.AssertNotCalled(t, "GetByProviderMessageID", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
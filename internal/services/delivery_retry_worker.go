@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+// DeliveryRetryWorker reintenta periódicamente las entregas salientes fallidas
+type DeliveryRetryWorker struct {
+	messageRepo      domain.MessageRepository
+	conversationRepo domain.ConversationRepository
+	senders          ChannelSenders
+	eventPublisher   EventPublisher
+	config           config.DeliveryConfig
+	logger           logger.Logger
+}
+
+func NewDeliveryRetryWorker(
+	messageRepo domain.MessageRepository,
+	conversationRepo domain.ConversationRepository,
+	senders ChannelSenders,
+	eventPublisher EventPublisher,
+	config config.DeliveryConfig,
+	logger logger.Logger,
+) *DeliveryRetryWorker {
+	return &DeliveryRetryWorker{
+		messageRepo:      messageRepo,
+		conversationRepo: conversationRepo,
+		senders:          senders,
+		eventPublisher:   eventPublisher,
+		config:           config,
+		logger:           logger,
+	}
+}
+
+// Start ejecuta el worker hasta que se cancele el contexto
+func (w *DeliveryRetryWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.config.RetryInterval)
+	defer ticker.Stop()
+
+	w.logger.Info("Delivery retry worker started", map[string]interface{}{
+		"interval":     w.config.RetryInterval.String(),
+		"max_attempts": w.config.RetryMaxAttempts,
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("Delivery retry worker stopped")
+			return
+		case <-ticker.C:
+			w.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce procesa un lote de mensajes pendientes de reintento y devuelve cuántos se entregaron
+func (w *DeliveryRetryWorker) RunOnce(ctx context.Context) int {
+	messages, err := w.messageRepo.GetPendingRetries(ctx, time.Now(), w.config.RetryMaxAttempts, w.config.RetryBatchSize)
+	if err != nil {
+		w.logger.Error("Failed to load messages pending delivery retry", err)
+		return 0
+	}
+
+	delivered := 0
+	for i := range messages {
+		if w.retry(ctx, &messages[i]) {
+			delivered++
+		}
+	}
+
+	return delivered
+}
+
+func (w *DeliveryRetryWorker) retry(ctx context.Context, message *domain.Message) bool {
+	// Every outcome counts as an attempt and moves next_retry_at forward, otherwise a message that
+	// cannot be sent would be picked first on every tick and starve the rest of the batch
+	message.DeliveryAttempts++
+	sendErr := w.send(ctx, message)
+	recordDeliveryMetadata(message, sendErr)
+
+	permanentlyFailed := false
+	if sendErr == nil {
+		message.DeliveryStatus = domain.DeliveryStatusSent
+		message.NextRetryAt = nil
+	} else if message.DeliveryAttempts >= w.config.RetryMaxAttempts {
+		message.NextRetryAt = nil
+		permanentlyFailed = true
+	} else {
+		nextRetry := time.Now().Add(w.backoff(message.DeliveryAttempts))
+		message.NextRetryAt = &nextRetry
+	}
+
+	if err := w.messageRepo.Update(ctx, message); err != nil {
+		w.logger.Error("Failed to update message after delivery retry", err)
+		return false
+	}
+
+	if sendErr != nil {
+		w.logger.Warn("Delivery retry failed", map[string]interface{}{
+			"message_id": message.ID,
+			"attempts":   message.DeliveryAttempts,
+			"error":      sendErr.Error(),
+		})
+	}
+
+	if permanentlyFailed && w.eventPublisher != nil {
+		event := domain.MessageEvent{
+			Type:           "delivery.permanently_failed",
+			ConversationID: message.ConversationID,
+			Message:        *message,
+			Timestamp:      time.Now(),
+		}
+
+		if err := w.eventPublisher.PublishMessageEvent(ctx, event); err != nil {
+			w.logger.Error("Failed to publish delivery failure event", err)
+		}
+	}
+
+	return sendErr == nil
+}
+
+// send entrega el mensaje con el sender de su canal
+func (w *DeliveryRetryWorker) send(ctx context.Context, message *domain.Message) error {
+	conversation, err := w.conversationRepo.GetByID(ctx, message.ConversationID)
+	if err != nil {
+		return fmt.Errorf("failed to load conversation: %w", err)
+	}
+
+	sender, ok := w.senders[conversation.Channel]
+	if !ok {
+		return fmt.Errorf("no channel sender configured for %s", conversation.Channel)
+	}
+
+	return sender.Send(ctx, conversation, message)
+}
+
+// backoff calcula la espera exponencial tras el intento indicado
+func (w *DeliveryRetryWorker) backoff(attempts int) time.Duration {
+	delay := w.config.RetryBaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= w.config.RetryMaxBackoff {
+			return w.config.RetryMaxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockChannelSender struct {
+	mock.Mock
+}
+
+func (m *MockChannelSender) Send(ctx context.Context, conversation *domain.Conversation, message *domain.Message) error {
+	args := m.Called(ctx, conversation, message)
+	return args.Error(0)
+}
+
+type recordingEventPublisher struct {
+	events        []domain.MessageEvent
+	channelEvents map[string][]interface{}
+}
+
+func (p *recordingEventPublisher) PublishMessageEvent(ctx context.Context, event domain.MessageEvent) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *recordingEventPublisher) PublishEvent(ctx context.Context, channel string, payload interface{}) error {
+	if p.channelEvents == nil {
+		p.channelEvents = make(map[string][]interface{})
+	}
+	p.channelEvents[channel] = append(p.channelEvents[channel], payload)
+	return nil
+}
+
+func newTestDeliveryConfig() config.DeliveryConfig {
+	return config.DeliveryConfig{
+		RetryEnabled:     true,
+		RetryInterval:    time.Second,
+		RetryMaxAttempts: 3,
+		RetryBaseBackoff: time.Minute,
+		RetryMaxBackoff:  10 * time.Minute,
+		RetryBatchSize:   10,
+	}
+}
+
+func TestDeliveryRetryWorker_RetrySucceeds(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockSender := new(MockChannelSender)
+	publisher := &recordingEventPublisher{}
+
+	worker := NewDeliveryRetryWorker(
+		mockMessageRepo,
+		mockConversationRepo,
+		ChannelSenders{domain.ChannelWhatsApp: mockSender},
+		publisher,
+		newTestDeliveryConfig(),
+		logger.NewLogger("debug"),
+	)
+
+	conversation := &domain.Conversation{ID: "conv123", Channel: domain.ChannelWhatsApp}
+	failed := domain.Message{ID: "msg123", ConversationID: "conv123", DeliveryStatus: domain.DeliveryStatusFailed, DeliveryAttempts: 1}
+
+	mockMessageRepo.On("GetPendingRetries", mock.Anything, mock.Anything, 3, 10).Return([]domain.Message{failed}, nil)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(conversation, nil)
+	mockSender.On("Send", mock.Anything, conversation, mock.AnythingOfType("*domain.Message")).Return(nil)
+	mockMessageRepo.On("Update", mock.Anything, mock.MatchedBy(func(m *domain.Message) bool {
+		return m.DeliveryStatus == domain.DeliveryStatusSent && m.DeliveryAttempts == 2 && m.NextRetryAt == nil
+	})).Return(nil)
+
+	// Execute
+	delivered := worker.RunOnce(context.Background())
+
+	// Assert
+	assert.Equal(t, 1, delivered)
+	assert.Empty(t, publisher.events)
+	mockSender.AssertExpectations(t)
+	mockMessageRepo.AssertExpectations(t)
+}
+
+func TestDeliveryRetryWorker_SchedulesBackoff(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockSender := new(MockChannelSender)
+	publisher := &recordingEventPublisher{}
+
+	worker := NewDeliveryRetryWorker(
+		mockMessageRepo,
+		mockConversationRepo,
+		ChannelSenders{domain.ChannelWhatsApp: mockSender},
+		publisher,
+		newTestDeliveryConfig(),
+		logger.NewLogger("debug"),
+	)
+
+	conversation := &domain.Conversation{ID: "conv123", Channel: domain.ChannelWhatsApp}
+	failed := domain.Message{ID: "msg123", ConversationID: "conv123", DeliveryStatus: domain.DeliveryStatusFailed, DeliveryAttempts: 1}
+
+	mockMessageRepo.On("GetPendingRetries", mock.Anything, mock.Anything, 3, 10).Return([]domain.Message{failed}, nil)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(conversation, nil)
+	mockSender.On("Send", mock.Anything, conversation, mock.AnythingOfType("*domain.Message")).Return(errors.New("provider unavailable"))
+	mockMessageRepo.On("Update", mock.Anything, mock.MatchedBy(func(m *domain.Message) bool {
+		// Second attempt waits twice the base backoff
+		return m.DeliveryStatus == domain.DeliveryStatusFailed &&
+			m.DeliveryAttempts == 2 &&
+			m.NextRetryAt != nil &&
+			m.NextRetryAt.After(time.Now().Add(90*time.Second))
+	})).Return(nil)
+
+	// Execute
+	delivered := worker.RunOnce(context.Background())
+
+	// Assert
+	assert.Equal(t, 0, delivered)
+	assert.Empty(t, publisher.events)
+	mockMessageRepo.AssertExpectations(t)
+}
+
+func TestDeliveryRetryWorker_GivesUpAfterMaxAttempts(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockSender := new(MockChannelSender)
+	publisher := &recordingEventPublisher{}
+
+	worker := NewDeliveryRetryWorker(
+		mockMessageRepo,
+		mockConversationRepo,
+		ChannelSenders{domain.ChannelWhatsApp: mockSender},
+		publisher,
+		newTestDeliveryConfig(),
+		logger.NewLogger("debug"),
+	)
+
+	conversation := &domain.Conversation{ID: "conv123", Channel: domain.ChannelWhatsApp}
+	failed := domain.Message{ID: "msg123", ConversationID: "conv123", DeliveryStatus: domain.DeliveryStatusFailed, DeliveryAttempts: 2}
+
+	mockMessageRepo.On("GetPendingRetries", mock.Anything, mock.Anything, 3, 10).Return([]domain.Message{failed}, nil)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(conversation, nil)
+	mockSender.On("Send", mock.Anything, conversation, mock.AnythingOfType("*domain.Message")).Return(errors.New("provider unavailable"))
+	mockMessageRepo.On("Update", mock.Anything, mock.MatchedBy(func(m *domain.Message) bool {
+		return m.DeliveryStatus == domain.DeliveryStatusFailed && m.DeliveryAttempts == 3 && m.NextRetryAt == nil
+	})).Return(nil)
+
+	// Execute
+	delivered := worker.RunOnce(context.Background())
+
+	// Assert
+	assert.Equal(t, 0, delivered)
+	assert.Len(t, publisher.events, 1)
+	assert.Equal(t, "delivery.permanently_failed", publisher.events[0].Type)
+	assert.Equal(t, "msg123", publisher.events[0].Message.ID)
+	mockMessageRepo.AssertExpectations(t)
+}
+
+func TestDeliveryRetryWorker_SchedulesBackoffWithoutSender(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+
+	worker := NewDeliveryRetryWorker(
+		mockMessageRepo,
+		mockConversationRepo,
+		ChannelSenders{},
+		NewNoOpEventPublisher(),
+		newTestDeliveryConfig(),
+		logger.NewLogger("debug"),
+	)
+
+	failed := domain.Message{ID: "msg123", ConversationID: "conv123", DeliveryStatus: domain.DeliveryStatusFailed}
+
+	mockMessageRepo.On("GetPendingRetries", mock.Anything, mock.Anything, 3, 10).Return([]domain.Message{failed}, nil)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", Channel: domain.ChannelWeb}, nil)
+	mockMessageRepo.On("Update", mock.Anything, mock.MatchedBy(func(m *domain.Message) bool {
+		return m.DeliveryAttempts == 1 && m.NextRetryAt != nil && m.NextRetryAt.After(time.Now())
+	})).Return(nil)
+
+	// Execute
+	delivered := worker.RunOnce(context.Background())
+
+	// Assert: the message is pushed back instead of being selected again on the next tick
+	assert.Equal(t, 0, delivered)
+	mockMessageRepo.AssertExpectations(t)
+}
+
+func TestDeliveryRetryWorker_SchedulesBackoffWhenConversationFails(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+
+	worker := NewDeliveryRetryWorker(
+		mockMessageRepo,
+		mockConversationRepo,
+		ChannelSenders{},
+		NewNoOpEventPublisher(),
+		newTestDeliveryConfig(),
+		logger.NewLogger("debug"),
+	)
+
+	failed := domain.Message{ID: "msg123", ConversationID: "conv123", DeliveryStatus: domain.DeliveryStatusFailed, DeliveryAttempts: 2}
+
+	mockMessageRepo.On("GetPendingRetries", mock.Anything, mock.Anything, 3, 10).Return([]domain.Message{failed}, nil)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return((*domain.Conversation)(nil), errors.New("connection refused"))
+	mockMessageRepo.On("Update", mock.Anything, mock.MatchedBy(func(m *domain.Message) bool {
+		// The last allowed attempt gives up instead of scheduling another one
+		return m.DeliveryAttempts == 3 && m.NextRetryAt == nil
+	})).Return(nil)
+
+	// Execute
+	delivered := worker.RunOnce(context.Background())
+
+	// Assert
+	assert.Equal(t, 0, delivered)
+	mockMessageRepo.AssertExpectations(t)
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+var (
+	// ErrDraftsDisabled indica que el servicio no guarda borradores
+	ErrDraftsDisabled = errors.New("drafts are not enabled")
+	// ErrInvalidDraft indica un borrador con datos no válidos
+	ErrInvalidDraft = errors.New("invalid draft")
+)
+
+// SaveDraftRequest es el contenido del borrador que se guarda en la conversación
+type SaveDraftRequest struct {
+	Content  string                 `json:"content"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// WithDrafts habilita los borradores de mensaje de hasta maxLength caracteres. El borrador de quien envía
+// un mensaje se elimina en cuanto el mensaje queda guardado
+func WithDrafts(repo domain.DraftRepository, maxLength int) MessagingServiceOption {
+	return func(s *messagingService) {
+		if repo == nil {
+			return
+		}
+
+		s.draftRepo = repo
+		s.draftMaxLength = maxLength
+		s.sendHooks = append(s.sendHooks, NewSendHook("clear_draft", SendHookPostPersist, func(ctx context.Context, send *SendContext) error {
+			if err := repo.Delete(ctx, send.Message.ConversationID, send.Message.SenderID); err != nil {
+				return fmt.Errorf("failed to clear draft: %w", err)
+			}
+			return nil
+		}))
+	}
+}
+
+// SaveDraft crea o sustituye el borrador del usuario en la conversación. Los borradores son privados:
+// cada usuario solo ve y modifica el suyo
+func (s *messagingService) SaveDraft(ctx context.Context, conversationID string, req SaveDraftRequest, userID string) (*domain.MessageDraft, error) {
+	if s.draftRepo == nil {
+		return nil, ErrDraftsDisabled
+	}
+	if utf8.RuneCountInString(req.Content) > s.draftMaxLength {
+		return nil, fmt.Errorf("%w: content exceeds %d characters", ErrInvalidDraft, s.draftMaxLength)
+	}
+
+	if _, err := s.GetConversation(ctx, conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	draft := &domain.MessageDraft{
+		ConversationID: conversationID,
+		UserID:         userID,
+		Content:        req.Content,
+		Metadata:       domain.JSONB(req.Metadata),
+		UpdatedAt:      time.Now(),
+	}
+
+	if err := s.draftRepo.Upsert(ctx, draft); err != nil {
+		return nil, fmt.Errorf("failed to save draft: %w", err)
+	}
+
+	return draft, nil
+}
+
+// GetDraft devuelve el borrador del usuario en la conversación
+func (s *messagingService) GetDraft(ctx context.Context, conversationID string, userID string) (*domain.MessageDraft, error) {
+	if s.draftRepo == nil {
+		return nil, ErrDraftsDisabled
+	}
+
+	if _, err := s.GetConversation(ctx, conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	draft, err := s.draftRepo.Get(ctx, conversationID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get draft: %w", err)
+	}
+
+	return draft, nil
+}
+
+// DeleteDraft descarta el borrador del usuario en la conversación; no tenerlo no es un error
+func (s *messagingService) DeleteDraft(ctx context.Context, conversationID string, userID string) error {
+	if s.draftRepo == nil {
+		return ErrDraftsDisabled
+	}
+
+	if _, err := s.GetConversation(ctx, conversationID, userID); err != nil {
+		return err
+	}
+
+	if err := s.draftRepo.Delete(ctx, conversationID, userID); err != nil {
+		return fmt.Errorf("failed to delete draft: %w", err)
+	}
+
+	return nil
+}
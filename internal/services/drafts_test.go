@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockDraftRepository es un mock del repositorio de borradores
+type MockDraftRepository struct {
+	mock.Mock
+}
+
+func (m *MockDraftRepository) Upsert(ctx context.Context, draft *domain.MessageDraft) error {
+	args := m.Called(ctx, draft)
+	return args.Error(0)
+}
+
+func (m *MockDraftRepository) Get(ctx context.Context, conversationID string, userID string) (*domain.MessageDraft, error) {
+	args := m.Called(ctx, conversationID, userID)
+	return args.Get(0).(*domain.MessageDraft), args.Error(1)
+}
+
+func (m *MockDraftRepository) Delete(ctx context.Context, conversationID string, userID string) error {
+	args := m.Called(ctx, conversationID, userID)
+	return args.Error(0)
+}
+
+func TestMessagingService_SaveDraft(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockDraftRepo := new(MockDraftRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo}, WithDrafts(mockDraftRepo, 10))
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockDraftRepo.On("Upsert", mock.Anything, mock.MatchedBy(func(draft *domain.MessageDraft) bool {
+		return draft.ConversationID == "conv123" && draft.UserID == "user123" && draft.Content == "Hola, ..."
+	})).Return(nil)
+
+	// Execute
+	draft, err := service.SaveDraft(context.Background(), "conv123", SaveDraftRequest{Content: "Hola, ..."}, "user123")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "Hola, ...", draft.Content)
+	assert.False(t, draft.UpdatedAt.IsZero())
+	mockDraftRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_SaveDraft_Invalid(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockDraftRepo := new(MockDraftRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo}, WithDrafts(mockDraftRepo, 10))
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv456").Return(&domain.Conversation{ID: "conv456", UserID: "other"}, nil)
+
+	// Execute
+	_, tooLong := service.SaveDraft(context.Background(), "conv123", SaveDraftRequest{Content: strings.Repeat("ñ", 11)}, "user123")
+	_, notOwner := service.SaveDraft(context.Background(), "conv456", SaveDraftRequest{Content: "hola"}, "user123")
+
+	// Assert
+	assert.True(t, errors.Is(tooLong, ErrInvalidDraft))
+	assert.True(t, errors.Is(notOwner, domain.ErrNotFound))
+	mockDraftRepo.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_Drafts_Disabled(t *testing.T) {
+	service := NewMessagingService(new(MockConversationRepository), new(MockMessageRepository), new(MockAttachmentRepository), NewNoOpEventPublisher(), NewNoOpCacheService(), logger.NewLogger("debug"))
+
+	_, err := service.GetDraft(context.Background(), "conv123", "user123")
+	assert.True(t, errors.Is(err, ErrDraftsDisabled))
+}
+
+func TestMessagingService_SendMessage_ClearsSenderDraft(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockDraftRepo := new(MockDraftRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo}, WithDrafts(mockDraftRepo, 10))
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123", Status: domain.ConversationStatusActive}, nil)
+	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+	mockConversationRepo.On("TouchUpdatedAt", mock.Anything, mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+	mockDraftRepo.On("Delete", mock.Anything, "conv123", "user123").Return(nil)
+
+	// Execute
+	_, err := service.SendMessage(context.Background(), SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "user123",
+		Content:        "Hola",
+		ContentType:    domain.ContentTypeText,
+	})
+
+	// Assert
+	require.NoError(t, err)
+	mockDraftRepo.AssertExpectations(t)
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// OutboxRelay publica los eventos de event_outbox. SendMessage guarda el evento message.received en la
+// misma transacción que el mensaje y lo publica en cuanto se confirma; si esa publicación falla, el relay
+// la reintenta con backoff exponencial hasta MaxAttempts. Así cada evento se publica al menos una vez y los
+// consumidores deben tolerar duplicados. Los que agotan los intentos quedan en la tabla como dead letter
+type OutboxRelay struct {
+	repo      domain.OutboxRepository
+	publisher EventPublisher
+	config    config.OutboxConfig
+	logger    logger.Logger
+}
+
+func NewOutboxRelay(repo domain.OutboxRepository, publisher EventPublisher, config config.OutboxConfig, logger logger.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		repo:      repo,
+		publisher: publisher,
+		config:    config,
+		logger:    logger,
+	}
+}
+
+// WithEventOutbox guarda el evento message.received de cada mensaje en event_outbox, en la transacción
+// del envío, y deja su publicación a cargo de relay; con relay nil el evento se publica sin outbox
+func WithEventOutbox(relay *OutboxRelay) MessagingServiceOption {
+	return func(s *messagingService) {
+		s.outbox = relay
+	}
+}
+
+// Start ejecuta el relay hasta que se cancele el contexto
+func (r *OutboxRelay) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.config.RelayInterval)
+	defer ticker.Stop()
+
+	r.logger.Info("Outbox relay started", map[string]interface{}{
+		"interval":     r.config.RelayInterval.String(),
+		"max_attempts": r.config.MaxAttempts,
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Outbox relay stopped")
+			return
+		case <-ticker.C:
+			r.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce reclama un lote de eventos pendientes, lo publica y devuelve cuántos se publicaron. Varias
+// instancias pueden ejecutarlo a la vez sin repartirse el mismo evento
+func (r *OutboxRelay) RunOnce(ctx context.Context) int {
+	now := time.Now()
+	events, err := r.repo.ClaimPending(ctx, now, now.Add(r.config.ClaimLease), r.config.MaxAttempts, r.config.BatchSize)
+	if err != nil {
+		r.logger.Error("Failed to load pending outbox events", err)
+		return 0
+	}
+
+	delivered := 0
+	for i := range events {
+		if r.publish(ctx, &events[i]) == nil {
+			delivered++
+		}
+	}
+
+	r.prune(ctx)
+	r.updateBacklog(ctx)
+
+	return delivered
+}
+
+// publish publica el evento guardado y anota el resultado en su fila
+func (r *OutboxRelay) publish(ctx context.Context, row *domain.OutboxEvent) error {
+	var event domain.MessageEvent
+	publishErr := json.Unmarshal(row.Payload, &event)
+	if publishErr == nil {
+		publishErr = r.publisher.PublishMessageEvent(ctx, event)
+	}
+
+	now := time.Now()
+	if publishErr == nil {
+		// If this fails the event stays pending and is published again, which at-least-once allows
+		if err := r.repo.MarkDelivered(ctx, row.ID, now); err != nil {
+			r.logger.Error("Failed to mark outbox event as delivered", err)
+		}
+		return nil
+	}
+
+	attempts := row.Attempts + 1
+	if err := r.repo.MarkFailed(ctx, row.ID, publishErr.Error(), now.Add(r.backoff(attempts))); err != nil {
+		r.logger.Error("Failed to record outbox publish failure", err)
+	}
+
+	fields := map[string]interface{}{
+		"outbox_id":       row.ID,
+		"event_type":      row.EventType,
+		"conversation_id": row.ConversationID,
+		"attempts":        attempts,
+		"error":           publishErr.Error(),
+	}
+	if attempts >= r.config.MaxAttempts {
+		r.logger.Error("Outbox event exhausted its attempts, left as dead letter", fields)
+	} else {
+		r.logger.Warn("Outbox event publish failed, will retry", fields)
+	}
+
+	return fmt.Errorf("failed to publish outbox event: %w", publishErr)
+}
+
+// prune elimina un lote de los eventos publicados que superan la retención
+func (r *OutboxRelay) prune(ctx context.Context) {
+	if r.config.Retention <= 0 {
+		return
+	}
+
+	deleted, err := r.repo.DeleteDeliveredBefore(ctx, time.Now().Add(-r.config.Retention), r.config.BatchSize)
+	if err != nil {
+		r.logger.Error("Failed to prune delivered outbox events", err)
+		return
+	}
+	if deleted > 0 {
+		r.logger.Debug("Delivered outbox events pruned", map[string]interface{}{
+			"pruned": deleted,
+		})
+	}
+}
+
+// updateBacklog actualiza outbox_backlog_size con los eventos pendientes y los que quedaron como dead letter
+func (r *OutboxRelay) updateBacklog(ctx context.Context) {
+	pending, dead, err := r.repo.CountUndelivered(ctx, r.config.MaxAttempts)
+	if err != nil {
+		r.logger.Error("Failed to count undelivered outbox events", err)
+		return
+	}
+
+	outboxBacklogSize.WithLabelValues("pending").Set(float64(pending))
+	outboxBacklogSize.WithLabelValues("dead").Set(float64(dead))
+}
+
+// backoff calcula la espera exponencial tras el intento indicado
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	delay := r.config.RetryBaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= r.config.RetryMaxBackoff {
+			return r.config.RetryMaxBackoff
+		}
+	}
+	return delay
+}
+
+// enqueueMessageEvent guarda en el outbox el evento message.received del mensaje; se llama dentro de la
+// transacción del envío, de modo que el mensaje no queda guardado sin su evento
+func (s *messagingService) enqueueMessageEvent(ctx context.Context, send *SendContext) error {
+	if s.outbox == nil {
+		return nil
+	}
+
+	event := withCorrelationID(ctx, newMessageReceivedEvent(send.Message))
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode message event: %w", err)
+	}
+
+	now := time.Now()
+	row := &domain.OutboxEvent{
+		ID:             uuid.New().String(),
+		EventType:      event.Type,
+		ConversationID: event.ConversationID,
+		Payload:        payload,
+		CreatedAt:      now,
+		// SendMessage publishes it right after the commit, so the relay only picks it up if that fails
+		NextAttemptAt: now.Add(s.outbox.config.RetryBaseBackoff),
+	}
+	if err := s.outbox.repo.Create(ctx, row); err != nil {
+		return err
+	}
+
+	send.outboxEvent = row
+	return nil
+}
+
+// publishMessageEvent es el hook event_publish: con outbox publica el evento guardado con el mensaje y,
+// si falla, lo deja pendiente para el relay
+func (s *messagingService) publishMessageEvent(ctx context.Context, send *SendContext) error {
+	if send.outboxEvent == nil {
+		return NewEventPublishSendHook(s.eventPublisher).Handle(ctx, send)
+	}
+	return s.outbox.publish(ctx, send.outboxEvent)
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryOutboxRepository guarda los eventos del outbox en memoria con la misma semántica que Postgres
+type memoryOutboxRepository struct {
+	mu     sync.Mutex
+	events []domain.OutboxEvent
+}
+
+func (r *memoryOutboxRepository) Create(ctx context.Context, event *domain.OutboxEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *memoryOutboxRepository) ClaimPending(ctx context.Context, now time.Time, leaseUntil time.Time, maxAttempts int, limit int) ([]domain.OutboxEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var pending []domain.OutboxEvent
+	for i := range r.events {
+		event := &r.events[i]
+		if event.DeliveredAt == nil && !event.NextAttemptAt.After(now) && event.Attempts < maxAttempts && len(pending) < limit {
+			event.NextAttemptAt = leaseUntil
+			pending = append(pending, *event)
+		}
+	}
+	return pending, nil
+}
+
+func (r *memoryOutboxRepository) MarkDelivered(ctx context.Context, id string, deliveredAt time.Time) error {
+	return r.update(id, func(event *domain.OutboxEvent) {
+		event.Attempts++
+		event.DeliveredAt = &deliveredAt
+	})
+}
+
+func (r *memoryOutboxRepository) MarkFailed(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error {
+	return r.update(id, func(event *domain.OutboxEvent) {
+		event.Attempts++
+		event.LastError = lastError
+		event.NextAttemptAt = nextAttemptAt
+	})
+}
+
+func (r *memoryOutboxRepository) CountUndelivered(ctx context.Context, maxAttempts int) (int, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pending, dead := 0, 0
+	for _, event := range r.events {
+		switch {
+		case event.DeliveredAt != nil:
+		case event.Attempts >= maxAttempts:
+			dead++
+		default:
+			pending++
+		}
+	}
+	return pending, dead, nil
+}
+
+func (r *memoryOutboxRepository) DeleteDeliveredBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	return 0, nil
+}
+
+func (r *memoryOutboxRepository) update(id string, fn func(event *domain.OutboxEvent)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.events {
+		if r.events[i].ID == id {
+			fn(&r.events[i])
+			return nil
+		}
+	}
+	return domain.ErrNotFound
+}
+
+func (r *memoryOutboxRepository) snapshot() []domain.OutboxEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]domain.OutboxEvent(nil), r.events...)
+}
+
+// flakyEventPublisher falla mientras down sea true y guarda los eventos publicados
+type flakyEventPublisher struct {
+	recordingEventPublisher
+	down bool
+}
+
+func (p *flakyEventPublisher) PublishMessageEvent(ctx context.Context, event domain.MessageEvent) error {
+	if p.down {
+		return errors.New("broker unavailable")
+	}
+	return p.recordingEventPublisher.PublishMessageEvent(ctx, event)
+}
+
+func newTestOutboxConfig() config.OutboxConfig {
+	return config.OutboxConfig{
+		Enabled:          true,
+		RelayInterval:    time.Second,
+		BatchSize:        10,
+		MaxAttempts:      3,
+		RetryBaseBackoff: time.Millisecond,
+		RetryMaxBackoff:  time.Millisecond,
+		ClaimLease:       time.Minute,
+	}
+}
+
+func outboxTestRequest() SendMessageRequest {
+	return SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "user123",
+		Content:        "Hola",
+		ContentType:    domain.ContentTypeText,
+	}
+}
+
+func TestEventOutbox_PublishedEventIsMarkedDelivered(t *testing.T) {
+	// Setup
+	repo := &memoryOutboxRepository{}
+	publisher := &flakyEventPublisher{}
+	relay := NewOutboxRelay(repo, publisher, newTestOutboxConfig(), logger.NewLogger("debug"))
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	expectSendMessage(mockConversationRepo, mockMessageRepo)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo, publisher: publisher}, WithEventOutbox(relay))
+
+	// Execute
+	message, err := service.SendMessage(context.Background(), outboxTestRequest())
+
+	// Assert: the event is published right away and its row is closed
+	require.NoError(t, err)
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, message.ID, publisher.events[0].Message.ID)
+	rows := repo.snapshot()
+	require.Len(t, rows, 1)
+	assert.NotNil(t, rows[0].DeliveredAt)
+	assert.Equal(t, 1, rows[0].Attempts)
+}
+
+func TestEventOutbox_RelayDrainsFailedPublish(t *testing.T) {
+	// Setup: the broker is down when the message is sent
+	repo := &memoryOutboxRepository{}
+	publisher := &flakyEventPublisher{down: true}
+	relay := NewOutboxRelay(repo, publisher, newTestOutboxConfig(), logger.NewLogger("debug"))
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	expectSendMessage(mockConversationRepo, mockMessageRepo)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo, publisher: publisher}, WithEventOutbox(relay))
+
+	message, err := service.SendMessage(context.Background(), outboxTestRequest())
+
+	// The message is saved and its event waits in the outbox instead of being lost
+	require.NoError(t, err)
+	assert.Empty(t, publisher.events)
+	rows := repo.snapshot()
+	require.Len(t, rows, 1)
+	assert.Nil(t, rows[0].DeliveredAt)
+	assert.Equal(t, 1, rows[0].Attempts)
+	assert.Equal(t, "broker unavailable", rows[0].LastError)
+
+	// Execute: the relay runs once the broker is back
+	publisher.down = false
+	require.Eventually(t, func() bool { return relay.RunOnce(context.Background()) == 1 }, time.Second, 5*time.Millisecond)
+
+	// Assert
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, "message.received", publisher.events[0].Type)
+	assert.Equal(t, message.ID, publisher.events[0].Message.ID)
+	assert.NotNil(t, repo.snapshot()[0].DeliveredAt)
+	assert.Zero(t, relay.RunOnce(context.Background()))
+}
+
+func TestOutboxRelay_DeadLettersAfterMaxAttempts(t *testing.T) {
+	// Setup
+	repo := &memoryOutboxRepository{}
+	publisher := &flakyEventPublisher{down: true}
+	cfg := newTestOutboxConfig()
+	relay := NewOutboxRelay(repo, publisher, cfg, logger.NewLogger("debug"))
+	require.NoError(t, repo.Create(context.Background(), &domain.OutboxEvent{
+		ID:             "evt1",
+		EventType:      "message.received",
+		ConversationID: "conv123",
+		Payload:        []byte(`{"type":"message.received","conversation_id":"conv123"}`),
+		NextAttemptAt:  time.Now(),
+	}))
+
+	// Execute: every attempt fails until the attempts run out
+	require.Eventually(t, func() bool {
+		relay.RunOnce(context.Background())
+		return repo.snapshot()[0].Attempts == cfg.MaxAttempts
+	}, time.Second, 5*time.Millisecond)
+
+	// Assert: the row stays as a dead letter and is no longer retried
+	pending, dead, err := repo.CountUndelivered(context.Background(), cfg.MaxAttempts)
+	require.NoError(t, err)
+	assert.Zero(t, pending)
+	assert.Equal(t, 1, dead)
+
+	publisher.down = false
+	time.Sleep(5 * time.Millisecond)
+	assert.Zero(t, relay.RunOnce(context.Background()))
+	assert.Empty(t, publisher.events)
+}
+
+func TestOutboxRelay_ClaimedEventsWaitForTheLease(t *testing.T) {
+	// Setup: another relay claimed the event and died before publishing it
+	repo := &memoryOutboxRepository{}
+	publisher := &flakyEventPublisher{}
+	cfg := newTestOutboxConfig()
+	relay := NewOutboxRelay(repo, publisher, cfg, logger.NewLogger("debug"))
+	require.NoError(t, repo.Create(context.Background(), &domain.OutboxEvent{
+		ID:             "evt1",
+		EventType:      "message.received",
+		ConversationID: "conv123",
+		Payload:        []byte(`{"type":"message.received","conversation_id":"conv123"}`),
+		NextAttemptAt:  time.Now(),
+	}))
+	now := time.Now()
+	claimed, err := repo.ClaimPending(context.Background(), now, now.Add(20*time.Millisecond), cfg.MaxAttempts, cfg.BatchSize)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	// Execute and assert: nobody else publishes it while the lease holds
+	assert.Zero(t, relay.RunOnce(context.Background()))
+	assert.Empty(t, publisher.events)
+
+	// Execute and assert: once it expires the event is published
+	require.Eventually(t, func() bool { return relay.RunOnce(context.Background()) == 1 }, time.Second, 5*time.Millisecond)
+	require.Len(t, publisher.events, 1)
+	assert.NotNil(t, repo.snapshot()[0].DeliveredAt)
+}
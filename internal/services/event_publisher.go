@@ -3,14 +3,56 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/company/microservice-template/internal/domain"
 	"github.com/company/microservice-template/pkg/logger"
 	"github.com/redis/go-redis/v9"
 )
 
+// ephemeralEventType etiqueta en las métricas los eventos publicados con PublishEvent
+const ephemeralEventType = "ephemeral"
+
 type EventPublisher interface {
 	PublishMessageEvent(ctx context.Context, event domain.MessageEvent) error
+	// PublishEvent publica payload como JSON en channel. Es para eventos efímeros, como los de escritura,
+	// que no se guardan ni pasan por los consumidores de eventos de mensajes
+	PublishEvent(ctx context.Context, channel string, payload interface{}) error
+}
+
+// correlatingEventPublisher copia en metadata.correlation_id de cada evento el request_id de la petición
+// que lo provocó, para seguirlo de un servicio a otro
+type correlatingEventPublisher struct {
+	EventPublisher
+}
+
+func (p correlatingEventPublisher) PublishMessageEvent(ctx context.Context, event domain.MessageEvent) error {
+	return p.EventPublisher.PublishMessageEvent(ctx, withCorrelationID(ctx, event))
+}
+
+// withCorrelationID añade al evento el request_id del contexto, salvo que ya tenga un correlation_id o
+// no haya petición en curso, como en los workers
+func withCorrelationID(ctx context.Context, event domain.MessageEvent) domain.MessageEvent {
+	requestID := logger.RequestIDFromContext(ctx)
+	if requestID == "" {
+		return event
+	}
+	if _, ok := event.Metadata["correlation_id"]; ok {
+		return event
+	}
+
+	metadata := make(domain.JSONB, len(event.Metadata)+1)
+	for key, value := range event.Metadata {
+		metadata[key] = value
+	}
+	metadata["correlation_id"] = requestID
+	event.Metadata = metadata
+	return event
+}
+
+// ConversationEventChannel es el canal de Redis con los eventos efímeros de una conversación
+func ConversationEventChannel(conversationID string) string {
+	return "conversation:" + conversationID
 }
 
 type redisEventPublisher struct {
@@ -28,13 +70,20 @@ func NewRedisEventPublisher(client *redis.Client, topic string, logger logger.Lo
 }
 
 func (p *redisEventPublisher) PublishMessageEvent(ctx context.Context, event domain.MessageEvent) error {
+	start := time.Now()
+	defer func() {
+		eventPublishDuration.WithLabelValues("redis", event.Type).Observe(time.Since(start).Seconds())
+	}()
+
 	data, err := json.Marshal(event)
 	if err != nil {
+		eventPublishFailuresTotal.WithLabelValues("redis", event.Type).Inc()
 		p.logger.Error("Failed to marshal event", err)
 		return err
 	}
 
 	if err := p.client.Publish(ctx, p.topic, data).Err(); err != nil {
+		eventPublishFailuresTotal.WithLabelValues("redis", event.Type).Inc()
 		p.logger.Error("Failed to publish event to Redis", err)
 		return err
 	}
@@ -48,6 +97,28 @@ func (p *redisEventPublisher) PublishMessageEvent(ctx context.Context, event dom
 	return nil
 }
 
+func (p *redisEventPublisher) PublishEvent(ctx context.Context, channel string, payload interface{}) error {
+	start := time.Now()
+	defer func() {
+		eventPublishDuration.WithLabelValues("redis", ephemeralEventType).Observe(time.Since(start).Seconds())
+	}()
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		eventPublishFailuresTotal.WithLabelValues("redis", ephemeralEventType).Inc()
+		p.logger.Error("Failed to marshal event", err)
+		return err
+	}
+
+	if err := p.client.Publish(ctx, channel, data).Err(); err != nil {
+		eventPublishFailuresTotal.WithLabelValues("redis", ephemeralEventType).Inc()
+		p.logger.Error("Failed to publish event to Redis", err)
+		return err
+	}
+
+	return nil
+}
+
 // NoOpEventPublisher for when events are disabled
 type noOpEventPublisher struct{}
 
@@ -58,4 +129,9 @@ func NewNoOpEventPublisher() EventPublisher {
 func (p *noOpEventPublisher) PublishMessageEvent(ctx context.Context, event domain.MessageEvent) error {
 	// Do nothing
 	return nil
+}
+
+func (p *noOpEventPublisher) PublishEvent(ctx context.Context, channel string, payload interface{}) error {
+	// Do nothing
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisEventPublisher_RecordsFailureMetrics(t *testing.T) {
+	// Setup: a client pointed at a closed port so every publish fails
+	client := redis.NewClient(&redis.Options{
+		Addr:        "127.0.0.1:1",
+		DialTimeout: 100 * time.Millisecond,
+		MaxRetries:  -1,
+	})
+	defer client.Close()
+
+	publisher := NewRedisEventPublisher(client, "message.events", logger.NewLogger("debug"))
+	failures := eventPublishFailuresTotal.WithLabelValues("redis", "metrics.test")
+	before := testutil.ToFloat64(failures)
+
+	// Execute
+	err := publisher.PublishMessageEvent(context.Background(), domain.MessageEvent{
+		Type:           "metrics.test",
+		ConversationID: "conv123",
+		Timestamp:      time.Now(),
+	})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, before+1, testutil.ToFloat64(failures))
+	assert.Equal(t, 1, testutil.CollectAndCount(eventPublishDuration, "event_publish_duration_seconds"))
+}
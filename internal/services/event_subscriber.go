@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// eventSubscriptionBuffer es la cantidad de eventos que se retienen para un suscriptor lento
+const eventSubscriptionBuffer = 32
+
+// EventSubscriber entrega en tiempo real los eventos publicados de una conversación
+type EventSubscriber interface {
+	// Subscribe devuelve los eventos de la conversación, tal como se publicaron en JSON, hasta que ctx
+	// termina; entonces libera la suscripción y cierra el canal
+	Subscribe(ctx context.Context, conversationID string) (<-chan json.RawMessage, error)
+}
+
+// redisEventSubscriber lee los canales de Redis en los que publica redisEventPublisher. Todas las
+// conversaciones comparten el de los eventos de mensajes, así que estos se filtran por conversation_id;
+// los efímeros llegan por ConversationEventChannel y se entregan sin más
+type redisEventSubscriber struct {
+	client *redis.Client
+	topic  string
+	logger logger.Logger
+}
+
+func NewRedisEventSubscriber(client *redis.Client, topic string, logger logger.Logger) EventSubscriber {
+	return &redisEventSubscriber{
+		client: client,
+		topic:  topic,
+		logger: logger,
+	}
+}
+
+func (s *redisEventSubscriber) Subscribe(ctx context.Context, conversationID string) (<-chan json.RawMessage, error) {
+	channels := []string{s.topic, ConversationEventChannel(conversationID)}
+	pubsub := s.client.Subscribe(ctx, channels...)
+
+	// Wait for every confirmation so no event published after Subscribe returns is missed
+	for range channels {
+		if _, err := pubsub.Receive(ctx); err != nil {
+			pubsub.Close()
+			return nil, fmt.Errorf("failed to subscribe to events: %w", err)
+		}
+	}
+
+	events := make(chan json.RawMessage, eventSubscriptionBuffer)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				if msg.Channel == s.topic {
+					var event domain.MessageEvent
+					if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+						s.logger.Error("Failed to decode published event", err)
+						continue
+					}
+					if event.ConversationID != conversationID {
+						continue
+					}
+				}
+
+				select {
+				case events <- json.RawMessage(msg.Payload):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// NoOpEventSubscriber for when events are disabled
+type noOpEventSubscriber struct{}
+
+func NewNoOpEventSubscriber() EventSubscriber {
+	return &noOpEventSubscriber{}
+}
+
+func (s *noOpEventSubscriber) Subscribe(ctx context.Context, conversationID string) (<-chan json.RawMessage, error) {
+	return nil, fmt.Errorf("events are disabled")
+}
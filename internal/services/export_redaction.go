@@ -0,0 +1,36 @@
+package services
+
+import "github.com/company/microservice-template/internal/domain"
+
+// exportRoleLabels sustituyen el ID del remitente en las exportaciones redactadas
+var exportRoleLabels = map[domain.SenderType]string{
+	domain.SenderTypeUser:   "customer",
+	domain.SenderTypeAgent:  "agent",
+	domain.SenderTypeBot:    "bot",
+	domain.SenderTypeSystem: "system",
+}
+
+// WithExportRedactor enmascara los datos personales de las exportaciones redactadas con las categorías de
+// redactor; sin él se usan los patrones incorporados
+func WithExportRedactor(redactor *PIIRedactor) MessagingServiceOption {
+	return func(s *messagingService) {
+		s.exportPIIRedactor = redactor
+	}
+}
+
+// exportRedactor devuelve el redactor de WithExportRedactor o, si no se configuró, uno con los patrones incorporados
+func (s *messagingService) exportRedactor() *PIIRedactor {
+	if s.exportPIIRedactor != nil {
+		return s.exportPIIRedactor
+	}
+
+	redactor, _ := NewPIIRedactor([]string{"credit_card", "ssn", "email"}, nil)
+	return redactor
+}
+
+// redactForExport devuelve el remitente y el contenido del mensaje tal como aparecen en una transcripción para
+// terceros: el rol en lugar del ID y los datos personales enmascarados
+func redactForExport(message *domain.Message, redactor *PIIRedactor) (senderID string, content string) {
+	content, _ = redactor.Redact(message.Content)
+	return exportRoleLabels[message.SenderType], content
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactForExport(t *testing.T) {
+	// Setup
+	redactor, err := NewPIIRedactor([]string{"email"}, nil)
+	require.NoError(t, err)
+	message := &domain.Message{
+		SenderType: domain.SenderTypeBot,
+		SenderID:   "bot123",
+		Content:    "Write to ana@example.com",
+	}
+
+	// Execute
+	senderID, content := redactForExport(message, redactor)
+
+	// Assert
+	assert.Equal(t, "bot", senderID)
+	assert.Equal(t, "Write to [REDACTED_EMAIL]", content)
+	assert.Equal(t, "Write to ana@example.com", message.Content)
+}
+
+func TestExportRedactor_DefaultsToBuiltInPatterns(t *testing.T) {
+	// Setup
+	configured, err := NewPIIRedactor([]string{"email"}, nil)
+	require.NoError(t, err)
+
+	// Execute
+	fallback := (&messagingService{}).exportRedactor()
+	withOption := (&messagingService{exportPIIRedactor: configured}).exportRedactor()
+
+	// Assert
+	require.NotNil(t, fallback)
+	redacted, categories := fallback.Redact("card 4111 1111 1111 1111")
+	assert.NotContains(t, redacted, "4111")
+	assert.Equal(t, []string{"credit_card"}, categories)
+	assert.Same(t, configured, withOption)
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+// ErrInvalidFileType indica que el contenido del archivo no corresponde a su extensión o no está permitido
+var ErrInvalidFileType = errors.New("invalid file type")
+
+// sniffLength es lo que http.DetectContentType tiene en cuenta del principio del archivo
+const sniffLength = 512
+
+type contentTypeValidatingFileService struct {
+	FileService
+	allowed []string
+	logger  logger.Logger
+}
+
+// NewContentTypeValidatingFileService detecta el tipo MIME real de cada archivo por sus primeros bytes y
+// rechaza la subida si no es de la categoría (imagen, vídeo, audio u otro) que indica su extensión o, con
+// allowed, si no está en la lista. allowed admite comodines como "image/*"; vacía admite cualquier tipo
+func NewContentTypeValidatingFileService(fileService FileService, allowed []string, logger logger.Logger) FileService {
+	normalized := make([]string, 0, len(allowed))
+	for _, mimeType := range allowed {
+		if mimeType = strings.ToLower(strings.TrimSpace(mimeType)); mimeType != "" {
+			normalized = append(normalized, mimeType)
+		}
+	}
+
+	return &contentTypeValidatingFileService{
+		FileService: fileService,
+		allowed:     normalized,
+		logger:      logger,
+	}
+}
+
+func (s *contentTypeValidatingFileService) UploadFile(ctx context.Context, req UploadFileRequest) (*UploadFileResponse, error) {
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(req.File, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	head = head[:n]
+
+	sniffed := detectContentType(head)
+	declared := determineFileType(req.Filename)
+	if !contentMatchesFileType(sniffed, declared) {
+		s.logger.Warn("File content does not match its extension", map[string]interface{}{
+			"filename":  req.Filename,
+			"user_id":   req.UserID,
+			"sniffed":   sniffed,
+			"extension": strings.ToLower(filepath.Ext(req.Filename)),
+		})
+		return nil, fmt.Errorf("%w: content of %q is %s, which is not a valid %s", ErrInvalidFileType, req.Filename, sniffed, declared)
+	}
+
+	if !s.isAllowed(sniffed) {
+		s.logger.Warn("File type not allowed", map[string]interface{}{
+			"filename": req.Filename,
+			"user_id":  req.UserID,
+			"sniffed":  sniffed,
+		})
+		return nil, fmt.Errorf("%w: %s files are not allowed", ErrInvalidFileType, sniffed)
+	}
+
+	// The provider still gets the whole file
+	req.File = io.MultiReader(bytes.NewReader(head), req.File)
+	return s.FileService.UploadFile(ctx, req)
+}
+
+func (s *contentTypeValidatingFileService) isAllowed(mimeType string) bool {
+	if len(s.allowed) == 0 {
+		return true
+	}
+
+	for _, allowed := range s.allowed {
+		if allowed == mimeType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mimeType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// detectContentType devuelve el tipo MIME detectado sin parámetros (p. ej. "text/plain" en lugar de
+// "text/plain; charset=utf-8")
+func detectContentType(head []byte) string {
+	detected := http.DetectContentType(head)
+	if mediaType, _, err := mime.ParseMediaType(detected); err == nil {
+		return mediaType
+	}
+	return detected
+}
+
+// contentMatchesFileType indica si el tipo MIME detectado es de la categoría que determineFileType dedujo
+// de la extensión
+func contentMatchesFileType(mimeType string, declared domain.AttachmentType) bool {
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return declared == domain.AttachmentTypeImage
+	case strings.HasPrefix(mimeType, "video/"):
+		return declared == domain.AttachmentTypeVideo
+	case strings.HasPrefix(mimeType, "audio/"):
+		return declared == domain.AttachmentTypeAudio
+	case mimeType == "application/ogg":
+		// Ogg is detected as a generic container; .ogg is audio and .ogv video
+		return declared == domain.AttachmentTypeAudio || declared == domain.AttachmentTypeVideo
+	default:
+		return declared == domain.AttachmentTypeFile
+	}
+}
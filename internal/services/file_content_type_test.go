@@ -0,0 +1,65 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// pngHeader es la firma con la que empieza cualquier PNG
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestContentTypeValidatingFileService_AllowsImage(t *testing.T) {
+	mockFileService := new(MockFileService)
+	var stored []byte
+	mockFileService.On("UploadFile", mock.Anything, mock.MatchedBy(func(req UploadFileRequest) bool {
+		stored, _ = io.ReadAll(req.File)
+		return true
+	})).Return(&UploadFileResponse{URL: "/uploads/user123/foto.png"}, nil)
+
+	service := NewContentTypeValidatingFileService(mockFileService, []string{"image/*", "application/pdf"}, logger.NewLogger("debug"))
+
+	// Longer than what is sniffed, so the provider must get the sniffed bytes back
+	content := append(append([]byte{}, pngHeader...), bytes.Repeat([]byte{0}, 1024)...)
+	result, err := service.UploadFile(context.Background(), UploadFileRequest{File: bytes.NewReader(content), Filename: "foto.png", UserID: "user123"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "/uploads/user123/foto.png", result.URL)
+	assert.Equal(t, content, stored)
+}
+
+func TestContentTypeValidatingFileService_RejectsMismatch(t *testing.T) {
+	mockFileService := new(MockFileService)
+	service := NewContentTypeValidatingFileService(mockFileService, nil, logger.NewLogger("debug"))
+
+	// A Windows executable renamed to .jpg
+	_, err := service.UploadFile(context.Background(), UploadFileRequest{File: strings.NewReader("MZ\x90\x00\x03\x00\x00\x00"), Filename: "foto.jpg", UserID: "user123"})
+	assert.ErrorIs(t, err, ErrInvalidFileType)
+
+	// An image can't pose as a document either
+	_, err = service.UploadFile(context.Background(), UploadFileRequest{File: bytes.NewReader(pngHeader), Filename: "informe.pdf", UserID: "user123"})
+	assert.ErrorIs(t, err, ErrInvalidFileType)
+
+	mockFileService.AssertNotCalled(t, "UploadFile", mock.Anything, mock.Anything)
+}
+
+func TestContentTypeValidatingFileService_AllowList(t *testing.T) {
+	mockFileService := new(MockFileService)
+	mockFileService.On("UploadFile", mock.Anything, mock.Anything).Return(&UploadFileResponse{}, nil)
+	service := NewContentTypeValidatingFileService(mockFileService, []string{"image/*", "application/pdf"}, logger.NewLogger("debug"))
+
+	// The extension matches the content, but plain text isn't on the list
+	_, err := service.UploadFile(context.Background(), UploadFileRequest{File: strings.NewReader("hola"), Filename: "notas.txt", UserID: "user123"})
+	assert.ErrorIs(t, err, ErrInvalidFileType)
+
+	_, err = service.UploadFile(context.Background(), UploadFileRequest{File: strings.NewReader("%PDF-1.7\n"), Filename: "informe.pdf", UserID: "user123"})
+	assert.NoError(t, err)
+	mockFileService.AssertNumberOfCalls(t, "UploadFile", 1)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
@@ -10,16 +11,26 @@ import (
 	"strings"
 	"time"
 
+	"github.com/company/microservice-template/internal/auth"
 	"github.com/company/microservice-template/internal/config"
 	"github.com/company/microservice-template/internal/domain"
 	"github.com/company/microservice-template/pkg/logger"
 	"github.com/google/uuid"
 )
 
+// ErrFileTooLarge indica que el archivo supera FILE_STORAGE_MAX_SIZE
+var ErrFileTooLarge = errors.New("file size exceeds maximum allowed size")
+
 type FileService interface {
 	UploadFile(ctx context.Context, req UploadFileRequest) (*UploadFileResponse, error)
+	// DeleteFile borra el archivo; devuelve domain.ErrNotFound si ya no existe
 	DeleteFile(ctx context.Context, url string) error
 	GetFileInfo(ctx context.Context, url string) (*FileInfo, error)
+	// GeneratePresignedURL devuelve una URL de descarga del archivo válida durante ttl
+	GeneratePresignedURL(ctx context.Context, url string, ttl time.Duration) (string, error)
+	// DownloadFile abre el archivo para leerlo; devuelve domain.ErrNotFound si no existe. Si el lector
+	// implementa io.ReadSeeker se pueden servir rangos. Quien llama debe cerrarlo
+	DownloadFile(ctx context.Context, url string) (io.ReadCloser, *FileInfo, error)
 }
 
 type UploadFileRequest struct {
@@ -30,28 +41,34 @@ type UploadFileRequest struct {
 }
 
 type UploadFileResponse struct {
-	URL      string
-	Filename string
-	Size     int64
-	Type     domain.AttachmentType
+	URL          string
+	Filename     string
+	Size         int64
+	Type         domain.AttachmentType
+	ThumbnailURL string // Solo en imágenes, con FILE_THUMBNAIL_SIZE mayor que 0
 }
 
 type FileInfo struct {
-	URL      string
-	Filename string
-	Size     int64
-	Type     domain.AttachmentType
-	Exists   bool
+	URL         string
+	Filename    string
+	Size        int64
+	Type        domain.AttachmentType
+	ContentType string
+	ModTime     time.Time
+	UserID      string // Usuario que subió el archivo, según su ruta {userID}/{nombre único}
+	Exists      bool
 }
 
 type localFileService struct {
 	config *config.FileStorageConfig
+	signer *auth.FileURLSigner
 	logger logger.Logger
 }
 
 func NewLocalFileService(config *config.FileStorageConfig, logger logger.Logger) FileService {
 	return &localFileService{
 		config: config,
+		signer: auth.NewFileURLSigner(config.SigningSecret),
 		logger: logger,
 	}
 }
@@ -59,7 +76,7 @@ func NewLocalFileService(config *config.FileStorageConfig, logger logger.Logger)
 func (s *localFileService) UploadFile(ctx context.Context, req UploadFileRequest) (*UploadFileResponse, error) {
 	// Validate file size
 	if req.Size > s.config.MaxFileSize {
-		return nil, fmt.Errorf("file size exceeds maximum allowed size of %d bytes", s.config.MaxFileSize)
+		return nil, fmt.Errorf("%w of %d bytes", ErrFileTooLarge, s.config.MaxFileSize)
 	}
 
 	// Generate unique filename
@@ -84,17 +101,21 @@ func (s *localFileService) UploadFile(ctx context.Context, req UploadFileRequest
 	}
 	defer file.Close()
 
-	// Copy file content
-	written, err := io.Copy(file, req.File)
+	// Copy file content; the declared size can't be trusted, so the copy stops past the limit
+	written, err := io.Copy(file, io.LimitReader(req.File, s.config.MaxFileSize+1))
 	if err != nil {
 		s.logger.Error("Failed to write file content", err)
 		// Clean up partial file
 		os.Remove(filePath)
 		return nil, fmt.Errorf("failed to write file: %w", err)
 	}
+	if written > s.config.MaxFileSize {
+		os.Remove(filePath)
+		return nil, fmt.Errorf("%w of %d bytes", ErrFileTooLarge, s.config.MaxFileSize)
+	}
 
 	// Determine file type
-	fileType := s.determineFileType(req.Filename)
+	fileType := determineFileType(req.Filename)
 
 	// Generate URL (relative path for local storage)
 	url := fmt.Sprintf("/uploads/%s/%s", req.UserID, uniqueFilename)
@@ -123,7 +144,7 @@ func (s *localFileService) DeleteFile(ctx context.Context, url string) error {
 	
 	if err := os.Remove(filePath); err != nil {
 		if os.IsNotExist(err) {
-			return fmt.Errorf("file not found")
+			return fmt.Errorf("file %w", domain.ErrNotFound)
 		}
 		s.logger.Error("Failed to delete file", err)
 		return fmt.Errorf("failed to delete file: %w", err)
@@ -138,7 +159,13 @@ func (s *localFileService) DeleteFile(ctx context.Context, url string) error {
 
 func (s *localFileService) GetFileInfo(ctx context.Context, url string) (*FileInfo, error) {
 	// Convert URL to file path
-	filePath := filepath.Join(s.config.LocalPath, strings.TrimPrefix(url, "/uploads/"))
+	filePath, ok := s.filePath(url)
+	if !ok {
+		return &FileInfo{
+			URL:    url,
+			Exists: false,
+		}, nil
+	}
 	
 	stat, err := os.Stat(filePath)
 	if err != nil {
@@ -155,18 +182,92 @@ func (s *localFileService) GetFileInfo(ctx context.Context, url string) (*FileIn
 	filename := filepath.Base(filePath)
 	
 	// Determine file type
-	fileType := s.determineFileType(filename)
+	fileType := determineFileType(filename)
 
 	return &FileInfo{
 		URL:      url,
 		Filename: filename,
 		Size:     stat.Size(),
 		Type:     fileType,
+		UserID:   fileOwner(strings.TrimPrefix(url, "/uploads/")),
 		Exists:   true,
 	}, nil
 }
 
-func (s *localFileService) determineFileType(filename string) domain.AttachmentType {
+// DownloadFile abre el archivo local; *os.File admite Seek, así que la descarga puede servir rangos
+func (s *localFileService) DownloadFile(ctx context.Context, url string) (io.ReadCloser, *FileInfo, error) {
+	filePath, ok := s.filePath(url)
+	if !ok {
+		return nil, nil, fmt.Errorf("file %w", domain.ErrNotFound)
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, fmt.Errorf("file %w", domain.ErrNotFound)
+		}
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+	if stat.IsDir() {
+		file.Close()
+		return nil, nil, fmt.Errorf("file %w", domain.ErrNotFound)
+	}
+
+	filename := filepath.Base(filePath)
+
+	return file, &FileInfo{
+		URL:         url,
+		Filename:    filename,
+		Size:        stat.Size(),
+		Type:        determineFileType(filename),
+		ContentType: mime.TypeByExtension(strings.ToLower(filepath.Ext(filename))),
+		ModTime:     stat.ModTime(),
+		UserID:      fileOwner(strings.TrimPrefix(url, "/uploads/")),
+		Exists:      true,
+	}, nil
+}
+
+// filePath convierte la URL en la ruta del archivo; devuelve false si la URL sale del directorio de subidas
+func (s *localFileService) filePath(url string) (string, bool) {
+	root, err := filepath.Abs(s.config.LocalPath)
+	if err != nil {
+		return "", false
+	}
+	filePath := filepath.Join(root, strings.TrimPrefix(url, "/uploads/"))
+	// Attachment URLs are stored as sent, so a crafted one must not reach outside the upload directory
+	return filePath, strings.HasPrefix(filePath, root+string(filepath.Separator))
+}
+
+// GeneratePresignedURL añade a la URL la caducidad y su firma HMAC, que comprueba la ruta /uploads. Sin
+// FILE_URL_SIGNING_SECRET las descargas son públicas y la URL se devuelve tal cual
+func (s *localFileService) GeneratePresignedURL(ctx context.Context, url string, ttl time.Duration) (string, error) {
+	if ttl <= 0 {
+		return "", fmt.Errorf("presigned url ttl must be positive")
+	}
+	if !s.signer.Enabled() {
+		return url, nil
+	}
+
+	return url + "?" + s.signer.SignedQuery(url, time.Now().Add(ttl)), nil
+}
+
+// fileOwner devuelve el usuario de una ruta {userID}/{nombre único}, o vacío si no tiene esa forma
+func fileOwner(path string) string {
+	owner, _, found := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !found {
+		return ""
+	}
+	return owner
+}
+
+// determineFileType clasifica el archivo por el tipo MIME de su extensión
+func determineFileType(filename string) domain.AttachmentType {
 	ext := strings.ToLower(filepath.Ext(filename))
 	mimeType := mime.TypeByExtension(ext)
 
@@ -199,4 +300,12 @@ func (s *noOpFileService) DeleteFile(ctx context.Context, url string) error {
 
 func (s *noOpFileService) GetFileInfo(ctx context.Context, url string) (*FileInfo, error) {
 	return nil, fmt.Errorf("file storage is disabled")
+}
+
+func (s *noOpFileService) GeneratePresignedURL(ctx context.Context, url string, ttl time.Duration) (string, error) {
+	return "", fmt.Errorf("file storage is disabled")
+}
+
+func (s *noOpFileService) DownloadFile(ctx context.Context, url string) (io.ReadCloser, *FileInfo, error) {
+	return nil, nil, fmt.Errorf("file storage is disabled")
 }
\ No newline at end of file
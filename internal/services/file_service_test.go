@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalFileService_GetFileInfo(t *testing.T) {
+	root := t.TempDir()
+	uploadsPath := filepath.Join(root, "uploads")
+	require.NoError(t, os.MkdirAll(filepath.Join(uploadsPath, "user123"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(uploadsPath, "user123", "a.pdf"), []byte("%PDF-1.4"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "secret.txt"), []byte("secret"), 0644))
+	service := NewLocalFileService(&config.FileStorageConfig{LocalPath: uploadsPath}, logger.NewLogger("debug"))
+
+	// The owner is the first segment of the path
+	info, err := service.GetFileInfo(context.Background(), "/uploads/user123/a.pdf")
+	require.NoError(t, err)
+	assert.True(t, info.Exists)
+	assert.Equal(t, "user123", info.UserID)
+	assert.Equal(t, int64(8), info.Size)
+
+	// A path outside the upload directory doesn't exist as far as the service is concerned
+	info, err = service.GetFileInfo(context.Background(), "/uploads/../secret.txt")
+	require.NoError(t, err)
+	assert.False(t, info.Exists)
+
+	_, _, err = service.DownloadFile(context.Background(), "/uploads/../secret.txt")
+	assert.Error(t, err)
+}
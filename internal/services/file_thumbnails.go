@@ -0,0 +1,238 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // Registers the GIF decoder for image.Decode
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"golang.org/x/image/draw"
+)
+
+const (
+	// maxThumbnailSourcePixels evita decodificar imágenes enormes (o bombas de descompresión) solo para la
+	// miniatura; se comprueba con la cabecera antes de decodificar nada
+	maxThumbnailSourcePixels = 16_000_000
+	// thumbnailJPEGQuality es la calidad de las miniaturas de fotos JPEG
+	thumbnailJPEGQuality = 80
+)
+
+// thumbnailJob es una imagen subida pendiente de miniatura
+type thumbnailJob struct {
+	data     []byte
+	format   string
+	url      string // URL del original
+	filename string
+	userID   string
+}
+
+// ThumbnailFileService genera, al subir una imagen PNG, JPEG o GIF, una miniatura cuyo lado mayor mide como
+// mucho ThumbnailSize píxeles y la guarda con el mismo proveedor que el original. Las imágenes que ya caben
+// usan el original como miniatura y lo devuelven en la respuesta. El resto se reducen en segundo plano, con un
+// número fijo de workers, y la miniatura se guarda con SaveThumbnail para los adjuntos del original, ya creados
+// o por crear. Si la cola está llena o la miniatura falla, el archivo se queda sin ella
+type ThumbnailFileService struct {
+	FileService
+	thumbnails domain.AttachmentRepository
+	maxSize    int
+	queue      chan thumbnailJob
+	workers    int
+	logger     logger.Logger
+}
+
+func NewThumbnailFileService(fileService FileService, thumbnails domain.AttachmentRepository, config *config.FileStorageConfig, logger logger.Logger) *ThumbnailFileService {
+	workers := config.ThumbnailWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	return &ThumbnailFileService{
+		FileService: fileService,
+		thumbnails:  thumbnails,
+		maxSize:     config.ThumbnailSize,
+		queue:       make(chan thumbnailJob, config.ThumbnailQueue),
+		workers:     workers,
+		logger:      logger,
+	}
+}
+
+// Start ejecuta los workers que generan las miniaturas hasta que se cancele el contexto; las pendientes
+// en ese momento se descartan
+func (s *ThumbnailFileService) Start(ctx context.Context) {
+	s.logger.Info("Thumbnail workers started", map[string]interface{}{
+		"workers":    s.workers,
+		"queue_size": cap(s.queue),
+	})
+
+	// A thumbnail being generated when shutdown starts is finished rather than aborted
+	jobCtx := context.WithoutCancel(ctx)
+
+	done := make(chan struct{})
+	for i := 0; i < s.workers; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-s.queue:
+					s.generate(jobCtx, job)
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < s.workers; i++ {
+		<-done
+	}
+	s.logger.Info("Thumbnail workers stopped", map[string]interface{}{
+		"dropped": len(s.queue),
+	})
+}
+
+func (s *ThumbnailFileService) UploadFile(ctx context.Context, req UploadFileRequest) (*UploadFileResponse, error) {
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(req.File, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	head = head[:n]
+	req.File = io.MultiReader(bytes.NewReader(head), req.File)
+
+	if !strings.HasPrefix(detectContentType(head), "image/") {
+		return s.FileService.UploadFile(ctx, req)
+	}
+
+	// The provider enforces the size limit, so the copy kept for the thumbnail is bounded too
+	var original bytes.Buffer
+	req.File = io.TeeReader(req.File, &original)
+
+	response, err := s.FileService.UploadFile(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(original.Bytes()))
+	switch {
+	case err != nil:
+		// WebP, BMP, ICO... are valid images the standard library can't decode
+		s.logger.Debug("Skipping thumbnail for unsupported image format", map[string]interface{}{
+			"filename": req.Filename,
+		})
+	case config.Width*config.Height > maxThumbnailSourcePixels:
+		s.logger.Warn("Skipping thumbnail for oversized image", map[string]interface{}{
+			"filename": req.Filename,
+			"user_id":  req.UserID,
+			"width":    config.Width,
+			"height":   config.Height,
+		})
+	case config.Width <= s.maxSize && config.Height <= s.maxSize:
+		response.ThumbnailURL = response.URL
+	default:
+		s.enqueue(thumbnailJob{
+			data:     original.Bytes(),
+			format:   format,
+			url:      response.URL,
+			filename: req.Filename,
+			userID:   req.UserID,
+		})
+	}
+
+	return response, nil
+}
+
+func (s *ThumbnailFileService) enqueue(job thumbnailJob) {
+	select {
+	case s.queue <- job:
+	default:
+		s.logger.Warn("Thumbnail queue full, image left without thumbnail", map[string]interface{}{
+			"filename": job.filename,
+			"user_id":  job.userID,
+		})
+	}
+}
+
+// generate sube la miniatura del trabajo y la guarda para los adjuntos del original
+func (s *ThumbnailFileService) generate(ctx context.Context, job thumbnailJob) {
+	thumbnailURL, err := s.uploadThumbnail(ctx, job)
+	if err == nil {
+		if err = s.thumbnails.SaveThumbnail(ctx, job.userID, job.url, thumbnailURL); err != nil {
+			// Nothing references the file, so it is removed instead of leaking
+			if deleteErr := s.FileService.DeleteFile(ctx, thumbnailURL); deleteErr != nil {
+				s.logger.Warn("Failed to delete unsaved thumbnail", map[string]interface{}{
+					"url":   thumbnailURL,
+					"error": deleteErr.Error(),
+				})
+			}
+		}
+	}
+	if err != nil {
+		s.logger.Warn("Failed to generate thumbnail", map[string]interface{}{
+			"filename": job.filename,
+			"user_id":  job.userID,
+			"error":    err.Error(),
+		})
+	}
+}
+
+// uploadThumbnail reduce la imagen y sube la miniatura; devuelve su URL
+func (s *ThumbnailFileService) uploadThumbnail(ctx context.Context, job thumbnailJob) (string, error) {
+	img, _, err := image.Decode(bytes.NewReader(job.data))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	thumbnail := resizeImage(img, s.maxSize)
+
+	var encoded bytes.Buffer
+	ext := ".png"
+	if job.format == "jpeg" {
+		ext = ".jpg"
+		err = jpeg.Encode(&encoded, thumbnail, &jpeg.Options{Quality: thumbnailJPEGQuality})
+	} else {
+		err = png.Encode(&encoded, thumbnail)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+
+	name := strings.TrimSuffix(job.filename, filepath.Ext(job.filename)) + "_thumb" + ext
+	response, err := s.FileService.UploadFile(ctx, UploadFileRequest{
+		File:     &encoded,
+		Filename: name,
+		Size:     int64(encoded.Len()),
+		UserID:   job.userID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload thumbnail: %w", err)
+	}
+
+	return response.URL, nil
+}
+
+// resizeImage reduce la imagen con interpolación bilineal para que su lado mayor mida maxSize
+func resizeImage(src image.Image, maxSize int) *image.RGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	dstWidth, dstHeight := maxSize, maxSize
+	if width > height {
+		dstHeight = max(1, height*maxSize/width)
+	} else {
+		dstWidth = max(1, width*maxSize/height)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	draw.BiLinear.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	return dst
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newThumbnailTestFileService(t *testing.T, thumbnails *MockAttachmentRepository) (*ThumbnailFileService, string) {
+	uploadsPath := t.TempDir()
+	cfg := &config.FileStorageConfig{
+		LocalPath:        uploadsPath,
+		MaxFileSize:      10 * 1024 * 1024,
+		ThumbnailSize:    256,
+		ThumbnailWorkers: 1,
+		ThumbnailQueue:   4,
+	}
+	local := NewLocalFileService(cfg, logger.NewLogger("debug"))
+	return NewThumbnailFileService(local, thumbnails, cfg, logger.NewLogger("debug")), uploadsPath
+}
+
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: uint8(x ^ y), A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestThumbnailFileService_UploadImage(t *testing.T) {
+	// Setup
+	thumbnails := new(MockAttachmentRepository)
+	service, uploadsPath := newThumbnailTestFileService(t, thumbnails)
+	original := encodeTestPNG(t, 1024, 512)
+
+	saved := make(chan string, 1)
+	thumbnails.On("SaveThumbnail", mock.Anything, "user123", mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { saved <- args.String(3) }).
+		Return(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go service.Start(ctx)
+
+	// Execute
+
+	response, err := service.UploadFile(context.Background(), UploadFileRequest{
+		File:     bytes.NewReader(original),
+		Filename: "foto.png",
+		Size:     int64(len(original)),
+		UserID:   "user123",
+	})
+	require.NoError(t, err)
+
+	// Assert
+	// The original is stored untouched
+	stored, err := os.ReadFile(filepath.Join(uploadsPath, strings.TrimPrefix(response.URL, "/uploads/")))
+	require.NoError(t, err)
+	assert.Equal(t, original, stored)
+
+	// The thumbnail is generated in the background, so the response doesn't carry it yet
+	assert.Empty(t, response.ThumbnailURL)
+
+	// And next to the original a smaller thumbnail that keeps the aspect ratio, saved for its attachments
+	var thumbnailURL string
+	select {
+	case thumbnailURL = <-saved:
+	case <-time.After(5 * time.Second):
+		t.Fatal("thumbnail was not saved")
+	}
+	thumbnails.AssertCalled(t, "SaveThumbnail", mock.Anything, "user123", response.URL, thumbnailURL)
+	assert.NotEqual(t, response.URL, thumbnailURL)
+	assert.True(t, strings.HasPrefix(thumbnailURL, "/uploads/user123/"))
+	thumbnail, err := os.ReadFile(filepath.Join(uploadsPath, strings.TrimPrefix(thumbnailURL, "/uploads/")))
+	require.NoError(t, err)
+	assert.Less(t, len(thumbnail), len(original))
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(thumbnail))
+	require.NoError(t, err)
+	assert.Equal(t, "png", format)
+	assert.Equal(t, 256, config.Width)
+	assert.Equal(t, 128, config.Height)
+}
+
+func TestThumbnailFileService_SkipsSmallImagesAndOtherFiles(t *testing.T) {
+	thumbnails := new(MockAttachmentRepository)
+	service, uploadsPath := newThumbnailTestFileService(t, thumbnails)
+
+	// An image that already fits is its own thumbnail
+	small := encodeTestPNG(t, 100, 80)
+	response, err := service.UploadFile(context.Background(), UploadFileRequest{
+		File:     bytes.NewReader(small),
+		Filename: "icono.png",
+		Size:     int64(len(small)),
+		UserID:   "user123",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, response.URL, response.ThumbnailURL)
+
+	// Anything else gets none
+	response, err = service.UploadFile(context.Background(), UploadFileRequest{
+		File:     strings.NewReader("just some notes"),
+		Filename: "notas.txt",
+		Size:     15,
+		UserID:   "user123",
+	})
+	require.NoError(t, err)
+	assert.Empty(t, response.ThumbnailURL)
+
+	files, err := os.ReadDir(filepath.Join(uploadsPath, "user123"))
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+	assert.Empty(t, service.queue)
+	thumbnails.AssertNotCalled(t, "SaveThumbnail", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestThumbnailFileService_SkipsOversizedImagesBeforeDecoding(t *testing.T) {
+	// Setup
+	thumbnails := new(MockAttachmentRepository)
+	service, _ := newThumbnailTestFileService(t, thumbnails)
+
+	// Only the header is read, so a PNG claiming 5000x5000 pixels with no pixel data is enough
+	var header bytes.Buffer
+	header.Write([]byte("\x89PNG\r\n\x1a\n"))
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], 5000)
+	binary.BigEndian.PutUint32(ihdr[4:], 5000)
+	ihdr[8], ihdr[9] = 8, 6 // 8-bit RGBA
+	binary.Write(&header, binary.BigEndian, uint32(len(ihdr)))
+	chunk := append([]byte("IHDR"), ihdr...)
+	header.Write(chunk)
+	binary.Write(&header, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+
+	// Execute
+	response, err := service.UploadFile(context.Background(), UploadFileRequest{
+		File:     bytes.NewReader(header.Bytes()),
+		Filename: "panorama.png",
+		Size:     int64(header.Len()),
+		UserID:   "user123",
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.NotEmpty(t, response.URL)
+	assert.Empty(t, response.ThumbnailURL)
+	assert.Empty(t, service.queue)
+}
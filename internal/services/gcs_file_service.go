@@ -0,0 +1,331 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/google/uuid"
+	"google.golang.org/api/option"
+)
+
+// ErrGCSObjectNotFound indica que el objeto no existe en el bucket
+var ErrGCSObjectNotFound = errors.New("gcs object not found")
+
+// GCSObjectInfo son los atributos de un objeto devueltos por Object.Attrs
+type GCSObjectInfo struct {
+	Size        int64
+	ContentType string
+}
+
+// GCSClient es el subconjunto de operaciones de Cloud Storage que usa gcsFileService
+type GCSClient interface {
+	// Upload sube el cuerpo a medida que lo lee; si la lectura falla el objeto no se crea
+	Upload(ctx context.Context, bucket string, object string, body io.Reader, contentType string) error
+	// Attrs devuelve ErrGCSObjectNotFound si el objeto no existe
+	Attrs(ctx context.Context, bucket string, object string) (*GCSObjectInfo, error)
+	// Download abre el contenido del objeto; devuelve ErrGCSObjectNotFound si no existe
+	Download(ctx context.Context, bucket string, object string) (io.ReadCloser, *GCSObjectInfo, error)
+	Delete(ctx context.Context, bucket string, object string) error
+	// ObjectURL devuelve la URL pública https del objeto
+	ObjectURL(bucket string, object string) string
+	// SignedURL devuelve una URL V4 que permite descargar el objeto sin credenciales durante ttl
+	SignedURL(bucket string, object string, ttl time.Duration) (string, error)
+}
+
+type gcsFileService struct {
+	client GCSClient
+	config *config.FileStorageConfig
+	logger logger.Logger
+}
+
+// NewGCSFileService guarda los archivos en config.BucketName bajo el objeto {userID}/{nombre único}
+func NewGCSFileService(client GCSClient, config *config.FileStorageConfig, logger logger.Logger) FileService {
+	return &gcsFileService{
+		client: client,
+		config: config,
+		logger: logger,
+	}
+}
+
+func (s *gcsFileService) UploadFile(ctx context.Context, req UploadFileRequest) (*UploadFileResponse, error) {
+	// Validate file size
+	if req.Size > s.config.MaxFileSize {
+		return nil, fmt.Errorf("%w of %d bytes", ErrFileTooLarge, s.config.MaxFileSize)
+	}
+
+	ext := filepath.Ext(req.Filename)
+	uniqueFilename := fmt.Sprintf("%s_%s%s", uuid.New().String(), time.Now().Format("20060102_150405"), ext)
+	object := req.UserID + "/" + uniqueFilename
+	contentType := mime.TypeByExtension(strings.ToLower(ext))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// The declared size can't be trusted, so the upload is aborted as soon as the body exceeds the limit
+	body := &sizeLimitedReader{reader: req.File, limit: s.config.MaxFileSize}
+	if err := s.client.Upload(ctx, s.config.BucketName, object, body, contentType); err != nil {
+		if errors.Is(err, ErrFileTooLarge) {
+			return nil, fmt.Errorf("%w of %d bytes", ErrFileTooLarge, s.config.MaxFileSize)
+		}
+		s.logger.Error("Failed to upload file to GCS", err)
+		return nil, fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	fileType := determineFileType(req.Filename)
+
+	s.logger.Info("File uploaded successfully", map[string]interface{}{
+		"filename": req.Filename,
+		"size":     body.read,
+		"type":     fileType,
+		"user_id":  req.UserID,
+		"bucket":   s.config.BucketName,
+		"object":   object,
+	})
+
+	return &UploadFileResponse{
+		URL:      s.client.ObjectURL(s.config.BucketName, object),
+		Filename: req.Filename,
+		Size:     body.read,
+		Type:     fileType,
+	}, nil
+}
+
+func (s *gcsFileService) DeleteFile(ctx context.Context, url string) error {
+	err := s.client.Delete(ctx, s.config.BucketName, s.objectName(url))
+	if errors.Is(err, ErrGCSObjectNotFound) {
+		return fmt.Errorf("file %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		s.logger.Error("Failed to delete file from GCS", err)
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	s.logger.Info("File deleted successfully", map[string]interface{}{
+		"url": url,
+	})
+
+	return nil
+}
+
+func (s *gcsFileService) GetFileInfo(ctx context.Context, url string) (*FileInfo, error) {
+	object := s.objectName(url)
+
+	info, err := s.client.Attrs(ctx, s.config.BucketName, object)
+	if errors.Is(err, ErrGCSObjectNotFound) {
+		return &FileInfo{
+			URL:    url,
+			Exists: false,
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	filename := object[strings.LastIndex(object, "/")+1:]
+
+	return &FileInfo{
+		URL:      url,
+		Filename: filename,
+		Size:     info.Size,
+		Type:     determineFileType(filename),
+		UserID:   fileOwner(object),
+		Exists:   true,
+	}, nil
+}
+
+// DownloadFile devuelve el cuerpo de la respuesta de Cloud Storage tal cual, sin cargar el objeto en memoria
+func (s *gcsFileService) DownloadFile(ctx context.Context, url string) (io.ReadCloser, *FileInfo, error) {
+	object := s.objectName(url)
+
+	body, info, err := s.client.Download(ctx, s.config.BucketName, object)
+	if errors.Is(err, ErrGCSObjectNotFound) {
+		return nil, nil, fmt.Errorf("file %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download file: %w", err)
+	}
+
+	filename := object[strings.LastIndex(object, "/")+1:]
+
+	return body, &FileInfo{
+		URL:         url,
+		Filename:    filename,
+		Size:        info.Size,
+		Type:        determineFileType(filename),
+		ContentType: info.ContentType,
+		UserID:      fileOwner(object),
+		Exists:      true,
+	}, nil
+}
+
+// maxGCSSignedURLTTL es la validez máxima que Cloud Storage admite en una URL firmada V4
+const maxGCSSignedURLTTL = 7 * 24 * time.Hour
+
+func (s *gcsFileService) GeneratePresignedURL(ctx context.Context, url string, ttl time.Duration) (string, error) {
+	if ttl <= 0 || ttl > maxGCSSignedURLTTL {
+		return "", fmt.Errorf("signed url ttl must be between 1s and %s", maxGCSSignedURLTTL)
+	}
+
+	return s.client.SignedURL(s.config.BucketName, s.objectName(url), ttl)
+}
+
+// objectName admite tanto la URL devuelta por UploadFile como el nombre del objeto
+func (s *gcsFileService) objectName(fileURL string) string {
+	prefix := s.client.ObjectURL(s.config.BucketName, "")
+	if !strings.HasPrefix(fileURL, prefix) {
+		return strings.TrimPrefix(fileURL, "/")
+	}
+
+	object := strings.TrimPrefix(fileURL, prefix)
+	if unescaped, err := url.PathUnescape(object); err == nil {
+		object = unescaped
+	}
+	return object
+}
+
+// gcsDefaultEndpoint sirve las descargas públicas de los objetos
+const gcsDefaultEndpoint = "https://storage.googleapis.com"
+
+// GCSConfig son los datos de conexión del cliente de Cloud Storage
+type GCSConfig struct {
+	Endpoint string // Vacío usa Google con Application Default Credentials; con valor (un emulador) no se autentica
+}
+
+// storageGCSClient implementa GCSClient con cloud.google.com/go/storage. Las credenciales son las Application
+// Default Credentials: GOOGLE_APPLICATION_CREDENTIALS, las de gcloud o la cuenta de servicio del entorno
+type storageGCSClient struct {
+	client   *storage.Client
+	endpoint string
+	timeout  time.Duration
+}
+
+// NewStorageGCSClient crea el cliente de Cloud Storage; timeout acota cada operación, incluida la lectura
+// completa de una descarga
+func NewStorageGCSClient(ctx context.Context, config GCSConfig, timeout time.Duration) (GCSClient, error) {
+	var opts []option.ClientOption
+	endpoint := gcsDefaultEndpoint
+	if config.Endpoint != "" {
+		endpoint = strings.TrimSuffix(config.Endpoint, "/")
+		opts = append(opts, option.WithEndpoint(endpoint+"/storage/v1/"), option.WithoutAuthentication())
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcs client: %w", err)
+	}
+
+	return &storageGCSClient{
+		client:   client,
+		endpoint: endpoint,
+		timeout:  timeout,
+	}, nil
+}
+
+func (c *storageGCSClient) Upload(ctx context.Context, bucket string, object string, body io.Reader, contentType string) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	// Cancelling before Close discards the partial upload
+	defer cancel()
+
+	writer := c.client.Bucket(bucket).Object(object).NewWriter(ctx)
+	writer.ContentType = contentType
+	if _, err := io.Copy(writer, body); err != nil {
+		cancel()
+		writer.Close()
+		return fmt.Errorf("failed to upload gcs object: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to upload gcs object: %w", err)
+	}
+
+	return nil
+}
+
+func (c *storageGCSClient) Attrs(ctx context.Context, bucket string, object string) (*GCSObjectInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	attrs, err := c.client.Bucket(bucket).Object(object).Attrs(ctx)
+	if err != nil {
+		return nil, gcsError("get attributes of", err)
+	}
+
+	return &GCSObjectInfo{
+		Size:        attrs.Size,
+		ContentType: attrs.ContentType,
+	}, nil
+}
+
+func (c *storageGCSClient) Download(ctx context.Context, bucket string, object string) (io.ReadCloser, *GCSObjectInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+
+	reader, err := c.client.Bucket(bucket).Object(object).NewReader(ctx)
+	if err != nil {
+		cancel()
+		return nil, nil, gcsError("download", err)
+	}
+
+	return &cancelOnClose{ReadCloser: reader, cancel: cancel}, &GCSObjectInfo{
+		Size:        reader.Attrs.Size,
+		ContentType: reader.Attrs.ContentType,
+	}, nil
+}
+
+func (c *storageGCSClient) Delete(ctx context.Context, bucket string, object string) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	if err := c.client.Bucket(bucket).Object(object).Delete(ctx); err != nil {
+		return gcsError("delete", err)
+	}
+	return nil
+}
+
+func (c *storageGCSClient) ObjectURL(bucket string, object string) string {
+	return c.endpoint + "/" + bucket + "/" + (&url.URL{Path: object}).EscapedPath()
+}
+
+// SignedURL firma con la clave de las credenciales o, si no la tienen (p. ej. en GKE o Cloud Run), con la
+// API signBlob de IAM, que requiere el permiso iam.serviceAccounts.signBlob
+func (c *storageGCSClient) SignedURL(bucket string, object string, ttl time.Duration) (string, error) {
+	signed, err := c.client.Bucket(bucket).SignedURL(object, &storage.SignedURLOptions{
+		Method:  http.MethodGet,
+		Expires: time.Now().Add(ttl),
+		Scheme:  storage.SigningSchemeV4,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to sign gcs url: %w", err)
+	}
+	return signed, nil
+}
+
+// gcsError traduce storage.ErrObjectNotExist a ErrGCSObjectNotFound
+func gcsError(operation string, err error) error {
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return ErrGCSObjectNotFound
+	}
+	return fmt.Errorf("failed to %s gcs object: %w", operation, err)
+}
+
+// cancelOnClose libera el contexto de la descarga al cerrar el cuerpo
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (r *cancelOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.cancel()
+	return err
+}
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockGCSClient es un mock de GCSClient; ObjectURL sigue el formato de Google. Upload lee el cuerpo antes de
+// registrar la llamada
+type MockGCSClient struct {
+	mock.Mock
+}
+
+func (m *MockGCSClient) Upload(ctx context.Context, bucket string, object string, body io.Reader, contentType string) error {
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	args := m.Called(ctx, bucket, object, content, contentType)
+	return args.Error(0)
+}
+
+func (m *MockGCSClient) Attrs(ctx context.Context, bucket string, object string) (*GCSObjectInfo, error) {
+	args := m.Called(ctx, bucket, object)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*GCSObjectInfo), args.Error(1)
+}
+
+func (m *MockGCSClient) Download(ctx context.Context, bucket string, object string) (io.ReadCloser, *GCSObjectInfo, error) {
+	args := m.Called(ctx, bucket, object)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(io.ReadCloser), args.Get(1).(*GCSObjectInfo), args.Error(2)
+}
+
+func (m *MockGCSClient) Delete(ctx context.Context, bucket string, object string) error {
+	args := m.Called(ctx, bucket, object)
+	return args.Error(0)
+}
+
+func (m *MockGCSClient) ObjectURL(bucket string, object string) string {
+	return "https://storage.googleapis.com/" + bucket + "/" + (&url.URL{Path: object}).EscapedPath()
+}
+
+func (m *MockGCSClient) SignedURL(bucket string, object string, ttl time.Duration) (string, error) {
+	args := m.Called(bucket, object, ttl)
+	return args.String(0), args.Error(1)
+}
+
+func newGCSTestFileService(client GCSClient) FileService {
+	return NewGCSFileService(client, &config.FileStorageConfig{
+		Provider:    "gcs",
+		BucketName:  "attachments",
+		MaxFileSize: 1024,
+	}, logger.NewLogger("debug"))
+}
+
+func TestGCSFileService_UploadFile(t *testing.T) {
+	client := new(MockGCSClient)
+	service := newGCSTestFileService(client)
+
+	var object string
+	client.On("Upload", mock.Anything, "attachments", mock.MatchedBy(func(o string) bool {
+		object = o
+		return strings.HasPrefix(o, "user123/") && strings.HasSuffix(o, ".png")
+	}), []byte("png-bytes"), "image/png").Return(nil)
+
+	response, err := service.UploadFile(context.Background(), UploadFileRequest{
+		File:     strings.NewReader("png-bytes"),
+		Filename: "foto.png",
+		Size:     9,
+		UserID:   "user123",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "https://storage.googleapis.com/attachments/"+object, response.URL)
+	assert.Equal(t, int64(9), response.Size)
+	assert.Equal(t, domain.AttachmentTypeImage, response.Type)
+	client.AssertExpectations(t)
+}
+
+func TestGCSFileService_GetFileInfoAndDelete(t *testing.T) {
+	client := new(MockGCSClient)
+	service := newGCSTestFileService(client)
+
+	url := "https://storage.googleapis.com/attachments/user%40example/file_1.pdf"
+	client.On("Attrs", mock.Anything, "attachments", "user@example/file_1.pdf").Return(&GCSObjectInfo{Size: 42}, nil).Once()
+	client.On("Attrs", mock.Anything, "attachments", "user@example/file_1.pdf").Return(nil, ErrGCSObjectNotFound)
+	client.On("Delete", mock.Anything, "attachments", "user@example/file_1.pdf").Return(nil).Once()
+	client.On("Delete", mock.Anything, "attachments", "user@example/file_1.pdf").Return(ErrGCSObjectNotFound)
+
+	info, err := service.GetFileInfo(context.Background(), url)
+	require.NoError(t, err)
+	assert.True(t, info.Exists)
+	assert.Equal(t, "file_1.pdf", info.Filename)
+	assert.Equal(t, int64(42), info.Size)
+
+	require.NoError(t, service.DeleteFile(context.Background(), url))
+
+	info, err = service.GetFileInfo(context.Background(), url)
+	require.NoError(t, err)
+	assert.False(t, info.Exists)
+
+	// Deleting it again reports the missing object
+	assert.ErrorIs(t, service.DeleteFile(context.Background(), url), domain.ErrNotFound)
+
+	_, err = service.GeneratePresignedURL(context.Background(), url, 8*24*time.Hour)
+	assert.Error(t, err)
+}
+
+// fakeGCSServer imita en memoria la API JSON de Cloud Storage y las descargas XML, como un emulador
+type fakeGCSServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeGCSServer(t *testing.T) *fakeGCSServer {
+	fake := &fakeGCSServer{objects: make(map[string][]byte)}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(fake.Close)
+	return fake
+}
+
+func (f *fakeGCSServer) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/attachments/o":
+		// Multipart upload: the object resource followed by its content
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		parts := multipart.NewReader(r.Body, params["boundary"])
+		var resource map[string]interface{}
+		metadata, err := parts.NextPart()
+		if err != nil || json.NewDecoder(metadata).Decode(&resource) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		content, err := parts.NextPart()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(content)
+		name, _ := resource["name"].(string)
+		f.objects[name] = body
+		json.NewEncoder(w).Encode(f.resource(name))
+	case strings.HasPrefix(r.URL.Path, "/storage/v1/b/attachments/o/"):
+		name := strings.TrimPrefix(r.URL.Path, "/storage/v1/b/attachments/o/")
+		if _, ok := f.objects[name]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{"code": 404, "message": "Not Found"}})
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(f.objects, name)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		json.NewEncoder(w).Encode(f.resource(name))
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/attachments/"):
+		body, ok := f.objects[strings.TrimPrefix(r.URL.Path, "/attachments/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write(body)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (f *fakeGCSServer) resource(name string) map[string]string {
+	return map[string]string{"bucket": "attachments", "name": name, "size": strconv.Itoa(len(f.objects[name])), "contentType": "text/plain"}
+}
+
+func TestStorageGCSClient_AgainstFakeServer(t *testing.T) {
+	fake := newFakeGCSServer(t)
+	ctx := context.Background()
+	client, err := NewStorageGCSClient(ctx, GCSConfig{Endpoint: fake.URL}, 5*time.Second)
+	require.NoError(t, err)
+
+	require.NoError(t, client.Upload(ctx, "attachments", "user 1/a.txt", strings.NewReader("hola"), "text/plain"))
+	assert.Equal(t, []byte("hola"), fake.objects["user 1/a.txt"])
+	assert.Equal(t, fake.URL+"/attachments/user%201/a.txt", client.ObjectURL("attachments", "user 1/a.txt"))
+
+	info, err := client.Attrs(ctx, "attachments", "user 1/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), info.Size)
+	assert.Equal(t, "text/plain", info.ContentType)
+
+	body, info, err := client.Download(ctx, "attachments", "user 1/a.txt")
+	require.NoError(t, err)
+	content, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.NoError(t, body.Close())
+	assert.Equal(t, "hola", string(content))
+	assert.Equal(t, int64(4), info.Size)
+
+	require.NoError(t, client.Delete(ctx, "attachments", "user 1/a.txt"))
+	_, err = client.Attrs(ctx, "attachments", "user 1/a.txt")
+	assert.ErrorIs(t, err, ErrGCSObjectNotFound)
+	assert.ErrorIs(t, client.Delete(ctx, "attachments", "user 1/a.txt"), ErrGCSObjectNotFound)
+
+	// A body that fails mid-upload leaves no object behind
+	err = client.Upload(ctx, "attachments", "big.bin", &sizeLimitedReader{reader: strings.NewReader("too large"), limit: 3}, "application/octet-stream")
+	assert.ErrorIs(t, err, ErrFileTooLarge)
+	assert.NotContains(t, fake.objects, "big.bin")
+}
+
+func TestStorageGCSClient_SignedURL(t *testing.T) {
+	// Application Default Credentials from a service account key file
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	credentials, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "project",
+		"private_key_id": "key1",
+		"client_email":   "uploader@project.iam.gserviceaccount.com",
+		"client_id":      "1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":      "https://oauth2.googleapis.com/token",
+	})
+	require.NoError(t, err)
+	credentialsFile := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(credentialsFile, credentials, 0o600))
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", credentialsFile)
+
+	client, err := NewStorageGCSClient(context.Background(), GCSConfig{}, 5*time.Second)
+	require.NoError(t, err)
+
+	signed, err := client.SignedURL("attachments", "user1/a b.txt", 15*time.Minute)
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "storage.googleapis.com", parsed.Host)
+	assert.Equal(t, "/attachments/user1/a%20b.txt", parsed.EscapedPath())
+	query := parsed.Query()
+	date := query.Get("X-Goog-Date")
+	scope := date[:8] + "/auto/storage/goog4_request"
+	assert.Equal(t, "GOOG4-RSA-SHA256", query.Get("X-Goog-Algorithm"))
+	assert.Equal(t, "uploader@project.iam.gserviceaccount.com/"+scope, query.Get("X-Goog-Credential"))
+	expires, err := strconv.Atoi(query.Get("X-Goog-Expires"))
+	require.NoError(t, err)
+	assert.InDelta(t, 900, expires, 5)
+
+	// The signature covers the canonical request as Cloud Storage rebuilds it
+	signature, err := hex.DecodeString(query.Get("X-Goog-Signature"))
+	require.NoError(t, err)
+	query.Del("X-Goog-Signature")
+	canonicalRequest := strings.Join([]string{"GET", parsed.EscapedPath(), query.Encode(), "host:storage.googleapis.com\n", "host", "UNSIGNED-PAYLOAD"}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	digest := sha256.Sum256([]byte("GOOG4-RSA-SHA256\n" + date + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])))
+	assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+}
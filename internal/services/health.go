@@ -1,7 +1,13 @@
 package services
 
 import (
+	"context"
+	"fmt"
+	"sort"
 	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/redis/go-redis/v9"
 )
 
 type HealthService interface {
@@ -9,14 +15,69 @@ type HealthService interface {
 	CheckReadiness() map[string]interface{}
 }
 
+// DependencyCheck comprueba que una dependencia responde; un error la marca como caída
+type DependencyCheck func(ctx context.Context) error
+
+// DatabaseCheck comprueba la base de datos con HealthRepository.CheckDatabase
+func DatabaseCheck(repo domain.HealthRepository) DependencyCheck {
+	return repo.CheckDatabase
+}
+
+// RedisCheck comprueba Redis con un PING
+func RedisCheck(client redis.UniversalClient) DependencyCheck {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}
+}
+
+// HealthServiceOption configura opciones del HealthService
+type HealthServiceOption func(*healthService)
+
+// WithDependencyCheck registra la comprobación de una dependencia en el readiness. Sin WithRequiredDependencies
+// la dependencia es opcional: si cae, el servicio sigue listo pero se informa como degradado
+func WithDependencyCheck(name string, check DependencyCheck) HealthServiceOption {
+	return func(s *healthService) {
+		s.checks[name] = check
+	}
+}
+
+// WithRequiredDependencies marca las dependencias sin las que el servicio no puede atender tráfico. Una
+// dependencia requerida que no tiene comprobación registrada (p. ej. Redis deshabilitado) cuenta como caída
+func WithRequiredDependencies(names []string) HealthServiceOption {
+	return func(s *healthService) {
+		for _, name := range names {
+			s.required[name] = true
+		}
+	}
+}
+
+// WithCheckTimeout limita cuánto puede tardar cada comprobación de dependencia
+func WithCheckTimeout(timeout time.Duration) HealthServiceOption {
+	return func(s *healthService) {
+		if timeout > 0 {
+			s.checkTimeout = timeout
+		}
+	}
+}
+
 type healthService struct {
-	startTime time.Time
+	startTime    time.Time
+	checks       map[string]DependencyCheck
+	required     map[string]bool
+	checkTimeout time.Duration
 }
 
-func NewHealthService() HealthService {
-	return &healthService{
-		startTime: time.Now(),
+func NewHealthService(opts ...HealthServiceOption) HealthService {
+	s := &healthService{
+		startTime:    time.Now(),
+		checks:       make(map[string]DependencyCheck),
+		required:     make(map[string]bool),
+		checkTimeout: 2 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 func (s *healthService) CheckHealth() map[string]interface{} {
@@ -29,49 +90,65 @@ func (s *healthService) CheckHealth() map[string]interface{} {
 	}
 }
 
+// CheckReadiness comprueba cada dependencia registrada. El servicio deja de estar listo solo si cae una
+// requerida; si solo caen opcionales sigue listo con estado "degraded" y un aviso por cada una
 func (s *healthService) CheckReadiness() map[string]interface{} {
-	// Aquí puedes agregar checks adicionales como:
-	// - Conexión a base de datos
-	// - Conexión a servicios externos
-	// - Estado de dependencias críticas
-	
+	names := make([]string, 0, len(s.checks)+len(s.required))
+	for name := range s.checks {
+		names = append(names, name)
+	}
+	for name := range s.required {
+		if _, ok := s.checks[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
 	ready := true
-	checks := make(map[string]bool)
-	
-	// Ejemplo de checks (comentados para testing)
-	// checks["database"] = s.checkDatabase()
-	// checks["external_api"] = s.checkExternalAPI()
-	// checks["vault"] = s.checkVault()
-	
-	// Si algún check falla, el servicio no está ready
-	for _, check := range checks {
-		if !check {
-			ready = false
-			break
+	warnings := []string{}
+	checks := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		err := s.runCheck(name)
+		result := map[string]interface{}{
+			"status":   "up",
+			"required": s.required[name],
 		}
+		if err != nil {
+			result["status"] = "down"
+			result["error"] = err.Error()
+			if s.required[name] {
+				ready = false
+			} else {
+				warnings = append(warnings, fmt.Sprintf("optional dependency %s is down: %v", name, err))
+			}
+		}
+		checks[name] = result
+	}
+
+	status := "ready"
+	if !ready {
+		status = "not_ready"
+	} else if len(warnings) > 0 {
+		status = "degraded"
 	}
-	
+
 	return map[string]interface{}{
 		"ready":     ready,
+		"status":    status,
 		"timestamp": time.Now().UTC(),
 		"checks":    checks,
+		"warnings":  warnings,
 	}
 }
 
-// Ejemplos de checks comentados
-/*
-func (s *healthService) checkDatabase() bool {
-	// Implementar check de base de datos
-	return true
-}
+func (s *healthService) runCheck(name string) error {
+	check, ok := s.checks[name]
+	if !ok {
+		return fmt.Errorf("not configured")
+	}
 
-func (s *healthService) checkExternalAPI() bool {
-	// Implementar check de API externa
-	return true
-}
+	ctx, cancel := context.WithTimeout(context.Background(), s.checkTimeout)
+	defer cancel()
 
-func (s *healthService) checkVault() bool {
-	// Implementar check de Vault
-	return true
+	return check(ctx)
 }
-*/
\ No newline at end of file
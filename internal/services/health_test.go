@@ -1,6 +1,8 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -26,4 +28,45 @@ func TestHealthService_CheckReadiness(t *testing.T) {
 	assert.Equal(t, true, result["ready"])
 	assert.NotNil(t, result["timestamp"])
 	assert.NotNil(t, result["checks"])
-}
\ No newline at end of file
+}
+
+func TestHealthService_CheckReadiness_OptionalDependencyDown(t *testing.T) {
+	service := NewHealthService(
+		WithRequiredDependencies([]string{"database"}),
+		WithDependencyCheck("database", func(ctx context.Context) error { return nil }),
+		WithDependencyCheck("redis", func(ctx context.Context) error { return errors.New("connection refused") }),
+	)
+
+	result := service.CheckReadiness()
+
+	// Still ready, but degraded with a warning for redis
+	assert.Equal(t, true, result["ready"])
+	assert.Equal(t, "degraded", result["status"])
+	assert.Len(t, result["warnings"], 1)
+	checks := result["checks"].(map[string]interface{})
+	assert.Equal(t, "down", checks["redis"].(map[string]interface{})["status"])
+	assert.Equal(t, false, checks["redis"].(map[string]interface{})["required"])
+}
+
+func TestHealthService_CheckReadiness_RequiredDependencyDown(t *testing.T) {
+	service := NewHealthService(
+		WithRequiredDependencies([]string{"database", "redis"}),
+		WithDependencyCheck("database", func(ctx context.Context) error { return nil }),
+		WithDependencyCheck("redis", func(ctx context.Context) error { return errors.New("connection refused") }),
+	)
+
+	result := service.CheckReadiness()
+
+	assert.Equal(t, false, result["ready"])
+	assert.Equal(t, "not_ready", result["status"])
+}
+
+func TestHealthService_CheckReadiness_RequiredDependencyNotConfigured(t *testing.T) {
+	service := NewHealthService(WithRequiredDependencies([]string{"redis"}))
+
+	result := service.CheckReadiness()
+
+	assert.Equal(t, false, result["ready"])
+	checks := result["checks"].(map[string]interface{})
+	assert.Equal(t, "not configured", checks["redis"].(map[string]interface{})["error"])
+}
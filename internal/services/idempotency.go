@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrInvalidIdempotencyKey indica una clave de idempotencia que no se puede guardar
+	ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
+	// ErrIdempotencyKeyInProgress indica que otra petición con la misma clave todavía no ha terminado
+	ErrIdempotencyKeyInProgress = errors.New("idempotency key in progress")
+	// ErrIdempotencyKeyReused indica una clave que ya creó un mensaje en otra conversación
+	ErrIdempotencyKeyReused = errors.New("idempotency key reused")
+)
+
+const (
+	// maxIdempotencyKeyLength acota la clave que se guarda en Redis
+	maxIdempotencyKeyLength = 255
+	// idempotencyPendingTTL es lo que dura la reserva de una petición en curso, para que un proceso que
+	// cae no deje la clave bloqueada hasta que caduque
+	idempotencyPendingTTL = time.Minute
+)
+
+// IdempotencyStore guarda las claves de idempotencia de cada usuario con el mensaje que creó cada una
+type IdempotencyStore interface {
+	// Claim reserva la clave para la petición en curso. Si ya estaba reservada devuelve el ID del mensaje
+	// que creó, o "" si la otra petición no ha terminado
+	Claim(ctx context.Context, userID string, key string) (messageID string, claimed bool, err error)
+	// Complete guarda el mensaje creado con la clave durante ttl
+	Complete(ctx context.Context, userID string, key string, messageID string, ttl time.Duration) error
+	// Release libera la reserva de una petición que falló para que se pueda reintentar
+	Release(ctx context.Context, userID string, key string) error
+}
+
+type redisIdempotencyStore struct {
+	client *redis.Client
+	logger logger.Logger
+}
+
+func NewRedisIdempotencyStore(client *redis.Client, logger logger.Logger) IdempotencyStore {
+	return &redisIdempotencyStore{
+		client: client,
+		logger: logger,
+	}
+}
+
+func idempotencyKey(userID string, key string) string {
+	return fmt.Sprintf("idempotency:%s:%s", userID, key)
+}
+
+func (s *redisIdempotencyStore) Claim(ctx context.Context, userID string, key string) (string, bool, error) {
+	redisKey := idempotencyKey(userID, key)
+
+	// An empty value marks the key as taken by a request that has not finished yet
+	claimed, err := s.client.SetNX(ctx, redisKey, "", idempotencyPendingTTL).Result()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if claimed {
+		return "", true, nil
+	}
+
+	messageID, err := s.client.Get(ctx, redisKey).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return messageID, false, nil
+}
+
+func (s *redisIdempotencyStore) Complete(ctx context.Context, userID string, key string, messageID string, ttl time.Duration) error {
+	if err := s.client.Set(ctx, idempotencyKey(userID, key), messageID, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (s *redisIdempotencyStore) Release(ctx context.Context, userID string, key string) error {
+	if err := s.client.Del(ctx, idempotencyKey(userID, key)).Err(); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+type idempotencyKeys struct {
+	store IdempotencyStore
+	ttl   time.Duration
+}
+
+// WithIdempotencyKeys habilita la cabecera Idempotency-Key en el envío de mensajes: un reintento con la
+// misma clave durante ttl devuelve el mensaje original en lugar de crear otro
+func WithIdempotencyKeys(store IdempotencyStore, ttl time.Duration) MessagingServiceOption {
+	return func(s *messagingService) {
+		if store == nil {
+			return
+		}
+
+		s.idempotency = &idempotencyKeys{
+			store: store,
+			ttl:   ttl,
+		}
+	}
+}
+
+func validateIdempotencyKey(key string) error {
+	if len(key) > maxIdempotencyKeyLength {
+		return fmt.Errorf("%w: longer than %d characters", ErrInvalidIdempotencyKey, maxIdempotencyKeyLength)
+	}
+	return nil
+}
+
+// claimIdempotencyKey reserva la clave del envío. Devuelve el mensaje que ya creó la clave, si lo hay, y si
+// el envío tiene que completarla al terminar. Si Redis falla el envío sigue como si no trajera clave
+func (s *messagingService) claimIdempotencyKey(ctx context.Context, req SendMessageRequest) (*domain.Message, bool, error) {
+	if s.idempotency == nil || req.IdempotencyKey == "" {
+		return nil, false, nil
+	}
+
+	messageID, claimed, err := s.idempotency.store.Claim(ctx, req.SenderID, req.IdempotencyKey)
+	if err != nil {
+		s.log(ctx).Warn("Idempotency store unavailable, sending message without idempotency", map[string]interface{}{
+			"conversation_id": req.ConversationID,
+			"sender_id":       req.SenderID,
+			"error":           err.Error(),
+		})
+		return nil, false, nil
+	}
+	if claimed {
+		return nil, true, nil
+	}
+	if messageID == "" {
+		return nil, false, ErrIdempotencyKeyInProgress
+	}
+
+	existing, err := s.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get idempotent message: %w", err)
+	}
+	if existing.ConversationID != req.ConversationID {
+		return nil, false, fmt.Errorf("%w: already used in another conversation", ErrIdempotencyKeyReused)
+	}
+
+	messages := []domain.Message{*existing}
+	s.loadAttachments(ctx, messages)
+
+	s.log(ctx).Info("Idempotent message replayed", map[string]interface{}{
+		"message_id":      existing.ID,
+		"conversation_id": req.ConversationID,
+		"sender_id":       req.SenderID,
+	})
+
+	return &messages[0], false, nil
+}
+
+// finishIdempotencyKey guarda el mensaje enviado con la clave, o la libera si el envío falló
+func (s *messagingService) finishIdempotencyKey(ctx context.Context, req SendMessageRequest, message *domain.Message) {
+	// The outcome is stored even if the client went away, which is when it will retry
+	ctx = context.WithoutCancel(ctx)
+
+	var err error
+	if message != nil {
+		err = s.idempotency.store.Complete(ctx, req.SenderID, req.IdempotencyKey, message.ID, s.idempotency.ttl)
+	} else {
+		err = s.idempotency.store.Release(ctx, req.SenderID, req.IdempotencyKey)
+	}
+	if err != nil {
+		s.log(ctx).Warn("Failed to update idempotency key", map[string]interface{}{
+			"conversation_id": req.ConversationID,
+			"sender_id":       req.SenderID,
+			"error":           err.Error(),
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestIdempotencyStore devuelve un IdempotencyStore sobre un Redis en memoria
+func newTestIdempotencyStore(t *testing.T) (IdempotencyStore, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisIdempotencyStore(client, logger.NewLogger("debug")), server
+}
+
+func idempotentRequest(key string) SendMessageRequest {
+	return SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "user123",
+		Content:        "Hola",
+		ContentType:    domain.ContentTypeText,
+		IdempotencyKey: key,
+	}
+}
+
+func TestMessagingService_SendMessage_IdempotencyKeyReplays(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	store, server := newTestIdempotencyStore(t)
+	expectSendMessage(mockConversationRepo, mockMessageRepo)
+	mockAttachmentRepo.On("GetByMessageID", mock.Anything, mock.Anything).Return([]domain.Attachment{}, nil)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo, attachmentRepo: mockAttachmentRepo}, WithIdempotencyKeys(store, time.Hour))
+
+	// Execute: the client retries with the same key
+	first, err := service.SendMessage(context.Background(), idempotentRequest("retry-1"))
+	require.NoError(t, err)
+	mockMessageRepo.On("GetByID", mock.Anything, first.ID).Return(first, nil)
+	second, err := service.SendMessage(context.Background(), idempotentRequest("retry-1"))
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, first.ID, second.ID)
+	mockMessageRepo.AssertNumberOfCalls(t, "Create", 1)
+	stored, err := server.Get("idempotency:user123:retry-1")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, stored)
+	assert.Equal(t, time.Hour, server.TTL("idempotency:user123:retry-1"))
+}
+
+func TestMessagingService_SendMessage_DistinctIdempotencyKeys(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	store, _ := newTestIdempotencyStore(t)
+	expectSendMessage(mockConversationRepo, mockMessageRepo)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo}, WithIdempotencyKeys(store, time.Hour))
+
+	// Execute
+	first, err := service.SendMessage(context.Background(), idempotentRequest("key-1"))
+	require.NoError(t, err)
+	second, err := service.SendMessage(context.Background(), idempotentRequest("key-2"))
+	require.NoError(t, err)
+
+	// Assert
+	assert.NotEqual(t, first.ID, second.ID)
+	mockMessageRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestMessagingService_SendMessage_IdempotencyKeyInProgress(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	store, server := newTestIdempotencyStore(t)
+	expectSendMessage(mockConversationRepo, mockMessageRepo)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo}, WithIdempotencyKeys(store, time.Hour))
+	// Another request holds the key and has not stored its message yet
+	server.Set("idempotency:user123:retry-1", "")
+
+	// Execute
+	_, err := service.SendMessage(context.Background(), idempotentRequest("retry-1"))
+
+	// Assert
+	assert.ErrorIs(t, err, ErrIdempotencyKeyInProgress)
+	mockMessageRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_SendMessage_IdempotencyWithoutRedis(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	store, server := newTestIdempotencyStore(t)
+	expectSendMessage(mockConversationRepo, mockMessageRepo)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo}, WithIdempotencyKeys(store, time.Hour))
+	server.Close()
+
+	// Execute: without Redis the key cannot be checked, so both sends go through
+	first, err := service.SendMessage(context.Background(), idempotentRequest("retry-1"))
+	require.NoError(t, err)
+	second, err := service.SendMessage(context.Background(), idempotentRequest("retry-1"))
+	require.NoError(t, err)
+
+	// Assert
+	assert.NotEqual(t, first.ID, second.ID)
+	mockMessageRepo.AssertNumberOfCalls(t, "Create", 2)
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// ErrDuplicateMessage indica que la conversación ya tiene un mensaje con el mismo provider_message_id
+var ErrDuplicateMessage = errors.New("duplicate message")
+
+// WithInboundDeduplication hace que un mensaje entrante cuyo provider_message_id ya está guardado en la
+// conversación devuelva el mensaje existente, de modo que un webhook reintentado por el proveedor no tiene
+// efecto. Sin ella el duplicado se rechaza con ErrDuplicateMessage
+func WithInboundDeduplication(enabled bool) MessagingServiceOption {
+	return func(s *messagingService) {
+		s.inboundDedup = enabled
+	}
+}
+
+// inboundProviderMessageID devuelve el identificador que el proveedor asignó al mensaje entrante, o "" si
+// los metadatos no lo traen
+func inboundProviderMessageID(metadata map[string]interface{}) string {
+	providerMessageID, _ := metadata[domain.MetadataProviderMessageID].(string)
+	return providerMessageID
+}
+
+// findInboundDuplicate devuelve el mensaje ya guardado con providerMessageID, o nil si no hay ninguno
+func (s *messagingService) findInboundDuplicate(ctx context.Context, conversationID string, providerMessageID string) (*domain.Message, error) {
+	existing, err := s.messageRepo.GetByConversationProviderMessageID(ctx, conversationID, providerMessageID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check duplicate message: %w", err)
+	}
+
+	messages := []domain.Message{*existing}
+	s.loadAttachments(ctx, messages)
+
+	s.log(ctx).Info("Duplicate inbound message ignored", map[string]interface{}{
+		"message_id":          existing.ID,
+		"conversation_id":     conversationID,
+		"provider_message_id": providerMessageID,
+	})
+
+	return &messages[0], nil
+}
+
+// duplicateInboundMessage resuelve el choque con el índice único al guardar: un reintento concurrente del
+// mismo webhook se adelantó y su mensaje es el que se devuelve
+func (s *messagingService) duplicateInboundMessage(ctx context.Context, conversationID string, providerMessageID string) (*domain.Message, error) {
+	if s.inboundDedup {
+		existing, err := s.findInboundDuplicate(ctx, conversationID, providerMessageID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return existing, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: provider message %q already stored", ErrDuplicateMessage, providerMessageID)
+}
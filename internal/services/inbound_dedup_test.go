@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func inboundRequest() SendMessageRequest {
+	return SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "user123",
+		Content:        "Hola",
+		ContentType:    domain.ContentTypeText,
+		Metadata:       map[string]interface{}{domain.MetadataProviderMessageID: "wamid.1"},
+	}
+}
+
+func TestMessagingService_SendMessage_RetriedInboundReturnsExisting(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo, attachmentRepo: mockAttachmentRepo}, WithInboundDeduplication(true))
+
+	existing := &domain.Message{ID: "msg1", ConversationID: "conv123", Content: "Hola"}
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("GetByConversationProviderMessageID", mock.Anything, "conv123", "wamid.1").Return(existing, nil)
+	mockAttachmentRepo.On("GetByMessageID", mock.Anything, "msg1").Return([]domain.Attachment{}, nil)
+
+	// Execute
+	message, err := service.SendMessage(context.Background(), inboundRequest())
+
+	// Assert: the retry is a no-op
+	require.NoError(t, err)
+	assert.Equal(t, "msg1", message.ID)
+	mockMessageRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_SendMessage_ConcurrentInboundRetry(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo, attachmentRepo: mockAttachmentRepo}, WithInboundDeduplication(true))
+
+	// The first lookup misses; the insert then collides with a retry that was stored in between
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("GetByConversationProviderMessageID", mock.Anything, "conv123", "wamid.1").Return(nil, fmt.Errorf("message %w", domain.ErrNotFound)).Once()
+	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(fmt.Errorf("message %w", domain.ErrAlreadyExists))
+	mockMessageRepo.On("GetByConversationProviderMessageID", mock.Anything, "conv123", "wamid.1").Return(&domain.Message{ID: "msg1", ConversationID: "conv123"}, nil)
+	mockAttachmentRepo.On("GetByMessageID", mock.Anything, "msg1").Return([]domain.Attachment{}, nil)
+
+	// Execute
+	message, err := service.SendMessage(context.Background(), inboundRequest())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "msg1", message.ID)
+}
+
+func TestMessagingService_SendMessage_DuplicateInboundRejectedWhenDisabled(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo}, WithInboundDeduplication(false))
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(fmt.Errorf("message %w", domain.ErrAlreadyExists))
+
+	// Execute
+	_, err := service.SendMessage(context.Background(), inboundRequest())
+
+	// Assert
+	assert.ErrorIs(t, err, ErrDuplicateMessage)
+	mockMessageRepo.AssertNotCalled(t, "GetByConversationProviderMessageID", mock.Anything, mock.Anything, mock.Anything)
+}
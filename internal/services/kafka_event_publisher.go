@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+// KafkaMessage es un registro a escribir en el topic; Key decide la partición
+type KafkaMessage struct {
+	Key   []byte
+	Value []byte
+	Time  time.Time
+}
+
+// KafkaWriter es el subconjunto del productor de Kafka que usa kafkaEventPublisher
+type KafkaWriter interface {
+	WriteMessages(ctx context.Context, messages ...KafkaMessage) error
+	Close() error
+}
+
+type kafkaEventPublisher struct {
+	writer KafkaWriter
+	topic  string
+	logger logger.Logger
+}
+
+// NewKafkaEventPublisher publica cada MessageEvent como JSON con el ID de la conversación como clave, de
+// modo que los eventos de una conversación caen en la misma partición y conservan su orden
+func NewKafkaEventPublisher(writer KafkaWriter, topic string, logger logger.Logger) EventPublisher {
+	return &kafkaEventPublisher{
+		writer: writer,
+		topic:  topic,
+		logger: logger,
+	}
+}
+
+func (p *kafkaEventPublisher) PublishMessageEvent(ctx context.Context, event domain.MessageEvent) error {
+	start := time.Now()
+	defer func() {
+		eventPublishDuration.WithLabelValues("kafka", event.Type).Observe(time.Since(start).Seconds())
+	}()
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		eventPublishFailuresTotal.WithLabelValues("kafka", event.Type).Inc()
+		p.logger.Error("Failed to marshal event", err)
+		return err
+	}
+
+	message := KafkaMessage{
+		Key:   []byte(event.ConversationID),
+		Value: data,
+		Time:  event.Timestamp,
+	}
+	if err := p.writer.WriteMessages(ctx, message); err != nil {
+		eventPublishFailuresTotal.WithLabelValues("kafka", event.Type).Inc()
+		p.logger.Error("Failed to publish event to Kafka", err)
+		return err
+	}
+
+	p.logger.Info("Event published", map[string]interface{}{
+		"topic":           p.topic,
+		"event_type":      event.Type,
+		"conversation_id": event.ConversationID,
+	})
+
+	return nil
+}
+
+// PublishEvent descarta los eventos efímeros: el topic de Kafka solo transporta eventos de mensajes y
+// nadie los lee en tiempo real desde ahí
+func (p *kafkaEventPublisher) PublishEvent(ctx context.Context, channel string, payload interface{}) error {
+	return nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockKafkaWriter is a mock implementation of KafkaWriter
+type MockKafkaWriter struct {
+	mock.Mock
+}
+
+func (m *MockKafkaWriter) WriteMessages(ctx context.Context, messages ...KafkaMessage) error {
+	args := m.Called(ctx, messages)
+	return args.Error(0)
+}
+
+func (m *MockKafkaWriter) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func TestKafkaEventPublisher_KeysByConversation(t *testing.T) {
+	// Setup
+	writer := new(MockKafkaWriter)
+	publisher := NewKafkaEventPublisher(writer, "message.events", logger.NewLogger("debug"))
+
+	var written []KafkaMessage
+	writer.On("WriteMessages", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		written = append(written, args.Get(1).([]KafkaMessage)...)
+	}).Return(nil)
+
+	timestamp := time.Date(2025, 1, 22, 10, 30, 0, 0, time.UTC)
+	events := []domain.MessageEvent{
+		{Type: "message.received", ConversationID: "conv1", Message: domain.Message{ID: "msg1"}, Timestamp: timestamp},
+		{Type: "message.received", ConversationID: "conv2", Message: domain.Message{ID: "msg2"}, Timestamp: timestamp},
+		{Type: "message.read", ConversationID: "conv1", Message: domain.Message{ID: "msg1"}, Timestamp: timestamp},
+	}
+
+	// Execute
+	for _, event := range events {
+		require.NoError(t, publisher.PublishMessageEvent(context.Background(), event))
+	}
+
+	// Assert: the conversation ID is the partition key, so conv1's events stay in order on one partition
+	require.Len(t, written, 3)
+	assert.Equal(t, "conv1", string(written[0].Key))
+	assert.Equal(t, "conv2", string(written[1].Key))
+	assert.Equal(t, "conv1", string(written[2].Key))
+	assert.Equal(t, timestamp, written[0].Time)
+
+	var decoded domain.MessageEvent
+	require.NoError(t, json.Unmarshal(written[2].Value, &decoded))
+	assert.Equal(t, "message.read", decoded.Type)
+	assert.Equal(t, "msg1", decoded.Message.ID)
+}
+
+func TestKafkaEventPublisher_RecordsFailureMetrics(t *testing.T) {
+	// Setup: the broker rejects every write
+	writer := new(MockKafkaWriter)
+	writer.On("WriteMessages", mock.Anything, mock.Anything).Return(errors.New("leader not available"))
+	publisher := NewKafkaEventPublisher(writer, "message.events", logger.NewLogger("debug"))
+
+	failures := eventPublishFailuresTotal.WithLabelValues("kafka", "metrics.test")
+	before := testutil.ToFloat64(failures)
+
+	// Execute
+	err := publisher.PublishMessageEvent(context.Background(), domain.MessageEvent{
+		Type:           "metrics.test",
+		ConversationID: "conv123",
+		Timestamp:      time.Now(),
+	})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, before+1, testutil.ToFloat64(failures))
+}
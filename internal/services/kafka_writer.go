@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaBatchTimeout acota la espera para completar un lote; el valor por defecto de kafka-go (1s) se
+// sumaría a cada publicación, que es síncrona
+const kafkaBatchTimeout = 10 * time.Millisecond
+
+type segmentioKafkaWriter struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaWriter crea el productor del topic tras comprobar que al menos un broker responde, para que un
+// clúster caído se detecte al arrancar y no en la primera publicación
+func NewKafkaWriter(ctx context.Context, brokers []string, topic string, timeout time.Duration) (KafkaWriter, error) {
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("no kafka brokers configured")
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var lastErr error
+	reachable := false
+	for _, broker := range brokers {
+		conn, err := kafka.DialContext(dialCtx, "tcp", broker)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		conn.Close()
+		reachable = true
+		break
+	}
+	if !reachable {
+		return nil, fmt.Errorf("no kafka broker reachable: %w", lastErr)
+	}
+
+	return &segmentioKafkaWriter{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			BatchTimeout: kafkaBatchTimeout,
+			WriteTimeout: timeout,
+		},
+	}, nil
+}
+
+func (w *segmentioKafkaWriter) WriteMessages(ctx context.Context, messages ...KafkaMessage) error {
+	records := make([]kafka.Message, len(messages))
+	for i, message := range messages {
+		records[i] = kafka.Message{
+			Key:   message.Key,
+			Value: message.Value,
+			Time:  message.Time,
+		}
+	}
+	return w.writer.WriteMessages(ctx, records...)
+}
+
+func (w *segmentioKafkaWriter) Close() error {
+	return w.writer.Close()
+}
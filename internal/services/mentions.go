@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// MetadataMentions es la clave de los metadatos con los usuarios mencionados en el mensaje
+const MetadataMentions = "mentions"
+
+// maxMentionsPerMessage acota las notificaciones que puede provocar un solo mensaje
+const maxMentionsPerMessage = 20
+
+// MentionParser extrae las menciones del contenido de un mensaje con un patrón configurable cuyo
+// único grupo de captura es el ID del usuario mencionado
+type MentionParser struct {
+	pattern *regexp.Regexp
+}
+
+// NewMentionParser compila el patrón de las menciones, p. ej. `(?:^|\s)@([A-Za-z0-9_-]+)`
+func NewMentionParser(pattern string) (*MentionParser, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mention pattern: %w", err)
+	}
+	if re.NumSubexp() != 1 {
+		return nil, fmt.Errorf("mention pattern must have exactly one capture group, got %d", re.NumSubexp())
+	}
+
+	return &MentionParser{pattern: re}, nil
+}
+
+// BuildMentionParser crea el parser a partir de la configuración. Devuelve nil si las menciones están desactivadas
+func BuildMentionParser(cfg config.MentionConfig) (*MentionParser, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	return NewMentionParser(cfg.Pattern)
+}
+
+// Parse devuelve los usuarios mencionados, sin repetir y en el orden en que aparecen
+func (p *MentionParser) Parse(content string) []string {
+	var mentions []string
+	seen := map[string]bool{}
+	for _, match := range p.pattern.FindAllStringSubmatch(content, -1) {
+		userID := match[1]
+		if userID == "" || seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		mentions = append(mentions, userID)
+		if len(mentions) == maxMentionsPerMessage {
+			break
+		}
+	}
+	return mentions
+}
+
+// WithMentions resuelve las menciones de cada mensaje antes de guardarlo, deja en metadata.mentions las que
+// son participantes de la conversación y publica un evento message.mention por cada una. Un parser nil
+// no cambia nada
+func WithMentions(parser *MentionParser) MessagingServiceOption {
+	return func(s *messagingService) {
+		if parser == nil {
+			return
+		}
+
+		s.sendHooks = append(s.sendHooks,
+			NewSendHook("mentions", SendHookPrePersist, func(ctx context.Context, send *SendContext) error {
+				s.resolveMentions(ctx, parser, send)
+				return nil
+			}),
+			NewSendHook("mention_notify", SendHookPostPersist, s.publishMentions),
+		)
+	}
+}
+
+// resolveMentions guarda en los metadatos los usuarios mencionados que participan en la conversación.
+// Si no se pueden comprobar los participantes solo se conserva la mención del dueño: una mención nunca
+// impide enviar el mensaje
+func (s *messagingService) resolveMentions(ctx context.Context, parser *MentionParser, send *SendContext) {
+	var candidates []string
+	for _, userID := range parser.Parse(send.Message.Content) {
+		if userID != send.Message.SenderID {
+			candidates = append(candidates, userID)
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	participants := map[string]bool{send.Conversation.UserID: true}
+	if s.participantRepo != nil {
+		found, err := s.participantRepo.FilterParticipants(ctx, send.Conversation.ID, candidates)
+		if err != nil {
+			s.log(ctx).Warn("Failed to resolve mentions", map[string]interface{}{
+				"conversation_id": send.Conversation.ID,
+				"error":           err.Error(),
+			})
+		}
+		for _, userID := range found {
+			participants[userID] = true
+		}
+	}
+
+	var mentions []string
+	for _, userID := range candidates {
+		if participants[userID] {
+			mentions = append(mentions, userID)
+		}
+	}
+	if len(mentions) == 0 {
+		return
+	}
+
+	if send.Message.Metadata == nil {
+		send.Message.Metadata = domain.JSONB{}
+	}
+	send.Message.Metadata[MetadataMentions] = mentions
+}
+
+// publishMentions avisa a cada usuario mencionado para que el servicio de notificaciones le alerte
+func (s *messagingService) publishMentions(ctx context.Context, send *SendContext) error {
+	mentions, _ := send.Message.Metadata[MetadataMentions].([]string)
+	if len(mentions) == 0 || s.eventPublisher == nil {
+		return nil
+	}
+
+	now := time.Now()
+	for _, mentionedUserID := range mentions {
+		event := domain.MessageEvent{
+			Type:           "message.mention",
+			ConversationID: send.Message.ConversationID,
+			Message:        *send.Message,
+			UserID:         send.Message.SenderID,
+			Data:           domain.JSONB{"mentioned_user_id": mentionedUserID},
+			Timestamp:      now,
+		}
+
+		if err := s.eventPublisher.PublishMessageEvent(ctx, event); err != nil {
+			return fmt.Errorf("failed to publish mention event: %w", err)
+		}
+	}
+
+	return nil
+}
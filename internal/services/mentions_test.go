@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMentionParser_Parse(t *testing.T) {
+	parser, err := BuildMentionParser(config.MentionConfig{Enabled: true, Pattern: `(?:^|\s)@([A-Za-z0-9_-]+(?:\.[A-Za-z0-9_-]+)*)`})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"ana", "luis.perez"}, parser.Parse("@ana mira esto con @luis.perez. Gracias @ana"))
+	assert.Empty(t, parser.Parse("escribe a soporte@example.com"))
+
+	// Custom syntax
+	parser, err = NewMentionParser(`<@([a-z0-9]+)>`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"u123"}, parser.Parse("hola <@u123>"))
+
+	_, err = NewMentionParser(`@\w+`)
+	assert.Error(t, err)
+}
+
+func TestMessagingService_SendMessage_PublishesMentions(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockParticipantRepo := new(MockParticipantRepository)
+	publisher := &recordingEventPublisher{}
+
+	parser, err := NewMentionParser(`(?:^|\s)@([A-Za-z0-9_-]+)`)
+	require.NoError(t, err)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		new(MockAttachmentRepository),
+		publisher,
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithParticipantRepository(mockParticipantRepo),
+		WithMentions(parser),
+	)
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "owner"}, nil)
+	mockParticipantRepo.On("FilterParticipants", mock.Anything, "conv123", []string{"owner", "agent2", "outsider"}).Return([]string{"agent2"}, nil)
+	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+	mockConversationRepo.On("TouchUpdatedAt", mock.Anything, mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+
+	// Execute: an agent desk posts on the customer's conversation; the sender's own mention is ignored
+	// and non-participants are dropped
+	ctx := auth.WithServiceIdentity(context.Background(), "agent-desk")
+	message, err := service.SendMessage(ctx, SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "agent1",
+		Content:        "@agent1 @owner @agent2 @outsider revisad esto",
+		ContentType:    domain.ContentTypeText,
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"owner", "agent2"}, message.Metadata[MetadataMentions])
+
+	var mentioned []interface{}
+	for _, event := range publisher.events {
+		if event.Type == "message.mention" {
+			assert.Equal(t, "agent1", event.UserID)
+			mentioned = append(mentioned, event.Data["mentioned_user_id"])
+		}
+	}
+	assert.Equal(t, []interface{}{"owner", "agent2"}, mentioned)
+}
+
+func TestMessagingService_SendMessage_MentionLookupFailureKeepsMessage(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockParticipantRepo := new(MockParticipantRepository)
+
+	parser, err := NewMentionParser(`(?:^|\s)@([A-Za-z0-9_-]+)`)
+	require.NoError(t, err)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithParticipantRepository(mockParticipantRepo),
+		WithMentions(parser),
+	)
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockParticipantRepo.On("FilterParticipants", mock.Anything, "conv123", []string{"agent2"}).Return([]string(nil), fmt.Errorf("database not available"))
+	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+	mockConversationRepo.On("TouchUpdatedAt", mock.Anything, mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+
+	// Execute
+	message, err := service.SendMessage(context.Background(), SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "user123",
+		Content:        "hola @agent2",
+		ContentType:    domain.ContentTypeText,
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.NotContains(t, message.Metadata, MetadataMentions)
+}
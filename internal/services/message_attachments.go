@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidAttachment indica que un adjunto del mensaje no apunta a un archivo subido por el remitente
+var ErrInvalidAttachment = errors.New("invalid attachment")
+
+// verifyAttachmentFiles comprueba que cada adjunto (y su miniatura) apunta a un archivo guardado que subió el
+// propio remitente, para que nadie adjunte archivos ajenos o inexistentes. Los servicios internos de
+// confianza pueden adjuntar cualquier URL, como la media de un canal externo
+func (s *messagingService) verifyAttachmentFiles(ctx context.Context, senderID string, attachments []CreateAttachmentRequest) error {
+	if s.fileService == nil || isTrustedService(ctx) {
+		return nil
+	}
+
+	for _, attachment := range attachments {
+		urls := []string{attachment.URL}
+		if attachment.ThumbnailURL != "" && attachment.ThumbnailURL != attachment.URL {
+			urls = append(urls, attachment.ThumbnailURL)
+		}
+
+		for _, url := range urls {
+			info, err := s.fileService.GetFileInfo(ctx, url)
+			if err != nil {
+				return fmt.Errorf("failed to verify attachment: %w", err)
+			}
+			if !info.Exists {
+				return fmt.Errorf("%w: file %q does not exist", ErrInvalidAttachment, url)
+			}
+			if info.UserID != senderID {
+				return fmt.Errorf("%w: file %q was uploaded by another user", ErrInvalidAttachment, url)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessagingService_SendMessage_WithUploadedAttachments(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockFileService := new(MockFileService)
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		mockAttachmentRepo,
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithFileService(mockFileService),
+	)
+
+	conversation := &domain.Conversation{ID: "conv123", UserID: "user123", Status: domain.ConversationStatusActive}
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(conversation, nil)
+	mockConversationRepo.On("TouchUpdatedAt", mock.Anything, "conv123", mock.AnythingOfType("time.Time")).Return(nil)
+	mockFileService.On("GetFileInfo", mock.Anything, "/uploads/user123/a.png").Return(&FileInfo{Exists: true, UserID: "user123"}, nil)
+	mockFileService.On("GetFileInfo", mock.Anything, "/uploads/user123/a_thumb.png").Return(&FileInfo{Exists: true, UserID: "user123"}, nil)
+
+	var stored *domain.Message
+	mockMessageRepo.On("CreateWithAttachments", mock.Anything, mock.AnythingOfType("*domain.Message")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*domain.Message)
+	}).Return(nil)
+
+	// Execute
+	message, err := service.SendMessage(context.Background(), SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "user123",
+		Content:        "Mira",
+		ContentType:    domain.ContentTypeImage,
+		Attachments: []CreateAttachmentRequest{{
+			URL:          "/uploads/user123/a.png",
+			Type:         domain.AttachmentTypeImage,
+			Size:         10,
+			Filename:     "a.png",
+			ThumbnailURL: "/uploads/user123/a_thumb.png",
+		}},
+	})
+
+	// Assert: the attachment rows are written with the message
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	require.Len(t, stored.Attachments, 1)
+	assert.Equal(t, message.ID, stored.Attachments[0].MessageID)
+	mockAttachmentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	// And GetMessage returns them populated
+	mockMessageRepo.On("GetByID", mock.Anything, message.ID).Return(&domain.Message{ID: message.ID, ConversationID: "conv123"}, nil)
+	mockAttachmentRepo.On("GetByMessageID", mock.Anything, message.ID).Return(stored.Attachments, nil)
+
+	loaded, err := service.GetMessage(context.Background(), message.ID, "user123")
+	require.NoError(t, err)
+	require.Len(t, loaded.Attachments, 1)
+	assert.Equal(t, "/uploads/user123/a.png", loaded.Attachments[0].URL)
+	assert.Equal(t, "/uploads/user123/a_thumb.png", loaded.Attachments[0].ThumbnailURL)
+	assert.Equal(t, "a.png", loaded.Attachments[0].Filename)
+}
+
+func TestMessagingService_SendMessage_RejectsForeignOrMissingAttachments(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockFileService := new(MockFileService)
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithFileService(mockFileService),
+	)
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockFileService.On("GetFileInfo", mock.Anything, "/uploads/other/b.pdf").Return(&FileInfo{Exists: true, UserID: "other"}, nil)
+	mockFileService.On("GetFileInfo", mock.Anything, "/uploads/user123/gone.pdf").Return(&FileInfo{Exists: false}, nil)
+
+	for _, url := range []string{"/uploads/other/b.pdf", "/uploads/user123/gone.pdf"} {
+		// Execute
+		_, err := service.SendMessage(context.Background(), SendMessageRequest{
+			ConversationID: "conv123",
+			SenderType:     domain.SenderTypeUser,
+			SenderID:       "user123",
+			Content:        "Adjunto",
+			ContentType:    domain.ContentTypeFile,
+			Attachments:    []CreateAttachmentRequest{{URL: url, Type: domain.AttachmentTypeFile, Size: 10, Filename: "doc.pdf"}},
+		})
+
+		// Assert
+		assert.ErrorIs(t, err, ErrInvalidAttachment, url)
+	}
+	mockMessageRepo.AssertNotCalled(t, "CreateWithAttachments", mock.Anything, mock.Anything)
+}
+
+// expectStoredAttachment prepara los mocks con el adjunto att1, con miniatura, de un mensaje de conv123 de user123
+func expectStoredAttachment(conversationRepo *MockConversationRepository, messageRepo *MockMessageRepository, attachmentRepo *MockAttachmentRepository) {
+	attachmentRepo.On("GetByID", mock.Anything, "att1").Return(&domain.Attachment{
+		ID:           "att1",
+		MessageID:    "msg1",
+		URL:          "/uploads/user123/a.png",
+		ThumbnailURL: "/uploads/user123/a_thumb.png",
+	}, nil)
+	messageRepo.On("GetByID", mock.Anything, "msg1").Return(&domain.Message{ID: "msg1", ConversationID: "conv123"}, nil)
+	conversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+}
+
+func TestMessagingService_DeleteAttachment(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockFileService := new(MockFileService)
+	expectStoredAttachment(mockConversationRepo, mockMessageRepo, mockAttachmentRepo)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo, attachmentRepo: mockAttachmentRepo}, WithFileService(mockFileService))
+	mockFileService.On("DeleteFile", mock.Anything, "/uploads/user123/a.png").Return(nil)
+	mockFileService.On("DeleteFile", mock.Anything, "/uploads/user123/a_thumb.png").Return(nil)
+	mockAttachmentRepo.On("Delete", mock.Anything, "att1").Return(nil)
+
+	// Execute
+	err := service.DeleteAttachment(context.Background(), "att1", "user123")
+
+	// Assert: both files and the row are gone
+	require.NoError(t, err)
+	mockFileService.AssertExpectations(t)
+	mockAttachmentRepo.AssertCalled(t, "Delete", mock.Anything, "att1")
+}
+
+func TestMessagingService_DeleteAttachment_NotOwner(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockFileService := new(MockFileService)
+	expectStoredAttachment(mockConversationRepo, mockMessageRepo, mockAttachmentRepo)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo, attachmentRepo: mockAttachmentRepo}, WithFileService(mockFileService))
+
+	// Execute
+	err := service.DeleteAttachment(context.Background(), "att1", "intruder")
+
+	// Assert: nothing is deleted and the attachment looks missing
+	require.Error(t, err)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	mockFileService.AssertNotCalled(t, "DeleteFile", mock.Anything, mock.Anything)
+	mockAttachmentRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_DeleteAttachment_FileAlreadyGone(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockFileService := new(MockFileService)
+	expectStoredAttachment(mockConversationRepo, mockMessageRepo, mockAttachmentRepo)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo, attachmentRepo: mockAttachmentRepo}, WithFileService(mockFileService))
+	mockFileService.On("DeleteFile", mock.Anything, mock.Anything).Return(fmt.Errorf("file %w", domain.ErrNotFound))
+	mockAttachmentRepo.On("Delete", mock.Anything, "att1").Return(nil)
+
+	// Execute
+	err := service.DeleteAttachment(context.Background(), "att1", "user123")
+
+	// Assert
+	require.NoError(t, err)
+	mockAttachmentRepo.AssertCalled(t, "Delete", mock.Anything, "att1")
+}
+
+func TestMessagingService_DeleteAttachment_FileDeleteFails(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockFileService := new(MockFileService)
+	expectStoredAttachment(mockConversationRepo, mockMessageRepo, mockAttachmentRepo)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo, attachmentRepo: mockAttachmentRepo}, WithFileService(mockFileService))
+	mockFileService.On("DeleteFile", mock.Anything, mock.Anything).Return(errors.New("storage unavailable"))
+
+	// Execute
+	err := service.DeleteAttachment(context.Background(), "att1", "user123")
+
+	// Assert: the row stays so the delete can be retried
+	require.Error(t, err)
+	mockAttachmentRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
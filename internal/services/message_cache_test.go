@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedisCache(t *testing.T, cfg config.RedisConfig) (CacheService, *miniredis.Miniredis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewRedisCacheService(client, logger.NewLogger("error"), cfg), server
+}
+
+func TestMessagingService_GetMessages_CachedUntilSend(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	cache, _ := newTestRedisCache(t, config.RedisConfig{MessageCacheTTL: time.Minute})
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		mockAttachmentRepo,
+		NewNoOpEventPublisher(),
+		cache,
+		logger.NewLogger("debug"),
+	)
+
+	page := domain.PaginationParams{Limit: 20}
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("GetByConversationID", mock.Anything, "conv123", page).Return([]domain.Message{{ID: "msg1", ConversationID: "conv123", Content: "Hola"}}, nil)
+	mockMessageRepo.On("CountReplies", mock.Anything, mock.Anything).Return(map[string]int{}, nil)
+	mockAttachmentRepo.On("GetByMessageID", mock.Anything, mock.Anything).Return([]domain.Attachment{}, nil)
+
+	// Execute: the second read is served from the cache
+	messages, err := service.GetMessages(context.Background(), "conv123", "user123", page)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+
+	messages, err = service.GetMessages(context.Background(), "conv123", "user123", page)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "Hola", messages[0].Content)
+	mockMessageRepo.AssertNumberOfCalls(t, "GetByConversationID", 1)
+
+	// Other pages are cached separately
+	otherPage := domain.PaginationParams{Limit: 20, Offset: 20}
+	mockMessageRepo.On("GetByConversationID", mock.Anything, "conv123", otherPage).Return([]domain.Message{}, nil)
+	_, err = service.GetMessages(context.Background(), "conv123", "user123", otherPage)
+	require.NoError(t, err)
+	mockMessageRepo.AssertNumberOfCalls(t, "GetByConversationID", 2)
+
+	// Sending a message drops the cached pages
+	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+	mockConversationRepo.On("TouchUpdatedAt", mock.Anything, "conv123", mock.AnythingOfType("time.Time")).Return(nil)
+	_, err = service.SendMessage(context.Background(), SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "user123",
+		Content:        "Otro",
+		ContentType:    domain.ContentTypeText,
+	})
+	require.NoError(t, err)
+
+	_, err = service.GetMessages(context.Background(), "conv123", "user123", page)
+	require.NoError(t, err)
+	mockMessageRepo.AssertNumberOfCalls(t, "GetByConversationID", 3)
+}
+
+func TestRedisCacheService_MessagesTTL(t *testing.T) {
+	ctx := context.Background()
+	page := domain.PaginationParams{Limit: 20}
+	messages := []domain.Message{{ID: "msg1", ConversationID: "conv123"}}
+
+	cache, server := newTestRedisCache(t, config.RedisConfig{MessageCacheTTL: 5 * time.Minute})
+	require.NoError(t, cache.SetMessages(ctx, "conv123", page, messages))
+	assert.Equal(t, 5*time.Minute, server.TTL("messages:conv123"))
+
+	cached, err := cache.GetMessages(ctx, "conv123", page)
+	require.NoError(t, err)
+	assert.Equal(t, "msg1", cached[0].ID)
+
+	// A zero TTL disables the message cache
+	disabled, server := newTestRedisCache(t, config.RedisConfig{})
+	require.NoError(t, disabled.SetMessages(ctx, "conv123", page, messages))
+	assert.False(t, server.Exists("messages:conv123"))
+	_, err = disabled.GetMessages(ctx, "conv123", page)
+	assert.Error(t, err)
+}
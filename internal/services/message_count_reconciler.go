@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+// MessageCountReconciler recorre las conversaciones por lotes y corrige el contador desnormalizado
+// message_count cuando se aparta del número real de mensajes
+type MessageCountReconciler struct {
+	conversationRepo domain.ConversationRepository
+	cacheService     CacheService
+	config           config.CountersConfig
+	logger           logger.Logger
+
+	// Último id revisado; vuelve a "" al terminar una vuelta completa
+	cursor string
+}
+
+func NewMessageCountReconciler(
+	conversationRepo domain.ConversationRepository,
+	cacheService CacheService,
+	config config.CountersConfig,
+	logger logger.Logger,
+) *MessageCountReconciler {
+	return &MessageCountReconciler{
+		conversationRepo: conversationRepo,
+		cacheService:     cacheService,
+		config:           config,
+		logger:           logger,
+	}
+}
+
+// Start ejecuta la reconciliación hasta que se cancele el contexto
+func (r *MessageCountReconciler) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.config.ReconcileInterval)
+	defer ticker.Stop()
+
+	r.logger.Info("Message count reconciler started", map[string]interface{}{
+		"interval": r.config.ReconcileInterval.String(),
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Message count reconciler stopped")
+			return
+		case <-ticker.C:
+			r.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce revisa el siguiente lote de conversaciones y devuelve cuántos contadores corrigió
+func (r *MessageCountReconciler) RunOnce(ctx context.Context) int {
+	lastID, fixedIDs, err := r.conversationRepo.ReconcileMessageCounts(ctx, r.cursor, r.config.ReconcileBatchSize)
+	if err != nil {
+		r.logger.Error("Failed to reconcile message counts", err)
+		return 0
+	}
+
+	r.cursor = lastID
+
+	if len(fixedIDs) > 0 {
+		// Cached conversations would keep serving the drifted counter until they expire
+		if r.cacheService != nil {
+			for _, id := range fixedIDs {
+				_ = r.cacheService.DeleteConversation(ctx, id)
+			}
+		}
+
+		r.logger.Warn("Message count drift corrected", map[string]interface{}{
+			"conversations": len(fixedIDs),
+			"last_id":       lastID,
+		})
+	}
+
+	return len(fixedIDs)
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type recordingCacheService struct {
+	CacheService
+	deleted []string
+}
+
+func (c *recordingCacheService) DeleteConversation(ctx context.Context, id string) error {
+	c.deleted = append(c.deleted, id)
+	return nil
+}
+
+func TestMessageCountReconciler_WalksBatchesAndWrapsAround(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	cache := &recordingCacheService{CacheService: NewNoOpCacheService()}
+
+	reconciler := NewMessageCountReconciler(
+		mockConversationRepo,
+		cache,
+		config.CountersConfig{ReconcileEnabled: true, ReconcileInterval: time.Second, ReconcileBatchSize: 2},
+		logger.NewLogger("debug"),
+	)
+
+	mockConversationRepo.On("ReconcileMessageCounts", mock.Anything, "", 2).Return("conv2", []string{"conv1"}, nil).Once()
+	mockConversationRepo.On("ReconcileMessageCounts", mock.Anything, "conv2", 2).Return("conv3", []string{}, nil).Once()
+	mockConversationRepo.On("ReconcileMessageCounts", mock.Anything, "conv3", 2).Return("", []string{}, nil).Once()
+	mockConversationRepo.On("ReconcileMessageCounts", mock.Anything, "", 2).Return("conv2", []string{}, nil).Once()
+
+	// Execute
+	fixed := []int{
+		reconciler.RunOnce(context.Background()),
+		reconciler.RunOnce(context.Background()),
+		reconciler.RunOnce(context.Background()),
+		reconciler.RunOnce(context.Background()),
+	}
+
+	// Assert
+	assert.Equal(t, []int{1, 0, 0, 0}, fixed)
+	assert.Equal(t, []string{"conv1"}, cache.deleted)
+	mockConversationRepo.AssertExpectations(t)
+}
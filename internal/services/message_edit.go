@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// ErrMessageNotEditable indica que el mensaje no admite edición, como los mensajes de sistema
+var ErrMessageNotEditable = errors.New("message cannot be edited")
+
+// EditMessage sustituye el contenido de un mensaje de la conversación y marca edited_at. El contenido nuevo
+// pasa por las mismas reglas de contenido y el mismo enmascarado de datos personales que un envío
+func (s *messagingService) EditMessage(ctx context.Context, messageID string, userID string, newContent string) (*domain.Message, error) {
+	message, err := s.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	// Verify user has access to the conversation
+	conversation, err := s.GetConversation(ctx, message.ConversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.SenderType == domain.SenderTypeSystem {
+		return nil, fmt.Errorf("%w: system messages are immutable", ErrMessageNotEditable)
+	}
+
+	if rule, ok := s.contentRules[message.ContentType]; ok && rule.RequireContent && strings.TrimSpace(newContent) == "" {
+		return nil, &ContentRuleError{Code: ContentRuleCodeContentRequired, ContentType: message.ContentType, Reason: "requires non-empty content"}
+	}
+
+	message.Content = newContent
+	if err := s.redactEditedContent(ctx, message); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	message.EditedAt = &now
+	if err := s.messageRepo.Update(ctx, message); err != nil {
+		return nil, fmt.Errorf("failed to update message: %w", err)
+	}
+
+	messages := []domain.Message{*message}
+	s.loadAttachments(ctx, messages)
+	message = &messages[0]
+
+	if s.cacheService != nil {
+		_ = s.cacheService.DeleteMessages(ctx, message.ConversationID)
+	}
+	// The listing may be previewing this message
+	s.invalidateConversationList(ctx, conversation.UserID)
+
+	s.log(ctx).Info("Message edited", map[string]interface{}{
+		"message_id":      messageID,
+		"conversation_id": message.ConversationID,
+		"user_id":         userID,
+	})
+
+	if s.eventPublisher != nil {
+		event := domain.MessageEvent{
+			Type:           "message.edited",
+			ConversationID: message.ConversationID,
+			Message:        *message,
+			UserID:         userID,
+			Timestamp:      now,
+		}
+		if err := s.eventPublisher.PublishMessageEvent(ctx, event); err != nil {
+			s.log(ctx).Error("Failed to publish message edited event", err)
+		}
+	}
+
+	return message, nil
+}
+
+// redactEditedContent aplica al contenido editado el hook de enmascarado de datos personales, si está
+// configurado, para que una edición no guarde lo que el envío habría enmascarado
+func (s *messagingService) redactEditedContent(ctx context.Context, message *domain.Message) error {
+	for _, hook := range s.sendHooks {
+		if hook.Name() == piiRedactionHookName {
+			return hook.Handle(ctx, &SendContext{Message: message})
+		}
+	}
+	return nil
+}
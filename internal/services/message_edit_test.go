@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessagingService_EditMessage(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	publisher := &recordingEventPublisher{}
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo, attachmentRepo: mockAttachmentRepo, publisher: publisher})
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockAttachmentRepo.On("GetByMessageID", mock.Anything, "msg1").Return([]domain.Attachment{}, nil)
+	mockMessageRepo.On("GetByID", mock.Anything, "msg1").Return(&domain.Message{
+		ID:             "msg1",
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		Content:        "Hola",
+		ContentType:    domain.ContentTypeText,
+	}, nil)
+	mockMessageRepo.On("Update", mock.Anything, mock.MatchedBy(func(message *domain.Message) bool {
+		return message.Content == "Hola, buenos días" && message.EditedAt != nil
+	})).Return(nil)
+
+	// Execute
+	message, err := service.EditMessage(context.Background(), "msg1", "user123", "Hola, buenos días")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "Hola, buenos días", message.Content)
+	assert.NotNil(t, message.EditedAt)
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, "message.edited", publisher.events[0].Type)
+	assert.Equal(t, "user123", publisher.events[0].UserID)
+}
+
+func TestMessagingService_EditMessage_AccessDenied(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	publisher := &recordingEventPublisher{}
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo, publisher: publisher})
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "owner"}, nil)
+	mockMessageRepo.On("GetByID", mock.Anything, "msg1").Return(&domain.Message{ID: "msg1", ConversationID: "conv123", SenderType: domain.SenderTypeUser, ContentType: domain.ContentTypeText}, nil)
+
+	// Execute
+	_, err := service.EditMessage(context.Background(), "msg1", "intruder", "editado")
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	mockMessageRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	assert.Empty(t, publisher.events)
+}
+
+func TestMessagingService_EditMessage_SystemMessage(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo})
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("GetByID", mock.Anything, "msg1").Return(&domain.Message{ID: "msg1", ConversationID: "conv123", SenderType: domain.SenderTypeSystem, ContentType: domain.ContentTypeText}, nil)
+
+	// Execute
+	_, err := service.EditMessage(context.Background(), "msg1", "user123", "editado")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrMessageNotEditable)
+	mockMessageRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+// MessageExpiryReaper elimina definitivamente los mensajes efímeros vencidos y sus archivos
+type MessageExpiryReaper struct {
+	messageRepo    domain.MessageRepository
+	attachmentRepo domain.AttachmentRepository
+	fileService    FileService
+	eventPublisher EventPublisher
+	cacheService   CacheService
+	config         config.ExpiryConfig
+	logger         logger.Logger
+}
+
+func NewMessageExpiryReaper(
+	messageRepo domain.MessageRepository,
+	attachmentRepo domain.AttachmentRepository,
+	fileService FileService,
+	eventPublisher EventPublisher,
+	cacheService CacheService,
+	config config.ExpiryConfig,
+	logger logger.Logger,
+) *MessageExpiryReaper {
+	return &MessageExpiryReaper{
+		messageRepo:    messageRepo,
+		attachmentRepo: attachmentRepo,
+		fileService:    fileService,
+		eventPublisher: eventPublisher,
+		cacheService:   cacheService,
+		config:         config,
+		logger:         logger,
+	}
+}
+
+// Start ejecuta el reaper hasta que se cancele el contexto
+func (r *MessageExpiryReaper) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.config.ReaperInterval)
+	defer ticker.Stop()
+
+	r.logger.Info("Message expiry reaper started", map[string]interface{}{
+		"interval": r.config.ReaperInterval.String(),
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Message expiry reaper stopped")
+			return
+		case <-ticker.C:
+			r.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce elimina un lote de mensajes vencidos y devuelve cuántos se borraron
+func (r *MessageExpiryReaper) RunOnce(ctx context.Context) int {
+	messages, err := r.messageRepo.GetExpired(ctx, time.Now(), r.config.ReaperBatchSize)
+	if err != nil {
+		r.logger.Error("Failed to load expired messages", err)
+		return 0
+	}
+
+	deleted := 0
+	for i := range messages {
+		if r.expire(ctx, &messages[i]) {
+			deleted++
+		}
+	}
+
+	return deleted
+}
+
+func (r *MessageExpiryReaper) expire(ctx context.Context, message *domain.Message) bool {
+	attachments, err := r.attachmentRepo.GetByMessageID(ctx, message.ID)
+	if err != nil {
+		r.logger.Error("Failed to load attachments of expired message", err)
+		return false
+	}
+
+	// Files go first: the attachment rows disappear with the message (ON DELETE CASCADE)
+	if r.fileService != nil {
+		for _, attachment := range attachments {
+			urls := []string{attachment.URL}
+			if attachment.ThumbnailURL != "" && attachment.ThumbnailURL != attachment.URL {
+				urls = append(urls, attachment.ThumbnailURL)
+			}
+			for _, url := range urls {
+				if err := r.fileService.DeleteFile(ctx, url); err != nil {
+					r.logger.Warn("Failed to delete file of expired message", map[string]interface{}{
+						"message_id": message.ID,
+						"url":        url,
+						"error":      err.Error(),
+					})
+				}
+			}
+		}
+	}
+
+	// Expired content must not survive as a soft-deleted row
+	if err := r.messageRepo.Purge(ctx, message.ID); err != nil {
+		r.logger.Error("Failed to delete expired message", err)
+		return false
+	}
+
+	// The cached conversation and message list still include the deleted message
+	if r.cacheService != nil {
+		_ = r.cacheService.DeleteConversation(ctx, message.ConversationID)
+		_ = r.cacheService.DeleteMessages(ctx, message.ConversationID)
+	}
+
+	if r.eventPublisher != nil {
+		// Only identifiers are published so the expired content doesn't leak through the event bus
+		event := domain.MessageEvent{
+			Type:           "message.expired",
+			ConversationID: message.ConversationID,
+			Message: domain.Message{
+				ID:             message.ID,
+				ConversationID: message.ConversationID,
+				ExpiresAt:      message.ExpiresAt,
+			},
+			Timestamp: time.Now(),
+		}
+
+		if err := r.eventPublisher.PublishMessageEvent(ctx, event); err != nil {
+			r.logger.Error("Failed to publish message expired event", err)
+		}
+	}
+
+	return true
+}
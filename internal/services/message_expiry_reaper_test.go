@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMessageExpiryReaper_DeletesExpiredMessages(t *testing.T) {
+	// Setup
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockFileService := new(MockFileService)
+	publisher := &recordingEventPublisher{}
+
+	reaper := NewMessageExpiryReaper(
+		mockMessageRepo,
+		mockAttachmentRepo,
+		mockFileService,
+		publisher,
+		NewNoOpCacheService(),
+		config.ExpiryConfig{ReaperEnabled: true, ReaperInterval: time.Second, ReaperBatchSize: 10},
+		logger.NewLogger("debug"),
+	)
+
+	expiredAt := time.Now().Add(-time.Minute)
+	expired := domain.Message{ID: "msg123", ConversationID: "conv123", Content: "secret", ExpiresAt: &expiredAt}
+
+	mockMessageRepo.On("GetExpired", mock.Anything, mock.Anything, 10).Return([]domain.Message{expired}, nil)
+	mockAttachmentRepo.On("GetByMessageID", mock.Anything, "msg123").Return([]domain.Attachment{
+		{ID: "att1", MessageID: "msg123", URL: "/uploads/user123/photo.png"},
+	}, nil)
+	mockFileService.On("DeleteFile", mock.Anything, "/uploads/user123/photo.png").Return(nil)
+	mockMessageRepo.On("Purge", mock.Anything, "msg123").Return(nil)
+
+	// Execute
+	deleted := reaper.RunOnce(context.Background())
+
+	// Assert
+	assert.Equal(t, 1, deleted)
+	assert.Len(t, publisher.events, 1)
+	assert.Equal(t, "message.expired", publisher.events[0].Type)
+	assert.Equal(t, "msg123", publisher.events[0].Message.ID)
+	assert.Empty(t, publisher.events[0].Message.Content)
+	mockFileService.AssertExpectations(t)
+	mockMessageRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_SendMessage_RejectsPastExpiry(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	conversation := &domain.Conversation{ID: "conv123", UserID: "user123", Channel: domain.ChannelWeb, Status: domain.ConversationStatusActive}
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(conversation, nil)
+
+	past := time.Now().Add(-time.Hour)
+
+	// Execute
+	message, err := service.SendMessage(context.Background(), SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "user123",
+		Content:        "self-destruct",
+		ContentType:    domain.ContentTypeText,
+		ExpiresAt:      &past,
+	})
+
+	// Assert
+	assert.Nil(t, message)
+	assert.ErrorIs(t, err, ErrMessageRejected)
+	assert.Contains(t, err.Error(), "expires_at")
+	mockMessageRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// maxMessageReadBatch limita cuántos mensajes se pueden marcar como leídos en una sola llamada
+const maxMessageReadBatch = 500
+
+// WithMessageReadRepository habilita los acuses de lectura por mensaje
+func WithMessageReadRepository(repo domain.MessageReadRepository) MessagingServiceOption {
+	return func(s *messagingService) {
+		s.messageReadRepo = repo
+	}
+}
+
+// MarkMessageRead registra que el usuario leyó un mensaje
+func (s *messagingService) MarkMessageRead(ctx context.Context, messageID string, userID string) error {
+	message, err := s.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to get message: %w", err)
+	}
+
+	return s.MarkMessagesRead(ctx, message.ConversationID, []string{messageID}, userID)
+}
+
+// MarkMessagesRead registra en una sola escritura los acuses de varios mensajes de una conversación.
+// Solo los mensajes que no estaban leídos publican el evento message.read
+func (s *messagingService) MarkMessagesRead(ctx context.Context, conversationID string, messageIDs []string, userID string) error {
+	if s.messageReadRepo == nil {
+		return fmt.Errorf("message read receipts are not enabled")
+	}
+
+	if len(messageIDs) == 0 {
+		return fmt.Errorf("at least one message ID is required")
+	}
+
+	if len(messageIDs) > maxMessageReadBatch {
+		return fmt.Errorf("cannot mark more than %d messages as read at once", maxMessageReadBatch)
+	}
+
+	// Verify conversation access
+	conversation, err := s.GetConversation(ctx, conversationID, userID)
+	if err != nil {
+		return err
+	}
+
+	readAt := time.Now()
+	marked, err := s.messageReadRepo.MarkRead(ctx, conversationID, userID, messageIDs, readAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark messages as read: %w", err)
+	}
+
+	s.publishMessageReads(ctx, conversation, userID, marked, readAt)
+
+	return nil
+}
+
+// MarkConversationReadUpTo registra los acuses del usuario sobre todos los mensajes de la conversación hasta
+// upToMessageID inclusive. Repetirla no reescribe los acuses ni vuelve a publicar message.read
+func (s *messagingService) MarkConversationReadUpTo(ctx context.Context, conversationID string, upToMessageID string, userID string) error {
+	if s.messageReadRepo == nil {
+		return fmt.Errorf("message read receipts are not enabled")
+	}
+
+	// Verify conversation access
+	conversation, err := s.GetConversation(ctx, conversationID, userID)
+	if err != nil {
+		return err
+	}
+
+	message, err := s.messageRepo.GetByID(ctx, upToMessageID)
+	if err != nil {
+		return fmt.Errorf("failed to get message: %w", err)
+	}
+	if message.ConversationID != conversationID {
+		return fmt.Errorf("message %w in conversation", domain.ErrNotFound)
+	}
+
+	readAt := time.Now()
+	marked, err := s.messageReadRepo.MarkReadUpTo(ctx, conversationID, userID, upToMessageID, readAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark messages as read: %w", err)
+	}
+
+	s.publishMessageReads(ctx, conversation, userID, marked, readAt)
+
+	return nil
+}
+
+// publishMessageReads publica message.read por cada mensaje que el usuario acaba de leer
+func (s *messagingService) publishMessageReads(ctx context.Context, conversation *domain.Conversation, userID string, marked []string, readAt time.Time) {
+	if len(marked) > 0 {
+		s.invalidateConversationList(ctx, conversation.UserID)
+	}
+
+	conversationID := conversation.ID
+	if s.eventPublisher != nil {
+		for _, messageID := range marked {
+			event := domain.MessageEvent{
+				Type:           "message.read",
+				ConversationID: conversationID,
+				Message: domain.Message{
+					ID:             messageID,
+					ConversationID: conversationID,
+					ReadBy:         []domain.MessageRead{{MessageID: messageID, UserID: userID, ReadAt: readAt}},
+				},
+				UserID:    userID,
+				Timestamp: readAt,
+			}
+
+			if err := s.eventPublisher.PublishMessageEvent(ctx, event); err != nil {
+				s.log(ctx).Error("Failed to publish message read event", err)
+			}
+		}
+	}
+}
+
+// attachReadStatus completa Read con los acuses del usuario que lista los mensajes. Si no se pueden
+// cargar los mensajes se devuelven sin el campo, como con los adjuntos
+func (s *messagingService) attachReadStatus(ctx context.Context, conversationID string, messages []domain.Message, userID string) {
+	if s.messageReadRepo == nil || len(messages) == 0 {
+		return
+	}
+
+	status, err := s.messageReadRepo.GetReadStatus(ctx, conversationID, userID)
+	if err != nil {
+		s.log(ctx).Error("Failed to load read status for messages", err)
+		return
+	}
+
+	for i := range messages {
+		_, read := status[messages[i].ID]
+		messages[i].Read = &read
+	}
+}
+
+// attachReadBy carga los acuses de lectura de todos los mensajes con una sola consulta
+func (s *messagingService) attachReadBy(ctx context.Context, messages []domain.Message) error {
+	if s.messageReadRepo == nil || len(messages) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(messages))
+	for i := range messages {
+		ids[i] = messages[i].ID
+	}
+
+	reads, err := s.messageReadRepo.GetByMessageIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get message reads: %w", err)
+	}
+
+	for i := range messages {
+		messages[i].ReadBy = reads[messages[i].ID]
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockMessageReadRepository es un mock del repositorio de acuses de lectura
+type MockMessageReadRepository struct {
+	mock.Mock
+}
+
+func (m *MockMessageReadRepository) MarkRead(ctx context.Context, conversationID string, userID string, messageIDs []string, readAt time.Time) ([]string, error) {
+	args := m.Called(ctx, conversationID, userID, messageIDs, readAt)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockMessageReadRepository) MarkReadUpTo(ctx context.Context, conversationID string, userID string, upToMessageID string, readAt time.Time) ([]string, error) {
+	args := m.Called(ctx, conversationID, userID, upToMessageID, readAt)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockMessageReadRepository) GetReadStatus(ctx context.Context, conversationID string, userID string) (map[string]time.Time, error) {
+	args := m.Called(ctx, conversationID, userID)
+	return args.Get(0).(map[string]time.Time), args.Error(1)
+}
+
+func (m *MockMessageReadRepository) GetByMessageIDs(ctx context.Context, messageIDs []string) (map[string][]domain.MessageRead, error) {
+	args := m.Called(ctx, messageIDs)
+	return args.Get(0).(map[string][]domain.MessageRead), args.Error(1)
+}
+
+func TestMessagingService_MarkMessageRead(t *testing.T) {
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockReadRepo := new(MockMessageReadRepository)
+	publisher := &recordingEventPublisher{}
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		new(MockAttachmentRepository),
+		publisher,
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithMessageReadRepository(mockReadRepo),
+	)
+
+	mockMessageRepo.On("GetByID", mock.Anything, "msg123").Return(&domain.Message{ID: "msg123", ConversationID: "conv123"}, nil)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockReadRepo.On("MarkRead", mock.Anything, "conv123", "user123", []string{"msg123"}, mock.Anything).Return([]string{"msg123"}, nil).Once()
+	mockReadRepo.On("MarkRead", mock.Anything, "conv123", "user123", []string{"msg123"}, mock.Anything).Return([]string(nil), nil).Once()
+
+	// First read publishes the receipt
+	assert.NoError(t, service.MarkMessageRead(context.Background(), "msg123", "user123"))
+	assert.Len(t, publisher.events, 1)
+	assert.Equal(t, "message.read", publisher.events[0].Type)
+	assert.Equal(t, "user123", publisher.events[0].UserID)
+	assert.Equal(t, "msg123", publisher.events[0].Message.ID)
+
+	// Reading it again is a no-op
+	assert.NoError(t, service.MarkMessageRead(context.Background(), "msg123", "user123"))
+	assert.Len(t, publisher.events, 1)
+}
+
+func TestMessagingService_MarkMessagesRead_Validation(t *testing.T) {
+	mockConversationRepo := new(MockConversationRepository)
+	mockReadRepo := new(MockMessageReadRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithMessageReadRepository(mockReadRepo),
+	)
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user456"}, nil)
+
+	assert.Error(t, service.MarkMessagesRead(context.Background(), "conv123", nil, "user123"))
+	assert.Error(t, service.MarkMessagesRead(context.Background(), "conv123", make([]string, maxMessageReadBatch+1), "user123"))
+	// Other users' conversations are rejected before writing
+	assert.Error(t, service.MarkMessagesRead(context.Background(), "conv123", []string{"msg1"}, "user123"))
+	mockReadRepo.AssertNotCalled(t, "MarkRead", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_GetMessages_IncludeReadBy(t *testing.T) {
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockReadRepo := new(MockMessageReadRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		mockAttachmentRepo,
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithMessageReadRepository(mockReadRepo),
+	)
+
+	pagination := domain.PaginationParams{Limit: 50, IncludeReadBy: true}
+	readAt := time.Now()
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("GetByConversationID", mock.Anything, "conv123", pagination).Return([]domain.Message{{ID: "msg1"}, {ID: "msg2"}}, nil)
+	mockMessageRepo.On("CountReplies", mock.Anything, mock.Anything).Return(map[string]int{}, nil)
+	mockAttachmentRepo.On("GetByMessageID", mock.Anything, mock.Anything).Return([]domain.Attachment{}, nil)
+	mockReadRepo.On("GetByMessageIDs", mock.Anything, []string{"msg1", "msg2"}).Return(map[string][]domain.MessageRead{
+		"msg1": {{MessageID: "msg1", UserID: "agent1", ReadAt: readAt}},
+	}, nil)
+	mockReadRepo.On("GetReadStatus", mock.Anything, "conv123", "user123").Return(map[string]time.Time{"msg2": readAt}, nil)
+
+	messages, err := service.GetMessages(context.Background(), "conv123", "user123", pagination)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.MessageRead{{MessageID: "msg1", UserID: "agent1", ReadAt: readAt}}, messages[0].ReadBy)
+	assert.Empty(t, messages[1].ReadBy)
+	mockReadRepo.AssertNumberOfCalls(t, "GetByMessageIDs", 1)
+
+	// read reflects the requesting user's own receipts, not other readers'
+	require.NotNil(t, messages[0].Read)
+	assert.False(t, *messages[0].Read)
+	require.NotNil(t, messages[1].Read)
+	assert.True(t, *messages[1].Read)
+}
+
+func TestMessagingService_MarkConversationReadUpTo(t *testing.T) {
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockReadRepo := new(MockMessageReadRepository)
+	publisher := &recordingEventPublisher{}
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		new(MockAttachmentRepository),
+		publisher,
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithMessageReadRepository(mockReadRepo),
+	)
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("GetByID", mock.Anything, "msg3").Return(&domain.Message{ID: "msg3", ConversationID: "conv123"}, nil)
+	mockReadRepo.On("MarkReadUpTo", mock.Anything, "conv123", "user123", "msg3", mock.Anything).Return([]string{"msg1", "msg2", "msg3"}, nil).Once()
+	mockReadRepo.On("MarkReadUpTo", mock.Anything, "conv123", "user123", "msg3", mock.Anything).Return([]string(nil), nil).Once()
+
+	// The whole batch is marked with one event per newly read message
+	require.NoError(t, service.MarkConversationReadUpTo(context.Background(), "conv123", "msg3", "user123"))
+	require.Len(t, publisher.events, 3)
+	for i, messageID := range []string{"msg1", "msg2", "msg3"} {
+		assert.Equal(t, "message.read", publisher.events[i].Type)
+		assert.Equal(t, messageID, publisher.events[i].Message.ID)
+		assert.Equal(t, "user123", publisher.events[i].UserID)
+	}
+
+	// Marking the same range again publishes nothing
+	require.NoError(t, service.MarkConversationReadUpTo(context.Background(), "conv123", "msg3", "user123"))
+	assert.Len(t, publisher.events, 3)
+}
+
+func TestMessagingService_MarkConversationReadUpTo_MessageFromAnotherConversation(t *testing.T) {
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockReadRepo := new(MockMessageReadRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithMessageReadRepository(mockReadRepo),
+	)
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("GetByID", mock.Anything, "msg9").Return(&domain.Message{ID: "msg9", ConversationID: "conv456"}, nil)
+
+	err := service.MarkConversationReadUpTo(context.Background(), "conv123", "msg9", "user123")
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	mockReadRepo.AssertNotCalled(t, "MarkReadUpTo", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// maxMessageSearchLength acota el texto buscado en el contenido de los mensajes
+const maxMessageSearchLength = 255
+
+// SearchMessages busca por texto completo en los mensajes de las conversaciones del usuario, de más
+// relevante a menos. No distingue mayúsculas y los resultados incluyen sus adjuntos
+func (s *messagingService) SearchMessages(ctx context.Context, userID string, query string, pagination domain.PaginationParams) ([]domain.Message, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("%w: search query is required", ErrInvalidMessageFilter)
+	}
+	if utf8.RuneCountInString(query) > maxMessageSearchLength {
+		return nil, fmt.Errorf("%w: search query cannot exceed %d characters", ErrInvalidMessageFilter, maxMessageSearchLength)
+	}
+
+	messages, err := s.messageRepo.Search(ctx, userID, query, pagination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	s.loadAttachments(ctx, messages)
+
+	return messages, nil
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessagingService_SearchMessages(t *testing.T) {
+	// Setup
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+
+	service := NewMessagingService(
+		new(MockConversationRepository),
+		mockMessageRepo,
+		mockAttachmentRepo,
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	pagination := domain.PaginationParams{Limit: 20}
+	mockMessageRepo.On("Search", mock.Anything, "user123", "factura", pagination).Return([]domain.Message{{ID: "msg1"}}, nil)
+	mockAttachmentRepo.On("GetByMessageID", mock.Anything, "msg1").Return([]domain.Attachment{{ID: "att1"}}, nil)
+
+	// Execute: the query is trimmed and scoped to the caller
+	messages, err := service.SearchMessages(context.Background(), "user123", "  factura ", pagination)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Len(t, messages[0].Attachments, 1)
+
+	// Blank queries are rejected before hitting the repository
+	_, err = service.SearchMessages(context.Background(), "user123", "   ", pagination)
+	assert.ErrorIs(t, err, ErrInvalidMessageFilter)
+	mockMessageRepo.AssertNumberOfCalls(t, "Search", 1)
+}
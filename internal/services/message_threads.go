@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// ErrInvalidReply indica una respuesta a un mensaje que no existe o que es de otra conversación
+var ErrInvalidReply = errors.New("invalid reply")
+
+// GetThread devuelve el mensaje con sus respuestas directas en orden cronológico
+func (s *messagingService) GetThread(ctx context.Context, messageID string, userID string) (*domain.MessageThread, error) {
+	parent, err := s.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	// Verify user has access to the conversation
+	if _, err := s.GetConversation(ctx, parent.ConversationID, userID); err != nil {
+		return nil, err
+	}
+
+	replies, err := s.messageRepo.GetReplies(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get replies: %w", err)
+	}
+
+	messages := append([]domain.Message{*parent}, replies...)
+	s.loadAttachments(ctx, messages)
+	s.loadReactionCounts(ctx, messages)
+	s.loadReplyCounts(ctx, messages)
+
+	return &domain.MessageThread{
+		Parent:  messages[0],
+		Replies: messages[1:],
+	}, nil
+}
+
+// validateReplyTo comprueba que el mensaje al que se responde existe y es de la misma conversación
+func (s *messagingService) validateReplyTo(ctx context.Context, req SendMessageRequest) error {
+	if req.ReplyToID == nil {
+		return nil
+	}
+
+	parent, err := s.messageRepo.GetByID(ctx, *req.ReplyToID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("%w: message %q not found", ErrInvalidReply, *req.ReplyToID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get replied message: %w", err)
+	}
+	if parent.ConversationID != req.ConversationID {
+		return fmt.Errorf("%w: message %q belongs to another conversation", ErrInvalidReply, *req.ReplyToID)
+	}
+
+	return nil
+}
+
+// loadReplyCounts agrega a cada mensaje su número de respuestas directas con una sola consulta; un fallo
+// se registra y deja los mensajes sin contadores
+func (s *messagingService) loadReplyCounts(ctx context.Context, messages []domain.Message) {
+	if len(messages) == 0 {
+		return
+	}
+
+	ids := make([]string, len(messages))
+	for i := range messages {
+		ids[i] = messages[i].ID
+	}
+
+	counts, err := s.messageRepo.CountReplies(ctx, ids)
+	if err != nil {
+		s.log(ctx).Error("Failed to load reply counts for messages", err)
+		return
+	}
+
+	for i := range messages {
+		messages[i].ReplyCount = counts[messages[i].ID]
+	}
+}
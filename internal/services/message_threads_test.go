@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func replyRequest(replyToID string) SendMessageRequest {
+	return SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "user123",
+		Content:        "Respuesta",
+		ContentType:    domain.ContentTypeText,
+		ReplyToID:      &replyToID,
+	}
+}
+
+func TestMessagingService_SendMessage_Reply(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo})
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123", Status: domain.ConversationStatusActive}, nil)
+	mockConversationRepo.On("TouchUpdatedAt", mock.Anything, "conv123", mock.Anything).Return(nil)
+	mockMessageRepo.On("GetByID", mock.Anything, "msg1").Return(&domain.Message{ID: "msg1", ConversationID: "conv123"}, nil)
+	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+
+	// Execute
+	message, err := service.SendMessage(context.Background(), replyRequest("msg1"))
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, message.ReplyToID)
+	assert.Equal(t, "msg1", *message.ReplyToID)
+}
+
+func TestMessagingService_SendMessage_ReplyAcrossConversationsRejected(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo})
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123", Status: domain.ConversationStatusActive}, nil)
+	mockMessageRepo.On("GetByID", mock.Anything, "msg9").Return(&domain.Message{ID: "msg9", ConversationID: "conv456"}, nil)
+	mockMessageRepo.On("GetByID", mock.Anything, "missing").Return((*domain.Message)(nil), domain.ErrNotFound)
+
+	// Execute & Assert: neither a message from another conversation nor an unknown one can be replied to
+	_, err := service.SendMessage(context.Background(), replyRequest("msg9"))
+	assert.ErrorIs(t, err, ErrInvalidReply)
+
+	_, err = service.SendMessage(context.Background(), replyRequest("missing"))
+	assert.ErrorIs(t, err, ErrInvalidReply)
+
+	mockMessageRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_GetThread(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo, attachmentRepo: mockAttachmentRepo})
+
+	now := time.Now()
+	parentID := "msg1"
+	mockMessageRepo.On("GetByID", mock.Anything, "msg1").Return(&domain.Message{ID: "msg1", ConversationID: "conv123", Timestamp: now}, nil)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("GetReplies", mock.Anything, "msg1").Return([]domain.Message{
+		{ID: "msg2", ConversationID: "conv123", ReplyToID: &parentID, Timestamp: now.Add(time.Minute)},
+		{ID: "msg3", ConversationID: "conv123", ReplyToID: &parentID, Timestamp: now.Add(2 * time.Minute)},
+	}, nil)
+	mockMessageRepo.On("CountReplies", mock.Anything, []string{"msg1", "msg2", "msg3"}).Return(map[string]int{"msg1": 2, "msg3": 1}, nil)
+	mockAttachmentRepo.On("GetByMessageID", mock.Anything, mock.Anything).Return([]domain.Attachment{}, nil)
+
+	// Execute
+	thread, err := service.GetThread(context.Background(), "msg1", "user123")
+
+	// Assert: the parent comes first and the replies keep their chronological order
+	require.NoError(t, err)
+	assert.Equal(t, "msg1", thread.Parent.ID)
+	assert.Equal(t, 2, thread.Parent.ReplyCount)
+	require.Len(t, thread.Replies, 2)
+	assert.Equal(t, "msg2", thread.Replies[0].ID)
+	assert.Equal(t, "msg3", thread.Replies[1].ID)
+	assert.Equal(t, 1, thread.Replies[1].ReplyCount)
+}
+
+func TestMessagingService_GetThread_AccessDenied(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo})
+
+	mockMessageRepo.On("GetByID", mock.Anything, "msg1").Return(&domain.Message{ID: "msg1", ConversationID: "conv123"}, nil)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "owner"}, nil)
+
+	// Execute
+	_, err := service.GetThread(context.Background(), "msg1", "intruder")
+
+	// Assert
+	require.Error(t, err)
+	mockMessageRepo.AssertNotCalled(t, "GetReplies", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// Acciones de auditoría de las operaciones que modifican conversaciones y sus adjuntos
+const (
+	AuditActionConversationCreate            = "CONVERSATION_CREATE"
+	AuditActionConversationStatusUpdate      = "CONVERSATION_STATUS_UPDATE"
+	AuditActionConversationParticipantAdd    = "CONVERSATION_PARTICIPANT_ADD"
+	AuditActionConversationParticipantRemove = "CONVERSATION_PARTICIPANT_REMOVE"
+	AuditActionAttachmentDelete              = "ATTACHMENT_DELETE"
+)
+
+// newAuditLog crea una entrada de auditoría con la IP y el User-Agent de la petición del contexto
+func newAuditLog(ctx context.Context, userID string, action string, resource string, details map[string]interface{}) *domain.AuditLog {
+	client := auth.ClientInfoFromContext(ctx)
+
+	return &domain.AuditLog{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Action:    action,
+		Resource:  resource,
+		Details:   details,
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		CreatedAt: time.Now(),
+	}
+}
+
+type auditingMessagingService struct {
+	MessagingService
+	auditRepo domain.AuditRepository
+	logger    logger.Logger
+}
+
+// NewAuditingMessagingService registra en auditRepo quién creó una conversación, cambió su estado o
+// sus participantes o borró un adjunto, y desde dónde. Solo se auditan las operaciones que terminan bien; si la auditoría
+// no se puede escribir, la operación ya hecha se mantiene y el fallo queda en el log
+func NewAuditingMessagingService(service MessagingService, auditRepo domain.AuditRepository, logger logger.Logger) MessagingService {
+	return &auditingMessagingService{
+		MessagingService: service,
+		auditRepo:        auditRepo,
+		logger:           logger,
+	}
+}
+
+func (s *auditingMessagingService) record(ctx context.Context, userID string, action string, resource string, details map[string]interface{}) {
+	if err := s.auditRepo.Create(ctx, newAuditLog(ctx, userID, action, resource, details)); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to record audit log", err, map[string]interface{}{
+			"action":   action,
+			"resource": resource,
+		})
+	}
+}
+
+func (s *auditingMessagingService) CreateConversation(ctx context.Context, userID string, channel domain.Channel, metadata domain.JSONB) (*domain.Conversation, error) {
+	conversation, err := s.MessagingService.CreateConversation(ctx, userID, channel, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, userID, AuditActionConversationCreate, "conversation:"+conversation.ID, map[string]interface{}{
+		"channel": channel,
+	})
+	return conversation, nil
+}
+
+func (s *auditingMessagingService) UpdateConversationStatus(ctx context.Context, id string, status domain.ConversationStatus, userID string) error {
+	if err := s.MessagingService.UpdateConversationStatus(ctx, id, status, userID); err != nil {
+		return err
+	}
+
+	s.record(ctx, userID, AuditActionConversationStatusUpdate, "conversation:"+id, map[string]interface{}{
+		"status": status,
+	})
+	return nil
+}
+
+func (s *auditingMessagingService) UpdateConversationStatusBulk(ctx context.Context, conversationIDs []string, status domain.ConversationStatus, userID string) (*BulkStatusResult, error) {
+	result, err := s.MessagingService.UpdateConversationStatusBulk(ctx, conversationIDs, status, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the conversations whose status actually changed
+	for _, item := range result.Results {
+		if item.Result != domain.ConversationStatusOutcomeUpdated {
+			continue
+		}
+		s.record(ctx, userID, AuditActionConversationStatusUpdate, "conversation:"+item.ConversationID, map[string]interface{}{
+			"status": status,
+			"bulk":   true,
+		})
+	}
+	return result, nil
+}
+
+func (s *auditingMessagingService) AddParticipant(ctx context.Context, conversationID string, req AddParticipantRequest, userID string) (*domain.ConversationParticipant, error) {
+	participant, err := s.MessagingService.AddParticipant(ctx, conversationID, req, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, userID, AuditActionConversationParticipantAdd, "conversation:"+conversationID, map[string]interface{}{
+		"participant_id": participant.UserID,
+		"role":           participant.Role,
+	})
+	return participant, nil
+}
+
+func (s *auditingMessagingService) RemoveParticipant(ctx context.Context, conversationID string, participantID string, userID string) error {
+	if err := s.MessagingService.RemoveParticipant(ctx, conversationID, participantID, userID); err != nil {
+		return err
+	}
+
+	s.record(ctx, userID, AuditActionConversationParticipantRemove, "conversation:"+conversationID, map[string]interface{}{
+		"participant_id": participantID,
+	})
+	return nil
+}
+
+func (s *auditingMessagingService) DeleteAttachment(ctx context.Context, attachmentID string, userID string) error {
+	if err := s.MessagingService.DeleteAttachment(ctx, attachmentID, userID); err != nil {
+		return err
+	}
+
+	s.record(ctx, userID, AuditActionAttachmentDelete, "attachment:"+attachmentID, map[string]interface{}{})
+	return nil
+}
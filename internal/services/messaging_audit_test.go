@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditingMessagingService_UpdateConversationStatus(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockAuditRepo := new(MockAuditRepository)
+	service := NewAuditingMessagingService(
+		newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo}, WithAuditRepository(mockAuditRepo)),
+		mockAuditRepo,
+		logger.NewLogger("debug"),
+	)
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv1").Return(&domain.Conversation{
+		ID:     "conv1",
+		UserID: "agent1",
+		Status: domain.ConversationStatusActive,
+	}, nil)
+	mockConversationRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Conversation")).Return(nil)
+
+	var recorded *domain.AuditLog
+	mockAuditRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.AuditLog")).Run(func(args mock.Arguments) {
+		recorded = args.Get(1).(*domain.AuditLog)
+	}).Return(nil)
+
+	ctx := auth.WithClientInfo(context.Background(), auth.ClientInfo{IPAddress: "203.0.113.7", UserAgent: "admin-console/2.1"})
+
+	// Execute
+	err := service.UpdateConversationStatus(ctx, "conv1", domain.ConversationStatusClosed, "agent1")
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, recorded)
+	assert.NotEmpty(t, recorded.ID)
+	assert.Equal(t, "agent1", recorded.UserID)
+	assert.Equal(t, AuditActionConversationStatusUpdate, recorded.Action)
+	assert.Equal(t, "conversation:conv1", recorded.Resource)
+	assert.Equal(t, domain.ConversationStatusClosed, recorded.Details["status"])
+	assert.Equal(t, "203.0.113.7", recorded.IPAddress)
+	assert.Equal(t, "admin-console/2.1", recorded.UserAgent)
+	assert.False(t, recorded.CreatedAt.IsZero())
+	mockAuditRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestAuditingMessagingService_FailedOperationNotAudited(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockAuditRepo := new(MockAuditRepository)
+	service := NewAuditingMessagingService(
+		newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo}, WithAuditRepository(mockAuditRepo)),
+		mockAuditRepo,
+		logger.NewLogger("debug"),
+	)
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv1").Return(&domain.Conversation{
+		ID:     "conv1",
+		UserID: "agent1",
+		Status: domain.ConversationStatusActive,
+	}, nil)
+	mockConversationRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Conversation")).Return(errors.New("connection reset"))
+
+	// Execute
+	err := service.UpdateConversationStatus(context.Background(), "conv1", domain.ConversationStatusClosed, "agent1")
+
+	// Assert
+	require.Error(t, err)
+	mockAuditRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_ListAuditLogs(t *testing.T) {
+	// Setup
+	mockAuditRepo := new(MockAuditRepository)
+	service := NewAuditingMessagingService(newTestMessagingService(testServiceDeps{}, WithAuditRepository(mockAuditRepo)), mockAuditRepo, logger.NewLogger("debug"))
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	mockAuditRepo.On("List", mock.Anything, domain.AuditLogFilters{
+		Action: AuditActionConversationCreate,
+		From:   from,
+		To:     to,
+		Limit:  maxAuditLogsPage,
+	}).Return([]*domain.AuditLog{{ID: "audit1", UserID: "agent1", Action: AuditActionConversationCreate}}, nil)
+
+	// Execute
+	logs, err := service.ListAuditLogs(context.Background(), domain.AuditLogFilters{
+		Action: AuditActionConversationCreate,
+		From:   from,
+		To:     to,
+		Limit:  1000,
+		Offset: -5,
+	})
+
+	// Assert: the page size is capped and the filters reach the repository unchanged
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "audit1", logs[0].ID)
+
+	// An empty or inverted range is rejected
+	_, err = service.ListAuditLogs(context.Background(), domain.AuditLogFilters{From: to, To: from})
+	assert.ErrorIs(t, err, ErrInvalidAuditFilter)
+	_, err = service.ListAuditLogs(context.Background(), domain.AuditLogFilters{From: from, To: from})
+	assert.ErrorIs(t, err, ErrInvalidAuditFilter)
+}
@@ -2,54 +2,191 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/company/microservice-template/internal/auth"
 	"github.com/company/microservice-template/internal/domain"
 	"github.com/company/microservice-template/pkg/logger"
 	"github.com/google/uuid"
 )
 
+// ErrInvalidMessageFilter indica que los filtros de la consulta de mensajes no son válidos
+var ErrInvalidMessageFilter = errors.New("invalid message filter")
+
+// ErrInvalidAttachmentFilter indica que los filtros de la consulta de adjuntos no son válidos
+var ErrInvalidAttachmentFilter = errors.New("invalid attachment filter")
+
 type MessagingService interface {
 	// Conversations
-	CreateConversation(ctx context.Context, userID string, channel domain.Channel) (*domain.Conversation, error)
+	CreateConversation(ctx context.Context, userID string, channel domain.Channel, metadata domain.JSONB) (*domain.Conversation, error)
 	GetConversation(ctx context.Context, id string, userID string) (*domain.Conversation, error)
+	GetConversationByReference(ctx context.Context, reference string, userID string) (*domain.Conversation, error)
+	AddTagToConversations(ctx context.Context, conversationIDs []string, tag string, userID string) (*BulkTagResult, error)
+	AddTag(ctx context.Context, conversationID string, tag string, userID string) ([]string, error)
+	RemoveTag(ctx context.Context, conversationID string, tag string, userID string) ([]string, error)
+	ListTags(ctx context.Context, conversationID string, userID string) ([]string, error)
+	UpdateConversationMetadata(ctx context.Context, conversationID string, patch domain.JSONB, userID string) (domain.JSONB, error)
 	GetConversations(ctx context.Context, userID string, filters domain.ConversationFilters) ([]domain.Conversation, error)
 	UpdateConversationStatus(ctx context.Context, id string, status domain.ConversationStatus, userID string) error
+	UpdateConversationStatusBulk(ctx context.Context, conversationIDs []string, status domain.ConversationStatus, userID string) (*BulkStatusResult, error)
+	MarkConversationRead(ctx context.Context, conversationID string, userID string, onBehalfOf string) error
+	AddParticipant(ctx context.Context, conversationID string, req AddParticipantRequest, userID string) (*domain.ConversationParticipant, error)
+	RemoveParticipant(ctx context.Context, conversationID string, participantID string, userID string) error
+	ListParticipants(ctx context.Context, conversationID string, userID string) ([]domain.ConversationParticipant, error)
+	AcquireConversationLock(ctx context.Context, conversationID string, agentID string, ttl time.Duration) (*domain.ConversationLock, error)
+	ReleaseConversationLock(ctx context.Context, conversationID string, agentID string) error
 	
 	// Messages
 	SendMessage(ctx context.Context, req SendMessageRequest) (*domain.Message, error)
 	GetMessages(ctx context.Context, conversationID string, userID string, pagination domain.PaginationParams) ([]domain.Message, error)
 	GetMessage(ctx context.Context, messageID string, userID string) (*domain.Message, error)
+	GetMessagesAround(ctx context.Context, conversationID string, messageID string, userID string, before int, after int) ([]domain.Message, error)
+	GetThread(ctx context.Context, messageID string, userID string) (*domain.MessageThread, error)
+	CountMessages(ctx context.Context, conversationID string, userID string) (*domain.ConversationMessageCount, error)
+	ExportConversation(ctx context.Context, conversationID string, userID string, opts ExportOptions, w ExportWriter) error
+	ScheduleMessage(ctx context.Context, req SendMessageRequest) (*domain.ScheduledMessage, error)
+	CancelScheduledMessage(ctx context.Context, conversationID string, scheduledMessageID string, userID string) error
+	MarkMessageRead(ctx context.Context, messageID string, userID string) error
+	MarkMessagesRead(ctx context.Context, conversationID string, messageIDs []string, userID string) error
+	MarkConversationReadUpTo(ctx context.Context, conversationID string, upToMessageID string, userID string) error
+	SetTyping(ctx context.Context, conversationID string, userID string, role domain.TypingRole, isTyping bool) (*domain.TypingEvent, error)
+	PinMessage(ctx context.Context, messageID string, userID string) (*domain.Message, error)
+	UnpinMessage(ctx context.Context, messageID string, userID string) (*domain.Message, error)
+	EditMessage(ctx context.Context, messageID string, userID string, newContent string) (*domain.Message, error)
+	SearchConversations(ctx context.Context, userID string, criteria ConversationSearchCriteria, pagination domain.PaginationParams) ([]domain.Conversation, error)
+	SearchMessages(ctx context.Context, userID string, query string, pagination domain.PaginationParams) ([]domain.Message, error)
+
+	// Drafts
+	SaveDraft(ctx context.Context, conversationID string, req SaveDraftRequest, userID string) (*domain.MessageDraft, error)
+	GetDraft(ctx context.Context, conversationID string, userID string) (*domain.MessageDraft, error)
+	DeleteDraft(ctx context.Context, conversationID string, userID string) error
+
+	// Share links
+	CreateShareLink(ctx context.Context, conversationID string, userID string, ttl time.Duration, readOnly bool) (*domain.ConversationShareLink, error)
+	ListShareLinks(ctx context.Context, conversationID string, userID string) ([]domain.ConversationShareLink, error)
+	RevokeShareLink(ctx context.Context, conversationID string, linkID string, userID string) error
+	GetSharedConversation(ctx context.Context, token string, pagination domain.PaginationParams) (*domain.SharedConversation, error)
 	
 	// Attachments
 	CreateAttachment(ctx context.Context, messageID string, req CreateAttachmentRequest) (*domain.Attachment, error)
 	GetAttachment(ctx context.Context, attachmentID string, userID string) (*domain.Attachment, error)
+	DeleteAttachment(ctx context.Context, attachmentID string, userID string) error
+	GetUserAttachments(ctx context.Context, userID string, attachmentType domain.AttachmentType, pagination domain.PaginationParams) ([]domain.Attachment, error)
+	SearchAttachments(ctx context.Context, conversationID string, userID string, query string, pagination domain.PaginationParams) ([]domain.Attachment, error)
+
+	// Reactions
+	GetReactions(ctx context.Context, messageID string, userID string) ([]domain.ReactionGroup, error)
+	AddReaction(ctx context.Context, messageID string, userID string, emoji string) (*domain.Reaction, bool, error)
+	RemoveReaction(ctx context.Context, messageID string, userID string, emoji string) error
+
+	// Templates
+	CreateTemplate(ctx context.Context, req ConversationTemplateRequest, userID string) (*domain.ConversationTemplate, error)
+	GetTemplate(ctx context.Context, id string) (*domain.ConversationTemplate, error)
+	ListTemplates(ctx context.Context, pagination domain.PaginationParams) ([]domain.ConversationTemplate, error)
+	UpdateTemplate(ctx context.Context, id string, req ConversationTemplateRequest, userID string) (*domain.ConversationTemplate, error)
+	DeleteTemplate(ctx context.Context, id string, userID string) error
+	CreateConversationFromTemplate(ctx context.Context, userID string, templateID string, vars map[string]string) (*domain.Conversation, error)
+
+	// Webhooks
+	CreateWebhook(ctx context.Context, conversationID string, req ConversationWebhookRequest, userID string) (*domain.ConversationWebhook, error)
+	ListWebhooks(ctx context.Context, conversationID string, userID string) ([]domain.ConversationWebhook, error)
+	DeleteWebhook(ctx context.Context, conversationID string, webhookID string, userID string) error
+	ListWebhookDeliveries(ctx context.Context, conversationID string, webhookID string, filters domain.WebhookDeliveryFilters, userID string) ([]domain.WebhookDelivery, error)
+	ReplayWebhookDelivery(ctx context.Context, conversationID string, webhookID string, deliveryID string, userID string) (*domain.WebhookDelivery, error)
+
+	// Administration
+	PurgeUser(ctx context.Context, userID string, requestedBy string) (*domain.UserDataPurge, error)
+	TransferOwnership(ctx context.Context, req OwnershipTransferRequest, requestedBy string) (*domain.OwnershipTransfer, error)
+	ListAuditLogs(ctx context.Context, filters domain.AuditLogFilters) ([]*domain.AuditLog, error)
+	ListAllWebhookDeliveries(ctx context.Context, filters domain.WebhookDeliveryFilters) ([]domain.WebhookDelivery, error)
+	ProcessDeliveryReceipt(ctx context.Context, channel domain.Channel, receipt DeliveryReceipt) (*DeliveryReceiptResult, error)
+	UpdateDeliveryStatus(ctx context.Context, messageID string, status domain.DeliveryStatus) (*domain.Message, error)
 }
 
 type messagingService struct {
-	conversationRepo domain.ConversationRepository
-	messageRepo      domain.MessageRepository
-	attachmentRepo   domain.AttachmentRepository
-	eventPublisher   EventPublisher
-	cacheService     CacheService
-	logger           logger.Logger
+	conversationRepo    domain.ConversationRepository
+	messageRepo         domain.MessageRepository
+	attachmentRepo      domain.AttachmentRepository
+	eventPublisher      EventPublisher
+	cacheService        CacheService
+	reactionRepo        domain.ReactionRepository
+	auditRepo           domain.AuditRepository
+	fileService         FileService
+	templateRepo        domain.ConversationTemplateRepository
+	participantRepo     domain.ParticipantRepository
+	messageReadRepo     domain.MessageReadRepository
+	webhookRepo         domain.WebhookRepository
+	webhookDeliveryRepo domain.WebhookDeliveryRepository
+	webhookDeliverer    *WebhookDeliverer
+	draftRepo           domain.DraftRepository
+	draftMaxLength      int
+	scheduledRepo       domain.ScheduledMessageRepository
+	inboundDedup        bool
+	shareLinks          *shareLinks
+	channelDelivery     *channelDelivery
+	locks               *conversationLocks
+	idempotency         *idempotencyKeys
+	outbox              *OutboxRelay
+	autoResponder       *AutoResponder
+	references          *conversationReferences
+	contentRules        ContentRules
+	exportPIIRedactor   *PIIRedactor
+	sendHooks           []SendHook
+	hookPlacements      []sendHookPlacement
+	statusThrottle      statusChangeThrottle
+	txManager           domain.TxManager
+	logger              logger.Logger
+}
+
+// MessagingServiceOption configura dependencias opcionales del servicio
+type MessagingServiceOption func(*messagingService)
+
+// WithReactionRepository habilita las reacciones a mensajes
+func WithReactionRepository(repo domain.ReactionRepository) MessagingServiceOption {
+	return func(s *messagingService) {
+		s.reactionRepo = repo
+	}
+}
+
+// WithAuditRepository registra auditoría de las operaciones administrativas
+func WithAuditRepository(repo domain.AuditRepository) MessagingServiceOption {
+	return func(s *messagingService) {
+		s.auditRepo = repo
+	}
+}
+
+// WithFileService permite eliminar los archivos físicos de los adjuntos
+func WithFileService(fileService FileService) MessagingServiceOption {
+	return func(s *messagingService) {
+		s.fileService = fileService
+	}
 }
 
 type SendMessageRequest struct {
-	ConversationID string                 `json:"conversation_id" binding:"required"`
-	SenderType     domain.SenderType      `json:"sender_type" binding:"required"`
-	SenderID       string                 `json:"sender_id" binding:"required"`
-	Content        string                 `json:"content" binding:"required"`
-	ContentType    domain.ContentType     `json:"content_type" binding:"required"`
-	Metadata       map[string]interface{} `json:"metadata,omitempty"`
+	ConversationID string                    `json:"conversation_id" binding:"required"`
+	SenderType     domain.SenderType         `json:"sender_type"`
+	SenderID       string                    `json:"sender_id" binding:"required"`
+	Content        string                    `json:"content"` // Obligatorio u opcional según ContentRules
+	ContentType    domain.ContentType        `json:"content_type" binding:"required"`
+	Metadata       map[string]interface{}    `json:"metadata,omitempty"`
+	ExpiresAt      *time.Time                `json:"expires_at,omitempty"`
+	Attachments    []CreateAttachmentRequest `json:"attachments,omitempty" binding:"dive"` // Archivos ya subidos que acompañan al mensaje
+	DeliveryMode   DeliveryMode              `json:"delivery_mode,omitempty"`              // async (por defecto) o sync
+	ReplyToID      *string                   `json:"reply_to_id,omitempty"`                // Mensaje de la conversación al que responde
+	ScheduledAt    *time.Time                `json:"scheduled_at,omitempty"`               // Si es futuro, el mensaje se programa con ScheduleMessage
+	IdempotencyKey string                    `json:"-"`                                    // Cabecera Idempotency-Key; se guarda por remitente
 }
 
 type CreateAttachmentRequest struct {
-	URL      string                `json:"url" binding:"required"`
-	Type     domain.AttachmentType `json:"type" binding:"required"`
-	Size     int64                 `json:"size" binding:"required"`
-	Filename string                `json:"filename" binding:"required"`
+	URL          string                `json:"url" binding:"required"`
+	Type         domain.AttachmentType `json:"type" binding:"required"`
+	Size         int64                 `json:"size" binding:"required"`
+	Filename     string                `json:"filename" binding:"required"`
+	ThumbnailURL string                `json:"thumbnail_url,omitempty"` // La que devolvió la subida del archivo
 }
 
 func NewMessagingService(
@@ -59,38 +196,79 @@ func NewMessagingService(
 	eventPublisher EventPublisher,
 	cacheService CacheService,
 	logger logger.Logger,
+	opts ...MessagingServiceOption,
 ) MessagingService {
-	return &messagingService{
+	if eventPublisher != nil {
+		// Events published while serving a request carry its ID as correlation_id
+		eventPublisher = correlatingEventPublisher{eventPublisher}
+	}
+
+	s := &messagingService{
 		conversationRepo: conversationRepo,
 		messageRepo:      messageRepo,
 		attachmentRepo:   attachmentRepo,
 		eventPublisher:   eventPublisher,
 		cacheService:     cacheService,
+		contentRules:     DefaultContentRules,
 		logger:           logger,
 	}
+	s.sendHooks = s.defaultSendHooks()
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.placeSendHooks()
+
+	return s
+}
+
+// log devuelve el logger con el request_id y la traza de la petición del contexto, si la hay
+func (s *messagingService) log(ctx context.Context) logger.Logger {
+	return s.logger.WithContext(ctx)
 }
 
-func (s *messagingService) CreateConversation(ctx context.Context, userID string, channel domain.Channel) (*domain.Conversation, error) {
+func (s *messagingService) CreateConversation(ctx context.Context, userID string, channel domain.Channel, metadata domain.JSONB) (*domain.Conversation, error) {
+	if err := validateConversationMetadata(metadata); err != nil {
+		return nil, err
+	}
+
 	conversation := &domain.Conversation{
 		ID:        uuid.New().String(),
 		UserID:    userID,
 		Channel:   channel,
 		Status:    domain.ConversationStatusActive,
+		Metadata:  metadata,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
+	if err := s.assignReference(ctx, conversation); err != nil {
+		s.log(ctx).Error("Failed to create conversation", err)
+		return nil, err
+	}
+
 	if err := s.conversationRepo.Create(ctx, conversation); err != nil {
-		s.logger.Error("Failed to create conversation", err)
+		s.log(ctx).Error("Failed to create conversation", err)
 		return nil, fmt.Errorf("failed to create conversation: %w", err)
 	}
 
-	s.logger.Info("Conversation created", map[string]interface{}{
+	s.invalidateConversationList(ctx, userID)
+
+	s.log(ctx).Info("Conversation created", map[string]interface{}{
 		"conversation_id": conversation.ID,
+		"reference":       conversation.Reference,
 		"user_id":         userID,
 		"channel":         channel,
 	})
 
+	s.publishConversationCreated(ctx, conversation)
+
+	if s.autoResponder != nil {
+		if _, err := s.autoResponder.Respond(ctx, conversation); err != nil {
+			s.log(ctx).Error("Failed to send auto response", err)
+		}
+	}
+
 	return conversation, nil
 }
 
@@ -99,7 +277,8 @@ func (s *messagingService) GetConversation(ctx context.Context, id string, userI
 	if s.cacheService != nil {
 		if cached, err := s.cacheService.GetConversation(ctx, id); err == nil && cached != nil {
 			// Verify user ownership
-			if cached.UserID == userID {
+			if cached.UserID == userID || isTrustedService(ctx) {
+				s.attachConversationLock(ctx, cached)
 				return cached, nil
 			}
 		}
@@ -110,25 +289,52 @@ func (s *messagingService) GetConversation(ctx context.Context, id string, userI
 		return nil, fmt.Errorf("failed to get conversation: %w", err)
 	}
 
-	// Verify user ownership
-	if conversation.UserID != userID {
-		return nil, fmt.Errorf("conversation not found or access denied")
+	// Verify user ownership or participation; trusted internal services may act on any conversation
+	allowed, err := s.canAccessConversation(ctx, conversation, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, fmt.Errorf("conversation %w: %w", domain.ErrAccessDenied, domain.ErrNotFound)
 	}
 
 	// Cache the result
 	if s.cacheService != nil {
 		_ = s.cacheService.SetConversation(ctx, conversation)
 	}
+	s.attachConversationLock(ctx, conversation)
 
 	return conversation, nil
 }
 
 func (s *messagingService) GetConversations(ctx context.Context, userID string, filters domain.ConversationFilters) ([]domain.Conversation, error) {
+	tags, err := normalizeTagFilter(filters.Tags)
+	if err != nil {
+		return nil, err
+	}
+	filters.Tags = tags
+
+	cached, version, cacheable := s.cachedConversationList(ctx, userID, filters)
+	if cached != nil {
+		return cached, nil
+	}
+
 	conversations, err := s.conversationRepo.GetByUserID(ctx, userID, filters)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get conversations: %w", err)
 	}
 
+	if filters.IncludeReadState {
+		if err := s.attachReadStates(ctx, conversations); err != nil {
+			return nil, err
+		}
+	}
+
+	// Stored under the version read before the query, so a change made meanwhile isn't hidden
+	if cacheable {
+		_ = s.cacheService.SetConversationList(ctx, userID, version, filters, conversations)
+	}
+
 	return conversations, nil
 }
 
@@ -138,19 +344,44 @@ func (s *messagingService) UpdateConversationStatus(ctx context.Context, id stri
 		return err
 	}
 
-	conversation.Status = status
-	conversation.UpdatedAt = time.Now()
+	now := time.Now()
+	changed := conversation.Status != status
+	if changed {
+		if wait := s.statusThrottle.wait(conversation, now); wait > 0 {
+			return s.throttleStatusChange(ctx, conversation, status, userID, wait)
+		}
+		conversation.StatusChangedAt = &now
+	}
+
+	if changed && s.statusThrottle.minInterval > 0 {
+		// The interval is enforced again in the UPDATE so a concurrent change can't slip past it
+		applied, err := s.conversationRepo.UpdateStatusIfIdle(ctx, id, status, now.Add(-s.statusThrottle.minInterval), now)
+		if err != nil {
+			return fmt.Errorf("failed to update conversation: %w", err)
+		}
+		if !applied {
+			return s.throttleStatusChange(ctx, conversation, status, userID, s.statusThrottle.minInterval)
+		}
+	} else {
+		conversation.Status = status
+		conversation.UpdatedAt = now
 
-	if err := s.conversationRepo.Update(ctx, conversation); err != nil {
-		return fmt.Errorf("failed to update conversation: %w", err)
+		if err := s.conversationRepo.Update(ctx, conversation); err != nil {
+			return fmt.Errorf("failed to update conversation: %w", err)
+		}
 	}
 
 	// Invalidate cache
 	if s.cacheService != nil {
 		_ = s.cacheService.DeleteConversation(ctx, id)
 	}
+	s.invalidateConversationList(ctx, conversation.UserID)
+
+	if changed {
+		s.publishConversationStatusChanged(ctx, id, userID, status, now)
+	}
 
-	s.logger.Info("Conversation status updated", map[string]interface{}{
+	s.log(ctx).Info("Conversation status updated", map[string]interface{}{
 		"conversation_id": id,
 		"status":          status,
 		"user_id":         userID,
@@ -159,13 +390,91 @@ func (s *messagingService) UpdateConversationStatus(ctx context.Context, id stri
 	return nil
 }
 
-func (s *messagingService) SendMessage(ctx context.Context, req SendMessageRequest) (*domain.Message, error) {
+// conversationStatusEvents son los cambios de estado que se publican para que analítica y el CRM sigan
+// el ciclo de vida de la conversación
+var conversationStatusEvents = map[domain.ConversationStatus]string{
+	domain.ConversationStatusClosed:   "conversation.closed",
+	domain.ConversationStatusArchived: "conversation.archived",
+}
+
+// publishConversationStatusChanged avisa de que userID cerró o archivó la conversación; los demás estados
+// no publican nada
+func (s *messagingService) publishConversationStatusChanged(ctx context.Context, conversationID string, userID string, status domain.ConversationStatus, changedAt time.Time) {
+	eventType, ok := conversationStatusEvents[status]
+	if !ok {
+		return
+	}
+
+	s.publishConversationEvent(ctx, domain.MessageEvent{
+		Type:           eventType,
+		ConversationID: conversationID,
+		UserID:         userID,
+		Data:           domain.JSONB{"status": status},
+		Timestamp:      changedAt,
+	})
+}
+
+// publishConversationCreated avisa de una conversación nueva con su propietario, canal y estado inicial
+func (s *messagingService) publishConversationCreated(ctx context.Context, conversation *domain.Conversation) {
+	s.publishConversationEvent(ctx, domain.MessageEvent{
+		Type:           "conversation.created",
+		ConversationID: conversation.ID,
+		UserID:         conversation.UserID,
+		Data: domain.JSONB{
+			"status":  conversation.Status,
+			"channel": conversation.Channel,
+		},
+		Timestamp: conversation.CreatedAt,
+	})
+}
+
+func (s *messagingService) publishConversationEvent(ctx context.Context, event domain.MessageEvent) {
+	if s.eventPublisher == nil {
+		return
+	}
+
+	if err := s.eventPublisher.PublishMessageEvent(ctx, event); err != nil {
+		s.log(ctx).Error("Failed to publish "+event.Type+" event", err)
+	}
+}
+
+func (s *messagingService) SendMessage(ctx context.Context, req SendMessageRequest) (sent *domain.Message, err error) {
+	if !isValidDeliveryMode(req.DeliveryMode) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDeliveryMode, req.DeliveryMode)
+	}
+	if req.IsScheduled(time.Now()) {
+		return nil, fmt.Errorf("%w: a future scheduled_at goes through ScheduleMessage", ErrInvalidScheduledMessage)
+	}
+	if err := validateIdempotencyKey(req.IdempotencyKey); err != nil {
+		return nil, err
+	}
+
 	// Verify conversation exists and user has access
-	_, err := s.GetConversation(ctx, req.ConversationID, req.SenderID)
+	conversation, err := s.GetConversation(ctx, req.ConversationID, req.SenderID)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.validateReplyTo(ctx, req); err != nil {
+		return nil, err
+	}
+
+
+	replay, claimed, err := s.claimIdempotencyKey(ctx, req)
+	if err != nil || replay != nil {
+		return replay, err
+	}
+	if claimed {
+		defer func() { s.finishIdempotencyKey(ctx, req, sent) }()
+	}
+
+	providerMessageID := inboundProviderMessageID(req.Metadata)
+	if s.inboundDedup && providerMessageID != "" {
+		if existing, err := s.findInboundDuplicate(ctx, req.ConversationID, providerMessageID); err != nil || existing != nil {
+			return existing, err
+		}
+	}
+
 	message := &domain.Message{
 		ID:             uuid.New().String(),
 		ConversationID: req.ConversationID,
@@ -175,60 +484,260 @@ func (s *messagingService) SendMessage(ctx context.Context, req SendMessageReque
 		ContentType:    req.ContentType,
 		Metadata:       domain.JSONB(req.Metadata),
 		Timestamp:      time.Now(),
+		ExpiresAt:      req.ExpiresAt,
+		ReplyToID:      req.ReplyToID,
 	}
 
-	if err := s.messageRepo.Create(ctx, message); err != nil {
-		s.logger.Error("Failed to create message", err)
-		return nil, fmt.Errorf("failed to create message: %w", err)
+	send := &SendContext{
+		Request:      req,
+		Conversation: conversation,
+		Message:      message,
+	}
+
+	if err := s.runSendHooks(ctx, SendHookPrePersist, send); err != nil {
+		s.log(ctx).Warn("Message rejected by send hook", map[string]interface{}{
+			"conversation_id": req.ConversationID,
+			"sender_id":       req.SenderID,
+			"error":           err.Error(),
+		})
+		return nil, err
+	}
+
+	if err := s.verifyAttachmentFiles(ctx, req.SenderID, req.Attachments); err != nil {
+		return nil, err
+	}
+	for _, attachmentReq := range req.Attachments {
+		send.Message.Attachments = append(send.Message.Attachments, *newAttachment(send.Message.ID, attachmentReq))
 	}
 
-	// Publish message event
-	if s.eventPublisher != nil {
-		event := domain.MessageEvent{
-			Type:           "message.received",
-			ConversationID: message.ConversationID,
-			Message:        *message,
-			Timestamp:      time.Now(),
+	err = s.withinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.createMessage(ctx, send.Message); err != nil {
+			return err
 		}
-		
-		if err := s.eventPublisher.PublishMessageEvent(ctx, event); err != nil {
-			s.logger.Error("Failed to publish message event", err)
+		// The conversation's activity moves with the message or not at all
+		if err := s.conversationRepo.TouchUpdatedAt(ctx, req.ConversationID, send.Message.Timestamp); err != nil {
+			return fmt.Errorf("failed to update conversation: %w", err)
 		}
+		return s.enqueueMessageEvent(ctx, send)
+	})
+	if errors.Is(err, domain.ErrAlreadyExists) {
+		return s.duplicateInboundMessage(ctx, req.ConversationID, providerMessageID)
+	}
+	if err != nil {
+		s.log(ctx).Error("Failed to create message", err)
+		return nil, fmt.Errorf("failed to create message: %w", err)
+	}
+
+	// The cached conversation carries the message counter that was just incremented
+	if s.cacheService != nil {
+		_ = s.cacheService.DeleteConversation(ctx, req.ConversationID)
+		_ = s.cacheService.DeleteMessages(ctx, req.ConversationID)
 	}
+	s.invalidateConversationList(ctx, conversation.UserID)
 
-	s.logger.Info("Message sent", map[string]interface{}{
-		"message_id":      message.ID,
-		"conversation_id": message.ConversationID,
-		"sender_id":       message.SenderID,
-		"content_type":    message.ContentType,
+	s.runSendHooks(ctx, SendHookPostPersist, send)
+
+	s.log(ctx).Info("Message sent", map[string]interface{}{
+		"message_id":      send.Message.ID,
+		"conversation_id": send.Message.ConversationID,
+		"sender_id":       send.Message.SenderID,
+		"content_type":    send.Message.ContentType,
 	})
 
-	return message, nil
+	return send.Message, nil
+}
+
+// createMessage guarda el mensaje; si trae adjuntos, un adjunto que falla no deja el mensaje guardado sin él
+func (s *messagingService) createMessage(ctx context.Context, message *domain.Message) error {
+	if len(message.Attachments) == 0 {
+		return s.messageRepo.Create(ctx, message)
+	}
+	return s.messageRepo.CreateWithAttachments(ctx, message)
 }
 
 func (s *messagingService) GetMessages(ctx context.Context, conversationID string, userID string, pagination domain.PaginationParams) ([]domain.Message, error) {
+	for _, senderType := range pagination.ExcludeSenderTypes {
+		if !isValidSenderType(senderType) {
+			return nil, fmt.Errorf("%w: unknown sender type %q", ErrInvalidMessageFilter, senderType)
+		}
+	}
+
 	// Verify conversation access
 	_, err := s.GetConversation(ctx, conversationID, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	messages, err := s.loadMessagePage(ctx, conversationID, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	s.loadReactionCounts(ctx, messages)
+	s.loadReplyCounts(ctx, messages)
+	s.attachReadStatus(ctx, conversationID, messages, userID)
+
+	if pagination.IncludeReadBy {
+		if err := s.attachReadBy(ctx, messages); err != nil {
+			return nil, err
+		}
+	}
+
+	return messages, nil
+}
+
+// loadMessagePage devuelve la página de mensajes con sus adjuntos, desde la caché si está y si no desde la
+// base de datos, dejándola cacheada. Lo que cambia con cada lectura o con cada usuario se añade después
+func (s *messagingService) loadMessagePage(ctx context.Context, conversationID string, pagination domain.PaginationParams) ([]domain.Message, error) {
+	if s.cacheService != nil {
+		if cached, err := s.cacheService.GetMessages(ctx, conversationID, pagination); err == nil && cached != nil {
+			return cached, nil
+		}
+	}
+
 	messages, err := s.messageRepo.GetByConversationID(ctx, conversationID, pagination)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get messages: %w", err)
 	}
 
-	// Load attachments for each message
+	if messages, err = s.withPinnedFirst(ctx, conversationID, pagination, messages); err != nil {
+		return nil, err
+	}
+
+	s.loadAttachments(ctx, messages)
+
+	if s.cacheService != nil {
+		_ = s.cacheService.SetMessages(ctx, conversationID, pagination, messages)
+	}
+
+	return messages, nil
+}
+
+// CountMessages devuelve el número de mensajes de la conversación sin cargarlos
+func (s *messagingService) CountMessages(ctx context.Context, conversationID string, userID string) (*domain.ConversationMessageCount, error) {
+	// Verify conversation access
+	_, err := s.GetConversation(ctx, conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.messageRepo.CountByConversationID(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count messages: %w", err)
+	}
+
+	return &domain.ConversationMessageCount{
+		ConversationID: conversationID,
+		Count:          count,
+	}, nil
+}
+
+// maxMessagesAround limita cuántos mensajes se devuelven a cada lado del mensaje objetivo
+const maxMessagesAround = 100
+
+// GetMessagesAround devuelve el mensaje objetivo con hasta before mensajes anteriores y after
+// posteriores, en orden cronológico. Ambos lados se limitan a maxMessagesAround
+func (s *messagingService) GetMessagesAround(ctx context.Context, conversationID string, messageID string, userID string, before int, after int) ([]domain.Message, error) {
+	// Verify conversation access
+	_, err := s.GetConversation(ctx, conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	if target.ConversationID != conversationID {
+		return nil, fmt.Errorf("message %w", domain.ErrNotFound)
+	}
+
+	messages, err := s.messageRepo.GetAround(ctx, target, clampWindow(before), clampWindow(after))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	s.loadAttachments(ctx, messages)
+	s.loadReactionCounts(ctx, messages)
+	s.loadReplyCounts(ctx, messages)
+
+	return messages, nil
+}
+
+func clampWindow(size int) int {
+	if size < 0 {
+		return 0
+	}
+	if size > maxMessagesAround {
+		return maxMessagesAround
+	}
+	return size
+}
+
+// loadAttachments carga los adjuntos de cada mensaje; los fallos se registran sin interrumpir la lectura
+func (s *messagingService) loadAttachments(ctx context.Context, messages []domain.Message) {
 	for i := range messages {
 		attachments, err := s.attachmentRepo.GetByMessageID(ctx, messages[i].ID)
 		if err != nil {
-			s.logger.Error("Failed to load attachments for message", err)
+			s.log(ctx).Error("Failed to load attachments for message", err)
 			continue
 		}
 		messages[i].Attachments = attachments
 	}
+}
 
-	return messages, nil
+// loadReactionCounts agrega a cada mensaje sus reacciones por emoji con una sola consulta; un fallo
+// se registra y deja los mensajes sin contadores
+func (s *messagingService) loadReactionCounts(ctx context.Context, messages []domain.Message) {
+	if s.reactionRepo == nil || len(messages) == 0 {
+		return
+	}
+
+	ids := make([]string, len(messages))
+	for i := range messages {
+		ids[i] = messages[i].ID
+	}
+
+	counts, err := s.reactionRepo.CountByMessageIDs(ctx, ids)
+	if err != nil {
+		s.log(ctx).Error("Failed to load reaction counts for messages", err)
+		return
+	}
+
+	for i := range messages {
+		messages[i].ReactionCounts = counts[messages[i].ID]
+	}
+}
+
+// isValidSenderType indica si el tipo de remitente es uno de los conocidos
+func isValidSenderType(senderType domain.SenderType) bool {
+	switch senderType {
+	case domain.SenderTypeUser, domain.SenderTypeAgent, domain.SenderTypeBot, domain.SenderTypeSystem:
+		return true
+	default:
+		return false
+	}
+}
+
+// isValidChannel indica si el canal es uno de los soportados
+func isValidChannel(channel domain.Channel) bool {
+	switch channel {
+	case domain.ChannelWhatsApp, domain.ChannelWeb, domain.ChannelMessenger, domain.ChannelInstagram:
+		return true
+	default:
+		return false
+	}
+}
+
+// isValidContentType indica si el tipo de contenido es uno de los soportados
+func isValidContentType(contentType domain.ContentType) bool {
+	switch contentType {
+	case domain.ContentTypeText, domain.ContentTypeImage, domain.ContentTypeVideo, domain.ContentTypeAudio, domain.ContentTypeFile:
+		return true
+	default:
+		return false
+	}
 }
 
 func (s *messagingService) GetMessage(ctx context.Context, messageID string, userID string) (*domain.Message, error) {
@@ -246,31 +755,48 @@ func (s *messagingService) GetMessage(ctx context.Context, messageID string, use
 	// Load attachments
 	attachments, err := s.attachmentRepo.GetByMessageID(ctx, messageID)
 	if err != nil {
-		s.logger.Error("Failed to load attachments for message", err)
+		s.log(ctx).Error("Failed to load attachments for message", err)
 	} else {
 		message.Attachments = attachments
 	}
 
+	loaded := []domain.Message{*message}
+	s.loadReactionCounts(ctx, loaded)
+	message.ReactionCounts = loaded[0].ReactionCounts
+
 	return message, nil
 }
 
-func (s *messagingService) CreateAttachment(ctx context.Context, messageID string, req CreateAttachmentRequest) (*domain.Attachment, error) {
-	attachment := &domain.Attachment{
-		ID:        uuid.New().String(),
-		MessageID: messageID,
-		URL:       req.URL,
-		Type:      req.Type,
-		Size:      req.Size,
-		Filename:  req.Filename,
-		CreatedAt: time.Now(),
+// newAttachment construye el adjunto del mensaje a partir de la petición, sin guardarlo
+func newAttachment(messageID string, req CreateAttachmentRequest) *domain.Attachment {
+	return &domain.Attachment{
+		ID:           uuid.New().String(),
+		MessageID:    messageID,
+		URL:          req.URL,
+		Type:         req.Type,
+		Size:         req.Size,
+		Filename:     req.Filename,
+		ThumbnailURL: req.ThumbnailURL,
+		CreatedAt:    time.Now(),
 	}
+}
+
+func (s *messagingService) CreateAttachment(ctx context.Context, messageID string, req CreateAttachmentRequest) (*domain.Attachment, error) {
+	attachment := newAttachment(messageID, req)
 
 	if err := s.attachmentRepo.Create(ctx, attachment); err != nil {
-		s.logger.Error("Failed to create attachment", err)
+		s.log(ctx).Error("Failed to create attachment", err)
 		return nil, fmt.Errorf("failed to create attachment: %w", err)
 	}
 
-	s.logger.Info("Attachment created", map[string]interface{}{
+	// Cached message pages carry their attachments
+	if s.cacheService != nil {
+		if message, err := s.messageRepo.GetByID(ctx, messageID); err == nil {
+			_ = s.cacheService.DeleteMessages(ctx, message.ConversationID)
+		}
+	}
+
+	s.log(ctx).Info("Attachment created", map[string]interface{}{
 		"attachment_id": attachment.ID,
 		"message_id":    messageID,
 		"type":          attachment.Type,
@@ -293,4 +819,223 @@ func (s *messagingService) GetAttachment(ctx context.Context, attachmentID strin
 	}
 
 	return attachment, nil
-}
\ No newline at end of file
+}
+
+// DeleteAttachment borra el adjunto y su archivo; solo puede hacerlo el propietario de la conversación del
+// mensaje. Si el archivo ya no existe se borra igualmente el registro
+func (s *messagingService) DeleteAttachment(ctx context.Context, attachmentID string, userID string) error {
+	attachment, err := s.attachmentRepo.GetByID(ctx, attachmentID)
+	if err != nil {
+		return fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	message, err := s.messageRepo.GetByID(ctx, attachment.MessageID)
+	if err != nil {
+		return fmt.Errorf("failed to get message: %w", err)
+	}
+
+	if _, err := s.getOwnedConversation(ctx, message.ConversationID, userID); err != nil {
+		return err
+	}
+
+	// The file goes first: if the row were deleted and the file delete then failed, nothing would point
+	// at the orphaned file anymore
+	if s.fileService != nil {
+		for _, url := range []string{attachment.URL, attachment.ThumbnailURL} {
+			if url == "" {
+				continue
+			}
+			if err := s.fileService.DeleteFile(ctx, url); err != nil && !errors.Is(err, domain.ErrNotFound) {
+				return fmt.Errorf("failed to delete attachment file: %w", err)
+			}
+		}
+	}
+
+	if err := s.attachmentRepo.Delete(ctx, attachmentID); err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	s.log(ctx).Info("Attachment deleted", map[string]interface{}{
+		"attachment_id": attachmentID,
+		"message_id":    attachment.MessageID,
+		"user_id":       userID,
+	})
+
+	return nil
+}
+
+// GetUserAttachments devuelve los adjuntos de todas las conversaciones del usuario, del más reciente al más antiguo.
+// Un tipo vacío devuelve adjuntos de cualquier tipo
+func (s *messagingService) GetUserAttachments(ctx context.Context, userID string, attachmentType domain.AttachmentType, pagination domain.PaginationParams) ([]domain.Attachment, error) {
+	if attachmentType != "" && !isValidAttachmentType(attachmentType) {
+		return nil, fmt.Errorf("%w: unknown attachment type %q", ErrInvalidAttachmentFilter, attachmentType)
+	}
+
+	attachments, err := s.attachmentRepo.GetByUserID(ctx, userID, attachmentType, pagination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// maxAttachmentSearchLength acota el texto buscado en los nombres de archivo
+const maxAttachmentSearchLength = 255
+
+// SearchAttachments busca en los adjuntos de una conversación por nombre de archivo (p. ej. "factura" encuentra
+// "Factura_marzo.pdf"), del más reciente al más antiguo. Los comodines de LIKE se buscan literalmente
+func (s *messagingService) SearchAttachments(ctx context.Context, conversationID string, userID string, query string, pagination domain.PaginationParams) ([]domain.Attachment, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("%w: search query is required", ErrInvalidAttachmentFilter)
+	}
+	if utf8.RuneCountInString(query) > maxAttachmentSearchLength {
+		return nil, fmt.Errorf("%w: search query cannot exceed %d characters", ErrInvalidAttachmentFilter, maxAttachmentSearchLength)
+	}
+
+	// Verify conversation access
+	if _, err := s.GetConversation(ctx, conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.attachmentRepo.SearchByFilename(ctx, conversationID, query, pagination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+func isValidAttachmentType(attachmentType domain.AttachmentType) bool {
+	switch attachmentType {
+	case domain.AttachmentTypeImage, domain.AttachmentTypeVideo, domain.AttachmentTypeFile, domain.AttachmentTypeAudio:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *messagingService) GetReactions(ctx context.Context, messageID string, userID string) ([]domain.ReactionGroup, error) {
+	if s.reactionRepo == nil {
+		return nil, fmt.Errorf("reactions not available")
+	}
+
+	message, err := s.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	// Verify user has access to the conversation
+	_, err = s.GetConversation(ctx, message.ConversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reactions come ordered by creation time, so users keep reaction order
+	reactions, err := s.reactionRepo.GetByMessageID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reactions: %w", err)
+	}
+
+	groups := make([]domain.ReactionGroup, 0)
+	index := make(map[string]int)
+	for _, reaction := range reactions {
+		i, ok := index[reaction.Emoji]
+		if !ok {
+			i = len(groups)
+			index[reaction.Emoji] = i
+			groups = append(groups, domain.ReactionGroup{Emoji: reaction.Emoji, UserIDs: []string{}})
+		}
+		groups[i].Count++
+		groups[i].UserIDs = append(groups[i].UserIDs, reaction.UserID)
+	}
+
+	return groups, nil
+}
+
+// PurgeUser elimina definitivamente las conversaciones del usuario junto con sus mensajes, adjuntos y archivos,
+// y también sus mensajes y reacciones en conversaciones ajenas. La auditoría se registra antes de confirmar el
+// borrado: si falla no se elimina nada. Los archivos se borran cuando el borrado ya está confirmado, para no
+// perderlos si la transacción se deshace; los que fallan se devuelven en FailedFiles para limpiarlos a mano. Es
+// idempotente: purgar un usuario sin datos devuelve contadores en cero.
+func (s *messagingService) PurgeUser(ctx context.Context, userID string, requestedBy string) (*domain.UserDataPurge, error) {
+	purge, err := s.conversationRepo.DeleteByUserID(ctx, userID, func(purge *domain.UserDataPurge) error {
+		return s.recordPurgeAudit(ctx, purge, requestedBy)
+	})
+	if err != nil {
+		s.log(ctx).Error("Failed to purge user data", err)
+		return nil, fmt.Errorf("failed to purge user data: %w", err)
+	}
+
+	s.deletePurgedFiles(ctx, purge)
+
+	if s.cacheService != nil {
+		for _, conversationID := range purge.ConversationIDs {
+			_ = s.cacheService.DeleteConversation(ctx, conversationID)
+			_ = s.cacheService.DeleteMessages(ctx, conversationID)
+		}
+	}
+	s.invalidateConversationList(ctx, userID)
+
+	s.log(ctx).Info("User data purged", map[string]interface{}{
+		"user_id":       userID,
+		"requested_by":  requestedBy,
+		"conversations": purge.Conversations,
+		"messages":      purge.Messages,
+		"attachments":   purge.Attachments,
+		"reactions":     purge.Reactions,
+		"files":         purge.Files,
+		"failed_files":  len(purge.FailedFiles),
+	})
+
+	return purge, nil
+}
+
+// deletePurgedFiles borra los archivos físicos de los adjuntos purgados; los que fallan se devuelven en FailedFiles
+func (s *messagingService) deletePurgedFiles(ctx context.Context, purge *domain.UserDataPurge) {
+	if s.fileService == nil {
+		return
+	}
+
+	for _, url := range purge.AttachmentURLs {
+		if err := s.fileService.DeleteFile(ctx, url); err != nil {
+			s.log(ctx).Warn("Failed to delete purged attachment file", map[string]interface{}{
+				"url":   url,
+				"error": err.Error(),
+			})
+			purge.FailedFiles = append(purge.FailedFiles, url)
+			continue
+		}
+		purge.Files++
+	}
+}
+
+// recordPurgeAudit registra la purga; solo se auditan los contadores, nunca el contenido purgado. Los archivos
+// aún no se han borrado, así que se audita cuántos se van a borrar
+func (s *messagingService) recordPurgeAudit(ctx context.Context, purge *domain.UserDataPurge, requestedBy string) error {
+	if s.auditRepo == nil {
+		return nil
+	}
+
+	auditLog := newAuditLog(ctx, requestedBy, "USER_DATA_PURGE", "user:"+purge.UserID, map[string]interface{}{
+		"target_user_id":     purge.UserID,
+		"conversations":      purge.Conversations,
+		"messages":           purge.Messages,
+		"attachments":        purge.Attachments,
+		"reactions":          purge.Reactions,
+		"scheduled_messages": purge.ScheduledMessages,
+		"drafts":             purge.Drafts,
+		"files":              len(purge.AttachmentURLs),
+	})
+	if err := s.auditRepo.Create(ctx, auditLog); err != nil {
+		return fmt.Errorf("failed to record purge audit log: %w", err)
+	}
+
+	return nil
+}
+
+// isTrustedService indica si la llamada proviene de un servicio interno autenticado con X-Service-Token
+func isTrustedService(ctx context.Context) bool {
+	_, ok := auth.ServiceIdentityFromContext(ctx)
+	return ok
+}
@@ -2,6 +2,9 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
 	"time"
 
@@ -9,6 +12,7 @@ import (
 	"github.com/company/microservice-template/pkg/logger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // Mock repositories
@@ -41,6 +45,134 @@ func (m *MockConversationRepository) Delete(ctx context.Context, id string) erro
 	return args.Error(0)
 }
 
+func (m *MockConversationRepository) DeleteByUserID(ctx context.Context, userID string, beforeCommit func(*domain.UserDataPurge) error) (*domain.UserDataPurge, error) {
+	args := m.Called(ctx, userID)
+	purge := args.Get(0).(*domain.UserDataPurge)
+	if args.Error(1) == nil && beforeCommit != nil {
+		if err := beforeCommit(purge); err != nil {
+			return nil, err
+		}
+	}
+	return purge, args.Error(1)
+}
+
+func (m *MockConversationRepository) CreateWithMessages(ctx context.Context, conversation *domain.Conversation, messages []domain.Message) error {
+	args := m.Called(ctx, conversation, messages)
+	return args.Error(0)
+}
+
+func (m *MockConversationRepository) GetAbandonmentCandidates(ctx context.Context, idleSince time.Time, channels []domain.Channel, limit int) ([]domain.Conversation, error) {
+	args := m.Called(ctx, idleSince, channels, limit)
+	return args.Get(0).([]domain.Conversation), args.Error(1)
+}
+
+func (m *MockConversationRepository) CountByUserID(ctx context.Context, userID string, filters domain.ConversationFilters) (int64, error) {
+	args := m.Called(ctx, userID, filters)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockConversationRepository) TransferOwnership(ctx context.Context, fromUserID string, toUserID string, filters domain.ConversationFilters, limit int, requestedBy string) ([]string, error) {
+	args := m.Called(ctx, fromUserID, toUserID, filters, limit, requestedBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockConversationRepository) ReconcileMessageCounts(ctx context.Context, afterID string, limit int) (string, []string, error) {
+	args := m.Called(ctx, afterID, limit)
+	if args.Get(1) == nil {
+		return args.String(0), nil, args.Error(2)
+	}
+	return args.String(0), args.Get(1).([]string), args.Error(2)
+}
+
+func (m *MockConversationRepository) GetByReference(ctx context.Context, reference string) (*domain.Conversation, error) {
+	args := m.Called(ctx, reference)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Conversation), args.Error(1)
+}
+
+func (m *MockConversationRepository) NextReferenceNumber(ctx context.Context, prefix string) (int64, error) {
+	args := m.Called(ctx, prefix)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockConversationRepository) AddTagToConversations(ctx context.Context, conversationIDs []string, tag string, ownerID string) (map[string]bool, error) {
+	args := m.Called(ctx, conversationIDs, tag, ownerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]bool), args.Error(1)
+}
+
+func (m *MockConversationRepository) RemoveTag(ctx context.Context, conversationID string, tag string) (bool, error) {
+	args := m.Called(ctx, conversationID, tag)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockConversationRepository) TouchUpdatedAt(ctx context.Context, conversationID string, updatedAt time.Time) error {
+	args := m.Called(ctx, conversationID, updatedAt)
+	return args.Error(0)
+}
+
+func (m *MockConversationRepository) CountCreatedBefore(ctx context.Context, channel domain.Channel, createdBefore time.Time) (int64, error) {
+	args := m.Called(ctx, channel, createdBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockConversationRepository) CountStale(ctx context.Context, olderThan time.Time) (int64, error) {
+	args := m.Called(ctx, olderThan)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockConversationRepository) ArchiveStale(ctx context.Context, olderThan time.Time) (int64, error) {
+	args := m.Called(ctx, olderThan)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockConversationRepository) ArchiveCreatedBefore(ctx context.Context, channel domain.Channel, createdBefore time.Time, now time.Time, limit int) ([]string, error) {
+	args := m.Called(ctx, channel, createdBefore, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockConversationRepository) ClaimAutoReply(ctx context.Context, conversationID string, repliedBefore time.Time, now time.Time) (bool, error) {
+	args := m.Called(ctx, conversationID, repliedBefore, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockConversationRepository) UpdateStatusIfIdle(ctx context.Context, conversationID string, status domain.ConversationStatus, changedBefore time.Time, now time.Time) (bool, error) {
+	args := m.Called(ctx, conversationID, status, changedBefore, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockConversationRepository) SearchByMetadata(ctx context.Context, match domain.JSONB, userID string, limit int, offset int) ([]domain.Conversation, error) {
+	args := m.Called(ctx, match, userID, limit, offset)
+	return args.Get(0).([]domain.Conversation), args.Error(1)
+}
+
+func (m *MockConversationRepository) UpdateStatusBulk(ctx context.Context, conversationIDs []string, status domain.ConversationStatus, ownerID string, changedBefore time.Time, now time.Time) (map[string]domain.ConversationStatusOutcome, error) {
+	args := m.Called(ctx, conversationIDs, status, ownerID, changedBefore, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]domain.ConversationStatusOutcome), args.Error(1)
+}
+
+func (m *MockConversationRepository) UpdateMetadata(ctx context.Context, conversationID string, update func(metadata domain.JSONB) (domain.JSONB, error)) (domain.JSONB, error) {
+	args := m.Called(ctx, conversationID)
+	if err := args.Error(1); err != nil {
+		return nil, err
+	}
+	current, _ := args.Get(0).(domain.JSONB)
+	return update(current)
+}
+
 type MockMessageRepository struct {
 	mock.Mock
 }
@@ -50,6 +182,11 @@ func (m *MockMessageRepository) Create(ctx context.Context, message *domain.Mess
 	return args.Error(0)
 }
 
+func (m *MockMessageRepository) CreateWithAttachments(ctx context.Context, message *domain.Message) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
+
 func (m *MockMessageRepository) GetByID(ctx context.Context, id string) (*domain.Message, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(*domain.Message), args.Error(1)
@@ -60,6 +197,87 @@ func (m *MockMessageRepository) GetByConversationID(ctx context.Context, convers
 	return args.Get(0).([]domain.Message), args.Error(1)
 }
 
+func (m *MockMessageRepository) StreamByConversationID(ctx context.Context, conversationID string, excludeSenderTypes []domain.SenderType, fn func(message *domain.Message) error) error {
+	args := m.Called(ctx, conversationID, excludeSenderTypes)
+	if messages, ok := args.Get(0).([]domain.Message); ok {
+		for i := range messages {
+			if err := fn(&messages[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockMessageRepository) GetReplies(ctx context.Context, parentID string) ([]domain.Message, error) {
+	args := m.Called(ctx, parentID)
+	return args.Get(0).([]domain.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) CountReplies(ctx context.Context, messageIDs []string) (map[string]int, error) {
+	args := m.Called(ctx, messageIDs)
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+
+func (m *MockMessageRepository) GetAround(ctx context.Context, target *domain.Message, before int, after int) ([]domain.Message, error) {
+	args := m.Called(ctx, target, before, after)
+	return args.Get(0).([]domain.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) CountByConversationID(ctx context.Context, conversationID string) (int64, error) {
+	args := m.Called(ctx, conversationID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockMessageRepository) GetPendingRetries(ctx context.Context, now time.Time, maxAttempts int, limit int) ([]domain.Message, error) {
+	args := m.Called(ctx, now, maxAttempts, limit)
+	return args.Get(0).([]domain.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) GetExpired(ctx context.Context, now time.Time, limit int) ([]domain.Message, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]domain.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) GetByConversationProviderMessageID(ctx context.Context, conversationID string, providerMessageID string) (*domain.Message, error) {
+	args := m.Called(ctx, conversationID, providerMessageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) GetPinned(ctx context.Context, conversationID string, limit int) ([]domain.Message, error) {
+	args := m.Called(ctx, conversationID, limit)
+	return args.Get(0).([]domain.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) Search(ctx context.Context, userID string, query string, pagination domain.PaginationParams) ([]domain.Message, error) {
+	args := m.Called(ctx, userID, query, pagination)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) SetPinned(ctx context.Context, id string, pinnedAt *time.Time) error {
+	args := m.Called(ctx, id, pinnedAt)
+	return args.Error(0)
+}
+
+func (m *MockMessageRepository) GetByProviderMessageID(ctx context.Context, channel domain.Channel, providerMessageID string) (*domain.Message, error) {
+	args := m.Called(ctx, channel, providerMessageID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Message), args.Error(1)
+}
+
+func (m *MockMessageRepository) UpdateDeliveryStatus(ctx context.Context, id string, from domain.DeliveryStatus, to domain.DeliveryStatus) (bool, error) {
+	args := m.Called(ctx, id, from, to)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockMessageRepository) Update(ctx context.Context, message *domain.Message) error {
 	args := m.Called(ctx, message)
 	return args.Error(0)
@@ -70,6 +288,11 @@ func (m *MockMessageRepository) Delete(ctx context.Context, id string) error {
 	return args.Error(0)
 }
 
+func (m *MockMessageRepository) Purge(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 type MockAttachmentRepository struct {
 	mock.Mock
 }
@@ -89,11 +312,152 @@ func (m *MockAttachmentRepository) GetByMessageID(ctx context.Context, messageID
 	return args.Get(0).([]domain.Attachment), args.Error(1)
 }
 
+func (m *MockAttachmentRepository) GetByUserID(ctx context.Context, userID string, attachmentType domain.AttachmentType, pagination domain.PaginationParams) ([]domain.Attachment, error) {
+	args := m.Called(ctx, userID, attachmentType, pagination)
+	return args.Get(0).([]domain.Attachment), args.Error(1)
+}
+
+func (m *MockAttachmentRepository) SearchByFilename(ctx context.Context, conversationID string, query string, pagination domain.PaginationParams) ([]domain.Attachment, error) {
+	args := m.Called(ctx, conversationID, query, pagination)
+	return args.Get(0).([]domain.Attachment), args.Error(1)
+}
+
 func (m *MockAttachmentRepository) Delete(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockAttachmentRepository) SaveThumbnail(ctx context.Context, userID string, url string, thumbnailURL string) error {
+	args := m.Called(ctx, userID, url, thumbnailURL)
+	return args.Error(0)
+}
+
+type MockReactionRepository struct {
+	mock.Mock
+}
+
+func (m *MockReactionRepository) Add(ctx context.Context, reaction *domain.Reaction) (bool, error) {
+	args := m.Called(ctx, reaction)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockReactionRepository) Remove(ctx context.Context, messageID string, userID string, emoji string) (bool, error) {
+	args := m.Called(ctx, messageID, userID, emoji)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockReactionRepository) GetByMessageID(ctx context.Context, messageID string) ([]domain.Reaction, error) {
+	args := m.Called(ctx, messageID)
+	return args.Get(0).([]domain.Reaction), args.Error(1)
+}
+
+func (m *MockReactionRepository) CountByMessageIDs(ctx context.Context, messageIDs []string) (map[string]map[string]int, error) {
+	args := m.Called(ctx, messageIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string]map[string]int), args.Error(1)
+}
+
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) Create(ctx context.Context, log *domain.AuditLog) error {
+	args := m.Called(ctx, log)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*domain.AuditLog, error) {
+	args := m.Called(ctx, userID, limit, offset)
+	return args.Get(0).([]*domain.AuditLog), args.Error(1)
+}
+
+func (m *MockAuditRepository) GetByAction(ctx context.Context, action string, limit, offset int) ([]*domain.AuditLog, error) {
+	args := m.Called(ctx, action, limit, offset)
+	return args.Get(0).([]*domain.AuditLog), args.Error(1)
+}
+
+func (m *MockAuditRepository) List(ctx context.Context, filters domain.AuditLogFilters) ([]*domain.AuditLog, error) {
+	args := m.Called(ctx, filters)
+	return args.Get(0).([]*domain.AuditLog), args.Error(1)
+}
+
+type MockFileService struct {
+	mock.Mock
+}
+
+func (m *MockFileService) UploadFile(ctx context.Context, req UploadFileRequest) (*UploadFileResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*UploadFileResponse), args.Error(1)
+}
+
+func (m *MockFileService) DeleteFile(ctx context.Context, url string) error {
+	args := m.Called(ctx, url)
+	return args.Error(0)
+}
+
+func (m *MockFileService) GetFileInfo(ctx context.Context, url string) (*FileInfo, error) {
+	args := m.Called(ctx, url)
+	return args.Get(0).(*FileInfo), args.Error(1)
+}
+
+func (m *MockFileService) GeneratePresignedURL(ctx context.Context, url string, ttl time.Duration) (string, error) {
+	args := m.Called(ctx, url, ttl)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockFileService) DownloadFile(ctx context.Context, url string) (io.ReadCloser, *FileInfo, error) {
+	args := m.Called(ctx, url)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(io.ReadCloser), args.Get(1).(*FileInfo), args.Error(2)
+}
+
+// testServiceDeps son las dependencias de newTestMessagingService; las que quedan a nil se sustituyen por
+// mocks sin expectativas y por el publisher no-op
+type testServiceDeps struct {
+	conversationRepo *MockConversationRepository
+	messageRepo      *MockMessageRepository
+	attachmentRepo   *MockAttachmentRepository
+	publisher        EventPublisher
+}
+
+// newTestMessagingService construye el servicio de mensajería de los tests con deps, la caché no-op y opts
+func newTestMessagingService(deps testServiceDeps, opts ...MessagingServiceOption) MessagingService {
+	if deps.conversationRepo == nil {
+		deps.conversationRepo = new(MockConversationRepository)
+	}
+	if deps.messageRepo == nil {
+		deps.messageRepo = new(MockMessageRepository)
+	}
+	if deps.attachmentRepo == nil {
+		deps.attachmentRepo = new(MockAttachmentRepository)
+	}
+	if deps.publisher == nil {
+		deps.publisher = NewNoOpEventPublisher()
+	}
+
+	return NewMessagingService(
+		deps.conversationRepo,
+		deps.messageRepo,
+		deps.attachmentRepo,
+		deps.publisher,
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		opts...,
+	)
+}
+
+// expectSendMessage prepara los mocks para que SendMessage guarde mensajes en la conversación activa conv123
+// de user123
+func expectSendMessage(conversationRepo *MockConversationRepository, messageRepo *MockMessageRepository) {
+	conversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123", Status: domain.ConversationStatusActive}, nil)
+	conversationRepo.On("TouchUpdatedAt", mock.Anything, "conv123", mock.Anything).Return(nil)
+	messageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+}
+
 func TestMessagingService_CreateConversation(t *testing.T) {
 	// Setup
 	mockConversationRepo := new(MockConversationRepository)
@@ -120,7 +484,7 @@ func TestMessagingService_CreateConversation(t *testing.T) {
 	mockConversationRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Conversation")).Return(nil)
 
 	// Execute
-	conversation, err := service.CreateConversation(context.Background(), userID, channel)
+	conversation, err := service.CreateConversation(context.Background(), userID, channel, nil)
 
 	// Assert
 	assert.NoError(t, err)
@@ -176,6 +540,7 @@ func TestMessagingService_SendMessage(t *testing.T) {
 	// Mock expectations
 	mockConversationRepo.On("GetByID", mock.Anything, conversationID).Return(existingConversation, nil)
 	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+	mockConversationRepo.On("TouchUpdatedAt", mock.Anything, mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
 
 	// Execute
 	message, err := service.SendMessage(context.Background(), req)
@@ -189,6 +554,8 @@ func TestMessagingService_SendMessage(t *testing.T) {
 	assert.Equal(t, req.ContentType, message.ContentType)
 	assert.NotEmpty(t, message.ID)
 
+	// The conversation moves to the message's time so it sorts first in the listing
+	mockConversationRepo.AssertCalled(t, "TouchUpdatedAt", mock.Anything, conversationID, message.Timestamp)
 	mockConversationRepo.AssertExpectations(t)
 	mockMessageRepo.AssertExpectations(t)
 }
@@ -278,9 +645,654 @@ func TestMessagingService_GetConversation_AccessDenied(t *testing.T) {
 	conversation, err := service.GetConversation(context.Background(), conversationID, userID)
 
 	// Assert
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, domain.ErrAccessDenied)
+	// Still a not-found error for callers that don't tell them apart
+	assert.ErrorIs(t, err, domain.ErrNotFound)
 	assert.Nil(t, conversation)
-	assert.Contains(t, err.Error(), "not found or access denied")
 
 	mockConversationRepo.AssertExpectations(t)
-}
\ No newline at end of file
+}
+
+func TestMessagingService_GetConversation_NotFound(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	mockConversationRepo.On("GetByID", mock.Anything, "missing").Return((*domain.Conversation)(nil), fmt.Errorf("conversation %w", domain.ErrNotFound))
+
+	// Execute
+	conversation, err := service.GetConversation(context.Background(), "missing", "user123")
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.NotErrorIs(t, err, domain.ErrAccessDenied)
+	assert.Nil(t, conversation)
+}
+
+func TestMessagingService_GetMessages_ExcludeSenderTypes(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	logger := logger.NewLogger("debug")
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		mockAttachmentRepo,
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger,
+	)
+
+	pagination := domain.PaginationParams{Limit: 50, ExcludeSenderTypes: []domain.SenderType{domain.SenderTypeSystem}}
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("GetByConversationID", mock.Anything, "conv123", pagination).Return([]domain.Message{{ID: "msg1", SenderType: domain.SenderTypeUser}}, nil)
+	mockMessageRepo.On("CountReplies", mock.Anything, mock.Anything).Return(map[string]int{}, nil)
+	mockAttachmentRepo.On("GetByMessageID", mock.Anything, "msg1").Return([]domain.Attachment{}, nil)
+
+	// Execute
+	messages, err := service.GetMessages(context.Background(), "conv123", "user123", pagination)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+
+	// Unknown sender types are rejected before querying
+	_, err = service.GetMessages(context.Background(), "conv123", "user123", domain.PaginationParams{ExcludeSenderTypes: []domain.SenderType{"robot"}})
+	assert.ErrorIs(t, err, ErrInvalidMessageFilter)
+	mockMessageRepo.AssertNumberOfCalls(t, "GetByConversationID", 1)
+}
+
+func TestMessagingService_GetMessagesAround(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	logger := logger.NewLogger("debug")
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		mockAttachmentRepo,
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger,
+	)
+
+	target := &domain.Message{ID: "msg2", ConversationID: "conv123"}
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("GetByID", mock.Anything, "msg2").Return(target, nil)
+	mockMessageRepo.On("GetByID", mock.Anything, "other").Return(&domain.Message{ID: "other", ConversationID: "conv999"}, nil)
+	mockMessageRepo.On("GetAround", mock.Anything, target, maxMessagesAround, 0).Return([]domain.Message{{ID: "msg1"}, {ID: "msg2"}}, nil)
+	mockMessageRepo.On("CountReplies", mock.Anything, mock.Anything).Return(map[string]int{}, nil)
+	mockAttachmentRepo.On("GetByMessageID", mock.Anything, mock.Anything).Return([]domain.Attachment{}, nil)
+
+	// Execute: the window is capped and negative sizes are treated as zero
+	messages, err := service.GetMessagesAround(context.Background(), "conv123", "msg2", "user123", 1000, -5)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, messages, 2)
+
+	// A message from another conversation is not found
+	_, err = service.GetMessagesAround(context.Background(), "conv123", "other", "user123", 10, 10)
+	assert.Error(t, err)
+	mockMessageRepo.AssertNumberOfCalls(t, "GetAround", 1)
+}
+
+func TestMessagingService_CountMessages(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	logger := logger.NewLogger("debug")
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		mockAttachmentRepo,
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger,
+	)
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("CountByConversationID", mock.Anything, "conv123").Return(int64(42), nil)
+
+	// Execute
+	count, err := service.CountMessages(context.Background(), "conv123", "user123")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "conv123", count.ConversationID)
+	assert.Equal(t, int64(42), count.Count)
+	mockMessageRepo.AssertNotCalled(t, "GetByConversationID", mock.Anything, mock.Anything, mock.Anything)
+
+	// Other users cannot count messages of the conversation
+	_, err = service.CountMessages(context.Background(), "conv123", "user456")
+	assert.Error(t, err)
+	mockMessageRepo.AssertNumberOfCalls(t, "CountByConversationID", 1)
+}
+
+func TestMessagingService_GetReactions(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockReactionRepo := new(MockReactionRepository)
+	logger := logger.NewLogger("debug")
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		mockAttachmentRepo,
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger,
+		WithReactionRepository(mockReactionRepo),
+	)
+
+	// Test data
+	conversationID := "conv123"
+	messageID := "msg123"
+	userID := "user123"
+	now := time.Now()
+
+	mockMessageRepo.On("GetByID", mock.Anything, messageID).Return(&domain.Message{ID: messageID, ConversationID: conversationID}, nil)
+	mockConversationRepo.On("GetByID", mock.Anything, conversationID).Return(&domain.Conversation{ID: conversationID, UserID: userID}, nil)
+	mockReactionRepo.On("GetByMessageID", mock.Anything, messageID).Return([]domain.Reaction{
+		{MessageID: messageID, UserID: "user2", Emoji: "👍", CreatedAt: now},
+		{MessageID: messageID, UserID: "user3", Emoji: "❤️", CreatedAt: now.Add(time.Second)},
+		{MessageID: messageID, UserID: "user1", Emoji: "👍", CreatedAt: now.Add(2 * time.Second)},
+	}, nil)
+
+	// Execute
+	groups, err := service.GetReactions(context.Background(), messageID, userID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, groups, 2)
+	assert.Equal(t, "👍", groups[0].Emoji)
+	assert.Equal(t, 2, groups[0].Count)
+	assert.Equal(t, []string{"user2", "user1"}, groups[0].UserIDs)
+	assert.Equal(t, "❤️", groups[1].Emoji)
+	assert.Equal(t, []string{"user3"}, groups[1].UserIDs)
+
+	mockReactionRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_GetReactions_AccessDenied(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockReactionRepo := new(MockReactionRepository)
+	logger := logger.NewLogger("debug")
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		mockAttachmentRepo,
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger,
+		WithReactionRepository(mockReactionRepo),
+	)
+
+	mockMessageRepo.On("GetByID", mock.Anything, "msg123").Return(&domain.Message{ID: "msg123", ConversationID: "conv123"}, nil)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user456"}, nil)
+
+	// Execute
+	groups, err := service.GetReactions(context.Background(), "msg123", "user123")
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.Nil(t, groups)
+	mockReactionRepo.AssertNotCalled(t, "GetByMessageID", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_GetMessages_IncludesReactionCounts(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockReactionRepo := new(MockReactionRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		mockAttachmentRepo,
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithReactionRepository(mockReactionRepo),
+	)
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("GetByConversationID", mock.Anything, "conv123", mock.Anything).Return([]domain.Message{{ID: "msg1"}, {ID: "msg2"}}, nil)
+	mockMessageRepo.On("CountReplies", mock.Anything, mock.Anything).Return(map[string]int{}, nil)
+	mockAttachmentRepo.On("GetByMessageID", mock.Anything, mock.Anything).Return([]domain.Attachment{}, nil)
+	mockReactionRepo.On("CountByMessageIDs", mock.Anything, []string{"msg1", "msg2"}).Return(map[string]map[string]int{
+		"msg1": {"👍": 2, "❤️": 1},
+	}, nil)
+
+	// Execute
+	messages, err := service.GetMessages(context.Background(), "conv123", "user123", domain.PaginationParams{Limit: 20})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"👍": 2, "❤️": 1}, messages[0].ReactionCounts)
+	assert.Nil(t, messages[1].ReactionCounts)
+	mockReactionRepo.AssertNumberOfCalls(t, "CountByMessageIDs", 1)
+}
+
+func TestMessagingService_PurgeUser(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockAuditRepo := new(MockAuditRepository)
+	mockFileService := new(MockFileService)
+	logger := logger.NewLogger("debug")
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger,
+		WithAuditRepository(mockAuditRepo),
+		WithFileService(mockFileService),
+	)
+
+	mockConversationRepo.On("DeleteByUserID", mock.Anything, "user123").Return(&domain.UserDataPurge{
+		UserID:          "user123",
+		Conversations:   2,
+		Messages:        5,
+		Attachments:     2,
+		ConversationIDs: []string{"conv1", "conv2"},
+		AttachmentURLs:  []string{"/uploads/user123/a.png", "/uploads/user123/gone.pdf"},
+	}, nil)
+	mockFileService.On("DeleteFile", mock.Anything, "/uploads/user123/a.png").Return(nil)
+	mockFileService.On("DeleteFile", mock.Anything, "/uploads/user123/gone.pdf").Return(fmt.Errorf("file not found"))
+	mockAuditRepo.On("Create", mock.Anything, mock.MatchedBy(func(log *domain.AuditLog) bool {
+		return log.UserID == "admin1" &&
+			log.Action == "USER_DATA_PURGE" &&
+			log.Details["messages"] == int64(5) &&
+			log.Details["files"] == 2
+	})).Return(nil)
+
+	// Execute
+	purge, err := service.PurgeUser(context.Background(), "user123", "admin1")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purge.Conversations)
+	assert.Equal(t, int64(5), purge.Messages)
+	assert.Equal(t, int64(2), purge.Attachments)
+	assert.Equal(t, int64(1), purge.Files)
+	assert.Equal(t, []string{"/uploads/user123/gone.pdf"}, purge.FailedFiles)
+
+	mockFileService.AssertExpectations(t)
+	mockAuditRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_PurgeUser_AuditFailureAbortsPurge(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockAuditRepo := new(MockAuditRepository)
+	mockFileService := new(MockFileService)
+	logger := logger.NewLogger("debug")
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger,
+		WithAuditRepository(mockAuditRepo),
+		WithFileService(mockFileService),
+	)
+
+	mockConversationRepo.On("DeleteByUserID", mock.Anything, "user123").Return(&domain.UserDataPurge{
+		UserID:         "user123",
+		Conversations:  1,
+		AttachmentURLs: []string{"/uploads/user123/a.png"},
+	}, nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.AuditLog")).Return(fmt.Errorf("connection refused"))
+
+	// Execute
+	purge, err := service.PurgeUser(context.Background(), "user123", "admin1")
+
+	// Assert: the rows are kept, so their files must be too
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "audit")
+	assert.Nil(t, purge)
+	mockFileService.AssertNotCalled(t, "DeleteFile", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_PurgeUser_Idempotent(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockAuditRepo := new(MockAuditRepository)
+	mockFileService := new(MockFileService)
+	logger := logger.NewLogger("debug")
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger,
+		WithAuditRepository(mockAuditRepo),
+		WithFileService(mockFileService),
+	)
+
+	// A second purge finds nothing left to delete
+	mockConversationRepo.On("DeleteByUserID", mock.Anything, "user123").Return(&domain.UserDataPurge{UserID: "user123"}, nil)
+	mockAuditRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.AuditLog")).Return(nil)
+
+	// Execute
+	purge, err := service.PurgeUser(context.Background(), "user123", "admin1")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), purge.Conversations)
+	assert.Equal(t, int64(0), purge.Messages)
+	assert.Equal(t, int64(0), purge.Files)
+	mockFileService.AssertNotCalled(t, "DeleteFile", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_SendMessage_RejectedByCustomHook(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	publisher := &recordingEventPublisher{}
+	logger := logger.NewLogger("debug")
+
+	blockLinks := NewSendHook("block_links", SendHookPrePersist, func(ctx context.Context, send *SendContext) error {
+		if strings.Contains(send.Message.Content, "http://") {
+			return fmt.Errorf("links are not allowed")
+		}
+		return nil
+	})
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		new(MockAttachmentRepository),
+		publisher,
+		NewNoOpCacheService(),
+		logger,
+		WithSendHooks(blockLinks),
+	)
+
+	conversation := &domain.Conversation{ID: "conv123", UserID: "user123", Channel: domain.ChannelWeb, Status: domain.ConversationStatusActive}
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(conversation, nil)
+
+	req := SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "user123",
+		Content:        "visit http://spam.example",
+		ContentType:    domain.ContentTypeText,
+	}
+
+	// Execute
+	message, err := service.SendMessage(context.Background(), req)
+
+	// Assert
+	assert.Nil(t, message)
+	assert.ErrorIs(t, err, ErrMessageRejected)
+	assert.Contains(t, err.Error(), "block_links")
+	assert.Empty(t, publisher.events)
+	mockMessageRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_SendMessage_HooksRunInOrder(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	publisher := &recordingEventPublisher{}
+	logger := logger.NewLogger("debug")
+
+	var calls []string
+	trim := NewSendHook("trim", SendHookPrePersist, func(ctx context.Context, send *SendContext) error {
+		calls = append(calls, "trim")
+		send.Message.Content = strings.TrimSpace(send.Message.Content)
+		return nil
+	})
+	audit := NewSendHook("audit", SendHookPostPersist, func(ctx context.Context, send *SendContext) error {
+		calls = append(calls, "audit")
+		return fmt.Errorf("audit sink unavailable")
+	})
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		new(MockAttachmentRepository),
+		publisher,
+		NewNoOpCacheService(),
+		logger,
+		WithSendHooks(audit, trim),
+	)
+
+	conversation := &domain.Conversation{ID: "conv123", UserID: "user123", Channel: domain.ChannelWeb, Status: domain.ConversationStatusActive}
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(conversation, nil)
+	mockMessageRepo.On("Create", mock.Anything, mock.MatchedBy(func(m *domain.Message) bool {
+		return m.Content == "hello"
+	})).Return(nil)
+	mockConversationRepo.On("TouchUpdatedAt", mock.Anything, "conv123", mock.AnythingOfType("time.Time")).Return(nil)
+
+	req := SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "user123",
+		Content:        "  hello  ",
+		ContentType:    domain.ContentTypeText,
+	}
+
+	// Execute
+	message, err := service.SendMessage(context.Background(), req)
+
+	// Assert: pre-persist hooks run before post-persist ones, and post-persist errors don't fail the send
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", message.Content)
+	assert.Equal(t, []string{"trim", "audit"}, calls)
+	assert.Len(t, publisher.events, 1)
+	assert.Equal(t, "message.received", publisher.events[0].Type)
+	mockMessageRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_SendMessage_HooksPlacedAroundBuiltIns(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	publisher := &recordingEventPublisher{}
+	logger := logger.NewLogger("debug")
+
+	var calls []string
+	record := func(name string, stage SendHookStage) SendHook {
+		return NewSendHook(name, stage, func(ctx context.Context, send *SendContext) error {
+			calls = append(calls, name)
+			return nil
+		})
+	}
+	// The empty message would fail validation unless the pre-validation hook fills it in first
+	fill := NewSendHook("fill", SendHookPrePersist, func(ctx context.Context, send *SendContext) error {
+		calls = append(calls, "fill")
+		send.Message.Content = "filled"
+		return nil
+	})
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		new(MockAttachmentRepository),
+		publisher,
+		NewNoOpCacheService(),
+		logger,
+		WithSendHooksAfter("before_publish", record("after_anchor", SendHookPostPersist)),
+		WithSendHooksBefore("validation", fill),
+		WithSendHooksBefore("event_publish", record("before_publish", SendHookPostPersist)),
+		WithSendHooks(record("last", SendHookPostPersist)),
+		WithSendHooksAfter("missing", record("unknown_anchor", SendHookPostPersist)),
+	)
+
+	conversation := &domain.Conversation{ID: "conv123", UserID: "user123", Channel: domain.ChannelWeb, Status: domain.ConversationStatusActive}
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(conversation, nil)
+	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+	mockConversationRepo.On("TouchUpdatedAt", mock.Anything, mock.Anything, mock.AnythingOfType("time.Time")).Return(nil)
+
+	req := SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "user123",
+		ContentType:    domain.ContentTypeText,
+	}
+
+	// Execute
+	message, err := service.SendMessage(context.Background(), req)
+
+	// Assert: placements resolve after every option, so an anchor added by a later option still works
+	require.NoError(t, err)
+	assert.Equal(t, "filled", message.Content)
+	assert.Equal(t, []string{"fill", "before_publish", "after_anchor", "last", "unknown_anchor"}, calls)
+	assert.Len(t, publisher.events, 1)
+}
+
+func TestMessagingService_GetUserAttachments(t *testing.T) {
+	// Setup
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	logger := logger.NewLogger("debug")
+
+	service := NewMessagingService(
+		new(MockConversationRepository),
+		new(MockMessageRepository),
+		mockAttachmentRepo,
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger,
+	)
+
+	pagination := domain.PaginationParams{Limit: 50}
+	expected := []domain.Attachment{
+		{ID: "att2", MessageID: "msg2", ConversationID: "conv2", Type: domain.AttachmentTypeImage},
+		{ID: "att1", MessageID: "msg1", ConversationID: "conv1", Type: domain.AttachmentTypeImage},
+	}
+	mockAttachmentRepo.On("GetByUserID", mock.Anything, "user123", domain.AttachmentTypeImage, pagination).Return(expected, nil)
+
+	// Execute
+	attachments, err := service.GetUserAttachments(context.Background(), "user123", domain.AttachmentTypeImage, pagination)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, expected, attachments)
+
+	// Unknown types are rejected before querying
+	_, err = service.GetUserAttachments(context.Background(), "user123", domain.AttachmentType("sticker"), pagination)
+	assert.ErrorIs(t, err, ErrInvalidAttachmentFilter)
+	mockAttachmentRepo.AssertNumberOfCalls(t, "GetByUserID", 1)
+}
+
+func TestMessagingService_SearchAttachments(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	logger := logger.NewLogger("debug")
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		mockAttachmentRepo,
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger,
+	)
+
+	pagination := domain.PaginationParams{Limit: 50}
+	expected := []domain.Attachment{{ID: "att1", MessageID: "msg1", ConversationID: "conv123", Filename: "Factura_marzo.pdf"}}
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockAttachmentRepo.On("SearchByFilename", mock.Anything, "conv123", "factura", pagination).Return(expected, nil)
+
+	// Execute
+	attachments, err := service.SearchAttachments(context.Background(), "conv123", "user123", "  factura ", pagination)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, expected, attachments)
+
+	// Other users can't search the conversation
+	_, err = service.SearchAttachments(context.Background(), "conv123", "intruder", "factura", pagination)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	// An empty query is rejected before querying
+	_, err = service.SearchAttachments(context.Background(), "conv123", "user123", " ", pagination)
+	assert.ErrorIs(t, err, ErrInvalidAttachmentFilter)
+	mockAttachmentRepo.AssertNumberOfCalls(t, "SearchByFilename", 1)
+}
+
+func TestMessagingService_CreateConversation_AssignsReference(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithConversationReferences(map[domain.Channel]string{domain.ChannelWhatsApp: "wa"}, 6),
+	)
+
+	mockConversationRepo.On("NextReferenceNumber", mock.Anything, "WEB").Return(int64(1234), nil)
+	mockConversationRepo.On("NextReferenceNumber", mock.Anything, "WA").Return(int64(1234567), nil)
+	mockConversationRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Conversation")).Return(nil)
+
+	// Execute
+	web, err := service.CreateConversation(context.Background(), "user123", domain.ChannelWeb, nil)
+	require.NoError(t, err)
+	whatsapp, err := service.CreateConversation(context.Background(), "user123", domain.ChannelWhatsApp, nil)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, "WEB-001234", web.Reference)
+	assert.Equal(t, "WA-1234567", whatsapp.Reference)
+	mockConversationRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_GetConversationByReference(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	conversation := &domain.Conversation{ID: "conv1", Reference: "WEB-001234", UserID: "user123"}
+	mockConversationRepo.On("GetByReference", mock.Anything, "WEB-001234").Return(conversation, nil)
+
+	// Execute
+	found, err := service.GetConversationByReference(context.Background(), "WEB-001234", "user123")
+	_, otherErr := service.GetConversationByReference(context.Background(), "WEB-001234", "user456")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "conv1", found.ID)
+	assert.Error(t, otherErr)
+}
@@ -0,0 +1,520 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "github.com/company/microservice-template/internal/services"
+
+	attrConversationID = "conversation_id"
+	attrMessageID      = "message_id"
+)
+
+type tracingMessagingService struct {
+	MessagingService
+}
+
+// NewTracingMessagingService abre un span por cada llamada al servicio, con el conversation_id y el
+// message_id que la identifican; las consultas de los repositorios cuelgan de él. Sin TracerProvider
+// registrado los spans son no-ops
+func NewTracingMessagingService(service MessagingService) MessagingService {
+	return &tracingMessagingService{MessagingService: service}
+}
+
+func startServiceSpan(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "MessagingService."+method, trace.WithAttributes(attrs...))
+}
+
+func endServiceSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (s *tracingMessagingService) CreateConversation(ctx context.Context, userID string, channel domain.Channel, metadata domain.JSONB) (*domain.Conversation, error) {
+	ctx, span := startServiceSpan(ctx, "CreateConversation")
+	conversation, err := s.MessagingService.CreateConversation(ctx, userID, channel, metadata)
+	if conversation != nil {
+		span.SetAttributes(attribute.String(attrConversationID, conversation.ID))
+	}
+	endServiceSpan(span, err)
+	return conversation, err
+}
+
+func (s *tracingMessagingService) GetConversation(ctx context.Context, id string, userID string) (*domain.Conversation, error) {
+	ctx, span := startServiceSpan(ctx, "GetConversation", attribute.String(attrConversationID, id))
+	conversation, err := s.MessagingService.GetConversation(ctx, id, userID)
+	endServiceSpan(span, err)
+	return conversation, err
+}
+
+func (s *tracingMessagingService) GetConversationByReference(ctx context.Context, reference string, userID string) (*domain.Conversation, error) {
+	ctx, span := startServiceSpan(ctx, "GetConversationByReference")
+	conversation, err := s.MessagingService.GetConversationByReference(ctx, reference, userID)
+	endServiceSpan(span, err)
+	return conversation, err
+}
+
+func (s *tracingMessagingService) AddTagToConversations(ctx context.Context, conversationIDs []string, tag string, userID string) (*BulkTagResult, error) {
+	ctx, span := startServiceSpan(ctx, "AddTagToConversations", attribute.StringSlice(attrConversationID, conversationIDs))
+	bulkTagResult, err := s.MessagingService.AddTagToConversations(ctx, conversationIDs, tag, userID)
+	endServiceSpan(span, err)
+	return bulkTagResult, err
+}
+
+func (s *tracingMessagingService) AddTag(ctx context.Context, conversationID string, tag string, userID string) ([]string, error) {
+	ctx, span := startServiceSpan(ctx, "AddTag", attribute.String(attrConversationID, conversationID))
+	tags, err := s.MessagingService.AddTag(ctx, conversationID, tag, userID)
+	endServiceSpan(span, err)
+	return tags, err
+}
+
+func (s *tracingMessagingService) RemoveTag(ctx context.Context, conversationID string, tag string, userID string) ([]string, error) {
+	ctx, span := startServiceSpan(ctx, "RemoveTag", attribute.String(attrConversationID, conversationID))
+	tags, err := s.MessagingService.RemoveTag(ctx, conversationID, tag, userID)
+	endServiceSpan(span, err)
+	return tags, err
+}
+
+func (s *tracingMessagingService) ListTags(ctx context.Context, conversationID string, userID string) ([]string, error) {
+	ctx, span := startServiceSpan(ctx, "ListTags", attribute.String(attrConversationID, conversationID))
+	tags, err := s.MessagingService.ListTags(ctx, conversationID, userID)
+	endServiceSpan(span, err)
+	return tags, err
+}
+
+func (s *tracingMessagingService) UpdateConversationMetadata(ctx context.Context, conversationID string, patch domain.JSONB, userID string) (domain.JSONB, error) {
+	ctx, span := startServiceSpan(ctx, "UpdateConversationMetadata", attribute.String(attrConversationID, conversationID))
+	metadata, err := s.MessagingService.UpdateConversationMetadata(ctx, conversationID, patch, userID)
+	endServiceSpan(span, err)
+	return metadata, err
+}
+
+func (s *tracingMessagingService) GetConversations(ctx context.Context, userID string, filters domain.ConversationFilters) ([]domain.Conversation, error) {
+	ctx, span := startServiceSpan(ctx, "GetConversations")
+	conversations, err := s.MessagingService.GetConversations(ctx, userID, filters)
+	endServiceSpan(span, err)
+	return conversations, err
+}
+
+func (s *tracingMessagingService) UpdateConversationStatus(ctx context.Context, id string, status domain.ConversationStatus, userID string) error {
+	ctx, span := startServiceSpan(ctx, "UpdateConversationStatus", attribute.String(attrConversationID, id))
+	err := s.MessagingService.UpdateConversationStatus(ctx, id, status, userID)
+	endServiceSpan(span, err)
+	return err
+}
+
+func (s *tracingMessagingService) UpdateConversationStatusBulk(ctx context.Context, conversationIDs []string, status domain.ConversationStatus, userID string) (*BulkStatusResult, error) {
+	ctx, span := startServiceSpan(ctx, "UpdateConversationStatusBulk")
+	bulkStatusResult, err := s.MessagingService.UpdateConversationStatusBulk(ctx, conversationIDs, status, userID)
+	endServiceSpan(span, err)
+	return bulkStatusResult, err
+}
+
+func (s *tracingMessagingService) MarkConversationRead(ctx context.Context, conversationID string, userID string, onBehalfOf string) error {
+	ctx, span := startServiceSpan(ctx, "MarkConversationRead", attribute.String(attrConversationID, conversationID))
+	err := s.MessagingService.MarkConversationRead(ctx, conversationID, userID, onBehalfOf)
+	endServiceSpan(span, err)
+	return err
+}
+
+func (s *tracingMessagingService) AddParticipant(ctx context.Context, conversationID string, req AddParticipantRequest, userID string) (*domain.ConversationParticipant, error) {
+	ctx, span := startServiceSpan(ctx, "AddParticipant", attribute.String(attrConversationID, conversationID))
+	conversationParticipant, err := s.MessagingService.AddParticipant(ctx, conversationID, req, userID)
+	endServiceSpan(span, err)
+	return conversationParticipant, err
+}
+
+func (s *tracingMessagingService) RemoveParticipant(ctx context.Context, conversationID string, participantID string, userID string) error {
+	ctx, span := startServiceSpan(ctx, "RemoveParticipant", attribute.String(attrConversationID, conversationID))
+	err := s.MessagingService.RemoveParticipant(ctx, conversationID, participantID, userID)
+	endServiceSpan(span, err)
+	return err
+}
+
+func (s *tracingMessagingService) ListParticipants(ctx context.Context, conversationID string, userID string) ([]domain.ConversationParticipant, error) {
+	ctx, span := startServiceSpan(ctx, "ListParticipants", attribute.String(attrConversationID, conversationID))
+	conversationParticipants, err := s.MessagingService.ListParticipants(ctx, conversationID, userID)
+	endServiceSpan(span, err)
+	return conversationParticipants, err
+}
+
+func (s *tracingMessagingService) AcquireConversationLock(ctx context.Context, conversationID string, agentID string, ttl time.Duration) (*domain.ConversationLock, error) {
+	ctx, span := startServiceSpan(ctx, "AcquireConversationLock", attribute.String(attrConversationID, conversationID))
+	conversationLock, err := s.MessagingService.AcquireConversationLock(ctx, conversationID, agentID, ttl)
+	endServiceSpan(span, err)
+	return conversationLock, err
+}
+
+func (s *tracingMessagingService) ReleaseConversationLock(ctx context.Context, conversationID string, agentID string) error {
+	ctx, span := startServiceSpan(ctx, "ReleaseConversationLock", attribute.String(attrConversationID, conversationID))
+	err := s.MessagingService.ReleaseConversationLock(ctx, conversationID, agentID)
+	endServiceSpan(span, err)
+	return err
+}
+
+func (s *tracingMessagingService) SendMessage(ctx context.Context, req SendMessageRequest) (*domain.Message, error) {
+	ctx, span := startServiceSpan(ctx, "SendMessage", attribute.String(attrConversationID, req.ConversationID))
+	message, err := s.MessagingService.SendMessage(ctx, req)
+	if message != nil {
+		span.SetAttributes(attribute.String(attrMessageID, message.ID))
+	}
+	endServiceSpan(span, err)
+	return message, err
+}
+
+func (s *tracingMessagingService) GetMessages(ctx context.Context, conversationID string, userID string, pagination domain.PaginationParams) ([]domain.Message, error) {
+	ctx, span := startServiceSpan(ctx, "GetMessages", attribute.String(attrConversationID, conversationID))
+	messages, err := s.MessagingService.GetMessages(ctx, conversationID, userID, pagination)
+	endServiceSpan(span, err)
+	return messages, err
+}
+
+func (s *tracingMessagingService) ExportConversation(ctx context.Context, conversationID string, userID string, opts ExportOptions, w ExportWriter) error {
+	ctx, span := startServiceSpan(ctx, "ExportConversation", attribute.String(attrConversationID, conversationID))
+	err := s.MessagingService.ExportConversation(ctx, conversationID, userID, opts, w)
+	endServiceSpan(span, err)
+	return err
+}
+
+func (s *tracingMessagingService) ScheduleMessage(ctx context.Context, req SendMessageRequest) (*domain.ScheduledMessage, error) {
+	ctx, span := startServiceSpan(ctx, "ScheduleMessage", attribute.String(attrConversationID, req.ConversationID))
+	scheduled, err := s.MessagingService.ScheduleMessage(ctx, req)
+	endServiceSpan(span, err)
+	return scheduled, err
+}
+
+func (s *tracingMessagingService) CancelScheduledMessage(ctx context.Context, conversationID string, scheduledMessageID string, userID string) error {
+	ctx, span := startServiceSpan(ctx, "CancelScheduledMessage", attribute.String(attrConversationID, conversationID))
+	err := s.MessagingService.CancelScheduledMessage(ctx, conversationID, scheduledMessageID, userID)
+	endServiceSpan(span, err)
+	return err
+}
+
+func (s *tracingMessagingService) GetMessage(ctx context.Context, messageID string, userID string) (*domain.Message, error) {
+	ctx, span := startServiceSpan(ctx, "GetMessage", attribute.String(attrMessageID, messageID))
+	message, err := s.MessagingService.GetMessage(ctx, messageID, userID)
+	endServiceSpan(span, err)
+	return message, err
+}
+
+func (s *tracingMessagingService) GetMessagesAround(ctx context.Context, conversationID string, messageID string, userID string, before int, after int) ([]domain.Message, error) {
+	ctx, span := startServiceSpan(ctx, "GetMessagesAround", attribute.String(attrConversationID, conversationID), attribute.String(attrMessageID, messageID))
+	messages, err := s.MessagingService.GetMessagesAround(ctx, conversationID, messageID, userID, before, after)
+	endServiceSpan(span, err)
+	return messages, err
+}
+
+func (s *tracingMessagingService) GetThread(ctx context.Context, messageID string, userID string) (*domain.MessageThread, error) {
+	ctx, span := startServiceSpan(ctx, "GetThread", attribute.String(attrMessageID, messageID))
+	messageThread, err := s.MessagingService.GetThread(ctx, messageID, userID)
+	endServiceSpan(span, err)
+	return messageThread, err
+}
+
+func (s *tracingMessagingService) CountMessages(ctx context.Context, conversationID string, userID string) (*domain.ConversationMessageCount, error) {
+	ctx, span := startServiceSpan(ctx, "CountMessages", attribute.String(attrConversationID, conversationID))
+	conversationMessageCount, err := s.MessagingService.CountMessages(ctx, conversationID, userID)
+	endServiceSpan(span, err)
+	return conversationMessageCount, err
+}
+
+func (s *tracingMessagingService) MarkMessageRead(ctx context.Context, messageID string, userID string) error {
+	ctx, span := startServiceSpan(ctx, "MarkMessageRead", attribute.String(attrMessageID, messageID))
+	err := s.MessagingService.MarkMessageRead(ctx, messageID, userID)
+	endServiceSpan(span, err)
+	return err
+}
+
+func (s *tracingMessagingService) MarkMessagesRead(ctx context.Context, conversationID string, messageIDs []string, userID string) error {
+	ctx, span := startServiceSpan(ctx, "MarkMessagesRead", attribute.String(attrConversationID, conversationID))
+	err := s.MessagingService.MarkMessagesRead(ctx, conversationID, messageIDs, userID)
+	endServiceSpan(span, err)
+	return err
+}
+
+func (s *tracingMessagingService) MarkConversationReadUpTo(ctx context.Context, conversationID string, upToMessageID string, userID string) error {
+	ctx, span := startServiceSpan(ctx, "MarkConversationReadUpTo", attribute.String(attrConversationID, conversationID), attribute.String(attrMessageID, upToMessageID))
+	err := s.MessagingService.MarkConversationReadUpTo(ctx, conversationID, upToMessageID, userID)
+	endServiceSpan(span, err)
+	return err
+}
+
+func (s *tracingMessagingService) SetTyping(ctx context.Context, conversationID string, userID string, role domain.TypingRole, isTyping bool) (*domain.TypingEvent, error) {
+	ctx, span := startServiceSpan(ctx, "SetTyping", attribute.String(attrConversationID, conversationID))
+	typingEvent, err := s.MessagingService.SetTyping(ctx, conversationID, userID, role, isTyping)
+	endServiceSpan(span, err)
+	return typingEvent, err
+}
+
+func (s *tracingMessagingService) PinMessage(ctx context.Context, messageID string, userID string) (*domain.Message, error) {
+	ctx, span := startServiceSpan(ctx, "PinMessage", attribute.String(attrMessageID, messageID))
+	message, err := s.MessagingService.PinMessage(ctx, messageID, userID)
+	endServiceSpan(span, err)
+	return message, err
+}
+
+func (s *tracingMessagingService) UnpinMessage(ctx context.Context, messageID string, userID string) (*domain.Message, error) {
+	ctx, span := startServiceSpan(ctx, "UnpinMessage", attribute.String(attrMessageID, messageID))
+	message, err := s.MessagingService.UnpinMessage(ctx, messageID, userID)
+	endServiceSpan(span, err)
+	return message, err
+}
+
+func (s *tracingMessagingService) EditMessage(ctx context.Context, messageID string, userID string, newContent string) (*domain.Message, error) {
+	ctx, span := startServiceSpan(ctx, "EditMessage", attribute.String(attrMessageID, messageID))
+	message, err := s.MessagingService.EditMessage(ctx, messageID, userID, newContent)
+	endServiceSpan(span, err)
+	return message, err
+}
+
+func (s *tracingMessagingService) SearchConversations(ctx context.Context, userID string, criteria ConversationSearchCriteria, pagination domain.PaginationParams) ([]domain.Conversation, error) {
+	ctx, span := startServiceSpan(ctx, "SearchConversations")
+	conversations, err := s.MessagingService.SearchConversations(ctx, userID, criteria, pagination)
+	endServiceSpan(span, err)
+	return conversations, err
+}
+
+func (s *tracingMessagingService) SearchMessages(ctx context.Context, userID string, query string, pagination domain.PaginationParams) ([]domain.Message, error) {
+	ctx, span := startServiceSpan(ctx, "SearchMessages")
+	messages, err := s.MessagingService.SearchMessages(ctx, userID, query, pagination)
+	endServiceSpan(span, err)
+	return messages, err
+}
+
+func (s *tracingMessagingService) SaveDraft(ctx context.Context, conversationID string, req SaveDraftRequest, userID string) (*domain.MessageDraft, error) {
+	ctx, span := startServiceSpan(ctx, "SaveDraft", attribute.String(attrConversationID, conversationID))
+	messageDraft, err := s.MessagingService.SaveDraft(ctx, conversationID, req, userID)
+	endServiceSpan(span, err)
+	return messageDraft, err
+}
+
+func (s *tracingMessagingService) GetDraft(ctx context.Context, conversationID string, userID string) (*domain.MessageDraft, error) {
+	ctx, span := startServiceSpan(ctx, "GetDraft", attribute.String(attrConversationID, conversationID))
+	messageDraft, err := s.MessagingService.GetDraft(ctx, conversationID, userID)
+	endServiceSpan(span, err)
+	return messageDraft, err
+}
+
+func (s *tracingMessagingService) DeleteDraft(ctx context.Context, conversationID string, userID string) error {
+	ctx, span := startServiceSpan(ctx, "DeleteDraft", attribute.String(attrConversationID, conversationID))
+	err := s.MessagingService.DeleteDraft(ctx, conversationID, userID)
+	endServiceSpan(span, err)
+	return err
+}
+
+func (s *tracingMessagingService) CreateShareLink(ctx context.Context, conversationID string, userID string, ttl time.Duration, readOnly bool) (*domain.ConversationShareLink, error) {
+	ctx, span := startServiceSpan(ctx, "CreateShareLink", attribute.String(attrConversationID, conversationID))
+	conversationShareLink, err := s.MessagingService.CreateShareLink(ctx, conversationID, userID, ttl, readOnly)
+	endServiceSpan(span, err)
+	return conversationShareLink, err
+}
+
+func (s *tracingMessagingService) ListShareLinks(ctx context.Context, conversationID string, userID string) ([]domain.ConversationShareLink, error) {
+	ctx, span := startServiceSpan(ctx, "ListShareLinks", attribute.String(attrConversationID, conversationID))
+	conversationShareLinks, err := s.MessagingService.ListShareLinks(ctx, conversationID, userID)
+	endServiceSpan(span, err)
+	return conversationShareLinks, err
+}
+
+func (s *tracingMessagingService) RevokeShareLink(ctx context.Context, conversationID string, linkID string, userID string) error {
+	ctx, span := startServiceSpan(ctx, "RevokeShareLink", attribute.String(attrConversationID, conversationID))
+	err := s.MessagingService.RevokeShareLink(ctx, conversationID, linkID, userID)
+	endServiceSpan(span, err)
+	return err
+}
+
+func (s *tracingMessagingService) GetSharedConversation(ctx context.Context, token string, pagination domain.PaginationParams) (*domain.SharedConversation, error) {
+	ctx, span := startServiceSpan(ctx, "GetSharedConversation")
+	sharedConversation, err := s.MessagingService.GetSharedConversation(ctx, token, pagination)
+	endServiceSpan(span, err)
+	return sharedConversation, err
+}
+
+func (s *tracingMessagingService) CreateAttachment(ctx context.Context, messageID string, req CreateAttachmentRequest) (*domain.Attachment, error) {
+	ctx, span := startServiceSpan(ctx, "CreateAttachment", attribute.String(attrMessageID, messageID))
+	attachment, err := s.MessagingService.CreateAttachment(ctx, messageID, req)
+	endServiceSpan(span, err)
+	return attachment, err
+}
+
+func (s *tracingMessagingService) GetAttachment(ctx context.Context, attachmentID string, userID string) (*domain.Attachment, error) {
+	ctx, span := startServiceSpan(ctx, "GetAttachment")
+	attachment, err := s.MessagingService.GetAttachment(ctx, attachmentID, userID)
+	endServiceSpan(span, err)
+	return attachment, err
+}
+
+func (s *tracingMessagingService) DeleteAttachment(ctx context.Context, attachmentID string, userID string) error {
+	ctx, span := startServiceSpan(ctx, "DeleteAttachment")
+	err := s.MessagingService.DeleteAttachment(ctx, attachmentID, userID)
+	endServiceSpan(span, err)
+	return err
+}
+
+func (s *tracingMessagingService) GetUserAttachments(ctx context.Context, userID string, attachmentType domain.AttachmentType, pagination domain.PaginationParams) ([]domain.Attachment, error) {
+	ctx, span := startServiceSpan(ctx, "GetUserAttachments")
+	attachments, err := s.MessagingService.GetUserAttachments(ctx, userID, attachmentType, pagination)
+	endServiceSpan(span, err)
+	return attachments, err
+}
+
+func (s *tracingMessagingService) SearchAttachments(ctx context.Context, conversationID string, userID string, query string, pagination domain.PaginationParams) ([]domain.Attachment, error) {
+	ctx, span := startServiceSpan(ctx, "SearchAttachments", attribute.String(attrConversationID, conversationID))
+	attachments, err := s.MessagingService.SearchAttachments(ctx, conversationID, userID, query, pagination)
+	endServiceSpan(span, err)
+	return attachments, err
+}
+
+func (s *tracingMessagingService) GetReactions(ctx context.Context, messageID string, userID string) ([]domain.ReactionGroup, error) {
+	ctx, span := startServiceSpan(ctx, "GetReactions", attribute.String(attrMessageID, messageID))
+	reactionGroups, err := s.MessagingService.GetReactions(ctx, messageID, userID)
+	endServiceSpan(span, err)
+	return reactionGroups, err
+}
+
+func (s *tracingMessagingService) AddReaction(ctx context.Context, messageID string, userID string, emoji string) (*domain.Reaction, bool, error) {
+	ctx, span := startServiceSpan(ctx, "AddReaction", attribute.String(attrMessageID, messageID))
+	reaction, created, err := s.MessagingService.AddReaction(ctx, messageID, userID, emoji)
+	endServiceSpan(span, err)
+	return reaction, created, err
+}
+
+func (s *tracingMessagingService) RemoveReaction(ctx context.Context, messageID string, userID string, emoji string) error {
+	ctx, span := startServiceSpan(ctx, "RemoveReaction", attribute.String(attrMessageID, messageID))
+	err := s.MessagingService.RemoveReaction(ctx, messageID, userID, emoji)
+	endServiceSpan(span, err)
+	return err
+}
+
+func (s *tracingMessagingService) CreateTemplate(ctx context.Context, req ConversationTemplateRequest, userID string) (*domain.ConversationTemplate, error) {
+	ctx, span := startServiceSpan(ctx, "CreateTemplate")
+	conversationTemplate, err := s.MessagingService.CreateTemplate(ctx, req, userID)
+	endServiceSpan(span, err)
+	return conversationTemplate, err
+}
+
+func (s *tracingMessagingService) GetTemplate(ctx context.Context, id string) (*domain.ConversationTemplate, error) {
+	ctx, span := startServiceSpan(ctx, "GetTemplate")
+	conversationTemplate, err := s.MessagingService.GetTemplate(ctx, id)
+	endServiceSpan(span, err)
+	return conversationTemplate, err
+}
+
+func (s *tracingMessagingService) ListTemplates(ctx context.Context, pagination domain.PaginationParams) ([]domain.ConversationTemplate, error) {
+	ctx, span := startServiceSpan(ctx, "ListTemplates")
+	conversationTemplates, err := s.MessagingService.ListTemplates(ctx, pagination)
+	endServiceSpan(span, err)
+	return conversationTemplates, err
+}
+
+func (s *tracingMessagingService) UpdateTemplate(ctx context.Context, id string, req ConversationTemplateRequest, userID string) (*domain.ConversationTemplate, error) {
+	ctx, span := startServiceSpan(ctx, "UpdateTemplate")
+	conversationTemplate, err := s.MessagingService.UpdateTemplate(ctx, id, req, userID)
+	endServiceSpan(span, err)
+	return conversationTemplate, err
+}
+
+func (s *tracingMessagingService) DeleteTemplate(ctx context.Context, id string, userID string) error {
+	ctx, span := startServiceSpan(ctx, "DeleteTemplate")
+	err := s.MessagingService.DeleteTemplate(ctx, id, userID)
+	endServiceSpan(span, err)
+	return err
+}
+
+func (s *tracingMessagingService) CreateConversationFromTemplate(ctx context.Context, userID string, templateID string, vars map[string]string) (*domain.Conversation, error) {
+	ctx, span := startServiceSpan(ctx, "CreateConversationFromTemplate")
+	conversation, err := s.MessagingService.CreateConversationFromTemplate(ctx, userID, templateID, vars)
+	if conversation != nil {
+		span.SetAttributes(attribute.String(attrConversationID, conversation.ID))
+	}
+	endServiceSpan(span, err)
+	return conversation, err
+}
+
+func (s *tracingMessagingService) CreateWebhook(ctx context.Context, conversationID string, req ConversationWebhookRequest, userID string) (*domain.ConversationWebhook, error) {
+	ctx, span := startServiceSpan(ctx, "CreateWebhook", attribute.String(attrConversationID, conversationID))
+	conversationWebhook, err := s.MessagingService.CreateWebhook(ctx, conversationID, req, userID)
+	endServiceSpan(span, err)
+	return conversationWebhook, err
+}
+
+func (s *tracingMessagingService) ListWebhooks(ctx context.Context, conversationID string, userID string) ([]domain.ConversationWebhook, error) {
+	ctx, span := startServiceSpan(ctx, "ListWebhooks", attribute.String(attrConversationID, conversationID))
+	conversationWebhooks, err := s.MessagingService.ListWebhooks(ctx, conversationID, userID)
+	endServiceSpan(span, err)
+	return conversationWebhooks, err
+}
+
+func (s *tracingMessagingService) DeleteWebhook(ctx context.Context, conversationID string, webhookID string, userID string) error {
+	ctx, span := startServiceSpan(ctx, "DeleteWebhook", attribute.String(attrConversationID, conversationID))
+	err := s.MessagingService.DeleteWebhook(ctx, conversationID, webhookID, userID)
+	endServiceSpan(span, err)
+	return err
+}
+
+func (s *tracingMessagingService) ListWebhookDeliveries(ctx context.Context, conversationID string, webhookID string, filters domain.WebhookDeliveryFilters, userID string) ([]domain.WebhookDelivery, error) {
+	ctx, span := startServiceSpan(ctx, "ListWebhookDeliveries", attribute.String(attrConversationID, conversationID))
+	webhookDeliverys, err := s.MessagingService.ListWebhookDeliveries(ctx, conversationID, webhookID, filters, userID)
+	endServiceSpan(span, err)
+	return webhookDeliverys, err
+}
+
+func (s *tracingMessagingService) ReplayWebhookDelivery(ctx context.Context, conversationID string, webhookID string, deliveryID string, userID string) (*domain.WebhookDelivery, error) {
+	ctx, span := startServiceSpan(ctx, "ReplayWebhookDelivery", attribute.String(attrConversationID, conversationID))
+	webhookDelivery, err := s.MessagingService.ReplayWebhookDelivery(ctx, conversationID, webhookID, deliveryID, userID)
+	endServiceSpan(span, err)
+	return webhookDelivery, err
+}
+
+func (s *tracingMessagingService) PurgeUser(ctx context.Context, userID string, requestedBy string) (*domain.UserDataPurge, error) {
+	ctx, span := startServiceSpan(ctx, "PurgeUser")
+	userDataPurge, err := s.MessagingService.PurgeUser(ctx, userID, requestedBy)
+	endServiceSpan(span, err)
+	return userDataPurge, err
+}
+
+func (s *tracingMessagingService) TransferOwnership(ctx context.Context, req OwnershipTransferRequest, requestedBy string) (*domain.OwnershipTransfer, error) {
+	ctx, span := startServiceSpan(ctx, "TransferOwnership")
+	ownershipTransfer, err := s.MessagingService.TransferOwnership(ctx, req, requestedBy)
+	endServiceSpan(span, err)
+	return ownershipTransfer, err
+}
+
+func (s *tracingMessagingService) ListAuditLogs(ctx context.Context, filters domain.AuditLogFilters) ([]*domain.AuditLog, error) {
+	ctx, span := startServiceSpan(ctx, "ListAuditLogs")
+	auditLogs, err := s.MessagingService.ListAuditLogs(ctx, filters)
+	endServiceSpan(span, err)
+	return auditLogs, err
+}
+
+func (s *tracingMessagingService) ListAllWebhookDeliveries(ctx context.Context, filters domain.WebhookDeliveryFilters) ([]domain.WebhookDelivery, error) {
+	ctx, span := startServiceSpan(ctx, "ListAllWebhookDeliveries")
+	webhookDeliverys, err := s.MessagingService.ListAllWebhookDeliveries(ctx, filters)
+	endServiceSpan(span, err)
+	return webhookDeliverys, err
+}
+
+func (s *tracingMessagingService) ProcessDeliveryReceipt(ctx context.Context, channel domain.Channel, receipt DeliveryReceipt) (*DeliveryReceiptResult, error) {
+	ctx, span := startServiceSpan(ctx, "ProcessDeliveryReceipt")
+	deliveryReceiptResult, err := s.MessagingService.ProcessDeliveryReceipt(ctx, channel, receipt)
+	endServiceSpan(span, err)
+	return deliveryReceiptResult, err
+}
+
+func (s *tracingMessagingService) UpdateDeliveryStatus(ctx context.Context, messageID string, status domain.DeliveryStatus) (*domain.Message, error) {
+	ctx, span := startServiceSpan(ctx, "UpdateDeliveryStatus")
+	message, err := s.MessagingService.UpdateDeliveryStatus(ctx, messageID, status)
+	endServiceSpan(span, err)
+	return message, err
+}
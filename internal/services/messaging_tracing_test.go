@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans registra un TracerProvider global que guarda los spans en memoria durante el test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]string {
+	attrs := make(map[attribute.Key]string)
+	for _, attr := range span.Attributes() {
+		attrs[attr.Key] = attr.Value.Emit()
+	}
+	return attrs
+}
+
+func TestTracingMessagingService_SendMessage(t *testing.T) {
+	recorder := recordSpans(t)
+
+	conversationRepo := new(MockConversationRepository)
+	messageRepo := new(MockMessageRepository)
+	service := NewTracingMessagingService(NewMessagingService(conversationRepo, messageRepo, new(MockAttachmentRepository), NewNoOpEventPublisher(), NewNoOpCacheService(), logger.NewLogger("debug")))
+
+	conversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123", Status: domain.ConversationStatusActive}, nil)
+	conversationRepo.On("TouchUpdatedAt", mock.Anything, "conv123", mock.Anything).Return(nil)
+	// Stands in for the Postgres repository, whose queries start their spans from the context they get
+	messageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Run(func(args mock.Arguments) {
+		_, span := otel.Tracer("test").Start(args.Get(0).(context.Context), "INSERT messages")
+		span.End()
+	}).Return(nil)
+
+	message, err := service.SendMessage(context.Background(), SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "user123",
+		Content:        "Hola",
+		ContentType:    domain.ContentTypeText,
+	})
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	query, call := spans[0], spans[1]
+
+	assert.Equal(t, "MessagingService.SendMessage", call.Name())
+	assert.Equal(t, "conv123", spanAttributes(call)[attrConversationID])
+	assert.Equal(t, message.ID, spanAttributes(call)[attrMessageID])
+	assert.Equal(t, codes.Unset, call.Status().Code)
+
+	// The query hangs from the service call
+	assert.Equal(t, "INSERT messages", query.Name())
+	assert.Equal(t, call.SpanContext().TraceID(), query.SpanContext().TraceID())
+	assert.Equal(t, call.SpanContext().SpanID(), query.Parent().SpanID())
+}
+
+func TestTracingMessagingService_RecordsErrors(t *testing.T) {
+	recorder := recordSpans(t)
+
+	conversationRepo := new(MockConversationRepository)
+	service := NewTracingMessagingService(NewMessagingService(conversationRepo, new(MockMessageRepository), new(MockAttachmentRepository), NewNoOpEventPublisher(), NewNoOpCacheService(), logger.NewLogger("debug")))
+	conversationRepo.On("GetByID", mock.Anything, "missing").Return((*domain.Conversation)(nil), domain.ErrNotFound)
+
+	_, err := service.GetConversation(context.Background(), "missing", "user123")
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "MessagingService.GetConversation", spans[0].Name())
+	assert.Equal(t, "missing", spanAttributes(spans[0])[attrConversationID])
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	eventPublishDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "event_publish_duration_seconds",
+			Help:    "Duration of message event publishing in seconds",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		},
+		[]string{"provider", "event_type"},
+	)
+
+	eventPublishFailuresTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "event_publish_failures_total",
+			Help: "Total number of failed message event publications",
+		},
+		[]string{"provider", "event_type"},
+	)
+
+	outboxBacklogSize = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "outbox_backlog_size",
+			Help: "Number of undelivered events in the event outbox, pending retry or dead-lettered",
+		},
+		[]string{"state"},
+	)
+)
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// OutboundTransformer adapta un mensaje saliente al formato que espera un canal.
+// Recibe una copia del mensaje, por lo que puede modificarlo sin alterar el registro guardado
+type OutboundTransformer interface {
+	Name() string
+	Transform(ctx context.Context, conversation *domain.Conversation, message *domain.Message) error
+}
+
+// OutboundTransformerFunc adapta una función a la interfaz OutboundTransformer
+type OutboundTransformerFunc func(ctx context.Context, conversation *domain.Conversation, message *domain.Message) error
+
+type outboundTransformer struct {
+	name string
+	fn   OutboundTransformerFunc
+}
+
+// NewOutboundTransformer crea un transformador a partir de una función
+func NewOutboundTransformer(name string, fn OutboundTransformerFunc) OutboundTransformer {
+	return &outboundTransformer{name: name, fn: fn}
+}
+
+func (t *outboundTransformer) Name() string { return t.name }
+
+func (t *outboundTransformer) Transform(ctx context.Context, conversation *domain.Conversation, message *domain.Message) error {
+	return t.fn(ctx, conversation, message)
+}
+
+// outboundTransformerRegistry contiene los transformadores incorporados que se pueden activar por configuración
+var outboundTransformerRegistry = map[string]func() OutboundTransformer{
+	"markdown_to_plaintext": NewMarkdownToPlaintextTransformer,
+}
+
+// BuildOutboundTransformers crea la cadena de transformadores de cada canal a partir de sus nombres, respetando el orden
+func BuildOutboundTransformers(names map[string][]string) (map[domain.Channel][]OutboundTransformer, error) {
+	chains := make(map[domain.Channel][]OutboundTransformer)
+	for channel, channelNames := range names {
+		for _, name := range channelNames {
+			factory, ok := outboundTransformerRegistry[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown outbound transformer %q for channel %s", name, channel)
+			}
+			chains[domain.Channel(channel)] = append(chains[domain.Channel(channel)], factory())
+		}
+	}
+
+	return chains, nil
+}
+
+type transformingChannelSender struct {
+	sender       ChannelSender
+	transformers []OutboundTransformer
+}
+
+// NewTransformingChannelSender aplica los transformadores en orden sobre una copia del mensaje antes de entregarlo
+func NewTransformingChannelSender(sender ChannelSender, transformers ...OutboundTransformer) ChannelSender {
+	return &transformingChannelSender{sender: sender, transformers: transformers}
+}
+
+func (s *transformingChannelSender) Send(ctx context.Context, conversation *domain.Conversation, message *domain.Message) error {
+	outbound := copyOutboundMessage(message)
+	for _, transformer := range s.transformers {
+		if err := transformer.Transform(ctx, conversation, outbound); err != nil {
+			return fmt.Errorf("outbound transformer %s failed: %w", transformer.Name(), err)
+		}
+	}
+
+	err := s.sender.Send(ctx, conversation, outbound)
+	// The adapter records the provider's id on the copy; the stored message needs it to match delivery receipts
+	if providerMessageID, ok := outbound.Metadata[domain.MetadataProviderMessageID]; ok {
+		if message.Metadata == nil {
+			message.Metadata = make(domain.JSONB)
+		}
+		message.Metadata[domain.MetadataProviderMessageID] = providerMessageID
+	}
+	return err
+}
+
+// WithOutboundTransformers envuelve los adaptadores de los canales que tienen transformadores configurados
+func (senders ChannelSenders) WithOutboundTransformers(chains map[domain.Channel][]OutboundTransformer) ChannelSenders {
+	wrapped := make(ChannelSenders, len(senders))
+	for channel, sender := range senders {
+		if transformers := chains[channel]; len(transformers) > 0 {
+			sender = NewTransformingChannelSender(sender, transformers...)
+		}
+		wrapped[channel] = sender
+	}
+
+	return wrapped
+}
+
+// copyOutboundMessage copia el mensaje junto con sus metadatos y adjuntos para que los transformadores no compartan estado con el original
+func copyOutboundMessage(message *domain.Message) *domain.Message {
+	outbound := *message
+
+	if message.Metadata != nil {
+		outbound.Metadata = make(domain.JSONB, len(message.Metadata))
+		for key, value := range message.Metadata {
+			outbound.Metadata[key] = value
+		}
+	}
+
+	if message.Attachments != nil {
+		outbound.Attachments = append([]domain.Attachment(nil), message.Attachments...)
+	}
+
+	return &outbound
+}
+
+var (
+	markdownCodeFence = regexp.MustCompile("(?s)```[a-zA-Z0-9]*\n?(.*?)```")
+	markdownImage     = regexp.MustCompile(`!\[([^\]]*)\]\(([^)]+)\)`)
+	markdownLink      = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+	markdownBold      = regexp.MustCompile(`(\*\*|__)(.+?)(\*\*|__)`)
+	markdownItalic    = regexp.MustCompile(`(^|[^\w*])[*_]([^*_\n]+)[*_]`)
+	markdownStrike    = regexp.MustCompile(`~~(.+?)~~`)
+	markdownCode      = regexp.MustCompile("`([^`]+)`")
+	markdownHeading   = regexp.MustCompile(`(?m)^#{1,6}\s+`)
+	markdownQuote     = regexp.MustCompile(`(?m)^>\s?`)
+)
+
+// NewMarkdownToPlaintextTransformer elimina el formato markdown de los mensajes de texto para canales que no lo interpretan.
+// Los enlaces se conservan como "texto (url)"
+func NewMarkdownToPlaintextTransformer() OutboundTransformer {
+	return NewOutboundTransformer("markdown_to_plaintext", func(ctx context.Context, conversation *domain.Conversation, message *domain.Message) error {
+		if message.ContentType == domain.ContentTypeText {
+			message.Content = MarkdownToPlaintext(message.Content)
+		}
+		return nil
+	})
+}
+
+// MarkdownToPlaintext convierte texto markdown en texto plano
+func MarkdownToPlaintext(content string) string {
+	content = markdownCodeFence.ReplaceAllString(content, "$1")
+	content = markdownImage.ReplaceAllString(content, "$2")
+	content = markdownLink.ReplaceAllString(content, "$1 ($2)")
+	content = markdownBold.ReplaceAllString(content, "$2")
+	content = markdownItalic.ReplaceAllString(content, "$1$2")
+	content = markdownStrike.ReplaceAllString(content, "$1")
+	content = markdownCode.ReplaceAllString(content, "$1")
+	content = markdownHeading.ReplaceAllString(content, "")
+	content = markdownQuote.ReplaceAllString(content, "")
+
+	return strings.TrimSpace(content)
+}
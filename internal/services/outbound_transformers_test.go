@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMarkdownToPlaintext(t *testing.T) {
+	cases := map[string]string{
+		"**Hola** _Ana_, tu pedido está ~~pendiente~~ listo":  "Hola Ana, tu pedido está pendiente listo",
+		"# Estado\n> Revisa [tu cuenta](https://example.com)": "Estado\nRevisa tu cuenta (https://example.com)",
+		"Usa `reset` o:\n```bash\nreset --all\n```":           "Usa reset o:\nreset --all",
+		"* primero\n* segundo":                                "* primero\n* segundo",
+		"el campo user_id_value no cambia":                    "el campo user_id_value no cambia",
+	}
+
+	for input, expected := range cases {
+		assert.Equal(t, expected, MarkdownToPlaintext(input), input)
+	}
+}
+
+func TestTransformingChannelSender(t *testing.T) {
+	sender := new(MockChannelSender)
+	sender.On("Send", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	var order []string
+	tagger := NewOutboundTransformer("tagger", func(ctx context.Context, conversation *domain.Conversation, message *domain.Message) error {
+		order = append(order, "tagger")
+		message.Metadata["channel"] = string(conversation.Channel)
+		return nil
+	})
+
+	transforming := NewTransformingChannelSender(sender, NewMarkdownToPlaintextTransformer(), tagger)
+	conversation := &domain.Conversation{ID: "conv123", Channel: domain.ChannelWhatsApp}
+	message := &domain.Message{ID: "msg123", ContentType: domain.ContentTypeText, Content: "**Hola**", Metadata: domain.JSONB{}}
+
+	err := transforming.Send(context.Background(), conversation, message)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tagger"}, order)
+	sent := sender.Calls[0].Arguments.Get(2).(*domain.Message)
+	assert.Equal(t, "Hola", sent.Content)
+	assert.Equal(t, "whatsapp", sent.Metadata["channel"])
+
+	// The stored message is left untouched
+	assert.Equal(t, "**Hola**", message.Content)
+	assert.Empty(t, message.Metadata)
+}
+
+func TestTransformingChannelSender_KeepsProviderMessageID(t *testing.T) {
+	sender := new(MockChannelSender)
+	sender.On("Send", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(2).(*domain.Message).Metadata = domain.JSONB{domain.MetadataProviderMessageID: "wamid.1"}
+	}).Return(nil)
+
+	transforming := NewTransformingChannelSender(sender, NewMarkdownToPlaintextTransformer())
+	message := &domain.Message{ID: "msg123", ContentType: domain.ContentTypeText, Content: "**Hola**"}
+
+	err := transforming.Send(context.Background(), &domain.Conversation{ID: "conv123", Channel: domain.ChannelWhatsApp}, message)
+
+	// The provider's id reaches the stored message so its delivery receipts can find it
+	assert.NoError(t, err)
+	assert.Equal(t, "wamid.1", message.Metadata[domain.MetadataProviderMessageID])
+	assert.Equal(t, "**Hola**", message.Content)
+}
+
+func TestTransformingChannelSender_TransformerError(t *testing.T) {
+	sender := new(MockChannelSender)
+	failing := NewOutboundTransformer("failing", func(ctx context.Context, conversation *domain.Conversation, message *domain.Message) error {
+		return errors.New("unsupported content")
+	})
+
+	err := NewTransformingChannelSender(sender, failing).Send(context.Background(), &domain.Conversation{}, &domain.Message{})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failing")
+	sender.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBuildOutboundTransformers(t *testing.T) {
+	chains, err := BuildOutboundTransformers(map[string][]string{
+		"whatsapp": {"markdown_to_plaintext"},
+		"web":      nil,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, chains[domain.ChannelWhatsApp], 1)
+	assert.Empty(t, chains[domain.ChannelWeb])
+
+	_, err = BuildOutboundTransformers(map[string][]string{"whatsapp": {"emoji_to_text"}})
+	assert.Error(t, err)
+
+	senders := ChannelSenders{
+		domain.ChannelWhatsApp: new(MockChannelSender),
+		domain.ChannelWeb:      new(MockChannelSender),
+	}.WithOutboundTransformers(chains)
+	assert.IsType(t, &transformingChannelSender{}, senders[domain.ChannelWhatsApp])
+	assert.IsType(t, &MockChannelSender{}, senders[domain.ChannelWeb])
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// ErrInvalidTransfer indica que la solicitud de transferencia de propiedad no es válida
+var ErrInvalidTransfer = errors.New("invalid ownership transfer")
+
+const (
+	defaultTransferBatchSize  = 100
+	maxTransferBatchSize      = 500
+	defaultTransferMaxBatches = 10
+	maxTransferMaxBatches     = 20
+)
+
+// OwnershipTransferRequest describe qué conversaciones reasignar y a quién. ToUserID puede ser
+// otro agente o un equipo (p. ej. "team:soporte")
+type OwnershipTransferRequest struct {
+	FromUserID string                    `json:"from_user_id" binding:"required"`
+	ToUserID   string                    `json:"to_user_id" binding:"required"`
+	Channel    domain.Channel            `json:"channel,omitempty"`
+	Status     domain.ConversationStatus `json:"status,omitempty"`
+	BatchSize  int                       `json:"batch_size,omitempty"`  // Conversaciones por lote, 100 por defecto y 500 como máximo
+	MaxBatches int                       `json:"max_batches,omitempty"` // Lotes por llamada, 10 por defecto y 20 como máximo
+}
+
+// TransferOwnership reasigna por lotes las conversaciones de un usuario a otro, registrando un evento y
+// una entrada de auditoría por conversación; la auditoría se escribe en la misma transacción que cada lote. Procesa como mucho MaxBatches lotes por llamada; si quedan
+// conversaciones pendientes basta con repetir la misma solicitud, que continúa donde quedó
+func (s *messagingService) TransferOwnership(ctx context.Context, req OwnershipTransferRequest, requestedBy string) (*domain.OwnershipTransfer, error) {
+	if req.FromUserID == "" || req.ToUserID == "" {
+		return nil, fmt.Errorf("%w: from_user_id and to_user_id are required", ErrInvalidTransfer)
+	}
+
+	if req.FromUserID == req.ToUserID {
+		return nil, fmt.Errorf("%w: from_user_id and to_user_id must differ", ErrInvalidTransfer)
+	}
+
+	batchSize := boundedOrDefault(req.BatchSize, defaultTransferBatchSize, maxTransferBatchSize)
+	maxBatches := boundedOrDefault(req.MaxBatches, defaultTransferMaxBatches, maxTransferMaxBatches)
+	filters := domain.ConversationFilters{Channel: req.Channel, Status: req.Status}
+
+	transfer := &domain.OwnershipTransfer{
+		FromUserID: req.FromUserID,
+		ToUserID:   req.ToUserID,
+	}
+
+	for transfer.Batches < maxBatches {
+		ids, err := s.conversationRepo.TransferOwnership(ctx, req.FromUserID, req.ToUserID, filters, batchSize, requestedBy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to transfer ownership: %w", err)
+		}
+
+		if len(ids) == 0 {
+			break
+		}
+
+		transfer.Batches++
+		transfer.Transferred += len(ids)
+
+		for _, id := range ids {
+			s.recordOwnershipTransfer(ctx, id, req, requestedBy)
+		}
+
+		if len(ids) < batchSize {
+			break
+		}
+	}
+
+	if transfer.Transferred > 0 {
+		s.invalidateConversationList(ctx, req.FromUserID)
+		s.invalidateConversationList(ctx, req.ToUserID)
+	}
+
+	remaining, err := s.conversationRepo.CountByUserID(ctx, req.FromUserID, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count remaining conversations: %w", err)
+	}
+	transfer.Remaining = remaining
+	transfer.Completed = remaining == 0
+
+	s.log(ctx).Info("Conversation ownership transferred", map[string]interface{}{
+		"from_user_id": req.FromUserID,
+		"to_user_id":   req.ToUserID,
+		"requested_by": requestedBy,
+		"transferred":  transfer.Transferred,
+		"remaining":    transfer.Remaining,
+	})
+
+	return transfer, nil
+}
+
+// recordOwnershipTransfer invalida la caché y publica el evento de la reasignación de una conversación
+func (s *messagingService) recordOwnershipTransfer(ctx context.Context, conversationID string, req OwnershipTransferRequest, requestedBy string) {
+	if s.cacheService != nil {
+		_ = s.cacheService.DeleteConversation(ctx, conversationID)
+	}
+
+	details := map[string]interface{}{
+		"from_user_id": req.FromUserID,
+		"to_user_id":   req.ToUserID,
+	}
+
+	if s.eventPublisher != nil {
+		event := domain.MessageEvent{
+			Type:           "conversation.ownership_transferred",
+			ConversationID: conversationID,
+			UserID:         requestedBy,
+			Data:           details,
+			Timestamp:      time.Now(),
+		}
+
+		if err := s.eventPublisher.PublishMessageEvent(ctx, event); err != nil {
+			s.log(ctx).Error("Failed to publish ownership transfer event", err)
+		}
+	}
+}
+
+// boundedOrDefault devuelve value, o defaultValue si no es positivo, sin superar maxValue
+func boundedOrDefault(value int, defaultValue int, maxValue int) int {
+	if value <= 0 {
+		return defaultValue
+	}
+	if value > maxValue {
+		return maxValue
+	}
+	return value
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMessagingService_TransferOwnership(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	publisher := &recordingEventPublisher{}
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		publisher,
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	filters := domain.ConversationFilters{Channel: domain.ChannelWeb}
+	mockConversationRepo.On("TransferOwnership", mock.Anything, "agent1", "team:soporte", filters, 2, "admin1").Return([]string{"conv1", "conv2"}, nil).Once()
+	mockConversationRepo.On("TransferOwnership", mock.Anything, "agent1", "team:soporte", filters, 2, "admin1").Return([]string{"conv3"}, nil).Once()
+	mockConversationRepo.On("CountByUserID", mock.Anything, "agent1", filters).Return(int64(0), nil)
+
+	// Execute
+	transfer, err := service.TransferOwnership(context.Background(), OwnershipTransferRequest{
+		FromUserID: "agent1",
+		ToUserID:   "team:soporte",
+		Channel:    domain.ChannelWeb,
+		BatchSize:  2,
+	}, "admin1")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 3, transfer.Transferred)
+	assert.Equal(t, 2, transfer.Batches)
+	assert.True(t, transfer.Completed)
+	assert.Len(t, publisher.events, 3)
+	assert.Equal(t, "conversation.ownership_transferred", publisher.events[0].Type)
+	assert.Equal(t, "team:soporte", publisher.events[0].Data["to_user_id"])
+	mockConversationRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_TransferOwnership_Resumable(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	// Every batch is full, so the call stops after MaxBatches and reports what is left
+	mockConversationRepo.On("TransferOwnership", mock.Anything, "agent1", "agent2", domain.ConversationFilters{}, 1, "admin1").Return([]string{"conv"}, nil)
+	mockConversationRepo.On("CountByUserID", mock.Anything, "agent1", domain.ConversationFilters{}).Return(int64(7), nil)
+
+	// Execute
+	transfer, err := service.TransferOwnership(context.Background(), OwnershipTransferRequest{
+		FromUserID: "agent1",
+		ToUserID:   "agent2",
+		BatchSize:  1,
+		MaxBatches: 3,
+	}, "admin1")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 3, transfer.Transferred)
+	assert.Equal(t, int64(7), transfer.Remaining)
+	assert.False(t, transfer.Completed)
+	mockConversationRepo.AssertNumberOfCalls(t, "TransferOwnership", 3)
+}
+
+func TestMessagingService_TransferOwnership_SameUser(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	// Execute
+	transfer, err := service.TransferOwnership(context.Background(), OwnershipTransferRequest{
+		FromUserID: "agent1",
+		ToUserID:   "agent1",
+	}, "admin1")
+
+	// Assert
+	assert.True(t, errors.Is(err, ErrInvalidTransfer))
+	assert.Nil(t, transfer)
+	mockConversationRepo.AssertNotCalled(t, "TransferOwnership", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+const (
+	// piiRedactedKey marca en los metadatos los mensajes cuyo contenido se enmascaró
+	piiRedactedKey = "pii_redacted"
+	// piiRedactedCategoriesKey lista las categorías enmascaradas, nunca los valores originales
+	piiRedactedCategoriesKey = "pii_redacted_categories"
+	// piiRedactionHookName es el nombre del hook de envío, que EditMessage reutiliza
+	piiRedactionHookName = "pii_redaction"
+)
+
+// DefaultPIIPatterns son las expresiones incorporadas para cada categoría de datos personales
+var DefaultPIIPatterns = map[string]string{
+	"credit_card": `\b(?:\d[ -]?){12,18}\d\b`,
+	"ssn":         `\b\d{3}-\d{2}-\d{4}\b`,
+	"email":       `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+}
+
+// piiValidators descartan coincidencias que el patrón no distingue, como números largos que no son tarjetas
+var piiValidators = map[string]func(match string) bool{
+	"credit_card": isLuhnValid,
+}
+
+type piiRule struct {
+	category    string
+	pattern     *regexp.Regexp
+	placeholder string
+	validate    func(match string) bool
+}
+
+// PIIRedactor detecta datos personales en un texto y los sustituye por marcadores [REDACTED_<CATEGORÍA>]
+type PIIRedactor struct {
+	rules []piiRule
+}
+
+// NewPIIRedactor crea un redactor para las categorías indicadas, en ese orden. patterns sustituye el patrón
+// incorporado de una categoría o define categorías nuevas; una categoría sin patrón conocido es un error
+func NewPIIRedactor(categories []string, patterns map[string]string) (*PIIRedactor, error) {
+	redactor := &PIIRedactor{}
+	for _, category := range categories {
+		expr := patterns[category]
+		if expr == "" {
+			expr = DefaultPIIPatterns[category]
+		}
+		if expr == "" {
+			return nil, fmt.Errorf("no pattern configured for PII category %q", category)
+		}
+
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for PII category %q: %w", category, err)
+		}
+
+		redactor.rules = append(redactor.rules, piiRule{
+			category:    category,
+			pattern:     pattern,
+			placeholder: "[REDACTED_" + strings.ToUpper(category) + "]",
+			validate:    piiValidators[category],
+		})
+	}
+
+	return redactor, nil
+}
+
+// Redact devuelve el contenido con los datos personales enmascarados y las categorías encontradas
+func (r *PIIRedactor) Redact(content string) (string, []string) {
+	var found []string
+	for _, rule := range r.rules {
+		matched := false
+		content = rule.pattern.ReplaceAllStringFunc(content, func(match string) string {
+			if rule.validate != nil && !rule.validate(match) {
+				return match
+			}
+			matched = true
+			return rule.placeholder
+		})
+		if matched {
+			found = append(found, rule.category)
+		}
+	}
+
+	return content, found
+}
+
+// NewPIIRedactionSendHook enmascara los datos personales del contenido antes de guardar el mensaje y lo
+// marca en los metadatos. El contenido original no se conserva en ningún sitio
+func NewPIIRedactionSendHook(redactor *PIIRedactor) SendHook {
+	return NewSendHook(piiRedactionHookName, SendHookPrePersist, func(ctx context.Context, send *SendContext) error {
+		message := send.Message
+
+		// La marca solo puede ponerla este hook; se descarta la que venga del cliente
+		delete(message.Metadata, piiRedactedKey)
+		delete(message.Metadata, piiRedactedCategoriesKey)
+
+		redacted, categories := redactor.Redact(message.Content)
+		if len(categories) == 0 {
+			return nil
+		}
+
+		message.Content = redacted
+		if message.Metadata == nil {
+			message.Metadata = make(domain.JSONB)
+		}
+		message.Metadata[piiRedactedKey] = true
+		message.Metadata[piiRedactedCategoriesKey] = categories
+
+		return nil
+	})
+}
+
+// isLuhnValid comprueba el dígito de control de un número de tarjeta, ignorando espacios y guiones
+func isLuhnValid(number string) bool {
+	sum := 0
+	digits := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		if c < '0' || c > '9' {
+			return false
+		}
+
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+		digits++
+	}
+
+	return digits >= 13 && sum%10 == 0
+}
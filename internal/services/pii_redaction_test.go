@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDefaultPIIRedactor(t *testing.T) *PIIRedactor {
+	redactor, err := NewPIIRedactor([]string{"credit_card", "ssn", "email"}, nil)
+	require.NoError(t, err)
+	return redactor
+}
+
+func TestPIIRedactor_Redact(t *testing.T) {
+	redactor := newDefaultPIIRedactor(t)
+
+	tests := []struct {
+		name       string
+		content    string
+		expected   string
+		categories []string
+	}{
+		{"credit card", "mi tarjeta es 4111 1111 1111 1111 gracias", "mi tarjeta es [REDACTED_CREDIT_CARD] gracias", []string{"credit_card"}},
+		{"number failing luhn", "pedido 1234567812345678", "pedido 1234567812345678", nil},
+		{"ssn", "SSN 123-45-6789", "SSN [REDACTED_SSN]", []string{"ssn"}},
+		{"email", "escríbeme a ana.perez@example.com", "escríbeme a [REDACTED_EMAIL]", []string{"email"}},
+		{"several", "4111-1111-1111-1111 / ana@example.com", "[REDACTED_CREDIT_CARD] / [REDACTED_EMAIL]", []string{"credit_card", "email"}},
+		{"clean", "hola, necesito ayuda", "hola, necesito ayuda", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redacted, categories := redactor.Redact(tt.content)
+			assert.Equal(t, tt.expected, redacted)
+			assert.Equal(t, tt.categories, categories)
+		})
+	}
+}
+
+func TestNewPIIRedactor_Configuration(t *testing.T) {
+	// Custom categories need a pattern
+	_, err := NewPIIRedactor([]string{"iban"}, nil)
+	assert.Error(t, err)
+
+	_, err = NewPIIRedactor([]string{"ssn"}, map[string]string{"ssn": "("})
+	assert.Error(t, err)
+
+	redactor, err := NewPIIRedactor([]string{"iban"}, map[string]string{"iban": `\bES\d{22}\b`})
+	require.NoError(t, err)
+	redacted, categories := redactor.Redact("IBAN ES9121000418450200051332")
+	assert.Equal(t, "IBAN [REDACTED_IBAN]", redacted)
+	assert.Equal(t, []string{"iban"}, categories)
+}
+
+func TestPIIRedactionSendHook(t *testing.T) {
+	hook := NewPIIRedactionSendHook(newDefaultPIIRedactor(t))
+
+	// A client cannot mark its own message as redacted
+	send := &SendContext{Message: &domain.Message{
+		Content:  "llámame, mi ssn es 123-45-6789",
+		Metadata: domain.JSONB{"source": "web"},
+	}}
+	require.NoError(t, hook.Handle(context.Background(), send))
+	assert.Equal(t, "llámame, mi ssn es [REDACTED_SSN]", send.Message.Content)
+	assert.Equal(t, true, send.Message.Metadata["pii_redacted"])
+	assert.Equal(t, []string{"ssn"}, send.Message.Metadata["pii_redacted_categories"])
+	assert.Equal(t, "web", send.Message.Metadata["source"])
+
+	clean := &SendContext{Message: &domain.Message{
+		Content:  "hola",
+		Metadata: domain.JSONB{"pii_redacted": true},
+	}}
+	require.NoError(t, hook.Handle(context.Background(), clean))
+	assert.NotContains(t, clean.Message.Metadata, "pii_redacted")
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// maxPinnedMessages limita cuántos mensajes fijados se anteponen a la primera página
+const maxPinnedMessages = 50
+
+// PinMessage fija el mensaje en su conversación. Fijar un mensaje ya fijado conserva su pinned_at
+func (s *messagingService) PinMessage(ctx context.Context, messageID string, userID string) (*domain.Message, error) {
+	return s.setPinned(ctx, messageID, userID, true)
+}
+
+// UnpinMessage desfija el mensaje; desfijar uno que no lo está no es un error
+func (s *messagingService) UnpinMessage(ctx context.Context, messageID string, userID string) (*domain.Message, error) {
+	return s.setPinned(ctx, messageID, userID, false)
+}
+
+func (s *messagingService) setPinned(ctx context.Context, messageID string, userID string, pinned bool) (*domain.Message, error) {
+	message, err := s.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	// Verify user has access to the conversation
+	if _, err := s.GetConversation(ctx, message.ConversationID, userID); err != nil {
+		return nil, err
+	}
+
+	var pinnedAt *time.Time
+	if pinned {
+		pinnedAt = message.PinnedAt
+		if pinnedAt == nil {
+			now := time.Now()
+			pinnedAt = &now
+		}
+	}
+
+	if err := s.messageRepo.SetPinned(ctx, messageID, pinnedAt); err != nil {
+		return nil, fmt.Errorf("failed to pin message: %w", err)
+	}
+	message.PinnedAt = pinnedAt
+
+	if s.cacheService != nil {
+		_ = s.cacheService.DeleteMessages(ctx, message.ConversationID)
+	}
+
+	s.log(ctx).Info("Message pin updated", map[string]interface{}{
+		"message_id":      messageID,
+		"conversation_id": message.ConversationID,
+		"pinned":          pinned,
+		"user_id":         userID,
+	})
+
+	return message, nil
+}
+
+// withPinnedFirst antepone a la primera página los mensajes fijados de la conversación. Las páginas
+// siguientes no los repiten, porque la paginación solo recorre los no fijados
+func (s *messagingService) withPinnedFirst(ctx context.Context, conversationID string, pagination domain.PaginationParams, page []domain.Message) ([]domain.Message, error) {
+	if !pagination.PinnedFirst || pagination.Offset > 0 || pagination.Before != nil {
+		return page, nil
+	}
+
+	pinned, err := s.messageRepo.GetPinned(ctx, conversationID, maxPinnedMessages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pinned messages: %w", err)
+	}
+	if len(pinned) == 0 {
+		return page, nil
+	}
+
+	return append(pinned, page...), nil
+}
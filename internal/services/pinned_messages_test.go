@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessagingService_GetMessages_PinnedFirst(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		mockAttachmentRepo,
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	firstPage := domain.PaginationParams{Limit: 2, PinnedFirst: true}
+	secondPage := domain.PaginationParams{Limit: 2, Offset: 2, PinnedFirst: true}
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("GetByConversationID", mock.Anything, "conv123", firstPage).Return([]domain.Message{{ID: "msg1"}, {ID: "msg2"}}, nil)
+	mockMessageRepo.On("GetByConversationID", mock.Anything, "conv123", secondPage).Return([]domain.Message{{ID: "msg3"}}, nil)
+	mockMessageRepo.On("CountReplies", mock.Anything, mock.Anything).Return(map[string]int{}, nil)
+	mockMessageRepo.On("GetPinned", mock.Anything, "conv123", maxPinnedMessages).Return([]domain.Message{{ID: "msg9"}}, nil)
+	mockAttachmentRepo.On("GetByMessageID", mock.Anything, mock.Anything).Return([]domain.Attachment{}, nil)
+
+	// Execute: the first page starts with the pinned messages
+	messages, err := service.GetMessages(context.Background(), "conv123", "user123", firstPage)
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	assert.Equal(t, "msg9", messages[0].ID)
+	assert.Equal(t, "msg1", messages[1].ID)
+
+	// Later pages don't repeat them
+	messages, err = service.GetMessages(context.Background(), "conv123", "user123", secondPage)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "msg3", messages[0].ID)
+	mockMessageRepo.AssertNumberOfCalls(t, "GetPinned", 1)
+}
+
+func TestMessagingService_PinMessage(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	pinnedAt := time.Now().Add(-time.Hour)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("GetByID", mock.Anything, "msg1").Return(&domain.Message{ID: "msg1", ConversationID: "conv123"}, nil)
+	mockMessageRepo.On("GetByID", mock.Anything, "msg2").Return(&domain.Message{ID: "msg2", ConversationID: "conv123", PinnedAt: &pinnedAt}, nil)
+	mockMessageRepo.On("SetPinned", mock.Anything, "msg1", mock.AnythingOfType("*time.Time")).Return(nil)
+	mockMessageRepo.On("SetPinned", mock.Anything, "msg2", &pinnedAt).Return(nil)
+
+	// Execute
+	message, err := service.PinMessage(context.Background(), "msg1", "user123")
+	require.NoError(t, err)
+	assert.NotNil(t, message.PinnedAt)
+
+	// Pinning again keeps the original pinned_at
+	message, err = service.PinMessage(context.Background(), "msg2", "user123")
+	require.NoError(t, err)
+	assert.Equal(t, pinnedAt, *message.PinnedAt)
+
+	// Unpinning clears it
+	mockMessageRepo.On("SetPinned", mock.Anything, "msg2", (*time.Time)(nil)).Return(nil)
+	message, err = service.UnpinMessage(context.Background(), "msg2", "user123")
+	require.NoError(t, err)
+	assert.Nil(t, message.PinnedAt)
+}
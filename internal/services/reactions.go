@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/google/uuid"
+)
+
+// maxEmojiLength acota el emoji de una reacción; las secuencias con modificadores ocupan varias runas
+const maxEmojiLength = 16
+
+// ErrInvalidReaction indica que el emoji de la reacción está vacío, es demasiado largo o contiene espacios
+var ErrInvalidReaction = errors.New("invalid reaction")
+
+// normalizeEmoji valida el emoji de una reacción y lo devuelve sin espacios alrededor
+func normalizeEmoji(emoji string) (string, error) {
+	emoji = strings.TrimSpace(emoji)
+	if emoji == "" {
+		return "", fmt.Errorf("%w: emoji is required", ErrInvalidReaction)
+	}
+	if utf8.RuneCountInString(emoji) > maxEmojiLength {
+		return "", fmt.Errorf("%w: emoji must be at most %d characters", ErrInvalidReaction, maxEmojiLength)
+	}
+	if strings.IndexFunc(emoji, unicode.IsSpace) >= 0 {
+		return "", fmt.Errorf("%w: emoji cannot contain spaces", ErrInvalidReaction)
+	}
+	return emoji, nil
+}
+
+// AddReaction añade el emoji del usuario al mensaje. Es idempotente: si ya había reaccionado con ese emoji
+// devuelve la reacción existente y false, sin volver a publicar reaction.added
+func (s *messagingService) AddReaction(ctx context.Context, messageID string, userID string, emoji string) (*domain.Reaction, bool, error) {
+	if s.reactionRepo == nil {
+		return nil, false, fmt.Errorf("reactions not available")
+	}
+
+	emoji, err := normalizeEmoji(emoji)
+	if err != nil {
+		return nil, false, err
+	}
+
+	message, err := s.reactableMessage(ctx, messageID, userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	reaction := &domain.Reaction{
+		ID:        uuid.New().String(),
+		MessageID: messageID,
+		UserID:    userID,
+		Emoji:     emoji,
+		CreatedAt: time.Now(),
+	}
+	added, err := s.reactionRepo.Add(ctx, reaction)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to add reaction: %w", err)
+	}
+
+	if added {
+		s.publishReactionEvent(ctx, "reaction.added", message, reaction)
+	}
+
+	return reaction, added, nil
+}
+
+// RemoveReaction quita el emoji del usuario del mensaje; quitar uno que no estaba no es un error
+func (s *messagingService) RemoveReaction(ctx context.Context, messageID string, userID string, emoji string) error {
+	if s.reactionRepo == nil {
+		return fmt.Errorf("reactions not available")
+	}
+
+	emoji, err := normalizeEmoji(emoji)
+	if err != nil {
+		return err
+	}
+
+	message, err := s.reactableMessage(ctx, messageID, userID)
+	if err != nil {
+		return err
+	}
+
+	removed, err := s.reactionRepo.Remove(ctx, messageID, userID, emoji)
+	if err != nil {
+		return fmt.Errorf("failed to remove reaction: %w", err)
+	}
+
+	if removed {
+		s.publishReactionEvent(ctx, "reaction.removed", message, &domain.Reaction{MessageID: messageID, UserID: userID, Emoji: emoji, CreatedAt: time.Now()})
+	}
+
+	return nil
+}
+
+// reactableMessage devuelve el mensaje si el usuario tiene acceso a su conversación
+func (s *messagingService) reactableMessage(ctx context.Context, messageID string, userID string) (*domain.Message, error) {
+	message, err := s.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	// Verify user has access to the conversation
+	if _, err := s.GetConversation(ctx, message.ConversationID, userID); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// publishReactionEvent publica el cambio de reacción; un fallo se registra sin deshacer el cambio
+func (s *messagingService) publishReactionEvent(ctx context.Context, eventType string, message *domain.Message, reaction *domain.Reaction) {
+	if s.eventPublisher == nil {
+		return
+	}
+
+	event := domain.MessageEvent{
+		Type:           eventType,
+		ConversationID: message.ConversationID,
+		Message:        domain.Message{ID: message.ID, ConversationID: message.ConversationID},
+		UserID:         reaction.UserID,
+		Data:           domain.JSONB{"emoji": reaction.Emoji},
+		Timestamp:      reaction.CreatedAt,
+	}
+	if err := s.eventPublisher.PublishMessageEvent(ctx, event); err != nil {
+		s.log(ctx).Error("Failed to publish reaction event", err)
+	}
+}
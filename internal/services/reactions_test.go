@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessagingService_AddReaction_Idempotent(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockReactionRepo := new(MockReactionRepository)
+	publisher := &recordingEventPublisher{}
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo, publisher: publisher}, WithReactionRepository(mockReactionRepo))
+
+	mockMessageRepo.On("GetByID", mock.Anything, "msg123").Return(&domain.Message{ID: "msg123", ConversationID: "conv123"}, nil)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	isThumbsUp := mock.MatchedBy(func(r *domain.Reaction) bool {
+		return r.MessageID == "msg123" && r.UserID == "user123" && r.Emoji == "👍"
+	})
+	mockReactionRepo.On("Add", mock.Anything, isThumbsUp).Return(true, nil).Once()
+	mockReactionRepo.On("Add", mock.Anything, isThumbsUp).Return(false, nil).Once()
+
+	// Execute: the first reaction is stored and published
+	reaction, added, err := service.AddReaction(context.Background(), "msg123", "user123", " 👍 ")
+	require.NoError(t, err)
+	assert.True(t, added)
+	assert.Equal(t, "👍", reaction.Emoji)
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, "reaction.added", publisher.events[0].Type)
+	assert.Equal(t, "conv123", publisher.events[0].ConversationID)
+	assert.Equal(t, "👍", publisher.events[0].Data["emoji"])
+
+	// Adding it again is a no-op
+	_, added, err = service.AddReaction(context.Background(), "msg123", "user123", "👍")
+	require.NoError(t, err)
+	assert.False(t, added)
+	assert.Len(t, publisher.events, 1)
+}
+
+func TestMessagingService_RemoveReaction_Idempotent(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockReactionRepo := new(MockReactionRepository)
+	publisher := &recordingEventPublisher{}
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo, publisher: publisher}, WithReactionRepository(mockReactionRepo))
+
+	mockMessageRepo.On("GetByID", mock.Anything, "msg123").Return(&domain.Message{ID: "msg123", ConversationID: "conv123"}, nil)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockReactionRepo.On("Remove", mock.Anything, "msg123", "user123", "👍").Return(true, nil).Once()
+	mockReactionRepo.On("Remove", mock.Anything, "msg123", "user123", "👍").Return(false, nil).Once()
+
+	// Execute
+	require.NoError(t, service.RemoveReaction(context.Background(), "msg123", "user123", "👍"))
+	require.NoError(t, service.RemoveReaction(context.Background(), "msg123", "user123", "👍"))
+
+	// Assert: only the actual removal is published
+	require.Len(t, publisher.events, 1)
+	assert.Equal(t, "reaction.removed", publisher.events[0].Type)
+	assert.Equal(t, "user123", publisher.events[0].UserID)
+}
+
+func TestMessagingService_Reactions_RequireConversationAccess(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockReactionRepo := new(MockReactionRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo}, WithReactionRepository(mockReactionRepo))
+
+	mockMessageRepo.On("GetByID", mock.Anything, "msg123").Return(&domain.Message{ID: "msg123", ConversationID: "conv123"}, nil)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "owner"}, nil)
+
+	// Execute
+	_, _, addErr := service.AddReaction(context.Background(), "msg123", "intruder", "👍")
+	removeErr := service.RemoveReaction(context.Background(), "msg123", "intruder", "👍")
+
+	// Assert
+	assert.ErrorIs(t, addErr, domain.ErrNotFound)
+	assert.ErrorIs(t, removeErr, domain.ErrNotFound)
+	mockReactionRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+	mockReactionRepo.AssertNotCalled(t, "Remove", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_AddReaction_InvalidEmoji(t *testing.T) {
+	mockReactionRepo := new(MockReactionRepository)
+	service := newTestMessagingService(testServiceDeps{}, WithReactionRepository(mockReactionRepo))
+
+	for _, emoji := range []string{"", "   ", "👍 👎", "abcdefghijklmnopq"} {
+		_, _, err := service.AddReaction(context.Background(), "msg123", "user123", emoji)
+		assert.ErrorIs(t, err, ErrInvalidReaction, emoji)
+	}
+	mockReactionRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+}
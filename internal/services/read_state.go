@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// WithParticipantRepository habilita las marcas de lectura y el estado de lectura agregado
+func WithParticipantRepository(repo domain.ParticipantRepository) MessagingServiceOption {
+	return func(s *messagingService) {
+		s.participantRepo = repo
+	}
+}
+
+// MarkConversationRead registra que el lector leyó la conversación hasta ahora. Un servicio
+// interno puede marcarla en nombre de otro usuario (p. ej. un agente) indicando onBehalfOf; la marca
+// no convierte al lector en participante ni le da acceso a la conversación
+func (s *messagingService) MarkConversationRead(ctx context.Context, conversationID string, userID string, onBehalfOf string) error {
+	if s.participantRepo == nil {
+		return fmt.Errorf("read markers are not enabled")
+	}
+
+	conversation, err := s.GetConversation(ctx, conversationID, userID)
+	if err != nil {
+		return err
+	}
+
+	readerID := userID
+	if onBehalfOf != "" {
+		if !isTrustedService(ctx) {
+			return fmt.Errorf("only internal services can mark conversations as read on behalf of another user")
+		}
+		readerID = onBehalfOf
+	}
+
+	if err := s.participantRepo.MarkRead(ctx, conversationID, readerID, time.Now()); err != nil {
+		return fmt.Errorf("failed to mark conversation as read: %w", err)
+	}
+
+	// The owner's list shows the read state of every participant
+	s.invalidateConversationList(ctx, conversation.UserID)
+
+	return nil
+}
+
+// attachReadStates completa ReadState de cada conversación con una única consulta agregada
+func (s *messagingService) attachReadStates(ctx context.Context, conversations []domain.Conversation) error {
+	if s.participantRepo == nil || len(conversations) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(conversations))
+	for i := range conversations {
+		ids[i] = conversations[i].ID
+	}
+
+	states, err := s.participantRepo.GetReadStates(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get read states: %w", err)
+	}
+
+	for i := range conversations {
+		state := states[conversations[i].ID]
+		conversations[i].ReadState = &state
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockParticipantRepository es un mock del repositorio de participantes
+type MockParticipantRepository struct {
+	mock.Mock
+}
+
+func (m *MockParticipantRepository) MarkRead(ctx context.Context, conversationID string, userID string, readAt time.Time) error {
+	args := m.Called(ctx, conversationID, userID, readAt)
+	return args.Error(0)
+}
+
+func (m *MockParticipantRepository) GetReadStates(ctx context.Context, conversationIDs []string) (map[string]domain.ConversationReadState, error) {
+	args := m.Called(ctx, conversationIDs)
+	return args.Get(0).(map[string]domain.ConversationReadState), args.Error(1)
+}
+
+func (m *MockParticipantRepository) FilterParticipants(ctx context.Context, conversationID string, userIDs []string) ([]string, error) {
+	args := m.Called(ctx, conversationID, userIDs)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockParticipantRepository) AddParticipant(ctx context.Context, participant *domain.ConversationParticipant) error {
+	args := m.Called(ctx, participant)
+	return args.Error(0)
+}
+
+func (m *MockParticipantRepository) RemoveParticipant(ctx context.Context, conversationID string, userID string) error {
+	args := m.Called(ctx, conversationID, userID)
+	return args.Error(0)
+}
+
+func (m *MockParticipantRepository) ListParticipants(ctx context.Context, conversationID string) ([]domain.ConversationParticipant, error) {
+	args := m.Called(ctx, conversationID)
+	return args.Get(0).([]domain.ConversationParticipant), args.Error(1)
+}
+
+func TestMessagingService_GetConversations_WithReadState(t *testing.T) {
+	mockConversationRepo := new(MockConversationRepository)
+	mockParticipantRepo := new(MockParticipantRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo}, WithParticipantRepository(mockParticipantRepo))
+
+	filters := domain.ConversationFilters{Limit: 20, IncludeReadState: true}
+	mockConversationRepo.On("GetByUserID", mock.Anything, "user123", filters).Return([]domain.Conversation{
+		{ID: "conv1", UserID: "user123"},
+		{ID: "conv2", UserID: "user123"},
+	}, nil)
+	mockParticipantRepo.On("GetReadStates", mock.Anything, []string{"conv1", "conv2"}).Return(map[string]domain.ConversationReadState{
+		"conv1": {Participants: 3, ReadLatest: 2},
+	}, nil).Once()
+
+	conversations, err := service.GetConversations(context.Background(), "user123", filters)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.ConversationReadState{Participants: 3, ReadLatest: 2}, conversations[0].ReadState)
+	// Conversations nobody has opened still report an empty state
+	assert.Equal(t, &domain.ConversationReadState{}, conversations[1].ReadState)
+	mockParticipantRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_GetConversations_ReadStateIsOptIn(t *testing.T) {
+	mockConversationRepo := new(MockConversationRepository)
+	mockParticipantRepo := new(MockParticipantRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo}, WithParticipantRepository(mockParticipantRepo))
+
+	filters := domain.ConversationFilters{Limit: 20}
+	mockConversationRepo.On("GetByUserID", mock.Anything, "user123", filters).Return([]domain.Conversation{{ID: "conv1", UserID: "user123"}}, nil)
+
+	conversations, err := service.GetConversations(context.Background(), "user123", filters)
+
+	assert.NoError(t, err)
+	assert.Nil(t, conversations[0].ReadState)
+	mockParticipantRepo.AssertNotCalled(t, "GetReadStates", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_MarkConversationRead(t *testing.T) {
+	mockConversationRepo := new(MockConversationRepository)
+	mockParticipantRepo := new(MockParticipantRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo}, WithParticipantRepository(mockParticipantRepo))
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockParticipantRepo.On("MarkRead", mock.Anything, "conv123", mock.Anything, mock.Anything).Return(nil)
+
+	// The owner marks their own read position
+	assert.NoError(t, service.MarkConversationRead(context.Background(), "conv123", "user123", ""))
+	mockParticipantRepo.AssertCalled(t, "MarkRead", mock.Anything, "conv123", "user123", mock.Anything)
+
+	// Only internal services may act on behalf of an agent
+	assert.Error(t, service.MarkConversationRead(context.Background(), "conv123", "user123", "agent1"))
+
+	serviceCtx := auth.WithServiceIdentity(context.Background(), "agent-desk")
+	assert.NoError(t, service.MarkConversationRead(serviceCtx, "conv123", auth.ServiceUserID("agent-desk"), "agent1"))
+	mockParticipantRepo.AssertCalled(t, "MarkRead", mock.Anything, "conv123", "agent1", mock.Anything)
+	mockParticipantRepo.AssertNumberOfCalls(t, "MarkRead", 2)
+}
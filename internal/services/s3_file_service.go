@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// ErrS3ObjectNotFound indica que el objeto no existe en el bucket
+var ErrS3ObjectNotFound = errors.New("s3 object not found")
+
+// S3ObjectInfo son los metadatos de un objeto devueltos por HeadObject
+type S3ObjectInfo struct {
+	Size        int64
+	ContentType string
+}
+
+// S3Client es el subconjunto de operaciones de S3 que usa s3FileService
+type S3Client interface {
+	// PutObject sube el cuerpo a medida que lo lee, sin conocer su tamaño de antemano
+	PutObject(ctx context.Context, bucket string, key string, body io.Reader, contentType string) error
+	// HeadObject devuelve ErrS3ObjectNotFound si el objeto no existe
+	HeadObject(ctx context.Context, bucket string, key string) (*S3ObjectInfo, error)
+	// GetObject abre el contenido del objeto; devuelve ErrS3ObjectNotFound si no existe
+	GetObject(ctx context.Context, bucket string, key string) (io.ReadCloser, *S3ObjectInfo, error)
+	DeleteObject(ctx context.Context, bucket string, key string) error
+	// ObjectURL devuelve la URL https del objeto
+	ObjectURL(bucket string, key string) string
+	// PresignGetObject devuelve una URL que permite descargar el objeto sin credenciales durante ttl
+	PresignGetObject(ctx context.Context, bucket string, key string, ttl time.Duration) (string, error)
+}
+
+type s3FileService struct {
+	client S3Client
+	config *config.FileStorageConfig
+	logger logger.Logger
+}
+
+// NewS3FileService guarda los archivos en config.BucketName bajo la clave {userID}/{nombre único}
+func NewS3FileService(client S3Client, config *config.FileStorageConfig, logger logger.Logger) FileService {
+	return &s3FileService{
+		client: client,
+		config: config,
+		logger: logger,
+	}
+}
+
+func (s *s3FileService) UploadFile(ctx context.Context, req UploadFileRequest) (*UploadFileResponse, error) {
+	// Validate file size
+	if req.Size > s.config.MaxFileSize {
+		return nil, fmt.Errorf("%w of %d bytes", ErrFileTooLarge, s.config.MaxFileSize)
+	}
+
+	ext := filepath.Ext(req.Filename)
+	uniqueFilename := fmt.Sprintf("%s_%s%s", uuid.New().String(), time.Now().Format("20060102_150405"), ext)
+	key := req.UserID + "/" + uniqueFilename
+
+	contentType := mime.TypeByExtension(strings.ToLower(ext))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	// The declared size can't be trusted, so the upload is aborted as soon as the body exceeds the limit
+	body := &sizeLimitedReader{reader: req.File, limit: s.config.MaxFileSize}
+	if err := s.client.PutObject(ctx, s.config.BucketName, key, body, contentType); err != nil {
+		if errors.Is(err, ErrFileTooLarge) {
+			return nil, fmt.Errorf("%w of %d bytes", ErrFileTooLarge, s.config.MaxFileSize)
+		}
+		s.logger.Error("Failed to upload file to S3", err)
+		return nil, fmt.Errorf("failed to upload file: %w", err)
+	}
+
+	fileType := determineFileType(req.Filename)
+
+	s.logger.Info("File uploaded successfully", map[string]interface{}{
+		"filename": req.Filename,
+		"size":     body.read,
+		"type":     fileType,
+		"user_id":  req.UserID,
+		"bucket":   s.config.BucketName,
+		"key":      key,
+	})
+
+	return &UploadFileResponse{
+		URL:      s.client.ObjectURL(s.config.BucketName, key),
+		Filename: req.Filename,
+		Size:     body.read,
+		Type:     fileType,
+	}, nil
+}
+
+func (s *s3FileService) DeleteFile(ctx context.Context, url string) error {
+	err := s.client.DeleteObject(ctx, s.config.BucketName, s.objectKey(url))
+	if errors.Is(err, ErrS3ObjectNotFound) {
+		return fmt.Errorf("file %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		s.logger.Error("Failed to delete file from S3", err)
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	s.logger.Info("File deleted successfully", map[string]interface{}{
+		"url": url,
+	})
+
+	return nil
+}
+
+func (s *s3FileService) GetFileInfo(ctx context.Context, url string) (*FileInfo, error) {
+	key := s.objectKey(url)
+
+	info, err := s.client.HeadObject(ctx, s.config.BucketName, key)
+	if errors.Is(err, ErrS3ObjectNotFound) {
+		return &FileInfo{
+			URL:    url,
+			Exists: false,
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	filename := key[strings.LastIndex(key, "/")+1:]
+
+	return &FileInfo{
+		URL:      url,
+		Filename: filename,
+		Size:     info.Size,
+		Type:     determineFileType(filename),
+		UserID:   fileOwner(key),
+		Exists:   true,
+	}, nil
+}
+
+// DownloadFile devuelve el cuerpo de la respuesta de S3 tal cual, sin cargar el objeto en memoria
+func (s *s3FileService) DownloadFile(ctx context.Context, url string) (io.ReadCloser, *FileInfo, error) {
+	key := s.objectKey(url)
+
+	body, info, err := s.client.GetObject(ctx, s.config.BucketName, key)
+	if errors.Is(err, ErrS3ObjectNotFound) {
+		return nil, nil, fmt.Errorf("file %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download file: %w", err)
+	}
+
+	filename := key[strings.LastIndex(key, "/")+1:]
+
+	return body, &FileInfo{
+		URL:         url,
+		Filename:    filename,
+		Size:        info.Size,
+		Type:        determineFileType(filename),
+		ContentType: info.ContentType,
+		UserID:      fileOwner(key),
+		Exists:      true,
+	}, nil
+}
+
+// maxPresignTTL es la validez máxima que S3 admite en una URL prefirmada
+const maxPresignTTL = 7 * 24 * time.Hour
+
+func (s *s3FileService) GeneratePresignedURL(ctx context.Context, url string, ttl time.Duration) (string, error) {
+	if ttl <= 0 || ttl > maxPresignTTL {
+		return "", fmt.Errorf("presigned url ttl must be between 1s and %s", maxPresignTTL)
+	}
+
+	return s.client.PresignGetObject(ctx, s.config.BucketName, s.objectKey(url), ttl)
+}
+
+// objectKey admite tanto la URL devuelta por UploadFile como la clave del objeto
+func (s *s3FileService) objectKey(fileURL string) string {
+	prefix := s.client.ObjectURL(s.config.BucketName, "")
+	if !strings.HasPrefix(fileURL, prefix) {
+		return strings.TrimPrefix(fileURL, "/")
+	}
+
+	key := strings.TrimPrefix(fileURL, prefix)
+	if unescaped, err := url.PathUnescape(key); err == nil {
+		key = unescaped
+	}
+	return key
+}
+
+// sizeLimitedReader falla con ErrFileTooLarge en cuanto se lee más de limit bytes y cuenta los leídos
+type sizeLimitedReader struct {
+	reader io.Reader
+	limit  int64
+	read   int64
+}
+
+func (r *sizeLimitedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.read > r.limit {
+		return n, ErrFileTooLarge
+	}
+	return n, err
+}
+
+// S3Config son los datos de conexión del cliente S3
+type S3Config struct {
+	Region          string
+	Endpoint        string // Vacío usa AWS; con valor (p. ej. MinIO) se usan rutas path-style
+	AccessKeyID     string // Vacío usa la cadena de credenciales por defecto del SDK (entorno, perfil, rol de IAM)
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// sdkS3Client implementa S3Client con aws-sdk-go-v2; las subidas van por el upload manager, que trocea el
+// cuerpo en partes sin cargarlo entero en memoria
+type sdkS3Client struct {
+	config    S3Config
+	client    *s3.Client
+	uploader  *manager.Uploader
+	presigner *s3.PresignClient
+}
+
+// NewSDKS3Client crea el cliente S3 a partir de la configuración por defecto del SDK
+func NewSDKS3Client(ctx context.Context, config S3Config, timeout time.Duration) (S3Client, error) {
+	opts := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithRegion(config.Region),
+		awsconfig.WithHTTPClient(awshttp.NewBuildableClient().WithTimeout(timeout)),
+	}
+	if config.AccessKeyID != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(config.AccessKeyID, config.SecretAccessKey, config.SessionToken),
+		))
+	}
+
+	awsConfig, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsConfig, func(o *s3.Options) {
+		if config.Endpoint != "" {
+			o.BaseEndpoint = aws.String(config.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	return &sdkS3Client{
+		config:    config,
+		client:    client,
+		uploader:  manager.NewUploader(client),
+		presigner: s3.NewPresignClient(client),
+	}, nil
+}
+
+func (c *sdkS3Client) PutObject(ctx context.Context, bucket string, key string, body io.Reader, contentType string) error {
+	_, err := c.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put S3 object: %w", err)
+	}
+	return nil
+}
+
+func (c *sdkS3Client) HeadObject(ctx context.Context, bucket string, key string) (*S3ObjectInfo, error) {
+	output, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, s3Error("head", err)
+	}
+
+	return &S3ObjectInfo{
+		Size:        aws.ToInt64(output.ContentLength),
+		ContentType: aws.ToString(output.ContentType),
+	}, nil
+}
+
+func (c *sdkS3Client) GetObject(ctx context.Context, bucket string, key string) (io.ReadCloser, *S3ObjectInfo, error) {
+	output, err := c.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, nil, s3Error("get", err)
+	}
+
+	return output.Body, &S3ObjectInfo{
+		Size:        aws.ToInt64(output.ContentLength),
+		ContentType: aws.ToString(output.ContentType),
+	}, nil
+}
+
+func (c *sdkS3Client) DeleteObject(ctx context.Context, bucket string, key string) error {
+	_, err := c.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return s3Error("delete", err)
+	}
+	return nil
+}
+
+func (c *sdkS3Client) ObjectURL(bucket string, key string) string {
+	escaped := (&url.URL{Path: key}).EscapedPath()
+	if c.config.Endpoint != "" {
+		return strings.TrimSuffix(c.config.Endpoint, "/") + "/" + bucket + "/" + escaped
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, c.config.Region, escaped)
+}
+
+func (c *sdkS3Client) PresignGetObject(ctx context.Context, bucket string, key string, ttl time.Duration) (string, error) {
+	request, err := c.presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign S3 object: %w", err)
+	}
+	return request.URL, nil
+}
+
+// s3Error traduce un 404 de S3 a ErrS3ObjectNotFound
+func s3Error(operation string, err error) error {
+	var responseErr *awshttp.ResponseError
+	if errors.As(err, &responseErr) && responseErr.HTTPStatusCode() == http.StatusNotFound {
+		return ErrS3ObjectNotFound
+	}
+	return fmt.Errorf("failed to %s S3 object: %w", operation, err)
+}
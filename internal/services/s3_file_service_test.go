@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockS3Client es un mock de S3Client; ObjectURL sigue el formato de AWS. PutObject lee el cuerpo antes de
+// registrar la llamada y devuelve el error de lectura como lo haría el SDK
+type MockS3Client struct {
+	mock.Mock
+}
+
+func (m *MockS3Client) PutObject(ctx context.Context, bucket string, key string, body io.Reader, contentType string) error {
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	args := m.Called(ctx, bucket, key, content, contentType)
+	return args.Error(0)
+}
+
+func (m *MockS3Client) HeadObject(ctx context.Context, bucket string, key string) (*S3ObjectInfo, error) {
+	args := m.Called(ctx, bucket, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*S3ObjectInfo), args.Error(1)
+}
+
+func (m *MockS3Client) GetObject(ctx context.Context, bucket string, key string) (io.ReadCloser, *S3ObjectInfo, error) {
+	args := m.Called(ctx, bucket, key)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(io.ReadCloser), args.Get(1).(*S3ObjectInfo), args.Error(2)
+}
+
+func (m *MockS3Client) DeleteObject(ctx context.Context, bucket string, key string) error {
+	args := m.Called(ctx, bucket, key)
+	return args.Error(0)
+}
+
+func (m *MockS3Client) ObjectURL(bucket string, key string) string {
+	return "https://" + bucket + ".s3.eu-west-1.amazonaws.com/" + (&url.URL{Path: key}).EscapedPath()
+}
+
+func (m *MockS3Client) PresignGetObject(ctx context.Context, bucket string, key string, ttl time.Duration) (string, error) {
+	args := m.Called(ctx, bucket, key, ttl)
+	return args.String(0), args.Error(1)
+}
+
+func newS3TestFileService(client S3Client) FileService {
+	return NewS3FileService(client, &config.FileStorageConfig{
+		Provider:    "s3",
+		BucketName:  "attachments",
+		MaxFileSize: 1024,
+	}, logger.NewLogger("debug"))
+}
+
+func TestS3FileService_UploadFile(t *testing.T) {
+	client := new(MockS3Client)
+	service := newS3TestFileService(client)
+
+	var key string
+	client.On("PutObject", mock.Anything, "attachments", mock.MatchedBy(func(k string) bool {
+		key = k
+		return strings.HasPrefix(k, "user123/") && strings.HasSuffix(k, ".png")
+	}), []byte("png-bytes"), "image/png").Return(nil)
+
+	response, err := service.UploadFile(context.Background(), UploadFileRequest{
+		File:     strings.NewReader("png-bytes"),
+		Filename: "foto.png",
+		Size:     9,
+		UserID:   "user123",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "https://attachments.s3.eu-west-1.amazonaws.com/"+key, response.URL)
+	assert.Equal(t, int64(9), response.Size)
+	assert.Equal(t, domain.AttachmentTypeImage, response.Type)
+	client.AssertExpectations(t)
+}
+
+func TestS3FileService_UploadFile_TooLarge(t *testing.T) {
+	client := new(MockS3Client)
+	service := newS3TestFileService(client)
+
+	// The declared size is wrong; the body itself exceeds the limit
+	_, err := service.UploadFile(context.Background(), UploadFileRequest{
+		File:     strings.NewReader(strings.Repeat("a", 2048)),
+		Filename: "big.bin",
+		Size:     10,
+		UserID:   "user123",
+	})
+
+	assert.ErrorIs(t, err, ErrFileTooLarge)
+	client.AssertNotCalled(t, "PutObject", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestS3FileService_GetFileInfoAndDelete(t *testing.T) {
+	client := new(MockS3Client)
+	service := newS3TestFileService(client)
+
+	url := "https://attachments.s3.eu-west-1.amazonaws.com/user%40example/file_1.pdf"
+	client.On("HeadObject", mock.Anything, "attachments", "user@example/file_1.pdf").Return(&S3ObjectInfo{Size: 42}, nil).Once()
+	client.On("HeadObject", mock.Anything, "attachments", "user@example/file_1.pdf").Return(nil, ErrS3ObjectNotFound)
+	client.On("DeleteObject", mock.Anything, "attachments", "user@example/file_1.pdf").Return(nil).Once()
+	client.On("DeleteObject", mock.Anything, "attachments", "user@example/file_1.pdf").Return(ErrS3ObjectNotFound)
+
+	info, err := service.GetFileInfo(context.Background(), url)
+	require.NoError(t, err)
+	assert.True(t, info.Exists)
+	assert.Equal(t, "file_1.pdf", info.Filename)
+	assert.Equal(t, int64(42), info.Size)
+
+	require.NoError(t, service.DeleteFile(context.Background(), url))
+
+	info, err = service.GetFileInfo(context.Background(), url)
+	require.NoError(t, err)
+	assert.False(t, info.Exists)
+
+	// Deleting it again reports the missing object
+	assert.ErrorIs(t, service.DeleteFile(context.Background(), url), domain.ErrNotFound)
+}
+
+func TestSDKS3Client(t *testing.T) {
+	var put *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			put = r
+			body, _ = io.ReadAll(r.Body)
+		case http.MethodHead:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewSDKS3Client(context.Background(), S3Config{Region: "eu-west-1", Endpoint: server.URL, AccessKeyID: "AKID", SecretAccessKey: "secret"}, time.Second)
+	require.NoError(t, err)
+
+	// The body is streamed to the path-style endpoint and signed with SigV4
+	require.NoError(t, client.PutObject(context.Background(), "attachments", "user 1/a.txt", strings.NewReader("hola"), "text/plain"))
+	require.NotNil(t, put)
+	assert.Equal(t, "/attachments/user%201/a.txt", put.URL.EscapedPath())
+	assert.Equal(t, "hola", string(body))
+	assert.Equal(t, "text/plain", put.Header.Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(put.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+
+	_, err = client.HeadObject(context.Background(), "attachments", "missing.txt")
+	assert.ErrorIs(t, err, ErrS3ObjectNotFound)
+
+	assert.Equal(t, server.URL+"/attachments/user%201/a.txt", client.ObjectURL("attachments", "user 1/a.txt"))
+
+	presigned, err := client.PresignGetObject(context.Background(), "attachments", "user 1/a.txt", time.Hour)
+	require.NoError(t, err)
+	parsed, err := url.Parse(presigned)
+	require.NoError(t, err)
+	assert.Equal(t, "/attachments/user%201/a.txt", parsed.EscapedPath())
+	assert.Equal(t, "3600", parsed.Query().Get("X-Amz-Expires"))
+	assert.NotEmpty(t, parsed.Query().Get("X-Amz-Signature"))
+}
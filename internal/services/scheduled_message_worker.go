@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+// ScheduledMessageWorker envía los mensajes programados cuya hora ya llegó por el flujo normal de SendMessage
+type ScheduledMessageWorker struct {
+	scheduledRepo    domain.ScheduledMessageRepository
+	messagingService MessagingService
+	config           config.SchedulingConfig
+	logger           logger.Logger
+}
+
+func NewScheduledMessageWorker(
+	scheduledRepo domain.ScheduledMessageRepository,
+	messagingService MessagingService,
+	config config.SchedulingConfig,
+	logger logger.Logger,
+) *ScheduledMessageWorker {
+	return &ScheduledMessageWorker{
+		scheduledRepo:    scheduledRepo,
+		messagingService: messagingService,
+		config:           config,
+		logger:           logger,
+	}
+}
+
+// Start ejecuta el worker hasta que se cancele el contexto
+func (w *ScheduledMessageWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.config.WorkerInterval)
+	defer ticker.Stop()
+
+	w.logger.Info("Scheduled message worker started", map[string]interface{}{
+		"interval": w.config.WorkerInterval.String(),
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("Scheduled message worker stopped")
+			return
+		case <-ticker.C:
+			w.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce recupera los envíos abandonados en sending, envía un lote de mensajes programados vencidos y
+// devuelve cuántos se enviaron
+func (w *ScheduledMessageWorker) RunOnce(ctx context.Context) int {
+	w.releaseStale(ctx)
+
+	due, err := w.scheduledRepo.GetDue(ctx, time.Now(), w.config.WorkerBatchSize)
+	if err != nil {
+		w.logger.Error("Failed to load due scheduled messages", err)
+		return 0
+	}
+
+	sent := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		if w.send(ctx, &due[i]) {
+			sent++
+		}
+	}
+
+	return sent
+}
+
+// releaseStale devuelve a la cola los envíos reclamados hace más de SendingLease, cuya instancia cayó antes de
+// registrar el resultado
+func (w *ScheduledMessageWorker) releaseStale(ctx context.Context) {
+	now := time.Now()
+	released, err := w.scheduledRepo.ReleaseStale(ctx, now.Add(-w.config.SendingLease), now)
+	if err != nil {
+		w.logger.Error("Failed to release stale scheduled messages", err)
+		return
+	}
+	if released > 0 {
+		w.logger.Warn("Released stale scheduled messages", map[string]interface{}{
+			"released": released,
+			"lease":    w.config.SendingLease.String(),
+		})
+	}
+}
+
+// send reclama el envío y lo envía. Si la instancia cae a medias, releaseStale lo recupera cuando vence el
+// lease: no se repite si el mensaje llegó a guardarse
+func (w *ScheduledMessageWorker) send(ctx context.Context, scheduled *domain.ScheduledMessage) bool {
+	scheduled.Status = domain.ScheduledMessageStatusSending
+	scheduled.UpdatedAt = time.Now()
+	claimed, err := w.scheduledRepo.UpdateStatus(ctx, scheduled, domain.ScheduledMessageStatusScheduled)
+	if err != nil {
+		w.logger.Error("Failed to claim scheduled message", err)
+		return false
+	}
+	// Cancelled, or claimed by another instance
+	if !claimed {
+		return false
+	}
+
+	message, sendErr := w.messagingService.SendMessage(ctx, scheduledSendRequest(scheduled))
+
+	scheduled.UpdatedAt = time.Now()
+	if sendErr != nil {
+		scheduled.Status = domain.ScheduledMessageStatusFailed
+		scheduled.Error = sendErr.Error()
+		w.logger.Warn("Failed to send scheduled message", map[string]interface{}{
+			"scheduled_message_id": scheduled.ID,
+			"conversation_id":      scheduled.ConversationID,
+			"error":                sendErr.Error(),
+		})
+	} else {
+		scheduled.Status = domain.ScheduledMessageStatusSent
+		scheduled.MessageID = &message.ID
+	}
+
+	// The outcome is recorded even if the worker is stopping
+	if _, err := w.scheduledRepo.UpdateStatus(context.WithoutCancel(ctx), scheduled, domain.ScheduledMessageStatusSending); err != nil {
+		w.logger.Error("Failed to record scheduled message outcome", err)
+	}
+
+	return sendErr == nil
+}
+
+// scheduledSendRequest es la petición de envío del mensaje programado; el mensaje enviado guarda en sus
+// metadatos el ID del envío programado
+func scheduledSendRequest(scheduled *domain.ScheduledMessage) SendMessageRequest {
+	metadata := make(map[string]interface{}, len(scheduled.Metadata)+1)
+	for key, value := range scheduled.Metadata {
+		metadata[key] = value
+	}
+	metadata[domain.MetadataScheduledMessageID] = scheduled.ID
+
+	req := SendMessageRequest{
+		ConversationID: scheduled.ConversationID,
+		SenderType:     scheduled.SenderType,
+		SenderID:       scheduled.SenderID,
+		Content:        scheduled.Content,
+		ContentType:    scheduled.ContentType,
+		Metadata:       metadata,
+		ExpiresAt:      scheduled.ExpiresAt,
+		ReplyToID:      scheduled.ReplyToID,
+	}
+	for _, attachment := range scheduled.Attachments {
+		req.Attachments = append(req.Attachments, CreateAttachmentRequest{
+			URL:          attachment.URL,
+			Type:         attachment.Type,
+			Size:         attachment.Size,
+			Filename:     attachment.Filename,
+			ThumbnailURL: attachment.ThumbnailURL,
+		})
+	}
+
+	return req
+}
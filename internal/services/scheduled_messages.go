@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrScheduledMessagesDisabled indica que el servicio no guarda envíos programados
+	ErrScheduledMessagesDisabled = errors.New("scheduled messages are not enabled")
+	// ErrInvalidScheduledMessage indica un envío programado con datos no válidos
+	ErrInvalidScheduledMessage = errors.New("invalid scheduled message")
+	// ErrScheduledMessageNotPending indica que el envío programado ya se envió, falló o se canceló
+	ErrScheduledMessageNotPending = errors.New("scheduled message is no longer pending")
+)
+
+// WithScheduledMessages habilita los envíos con scheduled_at; ScheduledMessageWorker los envía cuando llega
+// su hora
+func WithScheduledMessages(repo domain.ScheduledMessageRepository) MessagingServiceOption {
+	return func(s *messagingService) {
+		s.scheduledRepo = repo
+	}
+}
+
+// IsScheduled indica si el envío tiene que esperar a su hora y va por ScheduleMessage; un scheduled_at pasado
+// se envía ya con SendMessage
+func (r SendMessageRequest) IsScheduled(now time.Time) bool {
+	return r.ScheduledAt != nil && r.ScheduledAt.After(now)
+}
+
+// ScheduleMessage guarda el envío para ScheduledAt y devuelve el envío programado. El mensaje pasa ya las
+// reglas de contenido, los hooks previos al envío y la comprobación de adjuntos, para rechazarlo ahora y no
+// horas después; al enviarlo se vuelven a aplicar sobre el mensaje guardado
+func (s *messagingService) ScheduleMessage(ctx context.Context, req SendMessageRequest) (*domain.ScheduledMessage, error) {
+	if s.scheduledRepo == nil {
+		return nil, ErrScheduledMessagesDisabled
+	}
+	if req.ScheduledAt == nil {
+		return nil, fmt.Errorf("%w: scheduled_at is required", ErrInvalidScheduledMessage)
+	}
+	// Nobody is waiting on the provider when the worker sends it
+	if req.DeliveryMode == DeliveryModeSync {
+		return nil, fmt.Errorf("%w: scheduled messages cannot use sync delivery", ErrInvalidScheduledMessage)
+	}
+	if !isValidDeliveryMode(req.DeliveryMode) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDeliveryMode, req.DeliveryMode)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(*req.ScheduledAt) {
+		return nil, fmt.Errorf("%w: expires_at must be after scheduled_at", ErrInvalidScheduledMessage)
+	}
+
+	conversation, err := s.GetConversation(ctx, req.ConversationID, req.SenderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateReplyTo(ctx, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkScheduledMessage(ctx, req, conversation); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	scheduled := &domain.ScheduledMessage{
+		ID:             uuid.New().String(),
+		ConversationID: req.ConversationID,
+		SenderType:     req.SenderType,
+		SenderID:       req.SenderID,
+		Content:        req.Content,
+		ContentType:    req.ContentType,
+		Metadata:       domain.JSONB(req.Metadata),
+		ReplyToID:      req.ReplyToID,
+		ExpiresAt:      req.ExpiresAt,
+		ScheduledAt:    req.ScheduledAt.UTC(),
+		Status:         domain.ScheduledMessageStatusScheduled,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	for _, attachmentReq := range req.Attachments {
+		// The attachment rows are created with the message when it is sent
+		scheduled.Attachments = append(scheduled.Attachments, domain.Attachment{
+			URL:          attachmentReq.URL,
+			Type:         attachmentReq.Type,
+			Size:         attachmentReq.Size,
+			Filename:     attachmentReq.Filename,
+			ThumbnailURL: attachmentReq.ThumbnailURL,
+			CreatedAt:    now,
+		})
+	}
+
+	if err := s.scheduledRepo.Create(ctx, scheduled); err != nil {
+		return nil, fmt.Errorf("failed to schedule message: %w", err)
+	}
+
+	s.log(ctx).Info("Message scheduled", map[string]interface{}{
+		"scheduled_message_id": scheduled.ID,
+		"conversation_id":      scheduled.ConversationID,
+		"sender_id":            scheduled.SenderID,
+		"scheduled_at":         scheduled.ScheduledAt,
+	})
+
+	return scheduled, nil
+}
+
+// checkScheduledMessage ejecuta los hooks previos al envío sobre una copia del mensaje tal como saldrá y
+// comprueba sus adjuntos. Lo que los hooks cambian en la copia se descarta: se guarda la petición original
+func (s *messagingService) checkScheduledMessage(ctx context.Context, req SendMessageRequest, conversation *domain.Conversation) error {
+	metadata := make(domain.JSONB, len(req.Metadata))
+	for key, value := range req.Metadata {
+		metadata[key] = value
+	}
+
+	send := &SendContext{
+		Request:      req,
+		Conversation: conversation,
+		Message: &domain.Message{
+			ID:             uuid.New().String(),
+			ConversationID: req.ConversationID,
+			SenderType:     req.SenderType,
+			SenderID:       req.SenderID,
+			Content:        req.Content,
+			ContentType:    req.ContentType,
+			Metadata:       metadata,
+			Timestamp:      *req.ScheduledAt,
+			ExpiresAt:      req.ExpiresAt,
+			ReplyToID:      req.ReplyToID,
+		},
+	}
+
+	if err := s.runSendHooks(ctx, SendHookPrePersist, send); err != nil {
+		s.log(ctx).Warn("Scheduled message rejected by send hook", map[string]interface{}{
+			"conversation_id": req.ConversationID,
+			"sender_id":       req.SenderID,
+			"error":           err.Error(),
+		})
+		return err
+	}
+
+	return s.verifyAttachmentFiles(ctx, req.SenderID, req.Attachments)
+}
+
+// CancelScheduledMessage cancela un envío programado que aún no se ha enviado. Pueden cancelarlo quien lo
+// programó y el propietario de la conversación
+func (s *messagingService) CancelScheduledMessage(ctx context.Context, conversationID string, scheduledMessageID string, userID string) error {
+	if s.scheduledRepo == nil {
+		return ErrScheduledMessagesDisabled
+	}
+
+	conversation, err := s.GetConversation(ctx, conversationID, userID)
+	if err != nil {
+		return err
+	}
+
+	scheduled, err := s.scheduledRepo.GetByID(ctx, scheduledMessageID)
+	if err != nil {
+		return fmt.Errorf("failed to get scheduled message: %w", err)
+	}
+	if scheduled.ConversationID != conversationID {
+		return fmt.Errorf("scheduled message %w", domain.ErrNotFound)
+	}
+	if scheduled.SenderID != userID && conversation.UserID != userID {
+		return fmt.Errorf("scheduled message %w: %w", domain.ErrAccessDenied, domain.ErrNotFound)
+	}
+
+	scheduled.Status = domain.ScheduledMessageStatusCancelled
+	scheduled.UpdatedAt = time.Now()
+	updated, err := s.scheduledRepo.UpdateStatus(ctx, scheduled, domain.ScheduledMessageStatusScheduled)
+	if err != nil {
+		return fmt.Errorf("failed to cancel scheduled message: %w", err)
+	}
+	// The worker may have claimed it between the read and the update
+	if !updated {
+		return ErrScheduledMessageNotPending
+	}
+
+	s.log(ctx).Info("Scheduled message cancelled", map[string]interface{}{
+		"scheduled_message_id": scheduled.ID,
+		"conversation_id":      conversationID,
+		"user_id":              userID,
+	})
+
+	return nil
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockScheduledMessageRepository es un mock del repositorio de envíos programados
+type MockScheduledMessageRepository struct {
+	mock.Mock
+}
+
+func (m *MockScheduledMessageRepository) Create(ctx context.Context, message *domain.ScheduledMessage) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
+
+func (m *MockScheduledMessageRepository) GetByID(ctx context.Context, id string) (*domain.ScheduledMessage, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*domain.ScheduledMessage), args.Error(1)
+}
+
+func (m *MockScheduledMessageRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]domain.ScheduledMessage, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]domain.ScheduledMessage), args.Error(1)
+}
+
+func (m *MockScheduledMessageRepository) UpdateStatus(ctx context.Context, message *domain.ScheduledMessage, from domain.ScheduledMessageStatus) (bool, error) {
+	args := m.Called(ctx, message, from)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockScheduledMessageRepository) ReleaseStale(ctx context.Context, staleBefore time.Time, now time.Time) (int64, error) {
+	args := m.Called(ctx, staleBefore, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func scheduledTextRequest(scheduledAt time.Time) SendMessageRequest {
+	return SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeAgent,
+		SenderID:       "user123",
+		Content:        "Your order ships tomorrow",
+		ContentType:    domain.ContentTypeText,
+		ScheduledAt:    &scheduledAt,
+	}
+}
+
+func TestMessagingService_SendMessage_PastScheduledAtSendsImmediately(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockScheduledRepo := new(MockScheduledMessageRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo}, WithScheduledMessages(mockScheduledRepo))
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+	mockConversationRepo.On("TouchUpdatedAt", mock.Anything, "conv123", mock.AnythingOfType("time.Time")).Return(nil)
+
+	// Execute
+	message, err := service.SendMessage(context.Background(), scheduledTextRequest(time.Now().Add(-time.Minute)))
+
+	// Assert
+	require.NoError(t, err)
+	assert.NotEmpty(t, message.ID)
+	mockMessageRepo.AssertCalled(t, "Create", mock.Anything, mock.AnythingOfType("*domain.Message"))
+	mockScheduledRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_ScheduleMessage(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockScheduledRepo := new(MockScheduledMessageRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo}, WithScheduledMessages(mockScheduledRepo))
+
+	scheduledAt := time.Now().Add(time.Hour)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockScheduledRepo.On("Create", mock.Anything, mock.MatchedBy(func(s *domain.ScheduledMessage) bool {
+		return s.Status == domain.ScheduledMessageStatusScheduled && s.ScheduledAt.Equal(scheduledAt) && s.Content == "Your order ships tomorrow"
+	})).Return(nil)
+
+	// Execute
+	scheduled, err := service.ScheduleMessage(context.Background(), scheduledTextRequest(scheduledAt))
+
+	// Assert: nothing is delivered until the worker picks it up
+	require.NoError(t, err)
+	assert.NotEmpty(t, scheduled.ID)
+	assert.Equal(t, domain.ScheduledMessageStatusScheduled, scheduled.Status)
+	assert.True(t, scheduled.ScheduledAt.Equal(scheduledAt))
+	mockScheduledRepo.AssertExpectations(t)
+	mockMessageRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockConversationRepo.AssertNotCalled(t, "TouchUpdatedAt", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_SendMessage_RejectsFutureScheduledAt(t *testing.T) {
+	// Setup
+	mockMessageRepo := new(MockMessageRepository)
+	service := newTestMessagingService(testServiceDeps{messageRepo: mockMessageRepo}, WithScheduledMessages(new(MockScheduledMessageRepository)))
+
+	// Execute
+	_, err := service.SendMessage(context.Background(), scheduledTextRequest(time.Now().Add(time.Hour)))
+
+	// Assert: a scheduled send never goes out early
+	assert.ErrorIs(t, err, ErrInvalidScheduledMessage)
+	mockMessageRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_ScheduleMessage_RejectsSyncDelivery(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockScheduledRepo := new(MockScheduledMessageRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo}, WithScheduledMessages(mockScheduledRepo))
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	req := scheduledTextRequest(time.Now().Add(time.Hour))
+	req.DeliveryMode = DeliveryModeSync
+
+	// Execute
+	_, err := service.ScheduleMessage(context.Background(), req)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidScheduledMessage)
+	mockScheduledRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_ScheduleMessage_ValidatesContentNow(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockScheduledRepo := new(MockScheduledMessageRepository)
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		NewNoOpEventPublisher(),
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithScheduledMessages(mockScheduledRepo),
+		WithSendHooks(NewSendHook("deny_refunds", SendHookPrePersist, func(ctx context.Context, send *SendContext) error {
+			if send.Message.Content == "refund" {
+				return errors.New("refunds are handled by phone")
+			}
+			return nil
+		})),
+	)
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	empty := scheduledTextRequest(time.Now().Add(time.Hour))
+	empty.Content = ""
+	rejected := scheduledTextRequest(time.Now().Add(time.Hour))
+	rejected.Content = "refund"
+
+	// Execute
+	_, ruleErr := service.ScheduleMessage(context.Background(), empty)
+	_, hookErr := service.ScheduleMessage(context.Background(), rejected)
+
+	// Assert: the sender hears about it now instead of finding a failed send later
+	var contentErr *ContentRuleError
+	assert.ErrorAs(t, ruleErr, &contentErr)
+	assert.ErrorIs(t, hookErr, ErrMessageRejected)
+	mockScheduledRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_CancelScheduledMessage(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockScheduledRepo := new(MockScheduledMessageRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo}, WithScheduledMessages(mockScheduledRepo))
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockScheduledRepo.On("GetByID", mock.Anything, "sched1").Return(&domain.ScheduledMessage{
+		ID: "sched1", ConversationID: "conv123", SenderID: "user123", Status: domain.ScheduledMessageStatusScheduled,
+	}, nil)
+	mockScheduledRepo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(s *domain.ScheduledMessage) bool {
+		return s.Status == domain.ScheduledMessageStatusCancelled
+	}), domain.ScheduledMessageStatusScheduled).Return(true, nil).Once()
+
+	// Execute
+	err := service.CancelScheduledMessage(context.Background(), "conv123", "sched1", "user123")
+
+	// Assert
+	require.NoError(t, err)
+	mockScheduledRepo.AssertExpectations(t)
+
+	// Execute: the worker already claimed it
+	mockScheduledRepo.On("UpdateStatus", mock.Anything, mock.Anything, domain.ScheduledMessageStatusScheduled).Return(false, nil)
+	err = service.CancelScheduledMessage(context.Background(), "conv123", "sched1", "user123")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrScheduledMessageNotPending)
+}
+
+func TestMessagingService_CancelScheduledMessage_OtherConversation(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockScheduledRepo := new(MockScheduledMessageRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo}, WithScheduledMessages(mockScheduledRepo))
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockScheduledRepo.On("GetByID", mock.Anything, "sched1").Return(&domain.ScheduledMessage{
+		ID: "sched1", ConversationID: "conv999", SenderID: "user999", Status: domain.ScheduledMessageStatusScheduled,
+	}, nil)
+
+	// Execute
+	err := service.CancelScheduledMessage(context.Background(), "conv123", "sched1", "user123")
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	mockScheduledRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestScheduledMessageWorker_SendsDueMessages(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockScheduledRepo := new(MockScheduledMessageRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo}, WithScheduledMessages(mockScheduledRepo))
+	worker := NewScheduledMessageWorker(mockScheduledRepo, service, config.SchedulingConfig{
+		WorkerEnabled:   true,
+		WorkerInterval:  time.Second,
+		WorkerBatchSize: 10,
+	}, logger.NewLogger("debug"))
+
+	due := domain.ScheduledMessage{
+		ID:             "sched1",
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeAgent,
+		SenderID:       "user123",
+		Content:        "Your order ships tomorrow",
+		ContentType:    domain.ContentTypeText,
+		ScheduledAt:    time.Now().Add(-time.Second),
+		Status:         domain.ScheduledMessageStatusScheduled,
+	}
+	var sent *domain.Message
+	mockScheduledRepo.On("ReleaseStale", mock.Anything, mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	mockScheduledRepo.On("GetDue", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]domain.ScheduledMessage{due}, nil)
+	mockScheduledRepo.On("UpdateStatus", mock.Anything, mock.Anything, domain.ScheduledMessageStatusScheduled).Return(true, nil)
+	mockScheduledRepo.On("UpdateStatus", mock.Anything, mock.MatchedBy(func(s *domain.ScheduledMessage) bool {
+		return s.Status == domain.ScheduledMessageStatusSent && s.MessageID != nil && *s.MessageID == sent.ID
+	}), domain.ScheduledMessageStatusSending).Return(true, nil)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Run(func(args mock.Arguments) {
+		sent = args.Get(1).(*domain.Message)
+	}).Return(nil)
+	mockConversationRepo.On("TouchUpdatedAt", mock.Anything, "conv123", mock.AnythingOfType("time.Time")).Return(nil)
+
+	// Execute
+	count := worker.RunOnce(context.Background())
+
+	// Assert: the message goes through the normal send flow and remembers where it came from
+	assert.Equal(t, 1, count)
+	require.NotNil(t, sent)
+	assert.Equal(t, "Your order ships tomorrow", sent.Content)
+	assert.Equal(t, "sched1", sent.Metadata[domain.MetadataScheduledMessageID])
+	mockScheduledRepo.AssertExpectations(t)
+}
+
+func TestScheduledMessageWorker_SkipsCancelledMessages(t *testing.T) {
+	// Setup: the message was cancelled after GetDue read it
+	mockMessageRepo := new(MockMessageRepository)
+	mockScheduledRepo := new(MockScheduledMessageRepository)
+	service := newTestMessagingService(testServiceDeps{messageRepo: mockMessageRepo}, WithScheduledMessages(mockScheduledRepo))
+	worker := NewScheduledMessageWorker(mockScheduledRepo, service, config.SchedulingConfig{WorkerBatchSize: 10}, logger.NewLogger("debug"))
+
+	mockScheduledRepo.On("ReleaseStale", mock.Anything, mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(int64(0), nil)
+	mockScheduledRepo.On("GetDue", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]domain.ScheduledMessage{{ID: "sched1", ConversationID: "conv123"}}, nil)
+	mockScheduledRepo.On("UpdateStatus", mock.Anything, mock.Anything, domain.ScheduledMessageStatusScheduled).Return(false, nil)
+
+	// Execute
+	count := worker.RunOnce(context.Background())
+
+	// Assert
+	assert.Equal(t, 0, count)
+	mockMessageRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestScheduledMessageWorker_ReleasesStaleClaims(t *testing.T) {
+	// Setup
+	mockScheduledRepo := new(MockScheduledMessageRepository)
+	service := newTestMessagingService(testServiceDeps{}, WithScheduledMessages(mockScheduledRepo))
+	worker := NewScheduledMessageWorker(mockScheduledRepo, service, config.SchedulingConfig{
+		WorkerBatchSize: 10,
+		SendingLease:    5 * time.Minute,
+	}, logger.NewLogger("debug"))
+
+	var staleBefore, releasedAt time.Time
+	mockScheduledRepo.On("ReleaseStale", mock.Anything, mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Run(func(args mock.Arguments) {
+		staleBefore = args.Get(1).(time.Time)
+		releasedAt = args.Get(2).(time.Time)
+	}).Return(int64(1), nil)
+	mockScheduledRepo.On("GetDue", mock.Anything, mock.AnythingOfType("time.Time"), 10).Return([]domain.ScheduledMessage{}, nil)
+
+	// Execute
+	worker.RunOnce(context.Background())
+
+	// Assert: claims older than the lease are released before loading the batch
+	mockScheduledRepo.AssertExpectations(t)
+	assert.Equal(t, 5*time.Minute, releasedAt.Sub(staleBefore))
+}
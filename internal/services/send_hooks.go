@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// ErrMessageRejected indica que un hook previo a la persistencia rechazó el mensaje
+var ErrMessageRejected = errors.New("message rejected")
+
+// SendHookStage indica en qué punto del envío se ejecuta un hook
+type SendHookStage string
+
+const (
+	// SendHookPrePersist se ejecuta antes de guardar el mensaje; un error cancela el envío
+	SendHookPrePersist SendHookStage = "pre_persist"
+	// SendHookPostPersist se ejecuta con el mensaje ya guardado; los errores solo se registran
+	SendHookPostPersist SendHookStage = "post_persist"
+)
+
+// SendContext contiene el estado compartido por la cadena de hooks de un envío
+type SendContext struct {
+	Request      SendMessageRequest
+	Conversation *domain.Conversation
+	Message      *domain.Message
+
+	outboxEvent *domain.OutboxEvent // Fila de event_outbox con el evento del mensaje, si hay outbox
+}
+
+// SendHook es un paso configurable del pipeline de SendMessage. Puede modificar
+// send.Message, cancelar el envío devolviendo un error o producir efectos secundarios
+type SendHook interface {
+	Name() string
+	Stage() SendHookStage
+	Handle(ctx context.Context, send *SendContext) error
+}
+
+// SendHookFunc adapta una función a la interfaz SendHook
+type SendHookFunc func(ctx context.Context, send *SendContext) error
+
+type sendHook struct {
+	name  string
+	stage SendHookStage
+	fn    SendHookFunc
+}
+
+// NewSendHook crea un hook a partir de una función
+func NewSendHook(name string, stage SendHookStage, fn SendHookFunc) SendHook {
+	return &sendHook{name: name, stage: stage, fn: fn}
+}
+
+func (h *sendHook) Name() string         { return h.name }
+func (h *sendHook) Stage() SendHookStage { return h.stage }
+
+func (h *sendHook) Handle(ctx context.Context, send *SendContext) error {
+	return h.fn(ctx, send)
+}
+
+// WithSendHooks añade hooks al final de la cadena de envío, en el orden indicado. Para colocarlos entre
+// los incorporados se usan WithSendHooksBefore y WithSendHooksAfter
+func WithSendHooks(hooks ...SendHook) MessagingServiceOption {
+	return func(s *messagingService) {
+		s.sendHooks = append(s.sendHooks, hooks...)
+	}
+}
+
+// WithSendHooksBefore inserta hooks justo antes del hook llamado name, que puede ser uno incorporado
+// ("validation", "event_publish", "reactivate_abandoned") o uno añadido por otra opción. Los hooks
+// solo se ordenan respecto a los de su misma etapa, así que el de referencia debe compartirla
+func WithSendHooksBefore(name string, hooks ...SendHook) MessagingServiceOption {
+	return func(s *messagingService) {
+		s.hookPlacements = append(s.hookPlacements, sendHookPlacement{anchor: name, hooks: hooks})
+	}
+}
+
+// WithSendHooksAfter inserta hooks justo después del hook llamado name; ver WithSendHooksBefore
+func WithSendHooksAfter(name string, hooks ...SendHook) MessagingServiceOption {
+	return func(s *messagingService) {
+		s.hookPlacements = append(s.hookPlacements, sendHookPlacement{anchor: name, after: true, hooks: hooks})
+	}
+}
+
+// sendHookPlacement es una inserción pendiente relativa a un hook con nombre. Se resuelven después de
+// aplicar todas las opciones, así que la referencia puede ser un hook añadido por una opción posterior
+type sendHookPlacement struct {
+	anchor string
+	after  bool
+	hooks  []SendHook
+}
+
+// placeSendHooks aplica las inserciones pendientes en varias pasadas hasta que ninguna referencia nueva
+// aparece. Las que siguen sin referencia añaden sus hooks al final de la cadena
+func (s *messagingService) placeSendHooks() {
+	pending := s.hookPlacements
+	for len(pending) > 0 {
+		var unresolved []sendHookPlacement
+		for _, placement := range pending {
+			if !s.insertSendHooks(placement) {
+				unresolved = append(unresolved, placement)
+			}
+		}
+
+		if len(unresolved) == len(pending) {
+			for _, placement := range unresolved {
+				s.logger.Warn("Send hook anchor not found, appending hooks", map[string]interface{}{
+					"anchor": placement.anchor,
+				})
+				s.sendHooks = append(s.sendHooks, placement.hooks...)
+			}
+			break
+		}
+		pending = unresolved
+	}
+	s.hookPlacements = nil
+}
+
+// insertSendHooks coloca los hooks junto a su referencia; devuelve false si la referencia no existe
+func (s *messagingService) insertSendHooks(placement sendHookPlacement) bool {
+	index := -1
+	for i, hook := range s.sendHooks {
+		if hook.Name() == placement.anchor {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return false
+	}
+
+	if placement.after {
+		index++
+	}
+	hooks := make([]SendHook, 0, len(s.sendHooks)+len(placement.hooks))
+	hooks = append(hooks, s.sendHooks[:index]...)
+	hooks = append(hooks, placement.hooks...)
+	s.sendHooks = append(hooks, s.sendHooks[index:]...)
+	return true
+}
+
+// defaultSendHooks devuelve los hooks incorporados: validación, publicación del evento y reactivación
+func (s *messagingService) defaultSendHooks() []SendHook {
+	return []SendHook{
+		NewSendHook("validation", SendHookPrePersist, s.validateMessage),
+		NewSendHook("event_publish", SendHookPostPersist, s.publishMessageEvent),
+		NewSendHook("reactivate_abandoned", SendHookPostPersist, s.reactivateAbandoned),
+	}
+}
+
+// NewEventPublishSendHook publica el evento message.received una vez guardado el mensaje
+func NewEventPublishSendHook(eventPublisher EventPublisher) SendHook {
+	return NewSendHook("event_publish", SendHookPostPersist, func(ctx context.Context, send *SendContext) error {
+		if eventPublisher == nil {
+			return nil
+		}
+
+		if err := eventPublisher.PublishMessageEvent(ctx, newMessageReceivedEvent(send.Message)); err != nil {
+			return fmt.Errorf("failed to publish message event: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// newMessageReceivedEvent crea el evento message.received del mensaje recién guardado
+func newMessageReceivedEvent(message *domain.Message) domain.MessageEvent {
+	return domain.MessageEvent{
+		Type:           "message.received",
+		ConversationID: message.ConversationID,
+		Message:        *message,
+		Timestamp:      time.Now(),
+	}
+}
+
+// reactivateAbandoned devuelve a activa una conversación abandonada cuando el cliente vuelve a escribir
+func (s *messagingService) reactivateAbandoned(ctx context.Context, send *SendContext) error {
+	if send.Conversation.Status != domain.ConversationStatusAbandoned || send.Message.SenderType != domain.SenderTypeUser {
+		return nil
+	}
+
+	now := time.Now()
+	send.Conversation.Status = domain.ConversationStatusActive
+	send.Conversation.StatusChangedAt = &now
+	send.Conversation.UpdatedAt = now
+
+	if err := s.conversationRepo.Update(ctx, send.Conversation); err != nil {
+		return fmt.Errorf("failed to reactivate conversation: %w", err)
+	}
+
+	if s.cacheService != nil {
+		_ = s.cacheService.DeleteConversation(ctx, send.Conversation.ID)
+	}
+	s.invalidateConversationList(ctx, send.Conversation.UserID)
+
+	return nil
+}
+
+// runSendHooks ejecuta en orden los hooks de la etapa indicada
+func (s *messagingService) runSendHooks(ctx context.Context, stage SendHookStage, send *SendContext) error {
+	for _, hook := range s.sendHooks {
+		if hook.Stage() != stage {
+			continue
+		}
+
+		if err := hook.Handle(ctx, send); err != nil {
+			if stage == SendHookPrePersist {
+				return fmt.Errorf("%w by %s: %w", ErrMessageRejected, hook.Name(), err)
+			}
+
+			s.log(ctx).Error("Send hook failed", map[string]interface{}{
+				"hook":       hook.Name(),
+				"message_id": send.Message.ID,
+				"error":      err.Error(),
+			})
+		}
+	}
+
+	return nil
+}
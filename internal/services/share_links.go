@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrShareLinksDisabled indica que el servicio no tiene secreto con el que firmar enlaces compartidos
+	ErrShareLinksDisabled = errors.New("share links are not enabled")
+	// ErrInvalidShareLink indica un enlace compartido con parámetros no válidos
+	ErrInvalidShareLink = errors.New("invalid share link")
+)
+
+// maxSharedMessages limita los mensajes que se sirven por página a través de un enlace compartido
+const maxSharedMessages = 200
+
+// shareLinks agrupa la configuración de los enlaces compartidos
+type shareLinks struct {
+	repo       domain.ShareLinkRepository
+	signer     *auth.ShareLinkSigner
+	defaultTTL time.Duration
+	maxTTL     time.Duration
+}
+
+// WithShareLinks habilita los enlaces compartidos firmados con signer. Un enlace sin duración usa
+// defaultTTL y ninguno puede durar más de maxTTL
+func WithShareLinks(repo domain.ShareLinkRepository, signer *auth.ShareLinkSigner, defaultTTL time.Duration, maxTTL time.Duration) MessagingServiceOption {
+	return func(s *messagingService) {
+		if repo == nil || !signer.Enabled() {
+			return
+		}
+
+		s.shareLinks = &shareLinks{
+			repo:       repo,
+			signer:     signer,
+			defaultTTL: defaultTTL,
+			maxTTL:     maxTTL,
+		}
+	}
+}
+
+// CreateShareLink crea un enlace que da acceso de lectura a la conversación a quien tenga su token, sin
+// ser participante, durante ttl (0 usa la duración por defecto). Por ahora solo hay enlaces de solo lectura
+func (s *messagingService) CreateShareLink(ctx context.Context, conversationID string, userID string, ttl time.Duration, readOnly bool) (*domain.ConversationShareLink, error) {
+	if s.shareLinks == nil {
+		return nil, ErrShareLinksDisabled
+	}
+	if !readOnly {
+		return nil, fmt.Errorf("%w: only read-only links are supported", ErrInvalidShareLink)
+	}
+	if ttl < 0 || ttl > s.shareLinks.maxTTL {
+		return nil, fmt.Errorf("%w: ttl must be between 0 and %s", ErrInvalidShareLink, s.shareLinks.maxTTL)
+	}
+	if ttl == 0 {
+		ttl = s.shareLinks.defaultTTL
+	}
+
+	if _, err := s.GetConversation(ctx, conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	link := &domain.ConversationShareLink{
+		ID:             uuid.New().String(),
+		ConversationID: conversationID,
+		CreatedBy:      userID,
+		ReadOnly:       readOnly,
+		// The token signs the expiry in whole seconds
+		ExpiresAt: now.Add(ttl).Truncate(time.Second),
+		CreatedAt: now,
+	}
+
+	if err := s.shareLinks.repo.Create(ctx, link); err != nil {
+		return nil, fmt.Errorf("failed to create share link: %w", err)
+	}
+	link.Token = s.shareLinks.signer.GenerateToken(link.ID, link.ConversationID, link.ExpiresAt)
+
+	s.log(ctx).Info("Share link created", map[string]interface{}{
+		"link_id":         link.ID,
+		"conversation_id": conversationID,
+		"expires_at":      link.ExpiresAt,
+		"user_id":         userID,
+	})
+
+	return link, nil
+}
+
+// ListShareLinks devuelve los enlaces de la conversación, incluidos los caducados y revocados, sin sus tokens
+func (s *messagingService) ListShareLinks(ctx context.Context, conversationID string, userID string) ([]domain.ConversationShareLink, error) {
+	if s.shareLinks == nil {
+		return nil, ErrShareLinksDisabled
+	}
+
+	if _, err := s.GetConversation(ctx, conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	links, err := s.shareLinks.repo.ListByConversation(ctx, conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list share links: %w", err)
+	}
+
+	return links, nil
+}
+
+// RevokeShareLink invalida el enlace en el acto, antes de que caduque
+func (s *messagingService) RevokeShareLink(ctx context.Context, conversationID string, linkID string, userID string) error {
+	if s.shareLinks == nil {
+		return ErrShareLinksDisabled
+	}
+
+	if _, err := s.GetConversation(ctx, conversationID, userID); err != nil {
+		return err
+	}
+
+	if err := s.shareLinks.repo.Revoke(ctx, conversationID, linkID, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke share link: %w", err)
+	}
+
+	s.log(ctx).Info("Share link revoked", map[string]interface{}{
+		"link_id":         linkID,
+		"conversation_id": conversationID,
+		"user_id":         userID,
+	})
+
+	return nil
+}
+
+// GetSharedConversation sirve la conversación de un enlace compartido con una página de sus mensajes. Un
+// token mal firmado, caducado o revocado se trata como inexistente para no revelar qué enlaces hubo
+func (s *messagingService) GetSharedConversation(ctx context.Context, token string, pagination domain.PaginationParams) (*domain.SharedConversation, error) {
+	if s.shareLinks == nil {
+		return nil, ErrShareLinksDisabled
+	}
+
+	link, err := s.resolveShareLink(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	conversation, err := s.conversationRepo.GetByID(ctx, link.ConversationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation: %w", err)
+	}
+
+	if pagination.Limit <= 0 || pagination.Limit > maxSharedMessages {
+		pagination.Limit = maxSharedMessages
+	}
+	messages, err := s.messageRepo.GetByConversationID(ctx, link.ConversationID, pagination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+	s.loadAttachments(ctx, messages)
+
+	return &domain.SharedConversation{
+		Conversation: *conversation,
+		Messages:     messages,
+		ReadOnly:     link.ReadOnly,
+		ExpiresAt:    link.ExpiresAt,
+	}, nil
+}
+
+func (s *messagingService) resolveShareLink(ctx context.Context, token string) (*domain.ConversationShareLink, error) {
+	linkID, err := auth.ShareLinkID(token)
+	if err != nil {
+		return nil, fmt.Errorf("share link %w", domain.ErrNotFound)
+	}
+
+	link, err := s.shareLinks.repo.GetByID(ctx, linkID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+
+	if err := s.shareLinks.signer.Verify(token, link.ConversationID, link.ExpiresAt); err != nil {
+		return nil, fmt.Errorf("share link %w", domain.ErrNotFound)
+	}
+	if link.RevokedAt != nil || !time.Now().Before(link.ExpiresAt) {
+		return nil, fmt.Errorf("share link %w", domain.ErrNotFound)
+	}
+
+	return link, nil
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockShareLinkRepository es un mock del repositorio de enlaces compartidos
+type MockShareLinkRepository struct {
+	mock.Mock
+}
+
+func (m *MockShareLinkRepository) Create(ctx context.Context, link *domain.ConversationShareLink) error {
+	args := m.Called(ctx, link)
+	return args.Error(0)
+}
+
+func (m *MockShareLinkRepository) GetByID(ctx context.Context, id string) (*domain.ConversationShareLink, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*domain.ConversationShareLink), args.Error(1)
+}
+
+func (m *MockShareLinkRepository) ListByConversation(ctx context.Context, conversationID string) ([]domain.ConversationShareLink, error) {
+	args := m.Called(ctx, conversationID)
+	return args.Get(0).([]domain.ConversationShareLink), args.Error(1)
+}
+
+func (m *MockShareLinkRepository) Revoke(ctx context.Context, conversationID string, id string, revokedAt time.Time) error {
+	args := m.Called(ctx, conversationID, id, revokedAt)
+	return args.Error(0)
+}
+
+func TestMessagingService_SharedConversation(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	mockAttachmentRepo := new(MockAttachmentRepository)
+	mockLinkRepo := new(MockShareLinkRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo, messageRepo: mockMessageRepo, attachmentRepo: mockAttachmentRepo}, WithShareLinks(mockLinkRepo, auth.NewShareLinkSigner("share-secret"), time.Hour, 24*time.Hour))
+
+	var stored *domain.ConversationShareLink
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockLinkRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.ConversationShareLink")).Run(func(args mock.Arguments) {
+		link := *args.Get(1).(*domain.ConversationShareLink)
+		stored = &link
+	}).Return(nil)
+
+	// Execute: the viewer is not a participant, the token is the only credential
+	link, err := service.CreateShareLink(context.Background(), "conv123", "user123", 0, true)
+	require.NoError(t, err)
+	require.NotEmpty(t, link.Token)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), link.ExpiresAt, 2*time.Second)
+
+	mockLinkRepo.On("GetByID", mock.Anything, link.ID).Return(stored, nil)
+	mockMessageRepo.On("GetByConversationID", mock.Anything, "conv123", mock.MatchedBy(func(p domain.PaginationParams) bool {
+		return p.Limit == maxSharedMessages
+	})).Return([]domain.Message{{ID: "msg1"}}, nil)
+	mockAttachmentRepo.On("GetByMessageID", mock.Anything, "msg1").Return([]domain.Attachment{}, nil)
+
+	shared, err := service.GetSharedConversation(context.Background(), link.Token, domain.PaginationParams{Limit: 1000})
+	require.NoError(t, err)
+	assert.Equal(t, "conv123", shared.Conversation.ID)
+	assert.Len(t, shared.Messages, 1)
+	assert.True(t, shared.ReadOnly)
+
+	// A tampered token is rejected
+	_, err = service.GetSharedConversation(context.Background(), link.ID+".deadbeef", domain.PaginationParams{})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	// So is a revoked link
+	revokedAt := time.Now()
+	stored.RevokedAt = &revokedAt
+	_, err = service.GetSharedConversation(context.Background(), link.Token, domain.PaginationParams{})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestMessagingService_SharedConversation_Expired(t *testing.T) {
+	// Setup
+	mockLinkRepo := new(MockShareLinkRepository)
+	service := newTestMessagingService(testServiceDeps{}, WithShareLinks(mockLinkRepo, auth.NewShareLinkSigner("share-secret"), time.Hour, 24*time.Hour))
+
+	expiresAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	token := auth.NewShareLinkSigner("share-secret").GenerateToken("link1", "conv123", expiresAt)
+	mockLinkRepo.On("GetByID", mock.Anything, "link1").Return(&domain.ConversationShareLink{ID: "link1", ConversationID: "conv123", ReadOnly: true, ExpiresAt: expiresAt}, nil)
+
+	// Execute
+	_, err := service.GetSharedConversation(context.Background(), token, domain.PaginationParams{})
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestMessagingService_CreateShareLink_Invalid(t *testing.T) {
+	// Setup
+	mockLinkRepo := new(MockShareLinkRepository)
+	service := newTestMessagingService(testServiceDeps{}, WithShareLinks(mockLinkRepo, auth.NewShareLinkSigner("share-secret"), time.Hour, 24*time.Hour))
+
+	// Execute: links can't outlive the configured maximum nor grant write access
+	_, err := service.CreateShareLink(context.Background(), "conv123", "user123", 48*time.Hour, true)
+	assert.ErrorIs(t, err, ErrInvalidShareLink)
+
+	_, err = service.CreateShareLink(context.Background(), "conv123", "user123", 0, false)
+	assert.ErrorIs(t, err, ErrInvalidShareLink)
+	mockLinkRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	// Without a signing secret share links are disabled
+	disabled := NewMessagingService(nil, nil, nil, nil, nil, logger.NewLogger("debug"), WithShareLinks(mockLinkRepo, auth.NewShareLinkSigner(""), time.Hour, time.Hour))
+	_, err = disabled.CreateShareLink(context.Background(), "conv123", "user123", 0, true)
+	assert.ErrorIs(t, err, ErrShareLinksDisabled)
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// ErrStatusChangeTooFrequent indica que el estado de la conversación cambió hace menos del intervalo mínimo
+var ErrStatusChangeTooFrequent = errors.New("status change too frequent")
+
+// StatusThrottleMode decide qué ocurre con un cambio de estado que llega antes del intervalo mínimo
+type StatusThrottleMode string
+
+const (
+	// StatusThrottleReject rechaza el cambio con ErrStatusChangeTooFrequent
+	StatusThrottleReject StatusThrottleMode = "reject"
+	// StatusThrottleDebounce descarta el cambio sin error, dejando el estado actual
+	StatusThrottleDebounce StatusThrottleMode = "debounce"
+)
+
+// ParseStatusThrottleMode interpreta el valor de configuración reject|debounce
+func ParseStatusThrottleMode(value string) (StatusThrottleMode, error) {
+	switch StatusThrottleMode(value) {
+	case StatusThrottleReject, StatusThrottleDebounce:
+		return StatusThrottleMode(value), nil
+	default:
+		return "", fmt.Errorf("invalid status throttle mode %q, expected reject or debounce", value)
+	}
+}
+
+// StatusChangeTooFrequentError acompaña a ErrStatusChangeTooFrequent con el tiempo que falta para poder cambiar el estado
+type StatusChangeTooFrequentError struct {
+	RetryAfter time.Duration
+}
+
+func (e *StatusChangeTooFrequentError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", ErrStatusChangeTooFrequent, e.RetryAfter.Round(time.Second))
+}
+
+func (e *StatusChangeTooFrequentError) Unwrap() error {
+	return ErrStatusChangeTooFrequent
+}
+
+type statusChangeThrottle struct {
+	minInterval time.Duration
+	mode        StatusThrottleMode
+}
+
+// WithStatusChangeThrottle impone un intervalo mínimo entre cambios de estado de una misma conversación.
+// Un intervalo cero, el valor por defecto, no aplica ningún límite
+func WithStatusChangeThrottle(minInterval time.Duration, mode StatusThrottleMode) MessagingServiceOption {
+	return func(s *messagingService) {
+		s.statusThrottle = statusChangeThrottle{minInterval: minInterval, mode: mode}
+	}
+}
+
+// wait devuelve cuánto falta para que la conversación pueda volver a cambiar de estado, o cero si ya puede
+func (t statusChangeThrottle) wait(conversation *domain.Conversation, now time.Time) time.Duration {
+	if t.minInterval <= 0 || conversation.StatusChangedAt == nil {
+		return 0
+	}
+
+	remaining := conversation.StatusChangedAt.Add(t.minInterval).Sub(now)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// throttleStatusChange aplica el modo configurado a un cambio de estado que llega antes del intervalo mínimo
+func (s *messagingService) throttleStatusChange(ctx context.Context, conversation *domain.Conversation, status domain.ConversationStatus, userID string, wait time.Duration) error {
+	if s.statusThrottle.mode == StatusThrottleDebounce {
+		s.log(ctx).Info("Conversation status change debounced", map[string]interface{}{
+			"conversation_id": conversation.ID,
+			"status":          status,
+			"current_status":  conversation.Status,
+			"user_id":         userID,
+		})
+		return nil
+	}
+	return &StatusChangeTooFrequentError{RetryAfter: wait}
+}
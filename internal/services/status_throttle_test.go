@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMessagingService_UpdateConversationStatus_TooFrequent(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo}, WithStatusChangeThrottle(time.Minute, StatusThrottleReject))
+
+	changedAt := time.Now().Add(-10 * time.Second)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv1").Return(&domain.Conversation{
+		ID:              "conv1",
+		UserID:          "user123",
+		Status:          domain.ConversationStatusClosed,
+		StatusChangedAt: &changedAt,
+	}, nil)
+
+	// Execute
+	err := service.UpdateConversationStatus(context.Background(), "conv1", domain.ConversationStatusActive, "user123")
+
+	// Assert
+	var tooFrequent *StatusChangeTooFrequentError
+	assert.True(t, errors.Is(err, ErrStatusChangeTooFrequent))
+	assert.True(t, errors.As(err, &tooFrequent))
+	assert.InDelta(t, 50*time.Second, tooFrequent.RetryAfter, float64(time.Second))
+	mockConversationRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_UpdateConversationStatus_Debounced(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo}, WithStatusChangeThrottle(time.Minute, StatusThrottleDebounce))
+
+	changedAt := time.Now().Add(-10 * time.Second)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv1").Return(&domain.Conversation{
+		ID:              "conv1",
+		UserID:          "user123",
+		Status:          domain.ConversationStatusClosed,
+		StatusChangedAt: &changedAt,
+	}, nil)
+
+	// Execute
+	err := service.UpdateConversationStatus(context.Background(), "conv1", domain.ConversationStatusActive, "user123")
+
+	// Assert
+	assert.NoError(t, err)
+	mockConversationRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_UpdateConversationStatus_AfterInterval(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo}, WithStatusChangeThrottle(time.Minute, StatusThrottleReject))
+
+	changedAt := time.Now().Add(-2 * time.Minute)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv1").Return(&domain.Conversation{
+		ID:              "conv1",
+		UserID:          "user123",
+		Status:          domain.ConversationStatusClosed,
+		StatusChangedAt: &changedAt,
+	}, nil)
+	mockConversationRepo.On("UpdateStatusIfIdle", mock.Anything, "conv1", domain.ConversationStatusActive, mock.MatchedBy(func(changedBefore time.Time) bool {
+		return changedBefore.After(changedAt)
+	}), mock.AnythingOfType("time.Time")).Return(true, nil)
+
+	// Execute
+	err := service.UpdateConversationStatus(context.Background(), "conv1", domain.ConversationStatusActive, "user123")
+
+	// Assert
+	assert.NoError(t, err)
+	mockConversationRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_UpdateConversationStatus_LostRace(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	service := newTestMessagingService(testServiceDeps{conversationRepo: mockConversationRepo}, WithStatusChangeThrottle(time.Minute, StatusThrottleReject))
+
+	changedAt := time.Now().Add(-2 * time.Minute)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv1").Return(&domain.Conversation{
+		ID:              "conv1",
+		UserID:          "user123",
+		Status:          domain.ConversationStatusClosed,
+		StatusChangedAt: &changedAt,
+	}, nil)
+	// Another request changed the status between the read and the update
+	mockConversationRepo.On("UpdateStatusIfIdle", mock.Anything, "conv1", domain.ConversationStatusActive, mock.Anything, mock.Anything).Return(false, nil)
+
+	// Execute
+	err := service.UpdateConversationStatus(context.Background(), "conv1", domain.ConversationStatusActive, "user123")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrStatusChangeTooFrequent)
+	mockConversationRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
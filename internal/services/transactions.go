@@ -0,0 +1,23 @@
+package services
+
+import (
+	"context"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// WithTxManager hace que las operaciones con varias escrituras, como guardar un mensaje y actualizar su
+// conversación, se confirmen o se deshagan juntas
+func WithTxManager(txManager domain.TxManager) MessagingServiceOption {
+	return func(s *messagingService) {
+		s.txManager = txManager
+	}
+}
+
+// withinTransaction ejecuta fn en una transacción si hay TxManager; si no, la ejecuta tal cual
+func (s *messagingService) withinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.txManager == nil {
+		return fn(ctx)
+	}
+	return s.txManager.WithinTransaction(ctx, fn)
+}
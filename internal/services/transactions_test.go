@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingTxManager ejecuta fn sin base de datos y guarda el error con el que terminó la transacción
+type recordingTxManager struct {
+	calls  int
+	result error
+}
+
+func (m *recordingTxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.calls++
+	m.result = fn(ctx)
+	return m.result
+}
+
+func TestMessagingService_SendMessage_TouchFailureFailsTransaction(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	txManager := &recordingTxManager{}
+	publisher := &recordingEventPublisher{}
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		new(MockAttachmentRepository),
+		publisher,
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+		WithTxManager(txManager),
+	)
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+	mockMessageRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Message")).Return(nil)
+	mockConversationRepo.On("TouchUpdatedAt", mock.Anything, "conv123", mock.AnythingOfType("time.Time")).Return(fmt.Errorf("connection reset"))
+
+	// Execute
+	message, err := service.SendMessage(context.Background(), SendMessageRequest{
+		ConversationID: "conv123",
+		SenderType:     domain.SenderTypeUser,
+		SenderID:       "user123",
+		Content:        "Hola",
+		ContentType:    domain.ContentTypeText,
+	})
+
+	// Assert: the insert and the touch share one transaction, which is rolled back
+	require.Error(t, err)
+	assert.Nil(t, message)
+	assert.Equal(t, 1, txManager.calls)
+	assert.Error(t, txManager.result)
+	mockMessageRepo.AssertNumberOfCalls(t, "Create", 1)
+	assert.Empty(t, publisher.events)
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+)
+
+// typingIndicatorTTL es cuánto dura un indicador de escritura si el cliente no lo renueva
+const typingIndicatorTTL = 5 * time.Second
+
+// SetTyping publica en el canal de la conversación que el usuario está escribiendo o ha dejado de
+// hacerlo, con su rol para que los agentes distingan al cliente de un compañero. El evento no se guarda:
+// solo lo reciben los clientes conectados en ese momento
+func (s *messagingService) SetTyping(ctx context.Context, conversationID string, userID string, role domain.TypingRole, isTyping bool) (*domain.TypingEvent, error) {
+	// Verify conversation access
+	if _, err := s.GetConversation(ctx, conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	event := &domain.TypingEvent{
+		Type:           "typing",
+		ConversationID: conversationID,
+		UserID:         userID,
+		Role:           role,
+		IsTyping:       isTyping,
+		ExpiresAt:      time.Now().Add(typingIndicatorTTL),
+	}
+
+	if s.eventPublisher != nil {
+		if err := s.eventPublisher.PublishEvent(ctx, ConversationEventChannel(conversationID), event); err != nil {
+			return nil, fmt.Errorf("failed to publish typing event: %w", err)
+		}
+	}
+
+	return event, nil
+}
@@ -0,0 +1,19 @@
+package services
+
+import "github.com/company/microservice-template/internal/domain"
+
+// ResolveTypingRole decide con qué rol aparece quien escribe en los indicadores de escritura: los servicios
+// internos y los usuarios con alguno de agentRoles escriben como agente y el resto como cliente
+func ResolveTypingRole(isService bool, userRoles []string, agentRoles []string) domain.TypingRole {
+	if isService {
+		return domain.TypingRoleAgent
+	}
+	for _, role := range userRoles {
+		for _, agentRole := range agentRoles {
+			if role == agentRole {
+				return domain.TypingRoleAgent
+			}
+		}
+	}
+	return domain.TypingRoleCustomer
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveTypingRole(t *testing.T) {
+	agentRoles := []string{"agent", "supervisor"}
+
+	tests := []struct {
+		name      string
+		isService bool
+		userRoles []string
+		role      domain.TypingRole
+	}{
+		{"internal service", true, nil, domain.TypingRoleAgent},
+		{"agent role", false, []string{"user", "agent"}, domain.TypingRoleAgent},
+		{"other configured role", false, []string{"supervisor"}, domain.TypingRoleAgent},
+		{"customer", false, []string{"user"}, domain.TypingRoleCustomer},
+		{"no roles", false, nil, domain.TypingRoleCustomer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.role, ResolveTypingRole(tt.isService, tt.userRoles, agentRoles))
+		})
+	}
+}
+
+func TestResolveTypingRole_NoAgentRolesConfigured(t *testing.T) {
+	assert.Equal(t, domain.TypingRoleCustomer, ResolveTypingRole(false, []string{"agent"}, nil))
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessagingService_SetTyping(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	mockMessageRepo := new(MockMessageRepository)
+	publisher := &recordingEventPublisher{}
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		mockMessageRepo,
+		new(MockAttachmentRepository),
+		publisher,
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user123"}, nil)
+
+	// Execute
+	event, err := service.SetTyping(context.Background(), "conv123", "user123", domain.TypingRoleCustomer, true)
+
+	// Assert: the event goes to the conversation channel only
+	require.NoError(t, err)
+	assert.True(t, event.IsTyping)
+	assert.Equal(t, domain.TypingRoleCustomer, event.Role)
+	assert.WithinDuration(t, time.Now().Add(typingIndicatorTTL), event.ExpiresAt, time.Second)
+	require.Len(t, publisher.channelEvents[ConversationEventChannel("conv123")], 1)
+	assert.Equal(t, event, publisher.channelEvents[ConversationEventChannel("conv123")][0])
+	assert.Empty(t, publisher.events)
+
+	// Typing is never written to the messages table
+	mockMessageRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockMessageRepo.AssertNotCalled(t, "CreateWithAttachments", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_SetTyping_RequiresAccess(t *testing.T) {
+	// Setup
+	mockConversationRepo := new(MockConversationRepository)
+	publisher := &recordingEventPublisher{}
+
+	service := NewMessagingService(
+		mockConversationRepo,
+		new(MockMessageRepository),
+		new(MockAttachmentRepository),
+		publisher,
+		NewNoOpCacheService(),
+		logger.NewLogger("debug"),
+	)
+
+	mockConversationRepo.On("GetByID", mock.Anything, "conv123").Return(&domain.Conversation{ID: "conv123", UserID: "user456"}, nil)
+
+	// Execute
+	_, err := service.SetTyping(context.Background(), "conv123", "user123", domain.TypingRoleCustomer, true)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.Empty(t, publisher.channelEvents)
+}
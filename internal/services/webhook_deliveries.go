@@ -0,0 +1,271 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidDeliveryFilter indica un filtro de estado desconocido al consultar las entregas de webhooks
+var ErrInvalidDeliveryFilter = errors.New("invalid webhook delivery filter")
+
+// ErrDeliveryNotReplayable indica que se pidió reenviar una entrega que no falló
+var ErrDeliveryNotReplayable = errors.New("webhook delivery not replayable")
+
+// ErrWebhookAddressNotAllowed indica que la URL del webhook no es https o resuelve a una dirección interna
+var ErrWebhookAddressNotAllowed = errors.New("webhook address not allowed")
+
+// maxWebhookDeliveriesPage limita las entregas devueltas por consulta
+const maxWebhookDeliveriesPage = 100
+
+// WebhookDeliverer envía los eventos a los webhooks de conversación y registra cada intento con su
+// código de respuesta, duración y error, para que los integradores puedan depurar sus endpoints
+type WebhookDeliverer struct {
+	repo   domain.WebhookDeliveryRepository
+	client *http.Client
+	logger logger.Logger
+	now    func() time.Time
+}
+
+func NewWebhookDeliverer(repo domain.WebhookDeliveryRepository, timeout time.Duration, logger logger.Logger) *WebhookDeliverer {
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+
+	return &WebhookDeliverer{
+		repo:   repo,
+		client: newWebhookHTTPClient(timeout),
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// newWebhookHTTPClient crea un cliente que solo conecta con direcciones públicas, sin proxy y sin seguir
+// redirecciones, para que un webhook no pueda usarse para alcanzar servicios internos
+func newWebhookHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: rejectInternalAddress}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// carrierGradeNAT es el rango 100.64.0.0/10, compartido por proveedores y no enrutable desde fuera
+var carrierGradeNAT = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// rejectInternalAddress se ejecuta con la IP ya resuelta de cada conexión, así que un nombre que
+// cambia de dirección entre el registro y la entrega (DNS rebinding) tampoco alcanza la red interna
+func rejectInternalAddress(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || carrierGradeNAT.Contains(ip) {
+		return fmt.Errorf("%w: %s", ErrWebhookAddressNotAllowed, host)
+	}
+
+	return nil
+}
+
+// Deliver envía payload al webhook y registra el intento. El fallo de la entrega queda en el estado del
+// intento devuelto; el error solo indica que no se pudo registrar
+func (d *WebhookDeliverer) Deliver(ctx context.Context, webhook domain.ConversationWebhook, eventType string, payload domain.JSONB, attempt int, replayOf *string) (*domain.WebhookDelivery, error) {
+	delivery := &domain.WebhookDelivery{
+		ID:             uuid.New().String(),
+		WebhookID:      webhook.ID,
+		ConversationID: webhook.ConversationID,
+		EventType:      eventType,
+		URL:            webhook.URL,
+		Payload:        payload,
+		Attempt:        attempt,
+		Status:         domain.WebhookDeliveryStatusSucceeded,
+		ReplayOf:       replayOf,
+		CreatedAt:      d.now(),
+	}
+
+	start := time.Now()
+	statusCode, err := d.post(ctx, delivery, webhook.Secret)
+	delivery.ResponseTimeMs = time.Since(start).Milliseconds()
+	if statusCode != 0 {
+		delivery.StatusCode = &statusCode
+	}
+	if err != nil {
+		delivery.Status = domain.WebhookDeliveryStatusFailed
+		delivery.Error = err.Error()
+
+		d.logger.Warn("Conversation webhook delivery failed", map[string]interface{}{
+			"webhook_id":      webhook.ID,
+			"delivery_id":     delivery.ID,
+			"conversation_id": webhook.ConversationID,
+			"event_type":      eventType,
+			"attempt":         attempt,
+			"error":           err.Error(),
+		})
+	}
+
+	if err := d.repo.Create(ctx, delivery); err != nil {
+		d.logger.Error("Failed to record webhook delivery", err)
+		return delivery, fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+func (d *WebhookDeliverer) post(ctx context.Context, delivery *domain.WebhookDelivery, secret string) (int, error) {
+	body, err := json.Marshal(delivery.Payload)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	// Webhooks registered before https was required are not delivered
+	if req.URL.Scheme != "https" {
+		return 0, fmt.Errorf("%w: url must use https", ErrWebhookAddressNotAllowed)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-ID", delivery.WebhookID)
+	req.Header.Set("X-Event-Type", delivery.EventType)
+	req.Header.Set("X-Delivery-ID", delivery.ID)
+	req.Header.Set("X-Delivery-Attempt", strconv.Itoa(delivery.Attempt))
+	if secret != "" {
+		timestamp := d.now().Unix()
+		req.Header.Set(auth.WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(auth.WebhookSignatureHeader, auth.SignWebhookPayload([]byte(secret), timestamp, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// WithWebhookDeliveries habilita la consulta y el reenvío de las entregas a webhooks
+func WithWebhookDeliveries(repo domain.WebhookDeliveryRepository, deliverer *WebhookDeliverer) MessagingServiceOption {
+	return func(s *messagingService) {
+		s.webhookDeliveryRepo = repo
+		s.webhookDeliverer = deliverer
+	}
+}
+
+// ListWebhookDeliveries devuelve los intentos de entrega a un webhook de la conversación, los más recientes primero
+func (s *messagingService) ListWebhookDeliveries(ctx context.Context, conversationID string, webhookID string, filters domain.WebhookDeliveryFilters, userID string) ([]domain.WebhookDelivery, error) {
+	if s.webhookRepo == nil || s.webhookDeliveryRepo == nil {
+		return nil, fmt.Errorf("webhook deliveries not available")
+	}
+
+	if _, err := s.getConversationWebhook(ctx, conversationID, webhookID, userID); err != nil {
+		return nil, err
+	}
+
+	filters.ConversationID = conversationID
+	filters.WebhookID = webhookID
+
+	return s.listWebhookDeliveries(ctx, filters)
+}
+
+// ListAllWebhookDeliveries devuelve los intentos de entrega de todos los webhooks, para administradores
+func (s *messagingService) ListAllWebhookDeliveries(ctx context.Context, filters domain.WebhookDeliveryFilters) ([]domain.WebhookDelivery, error) {
+	if s.webhookDeliveryRepo == nil {
+		return nil, fmt.Errorf("webhook deliveries not available")
+	}
+
+	return s.listWebhookDeliveries(ctx, filters)
+}
+
+func (s *messagingService) listWebhookDeliveries(ctx context.Context, filters domain.WebhookDeliveryFilters) ([]domain.WebhookDelivery, error) {
+	switch filters.Status {
+	case "", domain.WebhookDeliveryStatusSucceeded, domain.WebhookDeliveryStatusFailed:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidDeliveryFilter, filters.Status)
+	}
+
+	if filters.Limit <= 0 || filters.Limit > maxWebhookDeliveriesPage {
+		filters.Limit = maxWebhookDeliveriesPage
+	}
+
+	deliveries, err := s.webhookDeliveryRepo.List(ctx, filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// ReplayWebhookDelivery reenvía una entrega fallida a la URL actual del webhook con el mismo cuerpo.
+// El reenvío se registra como un intento nuevo enlazado a la entrega original
+func (s *messagingService) ReplayWebhookDelivery(ctx context.Context, conversationID string, webhookID string, deliveryID string, userID string) (*domain.WebhookDelivery, error) {
+	if s.webhookRepo == nil || s.webhookDeliveryRepo == nil || s.webhookDeliverer == nil {
+		return nil, fmt.Errorf("webhook deliveries not available")
+	}
+
+	webhook, err := s.getConversationWebhook(ctx, conversationID, webhookID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.webhookDeliveryRepo.GetByID(ctx, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	if delivery.WebhookID != webhookID {
+		return nil, fmt.Errorf("webhook delivery %w", domain.ErrNotFound)
+	}
+
+	if delivery.Status != domain.WebhookDeliveryStatusFailed {
+		return nil, fmt.Errorf("%w: delivery %s has status %s", ErrDeliveryNotReplayable, deliveryID, delivery.Status)
+	}
+
+	// Replays of a replay point at the first delivery so the chain stays flat
+	original := delivery.ID
+	if delivery.ReplayOf != nil {
+		original = *delivery.ReplayOf
+	}
+
+	replay, err := s.webhookDeliverer.Deliver(ctx, *webhook, delivery.EventType, delivery.Payload, delivery.Attempt+1, &original)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log(ctx).Info("Webhook delivery replayed", map[string]interface{}{
+		"webhook_id":  webhookID,
+		"delivery_id": deliveryID,
+		"replay_id":   replay.ID,
+		"status":      replay.Status,
+		"user_id":     userID,
+	})
+
+	return replay, nil
+}
@@ -0,0 +1,332 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockWebhookDeliveryRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookDeliveryRepository) Create(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookDeliveryRepository) GetByID(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDeliveryRepository) List(ctx context.Context, filters domain.WebhookDeliveryFilters) ([]domain.WebhookDelivery, error) {
+	args := m.Called(ctx, filters)
+	return args.Get(0).([]domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDeliveryRepository) DeleteCreatedBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	args := m.Called(ctx, before, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// newTestWebhookDeliverer usa el cliente del servidor de prueba, que confía en su certificado y puede
+// conectar con 127.0.0.1; el cliente por defecto rechaza las direcciones de loopback
+func newTestWebhookDeliverer(repo *MockWebhookDeliveryRepository, server *httptest.Server) *WebhookDeliverer {
+	deliverer := NewWebhookDeliverer(repo, time.Second, logger.NewLogger("debug"))
+	deliverer.client = server.Client()
+	return deliverer
+}
+
+func TestWebhookDeliverer_RecordsAttempts(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantStatus domain.WebhookDeliveryStatus
+		wantError  bool
+	}{
+		{"success", http.StatusNoContent, domain.WebhookDeliveryStatusSucceeded, false},
+		{"server error", http.StatusBadGateway, domain.WebhookDeliveryStatusFailed, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			var headers http.Header
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				headers = r.Header.Clone()
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+			mockDeliveryRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.WebhookDelivery")).Return(nil)
+			deliverer := newTestWebhookDeliverer(mockDeliveryRepo, server)
+
+			webhook := domain.ConversationWebhook{ID: "hook1", ConversationID: "conv1", URL: server.URL}
+
+			// Execute
+			delivery, err := deliverer.Deliver(context.Background(), webhook, "message.received", domain.JSONB{"type": "message.received"}, 1, nil)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, delivery.Status)
+			require.NotNil(t, delivery.StatusCode)
+			assert.Equal(t, tt.status, *delivery.StatusCode)
+			assert.Equal(t, tt.wantError, delivery.Error != "")
+			assert.Equal(t, 1, delivery.Attempt)
+			assert.Equal(t, server.URL, delivery.URL)
+			assert.Equal(t, delivery.ID, headers.Get("X-Delivery-ID"))
+			assert.Equal(t, "1", headers.Get("X-Delivery-Attempt"))
+			mockDeliveryRepo.AssertCalled(t, "Create", mock.Anything, delivery)
+		})
+	}
+}
+
+func TestWebhookDeliverer_RecordsConnectionErrors(t *testing.T) {
+	// Setup: a closed server refuses the connection, so there is no status code
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	server.Close()
+
+	mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+	mockDeliveryRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.WebhookDelivery")).Return(nil)
+	deliverer := newTestWebhookDeliverer(mockDeliveryRepo, server)
+
+	// Execute
+	delivery, err := deliverer.Deliver(context.Background(), domain.ConversationWebhook{ID: "hook1", URL: server.URL}, "message.read", domain.JSONB{}, 1, nil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, domain.WebhookDeliveryStatusFailed, delivery.Status)
+	assert.Nil(t, delivery.StatusCode)
+	assert.NotEmpty(t, delivery.Error)
+}
+
+func TestWebhookDeliverer_RejectsInternalAddresses(t *testing.T) {
+	// Setup
+	var called bool
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+	mockDeliveryRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.WebhookDelivery")).Return(nil)
+	deliverer := NewWebhookDeliverer(mockDeliveryRepo, time.Second, logger.NewLogger("debug"))
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"loopback", server.URL},
+		{"private network", "https://10.0.0.1/hooks"},
+		{"link-local metadata", "https://169.254.169.254/latest/meta-data"},
+		{"plain http", strings.Replace(server.URL, "https://", "http://", 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			delivery, err := deliverer.Deliver(context.Background(), domain.ConversationWebhook{ID: "hook1", URL: tt.url}, "message.read", domain.JSONB{}, 1, nil)
+
+			// Assert: the attempt is recorded as failed without reaching the endpoint
+			require.NoError(t, err)
+			assert.Equal(t, domain.WebhookDeliveryStatusFailed, delivery.Status)
+			assert.Contains(t, delivery.Error, ErrWebhookAddressNotAllowed.Error())
+		})
+	}
+	assert.False(t, called)
+}
+
+func TestRejectInternalAddress(t *testing.T) {
+	assert.NoError(t, rejectInternalAddress("tcp", "93.184.216.34:443", nil))
+	assert.NoError(t, rejectInternalAddress("tcp6", "[2606:2800:220:1::1]:443", nil))
+
+	for _, address := range []string{"127.0.0.1:443", "[::1]:443", "192.168.1.10:443", "172.16.0.5:443", "100.64.0.1:443", "[fe80::1]:443", "0.0.0.0:443", "[::ffff:127.0.0.1]:443"} {
+		assert.ErrorIs(t, rejectInternalAddress("tcp", address, nil), ErrWebhookAddressNotAllowed, address)
+	}
+}
+
+func TestMessagingService_ListWebhookDeliveries(t *testing.T) {
+	// Setup
+	mockWebhookRepo := new(MockWebhookRepository)
+	mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+	mockConversationRepo := new(MockConversationRepository)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv1").Return(&domain.Conversation{ID: "conv1", UserID: "user123"}, nil)
+	service := newTestMessagingService(
+		testServiceDeps{conversationRepo: mockConversationRepo},
+		WithWebhookRepository(mockWebhookRepo),
+		WithWebhookDeliveries(mockDeliveryRepo, NewWebhookDeliverer(mockDeliveryRepo, time.Second, logger.NewLogger("debug"))),
+	)
+
+	mockWebhookRepo.On("GetByID", mock.Anything, "hook1").Return(&domain.ConversationWebhook{ID: "hook1", ConversationID: "conv1"}, nil)
+	expected := domain.WebhookDeliveryFilters{WebhookID: "hook1", ConversationID: "conv1", Status: domain.WebhookDeliveryStatusFailed, Limit: 100}
+	mockDeliveryRepo.On("List", mock.Anything, expected).Return([]domain.WebhookDelivery{{ID: "d1", Status: domain.WebhookDeliveryStatusFailed}}, nil)
+
+	// Execute: an oversized limit is capped and the webhook filters cannot be overridden
+	deliveries, err := service.ListWebhookDeliveries(context.Background(), "conv1", "hook1", domain.WebhookDeliveryFilters{
+		WebhookID: "other",
+		Status:    domain.WebhookDeliveryStatusFailed,
+		Limit:     5000,
+	}, "user123")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	mockDeliveryRepo.AssertExpectations(t)
+}
+
+func TestMessagingService_ListWebhookDeliveries_RejectsForeignWebhook(t *testing.T) {
+	// Setup
+	mockWebhookRepo := new(MockWebhookRepository)
+	mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+	mockConversationRepo := new(MockConversationRepository)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv1").Return(&domain.Conversation{ID: "conv1", UserID: "user123"}, nil)
+	service := newTestMessagingService(
+		testServiceDeps{conversationRepo: mockConversationRepo},
+		WithWebhookRepository(mockWebhookRepo),
+		WithWebhookDeliveries(mockDeliveryRepo, NewWebhookDeliverer(mockDeliveryRepo, time.Second, logger.NewLogger("debug"))),
+	)
+
+	mockWebhookRepo.On("GetByID", mock.Anything, "hook2").Return(&domain.ConversationWebhook{ID: "hook2", ConversationID: "conv2"}, nil)
+
+	// Execute
+	deliveries, err := service.ListWebhookDeliveries(context.Background(), "conv1", "hook2", domain.WebhookDeliveryFilters{}, "user123")
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, deliveries)
+	mockDeliveryRepo.AssertNotCalled(t, "List", mock.Anything, mock.Anything)
+}
+
+func TestMessagingService_ListAllWebhookDeliveries(t *testing.T) {
+	// Setup
+	mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+	service := newTestMessagingService(
+		testServiceDeps{},
+		WithWebhookRepository(new(MockWebhookRepository)),
+		WithWebhookDeliveries(mockDeliveryRepo, NewWebhookDeliverer(mockDeliveryRepo, time.Second, logger.NewLogger("debug"))),
+	)
+
+	expected := domain.WebhookDeliveryFilters{ConversationID: "conv9", Limit: 20, Offset: 40}
+	mockDeliveryRepo.On("List", mock.Anything, expected).Return([]domain.WebhookDelivery{{ID: "d1"}, {ID: "d2"}}, nil)
+
+	// Execute
+	deliveries, err := service.ListAllWebhookDeliveries(context.Background(), domain.WebhookDeliveryFilters{ConversationID: "conv9", Limit: 20, Offset: 40})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 2)
+
+	// An unknown status is rejected before querying
+	_, err = service.ListAllWebhookDeliveries(context.Background(), domain.WebhookDeliveryFilters{Status: "pending"})
+	assert.True(t, errors.Is(err, ErrInvalidDeliveryFilter))
+	mockDeliveryRepo.AssertNumberOfCalls(t, "List", 1)
+}
+
+func TestMessagingService_ReplayWebhookDelivery(t *testing.T) {
+	// Setup
+	received := make(chan string, 1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("X-Delivery-Attempt")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	mockWebhookRepo := new(MockWebhookRepository)
+	mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+	mockConversationRepo := new(MockConversationRepository)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv1").Return(&domain.Conversation{ID: "conv1", UserID: "user123"}, nil)
+	service := newTestMessagingService(
+		testServiceDeps{conversationRepo: mockConversationRepo},
+		WithWebhookRepository(mockWebhookRepo),
+		WithWebhookDeliveries(mockDeliveryRepo, newTestWebhookDeliverer(mockDeliveryRepo, server)),
+	)
+
+	original := "d1"
+	failed := &domain.WebhookDelivery{
+		ID:        "d2",
+		WebhookID: "hook1",
+		EventType: "message.received",
+		Payload:   domain.JSONB{"type": "message.received"},
+		Attempt:   2,
+		Status:    domain.WebhookDeliveryStatusFailed,
+		ReplayOf:  &original,
+	}
+	mockWebhookRepo.On("GetByID", mock.Anything, "hook1").Return(&domain.ConversationWebhook{ID: "hook1", ConversationID: "conv1", URL: server.URL}, nil)
+	mockDeliveryRepo.On("GetByID", mock.Anything, "d2").Return(failed, nil)
+	mockDeliveryRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.WebhookDelivery")).Return(nil)
+
+	// Execute
+	replay, err := service.ReplayWebhookDelivery(context.Background(), "conv1", "hook1", "d2", "user123")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "3", <-received)
+	assert.Equal(t, domain.WebhookDeliveryStatusSucceeded, replay.Status)
+	assert.Equal(t, 3, replay.Attempt)
+	require.NotNil(t, replay.ReplayOf)
+	assert.Equal(t, "d1", *replay.ReplayOf)
+	assert.Equal(t, failed.Payload, replay.Payload)
+}
+
+func TestMessagingService_ReplayWebhookDelivery_OnlyFailed(t *testing.T) {
+	// Setup
+	mockWebhookRepo := new(MockWebhookRepository)
+	mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+	mockConversationRepo := new(MockConversationRepository)
+	mockConversationRepo.On("GetByID", mock.Anything, "conv1").Return(&domain.Conversation{ID: "conv1", UserID: "user123"}, nil)
+	service := newTestMessagingService(
+		testServiceDeps{conversationRepo: mockConversationRepo},
+		WithWebhookRepository(mockWebhookRepo),
+		WithWebhookDeliveries(mockDeliveryRepo, NewWebhookDeliverer(mockDeliveryRepo, time.Second, logger.NewLogger("debug"))),
+	)
+
+	mockWebhookRepo.On("GetByID", mock.Anything, "hook1").Return(&domain.ConversationWebhook{ID: "hook1", ConversationID: "conv1"}, nil)
+	mockDeliveryRepo.On("GetByID", mock.Anything, "d1").Return(&domain.WebhookDelivery{ID: "d1", WebhookID: "hook1", Status: domain.WebhookDeliveryStatusSucceeded}, nil)
+
+	// Execute
+	replay, err := service.ReplayWebhookDelivery(context.Background(), "conv1", "hook1", "d1", "user123")
+
+	// Assert
+	assert.True(t, errors.Is(err, ErrDeliveryNotReplayable))
+	assert.Nil(t, replay)
+	mockDeliveryRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestWebhookDeliveryPruner_DeletesInBatches(t *testing.T) {
+	// Setup
+	mockDeliveryRepo := new(MockWebhookDeliveryRepository)
+	pruner := NewWebhookDeliveryPruner(mockDeliveryRepo, config.WebhookConfig{
+		DeliveryRetention: 24 * time.Hour,
+		PruneInterval:     time.Hour,
+		PruneBatchSize:    2,
+	}, logger.NewLogger("debug"))
+
+	before := mock.MatchedBy(func(before time.Time) bool {
+		return before.Before(time.Now().Add(-23 * time.Hour))
+	})
+	mockDeliveryRepo.On("DeleteCreatedBefore", mock.Anything, before, 2).Return(int64(2), nil).Once()
+	mockDeliveryRepo.On("DeleteCreatedBefore", mock.Anything, before, 2).Return(int64(1), nil).Once()
+
+	// Execute
+	pruned := pruner.RunOnce(context.Background())
+
+	// Assert
+	assert.Equal(t, int64(3), pruned)
+	mockDeliveryRepo.AssertExpectations(t)
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/company/microservice-template/internal/config"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+// WebhookDeliveryPruner elimina del registro de entregas los intentos más antiguos que la retención
+// configurada, ya que cada uno guarda el cuerpo completo del evento
+type WebhookDeliveryPruner struct {
+	deliveryRepo domain.WebhookDeliveryRepository
+	config       config.WebhookConfig
+	logger       logger.Logger
+}
+
+func NewWebhookDeliveryPruner(deliveryRepo domain.WebhookDeliveryRepository, config config.WebhookConfig, logger logger.Logger) *WebhookDeliveryPruner {
+	return &WebhookDeliveryPruner{
+		deliveryRepo: deliveryRepo,
+		config:       config,
+		logger:       logger,
+	}
+}
+
+// Start ejecuta la limpieza periódica hasta que se cancele el contexto
+func (p *WebhookDeliveryPruner) Start(ctx context.Context) {
+	ticker := time.NewTicker(p.config.PruneInterval)
+	defer ticker.Stop()
+
+	p.logger.Info("Webhook delivery pruner started", map[string]interface{}{
+		"interval":  p.config.PruneInterval.String(),
+		"retention": p.config.DeliveryRetention.String(),
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.logger.Info("Webhook delivery pruner stopped")
+			return
+		case <-ticker.C:
+			p.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce elimina por lotes los intentos vencidos y devuelve cuántos se eliminaron
+func (p *WebhookDeliveryPruner) RunOnce(ctx context.Context) int64 {
+	before := time.Now().Add(-p.config.DeliveryRetention)
+
+	var pruned int64
+	for ctx.Err() == nil {
+		deleted, err := p.deliveryRepo.DeleteCreatedBefore(ctx, before, p.config.PruneBatchSize)
+		if err != nil {
+			p.logger.Error("Failed to prune webhook deliveries", err)
+			break
+		}
+		pruned += deleted
+
+		if deleted == 0 || deleted < int64(p.config.PruneBatchSize) {
+			break
+		}
+	}
+
+	if pruned > 0 {
+		p.logger.Info("Webhook deliveries pruned", map[string]interface{}{
+			"pruned":         pruned,
+			"created_before": before,
+		})
+	}
+
+	return pruned
+}
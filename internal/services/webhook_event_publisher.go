@@ -0,0 +1,186 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+const (
+	// webhookEventAttempts es el número de envíos de cada evento, contando el primero
+	webhookEventAttempts = 3
+	// webhookEventBackoff es la espera antes del primer reintento; se duplica en cada uno
+	webhookEventBackoff = 500 * time.Millisecond
+	// webhookFlushTimeout limita lo que se dedica a entregar los eventos que quedan en cola al detenerse
+	webhookFlushTimeout = 10 * time.Second
+)
+
+// WebhookEventPublisher envía cada evento por POST a EVENTS_WEBHOOK_URL, firmado con auth.SignEventPayload
+// en la cabecera X-Signature. Los envíos los hace un único worker en segundo plano, así que los eventos
+// llegan en el orden en que se publicaron y un endpoint lento no retrasa el envío de mensajes. Los
+// errores 5xx y de red se reintentan con backoff exponencial; si el evento no se entrega se registra en
+// el log y se descarta
+type WebhookEventPublisher struct {
+	url     string
+	secret  []byte
+	client  *http.Client
+	queue   chan domain.MessageEvent
+	backoff time.Duration
+	logger  logger.Logger
+}
+
+func NewWebhookEventPublisher(url string, secret string, timeout time.Duration, queueSize int, logger logger.Logger) *WebhookEventPublisher {
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+
+	return &WebhookEventPublisher{
+		url:     url,
+		secret:  []byte(secret),
+		client:  &http.Client{Timeout: timeout},
+		queue:   make(chan domain.MessageEvent, queueSize),
+		backoff: webhookEventBackoff,
+		logger:  logger,
+	}
+}
+
+// Start entrega los eventos encolados hasta que se cancele el contexto; al cancelarse entrega los que
+// quedan en cola durante webhookFlushTimeout como mucho
+func (p *WebhookEventPublisher) Start(ctx context.Context) {
+	p.logger.Info("Webhook event publisher started", map[string]interface{}{
+		"queue_size": cap(p.queue),
+	})
+
+	// A delivery in flight when shutdown starts is finished rather than aborted
+	deliverCtx := context.WithoutCancel(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), webhookFlushTimeout)
+			drainQueue(flushCtx, p.queue, p.deliver)
+			cancel()
+			p.logger.Info("Webhook event publisher stopped", map[string]interface{}{
+				"undelivered": len(p.queue),
+			})
+			return
+		case event := <-p.queue:
+			p.deliver(deliverCtx, event)
+		}
+	}
+}
+
+// PublishMessageEvent encola el evento; con la cola llena se descarta y se registra, sin afectar al envío
+func (p *WebhookEventPublisher) PublishMessageEvent(ctx context.Context, event domain.MessageEvent) error {
+	select {
+	case p.queue <- event:
+	default:
+		eventPublishFailuresTotal.WithLabelValues("webhook", event.Type).Inc()
+		p.logger.Warn("Webhook event queue full, event dropped", map[string]interface{}{
+			"conversation_id": event.ConversationID,
+			"event_type":      event.Type,
+		})
+	}
+
+	return nil
+}
+
+// PublishEvent descarta los eventos efímeros; enviarlos al webhook con reintentos no tiene sentido
+func (p *WebhookEventPublisher) PublishEvent(ctx context.Context, channel string, payload interface{}) error {
+	return nil
+}
+
+func (p *WebhookEventPublisher) deliver(ctx context.Context, event domain.MessageEvent) {
+	start := time.Now()
+	defer func() {
+		eventPublishDuration.WithLabelValues("webhook", event.Type).Observe(time.Since(start).Seconds())
+	}()
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		eventPublishFailuresTotal.WithLabelValues("webhook", event.Type).Inc()
+		p.logger.Error("Failed to marshal event", err)
+		return
+	}
+
+	backoff := p.backoff
+	for attempt := 1; ; attempt++ {
+		statusCode, err := p.post(ctx, body)
+		if err == nil {
+			p.logger.Info("Event published", map[string]interface{}{
+				"event_type":      event.Type,
+				"conversation_id": event.ConversationID,
+				"attempt":         attempt,
+			})
+			return
+		}
+
+		// 4xx responses won't change on retry
+		retryable := statusCode == 0 || statusCode >= 500
+		if !retryable || attempt == webhookEventAttempts || !sleepContext(ctx, backoff) {
+			eventPublishFailuresTotal.WithLabelValues("webhook", event.Type).Inc()
+			p.logger.Warn("Failed to publish event to webhook", map[string]interface{}{
+				"event_type":      event.Type,
+				"conversation_id": event.ConversationID,
+				"attempts":        attempt,
+				"status_code":     statusCode,
+				"error":           err.Error(),
+			})
+			return
+		}
+		backoff *= 2
+	}
+}
+
+func (p *WebhookEventPublisher) post(ctx context.Context, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(auth.EventSignatureHeader, auth.SignEventPayload(p.secret, body))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// drainQueue entrega con deliver los eventos que quedan en queue hasta vaciarla o hasta que venza ctx
+func drainQueue(ctx context.Context, queue chan domain.MessageEvent, deliver func(context.Context, domain.MessageEvent)) {
+	for ctx.Err() == nil {
+		select {
+		case event := <-queue:
+			deliver(ctx, event)
+		default:
+			return
+		}
+	}
+}
+
+// sleepContext espera d o hasta que se cancele el contexto; devuelve false si se canceló
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
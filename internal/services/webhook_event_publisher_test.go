@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/domain"
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyWebhookServer responde failures veces con status y después 200, guardando cada petición
+type flakyWebhookServer struct {
+	mu       sync.Mutex
+	failures int
+	status   int
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+func (s *flakyWebhookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bodies = append(s.bodies, body)
+	s.headers = append(s.headers, r.Header.Clone())
+	if len(s.bodies) <= s.failures {
+		w.WriteHeader(s.status)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *flakyWebhookServer) requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bodies)
+}
+
+func TestWebhookEventPublisher_RetriesServerErrors(t *testing.T) {
+	// Setup: the endpoint fails twice before accepting the event
+	handler := &flakyWebhookServer{failures: 2, status: http.StatusServiceUnavailable}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	publisher := NewWebhookEventPublisher(server.URL, "event-secret", time.Second, 10, logger.NewLogger("debug"))
+	publisher.backoff = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go publisher.Start(ctx)
+
+	// Execute
+	err := publisher.PublishMessageEvent(context.Background(), domain.MessageEvent{
+		Type:           "message.received",
+		ConversationID: "conv123",
+		Message:        domain.Message{ID: "msg1"},
+		Timestamp:      time.Now(),
+	})
+
+	// Assert: the third attempt succeeds and every attempt is signed over its body
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return handler.requests() == 3 }, 5*time.Second, 5*time.Millisecond)
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	for i, body := range handler.bodies {
+		assert.Equal(t, auth.SignEventPayload([]byte("event-secret"), body), handler.headers[i].Get("X-Signature"))
+	}
+	var event domain.MessageEvent
+	require.NoError(t, json.Unmarshal(handler.bodies[2], &event))
+	assert.Equal(t, "msg1", event.Message.ID)
+}
+
+func TestWebhookEventPublisher_GivesUpAfterMaxAttempts(t *testing.T) {
+	// Setup
+	handler := &flakyWebhookServer{failures: 10, status: http.StatusBadGateway}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	publisher := NewWebhookEventPublisher(server.URL, "event-secret", time.Second, 10, logger.NewLogger("debug"))
+	publisher.backoff = time.Millisecond
+	failures := eventPublishFailuresTotal.WithLabelValues("webhook", "webhook.retry.test")
+	before := testutil.ToFloat64(failures)
+
+	// Execute
+	publisher.deliver(context.Background(), domain.MessageEvent{Type: "webhook.retry.test", ConversationID: "conv123"})
+
+	// Assert
+	assert.Equal(t, webhookEventAttempts, handler.requests())
+	assert.Equal(t, before+1, testutil.ToFloat64(failures))
+}
+
+func TestWebhookEventPublisher_DoesNotRetryClientErrors(t *testing.T) {
+	// Setup
+	handler := &flakyWebhookServer{failures: 10, status: http.StatusBadRequest}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	publisher := NewWebhookEventPublisher(server.URL, "event-secret", time.Second, 10, logger.NewLogger("debug"))
+	publisher.backoff = time.Millisecond
+
+	// Execute
+	publisher.deliver(context.Background(), domain.MessageEvent{Type: "message.received", ConversationID: "conv123"})
+
+	// Assert
+	assert.Equal(t, 1, handler.requests())
+}
+
+func TestWebhookEventPublisher_FlushesQueueOnStop(t *testing.T) {
+	// Setup: events are queued while the publisher is already being stopped
+	handler := &flakyWebhookServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	publisher := NewWebhookEventPublisher(server.URL, "event-secret", time.Second, 10, logger.NewLogger("debug"))
+	for i := 0; i < 3; i++ {
+		require.NoError(t, publisher.PublishMessageEvent(context.Background(), domain.MessageEvent{Type: "message.received", ConversationID: "conv123"}))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Execute
+	publisher.Start(ctx)
+
+	// Assert: Start returns only after every queued event was delivered
+	assert.Equal(t, 3, handler.requests())
+	assert.Empty(t, publisher.queue)
+}
@@ -14,13 +14,18 @@ import (
 	"github.com/company/microservice-template/internal/config"
 	"github.com/company/microservice-template/internal/domain"
 	"github.com/company/microservice-template/internal/handlers"
+	"github.com/company/microservice-template/internal/integrations/messenger"
+	"github.com/company/microservice-template/internal/integrations/whatsapp"
 	"github.com/company/microservice-template/internal/middleware"
 	"github.com/company/microservice-template/internal/repositories"
 	"github.com/company/microservice-template/internal/services"
+	"github.com/company/microservice-template/pkg/lifecycle"
 	"github.com/company/microservice-template/pkg/logger"
+	"github.com/company/microservice-template/pkg/tracing"
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 // @title Microservice Template API
@@ -33,7 +38,27 @@ func main() {
 	cfg := config.Load()
 
 	// Inicializar logger
-	logger := logger.NewLogger(cfg.LogLevel)
+	logger := logger.NewLoggerWithFormat(cfg.LogLevel, cfg.LogFormat)
+
+	// Secretos de Vault; si no responde se quedan los de las variables de entorno
+	if cfg.VaultConfig.Enabled() {
+		loaded, err := cfg.LoadVaultSecrets(context.Background())
+		if err != nil {
+			logger.Warn("Vault unavailable, using secrets from environment", map[string]interface{}{
+				"vault_addr": cfg.VaultConfig.Address,
+				"error":      err.Error(),
+			})
+		} else {
+			logger.Info("Secrets loaded from Vault", map[string]interface{}{
+				"vault_path": cfg.VaultConfig.Path,
+				"secrets":    loaded,
+			})
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		logger.Fatal("Invalid configuration", err)
+	}
 
 	// Log startup information
 	logger.Info("=== IT Messaging Service Starting ===")
@@ -43,9 +68,23 @@ func main() {
 	logger.Info("DB Host: " + cfg.Database.Host)
 	logger.Info("Redis Enabled: " + fmt.Sprintf("%t", cfg.Redis.Enabled))
 
+	// Trazas OpenTelemetry (no-op sin OTEL_EXPORTER_OTLP_ENDPOINT)
+	shutdownTracing, err := tracing.InitTracing(tracing.Config{
+		ServiceName:   cfg.Tracing.ServiceName,
+		Environment:   cfg.Environment,
+		OTLPEndpoint:  cfg.Tracing.OTLPEndpoint,
+		SampleRatio:   cfg.Tracing.SampleRatio,
+		ExportTimeout: cfg.Tracing.ExportTimeout,
+	})
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", err)
+	}
+	if cfg.Tracing.OTLPEndpoint != "" {
+		logger.Info("Tracing enabled", map[string]interface{}{"endpoint": cfg.Tracing.OTLPEndpoint})
+	}
+
 	// Inicializar base de datos (no fatal si falla)
 	var db *sql.DB
-	var err error
 
 	// Intentar conectar a la base de datos, pero no fallar si no puede
 	if cfg.Database.Host != "" && cfg.Database.Password != "" {
@@ -75,45 +114,265 @@ func main() {
 	}
 
 	// Inicializar JWT manager
-	jwtManager := auth.NewJWTManager(cfg.JWT.SecretKey, cfg.JWT.Issuer)
+	jwtOpts := []auth.JWTOption{
+		auth.WithTokenExpiry(time.Duration(cfg.JWT.ExpiryHours)*time.Hour, cfg.JWT.RefreshExpiry),
+	}
+	if redisClient != nil {
+		jwtOpts = append(jwtOpts, auth.WithTokenRevocations(auth.NewRedisTokenRevocationStore(redisClient)))
+	}
+	jwtManager := auth.NewJWTManager(cfg.JWT.SecretKey, cfg.JWT.Issuer, jwtOpts...)
+	serviceTokens := auth.NewServiceTokenVerifier(cfg.JWT.ServiceTokenSecret)
 
 	// Inicializar repositorios (con manejo de DB nula)
 	var conversationRepo domain.ConversationRepository
 	var messageRepo domain.MessageRepository
 	var attachmentRepo domain.AttachmentRepository
+	var reactionRepo domain.ReactionRepository
+	var auditRepo domain.AuditRepository
+	var templateRepo domain.ConversationTemplateRepository
+	var participantRepo domain.ParticipantRepository
+	var messageReadRepo domain.MessageReadRepository
+	var webhookRepo domain.WebhookRepository
+	var webhookDeliveryRepo domain.WebhookDeliveryRepository
+	var draftRepo domain.DraftRepository
+	var scheduledMessageRepo domain.ScheduledMessageRepository
+	var shareLinkRepo domain.ShareLinkRepository
+	var outboxRepo domain.OutboxRepository
+	var txManager domain.TxManager
+	var healthRepo domain.HealthRepository
 
 	if db != nil {
 		conversationRepo = repositories.NewPostgresConversationRepository(db, logger)
 		messageRepo = repositories.NewPostgresMessageRepository(db, logger)
 		attachmentRepo = repositories.NewPostgresAttachmentRepository(db, logger)
+		reactionRepo = repositories.NewPostgresReactionRepository(db, logger)
+		auditRepo = repositories.NewPostgresAuditRepository(db, logger)
+		templateRepo = repositories.NewPostgresTemplateRepository(db, logger)
+		participantRepo = repositories.NewPostgresParticipantRepository(db, logger)
+		messageReadRepo = repositories.NewPostgresMessageReadRepository(db, logger)
+		webhookRepo = repositories.NewPostgresWebhookRepository(db, logger)
+		webhookDeliveryRepo = repositories.NewPostgresWebhookDeliveryRepository(db, logger)
+		draftRepo = repositories.NewPostgresDraftRepository(db, logger)
+		scheduledMessageRepo = repositories.NewPostgresScheduledMessageRepository(db, logger)
+		shareLinkRepo = repositories.NewPostgresShareLinkRepository(db, logger)
+		outboxRepo = repositories.NewPostgresOutboxRepository(db, logger)
+		txManager = repositories.NewPostgresTxManager(db, logger)
+		healthRepo = repositories.NewPostgresHealthRepository(db, logger)
 	} else {
 		// Usar repositorios mock/no-op cuando no hay DB
 		conversationRepo = repositories.NewNoOpConversationRepository()
 		messageRepo = repositories.NewNoOpMessageRepository()
 		attachmentRepo = repositories.NewNoOpAttachmentRepository()
+		reactionRepo = repositories.NewNoOpReactionRepository()
+		auditRepo = repositories.NewNoOpAuditRepository()
+		templateRepo = repositories.NewNoOpTemplateRepository()
+		participantRepo = repositories.NewNoOpParticipantRepository()
+		messageReadRepo = repositories.NewNoOpMessageReadRepository()
+		webhookRepo = repositories.NewNoOpWebhookRepository()
+		webhookDeliveryRepo = repositories.NewNoOpWebhookDeliveryRepository()
+		draftRepo = repositories.NewNoOpDraftRepository()
+		scheduledMessageRepo = repositories.NewNoOpScheduledMessageRepository()
+		shareLinkRepo = repositories.NewNoOpShareLinkRepository()
+		outboxRepo = repositories.NewNoOpOutboxRepository()
+		txManager = repositories.NewNoOpTxManager()
+		healthRepo = repositories.NewNoOpHealthRepository()
 	}
 
 	// Inicializar servicios auxiliares
 	var cacheService services.CacheService
 	if redisClient != nil {
-		cacheService = services.NewRedisCacheService(redisClient, logger)
+		cacheService = services.NewRedisCacheService(redisClient, logger, cfg.Redis)
 	} else {
 		cacheService = services.NewNoOpCacheService()
 	}
 
 	var eventPublisher services.EventPublisher
-	if redisClient != nil && cfg.Events.Provider == "redis" {
+	var eventSubscriber services.EventSubscriber
+	var eventWebhookPublisher *services.WebhookEventPublisher
+	switch {
+	case redisClient != nil && cfg.Events.Provider == "redis":
 		eventPublisher = services.NewRedisEventPublisher(redisClient, cfg.Events.Topic, logger)
-	} else {
+		eventSubscriber = services.NewRedisEventSubscriber(redisClient, cfg.Events.Topic, logger)
+	case cfg.Events.Provider == "kafka":
+		// An unreachable cluster must not keep the service from starting; events are dropped until restart
+		kafkaWriter, err := services.NewKafkaWriter(context.Background(), cfg.Events.KafkaBrokers, cfg.Events.Topic, cfg.Events.KafkaWriteTimeout)
+		if err != nil {
+			logger.Error("Kafka not available, events disabled", err)
+			eventPublisher = services.NewNoOpEventPublisher()
+		} else {
+			defer kafkaWriter.Close()
+			eventPublisher = services.NewKafkaEventPublisher(kafkaWriter, cfg.Events.Topic, logger)
+		}
+		eventSubscriber = services.NewNoOpEventSubscriber()
+	case cfg.Events.Provider == "webhook":
+		eventWebhookPublisher = services.NewWebhookEventPublisher(cfg.Events.WebhookURL, cfg.Events.WebhookSecret, cfg.Events.WebhookTimeout, cfg.Events.WebhookQueueSize, logger)
+		eventPublisher = eventWebhookPublisher
+		eventSubscriber = services.NewNoOpEventSubscriber()
+	default:
 		eventPublisher = services.NewNoOpEventPublisher()
+		eventSubscriber = services.NewNoOpEventSubscriber()
+	}
+
+	// Entrega adicional a los webhooks de cada conversación, filtrada por sus tipos de evento suscritos;
+	// cada intento queda registrado en webhook_deliveries
+	webhookDeliverer := services.NewWebhookDeliverer(webhookDeliveryRepo, cfg.Webhooks.Timeout, logger)
+	var webhookPublisher *services.ConversationWebhookPublisher
+	if db != nil {
+		webhookPublisher = services.NewConversationWebhookPublisher(eventPublisher, webhookRepo, webhookDeliverer, cfg.Webhooks, logger)
+		eventPublisher = webhookPublisher
+	}
+
+	// El evento de cada mensaje se guarda con él en event_outbox; el relay reintenta los que no se publicaron
+	var outboxRelay *services.OutboxRelay
+	if cfg.Outbox.Enabled && db != nil {
+		outboxRelay = services.NewOutboxRelay(outboxRepo, eventPublisher, cfg.Outbox, logger)
 	}
 
 	logger.Info("Initializing file service...")
-	fileService := services.NewLocalFileService(&cfg.FileStorage, logger)
+	var fileService services.FileService
+	switch cfg.FileStorage.Provider {
+	case "s3":
+		s3Client, err := services.NewSDKS3Client(context.Background(), services.S3Config{
+			Region:          cfg.FileStorage.S3.Region,
+			Endpoint:        cfg.FileStorage.S3.Endpoint,
+			AccessKeyID:     cfg.FileStorage.S3.AccessKeyID,
+			SecretAccessKey: cfg.FileStorage.S3.SecretAccessKey,
+			SessionToken:    cfg.FileStorage.S3.SessionToken,
+		}, cfg.FileStorage.S3.Timeout)
+		if err != nil {
+			logger.Fatal("Failed to initialize S3 client", err)
+		}
+		fileService = services.NewS3FileService(s3Client, &cfg.FileStorage, logger)
+	case "gcs":
+		gcsClient, err := services.NewStorageGCSClient(context.Background(), services.GCSConfig{
+			Endpoint: cfg.FileStorage.GCS.Endpoint,
+		}, cfg.FileStorage.GCS.Timeout)
+		if err != nil {
+			logger.Fatal("Failed to initialize Cloud Storage client", err)
+		}
+		fileService = services.NewGCSFileService(gcsClient, &cfg.FileStorage, logger)
+	default:
+		// Validate only lets "local" through here
+		fileService = services.NewLocalFileService(&cfg.FileStorage, logger)
+	}
+	// Innermost, so only files that passed validation and scanning get a thumbnail
+	var thumbnailService *services.ThumbnailFileService
+	if cfg.FileStorage.ThumbnailSize > 0 {
+		thumbnailService = services.NewThumbnailFileService(fileService, attachmentRepo, &cfg.FileStorage, logger)
+		fileService = thumbnailService
+	}
+	if cfg.Scan.URL != "" {
+		scanFailMode, err := services.ParseFailMode(cfg.Scan.FailMode)
+		if err != nil {
+			logger.Fatal("Invalid SCAN_FAIL_MODE", err)
+		}
+		fileService = services.NewScanningFileService(fileService, services.NewHTTPFileScanner(cfg.Scan.URL, cfg.Scan.Timeout), scanFailMode, logger)
+		logger.Info("File scanning enabled", map[string]interface{}{"fail_mode": scanFailMode})
+	}
+	// Checked before scanning, so spoofed files never reach the scanner
+	fileService = services.NewContentTypeValidatingFileService(fileService, cfg.FileStorage.AllowedMIMETypes, logger)
 	logger.Info("File service initialized")
 
+	// Subidas reanudables: el estado y las partes viven en Redis con TTL
+	var chunkedUploads services.ChunkedUploadService
+	if redisClient != nil {
+		chunkedUploads = services.NewRedisChunkedUploadService(redisClient, fileService, &cfg.FileStorage, logger)
+	} else {
+		chunkedUploads = services.NewNoOpChunkedUploadService()
+	}
+
+	// Bloqueos blandos de conversación, con caducidad en Redis
+	var conversationLocker services.ConversationLocker
+	if redisClient != nil {
+		conversationLocker = services.NewRedisConversationLocker(redisClient, logger)
+	}
+
+	// Claves de idempotencia de los envíos, también en Redis
+	var idempotencyStore services.IdempotencyStore
+	if redisClient != nil {
+		idempotencyStore = services.NewRedisIdempotencyStore(redisClient, logger)
+	}
+
+	var sendHooks []services.SendHook
+
+	// Enmascarado de datos personales; va antes de la moderación para que no salgan del servicio. Las
+	// exportaciones redactadas usan las mismas categorías aunque no se enmascare al guardar
+	piiRedactor, err := services.NewPIIRedactor(cfg.PII.Categories, cfg.PII.Patterns)
+	if err != nil {
+		logger.Fatal("Invalid PII redaction configuration", err)
+	}
+	if cfg.PII.Enabled {
+		sendHooks = append(sendHooks, services.NewPIIRedactionSendHook(piiRedactor))
+		logger.Info("PII redaction enabled", map[string]interface{}{"categories": cfg.PII.Categories})
+	}
+
+	// Moderación de contenido antes de guardar cada mensaje
+	if cfg.Moderation.URL != "" {
+		moderationFailMode, err := services.ParseFailMode(cfg.Moderation.FailMode)
+		if err != nil {
+			logger.Fatal("Invalid MODERATION_FAIL_MODE", err)
+		}
+		sendHooks = append(sendHooks, services.NewModerationSendHook(services.NewHTTPContentModerator(cfg.Moderation.URL, cfg.Moderation.Timeout), moderationFailMode, logger))
+		logger.Info("Content moderation enabled", map[string]interface{}{"fail_mode": moderationFailMode})
+	}
+
+	// Intervalo mínimo entre cambios de estado de una conversación, desactivado por defecto
+	statusThrottleMode, err := services.ParseStatusThrottleMode(cfg.Throttle.StatusMode)
+	if err != nil {
+		logger.Fatal("Invalid CONVERSATION_STATUS_THROTTLE_MODE", err)
+	}
+
+	// Respuesta automática fuera del horario de atención de cada canal
+	var autoResponder *services.AutoResponder
+	if cfg.AutoReply.Enabled && db != nil {
+		schedules, err := services.BuildAutoResponderSchedules(cfg.AutoReply)
+		if err != nil {
+			logger.Fatal("Invalid auto responder configuration", err)
+		}
+		autoResponder = services.NewAutoResponder(conversationRepo, messageRepo, eventPublisher, schedules, cfg.AutoReply.Cooldown, logger)
+		logger.Info("Auto responder enabled", map[string]interface{}{"channels": len(schedules)})
+	}
+
+	// Cierre automático de conversaciones con una frase de resolución, desactivado por defecto
+	autoCloseRule, err := services.BuildAutoCloseRule(cfg.AutoClose)
+	if err != nil {
+		logger.Fatal("Invalid auto-close configuration", err)
+	}
+
+	// Menciones @usuario a otros participantes de la conversación
+	mentionParser, err := services.BuildMentionParser(cfg.Mentions)
+	if err != nil {
+		logger.Fatal("Invalid MENTION_PATTERN", err)
+	}
+
+	// Requisitos estructurales de los mensajes por tipo de contenido
+	contentRules, err := services.BuildContentRules(cfg.Content.Rules)
+	if err != nil {
+		logger.Fatal("Invalid content rules", err)
+	}
+
+	// Borradores de mensaje guardados en el servidor
+	var drafts domain.DraftRepository
+	if cfg.Drafts.Enabled {
+		drafts = draftRepo
+	}
+
+	referencePrefixes := make(map[domain.Channel]string, len(cfg.Reference.Prefixes))
+	for channel, prefix := range cfg.Reference.Prefixes {
+		referencePrefixes[domain.Channel(channel)] = prefix
+	}
+
+	// Adaptadores de entrega saliente por canal, con sus transformadores y límites de entrega configurados
+	outboundTransformers, err := services.BuildOutboundTransformers(cfg.Delivery.OutboundTransformers)
+	if err != nil {
+		logger.Fatal("Invalid outbound transformer configuration", err)
+	}
+	channelSenders := metaChannelSenders(cfg).
+		WithOutboundTransformers(outboundTransformers).
+		WithRateLimits(cfg.Delivery.RateLimits)
+
 	// Inicializar servicios principales
-	healthService := services.NewHealthService()
+	healthService := services.NewHealthService(healthOptions(cfg, healthRepo, redisClient)...)
 	messagingService := services.NewMessagingService(
 		conversationRepo,
 		messageRepo,
@@ -121,7 +380,91 @@ func main() {
 		eventPublisher,
 		cacheService,
 		logger,
+		services.WithReactionRepository(reactionRepo),
+		services.WithTxManager(txManager),
+		services.WithAuditRepository(auditRepo),
+		services.WithTemplateRepository(templateRepo),
+		services.WithParticipantRepository(participantRepo),
+		services.WithMessageReadRepository(messageReadRepo),
+		services.WithWebhookRepository(webhookRepo),
+		services.WithWebhookDeliveries(webhookDeliveryRepo, webhookDeliverer),
+		services.WithFileService(fileService),
+		services.WithExportRedactor(piiRedactor),
+		services.WithSendHooks(sendHooks...),
+		services.WithStatusChangeThrottle(cfg.Throttle.StatusMinInterval, statusThrottleMode),
+		services.WithAutoResponder(autoResponder),
+		services.WithAutoClose(autoCloseRule),
+		services.WithMentions(mentionParser),
+		services.WithConversationReferences(referencePrefixes, cfg.Reference.Digits),
+		services.WithContentRules(contentRules),
+		services.WithDrafts(drafts, cfg.Drafts.MaxLength),
+		services.WithScheduledMessages(scheduledMessageRepo),
+		services.WithInboundDeduplication(cfg.Delivery.InboundDedupEnabled),
+		services.WithChannelDelivery(channelSenders, cfg.Delivery.SendTimeout, cfg.Delivery.RetryBaseBackoff),
+		services.WithConversationLocks(conversationLocker, cfg.Locks.DefaultTTL, cfg.Locks.MaxTTL),
+		services.WithIdempotencyKeys(idempotencyStore, cfg.API.IdempotencyKeyTTL),
+		services.WithEventOutbox(outboxRelay),
+		services.WithShareLinks(shareLinkRepo, auth.NewShareLinkSigner(cfg.ShareLinks.Secret), cfg.ShareLinks.DefaultTTL, cfg.ShareLinks.MaxTTL),
 	)
+	// Quién crea conversaciones o cambia su estado o sus participantes, y desde dónde
+	if db != nil {
+		messagingService = services.NewAuditingMessagingService(messagingService, auditRepo, logger)
+	}
+	messagingService = services.NewTracingMessagingService(messagingService)
+
+	// Workers en segundo plano y WebSocket, se detienen al apagar el servidor
+	background := lifecycle.NewManager(logger)
+
+	if cfg.Delivery.RetryEnabled && db != nil {
+		retryWorker := services.NewDeliveryRetryWorker(messageRepo, conversationRepo, channelSenders, eventPublisher, cfg.Delivery, logger)
+		background.Go("delivery-retry-worker", retryWorker.Start)
+	}
+
+	if cfg.Abandonment.Enabled && db != nil {
+		abandonmentWorker := services.NewAbandonmentWorker(conversationRepo, eventPublisher, cacheService, cfg.Abandonment, logger)
+		background.Go("abandonment-worker", abandonmentWorker.Start)
+	}
+
+	if cfg.Archival.Enabled && db != nil {
+		archivalWorker := services.NewArchivalWorker(conversationRepo, eventPublisher, cacheService, cfg.Archival, logger)
+		background.Go("archival-worker", archivalWorker.Start)
+	}
+
+	if thumbnailService != nil {
+		background.Go("thumbnail-worker", thumbnailService.Start)
+	}
+
+	if outboxRelay != nil {
+		background.Go("outbox-relay", outboxRelay.Start)
+	}
+
+	if webhookPublisher != nil {
+		background.Go("conversation-webhook-publisher", webhookPublisher.Start)
+	}
+
+	if eventWebhookPublisher != nil {
+		background.Go("event-webhook-publisher", eventWebhookPublisher.Start)
+	}
+
+	if cfg.Webhooks.DeliveryRetention > 0 && db != nil {
+		deliveryPruner := services.NewWebhookDeliveryPruner(webhookDeliveryRepo, cfg.Webhooks, logger)
+		background.Go("webhook-delivery-pruner", deliveryPruner.Start)
+	}
+
+	if cfg.Expiry.ReaperEnabled && db != nil {
+		expiryReaper := services.NewMessageExpiryReaper(messageRepo, attachmentRepo, fileService, eventPublisher, cacheService, cfg.Expiry, logger)
+		background.Go("message-expiry-reaper", expiryReaper.Start)
+	}
+
+	if cfg.Scheduling.WorkerEnabled && db != nil {
+		scheduledWorker := services.NewScheduledMessageWorker(scheduledMessageRepo, messagingService, cfg.Scheduling, logger)
+		background.Go("scheduled-message-worker", scheduledWorker.Start)
+	}
+
+	if cfg.Counters.ReconcileEnabled && db != nil {
+		countReconciler := services.NewMessageCountReconciler(conversationRepo, cacheService, cfg.Counters, logger)
+		background.Go("message-count-reconciler", countReconciler.Start)
+	}
 
 	// Configurar Gin
 	if cfg.Environment == "production" {
@@ -129,13 +472,39 @@ func main() {
 	}
 
 	router := gin.New()
+	// The request ID comes before everything else so every log line and event of the request carries it
+	router.Use(middleware.RequestID())
+	// Tracing goes before Recovery so the request span sees the 500 Recovery writes for a panic
+	router.Use(otelgin.Middleware(cfg.Tracing.ServiceName))
 	router.Use(gin.Recovery())
 	router.Use(middleware.Logger(logger))
 	router.Use(middleware.CORS())
 	router.Use(middleware.Metrics())
 
 	// Rutas
-	handlers.SetupRoutes(router, healthService, messagingService, fileService, jwtManager, logger)
+	handlers.SetupRoutes(router, healthService, messagingService, fileService, jwtManager, logger,
+		handlers.WithServiceAuth(serviceTokens, auditRepo),
+		handlers.WithChunkedUploads(chunkedUploads),
+		handlers.WithEventStream(eventSubscriber),
+		handlers.WithStreamLifecycle(background),
+		handlers.WithStreamBatching(cfg.Events.StreamBatchWindow, cfg.Events.StreamBatchMaxSize),
+		handlers.WithTypingAgentRoles(cfg.Events.TypingAgentRoles),
+		handlers.WithResponseEnvelope(cfg.API.ResponseEnvelope),
+		handlers.WithPinnedFirst(cfg.API.PinnedFirst),
+		handlers.WithAccessDeniedMasking(cfg.API.MaskAccessDenied),
+		handlers.WithDeliveryReceipts(auth.NewWebhookSignatureVerifier(cfg.Delivery.ReceiptSecrets, cfg.Delivery.ReceiptTolerance)),
+		handlers.WithMetaWebhooks(
+			whatsapp.NewWebhook(cfg.WhatsApp.AppSecret, cfg.WhatsApp.VerifyToken, messagingService, logger),
+			messenger.NewWebhook(domain.ChannelMessenger, cfg.Messenger.AppSecret, cfg.Messenger.VerifyToken, messagingService, logger),
+			messenger.NewWebhook(domain.ChannelInstagram, cfg.Instagram.AppSecret, cfg.Instagram.VerifyToken, messagingService, logger),
+		),
+		handlers.WithSignedUploads(auth.NewFileURLSigner(cfg.FileStorage.SigningSecret), cfg.FileStorage.LocalPath),
+		handlers.WithAttachmentURLTTL(cfg.FileStorage.URLTTL),
+		handlers.WithUploadBatchLimit(cfg.FileStorage.MaxBatchFiles),
+		handlers.WithRateLimits(middleware.NewRateLimiter(redisClient, logger),
+			handlers.RateLimit{Limit: cfg.RateLimit.UserRequests, Window: cfg.RateLimit.Window},
+			handlers.RateLimit{Limit: cfg.RateLimit.PublicRequests, Window: cfg.RateLimit.Window}),
+	)
 
 	// Servidor HTTP
 	srv := &http.Server{
@@ -159,6 +528,15 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
+	// Background tasks and WebSocket streams are cancelled first; publishers flush their queues meanwhile
+	backgroundCtx, cancelBackground := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	if err := background.Shutdown(backgroundCtx); err != nil {
+		logger.Warn("Background tasks did not stop in time", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	cancelBackground()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -166,6 +544,11 @@ func main() {
 		logger.Fatal("Server forced to shutdown", err)
 	}
 
+	// Flush the spans still buffered
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("Failed to flush traces", err)
+	}
+
 	logger.Info("Server exited")
 }
 
@@ -202,6 +585,43 @@ func initDatabase(dbCfg *config.DatabaseConfig, logger logger.Logger) (*sql.DB,
 	return db, nil
 }
 
+// healthOptions registra en el readiness las dependencias configuradas; una que no llegó a conectar al
+// arrancar se informa como caída en lugar de omitirse (el repositorio no-op siempre falla)
+func healthOptions(cfg *config.Config, healthRepo domain.HealthRepository, redisClient *redis.Client) []services.HealthServiceOption {
+	opts := []services.HealthServiceOption{
+		services.WithRequiredDependencies(cfg.Health.RequiredDependencies),
+		services.WithCheckTimeout(cfg.Health.CheckTimeout),
+		services.WithDependencyCheck("database", services.DatabaseCheck(healthRepo)),
+	}
+
+	if redisClient != nil {
+		opts = append(opts, services.WithDependencyCheck("redis", services.RedisCheck(redisClient)))
+	} else if cfg.Redis.Enabled {
+		opts = append(opts, services.WithDependencyCheck("redis", func(ctx context.Context) error {
+			return fmt.Errorf("redis not available")
+		}))
+	}
+
+	return opts
+}
+
+// metaChannelSenders crea los adaptadores de salida de los canales de Meta con credenciales; los mensajes
+// de un canal sin adaptador se guardan sin entregarse a ningún proveedor
+func metaChannelSenders(cfg *config.Config) services.ChannelSenders {
+	senders := services.ChannelSenders{}
+	if cfg.WhatsApp.SenderEnabled() {
+		senders[domain.ChannelWhatsApp] = whatsapp.NewSender(cfg.WhatsApp.GraphAPIURL, cfg.WhatsApp.AccessToken, cfg.WhatsApp.PhoneNumberID, cfg.Delivery.SendTimeout)
+	}
+	if cfg.Messenger.SenderEnabled() {
+		senders[domain.ChannelMessenger] = messenger.NewSender(cfg.Messenger.GraphAPIURL, cfg.Messenger.AccessToken, cfg.Delivery.SendTimeout)
+	}
+	if cfg.Instagram.SenderEnabled() {
+		senders[domain.ChannelInstagram] = messenger.NewSender(cfg.Instagram.GraphAPIURL, cfg.Instagram.AccessToken, cfg.Delivery.SendTimeout)
+	}
+
+	return senders
+}
+
 func initRedis(redisCfg *config.RedisConfig, logger logger.Logger) *redis.Client {
 	client := redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%s", redisCfg.Host, redisCfg.Port),
@@ -0,0 +1,109 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/company/microservice-template/pkg/logger"
+)
+
+// Manager lleva la cuenta de las tareas de larga duración del proceso (workers, publishers y
+// suscripciones a eventos) para cancelarlas juntas al apagar el servidor y esperar a que terminen
+type Manager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	logger logger.Logger
+
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	stopping bool
+	running  map[string]int
+}
+
+func NewManager(logger logger.Logger) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Manager{
+		ctx:     ctx,
+		cancel:  cancel,
+		logger:  logger,
+		running: make(map[string]int),
+	}
+}
+
+// Go ejecuta fn en una goroutine con un contexto que se cancela en Shutdown
+func (m *Manager) Go(name string, fn func(ctx context.Context)) {
+	ctx, done := m.Track(name)
+	go func() {
+		defer done()
+		fn(ctx)
+	}()
+}
+
+// Track registra una tarea que corre en la goroutine del llamador, como una conexión WebSocket. La tarea
+// debe terminar cuando se cancele el contexto devuelto y llamar a done al salir. Una vez iniciado el
+// apagado el contexto ya llega cancelado
+func (m *Manager) Track(name string) (ctx context.Context, done func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stopping {
+		return m.ctx, func() {}
+	}
+
+	m.wg.Add(1)
+	m.running[name]++
+
+	var once sync.Once
+	return m.ctx, func() {
+		once.Do(func() {
+			m.mu.Lock()
+			if m.running[name]--; m.running[name] == 0 {
+				delete(m.running, name)
+			}
+			m.mu.Unlock()
+			m.wg.Done()
+		})
+	}
+}
+
+// Shutdown cancela todas las tareas y espera a que terminen o a que venza ctx; en ese caso devuelve un
+// error con las que siguen en marcha
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.stopping = true
+	m.mu.Unlock()
+
+	m.cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		m.logger.Info("Background tasks stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background tasks still running after shutdown deadline: %s", m.pending())
+	}
+}
+
+// pending describe las tareas que no han terminado, p. ej. "stream(2), webhook-publisher(1)"
+func (m *Manager) pending() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.running))
+	for name, count := range m.running {
+		names = append(names, fmt.Sprintf("%s(%d)", name, count))
+	}
+	sort.Strings(names)
+
+	return strings.Join(names, ", ")
+}
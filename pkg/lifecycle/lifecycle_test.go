@@ -0,0 +1,78 @@
+package lifecycle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/microservice-template/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_ShutdownCancelsRunningSubscriber(t *testing.T) {
+	// Setup: a subscriber relays events until its context is cancelled, then releases the subscription
+	manager := NewManager(logger.NewLogger("debug"))
+	events := make(chan string)
+	started := make(chan struct{})
+	closed := make(chan struct{})
+
+	manager.Go("event-subscriber", func(ctx context.Context) {
+		defer close(closed)
+		close(started)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-events:
+			}
+		}
+	})
+	<-started
+	events <- "message.created"
+
+	// Execute
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := manager.Shutdown(ctx)
+
+	// Assert: the subscriber stopped before the deadline
+	require.NoError(t, err)
+	select {
+	case <-closed:
+	default:
+		t.Fatal("subscriber still running after Shutdown returned")
+	}
+}
+
+func TestManager_ShutdownDeadlineReportsPendingTasks(t *testing.T) {
+	// Setup: a task that ignores cancellation until the test releases it
+	manager := NewManager(logger.NewLogger("debug"))
+	release := make(chan struct{})
+	defer close(release)
+
+	manager.Go("stuck-worker", func(ctx context.Context) {
+		<-release
+	})
+	_, done := manager.Track("websocket-stream")
+	defer done()
+
+	// Execute
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := manager.Shutdown(ctx)
+
+	// Assert
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stuck-worker(1), websocket-stream(1)")
+}
+
+func TestManager_TrackAfterShutdownIsCancelled(t *testing.T) {
+	manager := NewManager(logger.NewLogger("debug"))
+	require.NoError(t, manager.Shutdown(context.Background()))
+
+	ctx, done := manager.Track("websocket-stream")
+	defer done()
+
+	assert.Error(t, ctx.Err())
+}
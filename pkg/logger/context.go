@@ -0,0 +1,16 @@
+package logger
+
+import "context"
+
+type requestIDKey struct{}
+
+// ContextWithRequestID guarda en el contexto el ID de correlación de la petición en curso
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext devuelve el ID de correlación de la petición, o "" fuera de una petición
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
@@ -1,77 +1,165 @@
 package logger
 
 import (
+	"context"
+	"os"
+	"sort"
+
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// Formatos de salida admitidos en LOG_FORMAT
+const (
+	FormatJSON = "json" // Un objeto por línea con severity, message y time, como lo interpreta Cloud Logging
+	FormatText = "text" // Líneas legibles para desarrollo local
+)
+
+// Logger recibe tras el mensaje pares clave-valor, errores y mapas de campos, en cualquier combinación:
+// logger.Error("Failed to send", err, map[string]interface{}{"message_id": id})
 type Logger interface {
 	Debug(msg string, fields ...interface{})
 	Info(msg string, fields ...interface{})
 	Warn(msg string, fields ...interface{})
 	Error(msg string, fields ...interface{})
 	Fatal(msg string, fields ...interface{})
+	// WithFields devuelve un logger que añade fields a cada línea
+	WithFields(fields map[string]interface{}) Logger
+	// WithContext devuelve un logger con el request_id y la traza (trace_id, span_id) del contexto, para
+	// correlacionar todas las líneas de una petición
+	WithContext(ctx context.Context) Logger
 }
 
 type zapLogger struct {
 	logger *zap.Logger
 }
 
+// NewLogger crea un logger JSON en stderr
 func NewLogger(level string) Logger {
-	config := zap.NewProductionConfig()
-	
-	// Configurar nivel de log
+	return NewLoggerWithFormat(level, FormatJSON)
+}
+
+// NewLoggerWithFormat crea un logger en stderr con el formato indicado; uno desconocido se trata como JSON
+func NewLoggerWithFormat(level string, format string) Logger {
+	return newLogger(level, format, zapcore.Lock(os.Stderr))
+}
+
+func newLogger(level string, format string, out zapcore.WriteSyncer) Logger {
+	var encoder zapcore.Encoder
+	if format == FormatText {
+		config := zap.NewDevelopmentEncoderConfig()
+		config.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(config)
+	} else {
+		encoder = zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+			TimeKey:        "time",
+			LevelKey:       "severity",
+			NameKey:        "logger",
+			CallerKey:      "caller",
+			MessageKey:     "message",
+			StacktraceKey:  "stacktrace",
+			LineEnding:     zapcore.DefaultLineEnding,
+			EncodeLevel:    zapcore.CapitalLevelEncoder,
+			EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+			EncodeDuration: zapcore.StringDurationEncoder,
+			EncodeCaller:   zapcore.ShortCallerEncoder,
+		})
+	}
+
+	core := zapcore.NewCore(encoder, out, parseLevel(level))
+	// Skip this wrapper so the caller is the line that logged
+	return &zapLogger{
+		logger: zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1), zap.AddStacktrace(zapcore.ErrorLevel)),
+	}
+}
+
+// parseLevel convierte LOG_LEVEL; un valor desconocido deja el nivel info
+func parseLevel(level string) zapcore.Level {
 	switch level {
 	case "debug":
-		config.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
-	case "info":
-		config.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
+		return zapcore.DebugLevel
 	case "warn":
-		config.Level = zap.NewAtomicLevelAt(zapcore.WarnLevel)
+		return zapcore.WarnLevel
 	case "error":
-		config.Level = zap.NewAtomicLevelAt(zapcore.ErrorLevel)
+		return zapcore.ErrorLevel
 	default:
-		config.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel)
-	}
-	
-	logger, _ := config.Build()
-	
-	return &zapLogger{
-		logger: logger,
+		return zapcore.InfoLevel
 	}
 }
 
 func (l *zapLogger) Debug(msg string, fields ...interface{}) {
-	l.logger.Debug(msg, l.convertFields(fields...)...)
+	l.logger.Debug(msg, convertFields(fields...)...)
 }
 
 func (l *zapLogger) Info(msg string, fields ...interface{}) {
-	l.logger.Info(msg, l.convertFields(fields...)...)
+	l.logger.Info(msg, convertFields(fields...)...)
 }
 
 func (l *zapLogger) Warn(msg string, fields ...interface{}) {
-	l.logger.Warn(msg, l.convertFields(fields...)...)
+	l.logger.Warn(msg, convertFields(fields...)...)
 }
 
 func (l *zapLogger) Error(msg string, fields ...interface{}) {
-	l.logger.Error(msg, l.convertFields(fields...)...)
+	l.logger.Error(msg, convertFields(fields...)...)
 }
 
 func (l *zapLogger) Fatal(msg string, fields ...interface{}) {
-	l.logger.Fatal(msg, l.convertFields(fields...)...)
+	l.logger.Fatal(msg, convertFields(fields...)...)
 }
 
-func (l *zapLogger) convertFields(fields ...interface{}) []zap.Field {
-	zapFields := make([]zap.Field, 0, len(fields)/2)
-	
-	for i := 0; i < len(fields)-1; i += 2 {
-		key, ok := fields[i].(string)
-		if !ok {
-			continue
+func (l *zapLogger) WithFields(fields map[string]interface{}) Logger {
+	if len(fields) == 0 {
+		return l
+	}
+	return &zapLogger{
+		logger: l.logger.With(convertFields(fields)...),
+	}
+}
+
+func (l *zapLogger) WithContext(ctx context.Context) Logger {
+	fields := make([]zap.Field, 0, 3)
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		fields = append(fields, zap.String("request_id", requestID))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		fields = append(fields, zap.String("trace_id", span.TraceID().String()), zap.String("span_id", span.SpanID().String()))
+	}
+	if len(fields) == 0 {
+		return l
+	}
+
+	return &zapLogger{
+		logger: l.logger.With(fields...),
+	}
+}
+
+// convertFields acepta pares clave-valor, errores (campo error) y mapas de campos; lo que no encaja en
+// ninguna de esas formas se descarta
+func convertFields(fields ...interface{}) []zap.Field {
+	zapFields := make([]zap.Field, 0, len(fields))
+
+	for i := 0; i < len(fields); i++ {
+		switch field := fields[i].(type) {
+		case error:
+			zapFields = append(zapFields, zap.Error(field))
+		case map[string]interface{}:
+			// Sorted so the same fields always come out in the same order
+			keys := make([]string, 0, len(field))
+			for key := range field {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				zapFields = append(zapFields, zap.Any(key, field[key]))
+			}
+		case string:
+			if i+1 < len(fields) {
+				zapFields = append(zapFields, zap.Any(field, fields[i+1]))
+				i++
+			}
 		}
-		value := fields[i+1]
-		zapFields = append(zapFields, zap.Any(key, value))
 	}
-	
+
 	return zapFields
-}
\ No newline at end of file
+}
@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+)
+
+// newBufferLogger escribe en un buffer para inspeccionar las líneas
+func newBufferLogger(level string, format string) (Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return newLogger(level, format, zapcore.AddSync(&buf)), &buf
+}
+
+// lines decodifica cada línea JSON escrita en buf
+func lines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), line)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestLogger_JSONOutput(t *testing.T) {
+	log, buf := newBufferLogger("info", FormatJSON)
+
+	log.WithFields(map[string]interface{}{"component": "outbox"}).
+		Warn("Failed to publish", errors.New("broker down"), map[string]interface{}{"attempts": 3}, "event_type", "message.received")
+
+	entries := lines(t, buf)
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, "WARN", entry["severity"])
+	assert.Equal(t, "Failed to publish", entry["message"])
+	assert.NotEmpty(t, entry["time"])
+	assert.Contains(t, entry["caller"], "logger_test.go")
+	assert.Equal(t, "outbox", entry["component"])
+	assert.Equal(t, "broker down", entry["error"])
+	assert.Equal(t, float64(3), entry["attempts"])
+	assert.Equal(t, "message.received", entry["event_type"])
+}
+
+func TestLogger_LevelFiltering(t *testing.T) {
+	log, buf := newBufferLogger("warn", FormatJSON)
+
+	log.Debug("debug line")
+	log.Info("info line")
+	log.Warn("warn line")
+	log.Error("error line")
+
+	entries := lines(t, buf)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "warn line", entries[0]["message"])
+	assert.Equal(t, "ERROR", entries[1]["severity"])
+}
+
+func TestLogger_WithContext(t *testing.T) {
+	log, buf := newBufferLogger("info", FormatJSON)
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	})
+	ctx := trace.ContextWithSpanContext(ContextWithRequestID(context.Background(), "req-42"), spanContext)
+
+	log.WithContext(ctx).Info("Conversation created")
+	log.WithContext(context.Background()).Info("Outside a request")
+
+	entries := lines(t, buf)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "req-42", entries[0]["request_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", entries[0]["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", entries[0]["span_id"])
+	assert.NotContains(t, entries[1], "request_id")
+	assert.NotContains(t, entries[1], "trace_id")
+}
+
+func TestLogger_TextOutput(t *testing.T) {
+	log, buf := newBufferLogger("info", FormatText)
+
+	log.Info("Server started", "port", "8080")
+
+	assert.False(t, json.Valid(buf.Bytes()))
+	assert.Contains(t, buf.String(), "Server started")
+	assert.Contains(t, buf.String(), `"port": "8080"`)
+}
@@ -3,32 +3,53 @@ package tracing
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
 
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 )
 
+// defaultExportTimeout acota cada envío de spans al colector
+const defaultExportTimeout = 10 * time.Second
+
+// otlpTracesPath es la ruta del colector OTLP/HTTP que recibe las trazas
+const otlpTracesPath = "/v1/traces"
+
 type Config struct {
 	ServiceName    string
 	ServiceVersion string
 	Environment    string
-	JaegerEndpoint string
-	Enabled        bool
+	// OTLPEndpoint es la URL base del colector OTLP/HTTP; vacía desactiva el trazado
+	OTLPEndpoint string
+	// SampleRatio es la fracción de trazas nuevas que se muestrean; las que llegan con padre siguen su decisión
+	SampleRatio   float64
+	ExportTimeout time.Duration
 }
 
+// InitTracing registra el TracerProvider global que exporta por OTLP. Sin endpoint no registra nada y los
+// spans del servicio son no-ops
 func InitTracing(cfg Config) (func(context.Context) error, error) {
-	if !cfg.Enabled {
+	if cfg.OTLPEndpoint == "" {
 		return func(context.Context) error { return nil }, nil
 	}
 
-	// Create Jaeger exporter
-	exp, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(cfg.JaegerEndpoint)))
+	timeout := cfg.ExportTimeout
+	if timeout <= 0 {
+		timeout = defaultExportTimeout
+	}
+	opts, err := exporterOptions(cfg.OTLPEndpoint, timeout)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create jaeger exporter: %w", err)
+		return nil, err
+	}
+	exp, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
 	}
 
 	// Create resource
@@ -47,8 +68,9 @@ func InitTracing(cfg Config) (func(context.Context) error, error) {
 
 	// Create trace provider
 	tp := trace.NewTracerProvider(
-		trace.WithBatcher(exp),
+		trace.WithBatcher(exp, trace.WithExportTimeout(timeout)),
 		trace.WithResource(res),
+		trace.WithSampler(trace.ParentBased(trace.TraceIDRatioBased(cfg.SampleRatio))),
 	)
 
 	// Set global trace provider
@@ -61,4 +83,28 @@ func InitTracing(cfg Config) (func(context.Context) error, error) {
 	))
 
 	return tp.Shutdown, nil
-}
\ No newline at end of file
+}
+
+// exporterOptions traduce la URL base del colector (p. ej. http://otel-collector:4318) a las opciones de
+// otlptracehttp; si ya termina en /v1/traces se usa tal cual
+func exporterOptions(endpoint string, timeout time.Duration) ([]otlptracehttp.Option, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid otlp endpoint %q", endpoint)
+	}
+
+	path := parsed.Path
+	if !strings.HasSuffix(path, otlpTracesPath) {
+		path += otlpTracesPath
+	}
+
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(parsed.Host),
+		otlptracehttp.WithURLPath(path),
+		otlptracehttp.WithTimeout(timeout),
+	}
+	if parsed.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	return opts, nil
+}
@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestInitTracing_ExportsToCollector(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	var contentType string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.Path)
+		contentType = r.Header.Get("Content-Type")
+	}))
+	defer collector.Close()
+
+	shutdown, err := InitTracing(Config{ServiceName: "test-service", OTLPEndpoint: collector.URL, SampleRatio: 1, ExportTimeout: time.Second})
+	require.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "operation")
+	span.End()
+
+	// Shutdown flushes the batch to the collector
+	require.NoError(t, shutdown(context.Background()))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"/v1/traces"}, paths)
+	assert.Equal(t, "application/x-protobuf", contentType)
+}
+
+func TestExporterOptions(t *testing.T) {
+	_, err := exporterOptions("http://otel-collector:4318/v1/traces", time.Second)
+	assert.NoError(t, err)
+
+	_, err = exporterOptions("otel-collector", time.Second)
+	assert.Error(t, err)
+}
+
+func TestInitTracing_DisabledWithoutEndpoint(t *testing.T) {
+	shutdown, err := InitTracing(Config{ServiceName: "test-service"})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
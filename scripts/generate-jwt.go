@@ -3,44 +3,43 @@ package main
 import (
 	"fmt"
 	"log"
-	"time"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/company/microservice-template/internal/auth"
 )
 
-type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
-	jwt.RegisteredClaims
+// Usar la misma clave secreta y el mismo emisor que en el .env
+const (
+	secretKey = "dev-jwt-secret-key-change-in-production"
+	issuer    = "messaging-service"
+)
+
+// testUser es un usuario de prueba; Roles va en el claim roles que comprueba RequireRole
+type testUser struct {
+	ID    string
+	Email string
+	Roles []string
 }
 
 func main() {
-	// Usar la misma clave secreta que en el .env
-	secretKey := "dev-jwt-secret-key-change-in-production"
-	issuer := "messaging-service"
-
 	// Crear tokens para diferentes usuarios de prueba
-	users := []struct {
-		ID    string
-		Email string
-		Role  string
-	}{
-		{"user-1", "user1@example.com", "user"},
-		{"user-2", "user2@example.com", "user"},
-		{"admin-1", "admin@example.com", "admin"},
+	users := []testUser{
+		{"user-1", "user1@example.com", []string{"user"}},
+		{"user-2", "user2@example.com", []string{"user"}},
+		{"agent-1", "agent1@example.com", []string{"agent"}},
+		{"admin-1", "admin@example.com", []string{"admin"}},
 	}
 
-	fmt.Println("=== JWT Tokens para Testing ===\n")
+	fmt.Println("=== JWT Tokens para Testing ===")
+	fmt.Println()
 
 	for _, user := range users {
-		token, err := generateToken(user.ID, user.Email, user.Role, secretKey, issuer)
+		token, err := generateToken(user)
 		if err != nil {
 			log.Printf("Error generando token para %s: %v", user.Email, err)
 			continue
 		}
 
-		fmt.Printf("Usuario: %s (%s)\n", user.Email, user.Role)
+		fmt.Printf("Usuario: %s (%v)\n", user.Email, user.Roles)
 		fmt.Printf("Token: %s\n\n", token)
 	}
 
@@ -50,20 +49,8 @@ func main() {
 	fmt.Println("3. Los tokens son válidos por 24 horas")
 }
 
-func generateToken(userID, email, role, secretKey, issuer string) (string, error) {
-	claims := Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    issuer,
-			Subject:   userID,
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secretKey))
-}
\ No newline at end of file
+// generateToken firma el token con el mismo JWTManager que valida el servicio, para que sus claims no se
+// desalineen de las que espera el middleware
+func generateToken(user testUser) (string, error) {
+	return auth.NewJWTManager(secretKey, issuer).GenerateToken(user.ID, user.Email, user.Roles)
+}
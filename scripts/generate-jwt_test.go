@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/company/microservice-template/internal/auth"
+	"github.com/company/microservice-template/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateToken_RequireRole(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin", middleware.Authenticate(auth.NewJWTManager(secretKey, issuer)), middleware.RequireRole("admin"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	get := func(user testUser) int {
+		token, err := generateToken(user)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Execute & Assert
+	assert.Equal(t, http.StatusOK, get(testUser{"admin-1", "admin@example.com", []string{"admin"}}))
+	assert.Equal(t, http.StatusForbidden, get(testUser{"user-1", "user1@example.com", []string{"user"}}))
+}
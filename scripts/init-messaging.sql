@@ -1,25 +1,48 @@
 -- Messaging Service Database Schema
 
+-- Trigram matching for substring searches (attachment filenames)
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
 -- Create conversations table
 CREATE TABLE IF NOT EXISTS conversations (
     id UUID PRIMARY KEY,
     user_id VARCHAR(255) NOT NULL,
     channel VARCHAR(50) NOT NULL CHECK (channel IN ('whatsapp', 'web', 'messenger', 'instagram')),
-    status VARCHAR(50) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'closed', 'archived')),
+    status VARCHAR(50) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'closed', 'archived', 'abandoned')),
+    message_count INTEGER NOT NULL DEFAULT 0,
+    status_changed_at TIMESTAMP WITH TIME ZONE,
+    auto_replied_at TIMESTAMP WITH TIME ZONE,
+    reference VARCHAR(32),
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    metadata JSONB NOT NULL DEFAULT '{}',
     created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
 );
 
+-- Per-prefix counters for the human-friendly conversation references (e.g. WEB-001234)
+CREATE TABLE IF NOT EXISTS conversation_reference_counters (
+    prefix VARCHAR(16) PRIMARY KEY,
+    last_value BIGINT NOT NULL
+);
+
 -- Create messages table
 CREATE TABLE IF NOT EXISTS messages (
     id UUID PRIMARY KEY,
     conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
-    sender_type VARCHAR(50) NOT NULL CHECK (sender_type IN ('user', 'bot', 'system')),
+    sender_type VARCHAR(50) NOT NULL CHECK (sender_type IN ('user', 'agent', 'bot', 'system')),
     sender_id VARCHAR(255) NOT NULL,
     content TEXT NOT NULL,
     content_type VARCHAR(50) NOT NULL CHECK (content_type IN ('text', 'image', 'video', 'audio', 'file')),
     metadata JSONB DEFAULT '{}',
-    timestamp TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+    timestamp TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    delivery_status VARCHAR(50) CHECK (delivery_status IN ('pending', 'sent', 'delivered', 'read', 'failed')),
+    delivery_attempts INTEGER NOT NULL DEFAULT 0,
+    next_retry_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    pinned_at TIMESTAMP WITH TIME ZONE,
+    edited_at TIMESTAMP WITH TIME ZONE,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    reply_to_id UUID REFERENCES messages(id) ON DELETE SET NULL
 );
 
 -- Create attachments table
@@ -30,22 +53,219 @@ CREATE TABLE IF NOT EXISTS attachments (
     type VARCHAR(50) NOT NULL CHECK (type IN ('image', 'video', 'file', 'audio')),
     size BIGINT NOT NULL,
     filename VARCHAR(255) NOT NULL,
+    thumbnail_url TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create generated thumbnails table; an attachment created without thumbnail_url takes the one of its file
+CREATE TABLE IF NOT EXISTS file_thumbnails (
+    url TEXT PRIMARY KEY,
+    thumbnail_url TEXT NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create reactions table
+CREATE TABLE IF NOT EXISTS reactions (
+    id UUID PRIMARY KEY,
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    emoji VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (message_id, user_id, emoji)
+);
+
+-- Create audit logs table
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id UUID PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    action VARCHAR(100) NOT NULL,
+    resource VARCHAR(255) NOT NULL,
+    details JSONB DEFAULT '{}',
+    ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create conversation participants table; participants besides the owner may read and write the
+-- conversation. Only adding a participant creates a row: marking a conversation as read never does
+CREATE TABLE IF NOT EXISTS conversation_participants (
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    role VARCHAR(50) NOT NULL DEFAULT 'member',
+    joined_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+-- Create conversation read markers table; last_read_at is how far each reader has read the conversation
+CREATE TABLE IF NOT EXISTS conversation_read_markers (
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    last_read_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+-- Create per-message read receipts table
+CREATE TABLE IF NOT EXISTS message_reads (
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    read_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (message_id, user_id)
+);
+
+-- Create message drafts table; one private draft per user and conversation
+CREATE TABLE IF NOT EXISTS message_drafts (
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL DEFAULT '',
+    metadata JSONB NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+-- Create scheduled messages table; a row leaves 'scheduled' when the worker claims it for sending
+-- ('sending', then 'sent' or 'failed') or when it is cancelled. A claim older than the lease goes back to 'scheduled'
+CREATE TABLE IF NOT EXISTS scheduled_messages (
+    id UUID PRIMARY KEY,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    sender_type VARCHAR(50) NOT NULL CHECK (sender_type IN ('user', 'agent', 'bot', 'system')),
+    sender_id VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL DEFAULT '',
+    content_type VARCHAR(50) NOT NULL,
+    metadata JSONB NOT NULL DEFAULT '{}',
+    attachments JSONB NOT NULL DEFAULT '[]',
+    reply_to_id UUID,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    scheduled_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'sending', 'sent', 'failed', 'cancelled')),
+    message_id UUID,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create conversation share links table
+CREATE TABLE IF NOT EXISTS conversation_share_links (
+    id UUID PRIMARY KEY,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    created_by VARCHAR(255) NOT NULL,
+    read_only BOOLEAN NOT NULL DEFAULT TRUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create conversation templates table
+CREATE TABLE IF NOT EXISTS conversation_templates (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    channel VARCHAR(50) NOT NULL CHECK (channel IN ('whatsapp', 'web', 'messenger', 'instagram')),
+    messages JSONB NOT NULL DEFAULT '[]',
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Create per-conversation webhooks table; event_types holds the subscribed event types
+CREATE TABLE IF NOT EXISTS conversation_webhooks (
+    id UUID PRIMARY KEY,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    event_types TEXT[] NOT NULL,
+    secret VARCHAR(128) NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
     created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
 );
 
+-- Create webhook delivery log; one row per attempt, payload keeps the body sent so it can be replayed
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY,
+    webhook_id UUID NOT NULL REFERENCES conversation_webhooks(id) ON DELETE CASCADE,
+    conversation_id UUID NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    event_type VARCHAR(100) NOT NULL,
+    url TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    attempt INTEGER NOT NULL DEFAULT 1,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('succeeded', 'failed')),
+    status_code INTEGER,
+    response_time_ms BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    replay_of UUID REFERENCES webhook_deliveries(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Eventos guardados con el cambio que los produce; el relay publica los pendientes (al menos una vez)
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id UUID PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    conversation_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP WITH TIME ZONE
+);
+
 -- Create indexes for better performance
 CREATE INDEX IF NOT EXISTS idx_conversations_user_id ON conversations(user_id);
 CREATE INDEX IF NOT EXISTS idx_conversations_status ON conversations(status);
 CREATE INDEX IF NOT EXISTS idx_conversations_channel ON conversations(channel);
 CREATE INDEX IF NOT EXISTS idx_conversations_updated_at ON conversations(updated_at DESC);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_conversations_reference ON conversations(reference);
+CREATE INDEX IF NOT EXISTS idx_conversations_tags ON conversations USING GIN(tags);
+CREATE INDEX IF NOT EXISTS idx_conversations_metadata ON conversations USING GIN(metadata jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS idx_conversations_channel_created ON conversations(channel, created_at) WHERE status <> 'archived';
 
 CREATE INDEX IF NOT EXISTS idx_messages_conversation_id ON messages(conversation_id);
 CREATE INDEX IF NOT EXISTS idx_messages_sender_id ON messages(sender_id);
 CREATE INDEX IF NOT EXISTS idx_messages_timestamp ON messages(timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_messages_conversation_timestamp ON messages(conversation_id, timestamp DESC);
+-- Paginación por cursor de GetMessages: (timestamp, id) < (cursor)
+CREATE INDEX IF NOT EXISTS idx_messages_conversation_timestamp_id ON messages(conversation_id, timestamp DESC, id DESC);
 CREATE INDEX IF NOT EXISTS idx_messages_content_type ON messages(content_type);
+CREATE INDEX IF NOT EXISTS idx_messages_delivery_retry ON messages(next_retry_at) WHERE delivery_status = 'failed';
+CREATE INDEX IF NOT EXISTS idx_messages_expires_at ON messages(expires_at) WHERE expires_at IS NOT NULL;
+-- Búsqueda de texto completo en el contenido (GET /messages/search); la expresión debe coincidir con la del repositorio
+CREATE INDEX IF NOT EXISTS idx_messages_content_fts ON messages USING GIN (to_tsvector('simple', content));
+CREATE INDEX IF NOT EXISTS idx_messages_pinned ON messages(conversation_id, pinned_at) WHERE pinned_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_messages_reply_to_id ON messages(reply_to_id, timestamp) WHERE reply_to_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_messages_provider_message_id ON messages((metadata->>'provider_message_id'));
+-- Un webhook reintentado por el proveedor no puede guardar dos veces el mismo mensaje entrante
+CREATE UNIQUE INDEX IF NOT EXISTS idx_messages_conversation_provider_message_id
+    ON messages(conversation_id, (metadata->>'provider_message_id'))
+    WHERE metadata->>'provider_message_id' IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_conversation_share_links_conversation ON conversation_share_links(conversation_id, created_at DESC);
 
 CREATE INDEX IF NOT EXISTS idx_attachments_message_id ON attachments(message_id);
 CREATE INDEX IF NOT EXISTS idx_attachments_type ON attachments(type);
+CREATE INDEX IF NOT EXISTS idx_attachments_message_type_created ON attachments(message_id, type, created_at DESC);
+-- Búsqueda de adjuntos por nombre de archivo (ILIKE '%...%')
+CREATE INDEX IF NOT EXISTS idx_attachments_filename_trgm ON attachments USING GIN (filename gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_conversation_participants_user_id ON conversation_participants(user_id);
+CREATE INDEX IF NOT EXISTS idx_conversation_read_markers_user_id ON conversation_read_markers(user_id);
+
+CREATE INDEX IF NOT EXISTS idx_message_reads_user_id ON message_reads(user_id);
+
+CREATE INDEX IF NOT EXISTS idx_conversation_webhooks_conversation_id ON conversation_webhooks(conversation_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_event_outbox_pending ON event_outbox(next_attempt_at) WHERE delivered_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_event_outbox_delivered_at ON event_outbox(delivered_at) WHERE delivered_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries(status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created_at ON webhook_deliveries(created_at);
+
+CREATE INDEX IF NOT EXISTS idx_reactions_message_id ON reactions(message_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_file_thumbnails_user_id ON file_thumbnails(user_id);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_id ON audit_logs(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs(action, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_scheduled_messages_due ON scheduled_messages(scheduled_at) WHERE status = 'scheduled';
+CREATE INDEX IF NOT EXISTS idx_scheduled_messages_sending ON scheduled_messages(updated_at) WHERE status = 'sending';
+CREATE INDEX IF NOT EXISTS idx_scheduled_messages_sender_id ON scheduled_messages(sender_id);
 
 -- Create function to update updated_at timestamp
 CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -62,6 +282,11 @@ CREATE TRIGGER update_conversations_updated_at
     FOR EACH ROW 
     EXECUTE FUNCTION update_updated_at_column();
 
+CREATE TRIGGER update_conversation_templates_updated_at
+    BEFORE UPDATE ON conversation_templates
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
 -- Insert sample data for testing (optional)
 INSERT INTO conversations (id, user_id, channel, status) VALUES
     ('550e8400-e29b-41d4-a716-446655440001', 'user123', 'web', 'active'),
@@ -73,4 +298,8 @@ INSERT INTO messages (id, conversation_id, sender_type, sender_id, content, cont
     ('660e8400-e29b-41d4-a716-446655440001', '550e8400-e29b-41d4-a716-446655440001', 'user', 'user123', 'Hello, I need help with my account', 'text', '{"priority": "normal"}'),
     ('660e8400-e29b-41d4-a716-446655440002', '550e8400-e29b-41d4-a716-446655440001', 'bot', 'bot001', 'Hi! I''d be happy to help you with your account. What specific issue are you experiencing?', 'text', '{"bot_version": "1.0", "confidence": 0.95}'),
     ('660e8400-e29b-41d4-a716-446655440003', '550e8400-e29b-41d4-a716-446655440002', 'user', 'user123', 'Can you help me reset my password?', 'text', '{}')
-ON CONFLICT (id) DO NOTHING;
\ No newline at end of file
+ON CONFLICT (id) DO NOTHING;
+
+-- Backfill the denormalized message counter for the sample data
+UPDATE conversations c
+SET message_count = (SELECT COUNT(*) FROM messages m WHERE m.conversation_id = c.id);